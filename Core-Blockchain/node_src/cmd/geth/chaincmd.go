@@ -29,6 +29,8 @@ import (
 	"github.com/ethereum/go-ethereum/cmd/utils"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/congress"
+	"github.com/ethereum/go-ethereum/consensus/congress/systemcontract"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
@@ -49,6 +51,8 @@ var (
 		ArgsUsage: "<genesisPath>",
 		Flags: []cli.Flag{
 			utils.DataDirFlag,
+			utils.StateSchemeFlag,
+			initDryRunFlag,
 		},
 		Category: "BLOCKCHAIN COMMANDS",
 		Description: `
@@ -56,7 +60,14 @@ The init command initializes a new genesis block and definition for the network.
 This is a destructive action and changes the network in which you will be
 participating.
 
-It expects the genesis file as argument.`,
+It expects the genesis file as argument. With --dryrun the genesis block is only
+built in memory and checked, nothing is written to the data directory. The scheme
+used to store the state is picked with --state.scheme, it can't be changed later
+without migrating the database.`,
+	}
+	initDryRunFlag = cli.BoolFlag{
+		Name:  "dryrun",
+		Usage: "Validate the genesis file without writing it to the database",
 	}
 	dumpGenesisCommand = cli.Command{
 		Action:    utils.MigrateFlags(dumpGenesis),
@@ -70,6 +81,25 @@ It expects the genesis file as argument.`,
 		Category: "BLOCKCHAIN COMMANDS",
 		Description: `
 The dumpgenesis command dumps the genesis block configuration in JSON format to stdout.`,
+	}
+	checkUpgradesCommand = cli.Command{
+		Action:    utils.MigrateFlags(checkUpgrades),
+		Name:      "check-upgrades",
+		Usage:     "Check the system contract upgrade manifests against the local chain",
+		ArgsUsage: "[<genesisPath>]",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.AncientFlag,
+			utils.TestnetFlag,
+		},
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+The check-upgrades command validates the system contract upgrade manifests of
+the chain config and, for every upgrade at or below the local head, checks that
+the code deployed at the target address matches the declared code hash.
+
+If a genesis file is given, its manifests are checked instead of the stored ones,
+including their compatibility with the already imported chain history.`,
 	}
 	importCommand = cli.Command{
 		Action:    utils.MigrateFlags(importChain),
@@ -198,6 +228,19 @@ func initGenesis(ctx *cli.Context) error {
 	if err := json.NewDecoder(file).Decode(genesis); err != nil {
 		utils.Fatalf("invalid genesis file: %v", err)
 	}
+	if ctx.Bool(initDryRunFlag.Name) {
+		if genesis.Config != nil && genesis.Config.Congress != nil {
+			if err := congress.VerifyGenesis(genesis); err != nil {
+				utils.Fatalf("Invalid congress genesis: %v", err)
+			}
+		}
+		_, hash, err := core.SetupGenesisBlock(rawdb.NewMemoryDatabase(), genesis)
+		if err != nil {
+			utils.Fatalf("Failed to build genesis block: %v", err)
+		}
+		log.Info("Genesis file is valid", "hash", hash)
+		return nil
+	}
 	// Open and initialise both full and light databases
 	stack, _ := makeConfigNode(ctx)
 	defer stack.Close()
@@ -207,6 +250,16 @@ func initGenesis(ctx *cli.Context) error {
 		if err != nil {
 			utils.Fatalf("Failed to open database: %v", err)
 		}
+		// Light clients always use the hash-based scheme
+		if name == "chaindata" {
+			scheme, err := rawdb.ParseStateScheme(ctx.String(utils.StateSchemeFlag.Name), chaindb)
+			if err != nil {
+				utils.Fatalf("Failed to pick state scheme: %v", err)
+			}
+			if rawdb.ReadStateScheme(chaindb) == "" {
+				rawdb.WriteStateScheme(chaindb, scheme)
+			}
+		}
 		_, hash, err := core.SetupGenesisBlock(chaindb, genesis)
 		if err != nil {
 			utils.Fatalf("Failed to write genesis block: %v", err)
@@ -217,6 +270,96 @@ func initGenesis(ctx *cli.Context) error {
 	return nil
 }
 
+// checkUpgrades verifies the system contract upgrade manifests of the stored chain
+// config, or of the given genesis file, against the local chain history.
+func checkUpgrades(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chaindb := utils.MakeChainDatabase(ctx, stack, true)
+	defer chaindb.Close()
+
+	head := rawdb.ReadHeadHeader(chaindb)
+	if head == nil {
+		return errors.New("no head header")
+	}
+	config := rawdb.ReadChainConfig(chaindb, rawdb.ReadCanonicalHash(chaindb, 0))
+	if config == nil {
+		return errors.New("no stored chain config")
+	}
+	if genesisPath := ctx.Args().First(); genesisPath != "" {
+		file, err := os.Open(genesisPath)
+		if err != nil {
+			utils.Fatalf("Failed to read genesis file: %v", err)
+		}
+		defer file.Close()
+
+		genesis := new(core.Genesis)
+		if err := json.NewDecoder(file).Decode(genesis); err != nil {
+			utils.Fatalf("invalid genesis file: %v", err)
+		}
+		if genesis.Config == nil {
+			return errors.New("genesis file has no chain config")
+		}
+		if compatErr := config.CheckCompatible(genesis.Config, head.Number.Uint64()); compatErr != nil {
+			return compatErr
+		}
+		config = genesis.Config
+	}
+	if err := config.CheckConfigForkOrder(); err != nil {
+		return err
+	}
+	var (
+		sdb    = state.NewDatabase(chaindb)
+		failed int
+	)
+	for i, u := range config.SystemContractUpgrades {
+		if _, err := systemcontract.UpgradeCode(u); err != nil {
+			log.Error("Invalid system contract upgrade", "name", u.Name, "err", err)
+			failed++
+			continue
+		}
+		if u.Block.Cmp(head.Number) > 0 {
+			log.Info("System contract upgrade pending", "name", u.Name, "block", u.Block, "address", u.Address)
+			continue
+		}
+		// A later upgrade of the same contract in the same block overrides the code.
+		superseded := false
+		for _, next := range config.SystemContractUpgrades[i+1:] {
+			if next.Block.Cmp(u.Block) == 0 && next.Address == u.Address {
+				superseded = true
+			}
+		}
+		if superseded {
+			log.Info("System contract upgrade superseded", "name", u.Name, "block", u.Block, "address", u.Address)
+			continue
+		}
+		number := u.Block.Uint64()
+		header := rawdb.ReadHeader(chaindb, rawdb.ReadCanonicalHash(chaindb, number), number)
+		if header == nil {
+			log.Error("Missing header of system contract upgrade", "name", u.Name, "block", number)
+			failed++
+			continue
+		}
+		statedb, err := state.New(header.Root, sdb, nil)
+		if err != nil {
+			log.Warn("State unavailable, skipping code check", "name", u.Name, "block", number, "err", err)
+			continue
+		}
+		if have := statedb.GetCodeHash(u.Address); have != u.CodeHash {
+			log.Error("System contract code mismatch", "name", u.Name, "block", number, "address", u.Address, "have", have, "want", u.CodeHash)
+			failed++
+			continue
+		}
+		log.Info("System contract upgrade verified", "name", u.Name, "block", number, "address", u.Address)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d system contract upgrade(s) failed verification", failed)
+	}
+	log.Info("Checked system contract upgrades", "count", len(config.SystemContractUpgrades), "head", head.Number)
+	return nil
+}
+
 func dumpGenesis(ctx *cli.Context) error {
 	// TODO(rjl493456442) support loading from the custom datadir
 	genesis := utils.MakeGenesis(ctx)
@@ -33,6 +33,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/console/prompt"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/log"
@@ -69,6 +70,11 @@ Remove blockchain and state databases`,
 			dbDumpFreezerIndex,
 			dbImportCmd,
 			dbExportCmd,
+			dbConvertCmd,
+			dbPruneHistoryCmd,
+			dbMigrateStateCmd,
+			dbPruneAddressIndexCmd,
+			dbRebuildAddressIndexCmd,
 		},
 	}
 	dbInspectCmd = cli.Command{
@@ -203,6 +209,88 @@ WARNING: This is a low-level operation which may cause database corruption!`,
 		},
 		Description: "Exports the specified chain data to an RLP encoded stream, optionally gzip-compressed.",
 	}
+	dbConvertCmd = cli.Command{
+		Action: utils.MigrateFlags(dbConvert),
+		Name:   "convert",
+		Usage:  "Convert the chain database to the engine selected with --db.engine",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.SyncModeFlag,
+			utils.MainnetFlag,
+			utils.TestnetFlag,
+			utils.CacheFlag,
+			utils.CacheDatabaseFlag,
+			utils.DBEngineFlag,
+		},
+		Description: `This command copies every key of the chain database into a new database
+backed by the engine selected with --db.engine, e.g. from leveldb to pebble. The
+node must not be running. The ancient store is not converted, as it is independent
+of the engine. The original database is kept next to the new one with a
+.<engine>.bak suffix and can be removed once the node runs fine on the new one.`,
+	}
+	dbPruneHistoryCmd = cli.Command{
+		Action: utils.MigrateFlags(pruneHistory),
+		Name:   "prune-history",
+		Usage:  "Prune the bodies and receipts of the blocks beyond the history horizon",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.AncientFlag,
+			utils.SyncModeFlag,
+			utils.MainnetFlag,
+			utils.TestnetFlag,
+			utils.HistoryLimitFlag,
+		},
+		Description: `This command drops the bodies and receipts of the ancient blocks older than
+the number of recent blocks given with --history.blocks, along with the transaction
+indices pointing into them. Headers are kept for the entire chain. The node must
+not be running.`,
+	}
+	dbMigrateStateCmd = cli.Command{
+		Action: utils.MigrateFlags(migrateState),
+		Name:   "migrate-state",
+		Usage:  "Migrate the head state from the hash-based to the path-based state scheme",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.SyncModeFlag,
+			utils.MainnetFlag,
+			utils.TestnetFlag,
+		},
+		Description: `This command rewrites the trie nodes of the head state keyed by their path
+and switches the database over to the path-based state scheme, deleting the
+hash-keyed trie nodes afterwards. Historical states are not kept, the node only
+serves the state of the recent blocks from then on. The node must not be running.`,
+	}
+	dbPruneAddressIndexCmd = cli.Command{
+		Action:    utils.MigrateFlags(pruneAddressIndex),
+		Name:      "prune-address-index",
+		Usage:     "Prune the address index below a block",
+		ArgsUsage: "<blocknum>",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.SyncModeFlag,
+			utils.MainnetFlag,
+			utils.TestnetFlag,
+		},
+		Description: `This command deletes the address index entries of the blocks below the
+given one, eth_getTransactionsByAddress only serving the blocks from there on. The
+internal transfers recorded for the blocks are kept for rebuilding the index. The
+node must not be running.`,
+	}
+	dbRebuildAddressIndexCmd = cli.Command{
+		Action: utils.MigrateFlags(rebuildAddressIndex),
+		Name:   "rebuild-address-index",
+		Usage:  "Drop the address index so that it's rebuilt from the genesis",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.SyncModeFlag,
+			utils.MainnetFlag,
+			utils.TestnetFlag,
+		},
+		Description: `This command deletes the address index and its progress, the node rebuilding
+it from the genesis when started with --address.index. Internal transfers are only
+indexed for the blocks processed with the address index enabled. The node must not
+be running.`,
+	}
 )
 
 func removeDB(ctx *cli.Context) error {
@@ -460,6 +548,10 @@ func dbDumpTrie(ctx *cli.Context) error {
 			return err
 		}
 	}
+	// Storage tries are keyed by their owning account in the path-based scheme
+	if rawdb.ReadStateScheme(db) == rawdb.PathScheme {
+		return errors.New("storage trie dumps are not supported by the path-based state scheme")
+	}
 	theTrie, err := trie.New(stRoot, trie.NewDatabase(db))
 	if err != nil {
 		return err
@@ -657,3 +749,223 @@ func exportChaindata(ctx *cli.Context) error {
 	defer db.Close()
 	return utils.ExportChaindata(ctx.Args().Get(1), kind, exporter(db), stop)
 }
+
+// dbConvert migrates the chain key-value store offline to the engine selected
+// with --db.engine, keeping the original database as a backup.
+func dbConvert(ctx *cli.Context) error {
+	var (
+		stack, _  = makeConfigNode(ctx)
+		interrupt = make(chan os.Signal, 1)
+		stop      = make(chan struct{})
+	)
+	defer stack.Close()
+
+	target := stack.Config().DBEngine
+	if target == "" {
+		return errors.New("target engine required, set --db.engine")
+	}
+	name := "chaindata"
+	if ctx.GlobalString(utils.SyncModeFlag.Name) == "light" {
+		name = "lightchaindata"
+	}
+	var (
+		path    = stack.ResolvePath(name)
+		temp    = path + "." + target
+		source  = rawdb.PreexistingDatabase(path)
+		cache   = ctx.GlobalInt(utils.CacheFlag.Name) * ctx.GlobalInt(utils.CacheDatabaseFlag.Name) / 100
+		handles = utils.MakeDatabaseHandles()
+	)
+	switch {
+	case source == "":
+		return fmt.Errorf("no database found at %s", path)
+	case source == target:
+		return fmt.Errorf("database at %s already uses %s", path, target)
+	case common.FileExist(temp):
+		return fmt.Errorf("conversion target %s already exists, remove it first", temp)
+	}
+	signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+	defer close(interrupt)
+	go func() {
+		if _, ok := <-interrupt; ok {
+			log.Info("Interrupted during db convert, stopping at next batch")
+		}
+		close(stop)
+	}()
+	// Copy the key-value store over into a new database. The ancient store, if
+	// any, is not touched.
+	src, err := rawdb.Open(rawdb.OpenOptions{Type: source, Directory: path, Cache: cache / 2, Handles: handles / 2, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	dst, err := rawdb.Open(rawdb.OpenOptions{Type: target, Directory: temp, Cache: cache / 2, Handles: handles / 2})
+	if err != nil {
+		src.Close()
+		return err
+	}
+	log.Info("Converting database", "path", path, "from", source, "to", target)
+	err = copyKeyValues(src, dst, stop)
+	if err == nil {
+		rawdb.WriteDatabaseEngine(dst, target)
+	}
+	src.Close()
+	dst.Close()
+	if err != nil {
+		os.RemoveAll(temp)
+		return err
+	}
+	// Swap the databases, moving the default ancient store along
+	backup := path + "." + source + ".bak"
+	if ancient := filepath.Join(path, "ancient"); common.FileExist(ancient) {
+		if err := os.Rename(ancient, filepath.Join(temp, "ancient")); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(path, backup); err != nil {
+		return err
+	}
+	if err := os.Rename(temp, path); err != nil {
+		return err
+	}
+	log.Info("Database converted", "path", path, "engine", target, "backup", backup)
+	return nil
+}
+
+// copyKeyValues copies every entry of the source key-value store into the
+// destination one, aborting if stop is closed.
+func copyKeyValues(src ethdb.KeyValueStore, dst ethdb.KeyValueStore, stop chan struct{}) error {
+	var (
+		it     = src.NewIterator(nil, nil)
+		batch  = dst.NewBatch()
+		count  uint64
+		start  = time.Now()
+		logged = time.Now()
+	)
+	defer it.Release()
+
+	for it.Next() {
+		if err := batch.Put(it.Key(), it.Value()); err != nil {
+			return err
+		}
+		count++
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+
+			select {
+			case <-stop:
+				return errors.New("interrupted")
+			default:
+			}
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Converting database", "entries", count, "key", fmt.Sprintf("%x", it.Key()), "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	log.Info("Copied database entries", "entries", count, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// pruneHistory drops the bodies and receipts of the ancient blocks beyond the
+// history horizon.
+func pruneHistory(ctx *cli.Context) error {
+	limit := ctx.GlobalUint64(utils.HistoryLimitFlag.Name)
+	if limit == 0 {
+		return fmt.Errorf("history horizon required, set --%s", utils.HistoryLimitFlag.Name)
+	}
+	var (
+		stack, _  = makeConfigNode(ctx)
+		interrupt = make(chan os.Signal, 1)
+		stop      = make(chan struct{})
+	)
+	defer stack.Close()
+	signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+	defer close(interrupt)
+	go func() {
+		if _, ok := <-interrupt; ok {
+			log.Info("Interrupted during history pruning, stopping at next batch")
+		}
+		close(stop)
+	}()
+	db := utils.MakeChainDatabase(ctx, stack, false)
+	defer db.Close()
+
+	number := rawdb.ReadHeaderNumber(db, rawdb.ReadHeadBlockHash(db))
+	if number == nil {
+		return errors.New("head block missing")
+	}
+	if *number < limit {
+		log.Info("Block history within the horizon, nothing to prune", "head", *number, "horizon", limit)
+		return nil
+	}
+	// Only the blocks moved into the ancient store can be pruned
+	tail := *number - limit + 1
+	frozen, err := db.Ancients()
+	if err != nil {
+		return err
+	}
+	if tail > frozen {
+		log.Warn("Limiting history pruning to the ancient blocks", "tail", tail, "ancients", frozen)
+		tail = frozen
+	}
+	return rawdb.PruneHistory(db, tail, stop)
+}
+
+// migrateState rewrites the head state keyed by path and switches the database
+// over to the path-based state scheme.
+func migrateState(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	db := utils.MakeChainDatabase(ctx, stack, false)
+	defer db.Close()
+
+	if scheme := rawdb.ReadStateScheme(db); scheme == rawdb.PathScheme {
+		return errors.New("database already uses the path-based state scheme")
+	}
+	head := rawdb.ReadHeadBlock(db)
+	if head == nil {
+		return errors.New("head block missing")
+	}
+	return utils.MigrateStateScheme(db, head.Root())
+}
+
+// pruneAddressIndex deletes the address index entries below the given block.
+func pruneAddressIndex(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return fmt.Errorf("required arguments: %v", ctx.Command.ArgsUsage)
+	}
+	number, err := strconv.ParseUint(ctx.Args().Get(0), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid block number: %v", err)
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	db := utils.MakeChainDatabase(ctx, stack, false)
+	defer db.Close()
+
+	return core.PruneAddressIndex(db, number)
+}
+
+// rebuildAddressIndex drops the address index so that it's rebuilt on the next
+// start.
+func rebuildAddressIndex(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	db := utils.MakeChainDatabase(ctx, stack, false)
+	defer db.Close()
+
+	return core.ResetAddressIndex(db)
+}
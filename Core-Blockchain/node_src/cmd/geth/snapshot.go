@@ -20,7 +20,9 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/ethereum/go-ethereum/cmd/utils"
@@ -31,6 +33,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/state/snapshot"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/trie"
@@ -160,10 +163,55 @@ as the backend data source, making this command a lot faster.
 
 The argument is interpreted as block number or hash. If none is provided, the latest
 block is used.
+`,
+			},
+			{
+				Name:      "export",
+				Usage:     "Export the state of a block and the recent chain into a snapshot archive",
+				ArgsUsage: "<directory> [? <blockHash> | <blockNum>]",
+				Action:    utils.MigrateFlags(exportSnapshot),
+				Category:  "MISCELLANEOUS COMMANDS",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.AncientFlag,
+					utils.TestnetFlag,
+				},
+				Description: `
+geth snapshot export <directory> [<block>]
+will write the state of the given block, read from the snapshot, into a chunked
+archive in the given directory, along with the recent blocks and the Congress
+validator snapshot needed to continue the chain from it. Every state chunk carries
+a proof against the state root. If no block is provided, the latest one is used,
+otherwise it must be one of the recent blocks covered by the snapshot.
+`,
+			},
+			{
+				Name:      "import",
+				Usage:     "Bootstrap a freshly initialized node from a snapshot archive",
+				ArgsUsage: "<directory>",
+				Action:    utils.MigrateFlags(importSnapshot),
+				Category:  "MISCELLANEOUS COMMANDS",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.AncientFlag,
+					utils.TestnetFlag,
+					snapshotTrustedFlag,
+				},
+				Description: `
+geth snapshot import --trusted <blockHash> <directory>
+will verify the snapshot archive in the given directory and import it into the
+database, which must have been initialized with 'geth init' and the genesis of the
+same network. The archived block must be the one with the trusted hash, obtained
+from a source other than the archive. The node starts from the archived block,
+without the history before the recent blocks of the archive.
 `,
 			},
 		},
 	}
+	snapshotTrustedFlag = cli.StringFlag{
+		Name:  "trusted",
+		Usage: "Hash of the block a snapshot archive must have been taken at",
+	}
 )
 
 func pruneState(ctx *cli.Context) error {
@@ -205,7 +253,7 @@ func verifyState(ctx *cli.Context) error {
 		log.Error("Failed to load head block")
 		return errors.New("no head block")
 	}
-	snaptree, err := snapshot.New(chaindb, trie.NewDatabase(chaindb), 256, headBlock.Root(), false, false, false)
+	snaptree, err := snapshot.New(chaindb, openTrieDatabase(chaindb), 256, headBlock.Root(), false, false, false)
 	if err != nil {
 		log.Error("Failed to open snapshot tree", "err", err)
 		return err
@@ -262,7 +310,7 @@ func traverseState(ctx *cli.Context) error {
 		root = headBlock.Root()
 		log.Info("Start traversing the state", "root", root, "number", headBlock.NumberU64())
 	}
-	triedb := trie.NewDatabase(chaindb)
+	triedb := openTrieDatabase(chaindb)
 	t, err := trie.NewSecure(root, triedb)
 	if err != nil {
 		log.Error("Failed to open trie", "root", root, "err", err)
@@ -284,7 +332,7 @@ func traverseState(ctx *cli.Context) error {
 			return err
 		}
 		if acc.Root != emptyRoot {
-			storageTrie, err := trie.NewSecure(acc.Root, triedb)
+			storageTrie, err := trie.NewSecureWithOwner(common.BytesToHash(accIter.Key), acc.Root, triedb)
 			if err != nil {
 				log.Error("Failed to open storage trie", "root", acc.Root, "err", err)
 				return err
@@ -352,7 +400,7 @@ func traverseRawState(ctx *cli.Context) error {
 		root = headBlock.Root()
 		log.Info("Start traversing the state", "root", root, "number", headBlock.NumberU64())
 	}
-	triedb := trie.NewDatabase(chaindb)
+	triedb := openTrieDatabase(chaindb)
 	t, err := trie.NewSecure(root, triedb)
 	if err != nil {
 		log.Error("Failed to open trie", "root", root, "err", err)
@@ -374,7 +422,7 @@ func traverseRawState(ctx *cli.Context) error {
 		if node != (common.Hash{}) {
 			// Check the present for non-empty hash node(embedded node doesn't
 			// have their own hash).
-			blob := rawdb.ReadTrieNode(chaindb, node)
+			blob := readTrieNode(chaindb, triedb.Scheme(), common.Hash{}, accIter.Path(), node)
 			if len(blob) == 0 {
 				log.Error("Missing trie node(account)", "hash", node)
 				return errors.New("missing account")
@@ -390,7 +438,8 @@ func traverseRawState(ctx *cli.Context) error {
 				return errors.New("invalid account")
 			}
 			if acc.Root != emptyRoot {
-				storageTrie, err := trie.NewSecure(acc.Root, triedb)
+				owner := common.BytesToHash(accIter.LeafKey())
+				storageTrie, err := trie.NewSecureWithOwner(owner, acc.Root, triedb)
 				if err != nil {
 					log.Error("Failed to open storage trie", "root", acc.Root, "err", err)
 					return errors.New("missing storage trie")
@@ -403,7 +452,7 @@ func traverseRawState(ctx *cli.Context) error {
 					// Check the present for non-empty hash node(embedded node doesn't
 					// have their own hash).
 					if node != (common.Hash{}) {
-						blob := rawdb.ReadTrieNode(chaindb, node)
+						blob := readTrieNode(chaindb, triedb.Scheme(), owner, storageIter.Path(), node)
 						if len(blob) == 0 {
 							log.Error("Missing trie node(storage)", "hash", node)
 							return errors.New("missing storage")
@@ -457,7 +506,7 @@ func dumpState(ctx *cli.Context) error {
 	if err != nil {
 		return err
 	}
-	snaptree, err := snapshot.New(db, trie.NewDatabase(db), 256, root, false, false, false)
+	snaptree, err := snapshot.New(db, openTrieDatabase(db), 256, root, false, false, false)
 	if err != nil {
 		return err
 	}
@@ -518,3 +567,88 @@ func dumpState(ctx *cli.Context) error {
 		"elapsed", common.PrettyDuration(time.Since(start)))
 	return nil
 }
+
+func exportSnapshot(ctx *cli.Context) error {
+	if ctx.NArg() < 1 || ctx.NArg() > 2 {
+		return errors.New("expected a directory and an optional block (number or hash)")
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chaindb := utils.MakeChainDatabase(ctx, stack, true)
+	defer chaindb.Close()
+
+	headBlock := rawdb.ReadHeadBlock(chaindb)
+	if headBlock == nil {
+		return errors.New("no head block")
+	}
+	header := headBlock.Header()
+	if ctx.NArg() == 2 {
+		arg := ctx.Args().Get(1)
+		if hashish(arg) {
+			hash := common.HexToHash(arg)
+			if number := rawdb.ReadHeaderNumber(chaindb, hash); number != nil {
+				header = rawdb.ReadHeader(chaindb, hash, *number)
+			} else {
+				header = nil
+			}
+		} else {
+			number, err := strconv.ParseUint(arg, 10, 64)
+			if err != nil {
+				return err
+			}
+			header = rawdb.ReadHeader(chaindb, rawdb.ReadCanonicalHash(chaindb, number), number)
+		}
+		if header == nil {
+			return fmt.Errorf("block %s not found", arg)
+		}
+	}
+	triedb := openTrieDatabase(chaindb)
+	snaptree, err := snapshot.New(chaindb, triedb, 256, headBlock.Root(), false, false, false)
+	if err != nil {
+		log.Error("Failed to open snapshot tree", "err", err)
+		return err
+	}
+	return utils.ExportSnapshot(chaindb, snaptree, triedb, header, ctx.Args().First())
+}
+
+func importSnapshot(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return errors.New("expected the directory of the snapshot archive")
+	}
+	trusted := common.FromHex(ctx.String(snapshotTrustedFlag.Name))
+	if len(trusted) != common.HashLength {
+		return fmt.Errorf("expected the trusted hash of the archived block in --%s", snapshotTrustedFlag.Name)
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chaindb := utils.MakeChainDatabase(ctx, stack, false)
+	defer chaindb.Close()
+
+	return utils.ImportSnapshot(chaindb, ctx.Args().First(), common.BytesToHash(trusted))
+}
+
+// openTrieDatabase opens the trie database with the state scheme in use by the
+// given database.
+func openTrieDatabase(db ethdb.Database) *trie.Database {
+	return trie.NewDatabaseWithConfig(db, &trie.Config{Scheme: rawdb.ReadStateScheme(db)})
+}
+
+// readTrieNode retrieves the persisted trie node with the given hash, stored at
+// the given path of the trie of the given owner with the path-based scheme.
+func readTrieNode(db ethdb.KeyValueReader, scheme string, owner common.Hash, path []byte, hash common.Hash) []byte {
+	if scheme != rawdb.PathScheme {
+		return rawdb.ReadTrieNode(db, hash)
+	}
+	var blob []byte
+	if owner == (common.Hash{}) {
+		blob = rawdb.ReadAccountTrieNode(db, path)
+	} else {
+		blob = rawdb.ReadStorageTrieNode(db, owner, path)
+	}
+	if crypto.Keccak256Hash(blob) != hash {
+		return nil
+	}
+	return blob
+}
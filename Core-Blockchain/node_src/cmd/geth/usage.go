@@ -36,6 +36,7 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 			configFileFlag,
 			utils.DataDirFlag,
 			utils.AncientFlag,
+			utils.DBEngineFlag,
 			utils.MinFreeDiskSpaceFlag,
 			utils.KeyStoreDirFlag,
 			utils.USBFlag,
@@ -47,6 +48,12 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 			utils.ExitWhenSyncedFlag,
 			utils.GCModeFlag,
 			utils.TxLookupLimitFlag,
+			utils.HistoryLimitFlag,
+			utils.TraceIndexFlag,
+			utils.AddressIndexFlag,
+			utils.TokenIndexFlag,
+			utils.StateSchemeFlag,
+			utils.StateHistoryFlag,
 			utils.EthStatsURLFlag,
 			utils.IdentityFlag,
 			utils.LightKDFFlag,
@@ -102,6 +109,9 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 			utils.TxPoolAccountQueueFlag,
 			utils.TxPoolGlobalQueueFlag,
 			utils.TxPoolLifetimeFlag,
+			utils.TxPoolPersistFlag,
+			utils.TxPoolPersistIntervalFlag,
+			utils.TxPoolPersistLimitFlag,
 		},
 	},
 	{
@@ -186,6 +196,10 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 			utils.MinerExtraDataFlag,
 			utils.MinerRecommitIntervalFlag,
 			utils.MinerNoVerifyFlag,
+			utils.MinerPolicyFlag,
+			utils.MinerPriceBandFlag,
+			utils.MinerSenderCapFlag,
+			utils.MinerX402ReserveFlag,
 		},
 	},
 	{
@@ -20,12 +20,19 @@ import (
 	"bytes"
 	"encoding/json"
 	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/davecgh/go-spew/spew"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/congress"
+	"github.com/ethereum/go-ethereum/consensus/congress/systemcontract"
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/params"
 )
 
 // Tests the go-ethereum to Aleth chainspec conversion for the Stureby testnet.
@@ -93,3 +100,49 @@ func TestParitySturebyConverter(t *testing.T) {
 		t.Fatalf("chainspec mismatch")
 	}
 }
+
+// Tests that a congress genesis assembled from compiled system contract artifacts
+// passes the init dry run.
+func TestCongressGenesis(t *testing.T) {
+	folder := t.TempDir()
+	for _, name := range []string{"Validators", "Punish", "Proposal"} {
+		blob := `{"data": {"deployedBytecode": {"object": "6080604052"}}}`
+		if err := ioutil.WriteFile(filepath.Join(folder, name+".json"), []byte(blob), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(folder, "WalletBlocklist.sol"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	blob := `{"deployedBytecode": "0x6080604052"}`
+	if err := ioutil.WriteFile(filepath.Join(folder, "WalletBlocklist.sol", "WalletBlocklist.json"), []byte(blob), 0644); err != nil {
+		t.Fatal(err)
+	}
+	validator := common.HexToAddress("0x01")
+	genesis := &core.Genesis{
+		Difficulty: big.NewInt(1),
+		GasLimit:   4700000,
+		ExtraData:  congress.GenesisExtraData([]common.Address{validator}),
+		Alloc:      make(core.GenesisAlloc),
+		Config: &params.ChainConfig{
+			ChainID:  big.NewInt(1),
+			Congress: &params.CongressConfig{Period: 3, Epoch: 200},
+		},
+	}
+	if err := allocCongressContracts(genesis, folder, true); err != nil {
+		t.Fatalf("failed to allocate system contracts: %v", err)
+	}
+	if _, ok := genesis.Alloc[systemcontract.SlashingContractAddr]; ok {
+		t.Errorf("missing optional contract allocated")
+	}
+	if code := genesis.Alloc[core.WalletBlocklistContractAddress].Code; !bytes.Equal(code, common.FromHex("0x6080604052")) {
+		t.Errorf("blocklist code mismatch: have %x", code)
+	}
+	if err := verifyGenesis(genesis); err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	delete(genesis.Alloc, systemcontract.ProposalAddr)
+	if err := verifyGenesis(genesis); err == nil {
+		t.Fatalf("dry run passed without the proposal contract")
+	}
+}
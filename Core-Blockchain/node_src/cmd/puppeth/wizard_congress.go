@@ -0,0 +1,193 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/congress"
+	"github.com/ethereum/go-ethereum/consensus/congress/systemcontract"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// congressContract is a system contract to allocate in a congress genesis.
+type congressContract struct {
+	name     string
+	addr     common.Address
+	required bool
+}
+
+// congressContracts returns the system contracts to allocate in a congress genesis,
+// with the wallet blocklist appended if requested.
+func congressContracts(blocklist bool) []congressContract {
+	var contracts []congressContract
+	for _, contract := range congress.GenesisSystemContracts {
+		contracts = append(contracts, congressContract{contract.Name, contract.Address, true})
+	}
+	contracts = append(contracts, congressContract{"Slashing", systemcontract.SlashingContractAddr, false})
+	if blocklist {
+		contracts = append(contracts, congressContract{"WalletBlocklist", core.WalletBlocklistContractAddress, true})
+	}
+	return contracts
+}
+
+// loadContractArtifact reads the runtime bytecode of a compiled system contract.
+// Both the Remix layout (<name>.json with data.deployedBytecode.object) and the
+// Hardhat layout (<name>.sol/<name>.json with deployedBytecode) are supported.
+func loadContractArtifact(folder, name string) ([]byte, error) {
+	paths := []string{
+		filepath.Join(folder, name+".json"),
+		filepath.Join(folder, name+".sol", name+".json"),
+	}
+	for _, path := range paths {
+		blob, err := ioutil.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var artifact struct {
+			DeployedBytecode json.RawMessage `json:"deployedBytecode"`
+			Data             struct {
+				DeployedBytecode struct {
+					Object string `json:"object"`
+				} `json:"deployedBytecode"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(blob, &artifact); err != nil {
+			return nil, fmt.Errorf("invalid artifact %s: %v", path, err)
+		}
+		code := artifact.Data.DeployedBytecode.Object
+		if code == "" && len(artifact.DeployedBytecode) > 0 {
+			if err := json.Unmarshal(artifact.DeployedBytecode, &code); err != nil {
+				return nil, fmt.Errorf("invalid artifact %s: %v", path, err)
+			}
+		}
+		if bytecode := common.FromHex(code); len(bytecode) > 0 {
+			return bytecode, nil
+		}
+		return nil, fmt.Errorf("artifact %s has no deployed bytecode", path)
+	}
+	return nil, os.ErrNotExist
+}
+
+// allocCongressContracts allocates the compiled system contracts found in the
+// artifacts folder into the genesis.
+func allocCongressContracts(genesis *core.Genesis, folder string, blocklist bool) error {
+	for _, contract := range congressContracts(blocklist) {
+		code, err := loadContractArtifact(folder, contract.name)
+		if errors.Is(err, os.ErrNotExist) && !contract.required {
+			log.Warn("Skipping optional system contract", "name", contract.name)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to load %s system contract: %v", contract.name, err)
+		}
+		genesis.Alloc[contract.addr] = core.GenesisAccount{Balance: new(big.Int), Code: code}
+	}
+	return nil
+}
+
+// verifyGenesis does a dry run of geth init on the genesis, building the genesis
+// block in memory and checking the consensus specific requirements.
+func verifyGenesis(genesis *core.Genesis) error {
+	if genesis.Config.Congress != nil {
+		if err := congress.VerifyGenesis(genesis); err != nil {
+			return err
+		}
+	}
+	_, _, err := core.SetupGenesisBlock(rawdb.NewMemoryDatabase(), genesis)
+	return err
+}
+
+// makeCongressGenesis configures the consensus parameters, the genesis validators
+// and the system contracts of a congress genesis.
+func (w *wizard) makeCongressGenesis(genesis *core.Genesis) {
+	genesis.Difficulty = big.NewInt(1)
+	genesis.Config.Congress = &params.CongressConfig{
+		Period: 3,
+		Epoch:  200,
+	}
+	fmt.Println()
+	fmt.Println("How many seconds should blocks take? (default = 3)")
+	genesis.Config.Congress.Period = uint64(w.readDefaultInt(3))
+
+	fmt.Println()
+	fmt.Println("How many blocks should an epoch last? (default = 200)")
+	genesis.Config.Congress.Epoch = uint64(w.readDefaultInt(200))
+
+	// We also need the initial list of validators
+	fmt.Println()
+	fmt.Println("Which accounts are the genesis validators? (mandatory at least one)")
+
+	var validators []common.Address
+	for {
+		if address := w.readAddress(); address != nil {
+			validators = append(validators, *address)
+			continue
+		}
+		if len(validators) > 0 {
+			break
+		}
+	}
+	// Sort the validators and embed into the extra-data section
+	for i := 0; i < len(validators); i++ {
+		for j := i + 1; j < len(validators); j++ {
+			if bytes.Compare(validators[i][:], validators[j][:]) > 0 {
+				validators[i], validators[j] = validators[j], validators[i]
+			}
+		}
+	}
+	genesis.ExtraData = congress.GenesisExtraData(validators)
+	genesis.Coinbase = validators[0]
+
+	w.readCongressForks(genesis.Config)
+
+	fmt.Println()
+	fmt.Println("Should the wallet blocklist contract be deployed at genesis? (default = yes)")
+	blocklist := w.readDefaultYesNo(true)
+
+	for {
+		fmt.Println()
+		fmt.Println("Where are the compiled system contract artifacts? (default = System-Contracts/contracts/artifacts)")
+		folder := w.readDefaultString("System-Contracts/contracts/artifacts")
+		if err := allocCongressContracts(genesis, folder, blocklist); err != nil {
+			log.Error("Failed to allocate system contracts", "err", err)
+			continue
+		}
+		break
+	}
+}
+
+// readCongressForks queries the user for the congress specific fork blocks.
+func (w *wizard) readCongressForks(config *params.ChainConfig) {
+	fmt.Println()
+	fmt.Printf("Which block should RedCoast come into effect? (default = %v)\n", config.RedCoastBlock)
+	config.RedCoastBlock = w.readDefaultBigInt(config.RedCoastBlock)
+
+	fmt.Println()
+	fmt.Printf("Which block should Sophon come into effect? (default = %v)\n", config.SophonBlock)
+	config.SophonBlock = w.readDefaultBigInt(config.SophonBlock)
+
+	fmt.Println()
+	fmt.Printf("Which block should governance of the block period and epoch length come into effect? (default = %v)\n", config.GovParamsBlock)
+	config.GovParamsBlock = w.readDefaultBigInt(config.GovParamsBlock)
+}
@@ -59,6 +59,7 @@ func (w *wizard) makeGenesis() {
 	fmt.Println("Which consensus engine to use? (default = clique)")
 	fmt.Println(" 1. Ethash - proof-of-work")
 	fmt.Println(" 2. Clique - proof-of-authority")
+	fmt.Println(" 3. Congress - proof-of-staked-authority")
 
 	choice := w.read()
 	switch {
@@ -105,6 +106,10 @@ func (w *wizard) makeGenesis() {
 			copy(genesis.ExtraData[32+i*common.AddressLength:], signer[:])
 		}
 
+	case choice == "3":
+		// In the case of congress, configure the validators and system contracts
+		w.makeCongressGenesis(genesis)
+
 	default:
 		log.Crit("Invalid consensus engine choice", "choice", choice)
 	}
@@ -134,6 +139,11 @@ func (w *wizard) makeGenesis() {
 	fmt.Println("Specify your chain/network ID if you want an explicit one (default = random)")
 	genesis.Config.ChainID = new(big.Int).SetUint64(uint64(w.readDefaultInt(rand.Intn(65536))))
 
+	// Make sure geth would accept the genesis before storing it
+	if err := verifyGenesis(genesis); err != nil {
+		log.Error("Generated genesis failed the init dry run", "err", err)
+		return
+	}
 	// All done, store the genesis and flush to disk
 	log.Info("Configured new genesis block")
 
@@ -243,6 +253,13 @@ func (w *wizard) manageGenesis() {
 		fmt.Printf("Which block should London come into effect? (default = %v)\n", w.conf.Genesis.Config.LondonBlock)
 		w.conf.Genesis.Config.LondonBlock = w.readDefaultBigInt(w.conf.Genesis.Config.LondonBlock)
 
+		if w.conf.Genesis.Config.Congress != nil {
+			w.readCongressForks(w.conf.Genesis.Config)
+		}
+		if err := verifyGenesis(w.conf.Genesis); err != nil {
+			log.Error("Updated genesis failed the init dry run", "err", err)
+		}
+
 		out, _ := json.MarshalIndent(w.conf.Genesis.Config, "", "  ")
 		fmt.Printf("Chain configuration updated:\n\n%s\n", out)
 
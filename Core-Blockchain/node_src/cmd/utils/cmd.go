@@ -41,6 +41,7 @@ import (
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
 	"gopkg.in/urfave/cli.v1"
 )
 
@@ -48,6 +49,9 @@ const (
 	importBatchSize = 2500
 )
 
+// emptyCodeHash is the known hash of the empty EVM bytecode.
+var emptyCodeHash = crypto.Keccak256Hash(nil)
+
 // Fatalf formats a message to standard error and exits the program.
 // The message is also printed to standard output if standard error
 // is redirected to a different file.
@@ -349,6 +353,110 @@ func ExportPreimages(db ethdb.Database, fn string) error {
 	return nil
 }
 
+// MigrateStateScheme rewrites the state with the given root, stored with the
+// hash-based scheme, keyed by path and switches the database over to the
+// path-based state scheme. The hash-keyed trie nodes are deleted afterwards.
+func MigrateStateScheme(db ethdb.Database, root common.Hash) error {
+	var (
+		start  = time.Now()
+		batch  = db.NewBatch()
+		triedb = trie.NewDatabaseWithConfig(db, &trie.Config{Scheme: rawdb.HashScheme})
+		nodes  int
+	)
+	// migrateTrie writes every node of the given trie keyed by its path
+	migrateTrie := func(owner common.Hash, root common.Hash, onLeaf func(it trie.NodeIterator) error) error {
+		t, err := trie.New(root, triedb)
+		if err != nil {
+			return err
+		}
+		it := t.NodeIterator(nil)
+		for it.Next(true) {
+			if hash := it.Hash(); hash != (common.Hash{}) {
+				blob := rawdb.ReadTrieNode(db, hash)
+				if len(blob) == 0 {
+					return fmt.Errorf("missing trie node %x", hash)
+				}
+				if owner == (common.Hash{}) {
+					rawdb.WriteAccountTrieNode(batch, it.Path(), blob)
+				} else {
+					rawdb.WriteStorageTrieNode(batch, owner, it.Path(), blob)
+				}
+				nodes++
+			}
+			if it.Leaf() && onLeaf != nil {
+				if err := onLeaf(it); err != nil {
+					return err
+				}
+			}
+			if batch.ValueSize() > ethdb.IdealBatchSize {
+				if err := batch.Write(); err != nil {
+					return err
+				}
+				batch.Reset()
+				log.Info("Migrating state", "nodes", nodes, "elapsed", common.PrettyDuration(time.Since(start)))
+			}
+		}
+		return it.Error()
+	}
+	err := migrateTrie(common.Hash{}, root, func(it trie.NodeIterator) error {
+		var acc types.StateAccount
+		if err := rlp.DecodeBytes(it.LeafBlob(), &acc); err != nil {
+			return err
+		}
+		// Contract code may still be stored under the legacy unprefixed key
+		if hash := common.BytesToHash(acc.CodeHash); hash != emptyCodeHash {
+			if code := rawdb.ReadCode(db, hash); len(code) > 0 {
+				rawdb.WriteCode(batch, hash, code)
+			}
+		}
+		if acc.Root == types.EmptyRootHash {
+			return nil
+		}
+		return migrateTrie(common.BytesToHash(it.LeafKey()), acc.Root, nil)
+	})
+	if err != nil {
+		return err
+	}
+	rawdb.WritePersistentStateID(batch, 0)
+	rawdb.WriteStateScheme(batch, rawdb.PathScheme)
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	batch.Reset()
+	log.Info("Migrated state", "root", root, "nodes", nodes, "elapsed", common.PrettyDuration(time.Since(start)))
+
+	// Delete the hash-keyed trie nodes, which are no longer referenced
+	var (
+		deleted int
+		it      = db.NewIterator(nil, nil)
+	)
+	defer it.Release()
+	for it.Next() {
+		key := it.Key()
+		if len(key) != common.HashLength || crypto.Keccak256Hash(it.Value()) != common.BytesToHash(key) {
+			continue
+		}
+		if err := batch.Delete(key); err != nil {
+			return err
+		}
+		deleted++
+		if batch.ValueSize() > ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	log.Info("Deleted hash-based trie nodes", "nodes", deleted, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
 // exportHeader is used in the export/import flow. When we do an export,
 // the first element we output is the exportHeader.
 // Whenever a backwards-incompatible change is made, the Version header
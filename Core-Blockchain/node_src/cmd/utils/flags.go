@@ -117,6 +117,10 @@ var (
 		Name:  "datadir.ancient",
 		Usage: "Data directory for ancient chain segments (default = inside chaindata)",
 	}
+	DBEngineFlag = cli.StringFlag{
+		Name:  "db.engine",
+		Usage: "Backing database implementation to use ('leveldb' or 'pebble', default = the existing one or leveldb)",
+	}
 	MinFreeDiskSpaceFlag = DirectoryFlag{
 		Name:  "datadir.minfreedisk",
 		Usage: "Minimum free disk space in MB, once reached triggers auto shut down (default = --cache.gc converted to MB, 0 = disabled)",
@@ -223,6 +227,32 @@ var (
 		Usage: "Number of recent blocks to maintain transactions index for (default = about one year, 0 = entire chain)",
 		Value: ethconfig.Defaults.TxLookupLimit,
 	}
+	HistoryLimitFlag = cli.Uint64Flag{
+		Name:  "history.blocks",
+		Usage: "Number of recent blocks to maintain bodies and receipts for (default = 0, entire chain)",
+		Value: ethconfig.Defaults.HistoryLimit,
+	}
+	TraceIndexFlag = cli.BoolFlag{
+		Name:  "trace.index",
+		Usage: "Index the accounts in the call traces of new blocks to speed up trace_filter",
+	}
+	AddressIndexFlag = cli.BoolFlag{
+		Name:  "address.index",
+		Usage: "Index the transactions and internal transfers touching each address for eth_getTransactionsByAddress",
+	}
+	TokenIndexFlag = cli.BoolFlag{
+		Name:  "token.index",
+		Usage: "Index the ERC-20/721/1155 transfers and holdings of each address for eth_getTokenHoldings and eth_getTokenTransfers",
+	}
+	StateSchemeFlag = cli.StringFlag{
+		Name:  "state.scheme",
+		Usage: "Scheme to use for storing the state trie nodes (\"hash\" or \"path\"), defaults to the one of an existing database",
+	}
+	StateHistoryFlag = cli.Uint64Flag{
+		Name:  "history.state",
+		Usage: "Number of recent states that can be rolled back to with the path-based scheme (default = 90,000 blocks, 0 = disabled)",
+		Value: ethconfig.Defaults.StateHistory,
+	}
 	LightKDFFlag = cli.BoolFlag{
 		Name:  "lightkdf",
 		Usage: "Reduce key-derivation RAM & CPU usage at some expense of KDF strength",
@@ -375,6 +405,20 @@ var (
 		Usage: "Maximum amount of time non-executable transaction are queued",
 		Value: ethconfig.Defaults.TxPool.Lifetime,
 	}
+	TxPoolPersistFlag = cli.BoolFlag{
+		Name:  "txpool.persist",
+		Usage: "Snapshots all pending and queued transactions into the database to survive node restarts",
+	}
+	TxPoolPersistIntervalFlag = cli.DurationFlag{
+		Name:  "txpool.persistinterval",
+		Usage: "Time interval to regenerate the transaction pool snapshot",
+		Value: ethconfig.Defaults.TxPool.PersistInterval,
+	}
+	TxPoolPersistLimitFlag = cli.Uint64Flag{
+		Name:  "txpool.persistlimit",
+		Usage: "Maximum size in bytes of the transaction pool snapshot",
+		Value: ethconfig.Defaults.TxPool.PersistLimit,
+	}
 	// Performance tuning settings
 	CacheFlag = cli.IntFlag{
 		Name:  "cache",
@@ -465,6 +509,24 @@ var (
 		Name:  "miner.noverify",
 		Usage: "Disable remote sealing verification",
 	}
+	MinerPolicyFlag = cli.StringFlag{
+		Name:  "miner.policy",
+		Usage: "Block-building policy ordering the transactions (price, fifo)",
+		Value: miner.DefaultBlockBuilder,
+	}
+	MinerPriceBandFlag = BigFlag{
+		Name:  "miner.priceband",
+		Usage: "Width in wei of the miner fee bands ordered by arrival under the fifo policy",
+		Value: big.NewInt(params.GWei),
+	}
+	MinerSenderCapFlag = cli.IntFlag{
+		Name:  "miner.sendercap",
+		Usage: "Maximum number of transactions per sender in a block (0 = no cap)",
+	}
+	MinerX402ReserveFlag = cli.Uint64Flag{
+		Name:  "miner.x402reserve",
+		Usage: "Percentage of the block gas only x402 transactions can use",
+	}
 	// Account settings
 	UnlockedAccountFlag = cli.StringFlag{
 		Name:  "unlock",
@@ -1219,6 +1281,15 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	setDataDir(ctx, cfg)
 	setSmartCard(ctx, cfg)
 
+	if ctx.GlobalIsSet(DBEngineFlag.Name) {
+		switch engine := ctx.GlobalString(DBEngineFlag.Name); engine {
+		case rawdb.DBLeveldb, rawdb.DBPebble:
+			cfg.DBEngine = engine
+		default:
+			Fatalf("Invalid choice for db.engine '%s', allowed 'leveldb' or 'pebble'", engine)
+		}
+	}
+
 	if ctx.GlobalIsSet(ExternalSignerFlag.Name) {
 		cfg.ExternalSigner = ctx.GlobalString(ExternalSignerFlag.Name)
 	}
@@ -1335,6 +1406,15 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.GlobalIsSet(TxPoolLifetimeFlag.Name) {
 		cfg.Lifetime = ctx.GlobalDuration(TxPoolLifetimeFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolPersistFlag.Name) {
+		cfg.Persist = ctx.GlobalBool(TxPoolPersistFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolPersistIntervalFlag.Name) {
+		cfg.PersistInterval = ctx.GlobalDuration(TxPoolPersistIntervalFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolPersistLimitFlag.Name) {
+		cfg.PersistLimit = ctx.GlobalUint64(TxPoolPersistLimitFlag.Name)
+	}
 }
 
 func setEthash(ctx *cli.Context, cfg *ethconfig.Config) {
@@ -1384,6 +1464,18 @@ func setMiner(ctx *cli.Context, cfg *miner.Config) {
 	if ctx.GlobalIsSet(MinerNoVerifyFlag.Name) {
 		cfg.Noverify = ctx.GlobalBool(MinerNoVerifyFlag.Name)
 	}
+	if ctx.GlobalIsSet(MinerPolicyFlag.Name) {
+		cfg.Policy = ctx.GlobalString(MinerPolicyFlag.Name)
+	}
+	if ctx.GlobalIsSet(MinerPriceBandFlag.Name) {
+		cfg.PriceBand = GlobalBig(ctx, MinerPriceBandFlag.Name)
+	}
+	if ctx.GlobalIsSet(MinerSenderCapFlag.Name) {
+		cfg.SenderCap = ctx.GlobalInt(MinerSenderCapFlag.Name)
+	}
+	if ctx.GlobalIsSet(MinerX402ReserveFlag.Name) {
+		cfg.X402Reserve = ctx.GlobalUint64(MinerX402ReserveFlag.Name)
+	}
 	if ctx.GlobalIsSet(LegacyMinerGasTargetFlag.Name) {
 		log.Warn("The generic --miner.gastarget flag is deprecated and will be removed in the future!")
 	}
@@ -1463,6 +1555,18 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 		ctx.GlobalSet(TxLookupLimitFlag.Name, "0")
 		log.Warn("Disable transaction unindexing for archive node")
 	}
+	if ctx.GlobalString(GCModeFlag.Name) == "archive" && ctx.GlobalUint64(HistoryLimitFlag.Name) != 0 {
+		Fatalf("History expiry (--%s) is not supported by archive nodes", HistoryLimitFlag.Name)
+	}
+	if ctx.GlobalString(StateSchemeFlag.Name) == rawdb.PathScheme {
+		if ctx.GlobalString(GCModeFlag.Name) == "archive" {
+			Fatalf("Archive mode (--%s=archive) is not supported by the path-based state scheme", GCModeFlag.Name)
+		}
+		if ctx.GlobalIsSet(SyncModeFlag.Name) && ctx.GlobalString(SyncModeFlag.Name) != "full" {
+			Fatalf("Only full sync (--%s=full) is supported by the path-based state scheme", SyncModeFlag.Name)
+		}
+		cfg.SyncMode = downloader.FullSync
+	}
 	if ctx.GlobalIsSet(LightServeFlag.Name) && ctx.GlobalUint64(TxLookupLimitFlag.Name) != 0 {
 		log.Warn("LES server cannot serve old transaction status and cannot connect below les/4 protocol version if transaction lookup index is limited")
 	}
@@ -1530,6 +1634,24 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.GlobalIsSet(TxLookupLimitFlag.Name) {
 		cfg.TxLookupLimit = ctx.GlobalUint64(TxLookupLimitFlag.Name)
 	}
+	if ctx.GlobalIsSet(HistoryLimitFlag.Name) {
+		cfg.HistoryLimit = ctx.GlobalUint64(HistoryLimitFlag.Name)
+	}
+	if ctx.GlobalIsSet(TraceIndexFlag.Name) {
+		cfg.TraceIndex = ctx.GlobalBool(TraceIndexFlag.Name)
+	}
+	if ctx.GlobalIsSet(AddressIndexFlag.Name) {
+		cfg.AddressIndex = ctx.GlobalBool(AddressIndexFlag.Name)
+	}
+	if ctx.GlobalIsSet(TokenIndexFlag.Name) {
+		cfg.TokenIndex = ctx.GlobalBool(TokenIndexFlag.Name)
+	}
+	if ctx.GlobalIsSet(StateSchemeFlag.Name) {
+		cfg.StateScheme = ctx.GlobalString(StateSchemeFlag.Name)
+	}
+	if ctx.GlobalIsSet(StateHistoryFlag.Name) {
+		cfg.StateHistory = ctx.GlobalUint64(StateHistoryFlag.Name)
+	}
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheTrieFlag.Name) {
 		cfg.TrieCleanCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheTrieFlag.Name) / 100
 	}
@@ -1692,6 +1814,9 @@ func RegisterEthService(stack *node.Node, cfg *ethconfig.Config) (ethapi.Backend
 		}
 	}
 	stack.RegisterAPIs(tracers.APIs(backend.APIBackend))
+	if cfg.TraceIndex {
+		stack.RegisterLifecycle(tracers.NewTraceIndexer(backend.APIBackend))
+	}
 	return backend.APIBackend, backend
 }
 
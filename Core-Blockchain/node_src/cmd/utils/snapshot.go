@@ -0,0 +1,815 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package utils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/congress"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state/snapshot"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/light"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+const (
+	// snapshotArchiveVersion is the version of the snapshot archive format.
+	snapshotArchiveVersion = 1
+
+	// snapshotManifestName is the name of the file describing a snapshot archive.
+	snapshotManifestName = "manifest.json"
+
+	// snapshotChainName is the name of the file holding the chain segment.
+	snapshotChainName = "chain.rlp.gz"
+
+	// snapshotChunkSize is the approximate amount of state data per chunk.
+	snapshotChunkSize = 64 * 1024 * 1024
+
+	// snapshotRecentBlocks is the minimum number of recent blocks exported along
+	// with the state, available to the BLOCKHASH opcode.
+	snapshotRecentBlocks = 256
+)
+
+// snapshotManifest describes a snapshot archive, the hashes of its files being
+// taken over their uncompressed content.
+type snapshotManifest struct {
+	Version uint64        `json:"version"`
+	Genesis common.Hash   `json:"genesis"`
+	Number  uint64        `json:"number"`
+	Hash    common.Hash   `json:"hash"`
+	Root    common.Hash   `json:"root"`
+	Chain   archiveFile   `json:"chain"`
+	State   []archiveFile `json:"state"`
+}
+
+// archiveFile is a file of a snapshot archive.
+type archiveFile struct {
+	Name string      `json:"name"`
+	Hash common.Hash `json:"hash"`
+}
+
+// archiveChain is the first item of the chain segment file, followed by the
+// blocks of the segment.
+type archiveChain struct {
+	TD         *big.Int // Total difficulty of the first block
+	Checkpoint uint64   // Number of the block the consensus snapshot was taken at
+	Congress   []byte   // Congress validator snapshot, if the chain runs Congress
+}
+
+// archiveBlock is a block of the chain segment along with its receipts.
+type archiveBlock struct {
+	Block    *types.Block
+	Receipts []*types.ReceiptForStorage
+}
+
+// stateChunk is a range of accounts of the state, provable against the state
+// root. The proof covers the origin and the last account of the range.
+type stateChunk struct {
+	Origin   common.Hash
+	Accounts []chunkAccount
+	Codes    [][]byte
+	Proof    [][]byte
+}
+
+// chunkAccount is an account in the slim snapshot format, along with its entire
+// storage.
+type chunkAccount struct {
+	Hash common.Hash
+	Body []byte
+	Keys []common.Hash
+	Vals [][]byte
+}
+
+// ExportSnapshot writes the state of the given block, read from the snapshot
+// tree, into a snapshot archive in the given directory, along with the recent
+// chain segment and the consensus state needed to continue the chain from it.
+func ExportSnapshot(db ethdb.Database, snaptree *snapshot.Tree, triedb *trie.Database, head *types.Header, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if common.FileExist(filepath.Join(dir, snapshotManifestName)) {
+		return fmt.Errorf("snapshot archive already exists in %s", dir)
+	}
+	genesis := rawdb.ReadCanonicalHash(db, 0)
+	manifest := &snapshotManifest{
+		Version: snapshotArchiveVersion,
+		Genesis: genesis,
+		Number:  head.Number.Uint64(),
+		Hash:    head.Hash(),
+		Root:    head.Root,
+	}
+	start := time.Now()
+	chain, err := exportSnapshotChain(db, rawdb.ReadChainConfig(db, genesis), head, dir)
+	if err != nil {
+		return err
+	}
+	manifest.Chain = *chain
+	log.Info("Exported chain segment", "number", head.Number, "hash", head.Hash(), "elapsed", common.PrettyDuration(time.Since(start)))
+
+	if manifest.State, err = exportSnapshotState(db, snaptree, triedb, head.Root, dir); err != nil {
+		return err
+	}
+	blob, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, snapshotManifestName), blob, 0644); err != nil {
+		return err
+	}
+	log.Info("Exported state snapshot", "root", head.Root, "chunks", len(manifest.State), "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// exportSnapshotChain writes the recent chain segment up to the given head. The
+// segment starts at a bloombits section boundary, letting the log index resume
+// from there, and covers the latest persisted Congress checkpoint.
+func exportSnapshotChain(db ethdb.Database, config *params.ChainConfig, head *types.Header, dir string) (*archiveFile, error) {
+	var (
+		number = head.Number.Uint64()
+		first  uint64
+		item   = &archiveChain{}
+	)
+	if number > snapshotRecentBlocks {
+		first = number - snapshotRecentBlocks
+	}
+	if config != nil && config.Congress != nil {
+		item.Checkpoint, item.Congress = congress.ReadCheckpointSnapshot(db, number, params.FullImmutabilityThreshold)
+		if item.Congress == nil {
+			return nil, errors.New("congress checkpoint snapshot unavailable")
+		}
+		// Cover the epoch header the validators of the snapshot were taken from
+		epoch := item.Checkpoint
+		for ; epoch > 0; epoch-- {
+			header := rawdb.ReadHeader(db, rawdb.ReadCanonicalHash(db, epoch), epoch)
+			if header == nil {
+				return nil, fmt.Errorf("header %d missing", epoch)
+			}
+			if congress.CheckpointValidators(header) != nil {
+				break
+			}
+		}
+		if epoch < first {
+			first = epoch
+		}
+	}
+	first -= first % params.BloomBitsBlocks
+	if first == 0 {
+		first = 1 // The genesis is available to every node
+	}
+	if first > number {
+		return nil, errors.New("nothing to export beyond the genesis")
+	}
+	item.TD = rawdb.ReadTd(db, rawdb.ReadCanonicalHash(db, first), first)
+	if item.TD == nil {
+		return nil, fmt.Errorf("total difficulty of block %d missing", first)
+	}
+	return writeArchiveFile(dir, snapshotChainName, func(w io.Writer) error {
+		if err := rlp.Encode(w, item); err != nil {
+			return err
+		}
+		for n := first; n <= number; n++ {
+			hash := rawdb.ReadCanonicalHash(db, n)
+			block := rawdb.ReadBlock(db, hash, n)
+			if block == nil {
+				return fmt.Errorf("block %d missing", n)
+			}
+			receipts := rawdb.ReadRawReceipts(db, hash, n)
+			if receipts == nil {
+				return fmt.Errorf("receipts of block %d missing", n)
+			}
+			entry := &archiveBlock{Block: block, Receipts: make([]*types.ReceiptForStorage, len(receipts))}
+			for i, receipt := range receipts {
+				entry.Receipts[i] = (*types.ReceiptForStorage)(receipt)
+			}
+			if err := rlp.Encode(w, entry); err != nil {
+				return err
+			}
+		}
+		if hash := rawdb.ReadCanonicalHash(db, number); hash != head.Hash() {
+			return fmt.Errorf("block %d not canonical", number)
+		}
+		return nil
+	})
+}
+
+// exportSnapshotState writes the state with the given root in chunks, each one
+// proven against the state trie.
+func exportSnapshotState(db ethdb.Database, snaptree *snapshot.Tree, triedb *trie.Database, root common.Hash, dir string) ([]archiveFile, error) {
+	tr, err := trie.New(root, triedb)
+	if err != nil {
+		return nil, err
+	}
+	accIt, err := snaptree.AccountIterator(root, common.Hash{})
+	if err != nil {
+		return nil, err
+	}
+	defer accIt.Release()
+
+	var (
+		files []archiveFile
+		chunk = new(stateChunk)
+		codes = make(map[common.Hash]struct{})
+		size  int
+		start = time.Now()
+	)
+	flush := func() error {
+		proof := light.NewNodeSet()
+		if err := tr.Prove(chunk.Origin[:], 0, proof); err != nil {
+			return err
+		}
+		if n := len(chunk.Accounts); n > 0 {
+			if err := tr.Prove(chunk.Accounts[n-1].Hash[:], 0, proof); err != nil {
+				return err
+			}
+		}
+		for _, node := range proof.NodeList() {
+			chunk.Proof = append(chunk.Proof, node)
+		}
+		file, err := writeArchiveFile(dir, fmt.Sprintf("state-%06d.rlp.gz", len(files)), func(w io.Writer) error {
+			return rlp.Encode(w, chunk)
+		})
+		if err != nil {
+			return err
+		}
+		files = append(files, *file)
+		log.Info("Exported state chunk", "chunk", len(files), "accounts", len(chunk.Accounts), "elapsed", common.PrettyDuration(time.Since(start)))
+		return nil
+	}
+	for accIt.Next() {
+		account := chunkAccount{Hash: accIt.Hash(), Body: common.CopyBytes(accIt.Account())}
+		full, err := snapshot.FullAccount(account.Body)
+		if err != nil {
+			return nil, err
+		}
+		size += common.HashLength + len(account.Body)
+
+		if common.BytesToHash(full.Root) != types.EmptyRootHash {
+			stIt, err := snaptree.StorageIterator(root, account.Hash, common.Hash{})
+			if err != nil {
+				return nil, err
+			}
+			for stIt.Next() {
+				account.Keys = append(account.Keys, stIt.Hash())
+				account.Vals = append(account.Vals, common.CopyBytes(stIt.Slot()))
+				size += common.HashLength + len(stIt.Slot())
+			}
+			err = stIt.Error()
+			stIt.Release()
+			if err != nil {
+				return nil, err
+			}
+		}
+		if hash := common.BytesToHash(full.CodeHash); hash != emptyCodeHash {
+			if _, ok := codes[hash]; !ok {
+				code := rawdb.ReadCode(db, hash)
+				if len(code) == 0 {
+					return nil, fmt.Errorf("code %x missing", hash)
+				}
+				chunk.Codes = append(chunk.Codes, code)
+				codes[hash] = struct{}{}
+				size += len(code)
+			}
+		}
+		chunk.Accounts = append(chunk.Accounts, account)
+
+		if size >= snapshotChunkSize {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			next, overflow := incHash(account.Hash)
+			if overflow {
+				return files, accIt.Error()
+			}
+			chunk, codes, size = &stateChunk{Origin: next}, make(map[common.Hash]struct{}), 0
+		}
+	}
+	if err := accIt.Error(); err != nil {
+		return nil, err
+	}
+	// The last chunk proves the end of the state, unless the previous did
+	if len(chunk.Accounts) > 0 || len(files) == 0 {
+		if err := flush(); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// ImportSnapshot bootstraps the given freshly initialized database from the
+// snapshot archive in the given directory, whose head block must be the trusted
+// one. Every file of the archive is checked against the manifest, every state
+// chunk against the state root of the head block, and the chain segment against
+// the head block hash. On Congress chains, the validator snapshot is checked
+// against the last epoch header and the blocks after it against the consensus
+// rules. The whole archive is verified before anything is written.
+func ImportSnapshot(db ethdb.Database, dir string, trusted common.Hash) error {
+	blob, err := ioutil.ReadFile(filepath.Join(dir, snapshotManifestName))
+	if err != nil {
+		return err
+	}
+	manifest := new(snapshotManifest)
+	if err := json.Unmarshal(blob, manifest); err != nil {
+		return err
+	}
+	if manifest.Version != snapshotArchiveVersion {
+		return fmt.Errorf("unsupported snapshot archive version %d", manifest.Version)
+	}
+	if manifest.Hash != trusted {
+		return fmt.Errorf("untrusted snapshot head: have %x, want %x", manifest.Hash, trusted)
+	}
+	genesis := rawdb.ReadCanonicalHash(db, 0)
+	if genesis != manifest.Genesis {
+		return fmt.Errorf("genesis mismatch: have %x, archive %x", genesis, manifest.Genesis)
+	}
+	if rawdb.ReadHeadBlockHash(db) != genesis {
+		return errors.New("database not empty, snapshot imports require a freshly initialized one")
+	}
+	log.Info("Importing state snapshot", "number", manifest.Number, "hash", manifest.Hash, "root", manifest.Root)
+
+	// Verify the chain segment before touching the database
+	start := time.Now()
+	item, blocks, err := readSnapshotChain(dir, manifest)
+	if err != nil {
+		return err
+	}
+	first := blocks[0].Block.NumberU64()
+	if first > 1 && first%params.BloomBitsBlocks != 0 {
+		return fmt.Errorf("chain segment starting at unaligned block %d", first)
+	}
+	batch := db.NewBatch()
+	if config := rawdb.ReadChainConfig(db, genesis); config != nil && config.Congress != nil {
+		if err := verifySnapshotCongress(db, config, item, blocks); err != nil {
+			return err
+		}
+		if err := congress.WriteCheckpointSnapshot(batch, item.Checkpoint, blocks[item.Checkpoint-first].Block.Hash(), item.Congress); err != nil {
+			return err
+		}
+	}
+	log.Info("Verified chain segment", "blocks", len(blocks), "elapsed", common.PrettyDuration(time.Since(start)))
+
+	// Verify the state in full before writing it, the writes can't be undone
+	if err := importSnapshotState(db, discardBatch{}, dir, manifest); err != nil {
+		return err
+	}
+	if err := importSnapshotState(db, batch, dir, manifest); err != nil {
+		return fmt.Errorf("import interrupted, the database must be recreated: %v", err)
+	}
+	if rawdb.ReadStateScheme(db) == rawdb.PathScheme {
+		if err := MigrateStateScheme(db, manifest.Root); err != nil {
+			return fmt.Errorf("import interrupted, the database must be recreated: %v", err)
+		}
+	}
+	// Start the ancient store at the segment, as if the history before was pruned
+	if first > 1 {
+		if err := rawdb.InitAncientTail(db, first); err != nil {
+			return err
+		}
+	}
+	// Write the chain segment, updating the head last
+	td := new(big.Int).Set(item.TD)
+	for i, entry := range blocks {
+		block := entry.Block
+		if i > 0 {
+			td.Add(td, block.Difficulty())
+		}
+		receipts := make(types.Receipts, len(entry.Receipts))
+		for j, receipt := range entry.Receipts {
+			receipts[j] = (*types.Receipt)(receipt)
+		}
+		rawdb.WriteBlock(batch, block)
+		rawdb.WriteReceipts(batch, block.Hash(), block.NumberU64(), receipts)
+		rawdb.WriteTd(batch, block.Hash(), block.NumberU64(), td)
+		rawdb.WriteCanonicalHash(batch, block.Hash(), block.NumberU64())
+		rawdb.WriteTxLookupEntriesByBlock(batch, block)
+	}
+	rawdb.WriteTxIndexTail(batch, first)
+	rawdb.WriteHeadHeaderHash(batch, manifest.Hash)
+	rawdb.WriteHeadFastBlockHash(batch, manifest.Hash)
+	rawdb.WriteHeadBlockHash(batch, manifest.Hash)
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	core.InitBloomIndexer(db, first/params.BloomBitsBlocks, blocks[0].Block.ParentHash())
+
+	log.Info("Imported state snapshot", "number", manifest.Number, "hash", manifest.Hash, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// readSnapshotChain reads and verifies the chain segment of a snapshot archive.
+func readSnapshotChain(dir string, manifest *snapshotManifest) (*archiveChain, []*archiveBlock, error) {
+	data, err := readArchiveFile(dir, manifest.Chain)
+	if err != nil {
+		return nil, nil, err
+	}
+	var (
+		stream = rlp.NewStream(bytes.NewReader(data), 0)
+		item   = new(archiveChain)
+		blocks []*archiveBlock
+	)
+	if err := stream.Decode(item); err != nil {
+		return nil, nil, err
+	}
+	if item.TD == nil {
+		return nil, nil, errors.New("total difficulty missing")
+	}
+	for {
+		entry := new(archiveBlock)
+		if err := stream.Decode(entry); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, nil, err
+		}
+		block := entry.Block
+		if n := len(blocks); n > 0 {
+			if parent := blocks[n-1].Block; block.ParentHash() != parent.Hash() || block.NumberU64() != parent.NumberU64()+1 {
+				return nil, nil, fmt.Errorf("block %d not linked to its parent", block.NumberU64())
+			}
+		}
+		if err := verifyArchiveBlock(entry); err != nil {
+			return nil, nil, fmt.Errorf("block %d: %v", block.NumberU64(), err)
+		}
+		blocks = append(blocks, entry)
+	}
+	if len(blocks) == 0 {
+		return nil, nil, errors.New("empty chain segment")
+	}
+	head := blocks[len(blocks)-1].Block
+	if head.Hash() != manifest.Hash || head.NumberU64() != manifest.Number || head.Root() != manifest.Root {
+		return nil, nil, fmt.Errorf("head mismatch: have #%d [%x], archive #%d [%x]", head.NumberU64(), head.Hash(), manifest.Number, manifest.Hash)
+	}
+	return item, blocks, nil
+}
+
+// verifySnapshotCongress checks the Congress validator snapshot of a chain segment
+// against the validators listed by the last epoch header before it, and the blocks
+// after the checkpoint against the consensus rules, starting from the snapshot.
+func verifySnapshotCongress(db ethdb.Database, config *params.ChainConfig, item *archiveChain, blocks []*archiveBlock) error {
+	reader := newArchiveChainReader(db, config, blocks)
+	first := blocks[0].Block.NumberU64()
+	if item.Checkpoint < first || item.Checkpoint > reader.CurrentHeader().Number.Uint64() {
+		return fmt.Errorf("congress checkpoint %d outside of the chain segment", item.Checkpoint)
+	}
+	epoch := reader.GetHeaderByNumber(item.Checkpoint)
+	for epoch != nil && congress.CheckpointValidators(epoch) == nil {
+		epoch = reader.GetHeaderByNumber(epoch.Number.Uint64() - 1)
+	}
+	if epoch == nil {
+		return fmt.Errorf("epoch header of congress checkpoint %d outside of the chain segment", item.Checkpoint)
+	}
+	if err := congress.VerifyCheckpointSnapshot(item.Congress, epoch); err != nil {
+		return fmt.Errorf("congress checkpoint %d: %v", item.Checkpoint, err)
+	}
+	// Snapshot consistent with the validator set, verify the blocks signed with it
+	snapdb := rawdb.NewMemoryDatabase()
+	if err := congress.WriteCheckpointSnapshot(snapdb, item.Checkpoint, blocks[item.Checkpoint-first].Block.Hash(), item.Congress); err != nil {
+		return err
+	}
+	var (
+		headers = make([]*types.Header, 0, len(blocks))
+		seals   = make([]bool, 0, len(blocks))
+	)
+	for _, entry := range blocks[item.Checkpoint-first+1:] {
+		headers = append(headers, entry.Block.Header())
+		seals = append(seals, true)
+	}
+	abort, results := congress.New(config, snapdb).VerifyHeaders(reader, headers, seals)
+	defer close(abort)
+
+	for _, header := range headers {
+		if err := <-results; err != nil {
+			return fmt.Errorf("block %d: %v", header.Number, err)
+		}
+	}
+	return nil
+}
+
+// archiveChainReader serves the headers of a chain segment being imported, along
+// with the genesis of the database, to the consensus engine.
+type archiveChainReader struct {
+	config  *params.ChainConfig
+	genesis *types.Header
+	headers []*types.Header
+}
+
+// newArchiveChainReader creates a header reader over a chain segment.
+func newArchiveChainReader(db ethdb.Database, config *params.ChainConfig, blocks []*archiveBlock) *archiveChainReader {
+	reader := &archiveChainReader{
+		config:  config,
+		genesis: rawdb.ReadHeader(db, rawdb.ReadCanonicalHash(db, 0), 0),
+		headers: make([]*types.Header, len(blocks)),
+	}
+	for i, entry := range blocks {
+		reader.headers[i] = entry.Block.Header()
+	}
+	return reader
+}
+
+// Config implements consensus.ChainHeaderReader.
+func (r *archiveChainReader) Config() *params.ChainConfig { return r.config }
+
+// CurrentHeader implements consensus.ChainHeaderReader, returning the segment head.
+func (r *archiveChainReader) CurrentHeader() *types.Header { return r.headers[len(r.headers)-1] }
+
+// GetHeader implements consensus.ChainHeaderReader.
+func (r *archiveChainReader) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if header := r.GetHeaderByNumber(number); header != nil && header.Hash() == hash {
+		return header
+	}
+	return nil
+}
+
+// GetHeaderByNumber implements consensus.ChainHeaderReader.
+func (r *archiveChainReader) GetHeaderByNumber(number uint64) *types.Header {
+	if number == 0 {
+		return r.genesis
+	}
+	first := r.headers[0].Number.Uint64()
+	if number < first || number-first >= uint64(len(r.headers)) {
+		return nil
+	}
+	return r.headers[number-first]
+}
+
+// GetHeaderByHash implements consensus.ChainHeaderReader.
+func (r *archiveChainReader) GetHeaderByHash(hash common.Hash) *types.Header {
+	if r.genesis.Hash() == hash {
+		return r.genesis
+	}
+	for _, header := range r.headers {
+		if header.Hash() == hash {
+			return header
+		}
+	}
+	return nil
+}
+
+// verifyArchiveBlock checks the body and the receipts of a block against its header.
+func verifyArchiveBlock(entry *archiveBlock) error {
+	block := entry.Block
+	if hash := types.CalcUncleHash(block.Uncles()); hash != block.UncleHash() {
+		return fmt.Errorf("uncle root mismatch: have %x, want %x", hash, block.UncleHash())
+	}
+	if hash := types.DeriveSha(block.Transactions(), trie.NewStackTrie(nil)); hash != block.TxHash() {
+		return fmt.Errorf("transaction root mismatch: have %x, want %x", hash, block.TxHash())
+	}
+	if len(entry.Receipts) != len(block.Transactions()) {
+		return fmt.Errorf("receipt count mismatch: have %d, want %d", len(entry.Receipts), len(block.Transactions()))
+	}
+	receipts := make(types.Receipts, len(entry.Receipts))
+	for i, stored := range entry.Receipts {
+		receipt := (*types.Receipt)(stored)
+		receipt.Type = block.Transactions()[i].Type()
+		receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+		receipts[i] = receipt
+	}
+	if hash := types.DeriveSha(receipts, trie.NewStackTrie(nil)); hash != block.ReceiptHash() {
+		return fmt.Errorf("receipt root mismatch: have %x, want %x", hash, block.ReceiptHash())
+	}
+	return nil
+}
+
+// importSnapshotState verifies the state chunks of a snapshot archive and writes
+// them into the database, along with the trie nodes rebuilt from them.
+func importSnapshotState(db ethdb.Database, batch ethdb.Batch, dir string, manifest *snapshotManifest) error {
+	// Drop the genesis state stored by path, which wouldn't be overwritten
+	if rawdb.ReadStateScheme(db) == rawdb.PathScheme {
+		for _, prefix := range [][]byte{rawdb.TrieNodeAccountPrefix, rawdb.TrieNodeStoragePrefix} {
+			it := db.NewIterator(prefix, nil)
+			for it.Next() {
+				if rawdb.IsAccountTrieNodeKey(it.Key()) || rawdb.IsStorageTrieNodeKey(it.Key()) {
+					batch.Delete(common.CopyBytes(it.Key()))
+				}
+			}
+			it.Release()
+		}
+	}
+	var (
+		accTrie  = trie.NewStackTrie(batch)
+		codes    = make(map[common.Hash]struct{})
+		origin   common.Hash
+		accounts int
+		start    = time.Now()
+	)
+	for i, file := range manifest.State {
+		data, err := readArchiveFile(dir, file)
+		if err != nil {
+			return err
+		}
+		chunk := new(stateChunk)
+		if err := rlp.DecodeBytes(data, chunk); err != nil {
+			return fmt.Errorf("chunk %d: %v", i, err)
+		}
+		if chunk.Origin != origin {
+			return fmt.Errorf("chunk %d: origin mismatch: have %x, want %x", i, chunk.Origin, origin)
+		}
+		var (
+			keys = make([][]byte, len(chunk.Accounts))
+			vals = make([][]byte, len(chunk.Accounts))
+			last []byte
+		)
+		for j, account := range chunk.Accounts {
+			if keys[j] = chunk.Accounts[j].Hash[:]; j > 0 && bytes.Compare(keys[j-1], keys[j]) >= 0 {
+				return fmt.Errorf("chunk %d: accounts not ordered", i)
+			}
+			if vals[j], err = snapshot.FullAccountRLP(account.Body); err != nil {
+				return fmt.Errorf("chunk %d: %v", i, err)
+			}
+			last = keys[j]
+		}
+		proof := make(light.NodeList, len(chunk.Proof))
+		for j, node := range chunk.Proof {
+			proof[j] = node
+		}
+		more, err := trie.VerifyRangeProof(manifest.Root, origin[:], last, keys, vals, proof.NodeSet())
+		if err != nil {
+			return fmt.Errorf("chunk %d: invalid state proof: %v", i, err)
+		}
+		if final := i == len(manifest.State)-1; more == final {
+			return fmt.Errorf("chunk %d: unexpected state range end", i)
+		}
+		// Chunk proven against the state root, verify the storage and code it carries
+		chunkCodes := make(map[common.Hash][]byte, len(chunk.Codes))
+		for _, code := range chunk.Codes {
+			chunkCodes[crypto.Keccak256Hash(code)] = code
+		}
+		for j, account := range chunk.Accounts {
+			full, _ := snapshot.FullAccount(account.Body)
+			if err := importSnapshotStorage(batch, account, common.BytesToHash(full.Root)); err != nil {
+				return fmt.Errorf("chunk %d: account %x: %v", i, account.Hash, err)
+			}
+			if hash := common.BytesToHash(full.CodeHash); hash != emptyCodeHash {
+				if code, ok := chunkCodes[hash]; ok {
+					rawdb.WriteCode(batch, hash, code)
+					codes[hash] = struct{}{}
+				} else if _, ok := codes[hash]; !ok {
+					return fmt.Errorf("chunk %d: code %x missing", i, hash)
+				}
+			}
+			rawdb.WriteAccountSnapshot(batch, account.Hash, account.Body)
+			if err := accTrie.TryUpdate(account.Hash[:], vals[j]); err != nil {
+				return err
+			}
+			if batch.ValueSize() > ethdb.IdealBatchSize {
+				if err := batch.Write(); err != nil {
+					return err
+				}
+				batch.Reset()
+			}
+		}
+		accounts += len(chunk.Accounts)
+		msg := "Imported state chunk"
+		if _, verify := batch.(discardBatch); verify {
+			msg = "Verified state chunk"
+		}
+		log.Info(msg, "chunk", i+1, "chunks", len(manifest.State), "accounts", accounts, "elapsed", common.PrettyDuration(time.Since(start)))
+
+		if n := len(chunk.Accounts); n > 0 {
+			var overflow bool
+			if origin, overflow = incHash(chunk.Accounts[n-1].Hash); overflow && i < len(manifest.State)-1 {
+				return fmt.Errorf("chunk %d: state beyond the key space", i+1)
+			}
+		}
+	}
+	root, err := accTrie.Commit()
+	if err != nil {
+		return err
+	}
+	if root != manifest.Root {
+		return fmt.Errorf("state root mismatch: have %x, want %x", root, manifest.Root)
+	}
+	snapshot.MarkGenerated(batch, root)
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	batch.Reset()
+	return nil
+}
+
+// importSnapshotStorage writes the storage of an account, rebuilding its trie
+// and checking it against the storage root of the account.
+func importSnapshotStorage(batch ethdb.Batch, account chunkAccount, root common.Hash) error {
+	if len(account.Keys) != len(account.Vals) {
+		return errors.New("storage slot count mismatch")
+	}
+	if root == types.EmptyRootHash {
+		if len(account.Keys) > 0 {
+			return errors.New("storage of an account without one")
+		}
+		return nil
+	}
+	stTrie := trie.NewStackTrie(batch)
+	for i, key := range account.Keys {
+		if i > 0 && bytes.Compare(account.Keys[i-1][:], key[:]) >= 0 {
+			return errors.New("storage slots not ordered")
+		}
+		if err := stTrie.TryUpdate(key[:], account.Vals[i]); err != nil {
+			return err
+		}
+		rawdb.WriteStorageSnapshot(batch, account.Hash, key, account.Vals[i])
+	}
+	have, err := stTrie.Commit()
+	if err != nil {
+		return err
+	}
+	if have != root {
+		return fmt.Errorf("storage root mismatch: have %x, want %x", have, root)
+	}
+	return nil
+}
+
+// discardBatch is a batch dropping everything written to it, verifying the state
+// of an archive without importing it.
+type discardBatch struct{}
+
+func (discardBatch) Put(key []byte, value []byte) error  { return nil }
+func (discardBatch) Delete(key []byte) error             { return nil }
+func (discardBatch) ValueSize() int                      { return 0 }
+func (discardBatch) Write() error                        { return nil }
+func (discardBatch) Reset()                              {}
+func (discardBatch) Replay(w ethdb.KeyValueWriter) error { return nil }
+
+// writeArchiveFile writes a gzip compressed file of a snapshot archive with the
+// content produced by the given callback.
+func writeArchiveFile(dir string, name string, write func(w io.Writer) error) (*archiveFile, error) {
+	fh, err := os.OpenFile(filepath.Join(dir, name), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+
+	var (
+		hasher = crypto.NewKeccakState()
+		writer = gzip.NewWriter(fh)
+	)
+	if err := write(io.MultiWriter(writer, hasher)); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	file := &archiveFile{Name: name}
+	hasher.Read(file.Hash[:])
+	return file, fh.Sync()
+}
+
+// readArchiveFile reads the uncompressed content of a file of a snapshot archive,
+// checking it against the manifest.
+func readArchiveFile(dir string, file archiveFile) ([]byte, error) {
+	if filepath.Base(file.Name) != file.Name {
+		return nil, fmt.Errorf("invalid archive file name %q", file.Name)
+	}
+	fh, err := os.Open(filepath.Join(dir, file.Name))
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+
+	reader, err := gzip.NewReader(fh)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	if hash := crypto.Keccak256Hash(data); hash != file.Hash {
+		return nil, fmt.Errorf("archive file %s corrupted: have %x, want %x", file.Name, hash, file.Hash)
+	}
+	return data, nil
+}
+
+// incHash returns the next hash in lexicographical order, reporting whether the
+// given one was the last.
+func incHash(h common.Hash) (common.Hash, bool) {
+	for i := len(h) - 1; i >= 0; i-- {
+		h[i]++
+		if h[i] != 0 {
+			return h, false
+		}
+	}
+	return h, true
+}
@@ -0,0 +1,216 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package utils
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state/snapshot"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+var (
+	snapshotTestKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	snapshotTestAddr    = crypto.PubkeyToAddress(snapshotTestKey.PublicKey)
+	snapshotTestStorage = common.HexToAddress("0x1000000000000000000000000000000000000001")
+)
+
+// newSnapshotTestChain creates a chain long enough for the exported segment to
+// start past the first bloombits section, along with its genesis and database.
+func newSnapshotTestChain(t *testing.T) (*core.Genesis, ethdb.Database, *core.BlockChain) {
+	t.Helper()
+
+	genesis := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: core.GenesisAlloc{
+			snapshotTestAddr: {Balance: big.NewInt(params.Ether)},
+			snapshotTestStorage: {
+				Balance: big.NewInt(1),
+				Code:    []byte{byte(vm.PUSH1), 0x01, byte(vm.PUSH1), 0x00, byte(vm.SSTORE)},
+				Storage: map[common.Hash]common.Hash{
+					common.HexToHash("0x01"): common.HexToHash("0x02"),
+					common.HexToHash("0x03"): common.HexToHash("0x04"),
+				},
+			},
+		},
+		BaseFee: big.NewInt(params.InitialBaseFee),
+	}
+	db, err := rawdb.NewDatabaseWithFreezer(memorydb.New(), t.TempDir(), "", false)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	genesisBlock := genesis.MustCommit(db)
+
+	signer := types.LatestSigner(genesis.Config)
+	blocks, _ := core.GenerateChain(genesis.Config, genesisBlock, ethash.NewFaker(), db, int(params.BloomBitsBlocks)+300, func(i int, b *core.BlockGen) {
+		if i%100 != 0 {
+			return
+		}
+		to := common.BigToAddress(big.NewInt(int64(i + 1)))
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(snapshotTestAddr), to, big.NewInt(1000), params.TxGas, b.BaseFee(), nil), signer, snapshotTestKey)
+		b.AddTx(tx)
+		if i%500 == 0 {
+			tx, _ = types.SignTx(types.NewTransaction(b.TxNonce(snapshotTestAddr), snapshotTestStorage, nil, 100000, b.BaseFee(), nil), signer, snapshotTestKey)
+			b.AddTx(tx)
+		}
+	})
+	chain, err := core.NewBlockChain(db, nil, genesis.Config, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	t.Cleanup(chain.Stop)
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	return genesis, db, chain
+}
+
+// Tests that a node bootstrapped from a snapshot archive continues the chain
+// with the exported state, for both state schemes.
+func TestSnapshotExportImport(t *testing.T) {
+	genesis, sourcedb, source := newSnapshotTestChain(t)
+	head := source.CurrentBlock()
+
+	dir := t.TempDir()
+	if err := ExportSnapshot(sourcedb, source.Snapshots(), source.StateCache().TrieDB(), head.Header(), dir); err != nil {
+		t.Fatalf("failed to export snapshot: %v", err)
+	}
+	next, _ := core.GenerateChain(genesis.Config, head, ethash.NewFaker(), sourcedb, 10, nil)
+
+	for _, scheme := range []string{rawdb.HashScheme, rawdb.PathScheme} {
+		db, err := rawdb.NewDatabaseWithFreezer(memorydb.New(), t.TempDir(), "", false)
+		if err != nil {
+			t.Fatalf("failed to create database: %v", err)
+		}
+		rawdb.WriteStateScheme(db, scheme)
+		genesis.MustCommit(db)
+
+		if err := ImportSnapshot(db, dir, head.ParentHash()); err == nil || !strings.Contains(err.Error(), "untrusted") {
+			t.Fatalf("%s: snapshot of an untrusted head not rejected: %v", scheme, err)
+		}
+		if err := ImportSnapshot(db, dir, head.Hash()); err != nil {
+			t.Fatalf("%s: failed to import snapshot: %v", scheme, err)
+		}
+		if err := ImportSnapshot(db, dir, head.Hash()); err == nil {
+			t.Fatalf("%s: imported snapshot into a non-empty database", scheme)
+		}
+		if tail, _ := db.Tail(); tail != params.BloomBitsBlocks {
+			t.Fatalf("%s: ancient tail mismatch: have %d, want %d", scheme, tail, params.BloomBitsBlocks)
+		}
+		cacheConfig := &core.CacheConfig{
+			TrieCleanLimit: 16,
+			TrieDirtyLimit: 16,
+			SnapshotLimit:  16,
+			SnapshotWait:   true,
+			StateScheme:    scheme,
+		}
+		chain, err := core.NewBlockChain(db, cacheConfig, genesis.Config, ethash.NewFaker(), vm.Config{}, nil, nil)
+		if err != nil {
+			t.Fatalf("%s: failed to create chain: %v", scheme, err)
+		}
+		if have := chain.CurrentBlock().Hash(); have != head.Hash() {
+			t.Fatalf("%s: head mismatch: have %x, want %x", scheme, have, head.Hash())
+		}
+		if chain.Snapshots().Snapshot(head.Root()) == nil {
+			t.Fatalf("%s: snapshot unavailable", scheme)
+		}
+		statedb, err := chain.State()
+		if err != nil {
+			t.Fatalf("%s: state unavailable: %v", scheme, err)
+		}
+		if have, want := statedb.GetState(snapshotTestStorage, common.HexToHash("0x00")), common.HexToHash("0x01"); have != want {
+			t.Fatalf("%s: storage mismatch: have %x, want %x", scheme, have, want)
+		}
+		if _, err := chain.InsertChain(next); err != nil {
+			t.Fatalf("%s: failed to extend imported chain: %v", scheme, err)
+		}
+		chain.Stop()
+		db.Close()
+	}
+}
+
+// Tests that state chunks not matching the state root are rejected, even if the
+// manifest was updated along.
+func TestSnapshotImportForged(t *testing.T) {
+	genesis, sourcedb, source := newSnapshotTestChain(t)
+
+	dir := t.TempDir()
+	if err := ExportSnapshot(sourcedb, source.Snapshots(), source.StateCache().TrieDB(), source.CurrentHeader(), dir); err != nil {
+		t.Fatalf("failed to export snapshot: %v", err)
+	}
+	blob, _ := ioutil.ReadFile(filepath.Join(dir, snapshotManifestName))
+	manifest := new(snapshotManifest)
+	if err := json.Unmarshal(blob, manifest); err != nil {
+		t.Fatalf("failed to decode manifest: %v", err)
+	}
+	data, err := readArchiveFile(dir, manifest.State[0])
+	if err != nil {
+		t.Fatalf("failed to read chunk: %v", err)
+	}
+	chunk := new(stateChunk)
+	if err := rlp.DecodeBytes(data, chunk); err != nil {
+		t.Fatalf("failed to decode chunk: %v", err)
+	}
+	// Raise the balance of the funded account
+	for i, account := range chunk.Accounts {
+		if account.Hash == crypto.Keccak256Hash(snapshotTestAddr[:]) {
+			full, err := snapshot.FullAccount(account.Body)
+			if err != nil {
+				t.Fatalf("failed to decode account: %v", err)
+			}
+			full.Balance.Add(full.Balance, big.NewInt(params.Ether))
+			chunk.Accounts[i].Body = snapshot.SlimAccountRLP(full.Nonce, full.Balance, common.BytesToHash(full.Root), full.CodeHash)
+		}
+	}
+	file, err := writeArchiveFile(dir, manifest.State[0].Name, func(w io.Writer) error {
+		return rlp.Encode(w, chunk)
+	})
+	if err != nil {
+		t.Fatalf("failed to write chunk: %v", err)
+	}
+	manifest.State[0] = *file
+	blob, _ = json.Marshal(manifest)
+	ioutil.WriteFile(filepath.Join(dir, snapshotManifestName), blob, 0644)
+
+	db, err := rawdb.NewDatabaseWithFreezer(memorydb.New(), t.TempDir(), "", false)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	defer db.Close()
+	genesis.MustCommit(db)
+	if err := ImportSnapshot(db, dir, manifest.Hash); err == nil || !strings.Contains(err.Error(), "invalid state proof") {
+		t.Fatalf("forged snapshot not rejected by the proof: %v", err)
+	}
+	if have := rawdb.ReadHeadBlockHash(db); have != genesis.ToBlock(nil).Hash() {
+		t.Fatalf("head moved by a failed import: %x", have)
+	}
+	if tail, _ := db.Tail(); tail != 0 {
+		t.Fatalf("ancient tail moved by a failed import: %d", tail)
+	}
+	if rawdb.ReadAccountSnapshot(db, crypto.Keccak256Hash(snapshotTestAddr[:])) != nil {
+		t.Fatal("state written by a failed import")
+	}
+}
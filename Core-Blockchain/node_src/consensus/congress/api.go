@@ -18,9 +18,12 @@ package congress
 
 import (
 	"fmt"
+	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/rpc"
 )
@@ -145,3 +148,86 @@ func (api *API) Status() (*status, error) {
 		NumBlocks:     numBlocks,
 	}, nil
 }
+
+// ScheduledUpgrade is the RPC representation of a system contract upgrade manifest.
+type ScheduledUpgrade struct {
+	Name     string         `json:"name"`
+	Block    *hexutil.Big   `json:"block"`
+	Address  common.Address `json:"address"`
+	CodeHash common.Hash    `json:"codeHash"`
+	Source   string         `json:"source,omitempty"`
+	InitData hexutil.Bytes  `json:"initData,omitempty"`
+	Applied  bool           `json:"applied"` // Whether the current head is at or past the upgrade block
+}
+
+// GetScheduledUpgrades retrieves the system contract upgrade manifests of the chain
+// config, together with whether they are already applied at the current head.
+func (api *API) GetScheduledUpgrades() []*ScheduledUpgrade {
+	head := api.chain.CurrentHeader().Number
+	upgrades := make([]*ScheduledUpgrade, 0, len(api.congress.chainConfig.SystemContractUpgrades))
+	for _, u := range api.congress.chainConfig.SystemContractUpgrades {
+		upgrades = append(upgrades, &ScheduledUpgrade{
+			Name:     u.Name,
+			Block:    (*hexutil.Big)(u.Block),
+			Address:  u.Address,
+			CodeHash: u.CodeHash,
+			Source:   u.Source,
+			InitData: u.InitData,
+			Applied:  u.Block.Cmp(head) <= 0,
+		})
+	}
+	return upgrades
+}
+
+// stateAt resolves the header of the requested block (or current if none requested)
+// together with its state.
+func (api *API) stateAt(number *rpc.BlockNumber) (*types.Header, *state.StateDB, error) {
+	var header *types.Header
+	if number == nil || *number == rpc.LatestBlockNumber {
+		header = api.chain.CurrentHeader()
+	} else {
+		header = api.chain.GetHeaderByNumber(uint64(number.Int64()))
+	}
+	if header == nil {
+		return nil, nil, errUnknownBlock
+	}
+	if api.congress.stateFn == nil {
+		return nil, nil, errNoStateAccess
+	}
+	statedb, err := api.congress.stateFn(header.Root)
+	if err != nil {
+		return nil, nil, err
+	}
+	return header, statedb, nil
+}
+
+// GetDevVerification retrieves whether contract deployments are restricted to
+// verified developers for the block following the specified one.
+func (api *API) GetDevVerification(number *rpc.BlockNumber) (*DevVerificationStatus, error) {
+	header, statedb, err := api.stateAt(number)
+	if err != nil {
+		return nil, err
+	}
+	return api.congress.devVerificationStatus(statedb, new(big.Int).Add(header.Number, common.Big1)), nil
+}
+
+// IsDeveloperVerified retrieves whether the given address may deploy contracts in
+// the block following the specified one.
+func (api *API) IsDeveloperVerified(addr common.Address, number *rpc.BlockNumber) (bool, error) {
+	header, statedb, err := api.stateAt(number)
+	if err != nil {
+		return false, err
+	}
+	return api.congress.CanCreate(statedb, addr, new(big.Int).Add(header.Number, common.Big1)), nil
+}
+
+// GetVerifiedDevelopers retrieves the developers verified by the address list
+// contract at the specified block. The developer events are indexed from the
+// RedCoast fork on, in the background until the index catches up with the chain.
+func (api *API) GetVerifiedDevelopers(number *rpc.BlockNumber) ([]common.Address, error) {
+	header, statedb, err := api.stateAt(number)
+	if err != nil {
+		return nil, err
+	}
+	return api.congress.verifiedDevelopers(api.chain, header, statedb)
+}
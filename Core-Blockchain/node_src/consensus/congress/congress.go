@@ -218,6 +218,8 @@ type Congress struct {
 
 	chain consensus.ChainHeaderReader // chain is only for reading parent headers when getting blacklist and rules
 
+	devs devIndex // Developers added by the address list contract, indexed incrementally and persisted
+
 	// The fields below are for testing only
 	fakeDiff bool // Skip difficulty verifications
 }
@@ -315,18 +317,6 @@ func (c *Congress) verifyHeader(chain consensus.ChainHeaderReader, header *types
 	if len(header.Extra) < extraVanity+extraSeal {
 		return errMissingSignature
 	}
-	// check extra data
-	isEpoch := number%c.config.Epoch == 0
-
-	// Ensure that the extra-data contains a validator list on checkpoint, but none otherwise
-	validatorsBytes := len(header.Extra) - extraVanity - extraSeal
-	if !isEpoch && validatorsBytes != 0 {
-		return errExtraValidators
-	}
-	// Ensure that the validator bytes length is valid
-	if isEpoch && validatorsBytes%common.AddressLength != 0 {
-		return errExtraValidators
-	}
 
 	// Ensure that the mix digest is zero as we don't have fork protection currently
 	if header.MixDigest != (common.Hash{}) {
@@ -373,8 +363,29 @@ func (c *Congress) verifyCascadingFields(chain consensus.ChainHeaderReader, head
 	if parent == nil || parent.Number.Uint64() != number-1 || parent.Hash() != header.ParentHash {
 		return consensus.ErrUnknownAncestor
 	}
+	// The epoch length and block period in force are tracked by the parent snapshot
+	snap, err := c.snapshot(chain, number-1, header.ParentHash, parents)
+	if err != nil {
+		return err
+	}
+	// Ensure that the extra-data contains a validator list on checkpoint, but none otherwise
+	isEpoch := number%snap.Epoch == 0
+	if !isEpoch && len(header.Extra) != extraVanity+extraSeal {
+		return errExtraValidators
+	}
+	if isEpoch {
+		// Ensure that the validator bytes length is valid, and that the consensus params
+		// are present exactly from the GovParams fork on
+		_, gp, err := parseCheckpointExtra(header)
+		if err != nil {
+			return err
+		}
+		if (gp != nil) != chain.Config().IsGovParams(header.Number) {
+			return errInvalidExtraParams
+		}
+	}
 
-	if parent.Time+c.config.Period > header.Time {
+	if parent.Time+snap.Period > header.Time {
 		return ErrInvalidTimestamp
 	}
 
@@ -424,21 +435,27 @@ func (c *Congress) snapshot(chain consensus.ChainHeaderReader, number uint64, ha
 		// at a checkpoint block without a parent (light client CHT), or we have piled
 		// up more headers than allowed to be reorged (chain reinit from a freezer),
 		// consider the checkpoint trusted and snapshot it.
-		if number == 0 || (number%c.config.Epoch == 0 && (len(headers) > params.FullImmutabilityThreshold || chain.GetHeaderByNumber(number-1) == nil)) {
+		//
+		// Checkpoints after the GovParams fork are recognized by the consensus params they
+		// carry, as the epoch length might have been changed by governance since genesis.
+		govForked := c.chainConfig.IsGovParams(new(big.Int).SetUint64(number))
+		if number == 0 || ((govForked || number%c.config.Epoch == 0) && (len(headers) > params.FullImmutabilityThreshold || chain.GetHeaderByNumber(number-1) == nil)) {
 			checkpoint := chain.GetHeaderByNumber(number)
-			if checkpoint != nil {
-				hash := checkpoint.Hash()
-
-				validators := make([]common.Address, (len(checkpoint.Extra)-extraVanity-extraSeal)/common.AddressLength)
-				for i := 0; i < len(validators); i++ {
-					copy(validators[i][:], checkpoint.Extra[extraVanity+i*common.AddressLength:])
+			if checkpoint != nil && len(checkpoint.Extra) >= extraVanity+extraSeal {
+				validators, gp, err := parseCheckpointExtra(checkpoint)
+				if err == nil && (number == 0 || (gp != nil) == govForked) {
+					hash := checkpoint.Hash()
+
+					snap = newSnapshot(c.config, c.signatures, number, hash, validators)
+					if gp != nil {
+						snap.Period, snap.Epoch = gp.Period, gp.Epoch
+					}
+					if err := snap.store(c.db); err != nil {
+						return nil, err
+					}
+					log.Info("Stored checkpoint snapshot to disk", "number", number, "hash", hash)
+					break
 				}
-				snap = newSnapshot(c.config, c.signatures, number, hash, validators)
-				if err := snap.store(c.db); err != nil {
-					return nil, err
-				}
-				log.Info("Stored checkpoint snapshot to disk", "number", number, "hash", hash)
-				break
 			}
 		}
 		// No snapshot for this header, gather the header and move backward
@@ -569,7 +586,7 @@ func (c *Congress) Prepare(chain consensus.ChainHeaderReader, header *types.Head
 	}
 	header.Extra = header.Extra[:extraVanity]
 
-	if number%c.config.Epoch == 0 {
+	if number%snap.Epoch == 0 {
 		newSortedValidators, err := c.getTopValidators(chain, header)
 		if err != nil {
 			return err
@@ -578,6 +595,14 @@ func (c *Congress) Prepare(chain consensus.ChainHeaderReader, header *types.Head
 		for _, validator := range newSortedValidators {
 			header.Extra = append(header.Extra, validator.Bytes()...)
 		}
+		// Since the GovParams fork, checkpoints carry the consensus params of the next epoch
+		if chain.Config().IsGovParams(header.Number) {
+			gp, err := c.getGovParams(chain, header, snap)
+			if err != nil {
+				return err
+			}
+			header.Extra = append(header.Extra, gp.bytes()...)
+		}
 	}
 	header.Extra = append(header.Extra, make([]byte, extraSeal)...)
 
@@ -589,7 +614,7 @@ func (c *Congress) Prepare(chain consensus.ChainHeaderReader, header *types.Head
 	if parent == nil {
 		return consensus.ErrUnknownAncestor
 	}
-	header.Time = parent.Time + c.config.Period
+	header.Time = parent.Time + snap.Period
 	if header.Time < uint64(time.Now().Unix()) {
 		header.Time = uint64(time.Now().Unix())
 	}
@@ -599,6 +624,10 @@ func (c *Congress) Prepare(chain consensus.ChainHeaderReader, header *types.Head
 // Finalize implements consensus.Engine, ensuring no uncles are set, nor block
 // rewards given.
 func (c *Congress) Finalize(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB, txs *[]*types.Transaction, uncles []*types.Header, receipts *[]*types.Receipt, systemTxs []*types.Transaction) error {
+	snap, err := c.finalizeSnapshot(chain, header)
+	if err != nil {
+		return err
+	}
 	// Initialize all system contracts at block 1.
 	if header.Number.Cmp(common.Big1) == 0 {
 		if err := c.initializeSystemContracts(chain, header, state); err != nil {
@@ -608,7 +637,7 @@ func (c *Congress) Finalize(chain consensus.ChainHeaderReader, header *types.Hea
 	}
 
 	if header.Difficulty.Cmp(diffInTurn) != 0 {
-		if err := c.tryPunishValidator(chain, header, state); err != nil {
+		if err := c.tryPunishValidator(chain, header, state, snap); err != nil {
 			return err
 		}
 	}
@@ -694,8 +723,9 @@ func (c *Congress) Finalize(chain consensus.ChainHeaderReader, header *types.Hea
 	}
 
 	// do epoch thing at the end, because it will update active validators
-	if header.Number.Uint64()%c.config.Epoch == 0 {
-		newValidators, err := c.doSomethingAtEpoch(chain, header, state)
+	epoch := c.epochLength(snap)
+	if header.Number.Uint64()%epoch == 0 {
+		newValidators, err := c.doSomethingAtEpoch(chain, header, state, epoch)
 		if err != nil {
 			return err
 		}
@@ -706,6 +736,16 @@ func (c *Congress) Finalize(chain consensus.ChainHeaderReader, header *types.Hea
 		}
 
 		extraSuffix := len(header.Extra) - extraSeal
+		if chain.Config().IsGovParams(header.Number) {
+			gp, err := c.getGovParams(chain, header, snap)
+			if err != nil {
+				return err
+			}
+			if extraSuffix < extraVanity+extraParams || !bytes.Equal(header.Extra[extraSuffix-extraParams:extraSuffix], gp.bytes()) {
+				return errInvalidExtraParams
+			}
+			extraSuffix -= extraParams
+		}
 		if !bytes.Equal(header.Extra[extraVanity:extraSuffix], validatorsBytes) {
 			return errInvalidExtraValidators
 		}
@@ -764,6 +804,10 @@ func (c *Congress) FinalizeAndAssemble(chain consensus.ChainHeaderReader, header
 			log.Warn("FinalizeAndAssemble failed", "err", err)
 		}
 	}()
+	snap, err := c.finalizeSnapshot(chain, header)
+	if err != nil {
+		return nil, nil, err
+	}
 	// Initialize all system contracts at block 1.
 	if header.Number.Cmp(common.Big1) == 0 {
 		if err := c.initializeSystemContracts(chain, header, state); err != nil {
@@ -773,7 +817,7 @@ func (c *Congress) FinalizeAndAssemble(chain consensus.ChainHeaderReader, header
 
 	// punish validator if necessary
 	if header.Difficulty.Cmp(diffInTurn) != 0 {
-		if err := c.tryPunishValidator(chain, header, state); err != nil {
+		if err := c.tryPunishValidator(chain, header, state, snap); err != nil {
 			panic(err)
 		}
 	}
@@ -846,8 +890,8 @@ func (c *Congress) FinalizeAndAssemble(chain consensus.ChainHeaderReader, header
 	}
 
 	// do epoch thing at the end, because it will update active validators
-	if header.Number.Uint64()%c.config.Epoch == 0 {
-		if _, err := c.doSomethingAtEpoch(chain, header, state); err != nil {
+	if epoch := c.epochLength(snap); header.Number.Uint64()%epoch == 0 {
+		if _, err := c.doSomethingAtEpoch(chain, header, state, epoch); err != nil {
 			//panic(err)
 			log.Info(err.Error())
 		}
@@ -937,12 +981,28 @@ func (c *Congress) trySendBlockReward(chain consensus.ChainHeaderReader, header
 	return nil
 }
 
-func (c *Congress) tryPunishValidator(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB) error {
-	number := header.Number.Uint64()
-	snap, err := c.snapshot(chain, number-1, header.ParentHash, nil)
-	if err != nil {
-		return err
+// finalizeSnapshot returns the parent snapshot if finalizing the header needs it,
+// that is if the in-turn validator missed its slot or if the epoch length might
+// have been changed by governance. Otherwise nil is returned, sparing the lookup
+// on every in-turn block before the GovParams fork.
+func (c *Congress) finalizeSnapshot(chain consensus.ChainHeaderReader, header *types.Header) (*Snapshot, error) {
+	if header.Difficulty.Cmp(diffInTurn) == 0 && !chain.Config().IsGovParams(header.Number) {
+		return nil, nil
 	}
+	return c.snapshot(chain, header.Number.Uint64()-1, header.ParentHash, nil)
+}
+
+// epochLength returns the epoch length in force for a block finalized with the
+// given snapshot, the configured one if there's none.
+func (c *Congress) epochLength(snap *Snapshot) uint64 {
+	if snap == nil {
+		return c.config.Epoch
+	}
+	return snap.Epoch
+}
+
+func (c *Congress) tryPunishValidator(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB, snap *Snapshot) error {
+	number := header.Number.Uint64()
 	validators := snap.validators()
 	outTurnValidator := validators[number%uint64(len(validators))]
 	// check sigend recently or not
@@ -962,18 +1022,18 @@ func (c *Congress) tryPunishValidator(chain consensus.ChainHeaderReader, header
 	return nil
 }
 
-func (c *Congress) doSomethingAtEpoch(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB) ([]common.Address, error) {
+func (c *Congress) doSomethingAtEpoch(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB, epoch uint64) ([]common.Address, error) {
 	newSortedValidators, err := c.getTopValidators(chain, header)
 	if err != nil {
 		return []common.Address{}, err
 	}
 
 	// update contract new validators if new set exists
-	if err := c.updateValidators(newSortedValidators, chain, header, state); err != nil {
+	if err := c.updateValidators(newSortedValidators, epoch, chain, header, state); err != nil {
 		return []common.Address{}, err
 	}
 	//  decrease validator missed blocks counter at epoch
-	if err := c.decreaseMissedBlocksCounter(chain, header, state); err != nil {
+	if err := c.decreaseMissedBlocksCounter(epoch, chain, header, state); err != nil {
 		return []common.Address{}, err
 	}
 
@@ -1065,10 +1125,10 @@ func (c *Congress) getTopValidators(chain consensus.ChainHeaderReader, header *t
 	return validators, err
 }
 
-func (c *Congress) updateValidators(vals []common.Address, chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB) error {
+func (c *Congress) updateValidators(vals []common.Address, epoch uint64, chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB) error {
 	// method
 	method := "updateActiveValidatorSet"
-	data, err := c.abi[systemcontract.ValidatorsContractName].Pack(method, vals, new(big.Int).SetUint64(c.config.Epoch))
+	data, err := c.abi[systemcontract.ValidatorsContractName].Pack(method, vals, new(big.Int).SetUint64(epoch))
 	if err != nil {
 		log.Error("Can't pack data for updateActiveValidatorSet", "error", err)
 		return err
@@ -1105,10 +1165,10 @@ func (c *Congress) punishValidator(val common.Address, chain consensus.ChainHead
 	return nil
 }
 
-func (c *Congress) decreaseMissedBlocksCounter(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB) error {
+func (c *Congress) decreaseMissedBlocksCounter(epoch uint64, chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB) error {
 	// method
 	method := "decreaseMissedBlocksCounter"
-	data, err := c.abi[systemcontract.PunishContractName].Pack(method, new(big.Int).SetUint64(c.config.Epoch))
+	data, err := c.abi[systemcontract.PunishContractName].Pack(method, new(big.Int).SetUint64(epoch))
 	if err != nil {
 		log.Error("Can't pack data for decreaseMissedBlocksCounter", "error", err)
 		return err
@@ -1146,21 +1206,21 @@ func (c *Congress) Seal(chain consensus.ChainHeaderReader, block *types.Block, r
 	if number == 0 {
 		return errUnknownBlock
 	}
-	// For 0-period chains, refuse to seal empty blocks (no reward but would spin sealing)
-	if c.config.Period == 0 && len(block.Transactions()) == 0 {
-		log.Info("Sealing paused, waiting for transactions")
-		return nil
-	}
 	// Don't hold the val fields for the entire sealing procedure
 	c.lock.RLock()
 	val, signFn := c.validator, c.signFn
 	c.lock.RUnlock()
 
-	// Bail out if we're unauthorized to sign a block
 	snap, err := c.snapshot(chain, number-1, header.ParentHash, nil)
 	if err != nil {
 		return err
 	}
+	// For 0-period chains, refuse to seal empty blocks (no reward but would spin sealing)
+	if snap.Period == 0 && len(block.Transactions()) == 0 {
+		log.Info("Sealing paused, waiting for transactions")
+		return nil
+	}
+	// Bail out if we're unauthorized to sign a block
 	if _, authorized := snap.Validators[val]; !authorized {
 		return errUnauthorizedValidator
 	}
@@ -1294,13 +1354,26 @@ func encodeSigHeader(w io.Writer, header *types.Header) {
 }
 
 func (c *Congress) PreHandle(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB) error {
-	if c.chainConfig.RedCoastBlock != nil && c.chainConfig.RedCoastBlock.Cmp(header.Number) == 0 {
-		return systemcontract.ApplySystemContractUpgrade(systemcontract.SysContractV1, state, header, newChainContext(chain, c), c.chainConfig)
+	// Only one of the built-in upgrades is applied per block, RedCoast taking
+	// precedence if both activate at the same one.
+	switch {
+	case c.chainConfig.RedCoastBlock != nil && c.chainConfig.RedCoastBlock.Cmp(header.Number) == 0:
+		if err := systemcontract.ApplySystemContractUpgrade(systemcontract.SysContractV1, state, header, newChainContext(chain, c), c.chainConfig); err != nil {
+			return err
+		}
+	case c.chainConfig.SophonBlock != nil && c.chainConfig.SophonBlock.Cmp(header.Number) == 0:
+		if err := systemcontract.ApplySystemContractUpgrade(systemcontract.SysContractV2, state, header, newChainContext(chain, c), c.chainConfig); err != nil {
+			return err
+		}
 	}
-	if c.chainConfig.SophonBlock != nil && c.chainConfig.SophonBlock.Cmp(header.Number) == 0 {
-		return systemcontract.ApplySystemContractUpgrade(systemcontract.SysContractV2, state, header, newChainContext(chain, c), c.chainConfig)
+	// The consensus params contract is deployed at the GovParams fork, after the
+	// built-in upgrades but before the declarative ones of the same block.
+	if c.chainConfig.GovParamsBlock != nil && c.chainConfig.GovParamsBlock.Cmp(header.Number) == 0 {
+		if err := systemcontract.ApplySystemContractUpgrade(systemcontract.SysContractV3, state, header, newChainContext(chain, c), c.chainConfig); err != nil {
+			return err
+		}
 	}
-	return nil
+	return systemcontract.ApplyScheduledUpgrades(state, header, newChainContext(chain, c), c.chainConfig)
 }
 
 // IsSysTransaction checks whether a specific transaction is a system transaction.
@@ -1325,13 +1398,8 @@ func (c *Congress) IsSysTransaction(sender common.Address, tx *types.Transaction
 // This will queries the system Developers contract, by DIRECTLY to get the target slot value of the contract,
 // it means that it's strongly relative to the layout of the Developers contract's state variables
 func (c *Congress) CanCreate(state consensus.StateReader, addr common.Address, height *big.Int) bool {
-	if c.chainConfig.IsRedCoast(height) && c.config.EnableDevVerification {
-		if isDeveloperVerificationEnabled(state) {
-			slot := calcSlotOfDevMappingKey(addr)
-			valueHash := state.GetState(systemcontract.AddressListContractAddr, slot)
-			// none zero value means true
-			return valueHash.Big().Sign() > 0
-		}
+	if c.devVerificationStatus(state, height).Enabled {
+		return isVerifiedDeveloper(state, addr)
 	}
 	return true
 }
@@ -185,6 +185,22 @@ func (c *Congress) executeProposalMsg(chain consensus.ChainHeaderReader, header
 			receipt.Status = types.ReceiptStatusSuccessful
 		}
 		log.Info("executeProposalMsg", "action", "erase", "id", prop.Id.String(), "to", prop.To, "txHash", txHash.String(), "success", ok)
+	case govParamsAction:
+		// consensus params action, only supported since the GovParams fork
+		receipt = &types.Receipt{
+			Type:              types.LegacyTxType,
+			PostState:         []byte{},
+			Status:            types.ReceiptStatusFailed,
+			CumulativeGasUsed: header.GasUsed,
+		}
+		err := errors.New("unsupported action")
+		if c.chainConfig.IsGovParams(header.Number) {
+			err = executeGovParamsProposal(state, prop)
+		}
+		if err == nil {
+			receipt.Status = types.ReceiptStatusSuccessful
+		}
+		log.Info("executeProposalMsg", "action", "govParams", "id", prop.Id.String(), "data", hexutil.Encode(prop.Data), "txHash", txHash.String(), "err", err)
 	default:
 		receipt = &types.Receipt{
 			Type:              types.LegacyTxType,
@@ -259,6 +275,13 @@ func (c *Congress) ApplySysTx(evm *vm.EVM, state *state.StateDB, txIndex int, se
 	case 1:
 		// delete code action
 		_ = state.Erase(prop.To)
+	case govParamsAction:
+		// consensus params action
+		if c.chainConfig.IsGovParams(evm.Context.BlockNumber) {
+			vmerr = executeGovParamsProposal(state, prop)
+		} else {
+			vmerr = errors.New("unsupported action")
+		}
 	default:
 		vmerr = errors.New("unsupported action")
 	}
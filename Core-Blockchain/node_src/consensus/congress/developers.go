@@ -0,0 +1,283 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package congress
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/congress/systemcontract"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var (
+	// developerAddedTopic is the topic of the event the address list contract emits
+	// when a developer gets verified.
+	developerAddedTopic = crypto.Keccak256Hash([]byte("DeveloperAdded(address)"))
+
+	// errNoStateAccess is returned if the state of the system contracts is queried
+	// on a node that can't access it locally.
+	errNoStateAccess = errors.New("system contract state not available")
+
+	// errNoReceiptAccess is returned if the verified developers are listed on a node
+	// that can't access the receipts locally.
+	errNoReceiptAccess = errors.New("receipts not available")
+
+	// errDevIndexBuilding is returned if the verified developers are listed while
+	// the developer events are being indexed in the background.
+	errDevIndexBuilding = errors.New("developer index is being built, retry later")
+)
+
+// DevVerificationStatus is the state of the contract deployment allowlist.
+type DevVerificationStatus struct {
+	Enabled         bool `json:"enabled"`         // Whether deployments are restricted to verified developers
+	ConfigEnabled   bool `json:"configEnabled"`   // Whether the chain config enables developer verification
+	ContractEnabled bool `json:"contractEnabled"` // Whether the address list contract switched developer verification on
+}
+
+// devVerificationStatus returns the state of the deployment allowlist for blocks
+// at the given height built on top of the given state.
+func (c *Congress) devVerificationStatus(state consensus.StateReader, height *big.Int) *DevVerificationStatus {
+	status := &DevVerificationStatus{
+		ConfigEnabled: c.chainConfig.IsRedCoast(height) && c.config.EnableDevVerification,
+	}
+	if status.ConfigEnabled {
+		status.ContractEnabled = isDeveloperVerificationEnabled(state)
+	}
+	status.Enabled = status.ConfigEnabled && status.ContractEnabled
+	return status
+}
+
+// isVerifiedDeveloper reports whether addr is in the developers mapping of the
+// address list contract.
+func isVerifiedDeveloper(state consensus.StateReader, addr common.Address) bool {
+	valueHash := state.GetState(systemcontract.AddressListContractAddr, calcSlotOfDevMappingKey(addr))
+	// none zero value means true
+	return valueHash.Big().Sign() > 0
+}
+
+// developerFromLog extracts the developer address of a DeveloperAdded event,
+// whether the address is indexed or not.
+func developerFromLog(log *types.Log) (common.Address, bool) {
+	if len(log.Topics) > 1 {
+		return common.BytesToAddress(log.Topics[1].Bytes()), true
+	}
+	if len(log.Data) >= common.HashLength {
+		return common.BytesToAddress(log.Data[:common.HashLength]), true
+	}
+	return common.Address{}, false
+}
+
+// receiptReader is implemented by chains which can access receipts locally.
+type receiptReader interface {
+	GetReceiptsByHash(hash common.Hash) types.Receipts
+}
+
+const (
+	// devIndexBatch is the number of blocks indexed at once. Indexes lagging
+	// further behind are caught up by a background builder.
+	devIndexBatch = 4096
+
+	// devIndexKey is the database key the developer index is persisted under.
+	devIndexKey = "congress-devs"
+)
+
+// devIndex accumulates the developers ever added by the address list contract,
+// up to the last processed block. Developers of reorged blocks are kept, which is
+// harmless as the state tells which developers are verified.
+type devIndex struct {
+	lock       sync.Mutex
+	loaded     bool        // Whether the index persisted in the database was loaded
+	building   bool        // Whether a background builder is catching the index up
+	number     uint64      // Number of the last processed block, zero if none
+	hash       common.Hash // Hash of the last processed block
+	candidates map[common.Address]struct{}
+}
+
+// storedDevIndex is the database encoding of a developer index.
+type storedDevIndex struct {
+	Number     uint64           `json:"number"`
+	Hash       common.Hash      `json:"hash"`
+	Candidates []common.Address `json:"candidates"`
+}
+
+// load reads the index persisted in the database, if not done yet.
+func (idx *devIndex) load(db ethdb.Database) {
+	if idx.loaded {
+		return
+	}
+	idx.loaded, idx.candidates = true, make(map[common.Address]struct{})
+
+	blob, err := db.Get([]byte(devIndexKey))
+	if err != nil {
+		return
+	}
+	var stored storedDevIndex
+	if err := json.Unmarshal(blob, &stored); err != nil {
+		log.Warn("Failed to load developer index", "err", err)
+		return
+	}
+	idx.number, idx.hash = stored.Number, stored.Hash
+	for _, dev := range stored.Candidates {
+		idx.candidates[dev] = struct{}{}
+	}
+}
+
+// store persists the index into the database.
+func (idx *devIndex) store(db ethdb.Database) error {
+	stored := storedDevIndex{
+		Number:     idx.number,
+		Hash:       idx.hash,
+		Candidates: make([]common.Address, 0, len(idx.candidates)),
+	}
+	for dev := range idx.candidates {
+		stored.Candidates = append(stored.Candidates, dev)
+	}
+	sort.Sort(validatorsAscending(stored.Candidates))
+
+	blob, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	return db.Put([]byte(devIndexKey), blob)
+}
+
+// next returns the number of the first block to process, rewinding to the last
+// processed block still on the canonical chain.
+func (idx *devIndex) next(chain consensus.ChainHeaderReader, start uint64) uint64 {
+	for idx.number >= start {
+		if h := chain.GetHeaderByNumber(idx.number); h != nil && h.Hash() == idx.hash {
+			break
+		}
+		parent := chain.GetHeader(idx.hash, idx.number)
+		if parent == nil {
+			idx.number, idx.hash = 0, common.Hash{}
+			break
+		}
+		idx.number, idx.hash = idx.number-1, parent.ParentHash
+	}
+	if idx.number >= start {
+		return idx.number + 1
+	}
+	return start
+}
+
+// update processes the developer events of the canonical blocks up to the given
+// number, resuming from the last processed block if it is still canonical, or
+// from the block the chain forked off it otherwise.
+func (idx *devIndex) update(chain consensus.ChainHeaderReader, receipts receiptReader, to uint64, start uint64) error {
+	for number := idx.next(chain, start); number <= to; number++ {
+		h := chain.GetHeaderByNumber(number)
+		if h == nil {
+			return errUnknownBlock
+		}
+		idx.number, idx.hash = number, h.Hash()
+		if !types.BloomLookup(h.Bloom, systemcontract.AddressListContractAddr) || !types.BloomLookup(h.Bloom, developerAddedTopic) {
+			continue
+		}
+		for _, receipt := range receipts.GetReceiptsByHash(h.Hash()) {
+			for _, log := range receipt.Logs {
+				if log.Address != systemcontract.AddressListContractAddr || len(log.Topics) == 0 || log.Topics[0] != developerAddedTopic {
+					continue
+				}
+				if dev, ok := developerFromLog(log); ok {
+					idx.candidates[dev] = struct{}{}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// devIndexStart returns the first block the developer events are indexed from.
+func (c *Congress) devIndexStart() uint64 {
+	start := uint64(1)
+	if c.chainConfig.RedCoastBlock != nil && c.chainConfig.RedCoastBlock.Uint64() > start {
+		start = c.chainConfig.RedCoastBlock.Uint64()
+	}
+	return start
+}
+
+// buildDevIndex catches the developer index up to the given block in batches,
+// releasing the lock in between.
+func (c *Congress) buildDevIndex(chain consensus.ChainHeaderReader, receipts receiptReader, target uint64) {
+	start := c.devIndexStart()
+	for {
+		c.devs.lock.Lock()
+		to := c.devs.next(chain, start) + devIndexBatch - 1
+		if to > target {
+			to = target
+		}
+		err := c.devs.update(chain, receipts, to, start)
+		if err == nil {
+			err = c.devs.store(c.db)
+		}
+		done := err != nil || c.devs.number >= target
+		if done {
+			c.devs.building = false
+		}
+		c.devs.lock.Unlock()
+
+		if err != nil {
+			log.Warn("Failed to index verified developers", "number", to, "err", err)
+		}
+		if done {
+			return
+		}
+		log.Debug("Indexed verified developers", "number", to, "target", target)
+	}
+}
+
+// verifiedDevelopers returns the developers verified at the state of the given
+// header. The developer events are indexed incrementally and persisted, so only
+// the blocks since the last call are processed. If the index lags too far behind,
+// it is caught up in the background and errDevIndexBuilding is returned meanwhile.
+func (c *Congress) verifiedDevelopers(chain consensus.ChainHeaderReader, header *types.Header, state consensus.StateReader) ([]common.Address, error) {
+	receipts, ok := chain.(receiptReader)
+	if !ok {
+		return nil, errNoReceiptAccess
+	}
+	start, number := c.devIndexStart(), header.Number.Uint64()
+
+	c.devs.lock.Lock()
+	defer c.devs.lock.Unlock()
+
+	c.devs.load(c.db)
+	if next := c.devs.next(chain, start); next <= number && number-next >= devIndexBatch {
+		if !c.devs.building {
+			c.devs.building = true
+			go c.buildDevIndex(chain, receipts, number)
+		}
+		return nil, errDevIndexBuilding
+	}
+	if err := c.devs.update(chain, receipts, number, start); err != nil {
+		return nil, err
+	}
+	if err := c.devs.store(c.db); err != nil {
+		return nil, err
+	}
+	// Removals don't need replaying, the state tells which developers are left.
+	// Developers added after the header aren't verified at its state either.
+	developers := make([]common.Address, 0, len(c.devs.candidates))
+	for dev := range c.devs.candidates {
+		if isVerifiedDeveloper(state, dev) {
+			developers = append(developers, dev)
+		}
+	}
+	sort.Sort(validatorsAscending(developers))
+	return developers, nil
+}
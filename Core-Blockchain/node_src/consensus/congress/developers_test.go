@@ -0,0 +1,210 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package congress
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/congress/systemcontract"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestCanCreate(t *testing.T) {
+	config := &params.ChainConfig{
+		ChainID:       big.NewInt(1),
+		RedCoastBlock: big.NewInt(10),
+		Congress:      &params.CongressConfig{Period: 3, Epoch: 200, EnableDevVerification: true},
+	}
+	c := New(config, rawdb.NewMemoryDatabase())
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+
+	dev, other := common.HexToAddress("0x01"), common.HexToAddress("0x02")
+	statedb.SetState(systemcontract.AddressListContractAddr, calcSlotOfDevMappingKey(dev), common.BigToHash(common.Big1))
+
+	// Verification switched off in the contract
+	if status := c.devVerificationStatus(statedb, big.NewInt(10)); status.Enabled || !status.ConfigEnabled || status.ContractEnabled {
+		t.Fatalf("status mismatch: %+v", status)
+	}
+	if !c.CanCreate(statedb, other, big.NewInt(10)) {
+		t.Fatal("deployment denied while verification is disabled")
+	}
+	// Verification switched on: slot 0 holds [admin][enabled][initialized]
+	statedb.SetState(systemcontract.AddressListContractAddr, common.Hash{}, common.BytesToHash([]byte{0x01, 0x01}))
+	if status := c.devVerificationStatus(statedb, big.NewInt(10)); !status.Enabled {
+		t.Fatalf("status mismatch: %+v", status)
+	}
+	if !c.CanCreate(statedb, dev, big.NewInt(10)) {
+		t.Fatal("verified developer denied")
+	}
+	if c.CanCreate(statedb, other, big.NewInt(10)) {
+		t.Fatal("unverified developer allowed")
+	}
+	// Verification doesn't apply before the RedCoast fork
+	if !c.CanCreate(statedb, other, big.NewInt(9)) {
+		t.Fatal("deployment denied before RedCoast")
+	}
+}
+
+// devTestChain is a chain of headers whose receipts hold the given logs, counting
+// the receipt retrievals.
+type devTestChain struct {
+	config    *params.ChainConfig
+	canonical []*types.Header
+	headers   map[common.Hash]*types.Header
+	receipts  map[common.Hash]types.Receipts
+	reads     int
+}
+
+// extend appends a block holding the given logs to the canonical chain at the
+// given height, dropping the blocks above.
+func (c *devTestChain) extend(number uint64, logs ...*types.Log) {
+	receipts := types.Receipts{{Logs: logs}}
+	header := &types.Header{
+		ParentHash: c.canonical[number-1].Hash(),
+		Number:     new(big.Int).SetUint64(number),
+		Bloom:      types.CreateBloom(receipts),
+		Extra:      []byte{byte(len(c.headers))},
+	}
+	c.canonical = append(c.canonical[:number], header)
+	c.headers[header.Hash()] = header
+	c.receipts[header.Hash()] = receipts
+}
+
+func (c *devTestChain) Config() *params.ChainConfig  { return c.config }
+func (c *devTestChain) CurrentHeader() *types.Header { return c.canonical[len(c.canonical)-1] }
+func (c *devTestChain) GetHeader(hash common.Hash, number uint64) *types.Header {
+	return c.headers[hash]
+}
+func (c *devTestChain) GetHeaderByHash(hash common.Hash) *types.Header { return c.headers[hash] }
+func (c *devTestChain) GetHeaderByNumber(number uint64) *types.Header {
+	if number >= uint64(len(c.canonical)) {
+		return nil
+	}
+	return c.canonical[number]
+}
+func (c *devTestChain) GetReceiptsByHash(hash common.Hash) types.Receipts {
+	c.reads++
+	return c.receipts[hash]
+}
+
+// newDevTestChain creates a chain holding the genesis header only.
+func newDevTestChain() *devTestChain {
+	genesis := &types.Header{Number: big.NewInt(0)}
+	return &devTestChain{
+		config:    &params.ChainConfig{ChainID: big.NewInt(1), Congress: &params.CongressConfig{Period: 3, Epoch: 200}},
+		canonical: []*types.Header{genesis},
+		headers:   map[common.Hash]*types.Header{genesis.Hash(): genesis},
+		receipts:  make(map[common.Hash]types.Receipts),
+	}
+}
+
+// devAddedLog returns the event of the address list contract verifying dev.
+func devAddedLog(dev common.Address) *types.Log {
+	return &types.Log{Address: systemcontract.AddressListContractAddr, Topics: []common.Hash{developerAddedTopic, common.BytesToHash(dev.Bytes())}}
+}
+
+// Tests that the verified developers are indexed incrementally, following reorgs.
+func TestVerifiedDevelopers(t *testing.T) {
+	var (
+		chain   = newDevTestChain()
+		db      = rawdb.NewMemoryDatabase()
+		c       = New(chain.config, db)
+		statedb = func() *state.StateDB {
+			statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+			return statedb
+		}()
+		devA, devB = common.HexToAddress("0x0a"), common.HexToAddress("0x0b")
+	)
+	added := devAddedLog
+	verify := func(dev common.Address) {
+		statedb.SetState(systemcontract.AddressListContractAddr, calcSlotOfDevMappingKey(dev), common.BigToHash(common.Big1))
+	}
+	check := func(want ...common.Address) {
+		t.Helper()
+		have, err := c.verifiedDevelopers(chain, chain.CurrentHeader(), statedb)
+		if err != nil {
+			t.Fatalf("failed to list developers: %v", err)
+		}
+		if !reflect.DeepEqual(have, want) && !(len(have) == 0 && len(want) == 0) {
+			t.Fatalf("developers mismatch: have %x, want %x", have, want)
+		}
+	}
+	chain.extend(1)
+	chain.extend(2, added(devA))
+	verify(devA)
+	check(devA)
+
+	// Only the blocks since the last call should be processed
+	chain.reads = 0
+	chain.extend(3)
+	check(devA)
+	if chain.reads != 0 {
+		t.Fatalf("receipts of indexed blocks reread: %d reads", chain.reads)
+	}
+	// Reorg the last blocks, adding another developer on the new branch
+	chain.extend(2, added(devA))
+	chain.extend(3, added(devB))
+	verify(devB)
+	check(devA, devB)
+	if chain.reads != 2 {
+		t.Fatalf("reorged blocks reads mismatch: have %d, want 2", chain.reads)
+	}
+	// The index is persisted, an engine restarted on the same database resumes it
+	chain.reads = 0
+	c = New(chain.config, db)
+	check(devA, devB)
+	if chain.reads != 0 {
+		t.Fatalf("receipts of persisted blocks reread: %d reads", chain.reads)
+	}
+}
+
+// Tests that an index lagging far behind the chain is built in the background.
+func TestVerifiedDevelopersBackground(t *testing.T) {
+	var (
+		chain      = newDevTestChain()
+		c          = New(chain.config, rawdb.NewMemoryDatabase())
+		statedb, _ = state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+		dev        = common.HexToAddress("0x0a")
+	)
+	for number := uint64(1); number <= 2*devIndexBatch; number++ {
+		if number == devIndexBatch+1 {
+			chain.extend(number, devAddedLog(dev))
+		} else {
+			chain.extend(number)
+		}
+	}
+	statedb.SetState(systemcontract.AddressListContractAddr, calcSlotOfDevMappingKey(dev), common.BigToHash(common.Big1))
+
+	if _, err := c.verifiedDevelopers(chain, chain.CurrentHeader(), statedb); err != errDevIndexBuilding {
+		t.Fatalf("error mismatch: have %v, want %v", err, errDevIndexBuilding)
+	}
+	for {
+		c.devs.lock.Lock()
+		building := c.devs.building
+		c.devs.lock.Unlock()
+		if !building {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	have, err := c.verifiedDevelopers(chain, chain.CurrentHeader(), statedb)
+	if err != nil {
+		t.Fatalf("failed to list developers: %v", err)
+	}
+	if !reflect.DeepEqual(have, []common.Address{dev}) {
+		t.Fatalf("developers mismatch: have %x, want %x", have, dev)
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package congress
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/congress/systemcontract"
+	"github.com/ethereum/go-ethereum/core"
+)
+
+// GenesisSystemContracts are the system contracts a congress genesis has to
+// allocate, they are initialized with the genesis validators at block 1.
+var GenesisSystemContracts = []struct {
+	Name    string
+	Address common.Address
+}{
+	{"Validators", systemcontract.ValidatorsContractAddr},
+	{"Punish", systemcontract.PunishContractAddr},
+	{"Proposal", systemcontract.ProposalAddr},
+}
+
+// GenesisExtraData assembles the extra-data of a congress genesis block from the
+// initial validators, which are expected in ascending order.
+func GenesisExtraData(validators []common.Address) []byte {
+	extra := make([]byte, extraVanity+len(validators)*common.AddressLength+extraSeal)
+	for i, validator := range validators {
+		copy(extra[extraVanity+i*common.AddressLength:], validator[:])
+	}
+	return extra
+}
+
+// VerifyGenesis checks that a genesis spec can bootstrap a congress chain: the
+// extra-data must carry the initial validators and the system contracts must
+// be allocated, otherwise the chain halts at block 1.
+func VerifyGenesis(genesis *core.Genesis) error {
+	if genesis.Config == nil || genesis.Config.Congress == nil {
+		return errors.New("missing congress chain config")
+	}
+	if len(genesis.ExtraData) < extraVanity+extraSeal {
+		return errMissingSignature
+	}
+	validators := len(genesis.ExtraData) - extraVanity - extraSeal
+	if validators == 0 || validators%common.AddressLength != 0 || validators/common.AddressLength > maxValidators {
+		return errInvalidValidatorsLength
+	}
+	for _, contract := range GenesisSystemContracts {
+		if account, ok := genesis.Alloc[contract.Address]; !ok || len(account.Code) == 0 {
+			return fmt.Errorf("missing %s system contract code at %s", contract.Name, contract.Address.Hex())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package congress
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/congress/systemcontract"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+const (
+	// govParamsAction is the system governance proposal action that changes the
+	// block period and epoch length, its data is abi.encode(uint256 period, uint256 epoch).
+	govParamsAction = 2
+
+	// extraParams is the number of extra-data bytes following the validator list of
+	// checkpoint headers after the GovParams fork: 8 bytes period, 8 bytes epoch.
+	// As it is not a multiple of the address length, it can be told from the validators.
+	extraParams = 16
+
+	minGovEpoch  = 10      // Minimal epoch length that can be set by governance
+	maxGovEpoch  = 1000000 // Maximal epoch length that can be set by governance
+	minGovPeriod = 1       // Minimal block period that can be set by governance
+	maxGovPeriod = 3600    // Maximal block period that can be set by governance
+)
+
+var (
+	// errInvalidGovParams is returned if a governance proposal carries a block period
+	// or epoch length out of the allowed bounds.
+	errInvalidGovParams = errors.New("invalid governance consensus params")
+
+	// errInvalidExtraParams is returned if the consensus params in the extra-data of a
+	// checkpoint header doesn't match the ones voted by governance.
+	errInvalidExtraParams = errors.New("invalid consensus params in extra data field")
+)
+
+// govParams is the block period and epoch length applying from a checkpoint on.
+type govParams struct {
+	Period uint64
+	Epoch  uint64
+}
+
+func (p *govParams) bytes() []byte {
+	b := make([]byte, extraParams)
+	binary.BigEndian.PutUint64(b, p.Period)
+	binary.BigEndian.PutUint64(b[8:], p.Epoch)
+	return b
+}
+
+func (p *govParams) validate() error {
+	if p.Epoch < minGovEpoch || p.Epoch > maxGovEpoch || p.Period < minGovPeriod || p.Period > maxGovPeriod {
+		return errInvalidGovParams
+	}
+	return nil
+}
+
+// IsCheckpoint reports whether the header is a checkpoint, carrying the validator
+// set and consensus params header verification can be resumed from. The epoch
+// length may have been changed by governance, so checkpoints aren't necessarily
+// at multiples of the configured one.
+func IsCheckpoint(header *types.Header) bool {
+	return len(header.Extra) > extraVanity+extraSeal
+}
+
+// CheckpointEpoch returns the epoch length applying after a checkpoint header, the
+// one it carries since the GovParams fork or the configured one before.
+func CheckpointEpoch(config *params.CongressConfig, header *types.Header) (uint64, error) {
+	if !IsCheckpoint(header) {
+		return 0, errExtraValidators
+	}
+	_, gp, err := parseCheckpointExtra(header)
+	if err != nil {
+		return 0, err
+	}
+	if gp == nil {
+		return config.Epoch, nil
+	}
+	return gp.Epoch, nil
+}
+
+// EpochLength returns the epoch length applying after the given header, which
+// may have been changed by governance since the GovParams fork.
+func (c *Congress) EpochLength(chain consensus.ChainHeaderReader, header *types.Header) (uint64, error) {
+	snap, err := c.snapshot(chain, header.Number.Uint64(), header.Hash(), nil)
+	if err != nil {
+		return 0, err
+	}
+	return snap.Epoch, nil
+}
+
+// parseCheckpointExtra splits the extra-data of a checkpoint header into the validator
+// list and the optional consensus params.
+func parseCheckpointExtra(header *types.Header) ([]common.Address, *govParams, error) {
+	payload := header.Extra[extraVanity : len(header.Extra)-extraSeal]
+
+	var gp *govParams
+	switch len(payload) % common.AddressLength {
+	case 0:
+	case extraParams:
+		tail := payload[len(payload)-extraParams:]
+		gp = &govParams{
+			Period: binary.BigEndian.Uint64(tail),
+			Epoch:  binary.BigEndian.Uint64(tail[8:]),
+		}
+		if gp.Epoch == 0 {
+			return nil, nil, errExtraValidators
+		}
+		payload = payload[:len(payload)-extraParams]
+	default:
+		return nil, nil, errExtraValidators
+	}
+	validators := make([]common.Address, len(payload)/common.AddressLength)
+	for i := 0; i < len(validators); i++ {
+		copy(validators[i][:], payload[i*common.AddressLength:])
+	}
+	return validators, gp, nil
+}
+
+// getGovParams returns the consensus params that the checkpoint header should
+// carry, based on the state of the ConsensusParams contract at its parent block.
+// If governance never voted on them, the ones currently in force are kept.
+func (c *Congress) getGovParams(chain consensus.ChainHeaderReader, header *types.Header, snap *Snapshot) (*govParams, error) {
+	parent := chain.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+	if parent == nil {
+		return nil, consensus.ErrUnknownAncestor
+	}
+	statedb, err := c.stateFn(parent.Root)
+	if err != nil {
+		return nil, err
+	}
+	gp := &govParams{
+		Period: statedb.GetState(systemcontract.ConsensusParamsContractAddr, systemcontract.ConsensusPeriodPosition).Big().Uint64(),
+		Epoch:  statedb.GetState(systemcontract.ConsensusParamsContractAddr, systemcontract.ConsensusEpochPosition).Big().Uint64(),
+	}
+	if gp.Epoch == 0 {
+		return &govParams{Period: snap.Period, Epoch: snap.Epoch}, nil
+	}
+	return gp, nil
+}
+
+// executeGovParamsProposal stores the block period and epoch length of a passed
+// proposal in the state variables of the ConsensusParams contract, they take
+// effect from the next checkpoint on.
+func executeGovParamsProposal(state *state.StateDB, prop *Proposal) error {
+	if len(prop.Data) != 2*common.HashLength {
+		return errInvalidGovParams
+	}
+	period, epoch := new(big.Int).SetBytes(prop.Data[:common.HashLength]), new(big.Int).SetBytes(prop.Data[common.HashLength:])
+	if !period.IsUint64() || !epoch.IsUint64() {
+		return errInvalidGovParams
+	}
+	gp := &govParams{Period: period.Uint64(), Epoch: epoch.Uint64()}
+	if err := gp.validate(); err != nil {
+		return err
+	}
+	state.SetState(systemcontract.ConsensusParamsContractAddr, systemcontract.ConsensusPeriodPosition, common.BigToHash(period))
+	state.SetState(systemcontract.ConsensusParamsContractAddr, systemcontract.ConsensusEpochPosition, common.BigToHash(epoch))
+	log.Info("Consensus params voted by governance", "id", prop.Id, "period", gp.Period, "epoch", gp.Epoch)
+	return nil
+}
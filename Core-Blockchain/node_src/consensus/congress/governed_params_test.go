@@ -0,0 +1,81 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package congress
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/congress/systemcontract"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestParseCheckpointExtra(t *testing.T) {
+	vals := []common.Address{common.HexToAddress("0x01"), common.HexToAddress("0x02")}
+	extra := func(params *govParams) []byte {
+		b := make([]byte, extraVanity)
+		for _, val := range vals {
+			b = append(b, val.Bytes()...)
+		}
+		if params != nil {
+			b = append(b, params.bytes()...)
+		}
+		return append(b, make([]byte, extraSeal)...)
+	}
+
+	have, gp, err := parseCheckpointExtra(&types.Header{Extra: extra(nil)})
+	if err != nil || gp != nil || len(have) != 2 || have[1] != vals[1] {
+		t.Fatalf("legacy checkpoint mismatch: vals %v, params %v, err %v", have, gp, err)
+	}
+	have, gp, err = parseCheckpointExtra(&types.Header{Extra: extra(&govParams{Period: 3, Epoch: 100})})
+	if err != nil || gp == nil || *gp != (govParams{Period: 3, Epoch: 100}) || len(have) != 2 || have[0] != vals[0] {
+		t.Fatalf("governed checkpoint mismatch: vals %v, params %v, err %v", have, gp, err)
+	}
+	if _, _, err = parseCheckpointExtra(&types.Header{Extra: extra(&govParams{Period: 3})}); err == nil {
+		t.Fatal("expected error for zero epoch")
+	}
+	bad := append(extra(nil), 0x01)
+	if _, _, err = parseCheckpointExtra(&types.Header{Extra: bad}); err == nil {
+		t.Fatal("expected error for malformed extra-data")
+	}
+}
+
+func TestExecuteGovParamsProposal(t *testing.T) {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	data := func(period, epoch int64) []byte {
+		return append(common.BigToHash(big.NewInt(period)).Bytes(), common.BigToHash(big.NewInt(epoch)).Bytes()...)
+	}
+
+	tests := []struct {
+		data []byte
+		ok   bool
+	}{
+		{data(3, 200), true},
+		{data(3, minGovEpoch-1), false},
+		{data(0, 200), false},
+		{data(maxGovPeriod+1, 200), false},
+		{data(3, maxGovEpoch+1), false},
+		{data(3, 200)[:40], false},
+	}
+	for i, tt := range tests {
+		err := executeGovParamsProposal(statedb, &Proposal{Id: big.NewInt(int64(i)), Action: big.NewInt(govParamsAction), Data: tt.data})
+		if (err == nil) != tt.ok {
+			t.Errorf("test %d: error mismatch: %v", i, err)
+		}
+	}
+	period := statedb.GetState(systemcontract.ConsensusParamsContractAddr, systemcontract.ConsensusPeriodPosition)
+	epoch := statedb.GetState(systemcontract.ConsensusParamsContractAddr, systemcontract.ConsensusEpochPosition)
+	if !bytes.Equal(period.Bytes(), common.BigToHash(big.NewInt(3)).Bytes()) || epoch.Big().Uint64() != 200 {
+		t.Fatalf("stored params mismatch: period %x, epoch %x", period, epoch)
+	}
+}
@@ -28,6 +28,15 @@ func (cc *chainContext) GetHeader(hash common.Hash, number uint64) *types.Header
 	return cc.chainReader.GetHeader(hash, number)
 }
 
+// SysCallTracer returns the tracer of the system contract calls carried by the
+// chain reader, if any.
+func (cc *chainContext) SysCallTracer() consensus.SysCallTracer {
+	if tracing, ok := cc.chainReader.(consensus.SysCallTracing); ok {
+		return tracing.SysCallTracer()
+	}
+	return nil
+}
+
 // minimalChainContext provides a `core.ChainContext` implementation without really functioned `GetHeader` method,
 // it's used to execute those contracts which do no includes `BLOCKHASH` opcode.
 // The purpose is to reduce dependencies between different packages.
@@ -0,0 +1,84 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package congress
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/congress/vmcaller"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// recordingTracer records the system calls handed to it, tracing them with
+// struct loggers.
+type recordingTracer struct {
+	to      []common.Address
+	outputs [][]byte
+	loggers []*vm.StructLogger
+}
+
+func (t *recordingTracer) CaptureSysCall(from common.Address, to common.Address, input []byte, value *big.Int) vm.EVMLogger {
+	t.to = append(t.to, to)
+	t.loggers = append(t.loggers, vm.NewStructLogger(nil))
+	return t.loggers[len(t.loggers)-1]
+}
+
+func (t *recordingTracer) CaptureSysCallEnd(output []byte, err error) {
+	t.outputs = append(t.outputs, output)
+}
+
+// tracingReader is a chain reader carrying a system call tracer.
+type tracingReader struct {
+	consensus.ChainHeaderReader
+	tracer *recordingTracer
+}
+
+func (r *tracingReader) SysCallTracer() consensus.SysCallTracer {
+	if r.tracer == nil {
+		return nil
+	}
+	return r.tracer
+}
+
+func TestSysCallTracing(t *testing.T) {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	contract := common.HexToAddress("0x0100")
+	// LOG0 with empty data, then return 42
+	statedb.SetCode(contract, common.FromHex("0x60006000a0602a60005260206000f3"))
+
+	header := &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(1), GasLimit: 10000000}
+	msg := vmcaller.NewLegacyMessage(common.Address{0x01}, &contract, 0, new(big.Int), 100000, new(big.Int), nil, false)
+
+	// Untraced chains execute the call as usual
+	if _, err := vmcaller.ExecuteMsg(msg, statedb, header, newChainContext(&tracingReader{}, ethash.NewFaker()), params.TestChainConfig); err != nil {
+		t.Fatalf("failed to execute system call: %v", err)
+	}
+	tracer := new(recordingTracer)
+	ret, err := vmcaller.ExecuteMsg(msg, statedb, header, newChainContext(&tracingReader{tracer: tracer}, ethash.NewFaker()), params.TestChainConfig)
+	if err != nil {
+		t.Fatalf("failed to execute system call: %v", err)
+	}
+	if len(tracer.to) != 1 || tracer.to[0] != contract {
+		t.Fatalf("traced calls mismatch: have %v, want [%x]", tracer.to, contract)
+	}
+	if len(tracer.outputs) != 1 || common.BytesToHash(tracer.outputs[0]) != common.BytesToHash(ret) || ret[31] != 42 {
+		t.Fatalf("traced output mismatch: have %x, want %x", tracer.outputs, ret)
+	}
+	if steps := len(tracer.loggers[0].StructLogs()); steps != 9 {
+		t.Fatalf("traced steps mismatch: have %d, want %d", steps, 9)
+	}
+}
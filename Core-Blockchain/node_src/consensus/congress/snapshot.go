@@ -19,10 +19,12 @@ package congress
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"sort"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/params"
@@ -38,6 +40,8 @@ type Snapshot struct {
 	Hash       common.Hash                 `json:"hash"`       // Block hash where the snapshot was created
 	Validators map[common.Address]struct{} `json:"validators"` // Set of authorized validators at this moment
 	Recents    map[uint64]common.Address   `json:"recents"`    // Set of recent validators for spam protections
+	Period     uint64                      `json:"period"`     // Block period applying to the blocks after this one
+	Epoch      uint64                      `json:"epoch"`      // Epoch length applying to the blocks after this one
 }
 
 // validatorsAscending implements the sort interface to allow sorting a list of addresses
@@ -58,6 +62,8 @@ func newSnapshot(config *params.CongressConfig, sigcache *lru.ARCCache, number u
 		Hash:       hash,
 		Validators: make(map[common.Address]struct{}),
 		Recents:    make(map[uint64]common.Address),
+		Period:     config.Period,
+		Epoch:      config.Epoch,
 	}
 	for _, validator := range validators {
 		snap.Validators[validator] = struct{}{}
@@ -78,6 +84,11 @@ func loadSnapshot(config *params.CongressConfig, sigcache *lru.ARCCache, db ethd
 	snap.config = config
 	snap.sigcache = sigcache
 
+	// Snapshots stored before the consensus params were tracked use the configured ones
+	if snap.Epoch == 0 {
+		snap.Period, snap.Epoch = config.Period, config.Epoch
+	}
+
 	return snap, nil
 }
 
@@ -90,6 +101,72 @@ func (s *Snapshot) store(db ethdb.Database) error {
 	return db.Put(append([]byte("congress-"), s.Hash[:]...), blob)
 }
 
+// ReadCheckpointSnapshot retrieves the encoded snapshot of the most recent
+// checkpoint at or before the given block persisted in the database, looking
+// back at most limit blocks. It's meant for exporting the snapshot along with
+// the state, the number of the checkpoint is returned alongside.
+func ReadCheckpointSnapshot(db ethdb.Reader, number uint64, limit uint64) (uint64, []byte) {
+	for checkpoint := number - number%checkpointInterval; number-checkpoint <= limit; checkpoint -= checkpointInterval {
+		hash := rawdb.ReadCanonicalHash(db, checkpoint)
+		if blob, _ := db.Get(append([]byte("congress-"), hash[:]...)); len(blob) > 0 {
+			return checkpoint, blob
+		}
+		if checkpoint == 0 {
+			break
+		}
+	}
+	return 0, nil
+}
+
+// WriteCheckpointSnapshot stores an encoded snapshot read with ReadCheckpointSnapshot,
+// ensuring it was taken at the given checkpoint block.
+func WriteCheckpointSnapshot(db ethdb.KeyValueWriter, number uint64, hash common.Hash, blob []byte) error {
+	snap := new(Snapshot)
+	if err := json.Unmarshal(blob, snap); err != nil {
+		return err
+	}
+	if snap.Number != number || snap.Hash != hash || number%checkpointInterval != 0 {
+		return fmt.Errorf("snapshot mismatch: have #%d [%x], want #%d [%x]", snap.Number, snap.Hash, number, hash)
+	}
+	return db.Put(append([]byte("congress-"), hash[:]...), blob)
+}
+
+// VerifyCheckpointSnapshot checks the validators of a snapshot read with
+// ReadCheckpointSnapshot against the ones listed by the given header, the last
+// one updating the validator set at or before the checkpoint.
+func VerifyCheckpointSnapshot(blob []byte, epoch *types.Header) error {
+	snap := new(Snapshot)
+	if err := json.Unmarshal(blob, snap); err != nil {
+		return err
+	}
+	validators := CheckpointValidators(epoch)
+	if validators == nil {
+		return fmt.Errorf("block %d lists no validators", epoch.Number)
+	}
+	if len(validators) != len(snap.Validators) {
+		return fmt.Errorf("validator count mismatch: have %d, block %d lists %d", len(snap.Validators), epoch.Number, len(validators))
+	}
+	for _, validator := range validators {
+		if _, ok := snap.Validators[validator]; !ok {
+			return fmt.Errorf("validator %x of block %d missing", validator, epoch.Number)
+		}
+	}
+	return nil
+}
+
+// CheckpointValidators returns the validators listed in the extra-data of the
+// genesis or an epoch header, or nil for the headers not updating the validator set.
+func CheckpointValidators(header *types.Header) []common.Address {
+	if len(header.Extra) <= extraVanity+extraSeal {
+		return nil
+	}
+	validators, _, err := parseCheckpointExtra(header)
+	if err != nil || len(validators) == 0 {
+		return nil
+	}
+	return validators
+}
+
 // copy creates a deep copy of the snapshot, though not the individual votes.
 func (s *Snapshot) copy() *Snapshot {
 	cpy := &Snapshot{
@@ -99,6 +176,8 @@ func (s *Snapshot) copy() *Snapshot {
 		Hash:       s.Hash,
 		Validators: make(map[common.Address]struct{}),
 		Recents:    make(map[uint64]common.Address),
+		Period:     s.Period,
+		Epoch:      s.Epoch,
 	}
 	for validator := range s.Validators {
 		cpy.Validators[validator] = struct{}{}
@@ -154,14 +233,12 @@ func (s *Snapshot) apply(headers []*types.Header, chain consensus.ChainHeaderRea
 		}
 		snap.Recents[number] = validator
 
-		// update validators at the first block at epoch
-		if number > 0 && number%s.config.Epoch == 0 {
-			checkpointHeader := header
-
+		// update validators and consensus params at the first block at epoch
+		if number > 0 && number%snap.Epoch == 0 {
 			// get validators from headers and use that for new validator set
-			validators := make([]common.Address, (len(checkpointHeader.Extra)-extraVanity-extraSeal)/common.AddressLength)
-			for i := 0; i < len(validators); i++ {
-				copy(validators[i][:], checkpointHeader.Extra[extraVanity+i*common.AddressLength:])
+			validators, gp, err := parseCheckpointExtra(header)
+			if err != nil {
+				return nil, err
 			}
 
 			newValidators := make(map[common.Address]struct{})
@@ -170,6 +247,9 @@ func (s *Snapshot) apply(headers []*types.Header, chain consensus.ChainHeaderRea
 			}
 
 			snap.Validators = newValidators
+			if gp != nil {
+				snap.Period, snap.Epoch = gp.Period, gp.Epoch
+			}
 		}
 	}
 
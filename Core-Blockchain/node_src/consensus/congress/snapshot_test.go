@@ -0,0 +1,51 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package congress
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestVerifyCheckpointSnapshot(t *testing.T) {
+	vals := []common.Address{common.HexToAddress("0x01"), common.HexToAddress("0x02")}
+	header := func(vals ...common.Address) *types.Header {
+		extra := make([]byte, extraVanity)
+		for _, val := range vals {
+			extra = append(extra, val.Bytes()...)
+		}
+		return &types.Header{Number: common.Big1, Extra: append(extra, make([]byte, extraSeal)...)}
+	}
+	blob, err := json.Marshal(newSnapshot(&params.CongressConfig{Period: 3, Epoch: 100}, nil, checkpointInterval, common.Hash{0x01}, vals))
+	if err != nil {
+		t.Fatalf("failed to encode snapshot: %v", err)
+	}
+	if have := CheckpointValidators(header()); have != nil {
+		t.Fatalf("validators listed by a non-epoch header: %v", have)
+	}
+	if have := CheckpointValidators(header(vals...)); len(have) != 2 || have[0] != vals[0] || have[1] != vals[1] {
+		t.Fatalf("epoch header validators mismatch: have %v, want %v", have, vals)
+	}
+	if err := VerifyCheckpointSnapshot(blob, header(vals[1], vals[0])); err != nil {
+		t.Fatalf("snapshot of the epoch validators rejected: %v", err)
+	}
+	if err := VerifyCheckpointSnapshot(blob, header(vals[0])); err == nil {
+		t.Fatal("snapshot with an extra validator accepted")
+	}
+	if err := VerifyCheckpointSnapshot(blob, header(vals[0], common.HexToAddress("0x03"))); err == nil {
+		t.Fatal("snapshot with a foreign validator accepted")
+	}
+	if err := VerifyCheckpointSnapshot(blob, header()); err == nil {
+		t.Fatal("snapshot checked against a non-epoch header")
+	}
+}
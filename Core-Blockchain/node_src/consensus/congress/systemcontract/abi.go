@@ -3157,6 +3157,51 @@ const PunishV1InteractiveABI = `[
 // `pendingAdmin` stores at slot 1, so the position for `devs` is 2.
 const DevMappingPosition = 2
 
+// ConsensusParamsInteractiveABI contains all methods to interactive with the consensus params contract.
+const ConsensusParamsInteractiveABI = `[
+	{
+		"inputs": [],
+		"name": "epoch",
+		"outputs": [
+			{
+				"internalType": "uint256",
+				"name": "",
+				"type": "uint256"
+			}
+		],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [],
+		"name": "period",
+		"outputs": [
+			{
+				"internalType": "uint256",
+				"name": "",
+				"type": "uint256"
+			}
+		],
+		"stateMutability": "view",
+		"type": "function"
+	}
+]`
+
+// ConsensusParams contract storage layout:
+//
+//    uint256 public period;  // block period voted by governance
+//    uint256 public epoch;   // epoch length voted by governance
+//
+// The consensus engine writes them when a governance proposal passes, so the
+// positions are strongly relative to the layout of the contract's state variables.
+var (
+	ConsensusParamsContractName = "consensus_params"
+	ConsensusParamsContractAddr = common.HexToAddress("0x000000000000000000000000000000000000F008")
+
+	ConsensusPeriodPosition = common.BytesToHash([]byte{0x00})
+	ConsensusEpochPosition  = common.BytesToHash([]byte{0x01})
+)
+
 var (
 	BlackLastUpdatedNumberPosition = common.BytesToHash([]byte{0x07})
 	RulesLastUpdatedNumberPosition = common.BytesToHash([]byte{0x08})
@@ -3177,12 +3222,25 @@ var (
 	AddressListContractAddr  = common.HexToAddress("0x000000000000000000000000000000000000F004")
 	ValidatorsV1ContractAddr = common.HexToAddress("0x000000000000000000000000000000000000F005")
 	PunishV1ContractAddr     = common.HexToAddress("0x000000000000000000000000000000000000F006")
+	SlashingContractAddr     = common.HexToAddress("0x000000000000000000000000000000000000F007")
 	// SysGovToAddr is the To address for the system governance transaction, NOT contract address
 	SysGovToAddr = common.HexToAddress("0x000000000000000000000000000000000000ffff")
 
 	abiMap map[string]abi.ABI
 )
 
+// SystemContracts are the addresses of the system and governance contracts users
+// can transact with.
+var SystemContracts = []common.Address{
+	ValidatorsContractAddr,
+	PunishContractAddr,
+	ProposalAddr,
+	SysGovContractAddr,
+	AddressListContractAddr,
+	ValidatorsV1ContractAddr,
+	PunishV1ContractAddr,
+}
+
 func init() {
 	abiMap = make(map[string]abi.ABI, 0)
 	tmpABI, _ := abi.JSON(strings.NewReader(ValidatorsInteractiveABI))
@@ -3200,6 +3258,9 @@ func init() {
 	abiMap[ValidatorsV1ContractName] = tmpABI
 	tmpABI, _ = abi.JSON(strings.NewReader(PunishV1InteractiveABI))
 	abiMap[PunishV1ContractName] = tmpABI
+
+	tmpABI, _ = abi.JSON(strings.NewReader(ConsensusParamsInteractiveABI))
+	abiMap[ConsensusParamsContractName] = tmpABI
 }
 
 func GetInteractiveABI() map[string]abi.ABI {
@@ -0,0 +1,49 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package systemcontract
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+const (
+	// consensusParamsCode is the runtime code of the ConsensusParams contract, it
+	// only exposes the getters of the block period and epoch length, which are
+	// written by the consensus engine when a governance proposal passes.
+	consensusParamsCode = "0x3415600957600080fd5b60043610602a5760003560e01c8063ef78d4fd14602f5763900cf0cf146036575b600080fd5b600054603a565b6001545b60005260206000f3"
+)
+
+type hardForkConsensusParams struct {
+}
+
+func (s *hardForkConsensusParams) GetName() string {
+	return ConsensusParamsContractName
+}
+
+func (s *hardForkConsensusParams) Update(config *params.ChainConfig, height *big.Int, state *state.StateDB) (err error) {
+	contractCode := common.FromHex(consensusParamsCode)
+
+	//write code to sys contract
+	state.SetCode(ConsensusParamsContractAddr, contractCode)
+	log.Debug("Write code to system contract account", "addr", ConsensusParamsContractAddr.String(), "code", consensusParamsCode)
+
+	return
+}
+
+// Execute does nothing, the params are unset until governance votes on them.
+func (s *hardForkConsensusParams) Execute(state *state.StateDB, header *types.Header, chainContext core.ChainContext, config *params.ChainConfig) (err error) {
+	return
+}
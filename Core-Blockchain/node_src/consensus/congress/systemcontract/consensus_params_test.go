@@ -0,0 +1,52 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package systemcontract
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsensusParamsGetters(t *testing.T) {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	config := &params.ChainConfig{ChainID: big.NewInt(1)}
+	require.NoError(t, ApplySystemContractUpgrade(SysContractV3, statedb, &types.Header{Number: big.NewInt(1)}, nil, config))
+
+	statedb.SetState(ConsensusParamsContractAddr, ConsensusPeriodPosition, common.BigToHash(big.NewInt(3)))
+	statedb.SetState(ConsensusParamsContractAddr, ConsensusEpochPosition, common.BigToHash(big.NewInt(200)))
+
+	contract := GetInteractiveABI()[ConsensusParamsContractName]
+	for method, want := range map[string]int64{"period": 3, "epoch": 200} {
+		data, err := contract.Pack(method)
+		require.NoError(t, err)
+		ret, _, err := runtime.Call(ConsensusParamsContractAddr, data, &runtime.Config{State: statedb})
+		require.NoError(t, err, method)
+
+		out, err := contract.Unpack(method, ret)
+		require.NoError(t, err, method)
+		require.Equal(t, big.NewInt(want), out[0], method)
+	}
+	// Unknown selectors, short call data and value transfers are rejected
+	for _, data := range [][]byte{{0x01, 0x02, 0x03, 0x04}, {0xef, 0x78}} {
+		_, _, err := runtime.Call(ConsensusParamsContractAddr, data, &runtime.Config{State: statedb})
+		require.Error(t, err)
+	}
+	statedb.AddBalance(common.Address{}, big.NewInt(1))
+	data, _ := contract.Pack("period")
+	_, _, err := runtime.Call(ConsensusParamsContractAddr, data, &runtime.Config{State: statedb, Value: big.NewInt(1)})
+	require.Error(t, err)
+}
@@ -0,0 +1,113 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package systemcontract
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/congress/vmcaller"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// bundledCodes maps the names that can be used as the source of a system contract
+// upgrade manifest to the runtime bytecode shipped with this package.
+var bundledCodes = map[string]string{
+	"governance":      govCode,
+	"address_list":    addressListCode,
+	"address_list_v2": addressListV2Code,
+	"validators_v1":   validatorV1Code,
+	"validators_v2":   validatorsV2Code,
+	"punish_v1":       punishV1Code,
+}
+
+// BundledCodeNames returns the names of the bytecodes which can be referenced
+// by the source field of an upgrade manifest.
+func BundledCodeNames() []string {
+	names := make([]string, 0, len(bundledCodes))
+	for name := range bundledCodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// UpgradeCode resolves the runtime bytecode of an upgrade manifest and verifies
+// it against the declared code hash.
+func UpgradeCode(manifest *params.SystemContractUpgrade) ([]byte, error) {
+	code := []byte(manifest.Code)
+	if manifest.Source != "" {
+		hex, ok := bundledCodes[manifest.Source]
+		if !ok {
+			return nil, fmt.Errorf("system contract upgrade %q: unknown code source %q", manifest.Name, manifest.Source)
+		}
+		code = common.FromHex(hex)
+	}
+	if hash := crypto.Keccak256Hash(code); hash != manifest.CodeHash {
+		return nil, fmt.Errorf("system contract upgrade %q: code hash mismatch (have %x, want %x)", manifest.Name, hash, manifest.CodeHash)
+	}
+	return code, nil
+}
+
+// manifestUpgrade is an upgrade action declared by a manifest in the chain config.
+type manifestUpgrade struct {
+	manifest *params.SystemContractUpgrade
+}
+
+func (s *manifestUpgrade) GetName() string {
+	return s.manifest.Name
+}
+
+func (s *manifestUpgrade) Update(config *params.ChainConfig, height *big.Int, state *state.StateDB) (err error) {
+	contractCode, err := UpgradeCode(s.manifest)
+	if err != nil {
+		return err
+	}
+
+	state.SetCode(s.manifest.Address, contractCode)
+	log.Debug("Upgrade code to system contract account", "addr", s.manifest.Address.String(), "codeHash", s.manifest.CodeHash)
+
+	return
+}
+
+func (s *manifestUpgrade) Execute(state *state.StateDB, header *types.Header, chainContext core.ChainContext, config *params.ChainConfig) (err error) {
+	if len(s.manifest.InitData) == 0 {
+		return
+	}
+
+	msg := vmcaller.NewLegacyMessage(header.Coinbase, &s.manifest.Address, 0, new(big.Int), math.MaxUint64, new(big.Int), s.manifest.InitData, false)
+	_, err = vmcaller.ExecuteMsg(msg, state, header, chainContext, config)
+
+	return
+}
+
+// ApplyScheduledUpgrades applies all the system contract upgrade manifests
+// scheduled at the given header, in the order they are declared.
+func ApplyScheduledUpgrades(state *state.StateDB, header *types.Header, chainContext core.ChainContext, config *params.ChainConfig) error {
+	if config == nil || header == nil || state == nil {
+		return nil
+	}
+	manifests := config.SystemContractUpgradesAt(header.Number)
+	if len(manifests) == 0 {
+		return nil
+	}
+	actions := make([]IUpgradeAction, 0, len(manifests))
+	for _, manifest := range manifests {
+		actions = append(actions, &manifestUpgrade{manifest: manifest})
+	}
+	return applyUpgradeActions(actions, state, header, chainContext, config, "manifest", true)
+}
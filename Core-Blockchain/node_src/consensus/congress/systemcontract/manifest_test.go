@@ -0,0 +1,56 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package systemcontract
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpgradeCode(t *testing.T) {
+	bundled := common.FromHex(addressListV2Code)
+	code, err := UpgradeCode(&params.SystemContractUpgrade{Name: "v2", Source: "address_list_v2", CodeHash: crypto.Keccak256Hash(bundled)})
+	require.NoError(t, err)
+	require.Equal(t, bundled, code)
+
+	_, err = UpgradeCode(&params.SystemContractUpgrade{Name: "v2", Source: "address_list_v2", CodeHash: common.Hash{0x01}})
+	require.Error(t, err)
+
+	_, err = UpgradeCode(&params.SystemContractUpgrade{Name: "unknown", Source: "unknown", CodeHash: crypto.Keccak256Hash(bundled)})
+	require.Error(t, err)
+}
+
+func TestApplyScheduledUpgrades(t *testing.T) {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	code := []byte{0x60, 0x00, 0x60, 0x00, 0xf3}
+	config := &params.ChainConfig{
+		ChainID: big.NewInt(1),
+		SystemContractUpgrades: []*params.SystemContractUpgrade{{
+			Name:     "test",
+			Block:    big.NewInt(10),
+			Address:  AddressListContractAddr,
+			CodeHash: crypto.Keccak256Hash(code),
+			Code:     code,
+		}},
+	}
+
+	require.NoError(t, ApplyScheduledUpgrades(statedb, &types.Header{Number: big.NewInt(9)}, nil, config))
+	require.Empty(t, statedb.GetCode(AddressListContractAddr))
+
+	require.NoError(t, ApplyScheduledUpgrades(statedb, &types.Header{Number: big.NewInt(10)}, nil, config))
+	require.Equal(t, code, statedb.GetCode(AddressListContractAddr))
+}
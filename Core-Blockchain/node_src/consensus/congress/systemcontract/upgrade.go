@@ -13,6 +13,7 @@ import (
 const (
 	SysContractV1 SysContractVersion = iota + 1
 	SysContractV2
+	SysContractV3
 )
 
 type SysContractVersion int
@@ -27,8 +28,6 @@ func ApplySystemContractUpgrade(version SysContractVersion, state *state.StateDB
 	if config == nil || header == nil || state == nil {
 		return
 	}
-	height := header.Number
-
 	var sysContracts []IUpgradeAction
 	switch version {
 	case SysContractV1:
@@ -43,24 +42,34 @@ func ApplySystemContractUpgrade(version SysContractVersion, state *state.StateDB
 			&hardForkAddressListV2{},
 			&hardForkValidatorsV2{},
 		}
+	case SysContractV3:
+		sysContracts = []IUpgradeAction{
+			&hardForkConsensusParams{},
+		}
 	default:
 		log.Crit("unsupported SysContractVersion", "version", version)
 	}
 
-	for _, contract := range sysContracts {
-		log.Info("system contract upgrade", "version", version, "name", contract.GetName(), "height", height, "chainId", config.ChainID.String())
+	return applyUpgradeActions(sysContracts, state, header, chainContext, config, "version", version)
+}
+
+// applyUpgradeActions updates and executes the given upgrade actions one by one,
+// ctx is prepended to the log context of every message.
+func applyUpgradeActions(actions []IUpgradeAction, state *state.StateDB, header *types.Header, chainContext core.ChainContext, config *params.ChainConfig, ctx ...interface{}) (err error) {
+	for _, contract := range actions {
+		log.Info("system contract upgrade", append(ctx, "name", contract.GetName(), "height", header.Number, "chainId", config.ChainID.String())...)
 
-		err = contract.Update(config, height, state)
+		err = contract.Update(config, header.Number, state)
 		if err != nil {
-			log.Error("Upgrade system contract update error", "version", version, "name", contract.GetName(), "err", err)
+			log.Error("Upgrade system contract update error", append(ctx, "name", contract.GetName(), "err", err)...)
 			return
 		}
 
-		log.Info("system contract upgrade execution", "version", version, "name", contract.GetName(), "height", header.Number, "chainId", config.ChainID.String())
+		log.Info("system contract upgrade execution", append(ctx, "name", contract.GetName(), "height", header.Number, "chainId", config.ChainID.String())...)
 
 		err = contract.Execute(state, header, chainContext, config)
 		if err != nil {
-			log.Error("Upgrade system contract execute error", "version", version, "name", contract.GetName(), "err", err)
+			log.Error("Upgrade system contract execute error", append(ctx, "name", contract.GetName(), "err", err)...)
 			return
 		}
 	}
@@ -2,6 +2,7 @@ package vmcaller
 
 import (
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -12,14 +13,32 @@ import (
 )
 
 // ExecuteMsg executes transaction sent to system contracts.
+//
+// If the chain context carries a system call tracer, the execution is traced
+// with the logger it hands out.
 func ExecuteMsg(msg core.Message, state *state.StateDB, header *types.Header, chainContext core.ChainContext, chainConfig *params.ChainConfig) (ret []byte, err error) {
+	var (
+		config vm.Config
+		tracer consensus.SysCallTracer
+	)
+	if tracing, ok := chainContext.(consensus.SysCallTracing); ok {
+		tracer = tracing.SysCallTracer()
+	}
+	if tracer != nil {
+		if logger := tracer.CaptureSysCall(msg.From(), *msg.To(), msg.Data(), msg.Value()); logger != nil {
+			config = vm.Config{Debug: true, Tracer: logger}
+		}
+	}
 	blockContext := core.NewEVMBlockContext(header, chainContext, nil)
-	vmenv := vm.NewEVM(blockContext, core.NewEVMTxContext(msg), state, chainConfig, vm.Config{})
+	vmenv := vm.NewEVM(blockContext, core.NewEVMTxContext(msg), state, chainConfig, config)
 
 	ret, _, err = vmenv.Call(vm.AccountRef(msg.From()), *msg.To(), msg.Data(), msg.Gas(), msg.Value())
 	// Finalise the statedb so any changes can take effect,
 	// and especially if the `from` account is empty, it can be finally deleted.
 	state.Finalise(true)
+	if tracer != nil {
+		tracer.CaptureSysCallEnd(ret, err)
+	}
 	if err != nil {
 		log.Error("ExecuteMsg failed", "err", err, "ret", string(ret))
 	}
@@ -160,6 +160,23 @@ type PoSA interface {
 	ApplySysTx(evm *vm.EVM, state *state.StateDB, txIndex int, sender common.Address, tx *types.Transaction) (ret []byte, vmerr error, err error)
 }
 
+// SysCallTracer traces the system contract calls a PoSA engine executes while
+// pre-handling and finalizing blocks, outside of any transaction.
+type SysCallTracer interface {
+	// CaptureSysCall is called before a system contract call is executed, and
+	// returns the logger to execute it with, or nil to leave it untraced.
+	CaptureSysCall(from common.Address, to common.Address, input []byte, value *big.Int) vm.EVMLogger
+
+	// CaptureSysCallEnd is called with the outcome of the system contract call.
+	CaptureSysCallEnd(output []byte, err error)
+}
+
+// SysCallTracing is implemented by the chain readers which hand the system
+// contract calls of the engine to a tracer.
+type SysCallTracing interface {
+	SysCallTracer() SysCallTracer
+}
+
 type StateReader interface {
 	GetState(addr common.Address, hash common.Hash) common.Hash
 }
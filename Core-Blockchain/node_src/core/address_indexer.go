@@ -0,0 +1,244 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package core
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+const (
+	// AddressIndexSectionSize is the number of blocks in a section of the address
+	// index. The blocks past the last indexed section have to be scanned.
+	AddressIndexSectionSize = 1024
+
+	// AddressIndexConfirms is the number of confirmations before a section of the
+	// address index is processed.
+	AddressIndexConfirms = 256
+
+	// addressThrottling is the time to wait between processing two consecutive
+	// index sections.
+	addressThrottling = 100 * time.Millisecond
+)
+
+// AddressRole is the role of an address in a transaction.
+type AddressRole byte
+
+const (
+	AddressRoleSender            AddressRole = iota // Sender of the transaction
+	AddressRoleRecipient                            // Recipient of the transaction
+	AddressRoleCreation                             // Contract created by the transaction
+	AddressRoleMetaSigner                           // Inner signer sponsoring a meta transaction
+	AddressRoleX402Payer                            // Payer of an x402 settlement
+	AddressRoleX402Payee                            // Payee of an x402 settlement
+	AddressRoleTransferSender                       // Contract sending value in an internal transfer
+	AddressRoleTransferRecipient                    // Recipient of an internal transfer
+)
+
+var addressRoleNames = []string{"sender", "recipient", "creation", "metaSigner", "x402Payer", "x402Payee", "transferSender", "transferRecipient"}
+
+// String implements fmt.Stringer.
+func (r AddressRole) String() string {
+	if int(r) < len(addressRoleNames) {
+		return addressRoleNames[r]
+	}
+	return "unknown"
+}
+
+// x402Parties is the leading part of the payload of an x402 settlement, naming
+// the payer and the payee.
+type x402Parties struct {
+	From common.Address
+	To   common.Address
+	Rest []rlp.RawValue `rlp:"tail"`
+}
+
+// AddressActivity collects the addresses touched by the transactions of a block,
+// along with their roles, ordered by transaction index and role. The transfers
+// are the internal value transfers of the block grouped by transaction, if they
+// were recorded while processing it.
+func AddressActivity(config *params.ChainConfig, block *types.Block, transfers [][]*types.InternalTransfer) map[common.Address][]rawdb.AddressIndexEntry {
+	var (
+		number   = block.NumberU64()
+		hash     = block.Hash()
+		signer   = types.MakeSigner(config, block.Number())
+		activity = make(map[common.Address][]rawdb.AddressIndexEntry)
+		seen     = make(map[common.Address]map[AddressRole]bool)
+	)
+	for i, tx := range block.Transactions() {
+		add := func(addr common.Address, role AddressRole) {
+			if seen[addr] == nil {
+				seen[addr] = make(map[AddressRole]bool)
+			}
+			if seen[addr][role] {
+				return
+			}
+			seen[addr][role] = true
+			activity[addr] = append(activity[addr], rawdb.AddressIndexEntry{Number: number, Hash: hash, TxIndex: uint32(i), Role: byte(role)})
+		}
+		from, err := types.Sender(signer, tx)
+		if err == nil {
+			add(from, AddressRoleSender)
+		}
+		if to := tx.To(); to != nil {
+			add(*to, AddressRoleRecipient)
+		} else if err == nil {
+			add(crypto.CreateAddress(from, tx.Nonce()), AddressRoleCreation)
+		}
+		if err == nil && types.IsMetaTransaction(tx.Data()) {
+			if meta, err := types.DecodeMetaData(tx.Data(), block.Number()); err == nil {
+				if sponsor, err := meta.ParseMetaData(tx.Nonce(), tx.GasPrice(), tx.Gas(), tx.To(), tx.Value(), meta.Payload, from, config.ChainID); err == nil {
+					add(sponsor, AddressRoleMetaSigner)
+				}
+			}
+		}
+		if tx.Type() == types.X402TxType {
+			var parties x402Parties
+			if err := rlp.DecodeBytes(tx.Data(), &parties); err == nil {
+				add(parties.From, AddressRoleX402Payer)
+				add(parties.To, AddressRoleX402Payee)
+			}
+		}
+		if i < len(transfers) {
+			for _, transfer := range transfers[i] {
+				add(transfer.From, AddressRoleTransferSender)
+				add(transfer.To, AddressRoleTransferRecipient)
+			}
+		}
+		for addr := range seen {
+			delete(seen, addr)
+		}
+	}
+	for _, entries := range activity {
+		sort.Slice(entries, func(i, j int) bool {
+			if entries[i].TxIndex != entries[j].TxIndex {
+				return entries[i].TxIndex < entries[j].TxIndex
+			}
+			return entries[i].Role < entries[j].Role
+		})
+	}
+	return activity
+}
+
+// AddressIndexer implements a core.ChainIndexer, indexing the transactions and
+// the internal transfers touching each address.
+type AddressIndexer struct {
+	db     ethdb.Database      // database instance to write index data into
+	config *params.ChainConfig // chain config to recover the senders with
+	batch  ethdb.Batch         // batch of the section being processed
+}
+
+// NewAddressIndexer returns a chain indexer that indexes the activity of the
+// addresses on the canonical chain.
+func NewAddressIndexer(db ethdb.Database, config *params.ChainConfig) *ChainIndexer {
+	backend := &AddressIndexer{
+		db:     db,
+		config: config,
+	}
+	table := rawdb.NewTable(db, string(rawdb.AddressIndexPrefix))
+
+	return NewChainIndexer(db, table, backend, AddressIndexSectionSize, AddressIndexConfirms, addressThrottling, "address")
+}
+
+// Reset implements core.ChainIndexerBackend, starting a new address index
+// section. The entries of the reorged blocks are left in place, they are told
+// apart by their block hash.
+func (a *AddressIndexer) Reset(ctx context.Context, section uint64, lastSectionHead common.Hash) error {
+	a.batch = a.db.NewBatch()
+	return nil
+}
+
+// Process implements core.ChainIndexerBackend, indexing the addresses touched
+// by the transactions of a block. Blocks without a body, e.g. the ones beyond the
+// history horizon, are skipped.
+func (a *AddressIndexer) Process(ctx context.Context, header *types.Header) error {
+	hash, number := header.Hash(), header.Number.Uint64()
+
+	body := rawdb.ReadBody(a.db, hash, number)
+	if body == nil {
+		return nil
+	}
+	block := types.NewBlockWithHeader(header).WithBody(body.Transactions, body.Uncles)
+	for addr, entries := range AddressActivity(a.config, block, rawdb.ReadBlockTransfers(a.db, hash, number)) {
+		rawdb.WriteAddressIndexEntries(a.batch, addr, entries)
+	}
+	if a.batch.ValueSize() > ethdb.IdealBatchSize {
+		if err := a.batch.Write(); err != nil {
+			return err
+		}
+		a.batch.Reset()
+	}
+	return nil
+}
+
+// Commit implements core.ChainIndexerBackend, writing out the remaining entries
+// of the section.
+func (a *AddressIndexer) Commit() error {
+	return a.batch.Write()
+}
+
+// Prune implements core.ChainIndexerBackend, deleting the entries of the blocks
+// below the threshold.
+func (a *AddressIndexer) Prune(threshold uint64) error {
+	return PruneAddressIndex(a.db, threshold)
+}
+
+// PruneAddressIndex deletes the address index entries of the blocks below the
+// threshold. The internal transfers of the blocks are kept.
+func PruneAddressIndex(db ethdb.Database, threshold uint64) error {
+	if threshold == 0 {
+		return nil
+	}
+	if tail := rawdb.ReadAddressIndexTail(db); tail != nil && *tail >= threshold {
+		return nil
+	}
+	start := time.Now()
+	deleted, err := rawdb.DeleteAddressIndex(db, threshold)
+	if err != nil {
+		return err
+	}
+	rawdb.WriteAddressIndexTail(db, threshold)
+	log.Info("Pruned address index", "tail", threshold, "entries", deleted, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// ResetAddressIndex deletes the address index along with the progress of its
+// indexer, so that it's rebuilt from the genesis the next time it's started.
+func ResetAddressIndex(db ethdb.Database) error {
+	start := time.Now()
+	deleted, err := rawdb.DeleteAddressIndex(db, 0)
+	if err != nil {
+		return err
+	}
+	batch := db.NewBatch()
+	it := db.NewIterator(rawdb.AddressIndexPrefix, nil)
+	for it.Next() {
+		batch.Delete(common.CopyBytes(it.Key()))
+	}
+	it.Release()
+	if err := it.Error(); err != nil {
+		return err
+	}
+	rawdb.DeleteAddressIndexTail(batch)
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	log.Info("Reset address index", "entries", deleted, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
@@ -0,0 +1,148 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package core
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// forwardCode returns the code of a contract forwarding the value it receives
+// to the given address, reverting afterwards if requested.
+func forwardCode(to common.Address, revert bool) []byte {
+	code := []byte{
+		byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0,
+		byte(vm.CALLVALUE), byte(vm.PUSH20),
+	}
+	code = append(code, to.Bytes()...)
+	code = append(code, byte(vm.GAS), byte(vm.CALL))
+	if revert {
+		return append(code, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.REVERT))
+	}
+	return append(code, byte(vm.STOP))
+}
+
+// Tests that the internal transfers are recorded while processing blocks and
+// indexed along with the transactions, skipping the ones of reverted calls.
+func TestAddressIndexer(t *testing.T) {
+	var (
+		key, _    = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		sender    = crypto.PubkeyToAddress(key.PublicKey)
+		payee     = common.HexToAddress("0xbbbb")
+		forwarder = common.HexToAddress("0xaaaa")
+		reverter  = common.HexToAddress("0xcccc")
+		db        = rawdb.NewMemoryDatabase()
+		gspec     = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc: GenesisAlloc{
+				sender:    {Balance: big.NewInt(params.Ether)},
+				forwarder: {Balance: common.Big0, Code: forwardCode(payee, false)},
+				reverter:  {Balance: common.Big0, Code: forwardCode(payee, true)},
+			},
+		}
+		genesis = gspec.MustCommit(db)
+		signer  = types.LatestSigner(gspec.Config)
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 2, func(i int, b *BlockGen) {
+		if i != 1 {
+			return
+		}
+		for _, to := range []common.Address{forwarder, reverter} {
+			tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(sender), to, big.NewInt(1000), 100000, b.BaseFee(), nil), signer, key)
+			b.AddTx(tx)
+		}
+		tx, _ := types.SignTx(types.NewContractCreation(b.TxNonce(sender), common.Big0, 100000, b.BaseFee(), []byte{byte(vm.STOP)}), signer, key)
+		b.AddTx(tx)
+	})
+	chain, err := NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{EnableTransferRecording: true}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	block := blocks[1]
+	transfers := rawdb.ReadBlockTransfers(db, block.Hash(), block.NumberU64())
+	if len(transfers) != 3 || len(transfers[0]) != 1 || len(transfers[1]) != 0 || len(transfers[2]) != 0 {
+		t.Fatalf("recorded transfers mismatch: %v", transfers)
+	}
+	if transfer := transfers[0][0]; transfer.From != forwarder || transfer.To != payee || transfer.Value.Cmp(big.NewInt(1000)) != 0 {
+		t.Fatalf("recorded transfer mismatch: %+v", transfer)
+	}
+	if transfers := rawdb.ReadBlockTransfers(db, blocks[0].Hash(), 1); transfers != nil {
+		t.Fatalf("transfers recorded for an empty block: %v", transfers)
+	}
+	// Index the chain and check the activity of every party
+	indexer := &AddressIndexer{db: db, config: gspec.Config}
+	if err := indexer.Reset(context.Background(), 0, common.Hash{}); err != nil {
+		t.Fatalf("failed to reset indexer: %v", err)
+	}
+	for _, block := range blocks {
+		if err := indexer.Process(context.Background(), block.Header()); err != nil {
+			t.Fatalf("failed to index block %d: %v", block.NumberU64(), err)
+		}
+	}
+	if err := indexer.Commit(); err != nil {
+		t.Fatalf("failed to commit index: %v", err)
+	}
+	created := crypto.CreateAddress(sender, 2)
+	for i, tt := range []struct {
+		addr  common.Address
+		txs   []uint32
+		roles []AddressRole
+	}{
+		{sender, []uint32{0, 1, 2}, []AddressRole{AddressRoleSender, AddressRoleSender, AddressRoleSender}},
+		{forwarder, []uint32{0, 0}, []AddressRole{AddressRoleRecipient, AddressRoleTransferSender}},
+		{payee, []uint32{0}, []AddressRole{AddressRoleTransferRecipient}},
+		{reverter, []uint32{1}, []AddressRole{AddressRoleRecipient}},
+		{created, []uint32{2}, []AddressRole{AddressRoleCreation}},
+	} {
+		var entries []rawdb.AddressIndexEntry
+		rawdb.IterateAddressIndex(db, tt.addr, 0, 0, func(entry rawdb.AddressIndexEntry) bool {
+			entries = append(entries, entry)
+			return true
+		})
+		if len(entries) != len(tt.txs) {
+			t.Fatalf("test %d: entry count mismatch: have %d, want %d", i, len(entries), len(tt.txs))
+		}
+		for j, entry := range entries {
+			if entry.Number != 2 || entry.Hash != block.Hash() || entry.TxIndex != tt.txs[j] || AddressRole(entry.Role) != tt.roles[j] {
+				t.Errorf("test %d, entry %d: have %d/%d/%s, want 2/%d/%s", i, j, entry.Number, entry.TxIndex, AddressRole(entry.Role), tt.txs[j], tt.roles[j])
+			}
+		}
+	}
+	// Prune the index and rebuild it
+	if err := PruneAddressIndex(db, 3); err != nil {
+		t.Fatalf("failed to prune index: %v", err)
+	}
+	if tail := rawdb.ReadAddressIndexTail(db); tail == nil || *tail != 3 {
+		t.Fatalf("index tail mismatch: have %v, want 3", tail)
+	}
+	rawdb.IterateAddressIndex(db, sender, 0, 0, func(entry rawdb.AddressIndexEntry) bool {
+		t.Fatalf("entry left after pruning: %v", entry)
+		return false
+	})
+	if err := ResetAddressIndex(db); err != nil {
+		t.Fatalf("failed to reset index: %v", err)
+	}
+	if tail := rawdb.ReadAddressIndexTail(db); tail != nil {
+		t.Fatalf("index tail left after reset: %d", *tail)
+	}
+}
@@ -131,6 +131,9 @@ type CacheConfig struct {
 	TrieTimeLimit       time.Duration // Time limit after which to flush the current in-memory trie to disk
 	SnapshotLimit       int           // Memory allowance (MB) to use for caching snapshot entries in memory
 	Preimages           bool          // Whether to store preimage of trie key to the disk
+	HistoryLimit        uint64        // Number of recent blocks to keep the bodies and receipts of (0 = entire chain)
+	StateScheme         string        // Scheme used to store the state trie nodes on disk
+	StateHistory        uint64        // Number of recent states that can be rolled back to with the path-based scheme (0 = disabled)
 
 	SnapshotWait bool // Wait for snapshot construction on startup. TODO(karalabe): This is a dirty hack for testing, nuke it
 }
@@ -234,9 +237,11 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, chainConfig *par
 		db:          db,
 		triegc:      prque.New(nil),
 		stateCache: state.NewDatabaseWithConfig(db, &trie.Config{
-			Cache:     cacheConfig.TrieCleanLimit,
-			Journal:   cacheConfig.TrieCleanJournal,
-			Preimages: cacheConfig.Preimages,
+			Cache:        cacheConfig.TrieCleanLimit,
+			Journal:      cacheConfig.TrieCleanJournal,
+			Preimages:    cacheConfig.Preimages,
+			Scheme:       cacheConfig.StateScheme,
+			StateHistory: cacheConfig.StateHistory,
 		}),
 		quit:           make(chan struct{}),
 		chainmu:        syncx.NewClosableMutex(),
@@ -250,6 +255,10 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, chainConfig *par
 		engine:         engine,
 		vmConfig:       vmConfig,
 	}
+	if bc.stateCache.TrieDB().Scheme() == rawdb.PathScheme && cacheConfig.TrieDirtyDisabled {
+		bc.stateCache.TrieDB().Close()
+		return nil, errors.New("archive mode is not supported by the path-based state scheme")
+	}
 	bc.validator = NewBlockValidator(chainConfig, bc, engine)
 	bc.prefetcher = newStatePrefetcher(chainConfig, bc, engine)
 	bc.processor = NewStateProcessor(chainConfig, bc, engine)
@@ -529,7 +538,7 @@ func (bc *BlockChain) setHeadBeyondRoot(head uint64, root common.Hash, repair bo
 					if root != (common.Hash{}) && !beyondRoot && newHeadBlock.Root() == root {
 						beyondRoot, rootNumber = true, newHeadBlock.NumberU64()
 					}
-					if _, err := state.New(newHeadBlock.Root(), bc.stateCache, bc.snaps); err != nil {
+					if _, err := state.New(newHeadBlock.Root(), bc.stateCache, bc.snaps); err != nil && !bc.recoverState(newHeadBlock.Root()) {
 						log.Trace("Block state missing, rewinding further", "number", newHeadBlock.NumberU64(), "hash", newHeadBlock.Hash())
 						if pivot == nil || newHeadBlock.NumberU64() > *pivot {
 							parent := bc.GetBlock(newHeadBlock.ParentHash(), newHeadBlock.NumberU64()-1)
@@ -632,6 +641,20 @@ func (bc *BlockChain) setHeadBeyondRoot(head uint64, root common.Hash, repair bo
 	return rootNumber, bc.loadLastState()
 }
 
+// recoverState rolls the persisted state back to the one with the given root,
+// if it's still covered by the state histories of the path-based scheme.
+func (bc *BlockChain) recoverState(root common.Hash) bool {
+	triedb := bc.stateCache.TrieDB()
+	if !triedb.Recoverable(root) {
+		return false
+	}
+	if err := triedb.Recover(root); err != nil {
+		log.Error("Failed to roll back state", "root", root, "err", err)
+		return false
+	}
+	return true
+}
+
 // FastSyncCommitHead sets the current head block to the one defined by the hash
 // irrelevant what the chain contents were prior.
 func (bc *BlockChain) FastSyncCommitHead(hash common.Hash) error {
@@ -807,7 +830,16 @@ func (bc *BlockChain) Stop() {
 	//  - HEAD:     So we don't need to reprocess any blocks in the general case
 	//  - HEAD-1:   So we don't do large reorgs if our HEAD becomes an uncle
 	//  - HEAD-127: So we have a hard limit on the number of blocks reexecuted
-	if !bc.cacheConfig.TrieDirtyDisabled {
+	if triedb := bc.stateCache.TrieDB(); triedb.Scheme() == rawdb.PathScheme {
+		// Only a single state is persisted with the path-based scheme, the older
+		// ones being reachable through the state histories.
+
+		recent := bc.CurrentBlock()
+		log.Info("Writing cached state to disk", "block", recent.Number(), "hash", recent.Hash(), "root", recent.Root())
+		if err := triedb.Commit(recent.Root(), true, nil); err != nil {
+			log.Error("Failed to commit recent state trie", "err", err)
+		}
+	} else if !bc.cacheConfig.TrieDirtyDisabled {
 		triedb := bc.stateCache.TrieDB()
 
 		for _, offset := range []uint64{0, 1, TriesInMemory - 1} {
@@ -839,6 +871,9 @@ func (bc *BlockChain) Stop() {
 		triedb := bc.stateCache.TrieDB()
 		triedb.SaveCache(bc.cacheConfig.TrieCleanJournal)
 	}
+	if err := bc.stateCache.TrieDB().Close(); err != nil {
+		log.Error("Failed to close trie database", "err", err)
+	}
 	log.Info("Blockchain stopped")
 }
 
@@ -1222,6 +1257,24 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 		rawdb.WriteTd(blockBatch, block.Hash(), block.NumberU64(), externTd)
 		rawdb.WriteBlock(blockBatch, block)
 		rawdb.WriteReceipts(blockBatch, block.Hash(), block.NumberU64(), receipts)
+		for _, receipt := range receipts {
+			if receipt.DeniedCreator != nil {
+				rawdb.WriteDeniedCreate(blockBatch, receipt.TxHash, block.Hash(), *receipt.DeniedCreator)
+			}
+		}
+		if bc.vmConfig.EnableTransferRecording {
+			var (
+				transfers = make([][]*types.InternalTransfer, len(receipts))
+				recorded  bool
+			)
+			for i, receipt := range receipts {
+				transfers[i] = state.GetTransfers(receipt.TxHash)
+				recorded = recorded || len(transfers[i]) > 0
+			}
+			if recorded {
+				rawdb.WriteBlockTransfers(blockBatch, block.Hash(), block.NumberU64(), transfers)
+			}
+		}
 		rawdb.WritePreimages(blockBatch, state.Preimages())
 		if err := blockBatch.Write(); err != nil {
 			log.Crit("Failed to write block into disk", "err", err)
@@ -1234,6 +1287,14 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 	blockHash := block.Header().Hash()
 	afterCommit := func(root common.Hash) {
 		triedb := bc.stateCache.TrieDB()
+		// With the path-based scheme, keep the recent states in memory and flatten
+		// the older ones into the persisted state
+		if triedb.Scheme() == rawdb.PathScheme {
+			if err := triedb.CapLayers(root, TriesInMemory); err != nil {
+				log.Error("Failed to cap trie layers", "number", blockNumber, "hash", blockHash, "err", err)
+			}
+			return
+		}
 		// If we're running an archive node, always flush
 		if bc.cacheConfig.TrieDirtyDisabled {
 			if err := triedb.Commit(root, false, nil); err != nil {
@@ -2129,13 +2190,14 @@ func (bc *BlockChain) maintainTxIndex(ancients uint64) {
 			}
 			return
 		}
-		// If a previous indexing existed, make sure that we fill in any missing entries
-    if bc.txLookupLimit == 0 || head < bc.txLookupLimit {
-        if *tail > 0 {
-            rawdb.IndexTransactions(bc.db, 0, head+1, bc.quit) 
-        }
-        return
-    }
+		// If a previous indexing existed, make sure that we fill in any missing entries,
+		// short of the blocks pruned by history expiry
+		if bc.txLookupLimit == 0 || head < bc.txLookupLimit {
+			if pruned, _ := bc.db.Tail(); *tail > pruned {
+				rawdb.IndexTransactions(bc.db, 0, head+1, bc.quit)
+			}
+			return
+		}
 		// Update the transaction index to the new chain state
 		if head-bc.txLookupLimit+1 < *tail {
 			// Reindex a part of missing indices and rewind index tail to HEAD-limit
@@ -2162,7 +2224,10 @@ func (bc *BlockChain) maintainTxIndex(ancients uint64) {
 		case head := <-headCh:
 			if done == nil {
 				done = make(chan struct{})
-				go indexBlocks(rawdb.ReadTxIndexTail(bc.db), head.Block.NumberU64(), done)
+				go func(head uint64) {
+					bc.pruneHistory(head)
+					indexBlocks(rawdb.ReadTxIndexTail(bc.db), head, done)
+				}(head.Block.NumberU64())
 			}
 		case <-done:
 			done = nil
@@ -2176,6 +2241,30 @@ func (bc *BlockChain) maintainTxIndex(ancients uint64) {
 	}
 }
 
+// pruneHistory drops the bodies and receipts of the ancient blocks beyond the
+// history limit, along with the transaction indices pointing into them.
+func (bc *BlockChain) pruneHistory(head uint64) {
+	limit := bc.cacheConfig.HistoryLimit
+	if limit == 0 || head < limit {
+		return
+	}
+	// Only the blocks moved into the ancient store can be pruned
+	tail := head - limit + 1
+	frozen, err := bc.db.Ancients()
+	if err != nil {
+		return
+	}
+	if tail > frozen {
+		tail = frozen
+	}
+	if pruned, err := bc.db.Tail(); err != nil || tail <= pruned {
+		return
+	}
+	if err := rawdb.PruneHistory(bc.db, tail, bc.quit); err != nil {
+		log.Error("Failed to prune block history", "tail", tail, "err", err)
+	}
+}
+
 // reportBlock logs a bad block error.
 func (bc *BlockChain) reportBlock(block *types.Block, receipts types.Receipts, err error) {
 	rawdb.WriteBadBlock(bc.db, block)
@@ -57,6 +57,19 @@ func NewBloomIndexer(db ethdb.Database, size, confirms uint64) *ChainIndexer {
 	return NewChainIndexer(db, table, backend, size, confirms, bloomThrottling, "bloombits")
 }
 
+// InitBloomIndexer marks the given number of leading bloombits sections as
+// processed, for databases lacking the chain segment they cover, e.g. the ones
+// bootstrapped from a state snapshot. The head is the hash of the last block of
+// the last of those sections.
+func InitBloomIndexer(db ethdb.Database, sections uint64, head common.Hash) {
+	if sections == 0 {
+		return
+	}
+	c := &ChainIndexer{indexDb: rawdb.NewTable(db, string(rawdb.BloomBitsIndexPrefix))}
+	c.setSectionHead(sections-1, head)
+	c.setValidSections(sections)
+}
+
 // Reset implements core.ChainIndexerBackend, starting a new bloombits index
 // section.
 func (b *BloomIndexer) Reset(ctx context.Context, section uint64, lastSectionHead common.Hash) error {
@@ -0,0 +1,98 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// ErrBundleTxReverted is returned if a transaction of a bundle reverted without
+// being allowed to.
+var ErrBundleTxReverted = errors.New("bundle transaction reverted")
+
+// BundleTxResult is the outcome of a transaction of a bundle.
+type BundleTxResult struct {
+	Tx      *types.Transaction
+	From    common.Address
+	Receipt *types.Receipt // nil if the transaction could not be applied
+	Err     error          // why the transaction discarded the bundle, if it did
+}
+
+// ApplyBundle applies the transactions of a bundle in order on a copy of the given
+// state, with indexes in the block starting at txIndex. The bundle applies as a
+// whole or not at all: if a transaction is invalid, rejected by the consensus
+// engine (if it's a PoSA one) or reverts without being allowed to, the error is returned along with the results
+// so far, and the state, gas pool and used gas are left untouched. Otherwise the
+// gas pool and used gas are updated and the resulting state is returned, which
+// the caller is expected to continue with.
+func ApplyBundle(config *params.ChainConfig, bc ChainContext, author *common.Address, gp *GasPool, statedb *state.StateDB, header *types.Header, bundle *types.Bundle, txIndex int, usedGas *uint64, cfg vm.Config, posa consensus.PoSA) (*state.StateDB, []*BundleTxResult, error) {
+	blockContext := NewEVMBlockContext(header, bc, author)
+	vmenv := vm.NewEVM(blockContext, vm.TxContext{}, statedb, config, cfg)
+	return ApplyBundleWithEVM(config, bc, author, gp, statedb, header, bundle, txIndex, usedGas, vmenv, posa)
+}
+
+// ApplyBundleWithEVM is like ApplyBundle, but runs the transactions on the given
+// EVM, allowing the caller to cancel a bundle run.
+func ApplyBundleWithEVM(config *params.ChainConfig, bc ChainContext, author *common.Address, gp *GasPool, statedb *state.StateDB, header *types.Header, bundle *types.Bundle, txIndex int, usedGas *uint64, evm *vm.EVM, posa consensus.PoSA) (*state.StateDB, []*BundleTxResult, error) {
+	// Transactions are finalised one by one, so the journal can't roll back the
+	// whole bundle, run it on a copy instead.
+	var (
+		work    = statedb.Copy()
+		gas     = *gp
+		used    = *usedGas
+		signer  = types.MakeSigner(config, header.Number)
+		results = make([]*BundleTxResult, 0, len(bundle.Txs))
+	)
+	// The extra validator of a PoSA engine checks against the state the bundle runs on
+	if posa != nil {
+		evm.Context.ExtraValidator = posa.CreateEvmExtraValidator(header, work)
+	}
+	fail := func(result *BundleTxResult, err error) (*state.StateDB, []*BundleTxResult, error) {
+		result.Err = err
+		return nil, append(results, result), fmt.Errorf("tx %d [%v]: %w", len(results), result.Tx.Hash().Hex(), err)
+	}
+	for i, tx := range bundle.Txs {
+		result := &BundleTxResult{Tx: tx}
+		from, err := types.Sender(signer, tx)
+		if err != nil {
+			return fail(result, err)
+		}
+		result.From = from
+		if posa != nil {
+			if err := posa.ValidateTx(from, tx, header, work); err != nil {
+				return fail(result, err)
+			}
+		}
+		msg, err := tx.AsMessage(signer, header.BaseFee)
+		if err != nil {
+			return fail(result, err)
+		}
+		work.Prepare(tx.Hash(), txIndex+i)
+
+		receipt, err := applyTransaction(msg, config, bc, author, &gas, work, header.Number, header.Hash(), tx, &used, evm)
+		if err != nil {
+			return fail(result, err)
+		}
+		result.Receipt = receipt
+		if receipt.Status == types.ReceiptStatusFailed && !bundle.CanRevert(tx.Hash()) {
+			return fail(result, ErrBundleTxReverted)
+		}
+		results = append(results, result)
+	}
+	*gp, *usedGas = gas, used
+	return work, results, nil
+}
@@ -0,0 +1,167 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package core
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that bundles apply all-or-nothing, unless the reverting transactions are
+// explicitly allowed to revert.
+func TestApplyBundle(t *testing.T) {
+	var (
+		config    = params.TestChainConfig
+		signer    = types.LatestSigner(config)
+		key, _    = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		sender    = crypto.PubkeyToAddress(key.PublicKey)
+		recipient = common.HexToAddress("0x1000")
+		reverter  = common.HexToAddress("0x2000")
+
+		db    = rawdb.NewMemoryDatabase()
+		gspec = &Genesis{
+			Config: config,
+			Alloc: GenesisAlloc{
+				sender:   {Balance: big.NewInt(params.Ether)},
+				reverter: {Balance: new(big.Int), Code: common.FromHex("0x60006000fd")}, // revert(0, 0)
+			},
+		}
+		genesis       = gspec.MustCommit(db)
+		blockchain, _ = NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil, nil)
+	)
+	defer blockchain.Stop()
+
+	header := &types.Header{
+		ParentHash: genesis.Hash(),
+		Number:     big.NewInt(1),
+		GasLimit:   genesis.GasLimit(),
+		Time:       genesis.Time() + 1,
+		Difficulty: big.NewInt(1),
+		BaseFee:    misc.CalcBaseFee(config, genesis.Header()),
+	}
+	makeTx := func(nonce uint64, to common.Address) *types.Transaction {
+		tx, _ := types.SignTx(types.NewTransaction(nonce, to, big.NewInt(1), 100000, header.BaseFee, nil), signer, key)
+		return tx
+	}
+	transfer, revert := makeTx(0, recipient), makeTx(1, reverter)
+
+	for i, tt := range []struct {
+		bundle  *types.Bundle
+		err     error
+		results int
+		nonce   uint64
+	}{
+		{&types.Bundle{Txs: types.Transactions{transfer}}, nil, 1, 1},
+		{&types.Bundle{Txs: types.Transactions{transfer, revert}}, ErrBundleTxReverted, 2, 0},
+		{&types.Bundle{Txs: types.Transactions{transfer, revert}, RevertingTxHashes: []common.Hash{revert.Hash()}}, nil, 2, 2},
+		{&types.Bundle{Txs: types.Transactions{revert}}, ErrNonceTooHigh, 1, 0},
+	} {
+		statedb, _ := blockchain.State()
+		var (
+			gp      = new(GasPool).AddGas(header.GasLimit)
+			usedGas uint64
+		)
+		result, results, err := ApplyBundle(config, blockchain, &common.Address{}, gp, statedb, header, tt.bundle, 0, &usedGas, vm.Config{}, nil)
+		if err == nil {
+			statedb = result
+		}
+		if !errors.Is(err, tt.err) {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+		if len(results) != tt.results {
+			t.Errorf("test %d: results mismatch: have %d, want %d", i, len(results), tt.results)
+		}
+		if nonce := statedb.GetNonce(sender); nonce != tt.nonce {
+			t.Errorf("test %d: nonce mismatch: have %d, want %d", i, nonce, tt.nonce)
+		}
+		if err != nil {
+			if usedGas != 0 || gp.Gas() != header.GasLimit {
+				t.Errorf("test %d: gas not rolled back: used %d, pool %d", i, usedGas, gp.Gas())
+			}
+			if balance := statedb.GetBalance(recipient); balance.Sign() != 0 {
+				t.Errorf("test %d: transfer not rolled back: balance %v", i, balance)
+			}
+		}
+	}
+}
+
+// Tests that the pool validates the target range of the bundles and only hands
+// out the ones eligible for a block.
+func TestTxPoolBundles(t *testing.T) {
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	for i, tt := range []struct {
+		min, max uint64
+		err      error
+	}{
+		{0, 0, ErrBundleRange},
+		{3, 2, ErrBundleRange},
+		{1, maxBundleRange + 1, ErrBundleRange},
+		{2, 3, nil},
+		{1, 5, nil},
+	} {
+		tx := transaction(uint64(i), 100000, key)
+		if err := pool.AddBundle(&types.Bundle{Txs: types.Transactions{tx}, MinBlock: tt.min, MaxBlock: tt.max}); !errors.Is(err, tt.err) {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+	if err := pool.AddBundle(&types.Bundle{Txs: types.Transactions{transaction(4, 100000, key)}, MaxBlock: 1}); !errors.Is(err, ErrAlreadyKnown) {
+		t.Errorf("duplicate bundle error mismatch: have %v, want %v", err, ErrAlreadyKnown)
+	}
+	if err := pool.AddBundle(&types.Bundle{MaxBlock: 1}); !errors.Is(err, ErrBundleEmpty) {
+		t.Errorf("empty bundle error mismatch: have %v, want %v", err, ErrBundleEmpty)
+	}
+	for _, tt := range []struct {
+		number  uint64
+		bundles int
+	}{
+		{1, 1}, {2, 2}, {4, 1}, {3, 1}, {6, 0},
+	} {
+		if bundles := pool.Bundles(tt.number); len(bundles) != tt.bundles {
+			t.Errorf("block %d bundles mismatch: have %d, want %d", tt.number, len(bundles), tt.bundles)
+		}
+	}
+}
+
+// Tests that the bundles which can't apply anymore are dropped on reset.
+func TestTxPoolBundlesReset(t *testing.T) {
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	included, pending := transaction(0, 100000, key), transaction(1, 100000, key)
+	for _, bundle := range []*types.Bundle{
+		{Txs: types.Transactions{included}, MinBlock: 1, MaxBlock: 2},
+		{Txs: types.Transactions{included, pending}, MinBlock: 1, MaxBlock: 2},
+		{Txs: types.Transactions{pending}, MinBlock: 1, MaxBlock: 2},
+	} {
+		if err := pool.AddBundle(bundle); err != nil {
+			t.Fatalf("failed to add bundle: %v", err)
+		}
+	}
+	pool.currentState.SetNonce(crypto.PubkeyToAddress(key.PublicKey), 1)
+	<-pool.requestReset(nil, nil)
+
+	if n := len(pool.bundles.bundles); n != 1 {
+		t.Fatalf("bundles mismatch: have %d, want 1", n)
+	}
+	if bundle := pool.bundles.bundles[0]; bundle.Txs[0] != pending {
+		t.Errorf("stale bundle kept: %v", bundle.Txs[0].Hash())
+	}
+}
@@ -25,6 +25,19 @@ import (
 // NewTxsEvent is posted when a batch of transactions enter the transaction pool.
 type NewTxsEvent struct{ Txs []*types.Transaction }
 
+// RejectedTxsEvent is posted when transactions are rejected before entering the
+// transaction pool, like the ones of blocklisted senders.
+type RejectedTxsEvent struct {
+	Txs    []*types.Transaction
+	Reason error
+}
+
+// PendingBlockEvent is posted when the miner recommits the pending block.
+type PendingBlockEvent struct {
+	Block    *types.Block
+	Receipts types.Receipts
+}
+
 // NewMinedBlockEvent is posted when a block has been imported.
 type NewMinedBlockEvent struct{ Block *types.Block }
 
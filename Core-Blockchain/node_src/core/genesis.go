@@ -180,8 +180,10 @@ func SetupGenesisBlockWithOverride(db ethdb.Database, genesis *Genesis, override
 	}
 	// We have the genesis block in database(perhaps in ancient database)
 	// but the corresponding state is missing.
+	// Note, only the most recent state is persisted with the path-based scheme,
+	// so the genesis one is expected to be missing once the chain progressed.
 	header := rawdb.ReadHeader(db, stored, 0)
-	if _, err := state.New(header.Root, state.NewDatabaseWithConfig(db, nil), nil); err != nil {
+	if _, err := state.New(header.Root, state.NewDatabaseWithConfig(db, nil), nil); err != nil && rawdb.ReadPersistentStateID(db) == 0 {
 		if genesis == nil {
 			genesis = DefaultGenesisBlock()
 		}
@@ -34,6 +34,10 @@ import (
 	"github.com/ethereum/go-ethereum/rlp"
 )
 
+// ErrHistoryPruned is returned when the body and receipts of a block have been
+// pruned from the ancient store by history expiry.
+var ErrHistoryPruned = errors.New("block history pruned")
+
 // ReadCanonicalHash retrieves the hash assigned to a canonical block number.
 func ReadCanonicalHash(db ethdb.Reader, number uint64) common.Hash {
 	var data []byte
@@ -388,6 +392,15 @@ func isCanon(reader ethdb.AncientReader, number uint64, hash common.Hash) bool {
 	return bytes.Equal(h, hash[:])
 }
 
+// HistoryPruned returns ErrHistoryPruned if the body and receipts of the ancient
+// block with the given number have been pruned by history expiry.
+func HistoryPruned(db ethdb.AncientReader, number uint64) error {
+	if tail, err := db.Tail(); err == nil && number < tail {
+		return ErrHistoryPruned
+	}
+	return nil
+}
+
 // ReadBodyRLP retrieves the block body (transactions and uncles) in RLP encoding.
 func ReadBodyRLP(db ethdb.Reader, hash common.Hash, number uint64) rlp.RawValue {
 	// First try to look up the data in ancient database. Extra hash
@@ -437,7 +450,7 @@ func WriteBodyRLP(db ethdb.KeyValueWriter, hash common.Hash, number uint64, rlp
 // HasBody verifies the existence of a block body corresponding to the hash.
 func HasBody(db ethdb.Reader, hash common.Hash, number uint64) bool {
 	if isCanon(db, number, hash) {
-		return true
+		return HistoryPruned(db, number) == nil
 	}
 	if has, err := db.Has(blockBodyKey(number, hash)); !has || err != nil {
 		return false
@@ -527,7 +540,7 @@ func DeleteTd(db ethdb.KeyValueWriter, hash common.Hash, number uint64) {
 // to a block.
 func HasReceipts(db ethdb.Reader, hash common.Hash, number uint64) bool {
 	if isCanon(db, number, hash) {
-		return true
+		return HistoryPruned(db, number) == nil
 	}
 	if has, err := db.Has(blockReceiptsKey(number, hash)); !has || err != nil {
 		return false
@@ -622,6 +635,40 @@ func DeleteReceipts(db ethdb.KeyValueWriter, hash common.Hash, number uint64) {
 	}
 }
 
+// ReadBlockTransfers retrieves the internal value transfers of the transactions
+// of a block, grouped by transaction.
+func ReadBlockTransfers(db ethdb.KeyValueReader, hash common.Hash, number uint64) [][]*types.InternalTransfer {
+	data, _ := db.Get(blockTransfersKey(number, hash))
+	if len(data) == 0 {
+		return nil
+	}
+	var transfers [][]*types.InternalTransfer
+	if err := rlp.DecodeBytes(data, &transfers); err != nil {
+		log.Error("Invalid block transfers RLP", "hash", hash, "err", err)
+		return nil
+	}
+	return transfers
+}
+
+// WriteBlockTransfers stores the internal value transfers of the transactions
+// of a block, grouped by transaction.
+func WriteBlockTransfers(db ethdb.KeyValueWriter, hash common.Hash, number uint64, transfers [][]*types.InternalTransfer) {
+	data, err := rlp.EncodeToBytes(transfers)
+	if err != nil {
+		log.Crit("Failed to encode block transfers", "err", err)
+	}
+	if err := db.Put(blockTransfersKey(number, hash), data); err != nil {
+		log.Crit("Failed to store block transfers", "err", err)
+	}
+}
+
+// DeleteBlockTransfers removes the internal value transfers of a block.
+func DeleteBlockTransfers(db ethdb.KeyValueWriter, hash common.Hash, number uint64) {
+	if err := db.Delete(blockTransfersKey(number, hash)); err != nil {
+		log.Crit("Failed to delete block transfers", "err", err)
+	}
+}
+
 // storedReceiptRLP is the storage encoding of a receipt.
 // Re-definition in core/types/receipt.go.
 type storedReceiptRLP struct {
@@ -794,6 +841,7 @@ func writeAncientBlock(op ethdb.AncientWriteOp, block *types.Block, header *type
 // DeleteBlock removes all block data associated with a hash.
 func DeleteBlock(db ethdb.KeyValueWriter, hash common.Hash, number uint64) {
 	DeleteReceipts(db, hash, number)
+	DeleteBlockTransfers(db, hash, number)
 	DeleteHeader(db, hash, number)
 	DeleteBody(db, hash, number)
 	DeleteTd(db, hash, number)
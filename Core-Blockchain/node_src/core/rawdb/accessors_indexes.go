@@ -19,6 +19,7 @@ package rawdb
 
 import (
 	"bytes"
+	"encoding/binary"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -94,6 +95,201 @@ func DeleteTxLookupEntries(db ethdb.KeyValueWriter, hashes []common.Hash) {
 	}
 }
 
+// ReadDeniedCreate retrieves the address whose contract creation was denied by the
+// deployment allowlist while executing the given transaction in the given block.
+func ReadDeniedCreate(db ethdb.KeyValueReader, txHash common.Hash, blockHash common.Hash) *common.Address {
+	data, _ := db.Get(deniedCreateKey(txHash))
+	if len(data) != common.HashLength+common.AddressLength || common.BytesToHash(data[:common.HashLength]) != blockHash {
+		return nil
+	}
+	addr := common.BytesToAddress(data[common.HashLength:])
+	return &addr
+}
+
+// WriteDeniedCreate stores the address whose contract creation was denied by the
+// deployment allowlist while executing the given transaction in the given block.
+func WriteDeniedCreate(db ethdb.KeyValueWriter, txHash common.Hash, blockHash common.Hash, addr common.Address) {
+	if err := db.Put(deniedCreateKey(txHash), append(blockHash.Bytes(), addr.Bytes()...)); err != nil {
+		log.Crit("Failed to store denied contract creation", "err", err)
+	}
+}
+
+// ReadTraceIndexHead retrieves the number and hash of the latest block whose
+// call traces have been indexed.
+func ReadTraceIndexHead(db ethdb.KeyValueReader) (*uint64, common.Hash) {
+	data, _ := db.Get(traceIndexHeadKey)
+	if len(data) != 8+common.HashLength {
+		return nil, common.Hash{}
+	}
+	number := binary.BigEndian.Uint64(data[:8])
+	return &number, common.BytesToHash(data[8:])
+}
+
+// WriteTraceIndexHead stores the number and hash of the latest block whose
+// call traces have been indexed.
+func WriteTraceIndexHead(db ethdb.KeyValueWriter, number uint64, hash common.Hash) {
+	if err := db.Put(traceIndexHeadKey, append(encodeBlockNumber(number), hash.Bytes()...)); err != nil {
+		log.Crit("Failed to store the trace index head", "err", err)
+	}
+}
+
+// ReadTraceIndexTail retrieves the number of the oldest block whose call traces
+// have been indexed.
+func ReadTraceIndexTail(db ethdb.KeyValueReader) *uint64 {
+	data, _ := db.Get(traceIndexTailKey)
+	if len(data) != 8 {
+		return nil
+	}
+	number := binary.BigEndian.Uint64(data)
+	return &number
+}
+
+// WriteTraceIndexTail stores the number of the oldest block whose call traces
+// have been indexed.
+func WriteTraceIndexTail(db ethdb.KeyValueWriter, number uint64) {
+	if err := db.Put(traceIndexTailKey, encodeBlockNumber(number)); err != nil {
+		log.Crit("Failed to store the trace index tail", "err", err)
+	}
+}
+
+// WriteTraceIndexEntries stores the addresses appearing in the call traces of
+// the given block.
+func WriteTraceIndexEntries(db ethdb.KeyValueWriter, number uint64, addrs []common.Address) {
+	for _, addr := range addrs {
+		if err := db.Put(traceIndexKey(addr, number), []byte{0x01}); err != nil {
+			log.Crit("Failed to store trace index entry", "err", err)
+		}
+	}
+}
+
+// ReadTraceIndexBlocks retrieves the numbers of the blocks in the [from, to]
+// range whose call traces involve the given address, in ascending order.
+func ReadTraceIndexBlocks(db ethdb.Iteratee, addr common.Address, from uint64, to uint64) []uint64 {
+	prefix := append(traceIndexPrefix, addr.Bytes()...)
+	it := db.NewIterator(prefix, encodeBlockNumber(from))
+	defer it.Release()
+
+	var numbers []uint64
+	for it.Next() {
+		if len(it.Key()) != len(prefix)+8 {
+			continue
+		}
+		number := binary.BigEndian.Uint64(it.Key()[len(prefix):])
+		if number > to {
+			break
+		}
+		numbers = append(numbers, number)
+	}
+	return numbers
+}
+
+// ReadAddressIndexTail retrieves the number of the oldest block whose transactions
+// are indexed by address, if the index was pruned.
+func ReadAddressIndexTail(db ethdb.KeyValueReader) *uint64 {
+	data, _ := db.Get(addressIndexTailKey)
+	if len(data) != 8 {
+		return nil
+	}
+	number := binary.BigEndian.Uint64(data)
+	return &number
+}
+
+// WriteAddressIndexTail stores the number of the oldest block whose transactions
+// are indexed by address.
+func WriteAddressIndexTail(db ethdb.KeyValueWriter, number uint64) {
+	if err := db.Put(addressIndexTailKey, encodeBlockNumber(number)); err != nil {
+		log.Crit("Failed to store the address index tail", "err", err)
+	}
+}
+
+// DeleteAddressIndexTail removes the address index tail.
+func DeleteAddressIndexTail(db ethdb.KeyValueWriter) {
+	if err := db.Delete(addressIndexTailKey); err != nil {
+		log.Crit("Failed to delete the address index tail", "err", err)
+	}
+}
+
+// AddressIndexEntry is a transaction touching an address, in a given role.
+type AddressIndexEntry struct {
+	Number  uint64      // Number of the block including the transaction
+	Hash    common.Hash // Hash of the block including the transaction
+	TxIndex uint32      // Index of the transaction in the block
+	Role    byte        // Role of the address in the transaction
+}
+
+// WriteAddressIndexEntries stores the given transactions touching an address.
+func WriteAddressIndexEntries(db ethdb.KeyValueWriter, addr common.Address, entries []AddressIndexEntry) {
+	for _, entry := range entries {
+		if err := db.Put(addressIndexKey(addr, entry.Number, entry.TxIndex, entry.Role), entry.Hash.Bytes()); err != nil {
+			log.Crit("Failed to store address index entry", "err", err)
+		}
+	}
+}
+
+// IterateAddressIndex iterates over the indexed transactions touching an address
+// in ascending order, starting at the given block and transaction index, until
+// the callback returns false. The block hashes of the entries aren't checked, it's
+// up to the callback to skip the ones of reorged blocks.
+func IterateAddressIndex(db ethdb.Iteratee, addr common.Address, number uint64, index uint32, fn func(entry AddressIndexEntry) bool) {
+	prefix := append(append([]byte{}, addressIndexPrefix...), addr.Bytes()...)
+	start := addressIndexKey(addr, number, index, 0)[len(prefix):]
+
+	it := db.NewIterator(prefix, start)
+	defer it.Release()
+
+	for it.Next() {
+		key := it.Key()
+		if len(key) != len(prefix)+8+4+1 || len(it.Value()) != common.HashLength {
+			continue
+		}
+		entry := AddressIndexEntry{
+			Number:  binary.BigEndian.Uint64(key[len(prefix):]),
+			Hash:    common.BytesToHash(it.Value()),
+			TxIndex: binary.BigEndian.Uint32(key[len(prefix)+8:]),
+			Role:    key[len(key)-1],
+		}
+		if !fn(entry) {
+			return
+		}
+	}
+}
+
+// DeleteAddressIndex removes the indexed transactions of the blocks below the
+// given threshold, or all of them if the threshold is zero. It returns the number
+// of deleted entries.
+func DeleteAddressIndex(db ethdb.Database, threshold uint64) (int, error) {
+	it := db.NewIterator(addressIndexPrefix, nil)
+	defer it.Release()
+
+	var (
+		batch   = db.NewBatch()
+		deleted int
+	)
+	for it.Next() {
+		key := it.Key()
+		if len(key) != len(addressIndexPrefix)+common.AddressLength+8+4+1 {
+			continue
+		}
+		if threshold > 0 && binary.BigEndian.Uint64(key[len(addressIndexPrefix)+common.AddressLength:]) >= threshold {
+			continue
+		}
+		if err := batch.Delete(key); err != nil {
+			return deleted, err
+		}
+		deleted++
+		if batch.ValueSize() > ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return deleted, err
+			}
+			batch.Reset()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return deleted, err
+	}
+	return deleted, batch.Write()
+}
+
 // ReadTransaction retrieves a specific transaction from the database, along with
 // its added positional metadata.
 func ReadTransaction(db ethdb.Reader, hash common.Hash) (*types.Transaction, common.Hash, uint64, uint64) {
@@ -178,3 +178,133 @@ func TestDeleteBloomBits(t *testing.T) {
 	check(1, 1, params.MainnetGenesisHash, true)
 	// check(1, 1, params.RinkebyGenesisHash, true)
 }
+
+func TestDeniedCreateStorage(t *testing.T) {
+	db := NewMemoryDatabase()
+	txHash, blockHash, deployer := common.Hash{0x01}, common.Hash{0x02}, common.Address{0x03}
+
+	if addr := ReadDeniedCreate(db, txHash, blockHash); addr != nil {
+		t.Fatalf("non existent denied create returned: %x", addr)
+	}
+	WriteDeniedCreate(db, txHash, blockHash, deployer)
+	if addr := ReadDeniedCreate(db, txHash, blockHash); addr == nil || *addr != deployer {
+		t.Fatalf("denied create mismatch: have %v, want %x", addr, deployer)
+	}
+	// Entries of a transaction re-included in another block must be ignored
+	if addr := ReadDeniedCreate(db, txHash, common.Hash{0x04}); addr != nil {
+		t.Fatalf("denied create of another block returned: %x", addr)
+	}
+}
+
+func TestTraceIndexStorage(t *testing.T) {
+	db := NewMemoryDatabase()
+	a, b := common.Address{0x01}, common.Address{0x02}
+
+	if head, _ := ReadTraceIndexHead(db); head != nil {
+		t.Fatalf("non existent trace index head returned: %d", *head)
+	}
+	WriteTraceIndexEntries(db, 1, []common.Address{a, b})
+	WriteTraceIndexEntries(db, 5, []common.Address{a})
+	WriteTraceIndexEntries(db, 256, []common.Address{a})
+	WriteTraceIndexHead(db, 256, common.Hash{0x03})
+	WriteTraceIndexTail(db, 1)
+
+	if head, hash := ReadTraceIndexHead(db); head == nil || *head != 256 || hash != (common.Hash{0x03}) {
+		t.Fatalf("trace index head mismatch: have %v %x, want 256 %x", head, hash, common.Hash{0x03})
+	}
+	if tail := ReadTraceIndexTail(db); tail == nil || *tail != 1 {
+		t.Fatalf("trace index tail mismatch: have %v, want 1", tail)
+	}
+	for i, tt := range []struct {
+		addr     common.Address
+		from, to uint64
+		want     []uint64
+	}{
+		{a, 0, 1000, []uint64{1, 5, 256}},
+		{a, 2, 255, []uint64{5}},
+		{a, 5, 5, []uint64{5}},
+		{b, 0, 1000, []uint64{1}},
+		{b, 2, 1000, nil},
+		{common.Address{0x04}, 0, 1000, nil},
+	} {
+		have := ReadTraceIndexBlocks(db, tt.addr, tt.from, tt.to)
+		if len(have) != len(tt.want) {
+			t.Fatalf("test %d: indexed blocks mismatch: have %v, want %v", i, have, tt.want)
+		}
+		for j := range have {
+			if have[j] != tt.want[j] {
+				t.Fatalf("test %d: indexed blocks mismatch: have %v, want %v", i, have, tt.want)
+			}
+		}
+	}
+}
+
+func TestAddressIndexStorage(t *testing.T) {
+	db := NewMemoryDatabase()
+	a, b := common.Address{0x01}, common.Address{0x02}
+
+	WriteAddressIndexEntries(db, a, []AddressIndexEntry{
+		{Number: 1, Hash: common.Hash{0x01}, TxIndex: 0, Role: 0},
+		{Number: 1, Hash: common.Hash{0x01}, TxIndex: 0, Role: 6},
+		{Number: 1, Hash: common.Hash{0x01}, TxIndex: 2, Role: 1},
+		{Number: 300, Hash: common.Hash{0x03}, TxIndex: 1, Role: 1},
+	})
+	WriteAddressIndexEntries(db, b, []AddressIndexEntry{{Number: 2, Hash: common.Hash{0x02}, TxIndex: 0, Role: 1}})
+
+	collect := func(addr common.Address, number uint64, index uint32) []AddressIndexEntry {
+		var entries []AddressIndexEntry
+		IterateAddressIndex(db, addr, number, index, func(entry AddressIndexEntry) bool {
+			entries = append(entries, entry)
+			return true
+		})
+		return entries
+	}
+	if have := collect(a, 0, 0); len(have) != 4 || have[1].Role != 6 || have[3].Number != 300 || have[3].Hash != (common.Hash{0x03}) {
+		t.Fatalf("address index mismatch: %v", have)
+	}
+	if have := collect(a, 1, 1); len(have) != 2 || have[0].TxIndex != 2 {
+		t.Fatalf("address index mismatch from position: %v", have)
+	}
+	if have := collect(b, 0, 0); len(have) != 1 || have[0].Number != 2 {
+		t.Fatalf("address index mismatch of other address: %v", have)
+	}
+	if deleted, err := DeleteAddressIndex(db, 2); err != nil || deleted != 3 {
+		t.Fatalf("failed to prune address index: %d deleted, %v", deleted, err)
+	}
+	if have := collect(a, 0, 0); len(have) != 1 || have[0].Number != 300 {
+		t.Fatalf("address index mismatch after pruning: %v", have)
+	}
+	if deleted, err := DeleteAddressIndex(db, 0); err != nil || deleted != 2 {
+		t.Fatalf("failed to delete address index: %d deleted, %v", deleted, err)
+	}
+	if have := collect(a, 0, 0); len(have) != 0 {
+		t.Fatalf("address index left after deletion: %v", have)
+	}
+}
+
+func TestBlockTransfersStorage(t *testing.T) {
+	db := NewMemoryDatabase()
+	hash := common.Hash{0x01}
+
+	if transfers := ReadBlockTransfers(db, hash, 1); transfers != nil {
+		t.Fatalf("non existent block transfers returned: %v", transfers)
+	}
+	transfers := [][]*types.InternalTransfer{
+		{{From: common.Address{0x01}, To: common.Address{0x02}, Value: big.NewInt(1)}},
+		nil,
+		{{From: common.Address{0x03}, To: common.Address{0x04}, Value: big.NewInt(2)}, {From: common.Address{0x04}, To: common.Address{0x05}, Value: big.NewInt(3)}},
+	}
+	WriteBlockTransfers(db, hash, 1, transfers)
+
+	have := ReadBlockTransfers(db, hash, 1)
+	if len(have) != 3 || len(have[0]) != 1 || len(have[1]) != 0 || len(have[2]) != 2 {
+		t.Fatalf("block transfers mismatch: %v", have)
+	}
+	if have[2][1].To != (common.Address{0x05}) || have[2][1].Value.Cmp(big.NewInt(3)) != 0 {
+		t.Fatalf("block transfer mismatch: %+v", have[2][1])
+	}
+	DeleteBlock(db, hash, 1)
+	if transfers := ReadBlockTransfers(db, hash, 1); transfers != nil {
+		t.Fatalf("deleted block transfers returned: %v", transfers)
+	}
+}
@@ -54,6 +54,21 @@ func WriteDatabaseVersion(db ethdb.KeyValueWriter, version uint64) {
 	}
 }
 
+// ReadDatabaseEngine retrieves the key-value store implementation the database
+// was created with, or an empty string for databases predating its recording.
+func ReadDatabaseEngine(db ethdb.KeyValueReader) string {
+	data, _ := db.Get(databaseEngineKey)
+	return string(data)
+}
+
+// WriteDatabaseEngine stores the key-value store implementation backing the
+// database.
+func WriteDatabaseEngine(db ethdb.KeyValueWriter, engine string) {
+	if err := db.Put(databaseEngineKey, []byte(engine)); err != nil {
+		log.Crit("Failed to store the database engine", "err", err)
+	}
+}
+
 // ReadChainConfig retrieves the consensus settings based on the given genesis hash.
 func ReadChainConfig(db ethdb.KeyValueReader, hash common.Hash) *params.ChainConfig {
 	data, _ := db.Get(configKey(hash))
@@ -139,3 +154,25 @@ func PopUncleanShutdownMarker(db ethdb.KeyValueStore) {
 		log.Warn("Failed to clear unclean-shutdown marker", "err", err)
 	}
 }
+
+// ReadTxPoolSnapshot retrieves the serialized transaction pool contents saved at
+// the last snapshot.
+func ReadTxPoolSnapshot(db ethdb.KeyValueReader) []byte {
+	data, _ := db.Get(txPoolSnapshotKey)
+	return data
+}
+
+// WriteTxPoolSnapshot stores the serialized transaction pool contents. The blob
+// is capped by the pool, it is expected to be max a few 10s of megabytes.
+func WriteTxPoolSnapshot(db ethdb.KeyValueWriter, snapshot []byte) {
+	if err := db.Put(txPoolSnapshotKey, snapshot); err != nil {
+		log.Crit("Failed to store transaction pool snapshot", "err", err)
+	}
+}
+
+// DeleteTxPoolSnapshot deletes the serialized transaction pool contents.
+func DeleteTxPoolSnapshot(db ethdb.KeyValueWriter) {
+	if err := db.Delete(txPoolSnapshotKey); err != nil {
+		log.Crit("Failed to remove transaction pool snapshot", "err", err)
+	}
+}
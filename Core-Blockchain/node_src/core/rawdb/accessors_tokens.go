@@ -0,0 +1,331 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package rawdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// TokenInfo is the statistics of an indexed token contract.
+type TokenInfo struct {
+	Standard  uint16 // Token standard of the first transfer seen (20, 721 or 1155)
+	Holders   uint64 // Number of addresses holding a nonzero balance
+	Transfers uint64 // Number of transfers logged by the contract
+}
+
+// TokenTransfer is a standard token transfer logged by a contract. Batch transfers
+// are split into one transfer per token id, told apart by the item index.
+type TokenTransfer struct {
+	Number   uint64 `rlp:"-"` // Number of the block logging the transfer
+	LogIndex uint32 `rlp:"-"` // Index of the log in the block
+	Item     uint32 `rlp:"-"` // Index of the token id in a batch transfer
+
+	BlockHash common.Hash    // Hash of the block logging the transfer
+	TxIndex   uint32         // Index of the transaction in the block
+	Standard  uint16         // Token standard of the transfer (20, 721 or 1155)
+	Token     common.Address // Contract logging the transfer
+	From      common.Address // Sender of the tokens, zero for mints
+	To        common.Address // Recipient of the tokens, zero for burns
+	ID        common.Hash    // Token id, zero for fungible tokens
+	Value     *big.Int       // Amount of tokens transferred
+}
+
+// TokenJournalEntry is the value of a token holdings key before a section of the
+// token index was applied, empty if the key didn't exist.
+type TokenJournalEntry struct {
+	Key  []byte
+	Prev []byte
+}
+
+// ReadTokenBalance retrieves the balance of a token id held by an address.
+func ReadTokenBalance(db ethdb.KeyValueReader, holder common.Address, token common.Address, id common.Hash) *big.Int {
+	data, _ := db.Get(tokenBalanceKey(holder, token, id))
+	return new(big.Int).SetBytes(data)
+}
+
+// WriteTokenBalance stores the balance of a token id held by an address, deleting
+// it if the balance is zero.
+func WriteTokenBalance(db ethdb.KeyValueWriter, holder common.Address, token common.Address, id common.Hash, balance *big.Int) {
+	var err error
+	if balance.Sign() == 0 {
+		err = db.Delete(tokenBalanceKey(holder, token, id))
+	} else {
+		err = db.Put(tokenBalanceKey(holder, token, id), balance.Bytes())
+	}
+	if err != nil {
+		log.Crit("Failed to store token balance", "err", err)
+	}
+}
+
+// IterateTokenBalances iterates over the nonzero token balances held by an
+// address, ordered by token and id, until the callback returns false.
+func IterateTokenBalances(db ethdb.Iteratee, holder common.Address, fn func(token common.Address, id common.Hash, balance *big.Int) bool) {
+	prefix := append(append([]byte{}, tokenBalancePrefix...), holder.Bytes()...)
+
+	it := db.NewIterator(prefix, nil)
+	defer it.Release()
+
+	for it.Next() {
+		key := it.Key()
+		if len(key) != len(prefix)+common.AddressLength+common.HashLength {
+			continue
+		}
+		token := common.BytesToAddress(key[len(prefix) : len(prefix)+common.AddressLength])
+		id := common.BytesToHash(key[len(prefix)+common.AddressLength:])
+		if !fn(token, id, new(big.Int).SetBytes(it.Value())) {
+			return
+		}
+	}
+}
+
+// ReadTokenHolderIDs retrieves the number of ids of a token with a nonzero
+// balance held by an address.
+func ReadTokenHolderIDs(db ethdb.KeyValueReader, token common.Address, holder common.Address) uint64 {
+	data, _ := db.Get(tokenHolderKey(token, holder))
+	if len(data) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data)
+}
+
+// WriteTokenHolderIDs stores the number of ids of a token with a nonzero balance
+// held by an address, deleting it if the address holds none.
+func WriteTokenHolderIDs(db ethdb.KeyValueWriter, token common.Address, holder common.Address, ids uint64) {
+	var err error
+	if ids == 0 {
+		err = db.Delete(tokenHolderKey(token, holder))
+	} else {
+		err = db.Put(tokenHolderKey(token, holder), encodeBlockNumber(ids))
+	}
+	if err != nil {
+		log.Crit("Failed to store token holder", "err", err)
+	}
+}
+
+// ReadTokenInfo retrieves the statistics of a token contract, nil if none of its
+// transfers were indexed.
+func ReadTokenInfo(db ethdb.KeyValueReader, token common.Address) *TokenInfo {
+	data, _ := db.Get(tokenInfoKey(token))
+	if len(data) == 0 {
+		return nil
+	}
+	info := new(TokenInfo)
+	if err := rlp.DecodeBytes(data, info); err != nil {
+		log.Error("Invalid token info RLP", "token", token, "err", err)
+		return nil
+	}
+	return info
+}
+
+// WriteTokenInfo stores the statistics of a token contract.
+func WriteTokenInfo(db ethdb.KeyValueWriter, token common.Address, info *TokenInfo) {
+	data, err := rlp.EncodeToBytes(info)
+	if err != nil {
+		log.Crit("Failed to encode token info", "err", err)
+	}
+	if err := db.Put(tokenInfoKey(token), data); err != nil {
+		log.Crit("Failed to store token info", "err", err)
+	}
+}
+
+// WriteTokenTransfer stores a token transfer in the history of both parties and
+// in the one of the token contract.
+func WriteTokenTransfer(db ethdb.KeyValueWriter, transfer *TokenTransfer) {
+	data, err := rlp.EncodeToBytes(transfer)
+	if err != nil {
+		log.Crit("Failed to encode token transfer", "err", err)
+	}
+	keys := [][]byte{tokenTransferKey(tokenTransferPrefix, transfer.Token, transfer.Number, transfer.LogIndex, transfer.Item)}
+	if transfer.From != (common.Address{}) {
+		keys = append(keys, tokenTransferKey(holderTransferPrefix, transfer.From, transfer.Number, transfer.LogIndex, transfer.Item))
+	}
+	if transfer.To != (common.Address{}) && transfer.To != transfer.From {
+		keys = append(keys, tokenTransferKey(holderTransferPrefix, transfer.To, transfer.Number, transfer.LogIndex, transfer.Item))
+	}
+	for _, key := range keys {
+		if err := db.Put(key, data); err != nil {
+			log.Crit("Failed to store token transfer", "err", err)
+		}
+	}
+}
+
+// IterateHolderTokenTransfers iterates over the indexed token transfers sent or
+// received by an address in ascending order, starting at the given position, until
+// the callback returns false. The block hashes of the transfers aren't checked, it's
+// up to the callback to skip the ones of reorged blocks.
+func IterateHolderTokenTransfers(db ethdb.Iteratee, holder common.Address, number uint64, logIndex uint32, item uint32, fn func(transfer *TokenTransfer) bool) {
+	iterateTokenTransfers(db, holderTransferPrefix, holder, number, logIndex, item, fn)
+}
+
+// IterateTokenTransfers iterates over the indexed transfers logged by a token
+// contract in ascending order, starting at the given position, until the callback
+// returns false. The block hashes of the transfers aren't checked either.
+func IterateTokenTransfers(db ethdb.Iteratee, token common.Address, number uint64, logIndex uint32, item uint32, fn func(transfer *TokenTransfer) bool) {
+	iterateTokenTransfers(db, tokenTransferPrefix, token, number, logIndex, item, fn)
+}
+
+func iterateTokenTransfers(db ethdb.Iteratee, table []byte, addr common.Address, number uint64, logIndex uint32, item uint32, fn func(transfer *TokenTransfer) bool) {
+	prefix := append(append([]byte{}, table...), addr.Bytes()...)
+	start := tokenTransferKey(table, addr, number, logIndex, item)[len(prefix):]
+
+	it := db.NewIterator(prefix, start)
+	defer it.Release()
+
+	for it.Next() {
+		key := it.Key()
+		if len(key) != len(prefix)+8+4+4 {
+			continue
+		}
+		transfer := new(TokenTransfer)
+		if err := rlp.DecodeBytes(it.Value(), transfer); err != nil {
+			log.Error("Invalid token transfer RLP", "key", key, "err", err)
+			continue
+		}
+		transfer.Number = binary.BigEndian.Uint64(key[len(prefix):])
+		transfer.LogIndex = binary.BigEndian.Uint32(key[len(prefix)+8:])
+		transfer.Item = binary.BigEndian.Uint32(key[len(prefix)+12:])
+		if !fn(transfer) {
+			return
+		}
+	}
+}
+
+// DeleteTokenTransfers removes the token transfers of the blocks below the given
+// threshold from the histories of the holders and the token contracts. It returns
+// the number of deleted entries.
+func DeleteTokenTransfers(db ethdb.Database, threshold uint64) (int, error) {
+	var (
+		batch   = db.NewBatch()
+		deleted int
+	)
+	for _, table := range [][]byte{holderTransferPrefix, tokenTransferPrefix} {
+		it := db.NewIterator(table, nil)
+		for it.Next() {
+			key := it.Key()
+			if len(key) != len(table)+common.AddressLength+8+4+4 {
+				continue
+			}
+			if binary.BigEndian.Uint64(key[len(table)+common.AddressLength:]) >= threshold {
+				continue
+			}
+			if err := batch.Delete(key); err != nil {
+				it.Release()
+				return deleted, err
+			}
+			deleted++
+			if batch.ValueSize() > ethdb.IdealBatchSize {
+				if err := batch.Write(); err != nil {
+					it.Release()
+					return deleted, err
+				}
+				batch.Reset()
+			}
+		}
+		it.Release()
+		if err := it.Error(); err != nil {
+			return deleted, err
+		}
+	}
+	return deleted, batch.Write()
+}
+
+// ReadTokenJournal retrieves the previous values of the token holdings changed
+// by a section of the token index, nil if the journal isn't available.
+func ReadTokenJournal(db ethdb.KeyValueReader, section uint64) []TokenJournalEntry {
+	data, _ := db.Get(tokenJournalKey(section))
+	if len(data) == 0 {
+		return nil
+	}
+	var journal []TokenJournalEntry
+	if err := rlp.DecodeBytes(data, &journal); err != nil {
+		log.Error("Invalid token journal RLP", "section", section, "err", err)
+		return nil
+	}
+	return journal
+}
+
+// WriteTokenJournal stores the previous values of the token holdings changed by
+// a section of the token index.
+func WriteTokenJournal(db ethdb.KeyValueWriter, section uint64, journal []TokenJournalEntry) {
+	if journal == nil {
+		journal = []TokenJournalEntry{}
+	}
+	data, err := rlp.EncodeToBytes(journal)
+	if err != nil {
+		log.Crit("Failed to encode token journal", "err", err)
+	}
+	if err := db.Put(tokenJournalKey(section), data); err != nil {
+		log.Crit("Failed to store token journal", "err", err)
+	}
+}
+
+// DeleteTokenJournal removes the journal of a section of the token index.
+func DeleteTokenJournal(db ethdb.KeyValueWriter, section uint64) {
+	if err := db.Delete(tokenJournalKey(section)); err != nil {
+		log.Crit("Failed to delete token journal", "err", err)
+	}
+}
+
+// RevertTokenJournal restores the token holdings changed by a section of the
+// token index to their previous values.
+func RevertTokenJournal(db ethdb.KeyValueWriter, journal []TokenJournalEntry) {
+	for _, entry := range journal {
+		if !isTokenHoldingsKey(entry.Key) {
+			log.Crit("Invalid token journal key", "key", entry.Key)
+		}
+		var err error
+		if len(entry.Prev) == 0 {
+			err = db.Delete(entry.Key)
+		} else {
+			err = db.Put(entry.Key, entry.Prev)
+		}
+		if err != nil {
+			log.Crit("Failed to revert token holdings", "err", err)
+		}
+	}
+}
+
+// isTokenHoldingsKey reports whether a key belongs to the token holdings tables
+// reverted by the journals.
+func isTokenHoldingsKey(key []byte) bool {
+	switch {
+	case bytes.HasPrefix(key, tokenBalancePrefix):
+		return len(key) == len(tokenBalancePrefix)+2*common.AddressLength+common.HashLength
+	case bytes.HasPrefix(key, tokenHolderPrefix):
+		return len(key) == len(tokenHolderPrefix)+2*common.AddressLength
+	case bytes.HasPrefix(key, tokenInfoPrefix):
+		return len(key) == len(tokenInfoPrefix)+common.AddressLength
+	}
+	return false
+}
+
+// ReadTokenIndexApplied retrieves the number of token index sections whose
+// balance changes are applied to the token holdings.
+func ReadTokenIndexApplied(db ethdb.KeyValueReader) uint64 {
+	data, _ := db.Get(tokenIndexAppliedKey)
+	if len(data) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data)
+}
+
+// WriteTokenIndexApplied stores the number of token index sections whose
+// balance changes are applied to the token holdings.
+func WriteTokenIndexApplied(db ethdb.KeyValueWriter, sections uint64) {
+	if err := db.Put(tokenIndexAppliedKey, encodeBlockNumber(sections)); err != nil {
+		log.Crit("Failed to store token index progress", "err", err)
+	}
+}
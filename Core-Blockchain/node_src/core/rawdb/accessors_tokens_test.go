@@ -0,0 +1,68 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package rawdb
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Tests that token transfers are stored in the histories of both parties and of
+// the token, iterated from a position and pruned below a block.
+func TestTokenTransferStorage(t *testing.T) {
+	var (
+		db    = NewMemoryDatabase()
+		token = common.HexToAddress("0x2020")
+		alice = common.HexToAddress("0xaaaa")
+		bob   = common.HexToAddress("0xbbbb")
+	)
+	for number := uint64(1); number <= 3; number++ {
+		WriteTokenTransfer(db, &TokenTransfer{Number: number, LogIndex: 1, Item: 0, Token: token, From: alice, To: bob, Value: big.NewInt(int64(number))})
+	}
+	WriteTokenTransfer(db, &TokenTransfer{Number: 2, LogIndex: 0, Item: 1, Token: token, To: alice, Value: big.NewInt(10)})
+
+	for i, tt := range []struct {
+		holder   bool
+		addr     common.Address
+		number   uint64
+		logIndex uint32
+		want     []uint64 // number*100 + log index*10 + item
+	}{
+		{true, alice, 0, 0, []uint64{110, 201, 210, 310}},
+		{true, bob, 0, 0, []uint64{110, 210, 310}},
+		{true, alice, 2, 1, []uint64{210, 310}},
+		{false, token, 0, 0, []uint64{110, 201, 210, 310}},
+	} {
+		var have []uint64
+		fn := func(transfer *TokenTransfer) bool {
+			have = append(have, transfer.Number*100+uint64(transfer.LogIndex)*10+uint64(transfer.Item))
+			return true
+		}
+		if tt.holder {
+			IterateHolderTokenTransfers(db, tt.addr, tt.number, tt.logIndex, 0, fn)
+		} else {
+			IterateTokenTransfers(db, tt.addr, tt.number, tt.logIndex, 0, fn)
+		}
+		if !reflect.DeepEqual(have, tt.want) {
+			t.Errorf("test %d: transfers mismatch: have %v, want %v", i, have, tt.want)
+		}
+	}
+	if deleted, err := DeleteTokenTransfers(db, 3); err != nil || deleted != 8 {
+		t.Fatalf("pruning mismatch: deleted %d, err %v", deleted, err)
+	}
+	IterateTokenTransfers(db, token, 0, 0, 0, func(transfer *TokenTransfer) bool {
+		if transfer.Number < 3 {
+			t.Errorf("transfer of block %d left after pruning", transfer.Number)
+		}
+		return true
+	})
+}
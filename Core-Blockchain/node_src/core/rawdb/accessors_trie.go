@@ -0,0 +1,190 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package rawdb
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// The schemes used to store the trie nodes of the state.
+const (
+	// HashScheme stores every trie node keyed by its hash. Nodes are shared
+	// between states, so stale ones can only be removed by offline pruning.
+	HashScheme = "hash"
+
+	// PathScheme stores every trie node keyed by its owner and path within the
+	// trie, overwriting it in place whenever it changes. Only the state of a
+	// single block is persisted, older ones being reachable through the reverse
+	// diffs kept in the state history freezer.
+	PathScheme = "path"
+)
+
+// ReadStateScheme retrieves the scheme used to store the state of the database.
+// A database with chain data but no recorded scheme predates the path-based
+// scheme, an empty string is returned for a fresh one.
+func ReadStateScheme(db ethdb.KeyValueReader) string {
+	if data, _ := db.Get(stateSchemeKey); len(data) > 0 {
+		return string(data)
+	}
+	if ReadHeadBlockHash(db) != (common.Hash{}) {
+		return HashScheme
+	}
+	return ""
+}
+
+// IsPathScheme reports whether the database stores its state with the path-based
+// scheme. It's cheap for legacy databases, which lack any recorded scheme.
+func IsPathScheme(db ethdb.KeyValueReader) bool {
+	if ok, _ := db.Has(stateSchemeKey); !ok {
+		return false
+	}
+	return ReadStateScheme(db) == PathScheme
+}
+
+// WriteStateScheme stores the scheme used to store the state of the database.
+func WriteStateScheme(db ethdb.KeyValueWriter, scheme string) {
+	if err := db.Put(stateSchemeKey, []byte(scheme)); err != nil {
+		log.Crit("Failed to store state scheme", "err", err)
+	}
+}
+
+// ParseStateScheme checks the provided state scheme against the one already in
+// use by the database, returning the scheme to use. The hash scheme is the
+// default one for both fresh databases and legacy ones.
+func ParseStateScheme(provided string, db ethdb.KeyValueReader) (string, error) {
+	if provided != "" && provided != HashScheme && provided != PathScheme {
+		return "", fmt.Errorf("unknown state scheme %q", provided)
+	}
+	stored := ReadStateScheme(db)
+	switch {
+	case provided == "" && stored == "":
+		return HashScheme, nil
+	case provided == "":
+		return stored, nil
+	case stored == "" || stored == provided:
+		return provided, nil
+	}
+	return "", fmt.Errorf("incompatible state scheme, stored: %s, provided: %s", stored, provided)
+}
+
+// ReadAccountTrieNode retrieves the account trie node stored at the given path.
+func ReadAccountTrieNode(db ethdb.KeyValueReader, path []byte) []byte {
+	data, _ := db.Get(accountTrieNodeKey(path))
+	return data
+}
+
+// WriteAccountTrieNode writes the account trie node stored at the given path.
+func WriteAccountTrieNode(db ethdb.KeyValueWriter, path []byte, node []byte) {
+	if err := db.Put(accountTrieNodeKey(path), node); err != nil {
+		log.Crit("Failed to store account trie node", "err", err)
+	}
+}
+
+// DeleteAccountTrieNode deletes the account trie node stored at the given path.
+func DeleteAccountTrieNode(db ethdb.KeyValueWriter, path []byte) {
+	if err := db.Delete(accountTrieNodeKey(path)); err != nil {
+		log.Crit("Failed to delete account trie node", "err", err)
+	}
+}
+
+// ReadStorageTrieNode retrieves the storage trie node of the given account
+// stored at the given path.
+func ReadStorageTrieNode(db ethdb.KeyValueReader, accountHash common.Hash, path []byte) []byte {
+	data, _ := db.Get(storageTrieNodeKey(accountHash, path))
+	return data
+}
+
+// WriteStorageTrieNode writes the storage trie node of the given account
+// stored at the given path.
+func WriteStorageTrieNode(db ethdb.KeyValueWriter, accountHash common.Hash, path []byte, node []byte) {
+	if err := db.Put(storageTrieNodeKey(accountHash, path), node); err != nil {
+		log.Crit("Failed to store storage trie node", "err", err)
+	}
+}
+
+// DeleteStorageTrieNode deletes the storage trie node of the given account
+// stored at the given path.
+func DeleteStorageTrieNode(db ethdb.KeyValueWriter, accountHash common.Hash, path []byte) {
+	if err := db.Delete(storageTrieNodeKey(accountHash, path)); err != nil {
+		log.Crit("Failed to delete storage trie node", "err", err)
+	}
+}
+
+// ReadPersistentStateID retrieves the id of the state persisted by the
+// path-based trie database, zero if there is none.
+func ReadPersistentStateID(db ethdb.KeyValueReader) uint64 {
+	data, _ := db.Get(persistentStateIDKey)
+	if len(data) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data)
+}
+
+// WritePersistentStateID stores the id of the state persisted by the
+// path-based trie database.
+func WritePersistentStateID(db ethdb.KeyValueWriter, id uint64) {
+	if err := db.Put(persistentStateIDKey, encodeBlockNumber(id)); err != nil {
+		log.Crit("Failed to store the persistent state ID", "err", err)
+	}
+}
+
+// ReadStateID retrieves the id of the state with the given root.
+func ReadStateID(db ethdb.KeyValueReader, root common.Hash) *uint64 {
+	data, _ := db.Get(stateIDKey(root))
+	if len(data) != 8 {
+		return nil
+	}
+	id := binary.BigEndian.Uint64(data)
+	return &id
+}
+
+// WriteStateID stores the id of the state with the given root.
+func WriteStateID(db ethdb.KeyValueWriter, root common.Hash, id uint64) {
+	if err := db.Put(stateIDKey(root), encodeBlockNumber(id)); err != nil {
+		log.Crit("Failed to store state ID", "err", err)
+	}
+}
+
+// DeleteStateID deletes the id of the state with the given root.
+func DeleteStateID(db ethdb.KeyValueWriter, root common.Hash) {
+	if err := db.Delete(stateIDKey(root)); err != nil {
+		log.Crit("Failed to delete state ID", "err", err)
+	}
+}
+
+// ReadStateHistory retrieves the metadata and the original trie nodes of the
+// state history with the given id. History ids start at one.
+func ReadStateHistory(db ethdb.AncientReader, id uint64) ([]byte, []byte, error) {
+	meta, err := db.Ancient(stateHistoryMeta, id-1)
+	if err != nil {
+		return nil, nil, err
+	}
+	nodes, err := db.Ancient(stateHistoryNodes, id-1)
+	if err != nil {
+		return nil, nil, err
+	}
+	return meta, nodes, nil
+}
+
+// WriteStateHistory appends the metadata and the original trie nodes of the
+// state history with the given id.
+func WriteStateHistory(db ethdb.AncientWriter, id uint64, meta []byte, nodes []byte) error {
+	_, err := db.ModifyAncients(func(op ethdb.AncientWriteOp) error {
+		if err := op.AppendRaw(stateHistoryMeta, id-1, meta); err != nil {
+			return err
+		}
+		return op.AppendRaw(stateHistoryNodes, id-1, nodes)
+	})
+	return err
+}
@@ -18,6 +18,8 @@
 package rawdb
 
 import (
+	"errors"
+	"fmt"
 	"runtime"
 	"sync/atomic"
 	"time"
@@ -92,15 +94,19 @@ type blockTxHashes struct {
 // iterateTransactions iterates over all transactions in the (canon) block
 // number(s) given, and yields the hashes on a channel. If there is a signal
 // received from interrupt channel, the iteration will be aborted and result
-// channel will be closed.
-func iterateTransactions(db ethdb.Database, from uint64, to uint64, reverse bool, interrupt chan struct{}) chan *blockTxHashes {
+// channel will be closed. ErrHistoryPruned is returned if the bodies of some
+// of the blocks have been pruned.
+func iterateTransactions(db ethdb.Database, from uint64, to uint64, reverse bool, interrupt chan struct{}) (chan *blockTxHashes, error) {
 	// One thread sequentially reads data from db
 	type numberRlp struct {
 		number uint64
 		rlp    rlp.RawValue
 	}
 	if to == from {
-		return nil
+		return nil, nil
+	}
+	if err := HistoryPruned(db, from); err != nil {
+		return nil, err
 	}
 	threads := to - from
 	if cpus := runtime.NumCPU(); threads > uint64(cpus) {
@@ -167,7 +173,7 @@ func iterateTransactions(db ethdb.Database, from uint64, to uint64, reverse bool
 	for i := 0; i < int(threads); i++ {
 		go process()
 	}
-	return hashesCh
+	return hashesCh, nil
 }
 
 // indexTransactions creates txlookup indices of the specified block range.
@@ -179,15 +185,23 @@ func iterateTransactions(db ethdb.Database, from uint64, to uint64, reverse bool
 // There is a passed channel, the whole procedure will be interrupted if any
 // signal received.
 func indexTransactions(db ethdb.Database, from uint64, to uint64, interrupt chan struct{}, hook func(uint64) bool) {
+	// The transactions of blocks pruned by history expiry cannot be indexed
+	if tail, err := db.Tail(); err == nil && from < tail {
+		from = tail
+	}
 	// short circuit for invalid range
 	if from >= to {
 		return
 	}
+	hashesCh, err := iterateTransactions(db, from, to, true, interrupt)
+	if err != nil {
+		log.Error("Failed to iterate transactions", "from", from, "to", to, "err", err)
+		return
+	}
 	var (
-		hashesCh = iterateTransactions(db, from, to, true, interrupt)
-		batch    = db.NewBatch()
-		start    = time.Now()
-		logged   = start.Add(-7 * time.Second)
+		batch  = db.NewBatch()
+		start  = time.Now()
+		logged = start.Add(-7 * time.Second)
 		// Since we iterate in reverse, we expect the first number to come
 		// in to be [to-1]. Therefore, setting lastNum to means that the
 		// prqueue gap-evaluation will work correctly
@@ -274,11 +288,22 @@ func unindexTransactions(db ethdb.Database, from uint64, to uint64, interrupt ch
 	if from >= to {
 		return
 	}
+	// The indices of blocks pruned by history expiry were removed along with them
+	if tail, err := db.Tail(); err == nil && from < tail {
+		if from = tail; from >= to {
+			WriteTxIndexTail(db, to)
+			return
+		}
+	}
+	hashesCh, err := iterateTransactions(db, from, to, false, interrupt)
+	if err != nil {
+		log.Error("Failed to iterate transactions", "from", from, "to", to, "err", err)
+		return
+	}
 	var (
-		hashesCh = iterateTransactions(db, from, to, false, interrupt)
-		batch    = db.NewBatch()
-		start    = time.Now()
-		logged   = start.Add(-7 * time.Second)
+		batch  = db.NewBatch()
+		start  = time.Now()
+		logged = start.Add(-7 * time.Second)
 		// we expect the first number to come in to be [from]. Therefore, setting
 		// nextNum to from means that the prqueue gap-evaluation will work correctly
 		nextNum = from
@@ -351,3 +376,40 @@ func UnindexTransactions(db ethdb.Database, from uint64, to uint64, interrupt ch
 func unindexTransactionsForTesting(db ethdb.Database, from uint64, to uint64, interrupt chan struct{}, hook func(uint64) bool) {
 	unindexTransactions(db, from, to, interrupt, hook)
 }
+
+// PruneHistory discards the bodies and receipts of the ancient blocks below the
+// given number, removing the transaction indices pointing into them first.
+func PruneHistory(db ethdb.Database, tail uint64, interrupt chan struct{}) error {
+	frozen, err := db.Ancients()
+	if err != nil {
+		return err
+	}
+	if tail > frozen {
+		return fmt.Errorf("history tail %d beyond the ancient blocks %d", tail, frozen)
+	}
+	pruned, err := db.Tail()
+	if err != nil {
+		return err
+	}
+	if tail <= pruned {
+		return nil
+	}
+	// Remove the transaction indices of the pruned blocks while their bodies
+	// are still available. A missing index tail means all blocks are indexed.
+	var indexed uint64
+	if txTail := ReadTxIndexTail(db); txTail != nil {
+		indexed = *txTail
+	}
+	if indexed < tail {
+		unindexTransactions(db, indexed, tail, interrupt, nil)
+		if txTail := ReadTxIndexTail(db); txTail == nil || *txTail < tail {
+			return errors.New("transaction unindexing interrupted")
+		}
+	}
+	start := time.Now()
+	if err := db.TruncateTail(tail); err != nil {
+		return err
+	}
+	log.Info("Pruned block history", "tail", tail, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
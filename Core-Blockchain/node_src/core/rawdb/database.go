@@ -22,6 +22,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"sync/atomic"
 	"time"
 
@@ -75,6 +76,21 @@ func (frdb *freezerdb) Freeze(threshold uint64) error {
 	return nil
 }
 
+// InitAncientTail makes the empty ancient store of the database start at the
+// given block, for databases lacking the chain segment before it, e.g. the ones
+// bootstrapped from a state snapshot.
+func InitAncientTail(db ethdb.Database, tail uint64) error {
+	frdb, ok := db.(*freezerdb)
+	if !ok {
+		return errors.New("ancient store unavailable")
+	}
+	f, ok := frdb.AncientStore.(*freezer)
+	if !ok {
+		return errors.New("ancient store unavailable")
+	}
+	return f.resetTail(tail)
+}
+
 // nofreezedb is a database wrapper that disables freezer data retrievals.
 type nofreezedb struct {
 	ethdb.KeyValueStore
@@ -100,6 +116,16 @@ func (db *nofreezedb) Ancients() (uint64, error) {
 	return 0, errNotSupported
 }
 
+// Tail returns an error as we don't have a backing chain freezer.
+func (db *nofreezedb) Tail() (uint64, error) {
+	return 0, errNotSupported
+}
+
+// AncientDatadir returns an error as we don't have a backing chain freezer.
+func (db *nofreezedb) AncientDatadir() (string, error) {
+	return "", errNotSupported
+}
+
 // AncientSize returns an error as we don't have a backing chain freezer.
 func (db *nofreezedb) AncientSize(kind string) (uint64, error) {
 	return 0, errNotSupported
@@ -115,6 +141,11 @@ func (db *nofreezedb) TruncateAncients(items uint64) error {
 	return errNotSupported
 }
 
+// TruncateTail returns an error as we don't have a backing chain freezer.
+func (db *nofreezedb) TruncateTail(items uint64) error {
+	return errNotSupported
+}
+
 // Sync returns an error as we don't have a backing chain freezer.
 func (db *nofreezedb) Sync() error {
 	return errNotSupported
@@ -174,7 +205,9 @@ func NewDatabaseWithFreezer(db ethdb.KeyValueStore, freezer string, namespace st
 	// validate in this method. If, however, the genesis hash is not nil, compare
 	// it to the freezer content.
 	if kvgenesis, _ := db.Get(headerHashKey(0)); len(kvgenesis) > 0 {
-		if frozen, _ := frdb.Ancients(); frozen > 0 {
+		// A freezer started past the genesis, e.g. in a database bootstrapped from
+		// a state snapshot, doesn't hold the genesis to cross validate.
+		if frozen, _ := frdb.Ancients(); frozen > 0 && frdb.tables[freezerHashTable].tail() == 0 {
 			// If the freezer already contains something, ensure that the genesis blocks
 			// match, otherwise we might mix up freezers across chains and destroy both
 			// the freezer and the key-value store.
@@ -229,6 +262,16 @@ func NewDatabaseWithFreezer(db ethdb.KeyValueStore, freezer string, namespace st
 	}, nil
 }
 
+// NewStateFreezer initializes the freezer holding the state histories of the
+// path-based trie database, in the "state" directory of the ancient store.
+func NewStateFreezer(ancientDir string, readonly bool) (ethdb.AncientStore, error) {
+	dir := filepath.Join(ancientDir, "state")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return newFreezer(dir, "eth/db/state/", readonly, freezerTableSize, stateFreezerNoSnappy)
+}
+
 // NewMemoryDatabase creates an ephemeral in-memory key-value database without a
 // freezer moving immutable chain segments into cold storage.
 func NewMemoryDatabase() ethdb.Database {
@@ -267,6 +310,100 @@ func NewLevelDBDatabaseWithFreezer(file string, cache int, handles int, freezer
 	return frdb, nil
 }
 
+const (
+	// DBLeveldb is the identifier of the LevelDB backed key-value store.
+	DBLeveldb = "leveldb"
+
+	// DBPebble is the identifier of the Pebble backed key-value store.
+	DBPebble = "pebble"
+)
+
+// PreexistingDatabase checks the given data directory whether a database is
+// already instantiated at that location, and if so, returns the type of the
+// database (or the empty string).
+func PreexistingDatabase(path string) string {
+	if _, err := os.Stat(filepath.Join(path, "CURRENT")); err != nil {
+		return "" // No pre-existing db
+	}
+	// Only pebble keeps its options in OPTIONS files next to the manifest
+	if matches, _ := filepath.Glob(filepath.Join(path, "OPTIONS*")); len(matches) > 0 {
+		return DBPebble
+	}
+	return DBLeveldb
+}
+
+// OpenOptions contains the options to apply when opening a persistent database.
+type OpenOptions struct {
+	Type              string // Key-value store implementation, leveldb if empty on a new database
+	Directory         string // Directory of the key-value store
+	AncientsDirectory string // Directory of the chain freezer, none if empty
+	Namespace         string // Namespace of the database metrics
+	Cache             int    // Capacity (in megabytes) of the data caching
+	Handles           int    // Number of files to be open simultaneously
+	ReadOnly          bool   // Whether the database is opened read only
+}
+
+// openKeyValueDatabase opens a persistent key-value store with the requested
+// implementation. Existing databases are opened with the implementation they
+// were created with, requesting a different one being an error.
+func openKeyValueDatabase(o OpenOptions) (ethdb.KeyValueStore, string, error) {
+	existing := PreexistingDatabase(o.Directory)
+	if o.Type != "" && existing != "" && o.Type != existing {
+		return nil, "", fmt.Errorf("database engine mismatch: %s requested, %s found on disk (use 'geth db convert' to migrate)", o.Type, existing)
+	}
+	engine := o.Type
+	if engine == "" {
+		engine = existing
+	}
+	if engine == "" {
+		engine = DBLeveldb
+	}
+	var (
+		kvdb ethdb.KeyValueStore
+		err  error
+	)
+	switch engine {
+	case DBLeveldb:
+		log.Info("Using leveldb as the backing database")
+		kvdb, err = leveldb.New(o.Directory, o.Cache, o.Handles, o.Namespace, o.ReadOnly)
+	case DBPebble:
+		log.Info("Using pebble as the backing database")
+		kvdb, err = newPebbleDB(o.Directory, o.Cache, o.Handles, o.Namespace, o.ReadOnly)
+	default:
+		return nil, "", fmt.Errorf("unknown database engine %q", engine)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return kvdb, engine, nil
+}
+
+// Open opens both a persistent key-value store and, if an ancients directory is
+// configured, the chain freezer on top of it. The implementation backing the
+// key-value store is recorded in its metadata.
+func Open(o OpenOptions) (ethdb.Database, error) {
+	kvdb, engine, err := openKeyValueDatabase(o)
+	if err != nil {
+		return nil, err
+	}
+	switch recorded := ReadDatabaseEngine(kvdb); {
+	case recorded == "" && !o.ReadOnly:
+		WriteDatabaseEngine(kvdb, engine)
+	case recorded != "" && recorded != engine:
+		kvdb.Close()
+		return nil, fmt.Errorf("database engine mismatch: %s recorded, opened as %s", recorded, engine)
+	}
+	if o.AncientsDirectory == "" {
+		return NewDatabase(kvdb), nil
+	}
+	frdb, err := NewDatabaseWithFreezer(kvdb, o.AncientsDirectory, o.Namespace, o.ReadOnly)
+	if err != nil {
+		kvdb.Close()
+		return nil, err
+	}
+	return frdb, nil
+}
+
 type counter uint64
 
 func (c counter) String() string {
@@ -316,6 +453,9 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 		numHashPairings stat
 		hashNumPairings stat
 		tries           stat
+		accountTries    stat
+		storageTries    stat
+		stateIDs        stat
 		codes           stat
 		txLookups       stat
 		accountSnaps    stat
@@ -324,6 +464,10 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 		bloomBits       stat
 		cliqueSnaps     stat
 		congressSnaps   stat
+		traceIndex      stat
+		transfers       stat
+		addressIndex    stat
+		tokenIndex      stat
 
 		// Ancient store statistics
 		ancientHeadersSize  common.StorageSize
@@ -357,12 +501,20 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 			bodies.Add(size)
 		case bytes.HasPrefix(key, blockReceiptsPrefix) && len(key) == (len(blockReceiptsPrefix)+8+common.HashLength):
 			receipts.Add(size)
+		case bytes.HasPrefix(key, blockTransfersPrefix) && len(key) == (len(blockTransfersPrefix)+8+common.HashLength):
+			transfers.Add(size)
 		case bytes.HasPrefix(key, headerPrefix) && bytes.HasSuffix(key, headerTDSuffix):
 			tds.Add(size)
 		case bytes.HasPrefix(key, headerPrefix) && bytes.HasSuffix(key, headerHashSuffix):
 			numHashPairings.Add(size)
 		case bytes.HasPrefix(key, headerNumberPrefix) && len(key) == (len(headerNumberPrefix)+common.HashLength):
 			hashNumPairings.Add(size)
+		case IsAccountTrieNodeKey(key):
+			accountTries.Add(size)
+		case IsStorageTrieNodeKey(key):
+			storageTries.Add(size)
+		case bytes.HasPrefix(key, stateIDPrefix) && len(key) == len(stateIDPrefix)+common.HashLength:
+			stateIDs.Add(size)
 		case len(key) == common.HashLength:
 			tries.Add(size)
 		case bytes.HasPrefix(key, CodePrefix) && len(key) == len(CodePrefix)+common.HashLength:
@@ -381,6 +533,22 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 			bloomBits.Add(size)
 		case bytes.HasPrefix(key, BloomBitsIndexPrefix):
 			bloomBits.Add(size)
+		case bytes.HasPrefix(key, traceIndexPrefix) && len(key) == (len(traceIndexPrefix)+common.AddressLength+8):
+			traceIndex.Add(size)
+		case bytes.HasPrefix(key, addressIndexPrefix) && len(key) == (len(addressIndexPrefix)+common.AddressLength+8+4+1):
+			addressIndex.Add(size)
+		case bytes.HasPrefix(key, AddressIndexPrefix):
+			addressIndex.Add(size)
+		case isTokenHoldingsKey(key):
+			tokenIndex.Add(size)
+		case bytes.HasPrefix(key, holderTransferPrefix) && len(key) == (len(holderTransferPrefix)+common.AddressLength+8+4+4):
+			tokenIndex.Add(size)
+		case bytes.HasPrefix(key, tokenTransferPrefix) && len(key) == (len(tokenTransferPrefix)+common.AddressLength+8+4+4):
+			tokenIndex.Add(size)
+		case bytes.HasPrefix(key, tokenJournalPrefix) && len(key) == (len(tokenJournalPrefix)+8):
+			tokenIndex.Add(size)
+		case bytes.HasPrefix(key, TokenIndexPrefix):
+			tokenIndex.Add(size)
 		case bytes.HasPrefix(key, []byte("clique-")) && len(key) == 7+common.HashLength:
 			cliqueSnaps.Add(size)
 		case bytes.HasPrefix(key, []byte("congress-")) && len(key) == 7+common.HashLength:
@@ -396,10 +564,12 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 		default:
 			var accounted bool
 			for _, meta := range [][]byte{
-				databaseVersionKey, headHeaderKey, headBlockKey, headFastBlockKey, lastPivotKey,
+				databaseVersionKey, databaseEngineKey, headHeaderKey, headBlockKey, headFastBlockKey, lastPivotKey,
 				fastTrieProgressKey, snapshotDisabledKey, SnapshotRootKey, snapshotJournalKey,
 				snapshotGeneratorKey, snapshotRecoveryKey, txIndexTailKey, fastTxLookupLimitKey,
-				uncleanShutdownKey, badBlockKey,
+				uncleanShutdownKey, badBlockKey, txPoolSnapshotKey, traceIndexHeadKey,
+				traceIndexTailKey, stateSchemeKey, persistentStateIDKey, addressIndexTailKey,
+				tokenIndexAppliedKey,
 			} {
 				if bytes.Equal(key, meta) {
 					metadata.Add(size)
@@ -435,13 +605,20 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 		{"Key-Value store", "Headers", headers.Size(), headers.Count()},
 		{"Key-Value store", "Bodies", bodies.Size(), bodies.Count()},
 		{"Key-Value store", "Receipt lists", receipts.Size(), receipts.Count()},
+		{"Key-Value store", "Internal transfers", transfers.Size(), transfers.Count()},
 		{"Key-Value store", "Difficulties", tds.Size(), tds.Count()},
 		{"Key-Value store", "Block number->hash", numHashPairings.Size(), numHashPairings.Count()},
 		{"Key-Value store", "Block hash->number", hashNumPairings.Size(), hashNumPairings.Count()},
 		{"Key-Value store", "Transaction index", txLookups.Size(), txLookups.Count()},
 		{"Key-Value store", "Bloombit index", bloomBits.Size(), bloomBits.Count()},
+		{"Key-Value store", "Trace index", traceIndex.Size(), traceIndex.Count()},
+		{"Key-Value store", "Address index", addressIndex.Size(), addressIndex.Count()},
+		{"Key-Value store", "Token index", tokenIndex.Size(), tokenIndex.Count()},
 		{"Key-Value store", "Contract codes", codes.Size(), codes.Count()},
 		{"Key-Value store", "Trie nodes", tries.Size(), tries.Count()},
+		{"Key-Value store", "Path trie account nodes", accountTries.Size(), accountTries.Count()},
+		{"Key-Value store", "Path trie storage nodes", storageTries.Size(), storageTries.Count()},
+		{"Key-Value store", "State ID index", stateIDs.Size(), stateIDs.Count()},
 		{"Key-Value store", "Trie preimages", preimages.Size(), preimages.Count()},
 		{"Key-Value store", "Account snapshot", accountSnaps.Size(), accountSnaps.Count()},
 		{"Key-Value store", "Storage snapshot", storageSnaps.Size(), storageSnaps.Count()},
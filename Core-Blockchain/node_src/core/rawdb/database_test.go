@@ -16,3 +16,42 @@
 // bug across the project fixed by EtherAuthority <https://etherauthority.io/>
 
 package rawdb
+
+import (
+	"testing"
+)
+
+// Tests that the key-value store implementation is recorded when a database is
+// created and that reopening it with another one is refused.
+func TestOpenDatabaseEngine(t *testing.T) {
+	for _, engine := range []string{DBLeveldb, DBPebble} {
+		dir := t.TempDir()
+
+		db, err := Open(OpenOptions{Type: engine, Directory: dir})
+		if err != nil {
+			t.Fatalf("%s: failed to create database: %v", engine, err)
+		}
+		if recorded := ReadDatabaseEngine(db); recorded != engine {
+			t.Errorf("%s: recorded engine mismatch: have %q", engine, recorded)
+		}
+		db.Close()
+
+		if have := PreexistingDatabase(dir); have != engine {
+			t.Errorf("%s: detected engine mismatch: have %q", engine, have)
+		}
+		// Reopening without an explicit engine should pick the existing one
+		db, err = Open(OpenOptions{Directory: dir, ReadOnly: true})
+		if err != nil {
+			t.Fatalf("%s: failed to reopen database: %v", engine, err)
+		}
+		db.Close()
+
+		other := DBPebble
+		if engine == DBPebble {
+			other = DBLeveldb
+		}
+		if _, err := Open(OpenOptions{Type: other, Directory: dir}); err == nil {
+			t.Errorf("%s: reopened as %s", engine, other)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+//go:build (arm64 || amd64) && !openbsd
+// +build arm64 amd64
+// +build !openbsd
+
+package rawdb
+
+import (
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/pebble"
+)
+
+// newPebbleDB opens a Pebble backed key-value store.
+func newPebbleDB(file string, cache int, handles int, namespace string, readonly bool) (ethdb.KeyValueStore, error) {
+	return pebble.New(file, cache, handles, namespace, readonly)
+}
@@ -79,6 +79,7 @@ type freezer struct {
 	// 64-bit aligned fields can be atomic. The struct is guaranteed to be so aligned,
 	// so take advantage of that (https://golang.org/pkg/sync/atomic/#pkg-note-BUG).
 	frozen    uint64 // Number of blocks already frozen
+	tail      uint64 // Number of the first block whose prunable data is still stored
 	threshold uint64 // Number of recent blocks not to freeze (params.FullImmutabilityThreshold apart from tests)
 
 	// This lock synchronizes writers and the truncate operation, as well as
@@ -86,6 +87,7 @@ type freezer struct {
 	writeLock  sync.RWMutex
 	writeBatch *freezerBatch
 
+	datadir      string                   // Root directory of the data tables
 	readonly     bool
 	tables       map[string]*freezerTable // Data tables for storing everything
 	instanceLock fileutil.Releaser        // File-system lock to prevent double opens
@@ -124,6 +126,7 @@ func newFreezer(datadir string, namespace string, readonly bool, maxTableSize ui
 	}
 	// Open all the supported data tables
 	freezer := &freezer{
+		datadir:      datadir,
 		readonly:     readonly,
 		threshold:    params.FullImmutabilityThreshold,
 		tables:       make(map[string]*freezerTable),
@@ -220,6 +223,17 @@ func (f *freezer) Ancients() (uint64, error) {
 	return atomic.LoadUint64(&f.frozen), nil
 }
 
+// Tail returns the number of the first block whose bodies and receipts are still
+// stored in the freezer, the ones below having been pruned.
+func (f *freezer) Tail() (uint64, error) {
+	return atomic.LoadUint64(&f.tail), nil
+}
+
+// AncientDatadir returns the root directory of the freezer.
+func (f *freezer) AncientDatadir() (string, error) {
+	return f.datadir, nil
+}
+
 // AncientSize returns the ancient size of the specified category.
 func (f *freezer) AncientSize(kind string) (uint64, error) {
 	// This needs the write lock to avoid data races on table fields.
@@ -292,6 +306,58 @@ func (f *freezer) TruncateAncients(items uint64) error {
 		}
 	}
 	atomic.StoreUint64(&f.frozen, items)
+	if atomic.LoadUint64(&f.tail) > items {
+		atomic.StoreUint64(&f.tail, items)
+	}
+	return nil
+}
+
+// TruncateTail discards the prunable data (block bodies and receipts) below the
+// provided threshold number.
+func (f *freezer) TruncateTail(tail uint64) error {
+	if f.readonly {
+		return errReadOnly
+	}
+	f.writeLock.Lock()
+	defer f.writeLock.Unlock()
+
+	if atomic.LoadUint64(&f.tail) >= tail {
+		return nil
+	}
+	if frozen := atomic.LoadUint64(&f.frozen); tail > frozen {
+		return fmt.Errorf("tail truncation beyond the frozen items: %d > %d", tail, frozen)
+	}
+	for name, table := range f.tables {
+		if !freezerPrunableTables[name] {
+			continue
+		}
+		if err := table.truncateTail(tail); err != nil {
+			return err
+		}
+	}
+	atomic.StoreUint64(&f.tail, tail)
+	return nil
+}
+
+// resetTail makes an empty freezer start at the given item, used for databases
+// lacking the chain segment before it.
+func (f *freezer) resetTail(tail uint64) error {
+	if f.readonly {
+		return errReadOnly
+	}
+	f.writeLock.Lock()
+	defer f.writeLock.Unlock()
+
+	if atomic.LoadUint64(&f.frozen) != 0 {
+		return errors.New("tail reset of a non-empty freezer")
+	}
+	for _, table := range f.tables {
+		if err := table.resetTail(tail); err != nil {
+			return err
+		}
+	}
+	atomic.StoreUint64(&f.frozen, tail)
+	atomic.StoreUint64(&f.tail, tail)
 	return nil
 }
 
@@ -324,6 +390,24 @@ func (f *freezer) repair() error {
 		}
 	}
 	atomic.StoreUint64(&f.frozen, min)
+
+	// Prune all the prunable tables to the same tail, a previous pruning might
+	// have been interrupted halfway
+	var tail uint64
+	for name, table := range f.tables {
+		if freezerPrunableTables[name] && table.tail() > tail {
+			tail = table.tail()
+		}
+	}
+	for name, table := range f.tables {
+		if !freezerPrunableTables[name] {
+			continue
+		}
+		if err := table.truncateTail(tail); err != nil {
+			return err
+		}
+	}
+	atomic.StoreUint64(&f.tail, tail)
 	return nil
 }
 
@@ -0,0 +1,81 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package rawdb
+
+import (
+	"io"
+	"os"
+
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// freezerTableMetaVersion is the version of the freezer table metadata format.
+const freezerTableMetaVersion = 1
+
+// freezerTableMeta wraps the metadata of a freezer table, stored next to its
+// index file.
+type freezerTableMeta struct {
+	Version uint16 // Version of the metadata format
+	Tail    uint64 // Number of items pruned from the tail, whether discarded from the data files or only hidden
+}
+
+// readMetadata reads the metadata of a freezer table from the given file.
+func readMetadata(file *os.File) (*freezerTableMeta, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	var meta freezerTableMeta
+	if err := rlp.Decode(file, &meta); err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
+// writeMetadata overwrites the metadata of a freezer table in the given file.
+func writeMetadata(file *os.File, meta *freezerTableMeta) error {
+	enc, err := rlp.EncodeToBytes(meta)
+	if err != nil {
+		return err
+	}
+	if err := file.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := file.WriteAt(enc, 0); err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+// loadMetadata loads the metadata of a freezer table, initializing it if the
+// file is empty. The tail is never below the given number of items discarded
+// from the data files.
+func loadMetadata(file *os.File, discarded uint64) (*freezerTableMeta, error) {
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if stat.Size() == 0 {
+		meta := &freezerTableMeta{Version: freezerTableMetaVersion, Tail: discarded}
+		if err := writeMetadata(file, meta); err != nil {
+			return nil, err
+		}
+		return meta, nil
+	}
+	meta, err := readMetadata(file)
+	if err != nil {
+		return nil, err
+	}
+	if meta.Tail < discarded {
+		meta.Tail = discarded
+		if err := writeMetadata(file, meta); err != nil {
+			return nil, err
+		}
+	}
+	return meta, nil
+}
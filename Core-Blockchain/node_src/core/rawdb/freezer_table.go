@@ -23,6 +23,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"path/filepath"
 	"sync"
@@ -93,7 +94,8 @@ type freezerTable struct {
 	// WARNING: The `items` field is accessed atomically. On 32 bit platforms, only
 	// 64-bit aligned fields can be atomic. The struct is guaranteed to be so aligned,
 	// so take advantage of that (https://golang.org/pkg/sync/atomic/#pkg-note-BUG).
-	items uint64 // Number of items stored in the table (including items removed from tail)
+	items      uint64 // Number of items stored in the table (including items removed from tail)
+	itemHidden uint64 // Number of items pruned from the tail, including the discarded ones (itemOffset)
 
 	noCompression bool   // if true, disables snappy compression. Note: does not work retroactively
 	maxFileSize   uint32 // Max file size for data-files
@@ -105,6 +107,7 @@ type freezerTable struct {
 	headId uint32              // number of the currently active head file
 	tailId uint32              // number of the earliest file
 	index  *os.File            // File descriptor for the indexEntry file of the table
+	meta   *os.File            // File descriptor for the metadata of the table
 
 	// In the case that old items are deleted (from the tail), we use itemOffset
 	// to count how many historic items have gone missing.
@@ -182,9 +185,15 @@ func newTable(path string, name string, readMeter metrics.Meter, writeMeter metr
 	if err != nil {
 		return nil, err
 	}
+	meta, err := openFreezerFileForAppend(filepath.Join(path, fmt.Sprintf("%s.meta", name)))
+	if err != nil {
+		offsets.Close()
+		return nil, err
+	}
 	// Create the table and repair any past inconsistency
 	tab := &freezerTable{
 		index:         offsets,
+		meta:          meta,
 		files:         make(map[uint32]*os.File),
 		readMeter:     readMeter,
 		writeMeter:    writeMeter,
@@ -251,8 +260,21 @@ func (t *freezerTable) repair() error {
 	t.tailId = firstIndex.filenum
 	t.itemOffset = firstIndex.offset
 
-	t.index.ReadAt(buffer, offsetsSize-indexEntrySize)
-	lastIndex.unmarshalBinary(buffer)
+	// Load the tail pruned from the table, never below the discarded items
+	meta, err := loadMetadata(t.meta, uint64(t.itemOffset))
+	if err != nil {
+		return err
+	}
+	t.itemHidden = meta.Tail
+
+	// Read the last index, the first one carrying the tail info instead of a data
+	// offset if the table is empty
+	if offsetsSize == indexEntrySize {
+		lastIndex = indexEntry{filenum: t.tailId, offset: 0}
+	} else {
+		t.index.ReadAt(buffer, offsetsSize-indexEntrySize)
+		lastIndex.unmarshalBinary(buffer)
+	}
 	t.head, err = t.openFile(lastIndex.filenum, openFreezerFileForAppend)
 	if err != nil {
 		return err
@@ -311,6 +333,9 @@ func (t *freezerTable) repair() error {
 	}
 	// Update the item and byte counters and return
 	t.items = uint64(t.itemOffset) + uint64(offsetsSize/indexEntrySize-1) // last indexEntry points to the end of the data file
+	if t.itemHidden > t.items {
+		t.itemHidden = t.items
+	}
 	t.headBytes = contentSize
 	t.headId = lastIndex.filenum
 
@@ -350,6 +375,10 @@ func (t *freezerTable) truncate(items uint64) error {
 	if existing <= items {
 		return nil
 	}
+	// Items discarded from the tail cannot be truncated from the head
+	if items < uint64(t.itemOffset) {
+		return fmt.Errorf("truncation below the table tail: %d < %d", items, t.itemOffset)
+	}
 	// We need to truncate, save the old size for metrics tracking
 	oldSize, err := t.sizeNolock()
 	if err != nil {
@@ -361,16 +390,28 @@ func (t *freezerTable) truncate(items uint64) error {
 		log = t.logger.Warn // Only loud warn if we delete multiple items
 	}
 	log("Truncating freezer table", "items", existing, "limit", items)
-	if err := truncateFreezerFile(t.index, int64(items+1)*indexEntrySize); err != nil {
+	if items < atomic.LoadUint64(&t.itemHidden) {
+		// The pruned tail of the table reaches above the new head, lower it
+		if err := writeMetadata(t.meta, &freezerTableMeta{Version: freezerTableMetaVersion, Tail: items}); err != nil {
+			return err
+		}
+		atomic.StoreUint64(&t.itemHidden, items)
+	}
+	position := items - uint64(t.itemOffset)
+	if err := truncateFreezerFile(t.index, int64(position+1)*indexEntrySize); err != nil {
 		return err
 	}
 	// Calculate the new expected size of the data file and truncate it
 	buffer := make([]byte, indexEntrySize)
-	if _, err := t.index.ReadAt(buffer, int64(items*indexEntrySize)); err != nil {
+	if _, err := t.index.ReadAt(buffer, int64(position*indexEntrySize)); err != nil {
 		return err
 	}
 	var expected indexEntry
 	expected.unmarshalBinary(buffer)
+	if position == 0 {
+		// The first entry carries the tail info, no data is left in the table
+		expected = indexEntry{filenum: t.tailId, offset: 0}
+	}
 
 	// We might need to truncate back to older files
 	if expected.filenum != t.headId {
@@ -415,6 +456,11 @@ func (t *freezerTable) Close() error {
 	}
 	t.index = nil
 
+	if err := t.meta.Close(); err != nil {
+		errs = append(errs, err)
+	}
+	t.meta = nil
+
 	for _, f := range t.files {
 		if err := f.Close(); err != nil {
 			errs = append(errs, err)
@@ -569,7 +615,7 @@ func (t *freezerTable) retrieveItems(start, count, maxBytes uint64) ([]byte, []i
 	itemCount := atomic.LoadUint64(&t.items) // max number
 	// Ensure the start is written, not deleted from the tail, and that the
 	// caller actually wants something
-	if itemCount <= start || uint64(t.itemOffset) > start || count == 0 {
+	if itemCount <= start || atomic.LoadUint64(&t.itemHidden) > start || count == 0 {
 		return nil, nil, errOutOfBounds
 	}
 	if start+count > itemCount {
@@ -652,7 +698,176 @@ func (t *freezerTable) retrieveItems(start, count, maxBytes uint64) ([]byte, []i
 // has returns an indicator whether the specified number data
 // exists in the freezer table.
 func (t *freezerTable) has(number uint64) bool {
-	return atomic.LoadUint64(&t.items) > number
+	return atomic.LoadUint64(&t.items) > number && atomic.LoadUint64(&t.itemHidden) <= number
+}
+
+// tail returns the number of the first item still stored in the table.
+func (t *freezerTable) tail() uint64 {
+	return atomic.LoadUint64(&t.itemHidden)
+}
+
+// truncateTail prunes the items below the provided threshold number. The items
+// are hidden first, the data files entirely below the new tail being deleted
+// afterwards, along with their index entries.
+func (t *freezerTable) truncateTail(items uint64) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if atomic.LoadUint64(&t.itemHidden) >= items {
+		return nil
+	}
+	if items > atomic.LoadUint64(&t.items) {
+		return fmt.Errorf("tail truncation beyond the table head: %d > %d", items, atomic.LoadUint64(&t.items))
+	}
+	// Hide the pruned items, persisting the new tail before touching any file
+	if err := writeMetadata(t.meta, &freezerTableMeta{Version: freezerTableMetaVersion, Tail: items}); err != nil {
+		return err
+	}
+	atomic.StoreUint64(&t.itemHidden, items)
+
+	// Find the data file holding the new first item. Item n is indexed by the
+	// entry n-itemOffset+1, whose file is the one the item ends in.
+	stat, err := t.index.Stat()
+	if err != nil {
+		return err
+	}
+	var (
+		entries = uint64(stat.Size() / indexEntrySize)
+		buffer  = make([]byte, indexEntrySize)
+		entry   indexEntry
+	)
+	readEntry := func(pos uint64) (uint32, error) {
+		if _, err := t.index.ReadAt(buffer, int64(pos*indexEntrySize)); err != nil {
+			return 0, err
+		}
+		entry.unmarshalBinary(buffer)
+		return entry.filenum, nil
+	}
+	tailId := t.headId
+	if pos := items - uint64(t.itemOffset) + 1; pos < entries {
+		if tailId, err = readEntry(pos); err != nil {
+			return err
+		}
+	}
+	if tailId == t.tailId {
+		return nil // Data files are only discarded as a whole
+	}
+	// Find the first entry ending in the new tail file, its item starting at the
+	// beginning of the file
+	var (
+		lo, hi  = uint64(1), entries - 1
+		readErr error
+	)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		filenum, err := readEntry(mid)
+		if err != nil {
+			readErr = err
+			break
+		}
+		if filenum < tailId {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	if readErr != nil {
+		return readErr
+	}
+	first := lo
+	offset := uint64(t.itemOffset) + first - 1
+
+	// Rewrite the index without the discarded entries, the first entry carrying
+	// the new tail file and the number of discarded items
+	oldSize, err := t.sizeNolock()
+	if err != nil {
+		return err
+	}
+	remaining := make([]byte, (entries-first)*indexEntrySize)
+	if _, err := t.index.ReadAt(remaining, int64(first*indexEntrySize)); err != nil {
+		return err
+	}
+	head := indexEntry{filenum: tailId, offset: uint32(offset)}
+	if err := t.replaceIndex(append(head.append(nil), remaining...)); err != nil {
+		return err
+	}
+	// Delete the data files below the new tail
+	for id := t.tailId; id < tailId; id++ {
+		if f, exist := t.files[id]; exist {
+			delete(t.files, id)
+			f.Close()
+			if err := os.Remove(f.Name()); err != nil {
+				t.logger.Error("Failed to remove pruned freezer file", "file", f.Name(), "err", err)
+			}
+		}
+	}
+	t.tailId = tailId
+	t.itemOffset = uint32(offset)
+
+	newSize, err := t.sizeNolock()
+	if err != nil {
+		return err
+	}
+	t.sizeGauge.Dec(int64(oldSize - newSize))
+	t.logger.Debug("Pruned freezer table tail", "tail", items, "files", tailId, "discarded", offset)
+	return nil
+}
+
+// resetTail makes an empty table start at the given item, as if all the items
+// before had been pruned.
+func (t *freezerTable) resetTail(items uint64) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if atomic.LoadUint64(&t.items) != 0 || t.itemOffset != 0 {
+		return errors.New("tail reset of a non-empty table")
+	}
+	if items > math.MaxUint32 {
+		return fmt.Errorf("tail out of range: %d", items)
+	}
+	if err := writeMetadata(t.meta, &freezerTableMeta{Version: freezerTableMetaVersion, Tail: items}); err != nil {
+		return err
+	}
+	head := indexEntry{filenum: t.headId, offset: uint32(items)}
+	if err := t.replaceIndex(head.append(nil)); err != nil {
+		return err
+	}
+	t.tailId = t.headId
+	t.itemOffset = uint32(items)
+	atomic.StoreUint64(&t.itemHidden, items)
+	atomic.StoreUint64(&t.items, items)
+	return nil
+}
+
+// replaceIndex atomically replaces the index file with the given content. The
+// caller must hold the write lock.
+func (t *freezerTable) replaceIndex(content []byte) error {
+	name := t.index.Name()
+	temp, err := openFreezerFileTruncated(name + ".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := temp.Write(content); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Sync(); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(name+".tmp", name); err != nil {
+		return err
+	}
+	index, err := openFreezerFileForAppend(name)
+	if err != nil {
+		return err
+	}
+	t.index.Close()
+	t.index = index
+	return nil
 }
 
 // size returns the total data size in the freezer table.
@@ -399,6 +399,92 @@ func TestFreezerTruncate(t *testing.T) {
 	}
 }
 
+// TestFreezerTableTruncateTail tests that pruning the tail of a table hides the
+// pruned items, deletes the data files entirely below the new tail and persists
+// the tail across restarts.
+func TestFreezerTableTruncateTail(t *testing.T) {
+	t.Parallel()
+	rm, wm, sg := metrics.NewMeter(), metrics.NewMeter(), metrics.NewGauge()
+	fname := fmt.Sprintf("truncate-tail-%d", rand.Uint64())
+
+	// Fill table
+	{
+		f, err := newTable(os.TempDir(), fname, rm, wm, sg, 50, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Write 15 bytes 30 times, 3 items per file
+		writeChunks(t, f, 30, 15)
+
+		// Hide the first two items, no file can be discarded yet
+		if err := f.truncateTail(2); err != nil {
+			t.Fatal(err)
+		}
+		if f.tail() != 2 || f.tailId != 0 {
+			t.Fatalf("unexpected tail: have %d (file %d), want 2 (file 0)", f.tail(), f.tailId)
+		}
+		checkRetrieveError(t, f, map[uint64]error{
+			0: errOutOfBounds,
+			1: errOutOfBounds,
+		})
+		checkRetrieve(t, f, map[uint64][]byte{
+			2: getChunk(15, 2),
+		})
+		// Prune into the fourth file, the first three being deleted
+		if err := f.truncateTail(10); err != nil {
+			t.Fatal(err)
+		}
+		if f.tail() != 10 || f.tailId != 3 || f.itemOffset != 9 {
+			t.Fatalf("unexpected tail: have %d (file %d, offset %d), want 10 (file 3, offset 9)", f.tail(), f.tailId, f.itemOffset)
+		}
+		checkRetrieveError(t, f, map[uint64]error{
+			2: errOutOfBounds,
+			9: errOutOfBounds,
+		})
+		checkRetrieve(t, f, map[uint64][]byte{
+			10: getChunk(15, 10),
+			29: getChunk(15, 29),
+		})
+		// Truncating beyond the head must fail
+		if err := f.truncateTail(31); err == nil {
+			t.Fatal("expected error truncating beyond the head")
+		}
+		f.Close()
+	}
+	// Reopen, the tail should be retained
+	{
+		f, err := newTable(os.TempDir(), fname, rm, wm, sg, 50, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		if f.tail() != 10 || f.items != 30 {
+			t.Fatalf("unexpected table bounds after reopen: tail %d, items %d", f.tail(), f.items)
+		}
+		if f.has(9) || !f.has(10) {
+			t.Fatal("tail not respected after reopen")
+		}
+		checkRetrieveError(t, f, map[uint64]error{
+			9: errOutOfBounds,
+		})
+		checkRetrieve(t, f, map[uint64][]byte{
+			10: getChunk(15, 10),
+			20: getChunk(15, 20),
+		})
+		// Truncating the head below the tail hides everything
+		if err := f.truncate(5); err == nil {
+			t.Fatal("expected error truncating the head below the discarded items")
+		}
+		if err := f.truncate(10); err != nil {
+			t.Fatal(err)
+		}
+		if f.items != 10 || f.has(10) {
+			t.Fatalf("unexpected items after head truncation: %d", f.items)
+		}
+	}
+}
+
 // TestFreezerRepairFirstFile tests a head file with the very first item only half-written.
 // That will rewind the index, and _should_ truncate the head file
 func TestFreezerRepairFirstFile(t *testing.T) {
@@ -254,6 +254,146 @@ func TestFreezerConcurrentModifyTruncate(t *testing.T) {
 	}
 }
 
+// This checks that TruncateTail only prunes the history tables and that the
+// tail survives a restart.
+func TestFreezerTruncateTail(t *testing.T) {
+	t.Parallel()
+
+	tables := map[string]bool{freezerHeaderTable: true, freezerBodiesTable: true, freezerReceiptTable: true}
+	f, dir := newFreezerForTesting(t, tables)
+	defer os.RemoveAll(dir)
+
+	_, err := f.ModifyAncients(func(op ethdb.AncientWriteOp) error {
+		for i := uint64(0); i < 100; i++ {
+			for name := range tables {
+				if err := op.AppendRaw(name, i, getChunk(256, int(i))); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal("ModifyAncients failed:", err)
+	}
+	if err := f.TruncateTail(101); err == nil {
+		t.Fatal("expected error pruning beyond the frozen items")
+	}
+	if err := f.TruncateTail(50); err != nil {
+		t.Fatal("TruncateTail failed:", err)
+	}
+	checkTail := func(f *freezer) {
+		t.Helper()
+
+		if tail, _ := f.Tail(); tail != 50 {
+			t.Fatalf("Tail() returned %d, want %d", tail, 50)
+		}
+		if ok, _ := f.HasAncient(freezerHeaderTable, 0); !ok {
+			t.Fatal("header pruned unexpectedly")
+		}
+		for _, name := range []string{freezerBodiesTable, freezerReceiptTable} {
+			if _, err := f.Ancient(name, 49); err != errOutOfBounds {
+				t.Fatalf("Ancient(%q, 49) returned %v, want %v", name, err, errOutOfBounds)
+			}
+			if v, err := f.Ancient(name, 50); err != nil || !bytes.Equal(v, getChunk(256, 50)) {
+				t.Fatalf("Ancient(%q, 50) returned %x, %v", name, v, err)
+			}
+		}
+		checkAncientCount(t, f, freezerBodiesTable, 100)
+	}
+	checkTail(f)
+	f.Close()
+
+	f2, err := newFreezer(dir, "", false, 2049, tables)
+	if err != nil {
+		t.Fatalf("can't reopen freezer after TruncateTail: %v", err)
+	}
+	defer f2.Close()
+	checkTail(f2)
+}
+
+func TestFreezerResetTail(t *testing.T) {
+	t.Parallel()
+
+	tables := map[string]bool{freezerHeaderTable: false, freezerBodiesTable: true}
+	f, dir := newFreezerForTesting(t, tables)
+	defer os.RemoveAll(dir)
+
+	if err := f.resetTail(1000); err != nil {
+		t.Fatal("resetTail failed:", err)
+	}
+	_, err := f.ModifyAncients(func(op ethdb.AncientWriteOp) error {
+		for i := uint64(1000); i < 1020; i++ {
+			for name := range tables {
+				if err := op.AppendRaw(name, i, getChunk(256, int(i))); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal("ModifyAncients failed:", err)
+	}
+	if err := f.resetTail(2000); err == nil {
+		t.Fatal("expected error resetting the tail of a non-empty freezer")
+	}
+	checkTail := func(f *freezer) {
+		t.Helper()
+
+		if tail, _ := f.Tail(); tail != 1000 {
+			t.Fatalf("Tail() returned %d, want %d", tail, 1000)
+		}
+		for name := range tables {
+			if _, err := f.Ancient(name, 999); err != errOutOfBounds {
+				t.Fatalf("Ancient(%q, 999) returned %v, want %v", name, err, errOutOfBounds)
+			}
+			for i := 1000; i < 1020; i++ {
+				if v, err := f.Ancient(name, uint64(i)); err != nil || !bytes.Equal(v, getChunk(256, i)) {
+					t.Fatalf("Ancient(%q, %d) returned %x, %v", name, i, v, err)
+				}
+			}
+		}
+		checkAncientCount(t, f, freezerBodiesTable, 1020)
+	}
+	checkTail(f)
+	f.Close()
+
+	f2, err := newFreezer(dir, "", false, 2049, tables)
+	if err != nil {
+		t.Fatalf("can't reopen freezer after resetTail: %v", err)
+	}
+	defer f2.Close()
+	checkTail(f2)
+
+	// Truncating all the appended items must leave a consistent empty freezer
+	if err := f2.TruncateAncients(1000); err != nil {
+		t.Fatal("TruncateAncients failed:", err)
+	}
+	f2.Close()
+
+	f3, err := newFreezer(dir, "", false, 2049, tables)
+	if err != nil {
+		t.Fatalf("can't reopen freezer after TruncateAncients: %v", err)
+	}
+	defer f3.Close()
+	if frozen, _ := f3.Ancients(); frozen != 1000 {
+		t.Fatalf("Ancients() returned %d, want %d", frozen, 1000)
+	}
+	_, err = f3.ModifyAncients(func(op ethdb.AncientWriteOp) error {
+		for name := range tables {
+			if err := op.AppendRaw(name, 1000, getChunk(256, 1000)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal("ModifyAncients failed:", err)
+	}
+	checkAncientCount(t, f3, freezerBodiesTable, 1001)
+}
+
 func newFreezerForTesting(t *testing.T, tables map[string]bool) (*freezer, string) {
 	t.Helper()
 
@@ -31,6 +31,9 @@ var (
 	// databaseVersionKey tracks the current database version.
 	databaseVersionKey = []byte("DatabaseVersion")
 
+	// databaseEngineKey tracks the key-value store implementation backing the database.
+	databaseEngineKey = []byte("DatabaseEngine")
+
 	// headHeaderKey tracks the latest known header's hash.
 	headHeaderKey = []byte("LastHeader")
 
@@ -76,6 +79,32 @@ var (
 	// uncleanShutdownKey tracks the list of local crashes
 	uncleanShutdownKey = []byte("unclean-shutdown") // config prefix for the db
 
+	// txPoolSnapshotKey tracks the pending and queued transactions of the pool
+	// across restarts.
+	txPoolSnapshotKey = []byte("TxPoolSnapshot")
+
+	// traceIndexHeadKey tracks the number and hash of the latest block whose
+	// call traces have been indexed.
+	traceIndexHeadKey = []byte("TraceIndexHead")
+
+	// traceIndexTailKey tracks the oldest block whose call traces have been indexed.
+	traceIndexTailKey = []byte("TraceIndexTail")
+
+	// addressIndexTailKey tracks the oldest block whose transactions are indexed
+	// by address, once the index has been pruned.
+	addressIndexTailKey = []byte("AddressIndexTail")
+
+	// tokenIndexAppliedKey tracks the number of token index sections whose balance
+	// changes are applied to the token holdings.
+	tokenIndexAppliedKey = []byte("TokenIndexApplied")
+
+	// stateSchemeKey tracks the scheme used to store the trie nodes of the state.
+	stateSchemeKey = []byte("StateScheme")
+
+	// persistentStateIDKey tracks the id of the state persisted by the path-based
+	// trie database.
+	persistentStateIDKey = []byte("LastStateID")
+
 	// Data item prefixes (use single byte to avoid mixing data types, avoid `i`, used for indexes).
 	headerPrefix       = []byte("h") // headerPrefix + num (uint64 big endian) + hash -> header
 	headerTDSuffix     = []byte("t") // headerPrefix + num (uint64 big endian) + hash + headerTDSuffix -> td
@@ -90,12 +119,27 @@ var (
 	SnapshotAccountPrefix = []byte("a") // SnapshotAccountPrefix + account hash -> account trie value
 	SnapshotStoragePrefix = []byte("o") // SnapshotStoragePrefix + account hash + storage hash -> storage trie value
 	CodePrefix            = []byte("c") // CodePrefix + code hash -> account code
+	deniedCreatePrefix    = []byte("D") // deniedCreatePrefix + tx hash -> block hash + address whose contract creation was denied
+	TrieNodeAccountPrefix = []byte("A") // TrieNodeAccountPrefix + hexPath -> account trie node
+	TrieNodeStoragePrefix = []byte("O") // TrieNodeStoragePrefix + account hash + hexPath -> storage trie node
+	stateIDPrefix         = []byte("L") // stateIDPrefix + state root -> state id
+	blockTransfersPrefix  = []byte("v") // blockTransfersPrefix + num (uint64 big endian) + hash -> internal transfers of the block
+	addressIndexPrefix    = []byte("w") // addressIndexPrefix + address + num (uint64 big endian) + tx index (uint32 big endian) + role -> block hash
+	tokenBalancePrefix    = []byte("T") // tokenBalancePrefix + holder + token + id -> balance
+	tokenHolderPrefix     = []byte("U") // tokenHolderPrefix + token + holder -> number of token ids held (uint64 big endian)
+	tokenInfoPrefix       = []byte("K") // tokenInfoPrefix + token -> token statistics
+	holderTransferPrefix  = []byte("X") // holderTransferPrefix + holder + num (uint64 big endian) + log index (uint32 big endian) + item (uint32 big endian) -> token transfer
+	tokenTransferPrefix   = []byte("Y") // tokenTransferPrefix + token + num (uint64 big endian) + log index (uint32 big endian) + item (uint32 big endian) -> token transfer
+	tokenJournalPrefix    = []byte("J") // tokenJournalPrefix + section (uint64 big endian) -> previous values of the token holdings changed by the section
 
 	PreimagePrefix = []byte("secure-key-")      // PreimagePrefix + hash -> preimage
 	configPrefix   = []byte("ethereum-config-") // config prefix for the db
 
 	// Chain index prefixes (use `i` + single byte to avoid mixing data types).
 	BloomBitsIndexPrefix = []byte("iB") // BloomBitsIndexPrefix is the data table of a chain indexer to track its progress
+	traceIndexPrefix     = []byte("iT") // traceIndexPrefix + address + num (uint64 big endian) -> address traced in block
+	AddressIndexPrefix   = []byte("iA") // AddressIndexPrefix is the data table of a chain indexer to track its progress
+	TokenIndexPrefix     = []byte("iK") // TokenIndexPrefix is the data table of a chain indexer to track its progress
 
 	preimageCounter    = metrics.NewRegisteredCounter("db/preimage/total", nil)
 	preimageHitCounter = metrics.NewRegisteredCounter("db/preimage/hits", nil)
@@ -128,6 +172,34 @@ var FreezerNoSnappy = map[string]bool{
 	freezerDifficultyTable: true,
 }
 
+const (
+	// stateHistoryMeta indicates the name of the state history table holding
+	// the state roots of every history entry.
+	stateHistoryMeta = "history.meta"
+
+	// stateHistoryNodes indicates the name of the state history table holding
+	// the original trie nodes overwritten by every history entry.
+	stateHistoryNodes = "history.nodes"
+)
+
+// stateFreezerNoSnappy configures whether compression is disabled for the
+// state history tables. The metadata is made of hashes only.
+var stateFreezerNoSnappy = map[string]bool{
+	stateHistoryMeta:  true,
+	stateHistoryNodes: false,
+}
+
+// freezerPrunableTables lists the ancient tables whose items can be pruned from
+// the tail, by history expiry for the chain and by the bounded rollback window
+// for the state histories. Headers, hashes and difficulties are kept for the
+// whole chain.
+var freezerPrunableTables = map[string]bool{
+	freezerBodiesTable:  true,
+	freezerReceiptTable: true,
+	stateHistoryMeta:    true,
+	stateHistoryNodes:   true,
+}
+
 // LegacyTxLookupEntry is the legacy TxLookupEntry definition with some unnecessary
 // fields.
 type LegacyTxLookupEntry struct {
@@ -183,6 +255,63 @@ func txLookupKey(hash common.Hash) []byte {
 	return append(txLookupPrefix, hash.Bytes()...)
 }
 
+// deniedCreateKey = deniedCreatePrefix + hash
+func deniedCreateKey(hash common.Hash) []byte {
+	return append(deniedCreatePrefix, hash.Bytes()...)
+}
+
+// blockTransfersKey = blockTransfersPrefix + num (uint64 big endian) + hash
+func blockTransfersKey(number uint64, hash common.Hash) []byte {
+	return append(append(blockTransfersPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
+// addressIndexKey = addressIndexPrefix + address + num (uint64 big endian) + tx index (uint32 big endian) + role
+func addressIndexKey(addr common.Address, number uint64, index uint32, role byte) []byte {
+	key := make([]byte, len(addressIndexPrefix)+common.AddressLength+8+4+1)
+	copy(key, addressIndexPrefix)
+	copy(key[len(addressIndexPrefix):], addr.Bytes())
+	binary.BigEndian.PutUint64(key[len(addressIndexPrefix)+common.AddressLength:], number)
+	binary.BigEndian.PutUint32(key[len(addressIndexPrefix)+common.AddressLength+8:], index)
+	key[len(key)-1] = role
+	return key
+}
+
+// tokenBalanceKey = tokenBalancePrefix + holder + token + id
+func tokenBalanceKey(holder common.Address, token common.Address, id common.Hash) []byte {
+	return append(append(append(tokenBalancePrefix, holder.Bytes()...), token.Bytes()...), id.Bytes()...)
+}
+
+// tokenHolderKey = tokenHolderPrefix + token + holder
+func tokenHolderKey(token common.Address, holder common.Address) []byte {
+	return append(append(tokenHolderPrefix, token.Bytes()...), holder.Bytes()...)
+}
+
+// tokenInfoKey = tokenInfoPrefix + token
+func tokenInfoKey(token common.Address) []byte {
+	return append(tokenInfoPrefix, token.Bytes()...)
+}
+
+// tokenTransferKey = prefix + address + num (uint64 big endian) + log index (uint32 big endian) + item (uint32 big endian)
+func tokenTransferKey(prefix []byte, addr common.Address, number uint64, logIndex uint32, item uint32) []byte {
+	key := make([]byte, len(prefix)+common.AddressLength+8+4+4)
+	copy(key, prefix)
+	copy(key[len(prefix):], addr.Bytes())
+	binary.BigEndian.PutUint64(key[len(prefix)+common.AddressLength:], number)
+	binary.BigEndian.PutUint32(key[len(prefix)+common.AddressLength+8:], logIndex)
+	binary.BigEndian.PutUint32(key[len(prefix)+common.AddressLength+12:], item)
+	return key
+}
+
+// tokenJournalKey = tokenJournalPrefix + section (uint64 big endian)
+func tokenJournalKey(section uint64) []byte {
+	return append(tokenJournalPrefix, encodeBlockNumber(section)...)
+}
+
+// traceIndexKey = traceIndexPrefix + address + num (uint64 big endian)
+func traceIndexKey(addr common.Address, number uint64) []byte {
+	return append(append(traceIndexPrefix, addr.Bytes()...), encodeBlockNumber(number)...)
+}
+
 // accountSnapshotKey = SnapshotAccountPrefix + hash
 func accountSnapshotKey(hash common.Hash) []byte {
 	return append(SnapshotAccountPrefix, hash.Bytes()...)
@@ -227,6 +356,58 @@ func IsCodeKey(key []byte) (bool, []byte) {
 	return false, nil
 }
 
+// accountTrieNodeKey = TrieNodeAccountPrefix + nodePath.
+func accountTrieNodeKey(path []byte) []byte {
+	key := make([]byte, len(TrieNodeAccountPrefix)+len(path))
+	copy(key, TrieNodeAccountPrefix)
+	copy(key[len(TrieNodeAccountPrefix):], path)
+	return key
+}
+
+// storageTrieNodeKey = TrieNodeStoragePrefix + accountHash + nodePath.
+func storageTrieNodeKey(accountHash common.Hash, path []byte) []byte {
+	key := make([]byte, len(TrieNodeStoragePrefix)+common.HashLength+len(path))
+	n := copy(key, TrieNodeStoragePrefix)
+	n += copy(key[n:], accountHash.Bytes())
+	copy(key[n:], path)
+	return key
+}
+
+// IsAccountTrieNodeKey reports whether the given byte slice is the key of an
+// account trie node stored by path. Node paths are made of nibbles, which tells
+// them apart from the legacy hash keys sharing the prefix byte.
+func IsAccountTrieNodeKey(key []byte) bool {
+	if !bytes.HasPrefix(key, TrieNodeAccountPrefix) {
+		return false
+	}
+	return isNibbles(key[len(TrieNodeAccountPrefix):]) && len(key) <= len(TrieNodeAccountPrefix)+2*common.HashLength
+}
+
+// IsStorageTrieNodeKey reports whether the given byte slice is the key of a
+// storage trie node stored by path.
+func IsStorageTrieNodeKey(key []byte) bool {
+	if !bytes.HasPrefix(key, TrieNodeStoragePrefix) || len(key) < len(TrieNodeStoragePrefix)+common.HashLength {
+		return false
+	}
+	path := key[len(TrieNodeStoragePrefix)+common.HashLength:]
+	return isNibbles(path) && len(path) <= 2*common.HashLength
+}
+
+// isNibbles reports whether every byte of the given slice is a hex nibble.
+func isNibbles(path []byte) bool {
+	for _, b := range path {
+		if b > 0x0f {
+			return false
+		}
+	}
+	return true
+}
+
+// stateIDKey = stateIDPrefix + root (32 bytes)
+func stateIDKey(root common.Hash) []byte {
+	return append(stateIDPrefix, root.Bytes()...)
+}
+
 // configKey = configPrefix + hash
 func configKey(hash common.Hash) []byte {
 	return append(configPrefix, hash.Bytes()...)
@@ -92,6 +92,10 @@ type Trie interface {
 	// and external (for account tries) references.
 	Commit(onleaf trie.LeafCallback) (common.Hash, int, error)
 
+	// CommittedNodes returns the nodes updated or deleted by the last commit, only
+	// collected if the trie database uses the path-based scheme.
+	CommittedNodes() *trie.NodeSet
+
 	// NodeIterator returns an iterator that returns nodes of the trie. Iteration
 	// starts at the key after the given start key.
 	NodeIterator(startKey []byte) trie.NodeIterator
@@ -116,7 +120,18 @@ func NewDatabase(db ethdb.Database) Database {
 // NewDatabaseWithConfig creates a backing store for state. The returned database
 // is safe for concurrent use and retains a lot of collapsed RLP trie nodes in a
 // large memory cache.
+//
+// If no state scheme is configured, the one already in use by the database is
+// picked up, without retaining any state histories.
 func NewDatabaseWithConfig(db ethdb.Database, config *trie.Config) Database {
+	if (config == nil || config.Scheme == "") && rawdb.IsPathScheme(db) {
+		var cpy trie.Config
+		if config != nil {
+			cpy = *config
+		}
+		cpy.Scheme, cpy.StateHistory = rawdb.PathScheme, 0
+		config = &cpy
+	}
 	csc, _ := lru.New(codeSizeCacheSize)
 	cc, _ := lru.NewARC(codeCacheSize)
 	return &cachingDB{
@@ -143,7 +158,7 @@ func (db *cachingDB) OpenTrie(root common.Hash) (Trie, error) {
 
 // OpenStorageTrie opens the storage trie of an account.
 func (db *cachingDB) OpenStorageTrie(addrHash, root common.Hash) (Trie, error) {
-	tr, err := trie.NewSecure(root, db.db)
+	tr, err := trie.NewSecureWithOwner(addrHash, root, db.db)
 	if err != nil {
 		return nil, err
 	}
@@ -125,6 +125,9 @@ type (
 	addLogChange struct {
 		txhash common.Hash
 	}
+	addTransferChange struct {
+		txhash common.Hash
+	}
 	addPreimageChange struct {
 		hash common.Hash
 	}
@@ -144,6 +147,7 @@ type (
 		account            *common.Address
 		prevcode, prevhash []byte
 		prevroot           common.Hash
+		prevdestruct       bool
 	}
 )
 
@@ -158,8 +162,11 @@ func (ch createObjectChange) dirtied() *common.Address {
 
 func (ch resetObjectChange) revert(s *StateDB) {
 	s.setStateObject(ch.prev)
-	if !ch.prevdestruct && s.snap != nil {
-		delete(s.snapDestructs, ch.prev.addrHash)
+	if !ch.prevdestruct {
+		delete(s.stateObjectsDestruct, ch.prev.address)
+		if s.snap != nil {
+			delete(s.snapDestructs, ch.prev.addrHash)
+		}
 	}
 }
 
@@ -242,6 +249,19 @@ func (ch addLogChange) dirtied() *common.Address {
 	return nil
 }
 
+func (ch addTransferChange) revert(s *StateDB) {
+	transfers := s.transfers[ch.txhash]
+	if len(transfers) == 1 {
+		delete(s.transfers, ch.txhash)
+	} else {
+		s.transfers[ch.txhash] = transfers[:len(transfers)-1]
+	}
+}
+
+func (ch addTransferChange) dirtied() *common.Address {
+	return nil
+}
+
 func (ch addPreimageChange) revert(s *StateDB) {
 	delete(s.preimages, ch.hash)
 }
@@ -278,6 +298,9 @@ func (ch accessListAddSlotChange) dirtied() *common.Address {
 func (ch eraseChange) revert(s *StateDB) {
 	obj := s.getStateObject(*ch.account)
 	obj.revertErase(common.BytesToHash(ch.prevhash), ch.prevcode, ch.prevroot)
+	if !ch.prevdestruct {
+		delete(s.stateObjectsDestruct, *ch.account)
+	}
 }
 
 func (ch eraseChange) dirtied() *common.Address {
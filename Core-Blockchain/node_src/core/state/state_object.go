@@ -29,6 +29,7 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
 )
 
 var emptyCodeHash = crypto.Keccak256(nil)
@@ -164,7 +165,7 @@ func (s *stateObject) getTrie(db Database) Trie {
 		if s.data.Root != emptyRoot && s.db.prefetcher != nil {
 			// When the miner is creating the pending state, there is no
 			// prefetcher
-			s.trie = s.db.prefetcher.trie(s.data.Root)
+			s.trie = s.db.prefetcher.trie(s.addrHash, s.data.Root)
 		}
 		if s.trie == nil {
 			var err error
@@ -279,7 +280,7 @@ func (s *stateObject) finalise(prefetch bool) {
 		}
 	}
 	if s.db.prefetcher != nil && prefetch && len(slotsToPrefetch) > 0 && s.data.Root != emptyRoot {
-		s.db.prefetcher.prefetch(s.data.Root, slotsToPrefetch)
+		s.db.prefetcher.prefetch(s.addrHash, s.data.Root, slotsToPrefetch)
 	}
 	if len(s.dirtyStorage) > 0 {
 		s.dirtyStorage = make(Storage)
@@ -343,7 +344,7 @@ func (s *stateObject) updateSnapshot() {
 		s.snapStorage = nil
 	}
 	if s.db.prefetcher != nil && s.usedStorage != nil {
-		s.db.prefetcher.used(s.data.Root, s.usedStorage)
+		s.db.prefetcher.used(s.addrHash, s.data.Root, s.usedStorage)
 		s.usedStorage = nil
 	}
 }
@@ -363,24 +364,26 @@ func (s *stateObject) updateTrie(db Database) Trie {
 }
 
 // CommitTrie the storage trie of the object to db.
-// This updates the trie root.
-func (s *stateObject) CommitTrie(db Database) (int, error) {
+// This updates the trie root. The nodes committed are returned with the
+// path-based scheme.
+func (s *stateObject) CommitTrie(db Database) (*trie.NodeSet, int, error) {
 	// If nothing changed, don't bother with hashing anything
 	if s.updateTrie(db) == nil {
-		return 0, nil
+		return nil, 0, nil
 	}
 	if s.dbErr != nil {
-		return 0, s.dbErr
+		return nil, 0, s.dbErr
 	}
 	// Track the amount of time wasted on committing the storage trie
 	if metrics.EnabledExpensive {
 		defer func(start time.Time) { s.db.StorageCommits += time.Since(start) }(time.Now())
 	}
 	root, committed, err := s.trie.Commit(nil)
-	if err == nil {
-		s.data.Root = root
+	if err != nil {
+		return nil, 0, err
 	}
-	return committed, err
+	s.data.Root = root
+	return s.trie.CommittedNodes(), committed, nil
 }
 
 // AddBalance adds amount to s's balance.
@@ -531,12 +534,16 @@ func (s *stateObject) Value() *big.Int {
 
 func (s *stateObject) erase() {
 	prevcode := s.Code(s.db.db)
+	_, prevdestruct := s.db.stateObjectsDestruct[s.address]
 	s.db.journal.append(eraseChange{
-		account:  &s.address,
-		prevhash: s.CodeHash(),
-		prevcode: prevcode,
-		prevroot: s.data.Root,
+		account:      &s.address,
+		prevhash:     s.CodeHash(),
+		prevcode:     prevcode,
+		prevroot:     s.data.Root,
+		prevdestruct: prevdestruct,
 	})
+	// The storage trie is dropped, its nodes must be deleted with the path-based scheme
+	s.db.stateObjectsDestruct[s.address] = struct{}{}
 
 	s.code = []byte{}
 	s.data.CodeHash = emptyCodeHash
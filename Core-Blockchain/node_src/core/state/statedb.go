@@ -78,9 +78,9 @@ type StateDB struct {
 	snapStorage   map[common.Hash]map[common.Hash][]byte
 
 	// This map holds 'live' objects, which will get modified while processing a state transition.
-	stateObjects        map[common.Address]*stateObject
-	stateObjectsPending map[common.Address]struct{} // State objects finalized but not yet written to the trie
-	stateObjectsDirty   map[common.Address]struct{} // State objects modified in the current execution
+	stateObjects         map[common.Address]*stateObject
+	stateObjectsPending  map[common.Address]struct{} // State objects finalized but not yet written to the trie
+	stateObjectsDirty    map[common.Address]struct{} // State objects modified in the current execution
 	stateObjectsDestruct map[common.Address]struct{} // State objects destructed in the block
 
 	// DB error.
@@ -98,6 +98,8 @@ type StateDB struct {
 	logs    map[common.Hash][]*types.Log
 	logSize uint
 
+	transfers map[common.Hash][]*types.InternalTransfer
+
 	preimages map[common.Hash][]byte
 
 	// Per-transaction access list
@@ -135,18 +137,20 @@ func New(root common.Hash, db Database, snaps *snapshot.Tree) (*StateDB, error)
 		return nil, err
 	}
 	sdb := &StateDB{
-		db:                  db,
-		trie:                tr,
-		originalRoot:        root,
-		snaps:               snaps,
-		stateObjects:        make(map[common.Address]*stateObject),
-		stateObjectsPending: make(map[common.Address]struct{}),
-		stateObjectsDirty:   make(map[common.Address]struct{}),
-		logs:                make(map[common.Hash][]*types.Log),
-		preimages:           make(map[common.Hash][]byte),
-		journal:             newJournal(),
-		accessList:          newAccessList(),
-		hasher:              crypto.NewKeccakState(),
+		db:                   db,
+		trie:                 tr,
+		originalRoot:         root,
+		snaps:                snaps,
+		stateObjects:         make(map[common.Address]*stateObject),
+		stateObjectsPending:  make(map[common.Address]struct{}),
+		stateObjectsDirty:    make(map[common.Address]struct{}),
+		stateObjectsDestruct: make(map[common.Address]struct{}),
+		logs:                 make(map[common.Hash][]*types.Log),
+		transfers:            make(map[common.Hash][]*types.InternalTransfer),
+		preimages:            make(map[common.Hash][]byte),
+		journal:              newJournal(),
+		accessList:           newAccessList(),
+		hasher:               crypto.NewKeccakState(),
 	}
 	if sdb.snaps != nil {
 		if sdb.snap = sdb.snaps.Snapshot(root); sdb.snap != nil {
@@ -209,6 +213,19 @@ func (s *StateDB) GetLogs(hash common.Hash, blockHash common.Hash) []*types.Log
 	return logs
 }
 
+// AddTransfer records a value transfer made by a contract in the current
+// transaction.
+func (s *StateDB) AddTransfer(transfer *types.InternalTransfer) {
+	s.journal.append(addTransferChange{txhash: s.thash})
+	s.transfers[s.thash] = append(s.transfers[s.thash], transfer)
+}
+
+// GetTransfers returns the value transfers made by contracts in the given
+// transaction, if transfer recording is enabled in the EVM.
+func (s *StateDB) GetTransfers(hash common.Hash) []*types.InternalTransfer {
+	return s.transfers[hash]
+}
+
 func (s *StateDB) Logs() []*types.Log {
 	var logs []*types.Log
 	for _, lgs := range s.logs {
@@ -279,6 +296,11 @@ func (s *StateDB) GetNonce(addr common.Address) uint64 {
 	return 0
 }
 
+// TxHash returns the current transaction hash set by Prepare.
+func (s *StateDB) TxHash() common.Hash {
+	return s.thash
+}
+
 // TxIndex returns the current transaction index set by Prepare.
 func (s *StateDB) TxIndex() int {
 	return s.txIndex
@@ -432,7 +454,7 @@ func (s *StateDB) SetStorage(addr common.Address, storage map[common.Hash]common
 	// to a previous incarnation of the object.
 	s.stateObjectsDestruct[addr] = struct{}{}
 	stateObject := s.GetOrNewStateObject(addr)
-	
+
 	for k, v := range storage {
 		stateObject.SetState(s.db, k, v)
 	}
@@ -656,7 +678,7 @@ func (s *StateDB) getDeletedStateObject(addr common.Address) *stateObject {
 		if metrics.EnabledExpensive {
 			s.AccountReads += time.Since(start)
 		}
-		
+
 		if err != nil {
 			s.setError(fmt.Errorf("getDeleteStateObject (%x) error: %v", addr.Bytes(), err))
 			return nil
@@ -695,10 +717,13 @@ func (s *StateDB) createObject(addr common.Address) (newobj, prev *stateObject)
 	prev = s.getDeletedStateObject(addr) // Note, prev might have been deleted, we need that!
 
 	var prevdestruct bool
-	if s.snap != nil && prev != nil {
-		_, prevdestruct = s.snapDestructs[prev.addrHash]
+	if prev != nil {
+		_, prevdestruct = s.stateObjectsDestruct[prev.address]
 		if !prevdestruct {
-			s.snapDestructs[prev.addrHash] = struct{}{}
+			s.stateObjectsDestruct[prev.address] = struct{}{}
+			if s.snap != nil {
+				s.snapDestructs[prev.addrHash] = struct{}{}
+			}
 		}
 	}
 	newobj = newObject(s, addr, types.StateAccount{})
@@ -720,8 +745,8 @@ func (s *StateDB) createObject(addr common.Address) (newobj, prev *stateObject)
 // CreateAccount is called during the EVM CREATE operation. The situation might arise that
 // a contract does the following:
 //
-//   1. sends funds to sha(account ++ (nonce + 1))
-//   2. tx_create(sha(account ++ nonce)) (note that this gets the address of 1)
+//  1. sends funds to sha(account ++ (nonce + 1))
+//  2. tx_create(sha(account ++ nonce)) (note that this gets the address of 1)
 //
 // Carrying over the balance ensures that Ether doesn't disappear.
 func (s *StateDB) CreateAccount(addr common.Address) {
@@ -765,17 +790,19 @@ func (db *StateDB) ForEachStorage(addr common.Address, cb func(key, value common
 func (s *StateDB) Copy() *StateDB {
 	// Copy all the basic fields, initialize the memory ones
 	state := &StateDB{
-		db:                  s.db,
-		trie:                s.db.CopyTrie(s.trie),
-		stateObjects:        make(map[common.Address]*stateObject, len(s.journal.dirties)),
-		stateObjectsPending: make(map[common.Address]struct{}, len(s.stateObjectsPending)),
-		stateObjectsDirty:   make(map[common.Address]struct{}, len(s.journal.dirties)),
-		refund:              s.refund,
-		logs:                make(map[common.Hash][]*types.Log, len(s.logs)),
-		logSize:             s.logSize,
-		preimages:           make(map[common.Hash][]byte, len(s.preimages)),
-		journal:             newJournal(),
-		hasher:              crypto.NewKeccakState(),
+		db:                   s.db,
+		trie:                 s.db.CopyTrie(s.trie),
+		stateObjects:         make(map[common.Address]*stateObject, len(s.journal.dirties)),
+		stateObjectsPending:  make(map[common.Address]struct{}, len(s.stateObjectsPending)),
+		stateObjectsDirty:    make(map[common.Address]struct{}, len(s.journal.dirties)),
+		stateObjectsDestruct: make(map[common.Address]struct{}, len(s.stateObjectsDestruct)),
+		refund:               s.refund,
+		logs:                 make(map[common.Hash][]*types.Log, len(s.logs)),
+		logSize:              s.logSize,
+		transfers:            make(map[common.Hash][]*types.InternalTransfer, len(s.transfers)),
+		preimages:            make(map[common.Hash][]byte, len(s.preimages)),
+		journal:              newJournal(),
+		hasher:               crypto.NewKeccakState(),
 	}
 	// Copy the dirty states, logs, and preimages
 	for addr := range s.journal.dirties {
@@ -808,6 +835,9 @@ func (s *StateDB) Copy() *StateDB {
 		}
 		state.stateObjectsDirty[addr] = struct{}{}
 	}
+	for addr := range s.stateObjectsDestruct {
+		state.stateObjectsDestruct[addr] = struct{}{}
+	}
 	for hash, logs := range s.logs {
 		cpy := make([]*types.Log, len(logs))
 		for i, l := range logs {
@@ -816,6 +846,9 @@ func (s *StateDB) Copy() *StateDB {
 		}
 		state.logs[hash] = cpy
 	}
+	for hash, transfers := range s.transfers {
+		state.transfers[hash] = append([]*types.InternalTransfer(nil), transfers...)
+	}
 	for hash, preimage := range s.preimages {
 		state.preimages[hash] = preimage
 	}
@@ -907,6 +940,7 @@ func (s *StateDB) Finalise(deleteEmptyObjects bool) {
 		}
 		if obj.suicided || (deleteEmptyObjects && obj.empty()) {
 			obj.deleted = true
+			s.stateObjectsDestruct[obj.address] = struct{}{}
 
 			// If state snapshotting is active, also mark the destruction there.
 			// Note, we can't do this only at the end of a block because multiple
@@ -929,7 +963,7 @@ func (s *StateDB) Finalise(deleteEmptyObjects bool) {
 		addressesToPrefetch = append(addressesToPrefetch, common.CopyBytes(addr[:])) // Copy needed for closure
 	}
 	if s.prefetcher != nil && len(addressesToPrefetch) > 0 {
-		s.prefetcher.prefetch(s.originalRoot, addressesToPrefetch)
+		s.prefetcher.prefetch(common.Hash{}, s.originalRoot, addressesToPrefetch)
 	}
 	// Invalidate journal because reverting across transactions is not allowed.
 	s.clearJournalAndRefund()
@@ -987,7 +1021,7 @@ func (s *StateDB) IntermediateRoot(deleteEmptyObjects bool) common.Hash {
 	// _untouched_. We can check with the prefetcher, if it can give us a trie
 	// which has the same root, but also has some content loaded into it.
 	if prefetcher != nil {
-		if trie := prefetcher.trie(s.originalRoot); trie != nil {
+		if trie := prefetcher.trie(common.Hash{}, s.originalRoot); trie != nil {
 			s.trie = trie
 		}
 	}
@@ -1003,7 +1037,7 @@ func (s *StateDB) IntermediateRoot(deleteEmptyObjects bool) common.Hash {
 		usedAddrs = append(usedAddrs, common.CopyBytes(addr[:])) // Copy needed for closure
 	}
 	if prefetcher != nil {
-		prefetcher.used(s.originalRoot, usedAddrs)
+		prefetcher.used(common.Hash{}, s.originalRoot, usedAddrs)
 	}
 	if len(s.stateObjectsPending) > 0 {
 		s.stateObjectsPending = make(map[common.Address]struct{})
@@ -1041,6 +1075,10 @@ func (s *StateDB) Commit(deleteEmptyObjects bool) (common.Hash, error) {
 
 	// Commit objects to the trie, measuring the elapsed time
 	var storageCommitted int
+	nodes := trie.NewMergedNodeSet()
+	if err := s.wipeDestructedStorage(nodes); err != nil {
+		return common.Hash{}, err
+	}
 	codeWriter := s.db.TrieDB().DiskDB().NewBatch()
 	for addr := range s.stateObjectsDirty {
 		if obj := s.stateObjects[addr]; !obj.deleted {
@@ -1050,10 +1088,11 @@ func (s *StateDB) Commit(deleteEmptyObjects bool) (common.Hash, error) {
 				obj.dirtyCode = false
 			}
 			// Write any storage changes in the state object to its storage trie
-			committed, err := obj.CommitTrie(s.db)
+			set, committed, err := obj.CommitTrie(s.db)
 			if err != nil {
 				return common.Hash{}, err
 			}
+			nodes.Merge(set)
 			storageCommitted += committed
 		}
 	}
@@ -1085,6 +1124,12 @@ func (s *StateDB) Commit(deleteEmptyObjects bool) (common.Hash, error) {
 	if err != nil {
 		return common.Hash{}, err
 	}
+	nodes.Merge(s.trie.CommittedNodes())
+	if err := s.db.TrieDB().Update(root, s.originalRoot, nodes); err != nil {
+		return common.Hash{}, err
+	}
+	s.originalRoot = root
+
 	if metrics.EnabledExpensive {
 		s.AccountCommits += time.Since(start)
 
@@ -1120,6 +1165,53 @@ func (s *StateDB) Commit(deleteEmptyObjects bool) (common.Hash, error) {
 	return root, err
 }
 
+// wipeDestructedStorage marks all the storage trie nodes of the accounts
+// destructed since the state was opened as deleted. It's only needed by the
+// path-based scheme, where nodes are not dropped along with their trie root.
+func (s *StateDB) wipeDestructedStorage(nodes *trie.MergedNodeSet) error {
+	if len(s.stateObjectsDestruct) == 0 || s.db.TrieDB().Scheme() != rawdb.PathScheme {
+		return nil
+	}
+	tr, err := s.db.OpenTrie(s.originalRoot)
+	if err != nil {
+		return err
+	}
+	for addr := range s.stateObjectsDestruct {
+		enc, err := tr.TryGet(addr.Bytes())
+		if err != nil {
+			return err
+		}
+		if len(enc) == 0 {
+			continue
+		}
+		var account types.StateAccount
+		if err := rlp.DecodeBytes(enc, &account); err != nil {
+			return err
+		}
+		if account.Root == emptyRoot {
+			continue
+		}
+		addrHash := crypto.Keccak256Hash(addr.Bytes())
+		storage, err := s.db.OpenStorageTrie(addrHash, account.Root)
+		if err != nil {
+			return err
+		}
+		set := trie.NewNodeSet(addrHash)
+		it := storage.NodeIterator(nil)
+		for it.Next(true) {
+			if it.Hash() != (common.Hash{}) {
+				set.MarkDeleted(it.Path())
+			}
+		}
+		if it.Error() != nil {
+			return it.Error()
+		}
+		nodes.Merge(set)
+	}
+	s.stateObjectsDestruct = make(map[common.Address]struct{})
+	return nil
+}
+
 // PrepareAccessList handles the preparatory steps for executing a state transition with
 // regards to both EIP-2929 and EIP-2930:
 //
@@ -1242,15 +1334,21 @@ func (s *StateDB) AsyncCommit(deleteEmptyObjects bool, afterCommit func(common.H
 		defer s.db.TrieDB().FlushLatch.Done()
 		// Commit objects to the trie, measuring the elapsed time
 		var storageCommitted int
+		nodes := trie.NewMergedNodeSet()
+		if err := s.wipeDestructedStorage(nodes); err != nil {
+			log.Crit("Aync commit storage wipe error", "err", err)
+			return
+		}
 		for addr := range s.stateObjectsDirty {
 			if obj := s.stateObjects[addr]; !obj.deleted {
 
 				// Write any storage changes in the state object to its storage trie
-				committed, err := obj.CommitTrie(s.db)
+				set, committed, err := obj.CommitTrie(s.db)
 				if err != nil {
 					log.Crit("Aync commit storage trie error", "addr", addr, "err", err)
 					return
 				}
+				nodes.Merge(set)
 				storageCommitted += committed
 			}
 		}
@@ -1290,6 +1388,11 @@ func (s *StateDB) AsyncCommit(deleteEmptyObjects bool, afterCommit func(common.H
 			s.StorageUpdated, s.StorageDeleted = 0, 0
 		}
 		if err == nil {
+			nodes.Merge(s.trie.CommittedNodes())
+			err = s.db.TrieDB().Update(commitRoot, s.originalRoot, nodes)
+		}
+		if err == nil {
+			s.originalRoot = commitRoot
 			afterCommit(commitRoot)
 		} else {
 			log.Crit("Aync commit account trie error", "err", err)
@@ -33,6 +33,8 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/trie"
 )
 
 // Tests that updating a state trie does not leak any database writes prior to
@@ -973,3 +975,61 @@ func TestErase(t *testing.T) {
 		t.Fatal("erase should not change balance")
 	}
 }
+
+// Tests that the storage trie nodes of destructed accounts are deleted from
+// disk with the path-based scheme, while the other accounts are left intact.
+func TestPathSchemeDestructedStorage(t *testing.T) {
+	memDb := rawdb.NewMemoryDatabase()
+	db := NewDatabaseWithConfig(memDb, &trie.Config{Scheme: rawdb.PathScheme})
+	state, _ := New(common.Hash{}, db, nil)
+
+	var (
+		doomed = common.BytesToAddress([]byte("doomed"))
+		kept   = common.BytesToAddress([]byte("kept"))
+	)
+	for i := 0; i < 64; i++ {
+		key, val := common.BigToHash(big.NewInt(int64(i))), common.BigToHash(big.NewInt(int64(i+1)))
+		state.SetState(doomed, key, val)
+		state.SetState(kept, key, val)
+	}
+	root, err := state.Commit(false)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	if err := db.TrieDB().Commit(root, false, nil); err != nil {
+		t.Fatalf("failed to persist state: %v", err)
+	}
+	countNodes := func(addr common.Address) int {
+		owner := crypto.Keccak256Hash(addr.Bytes())
+		it := memDb.NewIterator(append(common.CopyBytes(rawdb.TrieNodeStoragePrefix), owner.Bytes()...), nil)
+		defer it.Release()
+
+		var count int
+		for it.Next() {
+			count++
+		}
+		return count
+	}
+	if countNodes(doomed) == 0 || countNodes(kept) == 0 {
+		t.Fatalf("storage trie nodes missing")
+	}
+	state, _ = New(root, db, nil)
+	state.Suicide(doomed)
+	root, err = state.Commit(true)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	if err := db.TrieDB().Commit(root, false, nil); err != nil {
+		t.Fatalf("failed to persist state: %v", err)
+	}
+	if n := countNodes(doomed); n != 0 {
+		t.Fatalf("destructed storage left on disk: %d nodes", n)
+	}
+	state, _ = New(root, NewDatabaseWithConfig(memDb, &trie.Config{Scheme: rawdb.PathScheme}), nil)
+	for i := 0; i < 64; i++ {
+		key, val := common.BigToHash(big.NewInt(int64(i))), common.BigToHash(big.NewInt(int64(i+1)))
+		if have := state.GetState(kept, key); have != val {
+			t.Fatalf("slot %d: value mismatch: have %x, want %x", i, have, val)
+		}
+	}
+}
@@ -181,6 +181,7 @@ func applyTransaction(msg types.Message, config *params.ChainConfig, bc ChainCon
 	}
 	receipt.TxHash = tx.Hash()
 	receipt.GasUsed = result.UsedGas
+	receipt.DeniedCreator = result.DeniedCreator
 
 	// If the transaction created a contract, store the creation address in the receipt.
 	if msg.To() == nil {
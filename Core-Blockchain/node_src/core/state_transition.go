@@ -94,6 +94,8 @@ type ExecutionResult struct {
 	UsedGas    uint64 // Total used gas but include the refunded gas
 	Err        error  // Any error encountered during the execution(listed in core/vm/errors.go)
 	ReturnData []byte // Returned data from evm(function result or data supplied with revert opcode)
+
+	DeniedCreator *common.Address // Address whose CREATE/CREATE2 was denied by the deployment allowlist, if any
 }
 
 // Unwrap returns the internal evm error which allows us for further
@@ -367,32 +369,38 @@ var gaslessTokenWhitelist = map[common.Address]bool{
 
 // isX402Transaction checks if the current transaction is an X402 transaction
 func (st *StateTransition) isX402Transaction() bool {
+	return IsX402Transaction(st.msg.To(), st.data, st.evm.Context.BlockNumber)
+}
+
+// IsX402Transaction checks if a transaction with the given recipient and data is
+// an X402 transaction in the block with the given number
+func IsX402Transaction(to *common.Address, data []byte, number *big.Int) bool {
 	// Method 1: Check for hex-encoded X402 metadata (0x78343032 = "x402" in hex)
 	x402HexPrefix := []byte{0x78, 0x34, 0x30, 0x32} // "x402" in hex
-	if len(st.data) >= 4 && bytes.HasPrefix(st.data, x402HexPrefix) {
-		log.Debug("X402 hex metadata detected", "data_prefix", fmt.Sprintf("0x%x", st.data[:8]))
-		return st.isValidX402Target()
+	if len(data) >= 4 && bytes.HasPrefix(data, x402HexPrefix) {
+		log.Debug("X402 hex metadata detected", "data_prefix", fmt.Sprintf("0x%x", data[:4]))
+		return isValidX402Target(to)
 	}
 	
 	// Method 2: Check for literal x402 metadata (backward compatibility)
-	if len(st.data) >= 4 && bytes.HasPrefix(st.data, []byte("x402")) {
-		log.Debug("X402 literal metadata detected", "data_prefix", string(st.data[:4]))
-		return st.isValidX402Target()
+	if len(data) >= 4 && bytes.HasPrefix(data, []byte("x402")) {
+		log.Debug("X402 literal metadata detected", "data_prefix", string(data[:4]))
+		return isValidX402Target(to)
 	}
 	
 	// Method 3: Check for X402 metadata prefix in meta transactions
-	if types.IsMetaTransaction(st.data) {
-		metaData, err := types.DecodeMetaData(st.data, st.evm.Context.BlockNumber)
+	if types.IsMetaTransaction(data) {
+		metaData, err := types.DecodeMetaData(data, number)
 		if err == nil {
 			// Check if payload contains hex-encoded x402 identifier
 			if len(metaData.Payload) >= 4 && bytes.HasPrefix(metaData.Payload, x402HexPrefix) {
 				log.Debug("X402 hex metadata detected in meta transaction")
-				return st.isValidX402Target()
+				return isValidX402Target(to)
 			}
 			// Check if payload contains literal x402 identifier (backward compatibility)
 			if bytes.Contains(metaData.Payload, []byte("x402")) {
 				log.Debug("X402 literal metadata detected in meta transaction")
-				return st.isValidX402Target()
+				return isValidX402Target(to)
 			}
 		}
 	}
@@ -401,15 +409,15 @@ func (st *StateTransition) isX402Transaction() bool {
 }
 
 // isValidX402Target checks if the transaction target is eligible for gasless policy
-func (st *StateTransition) isValidX402Target() bool {
+func isValidX402Target(to *common.Address) bool {
 	// If whitelist is empty, allow all targets (current behavior)
 	if len(gaslessTokenWhitelist) == 0 {
 		return true
 	}
 	
 	// Check if transaction is to a whitelisted token address
-	if st.msg.To() != nil {
-		targetAddress := *st.msg.To()
+	if to != nil {
+		targetAddress := *to
 		if gaslessTokenWhitelist[targetAddress] {
 			log.Debug("X402 gasless transaction to whitelisted token", "token", targetAddress.Hex())
 			return true
@@ -533,6 +541,12 @@ func (st *StateTransition) TransitionDb() (*ExecutionResult, error) {
 	if err := st.preCheck(); err != nil {
 		return nil, err
 	}
+	if st.evm.Config.Debug {
+		st.evm.Config.Tracer.CaptureTxStart(st.initialGas)
+		defer func() {
+			st.evm.Config.Tracer.CaptureTxEnd(st.gas)
+		}()
+	}
 	
 	// Process X402 transaction data - strip X402 metadata before execution
 	if st.isX402Transaction() {
@@ -615,9 +629,10 @@ func (st *StateTransition) TransitionDb() (*ExecutionResult, error) {
 	}
 
 	return &ExecutionResult{
-		UsedGas:    st.gasUsed(),
-		Err:        vmerr,
-		ReturnData: ret,
+		UsedGas:       st.gasUsed(),
+		Err:           vmerr,
+		ReturnData:    ret,
+		DeniedCreator: st.evm.DeniedCreator(),
 	}, nil
 }
 
@@ -0,0 +1,419 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// TokenStandard is the token standard of a transfer.
+type TokenStandard uint16
+
+const (
+	TokenERC20   TokenStandard = 20   // Fungible token, Transfer with the value in the data
+	TokenERC721  TokenStandard = 721  // Non-fungible token, Transfer with the id as the last topic
+	TokenERC1155 TokenStandard = 1155 // Multi token, TransferSingle and TransferBatch
+)
+
+// String implements fmt.Stringer.
+func (s TokenStandard) String() string {
+	switch s {
+	case TokenERC20:
+		return "erc20"
+	case TokenERC721:
+		return "erc721"
+	case TokenERC1155:
+		return "erc1155"
+	}
+	return "unknown"
+}
+
+var (
+	// TransferTopic is the event signature of the ERC-20 and ERC-721 transfers.
+	TransferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+	// TransferSingleTopic is the event signature of the ERC-1155 single transfers.
+	TransferSingleTopic = crypto.Keccak256Hash([]byte("TransferSingle(address,address,address,uint256,uint256)"))
+
+	// TransferBatchTopic is the event signature of the ERC-1155 batch transfers.
+	TransferBatchTopic = crypto.Keccak256Hash([]byte("TransferBatch(address,address,address,uint256[],uint256[])"))
+)
+
+// MayContainTokenTransfers reports whether a block may log token transfers, and
+// if addresses are given, whether it may log transfers involving any of them.
+func MayContainTokenTransfers(bloom types.Bloom, addrs ...common.Address) bool {
+	if !types.BloomLookup(bloom, TransferTopic) && !types.BloomLookup(bloom, TransferSingleTopic) && !types.BloomLookup(bloom, TransferBatchTopic) {
+		return false
+	}
+	if len(addrs) == 0 {
+		return true
+	}
+	for _, addr := range addrs {
+		// Holders are indexed as topics, tokens as log addresses
+		if types.BloomLookup(bloom, common.BytesToHash(addr.Bytes())) || types.BloomLookup(bloom, addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// BlockTokenTransfers decodes the standard token transfers logged by the receipts
+// of a block. Logs that don't match the layout of their standard are skipped.
+func BlockTokenTransfers(header *types.Header, receipts types.Receipts) []*rawdb.TokenTransfer {
+	var (
+		number    = header.Number.Uint64()
+		hash      = header.Hash()
+		transfers []*rawdb.TokenTransfer
+		logIndex  uint32
+	)
+	for txIndex, receipt := range receipts {
+		for _, l := range receipt.Logs {
+			for i, transfer := range decodeTokenTransfers(l) {
+				transfer.Number = number
+				transfer.LogIndex = logIndex
+				transfer.Item = uint32(i)
+				transfer.BlockHash = hash
+				transfer.TxIndex = uint32(txIndex)
+				transfers = append(transfers, transfer)
+			}
+			logIndex++
+		}
+	}
+	return transfers
+}
+
+// decodeTokenTransfers decodes the token transfers of a log, nil if it's not a
+// standard transfer event.
+func decodeTokenTransfers(l *types.Log) []*rawdb.TokenTransfer {
+	if len(l.Topics) == 0 {
+		return nil
+	}
+	switch {
+	case l.Topics[0] == TransferTopic && len(l.Topics) == 3 && len(l.Data) == 32:
+		return []*rawdb.TokenTransfer{{
+			Standard: uint16(TokenERC20),
+			Token:    l.Address,
+			From:     common.BytesToAddress(l.Topics[1][:]),
+			To:       common.BytesToAddress(l.Topics[2][:]),
+			Value:    new(big.Int).SetBytes(l.Data),
+		}}
+
+	case l.Topics[0] == TransferTopic && len(l.Topics) == 4 && len(l.Data) == 0:
+		return []*rawdb.TokenTransfer{{
+			Standard: uint16(TokenERC721),
+			Token:    l.Address,
+			From:     common.BytesToAddress(l.Topics[1][:]),
+			To:       common.BytesToAddress(l.Topics[2][:]),
+			ID:       l.Topics[3],
+			Value:    big.NewInt(1),
+		}}
+
+	case l.Topics[0] == TransferSingleTopic && len(l.Topics) == 4 && len(l.Data) == 64:
+		return []*rawdb.TokenTransfer{{
+			Standard: uint16(TokenERC1155),
+			Token:    l.Address,
+			From:     common.BytesToAddress(l.Topics[2][:]),
+			To:       common.BytesToAddress(l.Topics[3][:]),
+			ID:       common.BytesToHash(l.Data[:32]),
+			Value:    new(big.Int).SetBytes(l.Data[32:]),
+		}}
+
+	case l.Topics[0] == TransferBatchTopic && len(l.Topics) == 4:
+		ids, err := decodeWordArray(l.Data, 0)
+		if err != nil {
+			return nil
+		}
+		values, err := decodeWordArray(l.Data, 1)
+		if err != nil || len(values) != len(ids) {
+			return nil
+		}
+		transfers := make([]*rawdb.TokenTransfer, len(ids))
+		for i := range ids {
+			transfers[i] = &rawdb.TokenTransfer{
+				Standard: uint16(TokenERC1155),
+				Token:    l.Address,
+				From:     common.BytesToAddress(l.Topics[2][:]),
+				To:       common.BytesToAddress(l.Topics[3][:]),
+				ID:       common.BytesToHash(ids[i]),
+				Value:    new(big.Int).SetBytes(values[i]),
+			}
+		}
+		return transfers
+	}
+	return nil
+}
+
+// decodeWordArray decodes the ABI encoded dynamic array of 32 byte words whose
+// offset is the given argument of the data.
+func decodeWordArray(data []byte, arg int) ([][]byte, error) {
+	if len(data) < 32*(arg+1) {
+		return nil, errors.New("missing array offset")
+	}
+	offset := new(big.Int).SetBytes(data[32*arg : 32*(arg+1)])
+	if !offset.IsUint64() || offset.Uint64()%32 != 0 || offset.Uint64()+32 > uint64(len(data)) {
+		return nil, errors.New("invalid array offset")
+	}
+	start := offset.Uint64()
+	length := new(big.Int).SetBytes(data[start : start+32])
+	if !length.IsUint64() || length.Uint64() > (uint64(len(data))-start-32)/32 {
+		return nil, errors.New("invalid array length")
+	}
+	words := make([][]byte, length.Uint64())
+	for i := range words {
+		words[i] = data[start+32*uint64(i+1) : start+32*uint64(i+2)]
+	}
+	return words, nil
+}
+
+// applyTokenTransfer applies the balance changes of a token transfer to the
+// token holdings. Balances that would go negative, e.g. the ones of tokens minted
+// without logging a transfer, are clamped at zero.
+func applyTokenTransfer(db tokenHoldingsStore, transfer *rawdb.TokenTransfer) {
+	info := rawdb.ReadTokenInfo(db, transfer.Token)
+	if info == nil {
+		info = &rawdb.TokenInfo{Standard: transfer.Standard}
+	}
+	info.Transfers++
+
+	if transfer.Value.Sign() > 0 && transfer.From != transfer.To {
+		if transfer.From != (common.Address{}) {
+			balance := rawdb.ReadTokenBalance(db, transfer.From, transfer.Token, transfer.ID)
+			if balance.Sign() > 0 {
+				balance.Sub(balance, transfer.Value)
+				if balance.Sign() <= 0 {
+					balance.SetUint64(0)
+					ids := rawdb.ReadTokenHolderIDs(db, transfer.Token, transfer.From)
+					if ids > 0 {
+						ids--
+						if ids == 0 && info.Holders > 0 {
+							info.Holders--
+						}
+						rawdb.WriteTokenHolderIDs(db, transfer.Token, transfer.From, ids)
+					}
+				}
+				rawdb.WriteTokenBalance(db, transfer.From, transfer.Token, transfer.ID, balance)
+			}
+		}
+		if transfer.To != (common.Address{}) {
+			balance := rawdb.ReadTokenBalance(db, transfer.To, transfer.Token, transfer.ID)
+			if balance.Sign() == 0 {
+				ids := rawdb.ReadTokenHolderIDs(db, transfer.Token, transfer.To)
+				if ids == 0 {
+					info.Holders++
+				}
+				rawdb.WriteTokenHolderIDs(db, transfer.Token, transfer.To, ids+1)
+			}
+			rawdb.WriteTokenBalance(db, transfer.To, transfer.Token, transfer.ID, balance.Add(balance, transfer.Value))
+		}
+	}
+	rawdb.WriteTokenInfo(db, transfer.Token, info)
+}
+
+// tokenHoldingsStore is the part of a database the token holdings are read from
+// and written into.
+type tokenHoldingsStore interface {
+	ethdb.KeyValueReader
+	ethdb.KeyValueWriter
+}
+
+// tokenOverlay accumulates the changes of the token holdings on top of the
+// database, journaling the previous values of the modified keys.
+type tokenOverlay struct {
+	db      ethdb.KeyValueReader
+	dirty   map[string][]byte // Modified values, nil for deleted keys
+	journal []rawdb.TokenJournalEntry
+}
+
+func newTokenOverlay(db ethdb.KeyValueReader) *tokenOverlay {
+	return &tokenOverlay{db: db, dirty: make(map[string][]byte)}
+}
+
+// Has implements ethdb.KeyValueReader.
+func (o *tokenOverlay) Has(key []byte) (bool, error) {
+	if value, ok := o.dirty[string(key)]; ok {
+		return value != nil, nil
+	}
+	return o.db.Has(key)
+}
+
+// Get implements ethdb.KeyValueReader.
+func (o *tokenOverlay) Get(key []byte) ([]byte, error) {
+	if value, ok := o.dirty[string(key)]; ok {
+		if value == nil {
+			return nil, errors.New("not found")
+		}
+		return common.CopyBytes(value), nil
+	}
+	return o.db.Get(key)
+}
+
+// Put implements ethdb.KeyValueWriter.
+func (o *tokenOverlay) Put(key []byte, value []byte) error {
+	o.set(key, common.CopyBytes(value))
+	return nil
+}
+
+// Delete implements ethdb.KeyValueWriter.
+func (o *tokenOverlay) Delete(key []byte) error {
+	o.set(key, nil)
+	return nil
+}
+
+func (o *tokenOverlay) set(key []byte, value []byte) {
+	if _, ok := o.dirty[string(key)]; !ok {
+		prev, _ := o.db.Get(key)
+		o.journal = append(o.journal, rawdb.TokenJournalEntry{Key: common.CopyBytes(key), Prev: common.CopyBytes(prev)})
+	}
+	o.dirty[string(key)] = value
+}
+
+// flush writes the accumulated changes into a batch.
+func (o *tokenOverlay) flush(batch ethdb.KeyValueWriter) error {
+	for key, value := range o.dirty {
+		var err error
+		if value == nil {
+			err = batch.Delete([]byte(key))
+		} else {
+			err = batch.Put([]byte(key), value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tokenJournalSections is the number of most recent token index sections whose
+// holdings journals are kept, bounding how deep a reorg the holdings can follow.
+const tokenJournalSections = 2
+
+// TokenIndexer implements a core.ChainIndexer, indexing the standard token
+// transfers and the resulting holdings of each address. It runs as a child of the
+// bloombits indexer, reusing its sections and skipping the blocks whose header
+// bloom rules out token transfers.
+//
+// The holdings of a section are applied on top of the ones of the previous
+// sections, journaling the previous values so that the sections of reorged blocks
+// can be reverted before they're indexed again. Only the journals of the last
+// tokenJournalSections sections are kept.
+type TokenIndexer struct {
+	db      ethdb.Database // database instance to write index data into
+	section uint64         // section being processed
+	overlay *tokenOverlay  // holdings changes of the section being processed
+	batch   ethdb.Batch    // transfers of the section being processed
+}
+
+// NewTokenIndexer returns a chain indexer that indexes the token transfers and
+// holdings of the canonical chain. It has to be added as a child of the bloombits
+// indexer, its sections being processed as the bloombits ones are.
+func NewTokenIndexer(db ethdb.Database) *ChainIndexer {
+	table := rawdb.NewTable(db, string(rawdb.TokenIndexPrefix))
+	return NewChainIndexer(db, table, &TokenIndexer{db: db}, params.BloomBitsBlocks, 0, bloomThrottling, "tokens")
+}
+
+// Reset implements core.ChainIndexerBackend, starting a new token index section.
+// The holdings changes of the sections at or above it, left over by a reorg, are
+// reverted first, failing if they reach past the journaled sections. The transfers
+// of the reorged blocks are left in place, they are told apart by their block hash.
+func (t *TokenIndexer) Reset(ctx context.Context, section uint64, lastSectionHead common.Hash) error {
+	applied := rawdb.ReadTokenIndexApplied(t.db)
+	if applied < section {
+		return fmt.Errorf("token holdings applied up to section %d, missing section %d", applied, section)
+	}
+	if applied > section {
+		batch := t.db.NewBatch()
+		for applied > section {
+			applied--
+			journal := rawdb.ReadTokenJournal(t.db, applied)
+			if journal == nil {
+				return fmt.Errorf("token holdings journal of section %d unavailable", applied)
+			}
+			rawdb.RevertTokenJournal(batch, journal)
+			rawdb.DeleteTokenJournal(batch, applied)
+		}
+		rawdb.WriteTokenIndexApplied(batch, applied)
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		log.Info("Reverted token holdings", "section", section)
+	}
+	t.section = section
+	t.overlay = newTokenOverlay(t.db)
+	t.batch = t.db.NewBatch()
+	return nil
+}
+
+// Process implements core.ChainIndexerBackend, indexing the token transfers of a
+// block. Blocks without receipts, e.g. the ones beyond the history horizon, are
+// skipped.
+func (t *TokenIndexer) Process(ctx context.Context, header *types.Header) error {
+	if !MayContainTokenTransfers(header.Bloom) {
+		return nil
+	}
+	receipts := rawdb.ReadRawReceipts(t.db, header.Hash(), header.Number.Uint64())
+	if receipts == nil {
+		return nil
+	}
+	for _, transfer := range BlockTokenTransfers(header, receipts) {
+		applyTokenTransfer(t.overlay, transfer)
+		rawdb.WriteTokenTransfer(t.batch, transfer)
+	}
+	if t.batch.ValueSize() > ethdb.IdealBatchSize {
+		if err := t.batch.Write(); err != nil {
+			return err
+		}
+		t.batch.Reset()
+	}
+	return nil
+}
+
+// Commit implements core.ChainIndexerBackend, writing out the holdings changes of
+// the section atomically along with their journal, and dropping the journal of
+// the section falling out of the reorg window.
+func (t *TokenIndexer) Commit() error {
+	if err := t.overlay.flush(t.batch); err != nil {
+		return err
+	}
+	rawdb.WriteTokenJournal(t.batch, t.section, t.overlay.journal)
+	if t.section >= tokenJournalSections {
+		rawdb.DeleteTokenJournal(t.batch, t.section-tokenJournalSections)
+	}
+	rawdb.WriteTokenIndexApplied(t.batch, t.section+1)
+	return t.batch.Write()
+}
+
+// Prune implements core.ChainIndexerBackend, deleting the token transfers of the
+// blocks below the threshold along with the journals of the sections fully below
+// it. The holdings are kept.
+func (t *TokenIndexer) Prune(threshold uint64) error {
+	deleted, err := rawdb.DeleteTokenTransfers(t.db, threshold)
+	if err != nil {
+		return err
+	}
+	batch := t.db.NewBatch()
+	for section := uint64(0); section < threshold/params.BloomBitsBlocks; section++ {
+		rawdb.DeleteTokenJournal(batch, section)
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	log.Info("Pruned token transfers", "threshold", threshold, "entries", deleted)
+	return nil
+}
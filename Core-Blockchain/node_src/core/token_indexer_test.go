@@ -0,0 +1,198 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package core
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// tokenLog creates a log of a token contract with the given topics and words of
+// data.
+func tokenLog(token common.Address, topics []common.Hash, words ...int64) *types.Log {
+	var data []byte
+	for _, word := range words {
+		data = append(data, common.BigToHash(big.NewInt(word)).Bytes()...)
+	}
+	return &types.Log{Address: token, Topics: topics, Data: data}
+}
+
+// writeTokenBlock stores a header along with a receipt holding the given logs,
+// returning the header.
+func writeTokenBlock(db ethdb.Database, number uint64, extra byte, logs ...*types.Log) *types.Header {
+	receipts := types.Receipts{{Status: types.ReceiptStatusSuccessful, Logs: logs}}
+	header := &types.Header{Number: new(big.Int).SetUint64(number), Extra: []byte{extra}, Bloom: types.CreateBloom(receipts)}
+	rawdb.WriteHeader(db, header)
+	rawdb.WriteReceipts(db, header.Hash(), number, receipts)
+	return header
+}
+
+// indexTokenSection indexes the given headers as a section of the token index.
+func indexTokenSection(t *testing.T, indexer *TokenIndexer, section uint64, headers ...*types.Header) {
+	t.Helper()
+
+	if err := indexer.Reset(context.Background(), section, common.Hash{}); err != nil {
+		t.Fatalf("failed to reset section %d: %v", section, err)
+	}
+	for _, header := range headers {
+		if err := indexer.Process(context.Background(), header); err != nil {
+			t.Fatalf("failed to index block %d: %v", header.Number, err)
+		}
+	}
+	if err := indexer.Commit(); err != nil {
+		t.Fatalf("failed to commit section %d: %v", section, err)
+	}
+}
+
+// Tests that the token transfers of all standards are decoded into the holdings
+// and the histories, and that the holdings of reorged sections are reverted.
+func TestTokenIndexer(t *testing.T) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		indexer = &TokenIndexer{db: db}
+
+		erc20   = common.HexToAddress("0x2020")
+		erc721  = common.HexToAddress("0x0721")
+		erc1155 = common.HexToAddress("0x1155")
+		alice   = common.HexToAddress("0xaaaa")
+		bob     = common.HexToAddress("0xbbbb")
+
+		zero     = common.Hash{}
+		aliceKey = common.BytesToHash(alice.Bytes())
+		bobKey   = common.BytesToHash(bob.Bytes())
+	)
+	headers := []*types.Header{
+		writeTokenBlock(db, 1, 0,
+			tokenLog(erc20, []common.Hash{TransferTopic, zero, aliceKey}, 100),
+			tokenLog(erc721, []common.Hash{TransferTopic, zero, aliceKey, common.BigToHash(big.NewInt(7))}),
+		),
+		writeTokenBlock(db, 2, 0,
+			tokenLog(erc20, []common.Hash{TransferTopic, aliceKey, bobKey}, 30),
+			tokenLog(erc20, []common.Hash{TransferTopic, aliceKey}, 30),
+			tokenLog(erc1155, []common.Hash{TransferBatchTopic, aliceKey, zero, bobKey}, 64, 160, 2, 1, 2, 2, 5, 6),
+		),
+	}
+	indexTokenSection(t, indexer, 0, headers...)
+
+	for i, tt := range []struct {
+		holder  common.Address
+		token   common.Address
+		id      int64
+		balance int64
+	}{
+		{alice, erc20, 0, 70},
+		{bob, erc20, 0, 30},
+		{alice, erc721, 7, 1},
+		{bob, erc1155, 1, 5},
+		{bob, erc1155, 2, 6},
+		{alice, erc1155, 1, 0},
+	} {
+		if have := rawdb.ReadTokenBalance(db, tt.holder, tt.token, common.BigToHash(big.NewInt(tt.id))); have.Int64() != tt.balance {
+			t.Errorf("test %d: balance mismatch: have %d, want %d", i, have, tt.balance)
+		}
+	}
+	if info := rawdb.ReadTokenInfo(db, erc20); info == nil || info.Standard != 20 || info.Holders != 2 || info.Transfers != 2 {
+		t.Errorf("erc20 info mismatch: %+v", info)
+	}
+	if info := rawdb.ReadTokenInfo(db, erc1155); info == nil || info.Standard != 1155 || info.Holders != 1 || info.Transfers != 2 {
+		t.Errorf("erc1155 info mismatch: %+v", info)
+	}
+	if ids := rawdb.ReadTokenHolderIDs(db, erc1155, bob); ids != 2 {
+		t.Errorf("erc1155 ids of bob mismatch: have %d, want 2", ids)
+	}
+	var history []*rawdb.TokenTransfer
+	rawdb.IterateHolderTokenTransfers(db, bob, 0, 0, 0, func(transfer *rawdb.TokenTransfer) bool {
+		history = append(history, transfer)
+		return true
+	})
+	if len(history) != 3 {
+		t.Fatalf("history length mismatch: have %d, want 3", len(history))
+	}
+	for i, want := range []struct {
+		token    common.Address
+		logIndex uint32
+		item     uint32
+	}{{erc20, 0, 0}, {erc1155, 2, 0}, {erc1155, 2, 1}} {
+		if have := history[i]; have.Number != 2 || have.BlockHash != headers[1].Hash() || have.Token != want.token || have.LogIndex != want.logIndex || have.Item != want.item {
+			t.Errorf("transfer %d mismatch: %+v", i, have)
+		}
+	}
+	// Move the nft in the next section, then reorg it away
+	indexTokenSection(t, indexer, 1, writeTokenBlock(db, 3, 0,
+		tokenLog(erc721, []common.Hash{TransferTopic, aliceKey, bobKey, common.BigToHash(big.NewInt(7))}),
+	))
+	if have := rawdb.ReadTokenBalance(db, bob, erc721, common.BigToHash(big.NewInt(7))); have.Int64() != 1 {
+		t.Fatalf("nft not transferred: balance %d", have)
+	}
+	indexTokenSection(t, indexer, 1, writeTokenBlock(db, 3, 1))
+
+	if have := rawdb.ReadTokenBalance(db, bob, erc721, common.BigToHash(big.NewInt(7))); have.Sign() != 0 {
+		t.Errorf("reorged nft transfer not reverted: balance %d", have)
+	}
+	if have := rawdb.ReadTokenBalance(db, alice, erc721, common.BigToHash(big.NewInt(7))); have.Int64() != 1 {
+		t.Errorf("reorged nft transfer not reverted: balance %d", have)
+	}
+	if info := rawdb.ReadTokenInfo(db, erc721); info == nil || info.Holders != 1 || info.Transfers != 1 {
+		t.Errorf("erc721 info mismatch: %+v", info)
+	}
+	// Reindex from the genesis, reverting every section
+	if err := indexer.Reset(context.Background(), 0, common.Hash{}); err != nil {
+		t.Fatalf("failed to reset index: %v", err)
+	}
+	if info := rawdb.ReadTokenInfo(db, erc20); info != nil {
+		t.Errorf("token info left after revert: %+v", info)
+	}
+	rawdb.IterateTokenBalances(db, alice, func(token common.Address, id common.Hash, balance *big.Int) bool {
+		t.Errorf("balance left after revert: %x %x %d", token, id, balance)
+		return true
+	})
+	if applied := rawdb.ReadTokenIndexApplied(db); applied != 0 {
+		t.Errorf("applied sections mismatch: have %d, want 0", applied)
+	}
+}
+
+// Tests that only the journals of the most recent sections are kept, and that
+// reorgs reaching past them are refused.
+func TestTokenIndexerJournals(t *testing.T) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		indexer = &TokenIndexer{db: db}
+
+		erc20    = common.HexToAddress("0x2020")
+		aliceKey = common.BytesToHash(common.HexToAddress("0xaaaa").Bytes())
+	)
+	for section := uint64(0); section <= tokenJournalSections; section++ {
+		indexTokenSection(t, indexer, section, writeTokenBlock(db, section+1, 0,
+			tokenLog(erc20, []common.Hash{TransferTopic, {}, aliceKey}, 100),
+		))
+	}
+	if journal := rawdb.ReadTokenJournal(db, 0); journal != nil {
+		t.Errorf("journal of section 0 kept past the reorg window: %v", journal)
+	}
+	for section := uint64(1); section <= tokenJournalSections; section++ {
+		if journal := rawdb.ReadTokenJournal(db, section); journal == nil {
+			t.Errorf("journal of section %d missing", section)
+		}
+	}
+	if err := indexer.Reset(context.Background(), 0, common.Hash{}); err == nil {
+		t.Fatal("reorg past the journaled sections not refused")
+	}
+	if err := indexer.Reset(context.Background(), 1, common.Hash{}); err != nil {
+		t.Fatalf("failed to revert the journaled sections: %v", err)
+	}
+	if info := rawdb.ReadTokenInfo(db, erc20); info == nil || info.Transfers != 1 {
+		t.Errorf("erc20 info mismatch after revert: %+v", info)
+	}
+}
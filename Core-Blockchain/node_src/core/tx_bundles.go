@@ -0,0 +1,149 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package core
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// MaxBundleTxs is the maximum number of transactions in a bundle.
+const MaxBundleTxs = 16
+
+const (
+	maxBundles     = 1024 // Maximum number of bundles kept by the pool
+	maxBundleRange = 256  // Maximum number of blocks a bundle can target
+)
+
+var (
+	// ErrBundleEmpty is returned if a bundle has no transactions.
+	ErrBundleEmpty = errors.New("bundle has no transactions")
+
+	// ErrBundleTooLarge is returned if a bundle has more transactions than allowed.
+	ErrBundleTooLarge = errors.New("bundle has too many transactions")
+
+	// ErrBundleRange is returned if the target block range of a bundle is invalid,
+	// already passed or too far ahead.
+	ErrBundleRange = errors.New("invalid bundle block range")
+
+	// ErrBundlePoolFull is returned if the pool can't accept another bundle.
+	ErrBundlePoolFull = errors.New("bundle pool is full")
+)
+
+var bundleGauge = metrics.NewRegisteredGauge("txpool/bundles", nil)
+
+// txBundlePool keeps the bundles waiting to be included by the miner. Bundles
+// are not propagated, they are only included by the node they are sent to.
+type txBundlePool struct {
+	bundles []*types.Bundle // Bundles in arrival order
+	known   map[common.Hash]struct{}
+	mu      sync.Mutex
+}
+
+func newTxBundlePool() *txBundlePool {
+	return &txBundlePool{known: make(map[common.Hash]struct{})}
+}
+
+// add validates a bundle against the given head and the signer, and queues it.
+func (p *txBundlePool) add(bundle *types.Bundle, head uint64, signer types.Signer) error {
+	switch {
+	case len(bundle.Txs) == 0:
+		return ErrBundleEmpty
+	case len(bundle.Txs) > MaxBundleTxs:
+		return ErrBundleTooLarge
+	case bundle.MinBlock > bundle.MaxBlock, bundle.MaxBlock <= head, bundle.MaxBlock > head+maxBundleRange:
+		return ErrBundleRange
+	}
+	for _, tx := range bundle.Txs {
+		if tx.Size() > txMaxSize {
+			return ErrOversizedData
+		}
+		if _, err := types.Sender(signer, tx); err != nil {
+			return ErrInvalidSender
+		}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.prune(head + 1)
+	hash := bundle.Hash()
+	if _, ok := p.known[hash]; ok {
+		return ErrAlreadyKnown
+	}
+	if len(p.bundles) >= maxBundles {
+		return ErrBundlePoolFull
+	}
+	p.bundles = append(p.bundles, bundle)
+	p.known[hash] = struct{}{}
+	bundleGauge.Update(int64(len(p.bundles)))
+	return nil
+}
+
+// eligible drops the bundles expired at the given block number and returns the
+// ones targeting it, in arrival order.
+func (p *txBundlePool) eligible(number uint64) []*types.Bundle {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.prune(number)
+	var bundles []*types.Bundle
+	for _, bundle := range p.bundles {
+		if bundle.MinBlock <= number {
+			bundles = append(bundles, bundle)
+		}
+	}
+	return bundles
+}
+
+// reset drops the bundles which can't be included on top of a new head anymore,
+// as they expired or one of their transactions was included or replaced.
+func (p *txBundlePool) reset(head uint64, statedb *state.StateDB, signer types.Signer) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.filter(func(bundle *types.Bundle) bool {
+		if bundle.MaxBlock <= head {
+			return false
+		}
+		for _, tx := range bundle.Txs {
+			from, _ := types.Sender(signer, tx) // already validated
+			if tx.Nonce() < statedb.GetNonce(from) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// prune drops the bundles which can't be included from the given block number on.
+func (p *txBundlePool) prune(number uint64) {
+	p.filter(func(bundle *types.Bundle) bool { return bundle.MaxBlock >= number })
+}
+
+// filter drops the bundles not satisfying keep.
+func (p *txBundlePool) filter(keep func(*types.Bundle) bool) {
+	kept := p.bundles[:0]
+	for _, bundle := range p.bundles {
+		if keep(bundle) {
+			kept = append(kept, bundle)
+			continue
+		}
+		delete(p.known, bundle.Hash())
+	}
+	for i := len(kept); i < len(p.bundles); i++ {
+		p.bundles[i] = nil
+	}
+	p.bundles = kept
+	bundleGauge.Update(int64(len(p.bundles)))
+}
@@ -0,0 +1,319 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package core
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+const (
+	maxConditionalTxs        = 4096 // Maximum number of conditional transactions kept by the pool
+	maxConditionalAccountTxs = 16   // Maximum number of conditional transactions kept per sender
+	maxConditionalCost       = 1024 // Maximum number of state lookups of the conditions of a transaction
+)
+
+var (
+	// ErrConditionalPoolFull is returned if the pool can't accept another
+	// conditional transaction.
+	ErrConditionalPoolFull = errors.New("conditional transaction pool is full")
+
+	// ErrConditionalAccountFull is returned if the sender of a conditional
+	// transaction already has too many of them waiting in the pool.
+	ErrConditionalAccountFull = errors.New("too many conditional transactions of sender")
+
+	// ErrConditionsTooLarge is returned if the conditions of a transaction need
+	// too many state lookups.
+	ErrConditionsTooLarge = errors.New("transaction conditions too large")
+
+	// ErrConditionsExpired is returned if the conditions of a transaction can't
+	// be met anymore.
+	ErrConditionsExpired = errors.New("transaction conditions expired")
+
+	// ErrConditionsUnbounded is returned if the conditions of a transaction set
+	// neither a maximal block number nor a maximal timestamp.
+	ErrConditionsUnbounded = errors.New("transaction conditions without expiry")
+)
+
+var (
+	conditionalGauge        = metrics.NewRegisteredGauge("txpool/conditional", nil)
+	conditionalPromoteMeter = metrics.NewRegisteredMeter("txpool/conditional/promoted", nil)
+	conditionalDemoteMeter  = metrics.NewRegisteredMeter("txpool/conditional/demoted", nil)
+	conditionalExpireMeter  = metrics.NewRegisteredMeter("txpool/conditional/expired", nil)
+	conditionalDiscardMeter = metrics.NewRegisteredMeter("txpool/conditional/discarded", nil)
+)
+
+// ConditionalTx is a transaction waiting in the pool for its conditions.
+type ConditionalTx struct {
+	Tx         *types.Transaction
+	From       common.Address
+	Conditions *types.TxConditions
+	Local      bool
+	Added      time.Time
+	Promoted   bool // Whether the transaction currently sits in the executable pool
+}
+
+// txConditionals is the dedicated queue of the conditional transactions. They are
+// moved into the regular pool while their conditions hold, and back out when they
+// stop holding.
+type txConditionals struct {
+	txs map[common.Hash]*ConditionalTx
+	mu  sync.RWMutex
+}
+
+func newTxConditionals() *txConditionals {
+	return &txConditionals{txs: make(map[common.Hash]*ConditionalTx)}
+}
+
+// get returns a copy of the conditional transaction with the given hash.
+func (c *txConditionals) get(hash common.Hash) *ConditionalTx {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if entry := c.txs[hash]; entry != nil {
+		cpy := *entry
+		return &cpy
+	}
+	return nil
+}
+
+// list returns copies of all the conditional transactions.
+func (c *txConditionals) list() []*ConditionalTx {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	txs := make([]*ConditionalTx, 0, len(c.txs))
+	for _, entry := range c.txs {
+		cpy := *entry
+		txs = append(txs, &cpy)
+	}
+	return txs
+}
+
+// has reports whether the queue holds a conditional transaction with the given hash.
+func (c *txConditionals) has(hash common.Hash) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.txs[hash] != nil
+}
+
+// count returns the number of conditional transactions of the given sender.
+//
+// Note, this method assumes the conditionals lock is held!
+func (c *txConditionals) count(from common.Address) int {
+	var n int
+	for _, entry := range c.txs {
+		if entry.From == from {
+			n++
+		}
+	}
+	return n
+}
+
+// conditionsHold checks the state conditions against the given state.
+func conditionsHold(conditions *types.TxConditions, statedb *state.StateDB) bool {
+	for addr, min := range conditions.MinBalances {
+		if statedb.GetBalance(addr).Cmp(min.ToInt()) < 0 {
+			return false
+		}
+	}
+	for addr, account := range conditions.KnownAccounts {
+		if account.StorageRoot != nil {
+			root := types.EmptyRootHash
+			if storage := statedb.StorageTrie(addr); storage != nil {
+				root = storage.Hash()
+			}
+			if root != *account.StorageRoot {
+				return false
+			}
+			continue
+		}
+		for slot, value := range account.StorageSlots {
+			if statedb.GetState(addr, slot) != value {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// ConditionsHold reports whether the conditions of a transaction hold for a block
+// with the given number, built on a parent with the given timestamp, at the given
+// state.
+func ConditionsHold(conditions *types.TxConditions, number, parentTime uint64, statedb *state.StateDB) bool {
+	return conditions.InWindow(number, parentTime) && conditionsHold(conditions, statedb)
+}
+
+// conditionsMet reports whether the conditions hold for the block following head.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) conditionsMet(conditions *types.TxConditions, head *types.Header) bool {
+	return ConditionsHold(conditions, head.Number.Uint64()+1, head.Time, pool.currentState)
+}
+
+// AddConditional adds a transaction to the conditional queue. The transaction
+// enters the executable pool as soon as its conditions hold, and stays there as
+// long as they do. The conditions have to expire, and the transaction has to be
+// valid against the current state already.
+func (pool *TxPool) AddConditional(tx *types.Transaction, conditions *types.TxConditions, local bool) error {
+	from, err := types.Sender(pool.signer, tx)
+	if err != nil {
+		return ErrInvalidSender
+	}
+	if conditions.Cost() > maxConditionalCost {
+		return ErrConditionsTooLarge
+	}
+	if conditions.BlockNumberMax == nil && conditions.TimestampMax == nil {
+		return ErrConditionsUnbounded
+	}
+	head := pool.chain.CurrentBlock().Header()
+	if conditions.Expired(head.Number.Uint64()+1, head.Time) {
+		return ErrConditionsExpired
+	}
+	pool.mu.Lock()
+	if err := pool.validateTx(tx, local); err != nil {
+		pool.mu.Unlock()
+		return err
+	}
+	hash := tx.Hash()
+	pool.conditionals.mu.Lock()
+	switch {
+	case pool.conditionals.txs[hash] != nil || pool.all.Get(hash) != nil:
+		err = ErrAlreadyKnown
+	case len(pool.conditionals.txs) >= maxConditionalTxs:
+		err = ErrConditionalPoolFull
+	case pool.conditionals.count(from) >= maxConditionalAccountTxs:
+		err = ErrConditionalAccountFull
+	}
+	if err != nil {
+		pool.conditionals.mu.Unlock()
+		pool.mu.Unlock()
+		return err
+	}
+	entry := &ConditionalTx{Tx: tx, From: from, Conditions: conditions, Local: local, Added: time.Now()}
+	pool.conditionals.txs[hash] = entry
+	conditionalGauge.Update(int64(len(pool.conditionals.txs)))
+	pool.conditionals.mu.Unlock()
+
+	// Promote the transaction right away if its conditions already hold
+	var dirty *accountSet
+	if pool.conditionsMet(conditions, head) {
+		errs, dirtyAddrs := pool.addTxsLocked([]*types.Transaction{tx}, local)
+		if errs[0] != nil {
+			pool.conditionals.remove(hash)
+			pool.mu.Unlock()
+			return errs[0]
+		}
+		pool.conditionals.mu.Lock()
+		entry.Promoted = true
+		pool.conditionals.mu.Unlock()
+		conditionalPromoteMeter.Mark(1)
+		dirty = dirtyAddrs
+	}
+	pool.mu.Unlock()
+
+	if dirty != nil {
+		<-pool.requestPromoteExecutables(dirty)
+	}
+	return nil
+}
+
+// remove drops a conditional transaction from the queue.
+func (c *txConditionals) remove(hash common.Hash) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.txs, hash)
+	conditionalGauge.Update(int64(len(c.txs)))
+}
+
+// resetConditionals re-evaluates the conditional transactions against the new
+// head, promoting the ones whose conditions started holding, demoting the ones
+// whose conditions stopped holding and dropping the expired and stale ones.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) resetConditionals(head *types.Header) {
+	if head == nil {
+		head = pool.chain.CurrentBlock().Header()
+	}
+	pool.conditionals.mu.Lock()
+	defer pool.conditionals.mu.Unlock()
+
+	for hash, entry := range pool.conditionals.txs {
+		pooled := pool.all.Get(hash) != nil
+		switch {
+		case entry.Promoted && !pooled:
+			// Included in a block or evicted from the pool, forget about it
+			delete(pool.conditionals.txs, hash)
+
+		case entry.Conditions.Expired(head.Number.Uint64()+1, head.Time):
+			log.Trace("Dropping expired conditional transaction", "hash", hash)
+			if pooled {
+				pool.removeTx(hash, true)
+			}
+			delete(pool.conditionals.txs, hash)
+			conditionalExpireMeter.Mark(1)
+
+		case !entry.Promoted && entry.Tx.Nonce() < pool.currentState.GetNonce(entry.From):
+			// Superseded by a transaction of the same nonce
+			log.Trace("Dropping stale conditional transaction", "hash", hash)
+			delete(pool.conditionals.txs, hash)
+			conditionalDiscardMeter.Mark(1)
+
+		case pool.conditionsMet(entry.Conditions, head):
+			if entry.Promoted {
+				continue
+			}
+			if _, err := pool.add(entry.Tx, entry.Local); err != nil {
+				// Keep waiting for a balance top up, otherwise the transaction
+				// can't ever make it
+				if !errors.Is(err, ErrInsufficientFunds) {
+					log.Trace("Discarding conditional transaction", "hash", hash, "err", err)
+					delete(pool.conditionals.txs, hash)
+					conditionalDiscardMeter.Mark(1)
+				}
+				continue
+			}
+			entry.Promoted = true
+			conditionalPromoteMeter.Mark(1)
+
+		case entry.Promoted:
+			log.Trace("Demoting conditional transaction", "hash", hash)
+			pool.removeTx(hash, true)
+			entry.Promoted = false
+			conditionalDemoteMeter.Mark(1)
+		}
+	}
+	conditionalGauge.Update(int64(len(pool.conditionals.txs)))
+}
+
+// Conditional returns the conditional transaction with the given hash, or nil if
+// the pool doesn't hold it.
+func (pool *TxPool) Conditional(hash common.Hash) *ConditionalTx {
+	return pool.conditionals.get(hash)
+}
+
+// IsConditional reports whether the pool holds the transaction with the given
+// hash as a conditional one, which is not to be relayed to the network.
+func (pool *TxPool) IsConditional(hash common.Hash) bool {
+	return pool.conditionals.has(hash)
+}
+
+// Conditionals returns all the conditional transactions held by the pool.
+func (pool *TxPool) Conditionals() []*ConditionalTx {
+	return pool.conditionals.list()
+}
@@ -0,0 +1,181 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// resetConditionalsAt re-evaluates the conditional transactions of the pool as if
+// the given block was the new head.
+func resetConditionalsAt(pool *TxPool, number, time uint64) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	pool.resetConditionals(&types.Header{Number: new(big.Int).SetUint64(number), Time: time})
+}
+
+// Tests that conditional transactions wait for their block window, and get dropped
+// once it's over.
+func TestConditionalBlockWindow(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000))
+
+	min, max := hexutil.Uint64(5), hexutil.Uint64(6)
+	tx := transaction(0, 100000, key)
+	if err := pool.AddConditional(tx, &types.TxConditions{BlockNumberMin: &min, BlockNumberMax: &max}, false); err != nil {
+		t.Fatalf("failed to add conditional transaction: %v", err)
+	}
+	if entry := pool.Conditional(tx.Hash()); entry == nil || entry.Promoted {
+		t.Fatalf("conditional transaction state mismatch: %v", entry)
+	}
+	if pool.Get(tx.Hash()) != nil {
+		t.Fatalf("conditional transaction pooled before its window")
+	}
+	// The window opens with the block after the head
+	resetConditionalsAt(pool, 4, 0)
+	if entry := pool.Conditional(tx.Hash()); entry == nil || !entry.Promoted {
+		t.Fatalf("conditional transaction not promoted: %v", entry)
+	}
+	if pool.Get(tx.Hash()) == nil {
+		t.Fatalf("promoted conditional transaction not pooled")
+	}
+	// Past the window the transaction is dropped altogether
+	resetConditionalsAt(pool, 6, 0)
+	if pool.Conditional(tx.Hash()) != nil || pool.Get(tx.Hash()) != nil {
+		t.Fatalf("expired conditional transaction retained")
+	}
+	// Transactions can't be submitted past their window
+	if err := pool.AddConditional(transaction(1, 100000, key), &types.TxConditions{BlockNumberMax: new(hexutil.Uint64)}, false); err != ErrConditionsExpired {
+		t.Fatalf("expired conditions error mismatch: have %v, want %v", err, ErrConditionsExpired)
+	}
+}
+
+// Tests that conditional transactions get promoted and demoted as their state
+// conditions start and stop holding.
+func TestConditionalStateConditions(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000))
+
+	watched, max := common.HexToAddress("0xdeadbeef"), hexutil.Uint64(100)
+	conditions := &types.TxConditions{
+		BlockNumberMax: &max,
+		MinBalances:    map[common.Address]*hexutil.Big{watched: (*hexutil.Big)(big.NewInt(100))},
+	}
+	tx := transaction(0, 100000, key)
+	if err := pool.AddConditional(tx, conditions, false); err != nil {
+		t.Fatalf("failed to add conditional transaction: %v", err)
+	}
+	if pool.Get(tx.Hash()) != nil {
+		t.Fatalf("conditional transaction pooled before its conditions hold")
+	}
+	testAddBalance(pool, watched, big.NewInt(100))
+	resetConditionalsAt(pool, 0, 0)
+	if entry := pool.Conditional(tx.Hash()); entry == nil || !entry.Promoted || pool.Get(tx.Hash()) == nil {
+		t.Fatalf("conditional transaction not promoted: %v", entry)
+	}
+	testAddBalance(pool, watched, big.NewInt(-1))
+	resetConditionalsAt(pool, 0, 0)
+	if entry := pool.Conditional(tx.Hash()); entry == nil || entry.Promoted || pool.Get(tx.Hash()) != nil {
+		t.Fatalf("conditional transaction not demoted: %v", entry)
+	}
+	// Conditions needing too many lookups are rejected
+	large := &types.TxConditions{BlockNumberMax: &max, KnownAccounts: map[common.Address]types.KnownAccount{
+		watched: {StorageSlots: make(map[common.Hash]common.Hash)},
+	}}
+	for i := 0; i <= maxConditionalCost; i++ {
+		large.KnownAccounts[watched].StorageSlots[common.BigToHash(big.NewInt(int64(i)))] = common.Hash{}
+	}
+	if err := pool.AddConditional(transaction(1, 100000, key), large, false); err != ErrConditionsTooLarge {
+		t.Fatalf("large conditions error mismatch: have %v, want %v", err, ErrConditionsTooLarge)
+	}
+}
+
+// Tests that a sender can't fill the conditional queue.
+func TestConditionalAccountLimit(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	other, _ := crypto.GenerateKey()
+	testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(100000000))
+	testAddBalance(pool, crypto.PubkeyToAddress(other.PublicKey), big.NewInt(100000000))
+
+	max := hexutil.Uint64(100)
+	conditions := &types.TxConditions{
+		BlockNumberMax: &max,
+		MinBalances:    map[common.Address]*hexutil.Big{common.HexToAddress("0xdeadbeef"): (*hexutil.Big)(big.NewInt(100))},
+	}
+	for i := uint64(0); i < maxConditionalAccountTxs; i++ {
+		if err := pool.AddConditional(transaction(i, 100000, key), conditions, false); err != nil {
+			t.Fatalf("transaction %d: failed to add conditional transaction: %v", i, err)
+		}
+	}
+	if err := pool.AddConditional(transaction(maxConditionalAccountTxs, 100000, key), conditions, false); err != ErrConditionalAccountFull {
+		t.Fatalf("sender limit error mismatch: have %v, want %v", err, ErrConditionalAccountFull)
+	}
+	if err := pool.AddConditional(transaction(0, 100000, other), conditions, false); err != nil {
+		t.Fatalf("failed to add conditional transaction of another sender: %v", err)
+	}
+}
+
+// Tests that conditional transactions need an expiry and have to be valid on
+// admission, that stale ones are dropped and that they aren't relayed.
+func TestConditionalAdmission(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	watched, max := common.HexToAddress("0xdeadbeef"), hexutil.Uint64(100)
+	unbounded := &types.TxConditions{
+		MinBalances: map[common.Address]*hexutil.Big{watched: (*hexutil.Big)(big.NewInt(100))},
+	}
+	if err := pool.AddConditional(transaction(0, 100000, key), unbounded, false); err != ErrConditionsUnbounded {
+		t.Fatalf("unbounded conditions error mismatch: have %v, want %v", err, ErrConditionsUnbounded)
+	}
+	conditions := &types.TxConditions{BlockNumberMax: &max, MinBalances: unbounded.MinBalances}
+	if err := pool.AddConditional(transaction(0, 100000, key), conditions, false); err != ErrInsufficientFunds {
+		t.Fatalf("unfunded transaction error mismatch: have %v, want %v", err, ErrInsufficientFunds)
+	}
+	testAddBalance(pool, addr, big.NewInt(100000000))
+	testSetNonce(pool, addr, 1)
+	if err := pool.AddConditional(transaction(0, 100000, key), conditions, false); err != ErrNonceTooLow {
+		t.Fatalf("stale transaction error mismatch: have %v, want %v", err, ErrNonceTooLow)
+	}
+	tx := transaction(1, 100000, key)
+	if err := pool.AddConditional(tx, conditions, false); err != nil {
+		t.Fatalf("failed to add conditional transaction: %v", err)
+	}
+	if !pool.IsConditional(tx.Hash()) {
+		t.Fatalf("conditional transaction not reported as such")
+	}
+	// A transaction of the same nonce making it first leaves it stale
+	testSetNonce(pool, addr, 2)
+	resetConditionalsAt(pool, 0, 0)
+	if pool.Conditional(tx.Hash()) != nil || pool.IsConditional(tx.Hash()) {
+		t.Fatalf("stale conditional transaction retained")
+	}
+}
@@ -0,0 +1,357 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+const (
+	rejectionSendersLimit = 4096 // Number of senders whose recent rejections are kept
+	rejectionTxsLimit     = 8192 // Number of rejected transactions kept for lookups by hash
+	senderRejectionsLimit = 16   // Number of recent rejections kept per sender
+)
+
+// TxRejection is a transaction the pool refused to accept.
+type TxRejection struct {
+	Hash  common.Hash    `json:"hash"`
+	From  common.Address `json:"from"`
+	Nonce hexutil.Uint64 `json:"nonce"`
+	Error string         `json:"error"`
+	Time  time.Time      `json:"time"`
+}
+
+// txRejections keeps the recent rejections of the pool, a bounded ring of them
+// per sender and the last one of each transaction.
+type txRejections struct {
+	senders *lru.Cache // common.Address -> []*TxRejection, oldest first
+	txs     *lru.Cache // common.Hash -> *TxRejection
+	lock    sync.Mutex
+}
+
+func newTxRejections() *txRejections {
+	senders, _ := lru.New(rejectionSendersLimit)
+	txs, _ := lru.New(rejectionTxsLimit)
+	return &txRejections{senders: senders, txs: txs}
+}
+
+// record adds a rejection of a transaction, from is the zero address if the
+// sender couldn't be recovered.
+func (r *txRejections) record(from common.Address, tx *types.Transaction, err error) {
+	rejection := &TxRejection{
+		Hash:  tx.Hash(),
+		From:  from,
+		Nonce: hexutil.Uint64(tx.Nonce()),
+		Error: err.Error(),
+		Time:  time.Now(),
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.txs.Add(rejection.Hash, rejection)
+	if from == (common.Address{}) {
+		return
+	}
+	var ring []*TxRejection
+	if cached, ok := r.senders.Get(from); ok {
+		ring = cached.([]*TxRejection)
+	}
+	if len(ring) >= senderRejectionsLimit {
+		ring = ring[len(ring)-senderRejectionsLimit+1:]
+	}
+	r.senders.Add(from, append(append([]*TxRejection(nil), ring...), rejection))
+}
+
+// tx returns the last rejection of a transaction.
+func (r *txRejections) tx(hash common.Hash) *TxRejection {
+	if cached, ok := r.txs.Get(hash); ok {
+		return cached.(*TxRejection)
+	}
+	return nil
+}
+
+// sender returns the recent rejections of a sender, oldest first.
+func (r *txRejections) sender(from common.Address) []*TxRejection {
+	if cached, ok := r.senders.Get(from); ok {
+		return cached.([]*TxRejection)
+	}
+	return nil
+}
+
+// TxPoolSlots reports the slot usage of an account against the pool limits.
+type TxPoolSlots struct {
+	AccountPending hexutil.Uint64 `json:"accountPending"`
+	AccountQueued  hexutil.Uint64 `json:"accountQueued"`
+	AccountSlots   hexutil.Uint64 `json:"accountSlots"` // Pending transactions guaranteed per account
+	AccountQueue   hexutil.Uint64 `json:"accountQueue"` // Queued transactions allowed per account
+	GlobalPending  hexutil.Uint64 `json:"globalPending"`
+	GlobalQueued   hexutil.Uint64 `json:"globalQueued"`
+	GlobalSlots    hexutil.Uint64 `json:"globalSlots"`
+	GlobalQueue    hexutil.Uint64 `json:"globalQueue"`
+}
+
+// TxExplanation reports why a transaction is waiting in, or was refused by, the pool.
+type TxExplanation struct {
+	Hash   common.Hash    `json:"hash"`
+	From   common.Address `json:"from"`
+	Nonce  hexutil.Uint64 `json:"nonce"`
+	Status string         `json:"status"` // pending, queued or rejected
+	Local  bool           `json:"local"`
+
+	StateNonce hexutil.Uint64 `json:"stateNonce"`
+	NextNonce  hexutil.Uint64 `json:"nextNonce"` // Nonce following the executable transactions of the sender
+	NonceGap   hexutil.Uint64 `json:"nonceGap"`  // Nonces missing before the transaction can execute
+
+	GasTipCap         *hexutil.Big   `json:"gasTipCap,omitempty"`
+	GasFeeCap         *hexutil.Big   `json:"gasFeeCap,omitempty"`
+	EffectiveTip      *hexutil.Big   `json:"effectiveTip,omitempty"`  // Tip at the pending base fee
+	BaseFee           *hexutil.Big   `json:"baseFee,omitempty"`       // Pending base fee
+	MinTip            *hexutil.Big   `json:"minTip"`                  // Minimal tip accepted from remote transactions
+	PendingMinTip     *hexutil.Big   `json:"pendingMinTip,omitempty"` // Lowest effective tip of the pending remote transactions
+	PriceBump         hexutil.Uint64 `json:"priceBump"`
+	ReplacementTipCap *hexutil.Big   `json:"replacementTipCap,omitempty"` // Tip cap needed to replace the transaction
+	ReplacementFeeCap *hexutil.Big   `json:"replacementFeeCap,omitempty"` // Fee cap needed to replace the transaction
+
+	Slots     *TxPoolSlots `json:"slots,omitempty"`
+	ExpiresAt *time.Time   `json:"expiresAt,omitempty"` // When the queued transaction gets evicted without promotion
+	JamPoints int          `json:"jamPoints"`           // Contribution of the transaction to the jam index
+	JamIndex  int          `json:"jamIndex"`
+
+	LastRejection *TxRejection `json:"lastRejection,omitempty"`
+	Reasons       []string     `json:"reasons"`
+}
+
+// AccountExplanation reports the pool state of an account.
+type AccountExplanation struct {
+	Address    common.Address   `json:"address"`
+	StateNonce hexutil.Uint64   `json:"stateNonce"`
+	NextNonce  hexutil.Uint64   `json:"nextNonce"`
+	Slots      *TxPoolSlots     `json:"slots"`
+	Txs        []*TxExplanation `json:"txs"`
+	Rejections []*TxRejection   `json:"rejections"` // Recent rejections, oldest first
+}
+
+// errTxNotFound is returned if a transaction is neither in the pool nor among the
+// recent rejections.
+var errTxNotFound = errors.New("transaction not found in the pool nor among recent rejections")
+
+// Explain reports why the transaction with the given hash is stuck in the pool,
+// or why the pool refused it recently.
+func (pool *TxPool) Explain(hash common.Hash) (*TxExplanation, error) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	tx := pool.all.Get(hash)
+	if tx == nil {
+		rejection := pool.rejections.tx(hash)
+		if rejection == nil {
+			return nil, errTxNotFound
+		}
+		return &TxExplanation{
+			Hash:          hash,
+			From:          rejection.From,
+			Nonce:         rejection.Nonce,
+			Status:        "rejected",
+			MinTip:        (*hexutil.Big)(pool.gasPrice),
+			PriceBump:     hexutil.Uint64(pool.config.PriceBump),
+			LastRejection: rejection,
+			Reasons:       []string{"rejected: " + rejection.Error},
+		}, nil
+	}
+	return pool.explain(tx, pool.pendingMinTip()), nil
+}
+
+// ExplainAccount reports the pool state of an account, with an explanation of
+// each of its transactions and its recent rejections.
+func (pool *TxPool) ExplainAccount(addr common.Address) *AccountExplanation {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	explanation := &AccountExplanation{
+		Address:    addr,
+		StateNonce: hexutil.Uint64(pool.currentState.GetNonce(addr)),
+		NextNonce:  hexutil.Uint64(pool.pendingNonces.get(addr)),
+		Slots:      pool.slots(addr),
+		Txs:        []*TxExplanation{},
+		Rejections: pool.rejections.sender(addr),
+	}
+	if explanation.Rejections == nil {
+		explanation.Rejections = []*TxRejection{}
+	}
+	minTip := pool.pendingMinTip()
+	for _, list := range []*txList{pool.pending[addr], pool.queue[addr]} {
+		if list == nil {
+			continue
+		}
+		for _, tx := range list.Flatten() {
+			explanation.Txs = append(explanation.Txs, pool.explain(tx, minTip))
+		}
+	}
+	return explanation
+}
+
+// slots returns the slot usage of an account.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) slots(addr common.Address) *TxPoolSlots {
+	pending, queued := pool.stats()
+	slots := &TxPoolSlots{
+		AccountSlots:  hexutil.Uint64(pool.config.AccountSlots),
+		AccountQueue:  hexutil.Uint64(pool.config.AccountQueue),
+		GlobalPending: hexutil.Uint64(pending),
+		GlobalQueued:  hexutil.Uint64(queued),
+		GlobalSlots:   hexutil.Uint64(pool.config.GlobalSlots),
+		GlobalQueue:   hexutil.Uint64(pool.config.GlobalQueue),
+	}
+	if list := pool.pending[addr]; list != nil {
+		slots.AccountPending = hexutil.Uint64(list.Len())
+	}
+	if list := pool.queue[addr]; list != nil {
+		slots.AccountQueued = hexutil.Uint64(list.Len())
+	}
+	return slots
+}
+
+// minTipCache is the lowest effective tip of the pending remote transactions,
+// computed once per chain head.
+type minTipCache struct {
+	tip *big.Int // Nil if there were no pending remote transactions
+}
+
+// pendingMinTip returns the lowest effective tip of the pending remote transactions,
+// nil if there are none. The tip is computed on the first call after a new head
+// and cached until the next one, transactions promoted meanwhile aren't counted.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) pendingMinTip() *big.Int {
+	if pool.minTip != nil {
+		return pool.minTip.tip
+	}
+	var minTip *big.Int
+	baseFee := pool.priced.urgent.baseFee
+	for addr, list := range pool.pending {
+		if pool.locals.contains(addr) {
+			continue
+		}
+		for _, tx := range list.Flatten() {
+			if tip := tx.EffectiveGasTipValue(baseFee); minTip == nil || tip.Cmp(minTip) < 0 {
+				minTip = tip
+			}
+		}
+	}
+	pool.minTip = &minTipCache{tip: minTip}
+	return minTip
+}
+
+// explain reports why a pooled transaction is waiting.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) explain(tx *types.Transaction, pendingMinTip *big.Int) *TxExplanation {
+	var (
+		hash    = tx.Hash()
+		from, _ = types.Sender(pool.signer, tx) // already validated
+		local   = pool.locals.contains(from)
+		baseFee = pool.priced.urgent.baseFee
+	)
+	explanation := &TxExplanation{
+		Hash:       hash,
+		From:       from,
+		Nonce:      hexutil.Uint64(tx.Nonce()),
+		Status:     "queued",
+		Local:      local,
+		StateNonce: hexutil.Uint64(pool.currentState.GetNonce(from)),
+		NextNonce:  hexutil.Uint64(pool.pendingNonces.get(from)),
+		GasTipCap:  (*hexutil.Big)(tx.GasTipCap()),
+		GasFeeCap:  (*hexutil.Big)(tx.GasFeeCap()),
+		BaseFee:    (*hexutil.Big)(baseFee),
+		MinTip:     (*hexutil.Big)(pool.gasPrice),
+		PriceBump:  hexutil.Uint64(pool.config.PriceBump),
+		Slots:      pool.slots(from),
+		JamIndex:   pool.jamIndexer.JamIndex(),
+		Reasons:    []string{},
+	}
+	if list := pool.pending[from]; list != nil && list.txs.Get(tx.Nonce()) != nil {
+		explanation.Status = "pending"
+	}
+	pending := explanation.Status == "pending"
+
+	// Nonce ordering
+	if !pending && tx.Nonce() > uint64(explanation.NextNonce) {
+		explanation.NonceGap = hexutil.Uint64(tx.Nonce() - uint64(explanation.NextNonce))
+		explanation.Reasons = append(explanation.Reasons, fmt.Sprintf("nonce gap: %d transaction(s) missing from nonce %d", explanation.NonceGap, explanation.NextNonce))
+	}
+	// Pricing
+	tip, err := tx.EffectiveGasTip(baseFee)
+	if err != nil {
+		explanation.Reasons = append(explanation.Reasons, fmt.Sprintf("fee cap %v below the pending base fee %v", tx.GasFeeCap(), baseFee))
+	} else {
+		explanation.EffectiveTip = (*hexutil.Big)(tip)
+		if !local && tip.Cmp(pool.gasPrice) < 0 {
+			explanation.Reasons = append(explanation.Reasons, fmt.Sprintf("tip %v below the minimal accepted tip %v", tip, pool.gasPrice))
+		}
+		if pendingMinTip != nil {
+			explanation.PendingMinTip = (*hexutil.Big)(pendingMinTip)
+			full := uint64(pool.all.Slots()) >= pool.config.GlobalSlots+pool.config.GlobalQueue
+			if full && !local && tip.Cmp(pendingMinTip) <= 0 {
+				explanation.Reasons = append(explanation.Reasons, "pool is full and the transaction is among the cheapest, first in line for eviction")
+			}
+		}
+	}
+	a := big.NewInt(100 + int64(pool.config.PriceBump))
+	explanation.ReplacementTipCap = (*hexutil.Big)(new(big.Int).Div(new(big.Int).Mul(a, tx.GasTipCap()), big.NewInt(100)))
+	explanation.ReplacementFeeCap = (*hexutil.Big)(new(big.Int).Div(new(big.Int).Mul(a, tx.GasFeeCap()), big.NewInt(100)))
+
+	if balance := pool.currentState.GetBalance(from); balance.Cmp(tx.Cost()) < 0 {
+		explanation.Reasons = append(explanation.Reasons, fmt.Sprintf("balance %v below the transaction cost %v", balance, tx.Cost()))
+	}
+	// Slot limits
+	slots := explanation.Slots
+	if !local {
+		if uint64(slots.AccountQueued) >= pool.config.AccountQueue {
+			explanation.Reasons = append(explanation.Reasons, "account queue is full, further future transactions are dropped")
+		}
+		if pending && uint64(slots.AccountPending) > pool.config.AccountSlots && uint64(slots.GlobalPending) > pool.config.GlobalSlots {
+			explanation.Reasons = append(explanation.Reasons, "pending pool is over capacity and the account exceeds its slots, transactions may be truncated")
+		}
+		if !pending && uint64(slots.GlobalQueued) > pool.config.GlobalQueue {
+			explanation.Reasons = append(explanation.Reasons, "queue is over capacity, queued transactions may be dropped")
+		}
+		// Lifetime of non-executable transactions
+		if beat, ok := pool.beats[from]; ok && !pending {
+			expiry := beat.Add(pool.config.Lifetime)
+			explanation.ExpiresAt = &expiry
+			explanation.Reasons = append(explanation.Reasons, fmt.Sprintf("queued transaction gets evicted at %v unless promoted", expiry.Format(time.RFC3339)))
+		}
+	}
+	// Jam index
+	if pending {
+		if _, points, ok := pool.jamIndexer.jamPoints(tx, pool.currentMaxGas/10*6); ok {
+			explanation.JamPoints = points
+		}
+		if len(explanation.Reasons) == 0 {
+			explanation.Reasons = append(explanation.Reasons, "executable, waiting for inclusion")
+		}
+	}
+	// Rejections of this transaction or, failing that, of its sender
+	if rejection := pool.rejections.tx(hash); rejection != nil {
+		explanation.LastRejection = rejection
+	} else if rejections := pool.rejections.sender(from); len(rejections) > 0 {
+		explanation.LastRejection = rejections[len(rejections)-1]
+	}
+	return explanation
+}
@@ -0,0 +1,140 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Tests that the pool explains nonce gaps of queued transactions and remembers
+// the transactions it refused.
+func TestTransactionExplain(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	testAddBalance(pool, addr, big.NewInt(100000000000000))
+
+	// A transaction with a nonce gap stays queued
+	queued := transaction(2, 100000, key)
+	if err := pool.addRemoteSync(queued); err != nil {
+		t.Fatalf("failed to add queued transaction: %v", err)
+	}
+	explanation, err := pool.Explain(queued.Hash())
+	if err != nil {
+		t.Fatalf("failed to explain queued transaction: %v", err)
+	}
+	if explanation.Status != "queued" {
+		t.Errorf("status mismatch: have %s, want queued", explanation.Status)
+	}
+	if explanation.NonceGap != 2 {
+		t.Errorf("nonce gap mismatch: have %d, want 2", explanation.NonceGap)
+	}
+	if explanation.ExpiresAt == nil {
+		t.Errorf("queued remote transaction has no expiry")
+	}
+	// An underpriced transaction is refused and remembered
+	underpriced := pricedTransaction(0, 100000, big.NewInt(0), key)
+	if err := pool.addRemoteSync(underpriced); err != ErrUnderpriced {
+		t.Fatalf("underpriced error mismatch: have %v, want %v", err, ErrUnderpriced)
+	}
+	explanation, err = pool.Explain(underpriced.Hash())
+	if err != nil {
+		t.Fatalf("failed to explain rejected transaction: %v", err)
+	}
+	if explanation.Status != "rejected" || explanation.LastRejection == nil || explanation.LastRejection.Error != ErrUnderpriced.Error() {
+		t.Errorf("rejection mismatch: have %s %+v", explanation.Status, explanation.LastRejection)
+	}
+	// Filling the gap makes the transactions executable
+	for nonce := uint64(0); nonce < 2; nonce++ {
+		if err := pool.addRemoteSync(transaction(nonce, 100000, key)); err != nil {
+			t.Fatalf("failed to add transaction %d: %v", nonce, err)
+		}
+	}
+	if explanation, _ = pool.Explain(queued.Hash()); explanation.Status != "pending" || explanation.NonceGap != 0 {
+		t.Errorf("promoted transaction mismatch: have %s with gap %d", explanation.Status, explanation.NonceGap)
+	}
+	account := pool.ExplainAccount(addr)
+	if len(account.Txs) != 3 || account.NextNonce != 3 {
+		t.Errorf("account mismatch: have %d txs and next nonce %d, want 3 and 3", len(account.Txs), account.NextNonce)
+	}
+	if len(account.Rejections) != 1 {
+		t.Errorf("account rejections mismatch: have %d, want 1", len(account.Rejections))
+	}
+	if _, err := pool.Explain(common.Hash{}); err != errTxNotFound {
+		t.Errorf("unknown transaction error mismatch: have %v, want %v", err, errTxNotFound)
+	}
+}
+
+// Tests that the lowest pending tip is computed once per head and that queued
+// transactions of accounts without a heartbeat get no expiry.
+func TestTransactionExplainPendingMinTip(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	other, _ := crypto.GenerateKey()
+	testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(100000000000000))
+	testAddBalance(pool, crypto.PubkeyToAddress(other.PublicKey), big.NewInt(100000000000000))
+
+	if err := pool.addRemoteSync(pricedTransaction(0, 100000, big.NewInt(10), key)); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	queued := pricedTransaction(2, 100000, big.NewInt(10), key)
+	if err := pool.addRemoteSync(queued); err != nil {
+		t.Fatalf("failed to add queued transaction: %v", err)
+	}
+	if explanation, _ := pool.Explain(queued.Hash()); explanation.PendingMinTip.ToInt().Int64() != 10 {
+		t.Errorf("pending min tip mismatch: have %v, want 10", explanation.PendingMinTip)
+	}
+	// A cheaper pending transaction only shows after the next head
+	if err := pool.addRemoteSync(pricedTransaction(0, 100000, big.NewInt(5), other)); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	if explanation, _ := pool.Explain(queued.Hash()); explanation.PendingMinTip.ToInt().Int64() != 10 {
+		t.Errorf("cached pending min tip mismatch: have %v, want 10", explanation.PendingMinTip)
+	}
+	<-pool.requestReset(nil, nil)
+	if explanation, _ := pool.Explain(queued.Hash()); explanation.PendingMinTip.ToInt().Int64() != 5 {
+		t.Errorf("pending min tip after reset mismatch: have %v, want 5", explanation.PendingMinTip)
+	}
+	// Without a heartbeat the eviction time of a queued transaction is unknown
+	pool.mu.Lock()
+	delete(pool.beats, crypto.PubkeyToAddress(key.PublicKey))
+	pool.mu.Unlock()
+
+	if explanation, _ := pool.Explain(queued.Hash()); explanation.ExpiresAt != nil {
+		t.Errorf("queued transaction without heartbeat has expiry %v", explanation.ExpiresAt)
+	}
+}
+
+// Tests that the rejections kept per sender are bounded.
+func TestTxRejectionsBounded(t *testing.T) {
+	rejections := newTxRejections()
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	for nonce := uint64(0); nonce < 2*senderRejectionsLimit; nonce++ {
+		rejections.record(addr, transaction(nonce, 100000, key), ErrUnderpriced)
+	}
+	ring := rejections.sender(addr)
+	if len(ring) != senderRejectionsLimit {
+		t.Fatalf("rejections mismatch: have %d, want %d", len(ring), senderRejectionsLimit)
+	}
+	if first := uint64(ring[0].Nonce); first != senderRejectionsLimit {
+		t.Errorf("oldest rejection mismatch: have nonce %d, want %d", first, senderRejectionsLimit)
+	}
+}
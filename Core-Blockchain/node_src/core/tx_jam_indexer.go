@@ -120,8 +120,6 @@ func (indexer *txJamIndexer) updateLoop() {
 			}
 			// flatten
 			var p int
-			max := indexer.cfg.MaxValidPendingSecs
-			jamsecs := indexer.cfg.JamSecs
 			maxGas := uint64(10000000)
 			if indexer.head != nil {
 				maxGas = (indexer.head.GasLimit / 10) * 6
@@ -129,22 +127,12 @@ func (indexer *txJamIndexer) updateLoop() {
 			durs := make([]time.Duration, 0, 1024)
 			for _, txs := range pendings {
 				for _, tx := range txs {
-					// filtering
-					if tx.GasPrice().Cmp(oneGwei) < 0 ||
-						tx.Gas() > maxGas {
+					dur, points, ok := indexer.jamPoints(tx, maxGas)
+					if !ok {
 						continue
 					}
-
-					dur := time.Since(tx.LocalSeenTime())
-					sec := int(dur / time.Second)
-					if sec > max {
-						continue
-					}
-
 					durs = append(durs, dur)
-					if sec >= jamsecs {
-						p += sec / jamsecs
-					}
+					p += points
 				}
 			}
 			nTotal := len(durs)
@@ -182,6 +170,26 @@ func (indexer *txJamIndexer) updateLoop() {
 	}
 }
 
+// jamPoints returns how long a pending transaction has been waiting and how many
+// points it adds to the jam index, ok is false if the transaction isn't counted.
+func (indexer *txJamIndexer) jamPoints(tx *types.Transaction, maxGas uint64) (dur time.Duration, points int, ok bool) {
+	// filtering
+	if tx.GasPrice().Cmp(oneGwei) < 0 ||
+		tx.Gas() > maxGas {
+		return 0, 0, false
+	}
+
+	dur = time.Since(tx.LocalSeenTime())
+	sec := int(dur / time.Second)
+	if sec > indexer.cfg.MaxValidPendingSecs {
+		return 0, 0, false
+	}
+	if sec >= indexer.cfg.JamSecs {
+		points = sec / indexer.cfg.JamSecs
+	}
+	return dur, points, true
+}
+
 func (indexer *txJamIndexer) UpdateHeader(h *types.Header) {
 	indexer.chainHeadCh <- h
 }
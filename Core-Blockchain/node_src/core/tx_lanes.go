@@ -0,0 +1,236 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package core
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// TxLane is a class of pool traffic with its own slot budget, eviction policy
+// and ordering, so that priority transactions don't compete with public ones.
+type TxLane int
+
+const (
+	TxLaneSystem TxLane = iota // Transactions to the system and governance contracts
+	TxLaneX402                 // x402 settlement envelopes
+	TxLaneLocal                // Transactions of local senders
+	TxLanePublic               // Everything else
+
+	numTxLanes
+)
+
+// ReservedTxLanes are the lanes with reserved capacity, in the order the miner
+// fills them.
+var ReservedTxLanes = []TxLane{TxLaneSystem, TxLaneX402, TxLaneLocal}
+
+func (l TxLane) String() string {
+	switch l {
+	case TxLaneSystem:
+		return "system"
+	case TxLaneX402:
+		return "x402"
+	case TxLaneLocal:
+		return "local"
+	case TxLanePublic:
+		return "public"
+	default:
+		return fmt.Sprintf("lane(%d)", int(l))
+	}
+}
+
+// TxLaneConfig is the configuration of a lane with reserved capacity.
+type TxLaneConfig struct {
+	Slots        uint64 // Slots reserved to the lane on top of the global ones, 0 for none
+	AccountSlots uint64 // Reserved slots a single sender can hold in the lane, 0 for all of them
+	GasShare     uint64 // Percentage of the block gas limit the miner fills with the lane first
+	EvictOldest  bool   // Whether a full lane evicts its oldest transaction rather than overflowing to the public lane
+	ByArrival    bool   // Whether the miner orders the lane by arrival rather than price
+}
+
+// TxLanesConfig is the configuration of the lanes with reserved capacity.
+type TxLanesConfig struct {
+	System TxLaneConfig
+	X402   TxLaneConfig
+	Local  TxLaneConfig
+
+	SystemContracts []common.Address // Recipients whose transactions ride the system lane
+}
+
+// DefaultTxLanesConfig contains the default lane configuration. Local senders are
+// already exempt from price eviction, so their lane only gets a gas share.
+var DefaultTxLanesConfig = TxLanesConfig{
+	System: TxLaneConfig{Slots: 256, AccountSlots: 16, GasShare: 10, ByArrival: true},
+	X402:   TxLaneConfig{Slots: 1024, AccountSlots: 64, GasShare: 20, EvictOldest: true, ByArrival: true},
+	Local:  TxLaneConfig{GasShare: 10},
+}
+
+// lane returns the configuration of a lane, nil for the public one.
+func (c *TxLanesConfig) lane(lane TxLane) *TxLaneConfig {
+	switch lane {
+	case TxLaneSystem:
+		return &c.System
+	case TxLaneX402:
+		return &c.X402
+	case TxLaneLocal:
+		return &c.Local
+	default:
+		return nil
+	}
+}
+
+// sanitize checks the provided lane configuration and changes anything that's
+// unreasonable or unworkable.
+func (c *TxLanesConfig) sanitize() {
+	for _, lane := range ReservedTxLanes {
+		if config := c.lane(lane); config.AccountSlots == 0 || config.AccountSlots > config.Slots {
+			config.AccountSlots = config.Slots
+		}
+	}
+	var total uint64
+	for _, lane := range ReservedTxLanes {
+		total += c.lane(lane).GasShare
+	}
+	if total > 100 {
+		log.Warn("Sanitizing invalid txpool lane gas shares", "provided", total, "updated", 100)
+		for _, lane := range ReservedTxLanes {
+			c.lane(lane).GasShare = c.lane(lane).GasShare * 100 / total
+		}
+	}
+}
+
+var (
+	laneSlotsGauges     [numTxLanes]metrics.Gauge // Slots used by the reserved transactions of each lane
+	laneEvictionMeters  [numTxLanes]metrics.Meter // Transactions evicted to make room in a full lane
+	laneOverflowMeters  [numTxLanes]metrics.Meter // Transactions falling back to the public lane
+	laneInclusionMeters [numTxLanes]metrics.Meter // Transactions included by the miner within the lane gas share
+)
+
+func init() {
+	for lane := TxLane(0); lane < numTxLanes; lane++ {
+		laneSlotsGauges[lane] = metrics.NewRegisteredGauge(fmt.Sprintf("txpool/lane/%s/slots", lane), nil)
+		laneEvictionMeters[lane] = metrics.NewRegisteredMeter(fmt.Sprintf("txpool/lane/%s/evicted", lane), nil)
+		laneOverflowMeters[lane] = metrics.NewRegisteredMeter(fmt.Sprintf("txpool/lane/%s/overflow", lane), nil)
+		laneInclusionMeters[lane] = metrics.NewRegisteredMeter(fmt.Sprintf("txpool/lane/%s/included", lane), nil)
+	}
+}
+
+// MarkLaneInclusions records transactions the miner included within the gas
+// share of a lane.
+func MarkLaneInclusions(lane TxLane, count int) {
+	laneInclusionMeters[lane].Mark(int64(count))
+}
+
+// laneOf classifies a transaction of the given sender into its lane.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) laneOf(tx *types.Transaction, from common.Address) TxLane {
+	if to := tx.To(); to != nil {
+		if _, ok := pool.systemContracts[*to]; ok {
+			return TxLaneSystem
+		}
+	}
+	if tx.Type() == types.X402TxType {
+		return TxLaneX402
+	}
+	if pool.locals.contains(from) {
+		return TxLaneLocal
+	}
+	return TxLanePublic
+}
+
+// reserveLane checks whether a new transaction of the given sender fits the
+// reserved capacity of its lane. It returns the public lane if the transaction
+// has to compete for the global slots. If the lane is full and its policy says
+// so, the oldest transaction of the lane is returned too, to be evicted once the
+// new one is accepted.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) reserveLane(tx *types.Transaction, from common.Address, lane TxLane) (TxLane, *types.Transaction) {
+	config := pool.config.Lanes.lane(lane)
+	if config == nil || config.Slots == 0 {
+		return TxLanePublic, nil
+	}
+	if uint64(pool.laneAccountSlots(from, lane)+numSlots(tx)) > config.AccountSlots {
+		laneOverflowMeters[lane].Mark(1)
+		return TxLanePublic, nil
+	}
+	if uint64(pool.all.LaneSlots(lane)+numSlots(tx)) <= config.Slots {
+		return lane, nil
+	}
+	if config.EvictOldest {
+		if oldest := pool.all.OldestInLane(lane); oldest != nil && numSlots(oldest) >= numSlots(tx) {
+			return lane, oldest
+		}
+	}
+	laneOverflowMeters[lane].Mark(1)
+	return TxLanePublic, nil
+}
+
+// evictFromLane drops a transaction picked by reserveLane to make room in a full
+// lane, unless it already left the pool.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) evictFromLane(tx *types.Transaction, lane TxLane) {
+	if pool.all.Get(tx.Hash()) == nil {
+		return
+	}
+	log.Trace("Evicting oldest lane transaction", "lane", lane, "hash", tx.Hash())
+	laneEvictionMeters[lane].Mark(1)
+	pool.removeTx(tx.Hash(), true)
+}
+
+// laneAccountSlots returns the number of reserved slots a sender holds in a lane.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) laneAccountSlots(from common.Address, lane TxLane) int {
+	var slots int
+	for _, list := range []*txList{pool.pending[from], pool.queue[from]} {
+		if list == nil {
+			continue
+		}
+		for _, tx := range list.txs.items {
+			if reserved, ok := pool.all.Lane(tx.Hash()); ok && reserved == lane {
+				slots += numSlots(tx)
+			}
+		}
+	}
+	return slots
+}
+
+// LanePending extracts from the given pending transactions the executable ones of
+// a lane: the transactions of the lane leading each account's list.
+func (pool *TxPool) LanePending(pending map[common.Address]types.Transactions, lane TxLane) map[common.Address]types.Transactions {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	txs := make(map[common.Address]types.Transactions)
+	for addr, list := range pending {
+		n := 0
+		for n < len(list) && pool.laneOf(list[n], addr) == lane {
+			n++
+		}
+		if n > 0 {
+			txs[addr] = list[:n]
+		}
+	}
+	return txs
+}
+
+// LaneConfig returns the configuration of a lane with reserved capacity.
+func (pool *TxPool) LaneConfig(lane TxLane) TxLaneConfig {
+	if config := pool.config.Lanes.lane(lane); config != nil {
+		return *config
+	}
+	return TxLaneConfig{}
+}
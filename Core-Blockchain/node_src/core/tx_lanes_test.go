@@ -0,0 +1,207 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package core
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func systemTransaction(nonce uint64, to common.Address, key *ecdsa.PrivateKey) *types.Transaction {
+	tx, _ := types.SignTx(types.NewTransaction(nonce, to, big.NewInt(0), 100000, big.NewInt(1), nil), types.HomesteadSigner{}, key)
+	return tx
+}
+
+// Tests that transactions of a lane with reserved capacity are admitted into a
+// full pool, and that a full lane evicts its oldest transaction if configured so.
+func TestTransactionLaneReservation(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{1000000, statedb, new(event.Feed)}
+
+	system := common.HexToAddress("0x000000000000000000000000000000000000f000")
+
+	config := testTxPoolConfig
+	config.GlobalSlots = 2
+	config.GlobalQueue = 2
+	config.Lanes.System = TxLaneConfig{Slots: 2, AccountSlots: 2, GasShare: 10, EvictOldest: true}
+	config.Lanes.SystemContracts = []common.Address{system}
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	keys := make([]*ecdsa.PrivateKey, 4)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		testAddBalance(pool, crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(1000000000))
+	}
+	// Fill the global slots with public transactions
+	for i := uint64(0); i < 4; i++ {
+		if err := pool.addRemoteSync(pricedTransaction(i, 100000, big.NewInt(2), keys[0])); err != nil {
+			t.Fatalf("failed to add public transaction %d: %v", i, err)
+		}
+	}
+	if err := pool.addRemoteSync(transaction(0, 100000, keys[1])); err != ErrUnderpriced {
+		t.Fatalf("public transaction error mismatch: have %v, want %v", err, ErrUnderpriced)
+	}
+	// System transactions ride the reserved capacity despite the full pool
+	first, second := systemTransaction(0, system, keys[1]), systemTransaction(0, system, keys[2])
+	for _, tx := range []*types.Transaction{first, second} {
+		if err := pool.addRemoteSync(tx); err != nil {
+			t.Fatalf("failed to add system transaction: %v", err)
+		}
+	}
+	if slots := pool.all.LaneSlots(TxLaneSystem); slots != 2 {
+		t.Fatalf("system lane slots mismatch: have %d, want %d", slots, 2)
+	}
+	// A full lane evicts its oldest transaction
+	third := systemTransaction(0, system, keys[3])
+	if err := pool.addRemoteSync(third); err != nil {
+		t.Fatalf("failed to add system transaction into full lane: %v", err)
+	}
+	if pool.Get(first.Hash()) != nil {
+		t.Fatalf("oldest system transaction not evicted")
+	}
+	if pool.Get(second.Hash()) == nil || pool.Get(third.Hash()) == nil {
+		t.Fatalf("newest system transactions missing")
+	}
+	if slots := pool.all.PublicSlots(); slots != 4 {
+		t.Fatalf("public slots mismatch: have %d, want %d", slots, 4)
+	}
+	// A rejected transaction doesn't evict anything
+	replacement, _ := types.SignTx(types.NewTransaction(0, system, big.NewInt(0), 100001, big.NewInt(1), nil), types.HomesteadSigner{}, keys[2])
+	if err := pool.addRemoteSync(replacement); err != ErrReplaceUnderpriced {
+		t.Fatalf("replacement error mismatch: have %v, want %v", err, ErrReplaceUnderpriced)
+	}
+	if pool.Get(second.Hash()) == nil {
+		t.Fatalf("system transaction evicted by a rejected one")
+	}
+	// A sender can't hold more than its share of the lane
+	if err := pool.addRemoteSync(systemTransaction(1, system, keys[3])); err != nil {
+		t.Fatalf("failed to add system transaction: %v", err)
+	}
+	if err := pool.addRemoteSync(systemTransaction(2, system, keys[3])); err != ErrUnderpriced {
+		t.Fatalf("capped system transaction error mismatch: have %v, want %v", err, ErrUnderpriced)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+	// The miner gets the leading lane transactions of each account
+	pending := pool.Pending(false)
+	lane := pool.LanePending(pending, TxLaneSystem)
+	if len(lane) != 1 {
+		t.Fatalf("system lane pending accounts mismatch: have %d, want %d", len(lane), 1)
+	}
+	if _, ok := lane[crypto.PubkeyToAddress(keys[0].PublicKey)]; ok {
+		t.Fatalf("public account in system lane")
+	}
+}
+
+// Tests that reserved lane transactions are neither evicted to make room for
+// public ones nor set the price floor of a full pool, and that a lane keeps its
+// oldest transaction on top as others leave.
+func TestTransactionLaneNotPriced(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{1000000, statedb, new(event.Feed)}
+
+	system := common.HexToAddress("0x000000000000000000000000000000000000f000")
+
+	config := testTxPoolConfig
+	config.GlobalSlots = 2
+	config.GlobalQueue = 2
+	config.Lanes.System = TxLaneConfig{Slots: 3, AccountSlots: 3, GasShare: 10, EvictOldest: true}
+	config.Lanes.SystemContracts = []common.Address{system}
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	keys := make([]*ecdsa.PrivateKey, 5)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		testAddBalance(pool, crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(1000000000))
+	}
+	lane := make([]*types.Transaction, 3)
+	for i := range lane {
+		lane[i] = systemTransaction(0, system, keys[i])
+		if err := pool.addRemoteSync(lane[i]); err != nil {
+			t.Fatalf("failed to add system transaction %d: %v", i, err)
+		}
+	}
+	for i := uint64(0); i < 4; i++ {
+		if err := pool.addRemoteSync(pricedTransaction(i, 100000, big.NewInt(2), keys[3])); err != nil {
+			t.Fatalf("failed to add public transaction %d: %v", i, err)
+		}
+	}
+	// The cheaper system transactions don't lower the floor of the public slots
+	if err := pool.addRemoteSync(pricedTransaction(0, 100000, big.NewInt(1), keys[4])); err != ErrUnderpriced {
+		t.Fatalf("public transaction error mismatch: have %v, want %v", err, ErrUnderpriced)
+	}
+	// A pricier public transaction evicts a public one
+	if err := pool.addRemoteSync(pricedTransaction(0, 100000, big.NewInt(3), keys[4])); err != nil {
+		t.Fatalf("failed to add pricier public transaction: %v", err)
+	}
+	for i, tx := range lane {
+		if pool.Get(tx.Hash()) == nil {
+			t.Fatalf("system transaction %d evicted for a public one", i)
+		}
+	}
+	if slots := pool.all.PublicSlots(); slots != 4 {
+		t.Fatalf("public slots mismatch: have %d, want %d", slots, 4)
+	}
+	// The oldest transaction of the lane follows the removals
+	if oldest := pool.all.OldestInLane(TxLaneSystem); oldest.Hash() != lane[0].Hash() {
+		t.Fatalf("oldest system transaction mismatch: have %x, want %x", oldest.Hash(), lane[0].Hash())
+	}
+	pool.mu.Lock()
+	pool.removeTx(lane[0].Hash(), true)
+	pool.mu.Unlock()
+
+	if oldest := pool.all.OldestInLane(TxLaneSystem); oldest.Hash() != lane[1].Hash() {
+		t.Fatalf("oldest system transaction after removal mismatch: have %x, want %x", oldest.Hash(), lane[1].Hash())
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that arrival ordered transaction sets disregard the fees.
+func TestTransactionsByArrivalAndNonce(t *testing.T) {
+	signer := types.HomesteadSigner{}
+	groups := make(map[common.Address]types.Transactions)
+	var hashes []common.Hash
+	for i := 0; i < 5; i++ {
+		key, _ := crypto.GenerateKey()
+		tx := pricedTransaction(0, 100000, big.NewInt(int64(i+1)), key)
+		groups[crypto.PubkeyToAddress(key.PublicKey)] = types.Transactions{tx}
+		hashes = append(hashes, tx.Hash())
+	}
+	txs := types.NewTransactionsByArrivalAndNonce(signer, groups)
+	for i, hash := range hashes {
+		tx := txs.Peek()
+		if tx == nil {
+			t.Fatalf("transaction %d missing", i)
+		}
+		if tx.Hash() != hash {
+			t.Fatalf("transaction %d out of arrival order", i)
+		}
+		txs.Shift()
+	}
+}
@@ -543,7 +543,7 @@ func (l *txPricedList) underpricedFor(h *priceHeap, tx *types.Transaction) bool
 	// Discard stale price points if found at the heap start
 	for len(h.list) > 0 {
 		head := h.list[0]
-		if l.all.GetRemote(head.Hash()) == nil { // Removed or migrated
+		if l.all.GetPriced(head.Hash()) == nil { // Removed, migrated or reserved
 			atomic.AddInt64(&l.stales, -1)
 			heap.Pop(h)
 			continue
@@ -569,7 +569,7 @@ func (l *txPricedList) Discard(slots int, force bool) (types.Transactions, bool)
 		if len(l.urgent.list)*floatingRatio > len(l.floating.list)*urgentRatio || floatingRatio == 0 {
 			// Discard stale transactions if found during cleanup
 			tx := heap.Pop(&l.urgent).(*types.Transaction)
-			if l.all.GetRemote(tx.Hash()) == nil { // Removed or migrated
+			if l.all.GetPriced(tx.Hash()) == nil { // Removed, migrated or reserved
 				atomic.AddInt64(&l.stales, -1)
 				continue
 			}
@@ -582,7 +582,7 @@ func (l *txPricedList) Discard(slots int, force bool) (types.Transactions, bool)
 			}
 			// Discard stale transactions if found during cleanup
 			tx := heap.Pop(&l.floating).(*types.Transaction)
-			if l.all.GetRemote(tx.Hash()) == nil { // Removed or migrated
+			if l.all.GetPriced(tx.Hash()) == nil { // Removed, migrated or reserved
 				atomic.AddInt64(&l.stales, -1)
 				continue
 			}
@@ -609,7 +609,11 @@ func (l *txPricedList) Reheap() {
 	atomic.StoreInt64(&l.stales, 0)
 	l.urgent.list = make([]*types.Transaction, 0, l.all.RemoteCount())
 	l.all.Range(func(hash common.Hash, tx *types.Transaction, local bool) bool {
-		l.urgent.list = append(l.urgent.list, tx)
+		// Reserved lane transactions don't compete for the global slots, the
+		// lookup lock is held by Range
+		if _, reserved := l.all.reserved[hash]; !reserved {
+			l.urgent.list = append(l.urgent.list, tx)
+		}
 		return true
 	}, false, true) // Only iterate remotes
 	heap.Init(&l.urgent)
@@ -0,0 +1,168 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package core
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	// ErrMetaExpired is returned if the block limit of the sponsored payload of a
+	// meta transaction has passed.
+	ErrMetaExpired = errors.New("meta transaction expired")
+
+	// ErrMetaSponsorMismatch is returned if a pooled sponsor signature is reused
+	// for another relayer, nonce or fee parameters, which would bill another
+	// account than the sponsor.
+	ErrMetaSponsorMismatch = errors.New("meta transaction signature doesn't match the sponsor")
+)
+
+var metaExpiredMeter = metrics.NewRegisteredMeter("txpool/meta/expired", nil)
+
+// metaTx is the sponsorship of a pooled meta transaction.
+type metaTx struct {
+	sponsor common.Address // Inner signer covering a share of the fees
+	relayer common.Address // Outer sender of the transaction
+	nonce   uint64         // Outer nonce of the relayer
+	limit   uint64         // Last block the payload can be included in
+	percent uint64         // Share of the fees covered by the sponsor, in basis points
+	sig     common.Hash    // Identifier of the sponsor signature
+}
+
+// txMetas indexes the pooled meta transactions by hash and by sponsor signature,
+// so that a sponsored payload is only relayed once.
+//
+// Entries of transactions that left the pool are dropped lazily.
+type txMetas struct {
+	byTx  map[common.Hash]*metaTx
+	bySig map[common.Hash]common.Hash
+}
+
+func newTxMetas() *txMetas {
+	return &txMetas{
+		byTx:  make(map[common.Hash]*metaTx),
+		bySig: make(map[common.Hash]common.Hash),
+	}
+}
+
+// parseMeta decodes the sponsorship of a meta transaction sent by from, checking
+// it's still includable in the block after head. It returns nil for the plain
+// transactions.
+func (pool *TxPool) parseMeta(tx *types.Transaction, from common.Address, head *types.Header) (*metaTx, error) {
+	if !types.IsMetaTransaction(tx.Data()) {
+		return nil, nil
+	}
+	next := new(big.Int).Add(head.Number, common.Big1)
+	meta, err := types.DecodeMetaData(tx.Data(), common.Big0)
+	if err != nil {
+		return nil, err
+	}
+	if meta.BlockNumLimit < next.Uint64() {
+		return nil, ErrMetaExpired
+	}
+	sponsor, err := meta.ParseMetaData(tx.Nonce(), tx.GasPrice(), tx.Gas(), tx.To(), tx.Value(), meta.Payload, from, pool.chainconfig.ChainID)
+	if err != nil {
+		return nil, err
+	}
+	return &metaTx{
+		sponsor: sponsor,
+		relayer: from,
+		nonce:   tx.Nonce(),
+		limit:   meta.BlockNumLimit,
+		percent: meta.FeePercent,
+		sig:     crypto.Keccak256Hash(meta.R.Bytes(), meta.S.Bytes()),
+	}, nil
+}
+
+// validateMeta checks the sponsorship of a meta transaction: the sponsor has to
+// afford its share of the fees, and a pooled sponsor signature can only be reused
+// for the signed relayer, nonce and fee parameters. Any other outer transaction
+// recovers another sponsor than the pooled one.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) validateMeta(tx *types.Transaction, from common.Address) error {
+	meta, err := pool.parseMeta(tx, from, pool.currentHead)
+	if meta == nil {
+		return err
+	}
+	if prev := pool.pooledMeta(meta.sig); prev != nil {
+		if prev.sponsor != meta.sponsor {
+			return ErrMetaSponsorMismatch
+		}
+	}
+	fees := new(big.Int).Mul(new(big.Int).SetUint64(tx.Gas()), tx.GasPrice())
+	share := fees.Div(fees.Mul(fees, new(big.Int).SetUint64(meta.percent)), types.BIG10000)
+	if pool.currentState.GetBalance(meta.sponsor).Cmp(share) < 0 {
+		return ErrInsufficientMetaFunds
+	}
+	return nil
+}
+
+// pooledMeta returns the sponsorship of the pooled transaction carrying the given
+// sponsor signature, if any.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) pooledMeta(sig common.Hash) *metaTx {
+	hash, ok := pool.metas.bySig[sig]
+	if !ok {
+		return nil
+	}
+	if pool.all.Get(hash) == nil {
+		delete(pool.metas.bySig, sig)
+		delete(pool.metas.byTx, hash)
+		return nil
+	}
+	return pool.metas.byTx[hash]
+}
+
+// trackMeta indexes a freshly pooled transaction if it's sponsored.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) trackMeta(tx *types.Transaction, from common.Address) {
+	meta, err := pool.parseMeta(tx, from, pool.currentHead)
+	if meta == nil || err != nil {
+		return
+	}
+	hash := tx.Hash()
+	pool.metas.byTx[hash] = meta
+	pool.metas.bySig[meta.sig] = hash
+}
+
+// resetMetas drops the sponsored transactions whose payload can't be included
+// after the new head anymore, so their relayers can reuse the nonces, and forgets
+// the ones that left the pool.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) resetMetas(head *types.Header) {
+	if head == nil {
+		head = pool.currentHead
+	}
+	next := head.Number.Uint64() + 1
+	for hash, meta := range pool.metas.byTx {
+		if pool.all.Get(hash) == nil {
+			delete(pool.metas.byTx, hash)
+			delete(pool.metas.bySig, meta.sig)
+			continue
+		}
+		if meta.limit < next {
+			log.Trace("Dropping expired meta transaction", "hash", hash, "relayer", meta.relayer, "limit", meta.limit)
+			pool.removeTx(hash, true)
+			delete(pool.metas.byTx, hash)
+			delete(pool.metas.bySig, meta.sig)
+			metaExpiredMeter.Mark(1)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package core
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// sponsoredData wraps a payload into meta transaction call data, signed by the
+// sponsor for the given relayer and outer transaction fields.
+func sponsoredData(sponsor *ecdsa.PrivateKey, relayer common.Address, nonce uint64, gasPrice *big.Int, gas uint64, limit uint64, percent uint64, payload []byte) []byte {
+	to, value, chainID := common.Address{}, big.NewInt(0), params.TestChainConfig.ChainID
+
+	blob, _ := rlp.EncodeToBytes([]interface{}{nonce, gasPrice, gas, &to, value, payload, relayer, percent, limit, chainID})
+	sig, _ := crypto.Sign(crypto.Keccak256(blob), sponsor)
+
+	v := new(big.Int).Add(big.NewInt(int64(sig[64])+35), new(big.Int).Mul(chainID, big.NewInt(2)))
+	meta, _ := rlp.EncodeToBytes(&types.MetaData{
+		BlockNumLimit: limit,
+		FeePercent:    percent,
+		V:             v,
+		R:             new(big.Int).SetBytes(sig[:32]),
+		S:             new(big.Int).SetBytes(sig[32:64]),
+		Payload:       payload,
+	})
+	prefix, _ := hex.DecodeString(types.MetaPrefix)
+	return append(prefix, meta...)
+}
+
+// dataTransaction creates a transaction with the given call data to the zero
+// address, signed by key.
+func dataTransaction(nonce uint64, gasPrice *big.Int, gas uint64, data []byte, key *ecdsa.PrivateKey) *types.Transaction {
+	tx, _ := types.SignTx(types.NewTransaction(nonce, common.Address{}, big.NewInt(0), gas, gasPrice, data), types.HomesteadSigner{}, key)
+	return tx
+}
+
+// Tests that sponsored transactions can only be relayed once, by the relayer they
+// were signed for, and that the relayer can replace them with the bare payload.
+func TestMetaTransactionRelaying(t *testing.T) {
+	t.Parallel()
+
+	pool, relayer := setupTxPool()
+	defer pool.Stop()
+
+	sponsor, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+	for _, key := range []*ecdsa.PrivateKey{relayer, sponsor, other} {
+		testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(100000000))
+	}
+	var (
+		from    = crypto.PubkeyToAddress(relayer.PublicKey)
+		payload = []byte{0xa9, 0x05, 0x9c, 0xbb}
+		price   = big.NewInt(10)
+		gas     = uint64(100000)
+	)
+	// Expired payloads are rejected outright
+	expired := dataTransaction(0, price, gas, sponsoredData(sponsor, from, 0, price, gas, 0, 5000, payload), relayer)
+	if err := pool.AddRemote(expired); err != ErrMetaExpired {
+		t.Fatalf("expired meta transaction error mismatch: have %v, want %v", err, ErrMetaExpired)
+	}
+	data := sponsoredData(sponsor, from, 0, price, gas, 100, 5000, payload)
+	tx := dataTransaction(0, price, gas, data, relayer)
+	if err := pool.AddRemote(tx); err != nil {
+		t.Fatalf("failed to add meta transaction: %v", err)
+	}
+	// Another relayer can't relay the same payload
+	if err := pool.AddRemote(dataTransaction(0, price, gas, data, other)); err != ErrMetaSponsorMismatch {
+		t.Fatalf("replayed meta transaction error mismatch: have %v, want %v", err, ErrMetaSponsorMismatch)
+	}
+	// The relayer can't reuse the sponsor signature for other fees
+	bumped := big.NewInt(11)
+	if err := pool.AddRemote(dataTransaction(0, bumped, gas, data, relayer)); err != ErrMetaSponsorMismatch {
+		t.Fatalf("re-priced meta transaction error mismatch: have %v, want %v", err, ErrMetaSponsorMismatch)
+	}
+	// The relayer can replace it with the bare payload, paying the whole fee
+	bare := dataTransaction(0, bumped, gas, payload, relayer)
+	if err := pool.AddRemote(bare); err != nil {
+		t.Fatalf("failed to replace meta transaction: %v", err)
+	}
+	if pool.Get(tx.Hash()) != nil || pool.Get(bare.Hash()) == nil {
+		t.Fatalf("meta transaction not replaced")
+	}
+	// Re-signed sponsorships of the replaced payload are accepted again
+	resigned := dataTransaction(0, big.NewInt(13), gas, sponsoredData(sponsor, from, 0, big.NewInt(13), gas, 100, 5000, payload), relayer)
+	if err := pool.AddRemote(resigned); err != nil {
+		t.Fatalf("failed to add re-signed meta transaction: %v", err)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that sponsored transactions are dropped once their block limit passes.
+func TestMetaTransactionExpiry(t *testing.T) {
+	t.Parallel()
+
+	pool, relayer := setupTxPool()
+	defer pool.Stop()
+
+	sponsor, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(relayer.PublicKey)
+	testAddBalance(pool, from, big.NewInt(100000000))
+	testAddBalance(pool, crypto.PubkeyToAddress(sponsor.PublicKey), big.NewInt(100000000))
+
+	tx := dataTransaction(0, big.NewInt(1), 100000, sponsoredData(sponsor, from, 0, big.NewInt(1), 100000, 5, 10000, nil), relayer)
+	if err := pool.AddRemote(tx); err != nil {
+		t.Fatalf("failed to add meta transaction: %v", err)
+	}
+	pool.mu.Lock()
+	pool.resetMetas(&types.Header{Number: big.NewInt(4)})
+	pool.mu.Unlock()
+	if pool.Get(tx.Hash()) == nil {
+		t.Fatalf("live meta transaction dropped")
+	}
+	pool.mu.Lock()
+	pool.resetMetas(&types.Header{Number: big.NewInt(5)})
+	pool.mu.Unlock()
+	if pool.Get(tx.Hash()) != nil {
+		t.Fatalf("expired meta transaction retained")
+	}
+	if len(pool.metas.byTx) != 0 || len(pool.metas.bySig) != 0 {
+		t.Fatalf("expired meta transaction still indexed")
+	}
+	// Expiry is checked against the head the pool is at, not the chain's
+	pool.mu.Lock()
+	pool.currentHead = &types.Header{Number: big.NewInt(5)}
+	pool.mu.Unlock()
+
+	tx = dataTransaction(0, big.NewInt(1), 100000, sponsoredData(sponsor, from, 0, big.NewInt(1), 100000, 5, 10000, nil), relayer)
+	if err := pool.AddRemote(tx); err != ErrMetaExpired {
+		t.Fatalf("meta transaction expired at the pool head error mismatch: have %v, want %v", err, ErrMetaExpired)
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package core
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// persistedTx is a pooled transaction as stored in the pool snapshot.
+type persistedTx struct {
+	Tx    *types.Transaction
+	Time  uint64 // Time first seen locally, in unix nanoseconds
+	Local bool
+}
+
+// txPersister snapshots the pending and queued transactions of the pool, remote
+// ones included, into the database so a restarted node doesn't lose them.
+type txPersister struct {
+	db    ethdb.KeyValueStore // Database to store the snapshot into
+	limit uint64              // Maximum size in bytes of the snapshot
+}
+
+// collect gathers the pooled transactions to snapshot, those of local senders
+// first, then pending before queued ones, each group by price honouring the nonce
+// order, so that the size cap drops the least useful ones without leaving gaps.
+//
+// Note, this method assumes the pool lock is held!
+func (p *txPersister) collect(pool *TxPool) []persistedTx {
+	var (
+		txs  []persistedTx
+		size uint64
+	)
+	for _, local := range []bool{true, false} {
+		for _, lists := range []map[common.Address]*txList{pool.pending, pool.queue} {
+			group := make(map[common.Address]types.Transactions)
+			for addr, list := range lists {
+				if pool.locals.contains(addr) == local {
+					group[addr] = list.Flatten()
+				}
+			}
+			// Without a base fee no transaction is left out for its fee cap
+			set := types.NewTransactionsByPriceAndNonce(pool.signer, group, nil)
+			for tx := set.Peek(); tx != nil; tx = set.Peek() {
+				if size += uint64(tx.Size()); size > p.limit {
+					return txs
+				}
+				txs = append(txs, persistedTx{Tx: tx, Time: uint64(tx.LocalSeenTime().UnixNano()), Local: local})
+				set.Shift()
+			}
+		}
+	}
+	return txs
+}
+
+// save writes a snapshot of the given transactions into the database.
+func (p *txPersister) save(txs []persistedTx) error {
+	blob, err := rlp.EncodeToBytes(txs)
+	if err != nil {
+		return err
+	}
+	rawdb.WriteTxPoolSnapshot(p.db, blob)
+	return nil
+}
+
+// load reads the last snapshot from the database.
+func (p *txPersister) load() ([]persistedTx, error) {
+	blob := rawdb.ReadTxPoolSnapshot(p.db)
+	if len(blob) == 0 {
+		return nil, nil
+	}
+	return decodeTxPoolSnapshot(blob)
+}
+
+// decodeTxPoolSnapshot decodes a pool snapshot, restoring the time the
+// transactions were first seen.
+func decodeTxPoolSnapshot(blob []byte) ([]persistedTx, error) {
+	var txs []persistedTx
+	if err := rlp.DecodeBytes(blob, &txs); err != nil {
+		return nil, err
+	}
+	for _, entry := range txs {
+		entry.Tx.SetTime(time.Unix(0, int64(entry.Time)))
+	}
+	return txs, nil
+}
+
+// EncodeTxPoolSnapshot encodes the given transactions in the format of the pool
+// snapshots, keeping the time they were first seen.
+func EncodeTxPoolSnapshot(txs []*types.Transaction) ([]byte, error) {
+	entries := make([]persistedTx, len(txs))
+	for i, tx := range txs {
+		entries[i] = persistedTx{Tx: tx, Time: uint64(tx.LocalSeenTime().UnixNano())}
+	}
+	return rlp.EncodeToBytes(entries)
+}
+
+// DecodeTxPoolSnapshot decodes the transactions of a pool snapshot, as recorded
+// in the database or by EncodeTxPoolSnapshot.
+func DecodeTxPoolSnapshot(blob []byte) ([]*types.Transaction, error) {
+	entries, err := decodeTxPoolSnapshot(blob)
+	if err != nil {
+		return nil, err
+	}
+	txs := make([]*types.Transaction, len(entries))
+	for i, entry := range entries {
+		txs[i] = entry.Tx
+	}
+	return txs, nil
+}
+
+// EnablePersistence starts snapshotting the pool contents into the database, and
+// reloads the transactions of the last snapshot, revalidating them against the
+// current head.
+func (pool *TxPool) EnablePersistence(db ethdb.KeyValueStore) {
+	persister := &txPersister{db: db, limit: pool.config.PersistLimit}
+
+	txs, err := persister.load()
+	if err != nil {
+		log.Warn("Failed to load transaction pool snapshot", "err", err)
+	}
+	var locals, remotes []*types.Transaction
+	for _, entry := range txs {
+		if entry.Local && !pool.config.NoLocals {
+			locals = append(locals, entry.Tx)
+		} else {
+			remotes = append(remotes, entry.Tx)
+		}
+	}
+	dropped := 0
+	for _, err := range pool.addTxs(locals, true, true) {
+		if err != nil && err != ErrAlreadyKnown {
+			dropped++
+		}
+	}
+	for _, err := range pool.addTxs(remotes, false, true) {
+		if err != nil && err != ErrAlreadyKnown {
+			dropped++
+		}
+	}
+	if len(txs) > 0 {
+		log.Info("Loaded transaction pool snapshot", "transactions", len(txs), "dropped", dropped)
+	}
+	pool.mu.Lock()
+	pool.persister = persister
+	pool.mu.Unlock()
+}
+
+// persist snapshots the pool contents into the database, if enabled.
+func (pool *TxPool) persist() {
+	pool.mu.RLock()
+	persister := pool.persister
+	var txs []persistedTx
+	if persister != nil {
+		txs = persister.collect(pool)
+	}
+	pool.mu.RUnlock()
+
+	if persister == nil {
+		return
+	}
+	if err := persister.save(txs); err != nil {
+		log.Warn("Failed to save transaction pool snapshot", "err", err)
+		return
+	}
+	log.Debug("Saved transaction pool snapshot", "transactions", len(txs))
+}
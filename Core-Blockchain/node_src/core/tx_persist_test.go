@@ -0,0 +1,210 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package core
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that remote pending and queued transactions survive a pool restart with
+// their arrival times, and that the ones invalidated meanwhile are dropped.
+func TestTransactionPoolPersistence(t *testing.T) {
+	t.Parallel()
+
+	db := rawdb.NewMemoryDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{1000000, statedb, new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.Persist = true
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	pool.EnablePersistence(db)
+
+	keys := make([]*ecdsa.PrivateKey, 2)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		testAddBalance(pool, crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(1000000000))
+	}
+	txs := types.Transactions{
+		transaction(0, 100000, keys[0]),
+		transaction(1, 100000, keys[0]),
+		transaction(3, 100000, keys[0]), // queued
+		transaction(0, 100000, keys[1]),
+	}
+	for _, err := range pool.AddRemotesSync(txs) {
+		if err != nil {
+			t.Fatalf("failed to add transaction: %v", err)
+		}
+	}
+	pool.Stop()
+
+	// The second sender spends its nonce while the node is down
+	testSetNonce(pool, crypto.PubkeyToAddress(keys[1].PublicKey), 1)
+
+	pool = NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+	pool.EnablePersistence(db)
+
+	pending, queued := pool.Stats()
+	if pending != 2 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 2)
+	}
+	if queued != 1 {
+		t.Fatalf("queued transactions mismatched: have %d, want %d", queued, 1)
+	}
+	for _, tx := range txs[:3] {
+		restored := pool.Get(tx.Hash())
+		if restored == nil {
+			t.Fatalf("transaction %x not restored", tx.Hash())
+		}
+		if !restored.LocalSeenTime().Equal(tx.LocalSeenTime()) {
+			t.Fatalf("transaction %x arrival time mismatch: have %v, want %v", tx.Hash(), restored.LocalSeenTime(), tx.LocalSeenTime())
+		}
+	}
+	if pool.Get(txs[3].Hash()) != nil {
+		t.Fatalf("stale transaction restored")
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that the pool snapshot is capped to its configured size.
+func TestTransactionPoolPersistenceLimit(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+	for i := uint64(0); i < 10; i++ {
+		if err := pool.addRemoteSync(transaction(i, 100000, key)); err != nil {
+			t.Fatalf("failed to add transaction %d: %v", i, err)
+		}
+	}
+	size := uint64(transaction(0, 100000, key).Size())
+
+	persister := &txPersister{db: rawdb.NewMemoryDatabase(), limit: 4 * size}
+	pool.mu.RLock()
+	txs := persister.collect(pool)
+	pool.mu.RUnlock()
+
+	if len(txs) != 4 {
+		t.Fatalf("snapshot size mismatch: have %d, want %d", len(txs), 4)
+	}
+}
+
+// Tests that the pool snapshot keeps the local transactions first, then the
+// pending ones by price in nonce order, so the size cap drops the cheapest.
+func TestTransactionPoolPersistenceOrder(t *testing.T) {
+	t.Parallel()
+
+	pool, _ := setupTxPool()
+	defer pool.Stop()
+
+	keys := make([]*ecdsa.PrivateKey, 3)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		testAddBalance(pool, crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(1000000000))
+	}
+	cheap := types.Transactions{
+		pricedTransaction(0, 100000, big.NewInt(1), keys[0]),
+		pricedTransaction(1, 100000, big.NewInt(1), keys[0]),
+		pricedTransaction(3, 100000, big.NewInt(10), keys[0]), // queued
+	}
+	pricey := types.Transactions{
+		pricedTransaction(0, 100000, big.NewInt(5), keys[1]),
+		pricedTransaction(1, 100000, big.NewInt(5), keys[1]),
+	}
+	local := pricedTransaction(0, 100000, big.NewInt(1), keys[2])
+
+	for _, err := range pool.AddRemotesSync(append(append(types.Transactions{}, cheap...), pricey...)) {
+		if err != nil {
+			t.Fatalf("failed to add transaction: %v", err)
+		}
+	}
+	if err := pool.AddLocal(local); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	want := types.Transactions{local, pricey[0], pricey[1], cheap[0]}
+
+	var limit uint64
+	for _, tx := range want {
+		limit += uint64(tx.Size())
+	}
+	persister := &txPersister{db: rawdb.NewMemoryDatabase(), limit: limit}
+	pool.mu.RLock()
+	txs := persister.collect(pool)
+	pool.mu.RUnlock()
+
+	if len(txs) != len(want) {
+		t.Fatalf("snapshot size mismatch: have %d, want %d", len(txs), len(want))
+	}
+	for i, entry := range txs {
+		if entry.Tx.Hash() != want[i].Hash() {
+			t.Errorf("transaction %d mismatch: have %x, want %x", i, entry.Tx.Hash(), want[i].Hash())
+		}
+		if entry.Local != (i == 0) {
+			t.Errorf("transaction %d local flag mismatch: have %v", i, entry.Local)
+		}
+	}
+}
+
+// Tests that pool snapshots decode with their arrival times, whether recorded by
+// the pool or encoded for replay.
+func TestTxPoolSnapshotCodec(t *testing.T) {
+	t.Parallel()
+
+	key, _ := crypto.GenerateKey()
+	txs := []*types.Transaction{transaction(0, 100000, key), transaction(1, 100000, key)}
+	for i, tx := range txs {
+		tx.SetTime(time.Unix(int64(i+1), 0))
+	}
+	blob, err := EncodeTxPoolSnapshot(txs)
+	if err != nil {
+		t.Fatalf("failed to encode snapshot: %v", err)
+	}
+	decoded, err := DecodeTxPoolSnapshot(blob)
+	if err != nil {
+		t.Fatalf("failed to decode snapshot: %v", err)
+	}
+	if len(decoded) != len(txs) {
+		t.Fatalf("decoded transactions mismatch: have %d, want %d", len(decoded), len(txs))
+	}
+	for i, tx := range decoded {
+		if tx.Hash() != txs[i].Hash() || !tx.LocalSeenTime().Equal(txs[i].LocalSeenTime()) {
+			t.Errorf("transaction %d mismatch: have %x at %v, want %x at %v", i, tx.Hash(), tx.LocalSeenTime(), txs[i].Hash(), txs[i].LocalSeenTime())
+		}
+	}
+	// Snapshots recorded by the pool decode the same way
+	db := rawdb.NewMemoryDatabase()
+	persister := &txPersister{db: db, limit: 1024 * 1024}
+	if err := persister.save([]persistedTx{{Tx: txs[0], Time: uint64(txs[0].LocalSeenTime().UnixNano()), Local: true}}); err != nil {
+		t.Fatalf("failed to save snapshot: %v", err)
+	}
+	recorded, err := DecodeTxPoolSnapshot(rawdb.ReadTxPoolSnapshot(db))
+	if err != nil {
+		t.Fatalf("failed to decode recorded snapshot: %v", err)
+	}
+	if len(recorded) != 1 || recorded[0].Hash() != txs[0].Hash() || !recorded[0].LocalSeenTime().Equal(txs[0].LocalSeenTime()) {
+		t.Errorf("recorded snapshot mismatch: %v", recorded)
+	}
+}
@@ -27,6 +27,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/mclock"
 	"github.com/ethereum/go-ethereum/common/prque"
 	"github.com/ethereum/go-ethereum/consensus/misc"
 	"github.com/ethereum/go-ethereum/core/state"
@@ -171,7 +172,13 @@ type TxPoolConfig struct {
 
 	Lifetime time.Duration // Maximum amount of time non-executable transaction are queued
 
+	Persist         bool          // Whether to snapshot all pending and queued transactions into the database
+	PersistInterval time.Duration // Time interval to regenerate the transaction pool snapshot
+	PersistLimit    uint64        // Maximum size in bytes of the transaction pool snapshot
+
 	JamConfig TxJamConfig
+	Lanes     TxLanesConfig
+	RateLimit TxRateLimitConfig
 }
 
 // DefaultTxPoolConfig contains the default configurations for the transaction
@@ -190,7 +197,12 @@ var DefaultTxPoolConfig = TxPoolConfig{
 
 	Lifetime: 3 * time.Hour,
 
+	PersistInterval: time.Minute,
+	PersistLimit:    32 * 1024 * 1024,
+
 	JamConfig: DefaultJamConfig,
+	Lanes:     DefaultTxLanesConfig,
+	RateLimit: DefaultTxRateLimitConfig,
 }
 
 // sanitize checks the provided user configurations and changes anything that's
@@ -229,6 +241,16 @@ func (config *TxPoolConfig) sanitize() TxPoolConfig {
 		log.Warn("Sanitizing invalid txpool lifetime", "provided", conf.Lifetime, "updated", DefaultTxPoolConfig.Lifetime)
 		conf.Lifetime = DefaultTxPoolConfig.Lifetime
 	}
+	if conf.PersistInterval < time.Second {
+		log.Warn("Sanitizing invalid txpool persist interval", "provided", conf.PersistInterval, "updated", DefaultTxPoolConfig.PersistInterval)
+		conf.PersistInterval = DefaultTxPoolConfig.PersistInterval
+	}
+	if conf.PersistLimit < 1 {
+		log.Warn("Sanitizing invalid txpool persist limit", "provided", conf.PersistLimit, "updated", DefaultTxPoolConfig.PersistLimit)
+		conf.PersistLimit = DefaultTxPoolConfig.PersistLimit
+	}
+	conf.Lanes.sanitize()
+	conf.RateLimit.sanitize()
 	return conf
 }
 
@@ -253,12 +275,14 @@ type TxPool struct {
 	eip2718  bool // Fork indicator whether we are using EIP-2718 type transactions.
 	eip1559  bool // Fork indicator whether we are using EIP-1559 type transactions.
 
+	currentHead   *types.Header  // Current head of the blockchain
 	currentState  *state.StateDB // Current state in the blockchain head
 	pendingNonces *txNoncer      // Pending state tracking virtual nonces
 	currentMaxGas uint64         // Current gas limit for transaction caps
 
-	locals  *accountSet // Set of local transaction to exempt from eviction rules
-	journal *txJournal  // Journal of local transaction to back up to disk
+	locals    *accountSet  // Set of local transaction to exempt from eviction rules
+	journal   *txJournal   // Journal of local transaction to back up to disk
+	persister *txPersister // Snapshotter of all pooled transactions, nil if disabled
 
 	pending map[common.Address]*txList   // All currently processable transactions
 	queue   map[common.Address]*txList   // Queued but non-processable transactions
@@ -266,7 +290,16 @@ type TxPool struct {
 	all     *txLookup                    // All transactions to allow lookups
 	priced  *txPricedList                // All transactions sorted by price
 
-	jamIndexer *txJamIndexer // tx jam indexer
+	jamIndexer *txJamIndexer  // tx jam indexer
+	bundles    *txBundlePool  // Bundles waiting for atomic inclusion
+	rejections *txRejections  // Recent rejections, to explain stuck transactions
+	minTip     *minTipCache   // Lowest pending remote tip at the current head, nil until computed
+	limiter    *txRateLimiter // Rate limits of the transaction ingress
+
+	conditionals *txConditionals // Transactions waiting for their conditions
+	metas        *txMetas        // Sponsorships of the pooled meta transactions
+
+	systemContracts map[common.Address]struct{} // Recipients of the system lane
 
 	txValidator    exTxValidator // A specific consensus can use this to do some extra validation to a transaction
 	nextFakeHeader *types.Header // A fake header of next block for extra transaction validation
@@ -319,6 +352,15 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		gasPrice:        new(big.Int).SetUint64(config.PriceLimit),
 	}
 	pool.jamIndexer = newTxJamIndexer(config.JamConfig, pool)
+	pool.bundles = newTxBundlePool()
+	pool.rejections = newTxRejections()
+	pool.limiter = newTxRateLimiter(config.RateLimit, mclock.System{})
+	pool.conditionals = newTxConditionals()
+	pool.metas = newTxMetas()
+	pool.systemContracts = make(map[common.Address]struct{})
+	for _, addr := range config.Lanes.SystemContracts {
+		pool.systemContracts[addr] = struct{}{}
+	}
 	pool.locals = newAccountSet(pool.signer)
 	for _, addr := range config.Locals {
 		log.Info("Setting new local account", "address", addr)
@@ -369,12 +411,14 @@ func (pool *TxPool) loop() {
 		report  = time.NewTicker(statsReportInterval)
 		evict   = time.NewTicker(evictionInterval)
 		journal = time.NewTicker(pool.config.Rejournal)
+		persist = time.NewTicker(pool.config.PersistInterval)
 		// Track the previous head headers for transaction reorgs
 		head = pool.chain.CurrentBlock()
 	)
 	defer report.Stop()
 	defer evict.Stop()
 	defer journal.Stop()
+	defer persist.Stop()
 
 	// Notify tests that the init phase is done
 	close(pool.initDoneCh)
@@ -433,6 +477,10 @@ func (pool *TxPool) loop() {
 				}
 				pool.mu.Unlock()
 			}
+
+		// Handle pool snapshot regeneration
+		case <-persist.C:
+			pool.persist()
 		}
 	}
 }
@@ -447,6 +495,7 @@ func (pool *TxPool) Stop() {
 	pool.wg.Wait()
 
 	pool.jamIndexer.Stop()
+	pool.persist()
 
 	if pool.journal != nil {
 		pool.journal.close()
@@ -599,6 +648,18 @@ func (pool *TxPool) JamIndex() int {
 	return pool.jamIndexer.JamIndex()
 }
 
+// AddBundle validates a bundle and queues it for inclusion by the miner in its
+// target block range. Bundled transactions bypass the pool and are not propagated.
+func (pool *TxPool) AddBundle(bundle *types.Bundle) error {
+	return pool.bundles.add(bundle, pool.chain.CurrentBlock().NumberU64(), pool.signer)
+}
+
+// Bundles returns the bundles which can be included in the block with the given
+// number, in arrival order. Bundles expired at that number are dropped.
+func (pool *TxPool) Bundles(number uint64) []*types.Bundle {
+	return pool.bundles.eligible(number)
+}
+
 // local retrieves all currently known local transactions, grouped by origin
 // account and sorted by nonce. The returned transaction set is a copy and can be
 // freely modified by calling code.
@@ -677,6 +738,10 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	if tx.Gas() < intrGas {
 		return ErrIntrinsicGas
 	}
+	// Sponsored transactions need a live payload signed for this relayer
+	if err := pool.validateMeta(tx, from); err != nil {
+		return err
+	}
 
 	// do some extra validation if needed
 	if pool.txValidator != nil && !pool.disableExValidate {
@@ -717,8 +782,13 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 		invalidTxMeter.Mark(1)
 		return false, err
 	}
+	// Transactions fitting the reserved capacity of their lane don't compete for
+	// the global slots
+	from, _ := types.Sender(pool.signer, tx) // already validated
+	lane, victim := pool.reserveLane(tx, from, pool.laneOf(tx, from))
+
 	// If the transaction pool is full, discard underpriced transactions
-	if uint64(pool.all.Slots()+numSlots(tx)) > pool.config.GlobalSlots+pool.config.GlobalQueue {
+	if lane == TxLanePublic && uint64(pool.all.PublicSlots()+numSlots(tx)) > pool.config.GlobalSlots+pool.config.GlobalQueue {
 		// If the new transaction is underpriced, don't accept it
 		if !isLocal && pool.priced.Underpriced(tx) {
 			log.Trace("Discarding underpriced transaction", "hash", hash, "gasTipCap", tx.GasTipCap(), "gasFeeCap", tx.GasFeeCap())
@@ -738,7 +808,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 		// New transaction is better than our worse ones, make room for it.
 		// If it's a local transaction, forcibly discard all available transactions.
 		// Otherwise if we can't make enough room for new one, abort the operation.
-		drop, success := pool.priced.Discard(pool.all.Slots()-int(pool.config.GlobalSlots+pool.config.GlobalQueue)+numSlots(tx), isLocal)
+		drop, success := pool.priced.Discard(pool.all.PublicSlots()-int(pool.config.GlobalSlots+pool.config.GlobalQueue)+numSlots(tx), isLocal)
 
 		// Special case, we still can't make the room for the new remote one.
 		if !isLocal && !success {
@@ -757,7 +827,6 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 		}
 	}
 	// Try to replace an existing transaction in the pending pool
-	from, _ := types.Sender(pool.signer, tx) // already validated
 	if list := pool.pending[from]; list != nil && list.Overlaps(tx) {
 		// Nonce already pending, check if required price bump is met
 		inserted, old := list.Add(tx, pool.config.PriceBump)
@@ -772,8 +841,13 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 			pendingReplaceMeter.Mark(1)
 		}
 		pool.all.Add(tx, isLocal)
+		pool.all.Reserve(tx, lane)
 		pool.priced.Put(tx, isLocal)
+		if victim != nil {
+			pool.evictFromLane(victim, lane)
+		}
 		pool.journalTx(from, tx)
+		pool.trackMeta(tx, from)
 		pool.queueTxEvent(tx)
 		log.Trace("Pooled new executable transaction", "hash", hash, "from", from, "to", tx.To())
 
@@ -786,6 +860,10 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 	if err != nil {
 		return false, err
 	}
+	pool.all.Reserve(tx, lane)
+	if victim != nil {
+		pool.evictFromLane(victim, lane)
+	}
 	// Mark local addresses and journal local transactions
 	if local && !pool.locals.contains(from) {
 		log.Info("Setting new local account", "address", from)
@@ -796,6 +874,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 		localGauge.Inc(1)
 	}
 	pool.journalTx(from, tx)
+	pool.trackMeta(tx, from)
 
 	log.Trace("Pooled new future transaction", "hash", hash, "from", from, "to", tx.To())
 	return replaced, nil
@@ -993,6 +1072,10 @@ func (pool *TxPool) addTxsLocked(txs []*types.Transaction, local bool) ([]error,
 	for i, tx := range txs {
 		replaced, err := pool.add(tx, local)
 		errs[i] = err
+		if err != nil && err != ErrAlreadyKnown {
+			from, _ := types.Sender(pool.signer, tx)
+			pool.rejections.record(from, tx, err)
+		}
 		if err == nil && !replaced {
 			dirty.addTx(tx)
 		}
@@ -1205,6 +1288,8 @@ func (pool *TxPool) runReorg(done chan struct{}, reset *txpoolResetRequest, dirt
 	if reset != nil {
 		// Reset from the old head to the new, rescheduling any reorged transactions
 		pool.reset(reset.oldHead, reset.newHead)
+		pool.resetConditionals(reset.newHead)
+		pool.resetMetas(reset.newHead)
 
 		// Nonces were reset, discard any events that became stale
 		for addr := range events {
@@ -1339,9 +1424,12 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 		log.Error("Failed to reset txpool state", "err", err)
 		return
 	}
+	pool.currentHead = newHead
 	pool.currentState = statedb
 	pool.pendingNonces = newTxNoncer(statedb)
 	pool.currentMaxGas = newHead.GasLimit
+	pool.bundles.reset(newHead.Number.Uint64(), statedb, pool.signer)
+	pool.minTip = nil
 	// Update fake next header if necessary
 	next := new(big.Int).Add(newHead.Number, big.NewInt(1))
 	if pool.txValidator != nil {
@@ -1444,7 +1532,12 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) []*types.Trans
 func (pool *TxPool) truncatePending() {
 	pending := uint64(0)
 	for _, list := range pool.pending {
-		pending += uint64(list.Len())
+		for _, tx := range list.txs.items {
+			// Reserved lane transactions come on top of the global slots
+			if _, reserved := pool.all.Lane(tx.Hash()); !reserved {
+				pending++
+			}
+		}
 	}
 	if pending <= pool.config.GlobalSlots {
 		return
@@ -1734,14 +1827,27 @@ type txLookup struct {
 	lock    sync.RWMutex
 	locals  map[common.Hash]*types.Transaction
 	remotes map[common.Hash]*types.Transaction
+
+	reserved  map[common.Hash]TxLane   // Transactions held in the reserved capacity of a lane
+	laneSlots [numTxLanes]int          // Slots used by the reserved transactions of each lane
+	arrivals  [numTxLanes]*prque.Prque // Reserved transactions of each lane, first seen on top
+	arrived   map[common.Hash]int      // Index of each reserved transaction in its lane queue
 }
 
 // newTxLookup returns a new txLookup structure.
 func newTxLookup() *txLookup {
-	return &txLookup{
-		locals:  make(map[common.Hash]*types.Transaction),
-		remotes: make(map[common.Hash]*types.Transaction),
+	t := &txLookup{
+		locals:   make(map[common.Hash]*types.Transaction),
+		remotes:  make(map[common.Hash]*types.Transaction),
+		reserved: make(map[common.Hash]TxLane),
+		arrived:  make(map[common.Hash]int),
+	}
+	for lane := range t.arrivals {
+		t.arrivals[lane] = prque.New(func(data interface{}, index int) {
+			t.arrived[data.(*types.Transaction).Hash()] = index
+		})
 	}
+	return t
 }
 
 // Range calls f on each key and value present in the map. The callback passed
@@ -1857,10 +1963,90 @@ func (t *txLookup) Remove(hash common.Hash) {
 	t.slots -= numSlots(tx)
 	slotsGauge.Update(int64(t.slots))
 
+	if lane, ok := t.reserved[hash]; ok {
+		t.laneSlots[lane] -= numSlots(tx)
+		laneSlotsGauges[lane].Update(int64(t.laneSlots[lane]))
+		t.arrivals[lane].Remove(t.arrived[hash])
+		delete(t.arrived, hash)
+		delete(t.reserved, hash)
+	}
 	delete(t.locals, hash)
 	delete(t.remotes, hash)
 }
 
+// Reserve accounts a transaction of the lookup to the reserved capacity of its
+// lane. Transactions of the public lane aren't tracked.
+func (t *txLookup) Reserve(tx *types.Transaction, lane TxLane) {
+	if lane == TxLanePublic {
+		return
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if _, ok := t.reserved[tx.Hash()]; ok {
+		return
+	}
+	t.reserved[tx.Hash()] = lane
+	t.laneSlots[lane] += numSlots(tx)
+	laneSlotsGauges[lane].Update(int64(t.laneSlots[lane]))
+	t.arrivals[lane].Push(tx, -tx.LocalSeenTime().UnixNano())
+}
+
+// LaneSlots returns the number of slots used by the reserved transactions of a lane.
+func (t *txLookup) LaneSlots(lane TxLane) int {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return t.laneSlots[lane]
+}
+
+// PublicSlots returns the number of slots used outside of the reserved capacity
+// of the lanes.
+func (t *txLookup) PublicSlots() int {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	slots := t.slots
+	for _, used := range t.laneSlots {
+		slots -= used
+	}
+	return slots
+}
+
+// GetPriced returns a remote transaction competing for the global slots, or nil
+// if it's not found or is held in the reserved capacity of a lane.
+func (t *txLookup) GetPriced(hash common.Hash) *types.Transaction {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if _, ok := t.reserved[hash]; ok {
+		return nil
+	}
+	return t.remotes[hash]
+}
+
+// Lane returns the lane whose reserved capacity holds a transaction, if any.
+func (t *txLookup) Lane(hash common.Hash) (TxLane, bool) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	lane, ok := t.reserved[hash]
+	return lane, ok
+}
+
+// OldestInLane returns the first seen transaction held in the reserved capacity
+// of a lane, or nil if there's none.
+func (t *txLookup) OldestInLane(lane TxLane) *types.Transaction {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if t.arrivals[lane].Empty() {
+		return nil
+	}
+	oldest, _ := t.arrivals[lane].Peek()
+	return oldest.(*types.Transaction)
+}
+
 // RemoteToLocals migrates the transactions belongs to the given locals to locals
 // set. The assumption is held the locals set is thread-safe to be used.
 func (t *txLookup) RemoteToLocals(locals *accountSet) int {
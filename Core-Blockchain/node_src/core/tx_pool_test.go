@@ -140,8 +140,15 @@ func validateTxPoolInternals(pool *TxPool) error {
 	if total := pool.all.Count(); total != pending+queued {
 		return fmt.Errorf("total transaction count %d != %d pending + %d queued", total, pending, queued)
 	}
+	// Ensure the remote transactions outside of the reserved lane capacity are priced
 	pool.priced.Reheap()
-	priced, remote := pool.priced.urgent.Len()+pool.priced.floating.Len(), pool.all.RemoteCount()
+	priced, remote := pool.priced.urgent.Len()+pool.priced.floating.Len(), 0
+	pool.all.Range(func(hash common.Hash, tx *types.Transaction, local bool) bool {
+		if _, reserved := pool.all.reserved[hash]; !reserved {
+			remote++
+		}
+		return true
+	}, false, true)
 	if priced != remote {
 		return fmt.Errorf("total priced transaction count %d != %d", priced, remote)
 	}
@@ -0,0 +1,297 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package core
+
+import (
+	"errors"
+	"math"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	lru "github.com/hashicorp/golang-lru"
+	"golang.org/x/time/rate"
+)
+
+// rateLimitBuckets is the maximum number of token buckets tracked per kind of
+// source, the least recently used ones are forgotten first.
+const rateLimitBuckets = 16384
+
+// ErrTxRateLimited is returned if a transaction exceeds the rate permitted to its
+// sender or to the client submitting it.
+var ErrTxRateLimited = errors.New("transaction rate limit exceeded")
+
+// ErrPeerDemoted is returned if a peer keeps relaying transactions after being
+// demoted for relaying junk.
+var ErrPeerDemoted = errors.New("peer demoted for relaying junk transactions")
+
+var (
+	senderLimitedMeter = metrics.NewRegisteredMeter("txpool/ratelimit/sender", nil)
+	clientLimitedMeter = metrics.NewRegisteredMeter("txpool/ratelimit/client", nil)
+	peerLimitedMeter   = metrics.NewRegisteredMeter("txpool/ratelimit/peer", nil)
+	peerDemotedMeter   = metrics.NewRegisteredMeter("txpool/ratelimit/demoted", nil)
+	peerPenaltyMeter   = metrics.NewRegisteredMeter("txpool/ratelimit/penalty", nil)
+)
+
+// TxRateLimitConfig are the token bucket limits of the transaction ingress, and
+// the reputation rules of the peers relaying transactions.
+type TxRateLimitConfig struct {
+	SenderRate  float64 // Transactions per second permitted per sender, 0 for no limit
+	SenderBurst int     // Transactions a sender can submit at once
+	ClientRate  float64 // Transactions per second permitted per RPC client IP, 0 for no limit
+	ClientBurst int     // Transactions an RPC client can submit at once
+	PeerRate    float64 // Transactions per second permitted per p2p peer, 0 for no limit
+	PeerBurst   int     // Transactions a peer can relay at once
+	LimitLocals bool    // Whether to limit the senders of local (IPC, in-process) submissions too
+
+	UnderpricedPenalty float64       // Reputation penalty of a peer relaying an underpriced transaction
+	InvalidPenalty     float64       // Reputation penalty of a peer relaying an invalid transaction
+	DemoteThreshold    float64       // Penalty above which the transactions of a peer are dropped, 0 to never demote
+	PenaltyHalfLife    time.Duration // Time for the penalty of a peer to decay by half
+}
+
+// DefaultTxRateLimitConfig contains the default rate limits. The limits and the
+// peer demotion are off, the bursts apply once a rate is set.
+var DefaultTxRateLimitConfig = TxRateLimitConfig{
+	SenderBurst: 128,
+	ClientBurst: 512,
+	PeerBurst:   8192,
+
+	UnderpricedPenalty: 1,
+	InvalidPenalty:     8,
+	PenaltyHalfLife:    time.Minute,
+}
+
+// sanitize checks the provided rate limits and changes anything that's
+// unreasonable or unworkable.
+func (c *TxRateLimitConfig) sanitize() {
+	if c.SenderRate > 0 && c.SenderBurst < 1 {
+		log.Warn("Sanitizing invalid txpool sender burst", "provided", c.SenderBurst, "updated", DefaultTxRateLimitConfig.SenderBurst)
+		c.SenderBurst = DefaultTxRateLimitConfig.SenderBurst
+	}
+	if c.ClientRate > 0 && c.ClientBurst < 1 {
+		log.Warn("Sanitizing invalid txpool client burst", "provided", c.ClientBurst, "updated", DefaultTxRateLimitConfig.ClientBurst)
+		c.ClientBurst = DefaultTxRateLimitConfig.ClientBurst
+	}
+	if c.PeerRate > 0 && c.PeerBurst < 1 {
+		log.Warn("Sanitizing invalid txpool peer burst", "provided", c.PeerBurst, "updated", DefaultTxRateLimitConfig.PeerBurst)
+		c.PeerBurst = DefaultTxRateLimitConfig.PeerBurst
+	}
+	if c.PenaltyHalfLife < time.Second {
+		log.Warn("Sanitizing invalid txpool penalty half-life", "provided", c.PenaltyHalfLife, "updated", DefaultTxRateLimitConfig.PenaltyHalfLife)
+		c.PenaltyHalfLife = DefaultTxRateLimitConfig.PenaltyHalfLife
+	}
+}
+
+// tokenBuckets is a bounded set of token buckets sharing the same limits.
+type tokenBuckets struct {
+	limit   rate.Limit
+	burst   int
+	buckets *lru.Cache // string -> *rate.Limiter
+}
+
+func newTokenBuckets(limit float64, burst int) *tokenBuckets {
+	if limit <= 0 {
+		return nil
+	}
+	buckets, _ := lru.New(rateLimitBuckets)
+	return &tokenBuckets{limit: rate.Limit(limit), burst: burst, buckets: buckets}
+}
+
+// allow takes n tokens from the bucket of the given key, if it has enough. A nil
+// set doesn't limit anything.
+func (b *tokenBuckets) allow(key string, n int, now time.Time) bool {
+	if b == nil {
+		return true
+	}
+	var limiter *rate.Limiter
+	if cached, ok := b.buckets.Get(key); ok {
+		limiter = cached.(*rate.Limiter)
+	} else {
+		limiter = rate.NewLimiter(b.limit, b.burst)
+		b.buckets.Add(key, limiter)
+	}
+	return limiter.AllowN(now, n)
+}
+
+// peerPenalty is the decaying penalty of a peer.
+type peerPenalty struct {
+	value   float64
+	updated mclock.AbsTime
+}
+
+// txRateLimiter rate limits the transactions entering the pool per sender, per
+// RPC client and per peer, and demotes the peers relaying junk.
+type txRateLimiter struct {
+	config TxRateLimitConfig
+	clock  mclock.Clock
+
+	senders *tokenBuckets
+	clients *tokenBuckets
+	peers   *tokenBuckets
+
+	penalties map[string]*peerPenalty
+	lock      sync.Mutex
+}
+
+func newTxRateLimiter(config TxRateLimitConfig, clock mclock.Clock) *txRateLimiter {
+	return &txRateLimiter{
+		config:    config,
+		clock:     clock,
+		senders:   newTokenBuckets(config.SenderRate, config.SenderBurst),
+		clients:   newTokenBuckets(config.ClientRate, config.ClientBurst),
+		peers:     newTokenBuckets(config.PeerRate, config.PeerBurst),
+		penalties: make(map[string]*peerPenalty),
+	}
+}
+
+// now returns the wall clock time of the limiter clock, which drives the buckets.
+func (l *txRateLimiter) now() time.Time {
+	return time.Unix(0, int64(l.clock.Now()))
+}
+
+// penalty returns the current penalty of a peer, decaying it on the way.
+//
+// Note, this method assumes the limiter lock is held!
+func (l *txRateLimiter) penalty(peer string) float64 {
+	entry := l.penalties[peer]
+	if entry == nil {
+		return 0
+	}
+	now := l.clock.Now()
+	elapsed := time.Duration(now - entry.updated)
+	entry.value *= math.Pow(0.5, float64(elapsed)/float64(l.config.PenaltyHalfLife))
+	entry.updated = now
+
+	// Forget the peers that behaved long enough
+	if entry.value < 1 {
+		delete(l.penalties, peer)
+		return 0
+	}
+	return entry.value
+}
+
+// demoted reports whether the transactions of a peer are to be dropped.
+//
+// Note, this method assumes the limiter lock is held!
+func (l *txRateLimiter) demoted(peer string) bool {
+	return l.config.DemoteThreshold > 0 && l.penalty(peer) >= l.config.DemoteThreshold
+}
+
+// AdmitClient checks a transaction submitted over RPC against the limits of its
+// sender and of the client address. Clients without address (IPC, in-process)
+// are local and only limited per sender if configured so.
+func (pool *TxPool) AdmitClient(remote string, tx *types.Transaction) error {
+	l := pool.limiter
+	if remote == "" && !l.config.LimitLocals {
+		return nil
+	}
+	from, err := types.Sender(pool.signer, tx)
+	if err != nil {
+		return ErrInvalidSender
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := l.now()
+	if remote != "" {
+		if host, _, err := net.SplitHostPort(remote); err == nil {
+			remote = host
+		}
+		if !l.clients.allow(remote, 1, now) {
+			clientLimitedMeter.Mark(1)
+			return ErrTxRateLimited
+		}
+	}
+	if !l.senders.allow(from.Hex(), 1, now) {
+		senderLimitedMeter.Mark(1)
+		return ErrTxRateLimited
+	}
+	return nil
+}
+
+// AdmitPeer filters the transactions relayed by a peer, admitting them up to the
+// remaining budget of the peer. Transactions already pooled don't take from the
+// budget. The sender limits only apply to RPC submissions, as a peer relays the
+// transactions of senders it doesn't answer for. If the peer is demoted, all of
+// them are dropped and ErrPeerDemoted is returned for the caller to disconnect it.
+func (pool *TxPool) AdmitPeer(peer string, txs []*types.Transaction) ([]*types.Transaction, error) {
+	known := make([]bool, len(txs))
+	for i, tx := range txs {
+		known[i] = pool.Has(tx.Hash())
+	}
+	l := pool.limiter
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.demoted(peer) {
+		peerDemotedMeter.Mark(int64(len(txs)))
+		return nil, ErrPeerDemoted
+	}
+	now := l.now()
+	admitted := txs[:0:0]
+	for i, tx := range txs {
+		if !known[i] && !l.peers.allow(peer, 1, now) {
+			peerLimitedMeter.Mark(1)
+			continue
+		}
+		admitted = append(admitted, tx)
+	}
+	return admitted, nil
+}
+
+// relayPenalty returns the number of underpriced and invalid transactions among
+// the pool errors of a relayed batch. Errors any honest peer can run into, like
+// nonce races or a full pool, aren't penalized.
+func relayPenalty(errs []error) (underpriced, invalid int) {
+	for _, err := range errs {
+		switch {
+		case err == nil:
+		case errors.Is(err, ErrUnderpriced), errors.Is(err, ErrReplaceUnderpriced):
+			underpriced++
+		case errors.Is(err, ErrInvalidSender), errors.Is(err, ErrNegativeValue), errors.Is(err, ErrOversizedData),
+			errors.Is(err, ErrGasLimit), errors.Is(err, ErrIntrinsicGas), errors.Is(err, ErrTipAboveFeeCap),
+			errors.Is(err, ErrTipVeryHigh), errors.Is(err, ErrFeeCapVeryHigh), errors.Is(err, ErrTxTypeNotSupported):
+			invalid++
+		}
+	}
+	return underpriced, invalid
+}
+
+// ReportPeer penalizes a peer for the underpriced and invalid transactions among
+// the pool errors of a batch it relayed.
+func (pool *TxPool) ReportPeer(peer string, errs []error) {
+	underpriced, invalid := relayPenalty(errs)
+
+	l := pool.limiter
+	add := float64(underpriced)*l.config.UnderpricedPenalty + float64(invalid)*l.config.InvalidPenalty
+	if add <= 0 {
+		return
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	// Sweep the decayed penalties of the peers gone meanwhile
+	if len(l.penalties) >= rateLimitBuckets {
+		for id := range l.penalties {
+			l.penalty(id)
+		}
+	}
+	value := l.penalty(peer) + add
+	l.penalties[peer] = &peerPenalty{value: value, updated: l.clock.Now()}
+	peerPenaltyMeter.Mark(int64(add))
+
+	if l.config.DemoteThreshold > 0 && value >= l.config.DemoteThreshold && value-add < l.config.DemoteThreshold {
+		log.Debug("Demoting transaction relaying peer", "peer", peer, "penalty", value)
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Tests that RPC submissions are limited per client address and per sender, and
+// that the buckets refill over time.
+func TestTxRateLimitClients(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	clock := new(mclock.Simulated)
+	pool.limiter = newTxRateLimiter(TxRateLimitConfig{
+		SenderRate: 1, SenderBurst: 2,
+		ClientRate: 1, ClientBurst: 3,
+		PenaltyHalfLife: time.Minute,
+	}, clock)
+
+	// The sender bucket runs dry first
+	for i := uint64(0); i < 2; i++ {
+		if err := pool.AdmitClient("10.0.0.1:30303", transaction(i, 100000, key)); err != nil {
+			t.Fatalf("transaction %d: failed to admit: %v", i, err)
+		}
+	}
+	if err := pool.AdmitClient("10.0.0.1:40404", transaction(2, 100000, key)); err != ErrTxRateLimited {
+		t.Fatalf("sender limit error mismatch: have %v, want %v", err, ErrTxRateLimited)
+	}
+	// The client bucket ignores the port and applies across senders
+	other, _ := crypto.GenerateKey()
+	if err := pool.AdmitClient("10.0.0.1:50505", transaction(0, 100000, other)); err != ErrTxRateLimited {
+		t.Fatalf("client limit error mismatch: have %v, want %v", err, ErrTxRateLimited)
+	}
+	if err := pool.AdmitClient("10.0.0.2:30303", transaction(0, 100000, other)); err != nil {
+		t.Fatalf("failed to admit from another client: %v", err)
+	}
+	// Clients without address are local and not limited
+	for i := uint64(2); i < 6; i++ {
+		if err := pool.AdmitClient("", transaction(i, 100000, key)); err != nil {
+			t.Fatalf("transaction %d: failed to admit local submission: %v", i, err)
+		}
+	}
+	// Unless configured so, then they are limited per sender
+	pool.limiter.config.LimitLocals = true
+	if err := pool.AdmitClient("", transaction(6, 100000, key)); err != ErrTxRateLimited {
+		t.Fatalf("local sender limit error mismatch: have %v, want %v", err, ErrTxRateLimited)
+	}
+	clock.Run(time.Second)
+	if err := pool.AdmitClient("", transaction(6, 100000, key)); err != nil {
+		t.Fatalf("failed to admit after refill: %v", err)
+	}
+}
+
+// Tests that peers relaying junk get demoted, and recover as the penalty decays.
+func TestTxRateLimitPeers(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	clock := new(mclock.Simulated)
+	pool.limiter = newTxRateLimiter(TxRateLimitConfig{
+		PeerRate: 10, PeerBurst: 4,
+		UnderpricedPenalty: 1,
+		InvalidPenalty:     4,
+		DemoteThreshold:    8,
+		PenaltyHalfLife:    time.Minute,
+	}, clock)
+
+	txs := []*types.Transaction{transaction(0, 100000, key), transaction(1, 100000, key)}
+	if admitted, err := pool.AdmitPeer("peer", txs); len(admitted) != 2 || err != nil {
+		t.Fatalf("admitted transactions mismatch: have %d (%v), want %d", len(admitted), err, 2)
+	}
+	// A batch over the peer burst is admitted up to the remaining budget
+	if admitted, err := pool.AdmitPeer("peer", append(txs, txs...)); len(admitted) != 2 || err != nil {
+		t.Fatalf("over-limit admitted transactions mismatch: have %d (%v), want %d", len(admitted), err, 2)
+	}
+	// Nonce races aren't penalized, invalid transactions are
+	pool.ReportPeer("peer", []error{ErrNonceTooLow, ErrTxPoolOverflow, nil})
+	if penalty := pool.limiter.penalty("peer"); penalty != 0 {
+		t.Fatalf("penalty for benign errors: %v", penalty)
+	}
+	pool.ReportPeer("peer", []error{ErrUnderpriced, ErrIntrinsicGas, ErrInvalidSender})
+
+	clock.Run(time.Second)
+	if admitted, err := pool.AdmitPeer("peer", txs[:1]); len(admitted) != 0 || err != ErrPeerDemoted {
+		t.Fatalf("demoted peer admission mismatch: have %d (%v), want 0 (%v)", len(admitted), err, ErrPeerDemoted)
+	}
+	if admitted, _ := pool.AdmitPeer("other", txs[:1]); len(admitted) != 1 {
+		t.Fatalf("other peer transactions dropped")
+	}
+	// The penalty halves every minute
+	clock.Run(time.Minute)
+	if admitted, err := pool.AdmitPeer("peer", txs[:1]); len(admitted) != 1 || err != nil {
+		t.Fatalf("recovered peer transactions dropped: %v", err)
+	}
+}
+
+// Tests that relayed transactions only take from the budget of the peer, and
+// that the ones already pooled are admitted for free.
+func TestTxRateLimitRelayed(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	pool.limiter = newTxRateLimiter(TxRateLimitConfig{
+		SenderRate: 1, SenderBurst: 1,
+		PeerRate: 1, PeerBurst: 2,
+		PenaltyHalfLife: time.Minute,
+	}, new(mclock.Simulated))
+
+	testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+	pooled := transaction(0, 100000, key)
+	if err := pool.addRemoteSync(pooled); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	other, _ := crypto.GenerateKey()
+	txs := []*types.Transaction{
+		pooled,
+		transaction(1, 100000, key),
+		transaction(2, 100000, key),
+		pricedTransaction(0, 100000, big.NewInt(2), other),
+	}
+	admitted, _ := pool.AdmitPeer("peer", txs)
+	if len(admitted) != 3 || admitted[0] != txs[0] || admitted[1] != txs[1] || admitted[2] != txs[2] {
+		t.Fatalf("admitted transactions mismatch: have %v", admitted)
+	}
+	if admitted, _ := pool.AdmitPeer("peer", txs[:1]); len(admitted) != 1 {
+		t.Fatalf("pooled transaction dropped by the exhausted peer budget")
+	}
+}
+
+// Tests that the rate limits and the peer demotion are off by default.
+func TestTxRateLimitDefaults(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	if l := pool.limiter; l.senders != nil || l.clients != nil || l.peers != nil {
+		t.Fatalf("rate limits on by default: sender %v, client %v, peer %v", l.senders != nil, l.clients != nil, l.peers != nil)
+	}
+	errs := make([]error, 1024)
+	for i := range errs {
+		errs[i] = ErrInvalidSender
+	}
+	pool.ReportPeer("peer", errs)
+	if _, err := pool.AdmitPeer("peer", []*types.Transaction{transaction(0, 100000, key)}); err != nil {
+		t.Fatalf("peer demoted by default: %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package types
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Bundle is an ordered set of transactions to be included all-or-nothing, in the
+// given order, in one of the blocks of a target range.
+type Bundle struct {
+	Txs               Transactions
+	MinBlock          uint64        // First block the bundle may be included in
+	MaxBlock          uint64        // Last block the bundle may be included in
+	RevertingTxHashes []common.Hash // Transactions allowed to revert without discarding the bundle
+}
+
+// Hash returns the hash identifying the bundle, the hash of its transaction hashes.
+func (b *Bundle) Hash() common.Hash {
+	hashes := make([]byte, 0, len(b.Txs)*common.HashLength)
+	for _, tx := range b.Txs {
+		hashes = append(hashes, tx.Hash().Bytes()...)
+	}
+	return crypto.Keccak256Hash(hashes)
+}
+
+// CanRevert reports whether the transaction with the given hash is allowed to revert.
+func (b *Bundle) CanRevert(hash common.Hash) bool {
+	for _, h := range b.RevertingTxHashes {
+		if h == hash {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package types
+
+import (
+	"encoding/json"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// KnownAccount is the expected storage of an account, either its whole storage
+// root or the values of some of its slots.
+type KnownAccount struct {
+	StorageRoot  *common.Hash
+	StorageSlots map[common.Hash]common.Hash
+}
+
+// MarshalJSON encodes a known account as its storage root if set, otherwise as
+// the map of its slots.
+func (a KnownAccount) MarshalJSON() ([]byte, error) {
+	if a.StorageRoot != nil {
+		return json.Marshal(a.StorageRoot)
+	}
+	return json.Marshal(a.StorageSlots)
+}
+
+// UnmarshalJSON decodes a known account from either a storage root or a map of
+// slots.
+func (a *KnownAccount) UnmarshalJSON(input []byte) error {
+	var root common.Hash
+	if err := json.Unmarshal(input, &root); err == nil {
+		a.StorageRoot, a.StorageSlots = &root, nil
+		return nil
+	}
+	a.StorageRoot = nil
+	return json.Unmarshal(input, &a.StorageSlots)
+}
+
+// TxConditions are the conditions a conditional transaction waits for before it
+// becomes executable. The block and timestamp bounds are inclusive, the state
+// conditions are checked against the head state.
+type TxConditions struct {
+	BlockNumberMin *hexutil.Uint64                 `json:"blockNumberMin,omitempty"`
+	BlockNumberMax *hexutil.Uint64                 `json:"blockNumberMax,omitempty"`
+	TimestampMin   *hexutil.Uint64                 `json:"timestampMin,omitempty"`
+	TimestampMax   *hexutil.Uint64                 `json:"timestampMax,omitempty"`
+	KnownAccounts  map[common.Address]KnownAccount `json:"knownAccounts,omitempty"`
+	MinBalances    map[common.Address]*hexutil.Big `json:"balanceMin,omitempty"`
+}
+
+// Cost returns the number of state lookups needed to check the conditions.
+func (c *TxConditions) Cost() int {
+	cost := len(c.MinBalances)
+	for _, account := range c.KnownAccounts {
+		if account.StorageRoot != nil {
+			cost++
+		} else {
+			cost += len(account.StorageSlots)
+		}
+	}
+	return cost
+}
+
+// Expired reports whether the conditions can't be met anymore by a block with the
+// given number, built on a parent with the given timestamp.
+func (c *TxConditions) Expired(number, parentTime uint64) bool {
+	if c.BlockNumberMax != nil && number > uint64(*c.BlockNumberMax) {
+		return true
+	}
+	// The block is at least one second younger than its parent
+	if c.TimestampMax != nil && parentTime >= uint64(*c.TimestampMax) {
+		return true
+	}
+	return false
+}
+
+// InWindow reports whether a block with the given number, built on a parent with
+// the given timestamp, satisfies the block and timestamp bounds.
+func (c *TxConditions) InWindow(number, parentTime uint64) bool {
+	if c.Expired(number, parentTime) {
+		return false
+	}
+	if c.BlockNumberMin != nil && number < uint64(*c.BlockNumberMin) {
+		return false
+	}
+	if c.TimestampMin != nil && parentTime < uint64(*c.TimestampMin) {
+		return false
+	}
+	return true
+}
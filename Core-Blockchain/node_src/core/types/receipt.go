@@ -66,6 +66,10 @@ type Receipt struct {
 	ContractAddress common.Address `json:"contractAddress"`
 	GasUsed         uint64         `json:"gasUsed" gencodec:"required"`
 
+	// DeniedCreator is the address whose contract creation was denied by the deployment
+	// allowlist. It is set when processing and indexed separately from the receipt.
+	DeniedCreator *common.Address `json:"-" rlp:"-"`
+
 	// Inclusion information: These fields provide information about the inclusion of the
 	// transaction corresponding to this receipt.
 	BlockHash        common.Hash `json:"blockHash,omitempty"`
@@ -366,6 +366,12 @@ func (tx *Transaction) EffectiveGasTipIntCmp(other *big.Int, baseFee *big.Int) i
 	return tx.EffectiveGasTipValue(baseFee).Cmp(other)
 }
 
+// SetTime sets the time the transaction was first seen locally, to restore it
+// for transactions persisted across restarts.
+func (tx *Transaction) SetTime(t time.Time) {
+	tx.time = t
+}
+
 // Hash returns the transaction hash.
 func (tx *Transaction) Hash() common.Hash {
 	if hash := tx.hash.Load(); hash != nil {
@@ -507,6 +513,9 @@ type TransactionsByPriceAndNonce struct {
 	heads   TxByPriceAndTime                // Next transaction for each unique account (price heap)
 	signer  Signer                          // Signer for the set of transactions
 	baseFee *big.Int                        // Current base fee
+
+	byArrival bool     // Whether the heads are ordered by arrival time only
+	band      *big.Int // Width of the fee bands the heads are ordered by arrival within
 }
 
 // NewTransactionsByPriceAndNonce creates a transaction set that can retrieve
@@ -539,6 +548,73 @@ func NewTransactionsByPriceAndNonce(signer Signer, txs map[common.Address]Transa
 	}
 }
 
+// NewTransactionsByArrivalAndNonce creates a transaction set that can retrieve
+// transactions in the order they were first seen, in a nonce-honouring way.
+//
+// Note, the input map is reowned so the caller should not interact any more with
+// if after providing it to the constructor.
+func NewTransactionsByArrivalAndNonce(signer Signer, txs map[common.Address]Transactions) *TransactionsByPriceAndNonce {
+	heads := make(TxByPriceAndTime, 0, len(txs))
+	for from, accTxs := range txs {
+		if acc, _ := Sender(signer, accTxs[0]); acc != from {
+			delete(txs, from)
+			continue
+		}
+		heads = append(heads, &TxWithMinerFee{tx: accTxs[0], minerFee: new(big.Int)})
+		txs[from] = accTxs[1:]
+	}
+	heap.Init(&heads)
+
+	return &TransactionsByPriceAndNonce{
+		txs:       txs,
+		heads:     heads,
+		signer:    signer,
+		byArrival: true,
+	}
+}
+
+// NewTransactionsByPriceBandAndNonce creates a transaction set that can retrieve
+// transactions sorted by price band, and by the order they were first seen within
+// a band, in a nonce-honouring way. A band groups the miner fees of the same
+// multiple of the band width.
+//
+// Note, the input map is reowned so the caller should not interact any more with
+// if after providing it to the constructor.
+func NewTransactionsByPriceBandAndNonce(signer Signer, txs map[common.Address]Transactions, baseFee *big.Int, band *big.Int) *TransactionsByPriceAndNonce {
+	heads := make(TxByPriceAndTime, 0, len(txs))
+	for from, accTxs := range txs {
+		acc, _ := Sender(signer, accTxs[0])
+		wrapped, err := newTxWithMinerFeeBand(accTxs[0], baseFee, band)
+		if acc != from || err != nil {
+			delete(txs, from)
+			continue
+		}
+		heads = append(heads, wrapped)
+		txs[from] = accTxs[1:]
+	}
+	heap.Init(&heads)
+
+	return &TransactionsByPriceAndNonce{
+		txs:     txs,
+		heads:   heads,
+		signer:  signer,
+		baseFee: baseFee,
+		band:    band,
+	}
+}
+
+// newTxWithMinerFeeBand wraps a transaction with its miner fee rounded down to
+// the given band width, if any.
+func newTxWithMinerFeeBand(tx *Transaction, baseFee *big.Int, band *big.Int) (*TxWithMinerFee, error) {
+	wrapped, err := NewTxWithMinerFee(tx, baseFee)
+	if err != nil || band == nil || band.Sign() <= 0 {
+		return wrapped, err
+	}
+	fee := new(big.Int).Div(wrapped.minerFee, band)
+	wrapped.minerFee = fee.Mul(fee, band)
+	return wrapped, nil
+}
+
 // Peek returns the next transaction by price.
 func (t *TransactionsByPriceAndNonce) Peek() *Transaction {
 	if len(t.heads) == 0 {
@@ -551,7 +627,12 @@ func (t *TransactionsByPriceAndNonce) Peek() *Transaction {
 func (t *TransactionsByPriceAndNonce) Shift() {
 	acc, _ := Sender(t.signer, t.heads[0].tx)
 	if txs, ok := t.txs[acc]; ok && len(txs) > 0 {
-		if wrapped, err := NewTxWithMinerFee(txs[0], t.baseFee); err == nil {
+		if t.byArrival {
+			t.heads[0], t.txs[acc] = &TxWithMinerFee{tx: txs[0], minerFee: new(big.Int)}, txs[1:]
+			heap.Fix(&t.heads, 0)
+			return
+		}
+		if wrapped, err := newTxWithMinerFeeBand(txs[0], t.baseFee, t.band); err == nil {
 			t.heads[0], t.txs[acc] = wrapped, txs[1:]
 			heap.Fix(&t.heads, 0)
 			return
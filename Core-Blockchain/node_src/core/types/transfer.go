@@ -0,0 +1,23 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package types
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// InternalTransfer is a value transfer made by a contract while executing a
+// transaction, through a call, a contract creation or a self-destruct.
+type InternalTransfer struct {
+	From  common.Address
+	To    common.Address
+	Value *big.Int
+}
@@ -128,6 +128,9 @@ type EVM struct {
 	// available gas is calculated in gasCall* according to the 63/64 rule and later
 	// applied in opCall*.
 	callGasTemp uint64
+	// deniedCreator is the first address whose contract creation was denied by
+	// the CanCreate guard during the current transaction, if any.
+	deniedCreator *common.Address
 }
 
 // NewEVM returns a new EVM. The returned EVM is not thread safe and should
@@ -150,6 +153,13 @@ func NewEVM(blockCtx BlockContext, txCtx TxContext, statedb StateDB, chainConfig
 func (evm *EVM) Reset(txCtx TxContext, statedb StateDB) {
 	evm.TxContext = txCtx
 	evm.StateDB = statedb
+	evm.deniedCreator = nil
+}
+
+// DeniedCreator returns the first address whose contract creation was denied by
+// the CanCreate guard since the last reset, or nil if none was.
+func (evm *EVM) DeniedCreator() *common.Address {
+	return evm.deniedCreator
 }
 
 // Cancel cancels any running EVM operation. This may be called concurrently and
@@ -213,6 +223,9 @@ func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, gas
 		evm.StateDB.CreateAccount(addr)
 	}
 	evm.Context.Transfer(evm.StateDB, caller.Address(), addr, value)
+	if evm.Config.EnableTransferRecording && evm.depth > 0 && value.Sign() != 0 {
+		evm.StateDB.AddTransfer(&types.InternalTransfer{From: caller.Address(), To: addr, Value: new(big.Int).Set(value)})
+	}
 
 	// Capture the tracer start/end events in debug mode
 	if evm.Config.Debug {
@@ -467,6 +480,10 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 	// check developer if needed
 	if evm.Context.CanCreate != nil {
 		if !evm.Context.CanCreate(evm.StateDB, caller.Address(), evm.Context.BlockNumber) {
+			if evm.deniedCreator == nil {
+				creator := caller.Address()
+				evm.deniedCreator = &creator
+			}
 			return nil, common.Address{}, gas, ErrUnauthorizedDeveloper
 		}
 	}
@@ -496,6 +513,9 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 		evm.StateDB.SetNonce(address, 1)
 	}
 	evm.Context.Transfer(evm.StateDB, caller.Address(), address, value)
+	if evm.Config.EnableTransferRecording && evm.depth > 0 && value.Sign() != 0 {
+		evm.StateDB.AddTransfer(&types.InternalTransfer{From: caller.Address(), To: address, Value: new(big.Int).Set(value)})
+	}
 
 	// Initialise a new contract and set the code that is to be used by the EVM.
 	// The contract is a scoped environment for this execution context only.
@@ -34,6 +34,7 @@ type Config struct {
 	NoRecursion             bool      // Disables call, callcode, delegate call and create
 	NoBaseFee               bool      // Forces the EIP-1559 baseFee to 0 (needed for 0 price calls)
 	EnablePreimageRecording bool      // Enables recording of SHA3/keccak preimages
+	EnableTransferRecording bool      // Enables recording of the value transfers made by contracts
 
 	JumpTable [256]*operation // EVM instruction table, automatically populated if unset
 
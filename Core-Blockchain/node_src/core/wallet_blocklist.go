@@ -14,7 +14,9 @@ import (
 	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/params"
 )
@@ -27,6 +29,8 @@ var WalletBlocklistContractAddress = common.HexToAddress("0x00000000000000000000
 type BlocklistChecker struct {
 	enabled bool
 	mu      sync.RWMutex
+
+	rejectFeed event.Feed // Feed of the transactions rejected for a blocklisted address
 }
 
 var (
@@ -52,6 +56,18 @@ func (bc *BlocklistChecker) SetEnabled(enabled bool) {
 	log.Info("Blocklist checker status changed", "enabled", enabled)
 }
 
+// ReportRejectedTx announces a transaction rejected because of the blocklist to
+// the subscribers of the rejections.
+func (bc *BlocklistChecker) ReportRejectedTx(tx *types.Transaction, reason error) {
+	bc.rejectFeed.Send(RejectedTxsEvent{Txs: []*types.Transaction{tx}, Reason: reason})
+}
+
+// SubscribeRejectedTxsEvent registers a subscription of RejectedTxsEvent, fired
+// for the transactions rejected because of the blocklist.
+func (bc *BlocklistChecker) SubscribeRejectedTxsEvent(ch chan<- RejectedTxsEvent) event.Subscription {
+	return bc.rejectFeed.Subscribe(ch)
+}
+
 // IsEnabled returns whether blocklist checking is enabled
 func (bc *BlocklistChecker) IsEnabled() bool {
 	bc.mu.RLock()
@@ -0,0 +1,230 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package eth
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// defaultAddressPageSize is the number of transactions returned per page if
+	// the request doesn't set a limit.
+	defaultAddressPageSize = 100
+
+	// maxAddressPageSize is the maximum number of transactions returned per page.
+	maxAddressPageSize = 1000
+
+	// maxAddressScanBlocks is the maximum number of blocks past the indexed
+	// sections scanned per request. Longer ranges are continued on the next page.
+	maxAddressScanBlocks = 4 * core.AddressIndexSectionSize
+)
+
+var errAddressIndexDisabled = errors.New("address index disabled, enable it with --address.index")
+
+// PublicAddressAPI provides an API to list the transactions touching an address.
+type PublicAddressAPI struct {
+	e *Ethereum
+}
+
+// NewPublicAddressAPI creates a new address activity API.
+func NewPublicAddressAPI(e *Ethereum) *PublicAddressAPI {
+	return &PublicAddressAPI{e: e}
+}
+
+// AddressQueryArgs are the range and the page of the transactions to list. The
+// cursor is the one returned with the previous page, if any.
+type AddressQueryArgs struct {
+	FromBlock *rpc.BlockNumber `json:"fromBlock"`
+	ToBlock   *rpc.BlockNumber `json:"toBlock"`
+	Limit     *hexutil.Uint    `json:"limit"`
+	Cursor    *hexutil.Bytes   `json:"cursor"`
+}
+
+// AddressTransaction is a transaction touching an address, along with the roles
+// of the address in it. The transaction hash is null for the blocks beyond the
+// history horizon.
+type AddressTransaction struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	TxHash      *common.Hash   `json:"transactionHash"`
+	TxIndex     hexutil.Uint   `json:"transactionIndex"`
+	Roles       []string       `json:"roles"`
+}
+
+// AddressTransactions is a page of the transactions touching an address. The
+// cursor is null on the last page.
+type AddressTransactions struct {
+	Transactions []*AddressTransaction `json:"transactions"`
+	Cursor       *hexutil.Bytes        `json:"cursor"`
+}
+
+// addressPage collects the index entries of an address into a page of
+// transactions.
+type addressPage struct {
+	limit int
+	txs   []*AddressTransaction
+	body  func(hash common.Hash) *types.Body
+
+	cursor *hexutil.Bytes
+}
+
+// add appends an index entry to the page, returning false if the page is full.
+func (p *addressPage) add(entry rawdb.AddressIndexEntry) bool {
+	if n := len(p.txs); n > 0 {
+		last := p.txs[n-1]
+		if uint64(last.BlockNumber) == entry.Number && uint(last.TxIndex) == uint(entry.TxIndex) {
+			last.Roles = append(last.Roles, core.AddressRole(entry.Role).String())
+			return true
+		}
+		if n == p.limit {
+			p.cursor = encodeAddressCursor(entry.Number, entry.TxIndex)
+			return false
+		}
+	}
+	tx := &AddressTransaction{
+		BlockNumber: hexutil.Uint64(entry.Number),
+		BlockHash:   entry.Hash,
+		TxIndex:     hexutil.Uint(entry.TxIndex),
+		Roles:       []string{core.AddressRole(entry.Role).String()},
+	}
+	if body := p.body(entry.Hash); body != nil && int(entry.TxIndex) < len(body.Transactions) {
+		hash := body.Transactions[entry.TxIndex].Hash()
+		tx.TxHash = &hash
+	}
+	p.txs = append(p.txs, tx)
+	return true
+}
+
+// encodeAddressCursor encodes the position of the next page.
+func encodeAddressCursor(number uint64, index uint32) *hexutil.Bytes {
+	cursor := make(hexutil.Bytes, 12)
+	binary.BigEndian.PutUint64(cursor, number)
+	binary.BigEndian.PutUint32(cursor[8:], index)
+	return &cursor
+}
+
+// GetTransactionsByAddress returns a page of the transactions touching an
+// address in the given block range, in ascending order. Besides the senders and
+// recipients, the transactions touching an address include the contracts they
+// create, the inner signers of meta transactions, the parties of x402 settlements
+// and the internal value transfers.
+func (api *PublicAddressAPI) GetTransactionsByAddress(ctx context.Context, address common.Address, args *AddressQueryArgs) (*AddressTransactions, error) {
+	indexer := api.e.addressIndexer
+	if indexer == nil {
+		return nil, errAddressIndexDisabled
+	}
+	if args == nil {
+		args = new(AddressQueryArgs)
+	}
+	var (
+		db   = api.e.ChainDb()
+		head = api.e.blockchain.CurrentBlock().NumberU64()
+		from uint64
+		to   = head
+	)
+	if tail := rawdb.ReadAddressIndexTail(db); tail != nil {
+		from = *tail
+	}
+	if args.FromBlock != nil && *args.FromBlock >= 0 {
+		if uint64(*args.FromBlock) < from {
+			return nil, fmt.Errorf("address index pruned below block %d", from)
+		}
+		from = uint64(*args.FromBlock)
+	}
+	if args.ToBlock != nil && *args.ToBlock >= 0 && uint64(*args.ToBlock) < to {
+		to = uint64(*args.ToBlock)
+	}
+	limit := defaultAddressPageSize
+	if args.Limit != nil {
+		if *args.Limit == 0 || *args.Limit > maxAddressPageSize {
+			return nil, fmt.Errorf("limit must be between 1 and %d", maxAddressPageSize)
+		}
+		limit = int(*args.Limit)
+	}
+	var index uint32
+	if args.Cursor != nil {
+		if len(*args.Cursor) != 12 {
+			return nil, errors.New("invalid cursor")
+		}
+		number := binary.BigEndian.Uint64(*args.Cursor)
+		if number < from {
+			return nil, errors.New("cursor outside of the block range")
+		}
+		from, index = number, binary.BigEndian.Uint32((*args.Cursor)[8:])
+	}
+	page := &addressPage{
+		limit: limit,
+		txs:   []*AddressTransaction{},
+		body:  api.e.blockchain.GetBody,
+	}
+	if from > to {
+		return &AddressTransactions{Transactions: page.txs}, nil
+	}
+	// Collect the transactions of the indexed sections, skipping the entries of
+	// the blocks reorged since
+	sections, _, _ := indexer.Sections()
+	indexed := sections * core.AddressIndexSectionSize
+
+	if from < indexed {
+		canonical := make(map[uint64]common.Hash)
+		full := false
+		rawdb.IterateAddressIndex(db, address, from, index, func(entry rawdb.AddressIndexEntry) bool {
+			if entry.Number >= indexed || entry.Number > to {
+				return false
+			}
+			hash, ok := canonical[entry.Number]
+			if !ok {
+				hash = rawdb.ReadCanonicalHash(db, entry.Number)
+				canonical[entry.Number] = hash
+			}
+			if hash != entry.Hash {
+				return true
+			}
+			full = !page.add(entry)
+			return !full
+		})
+		if full {
+			return &AddressTransactions{Transactions: page.txs, Cursor: page.cursor}, nil
+		}
+		from, index = indexed, 0
+	}
+	// Scan the blocks past the indexed sections
+	for number := from; number <= to; number++ {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if number-from == maxAddressScanBlocks {
+			return &AddressTransactions{Transactions: page.txs, Cursor: encodeAddressCursor(number, 0)}, nil
+		}
+		block := api.e.blockchain.GetBlockByNumber(number)
+		if block == nil {
+			break
+		}
+		transfers := rawdb.ReadBlockTransfers(db, block.Hash(), number)
+		for _, entry := range core.AddressActivity(api.e.blockchain.Config(), block, transfers)[address] {
+			if number == from && entry.TxIndex < index {
+				continue
+			}
+			if !page.add(entry) {
+				return &AddressTransactions{Transactions: page.txs, Cursor: page.cursor}, nil
+			}
+		}
+	}
+	return &AddressTransactions{Transactions: page.txs}, nil
+}
@@ -108,11 +108,21 @@ func (b *EthAPIBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumbe
 	if number == rpc.LatestBlockNumber {
 		return b.eth.blockchain.CurrentBlock(), nil
 	}
-	return b.eth.blockchain.GetBlockByNumber(uint64(number)), nil
+	block := b.eth.blockchain.GetBlockByNumber(uint64(number))
+	if block == nil && b.eth.blockchain.GetHeaderByNumber(uint64(number)) != nil {
+		return nil, rawdb.HistoryPruned(b.eth.ChainDb(), uint64(number))
+	}
+	return block, nil
 }
 
 func (b *EthAPIBackend) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
-	return b.eth.blockchain.GetBlockByHash(hash), nil
+	block := b.eth.blockchain.GetBlockByHash(hash)
+	if block == nil {
+		if header := b.eth.blockchain.GetHeaderByHash(hash); header != nil {
+			return nil, rawdb.HistoryPruned(b.eth.ChainDb(), header.Number.Uint64())
+		}
+	}
+	return block, nil
 }
 
 func (b *EthAPIBackend) BlockByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.Block, error) {
@@ -129,6 +139,9 @@ func (b *EthAPIBackend) BlockByNumberOrHash(ctx context.Context, blockNrOrHash r
 		}
 		block := b.eth.blockchain.GetBlock(hash, header.Number.Uint64())
 		if block == nil {
+			if err := rawdb.HistoryPruned(b.eth.ChainDb(), header.Number.Uint64()); err != nil {
+				return nil, err
+			}
 			return nil, errors.New("header found, but block body is missing")
 		}
 		return block, nil
@@ -180,7 +193,13 @@ func (b *EthAPIBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockN
 }
 
 func (b *EthAPIBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
-	return b.eth.blockchain.GetReceiptsByHash(hash), nil
+	receipts := b.eth.blockchain.GetReceiptsByHash(hash)
+	if receipts == nil {
+		if number := rawdb.ReadHeaderNumber(b.eth.ChainDb(), hash); number != nil {
+			return nil, rawdb.HistoryPruned(b.eth.ChainDb(), *number)
+		}
+	}
+	return receipts, nil
 }
 
 func (b *EthAPIBackend) GetLogs(ctx context.Context, hash common.Hash) ([][]*types.Log, error) {
@@ -191,6 +210,9 @@ func (b *EthAPIBackend) GetLogs(ctx context.Context, hash common.Hash) ([][]*typ
 	}
 	logs := rawdb.ReadLogs(db, hash, *number, b.eth.blockchain.Config())
 	if logs == nil {
+		if err := rawdb.HistoryPruned(db, *number); err != nil {
+			return nil, err
+		}
 		return nil, errors.New("failed to get logs for block")
 	}
 	return logs, nil
@@ -233,6 +255,14 @@ func (b *EthAPIBackend) SubscribePendingLogsEvent(ch chan<- []*types.Log) event.
 	return b.eth.miner.SubscribePendingLogs(ch)
 }
 
+func (b *EthAPIBackend) SubscribeRejectedTxsEvent(ch chan<- core.RejectedTxsEvent) event.Subscription {
+	return core.GetBlocklistChecker().SubscribeRejectedTxsEvent(ch)
+}
+
+func (b *EthAPIBackend) SubscribePendingBlockEvent(ch chan<- core.PendingBlockEvent) event.Subscription {
+	return b.eth.miner.SubscribePendingBlock(ch)
+}
+
 func (b *EthAPIBackend) SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription {
 	return b.eth.BlockChain().SubscribeChainEvent(ch)
 }
@@ -250,9 +280,31 @@ func (b *EthAPIBackend) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscri
 }
 
 func (b *EthAPIBackend) SendTx(ctx context.Context, signedTx *types.Transaction) error {
+	// Throttle the submissions per sender and per HTTP client address
+	remote, _ := ctx.Value("remote").(string)
+	if err := b.eth.txPool.AdmitClient(remote, signedTx); err != nil {
+		return err
+	}
 	return b.eth.txPool.AddLocal(signedTx)
 }
 
+func (b *EthAPIBackend) SendConditionalTx(ctx context.Context, signedTx *types.Transaction, conditions *types.TxConditions) error {
+	remote, _ := ctx.Value("remote").(string)
+	if err := b.eth.txPool.AdmitClient(remote, signedTx); err != nil {
+		return err
+	}
+	// Only the submissions over IPC or in-process are local
+	return b.eth.txPool.AddConditional(signedTx, conditions, remote == "")
+}
+
+func (b *EthAPIBackend) GetConditionalTx(hash common.Hash) *core.ConditionalTx {
+	return b.eth.txPool.Conditional(hash)
+}
+
+func (b *EthAPIBackend) SendBundle(ctx context.Context, bundle *types.Bundle) error {
+	return b.eth.txPool.AddBundle(bundle)
+}
+
 func (b *EthAPIBackend) GetPoolTransactions() (types.Transactions, error) {
 	pending := b.eth.txPool.Pending(false)
 	var txs types.Transactions
@@ -287,6 +339,14 @@ func (b *EthAPIBackend) TxPoolContentFrom(addr common.Address) (types.Transactio
 	return b.eth.TxPool().ContentFrom(addr)
 }
 
+func (b *EthAPIBackend) TxPoolExplain(hash common.Hash) (*core.TxExplanation, error) {
+	return b.eth.TxPool().Explain(hash)
+}
+
+func (b *EthAPIBackend) TxPoolExplainAccount(addr common.Address) (*core.AccountExplanation, error) {
+	return b.eth.TxPool().ExplainAccount(addr), nil
+}
+
 func (b *EthAPIBackend) JamIndex() int {
 	return b.eth.TxPool().JamIndex()
 }
@@ -315,6 +375,10 @@ func (b *EthAPIBackend) PricePrediction(ctx context.Context) ([]uint, error) {
 	return b.gpp.CurrentPrices(), nil
 }
 
+func (b *EthAPIBackend) FeePrediction(ctx context.Context) (*gasprice.FeePrediction, error) {
+	return b.gpp.CurrentFees(), nil
+}
+
 func (b *EthAPIBackend) ChainDb() ethdb.Database {
 	return b.eth.ChainDb()
 }
@@ -347,6 +411,10 @@ func (b *EthAPIBackend) RPCTxFeeCap() float64 {
 	return b.eth.config.RPCTxFeeCap
 }
 
+func (b *EthAPIBackend) TxPoolPriceBump() uint64 {
+	return b.eth.config.TxPool.PriceBump
+}
+
 func (b *EthAPIBackend) BloomStatus() (uint64, uint64) {
 	sections, _, _ := b.eth.bloomIndexer.Sections()
 	return params.BloomBitsBlocks, sections
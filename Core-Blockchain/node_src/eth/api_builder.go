@@ -0,0 +1,98 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package eth
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/miner"
+)
+
+// BuilderSimulation is the block a block-building policy built out of a pool
+// snapshot, and how it differs from the block of the first policy.
+type BuilderSimulation struct {
+	Policy    string         `json:"policy"`
+	Txs       []common.Hash  `json:"transactions"`
+	GasUsed   hexutil.Uint64 `json:"gasUsed"`
+	Fees      *hexutil.Big   `json:"fees"`
+	Missing   []common.Hash  `json:"missing,omitempty"`
+	Extra     []common.Hash  `json:"extra,omitempty"`
+	Reordered bool           `json:"reordered,omitempty"`
+}
+
+// SimulateBlockBuilders replays a pool snapshot through the given block-building
+// policies, all the registered ones if none is given, and returns the block each
+// of them builds on top of the current head. The snapshot is in the format of the
+// pool snapshots, the last one the node recorded if none is given.
+func (api *PrivateDebugAPI) SimulateBlockBuilders(snapshot *hexutil.Bytes, policies []string) ([]*BuilderSimulation, error) {
+	blob := rawdb.ReadTxPoolSnapshot(api.eth.ChainDb())
+	if snapshot != nil {
+		blob = *snapshot
+	}
+	if len(blob) == 0 {
+		return nil, errors.New("no transaction pool snapshot recorded")
+	}
+	txs, err := core.DecodeTxPoolSnapshot(blob)
+	if err != nil {
+		return nil, err
+	}
+	if len(policies) == 0 {
+		policies = miner.BlockBuilders()
+	}
+	builders := make([]miner.BlockBuilder, len(policies))
+	for i, policy := range policies {
+		config := api.eth.config.Miner
+		config.Policy = policy
+		if builders[i], err = miner.NewBlockBuilder(&config); err != nil {
+			return nil, err
+		}
+	}
+	// Assemble the next block the way the worker does
+	var (
+		chain  = api.eth.BlockChain()
+		parent = chain.CurrentBlock()
+	)
+	statedb, err := chain.StateAt(parent.Root())
+	if err != nil {
+		return nil, err
+	}
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number(), common.Big1),
+		GasLimit:   core.CalcGasLimit(parent.GasLimit(), api.eth.config.Miner.GasCeil),
+		Time:       parent.Time() + 1,
+	}
+	if chain.Config().IsLondon(header.Number) {
+		header.BaseFee = misc.CalcBaseFee(chain.Config(), parent.Header())
+	}
+	header.Coinbase, _ = api.eth.Etherbase()
+
+	blocks := miner.SimulateBuilders(chain.Config(), statedb, header, txs, builders)
+	results := make([]*BuilderSimulation, len(blocks))
+	for i, block := range blocks {
+		diff := blocks[0].Diff(block)
+		results[i] = &BuilderSimulation{
+			Policy:    block.Policy,
+			Txs:       block.Txs,
+			GasUsed:   hexutil.Uint64(block.GasUsed),
+			Fees:      (*hexutil.Big)(block.Fees),
+			Missing:   diff.Missing,
+			Extra:     diff.Extra,
+			Reordered: diff.Reordered,
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,436 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package eth
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// defaultTokenPageSize is the number of transfers returned per page if the
+	// request doesn't set a limit.
+	defaultTokenPageSize = 100
+
+	// maxTokenPageSize is the maximum number of transfers returned per page.
+	maxTokenPageSize = 1000
+
+	// maxTokenScanBlocks is the maximum number of blocks past the indexed sections
+	// scanned per request. Transfer histories are continued on the next page, the
+	// holdings are reported as of the last indexed section instead.
+	maxTokenScanBlocks = 2 * params.BloomBitsBlocks
+)
+
+var (
+	errTokenIndexDisabled = errors.New("token index disabled, enable it with --token.index")
+	errTokenIndexNotReady = errors.New("token index not built yet")
+)
+
+// PublicTokenAPI provides an API to access the token holdings and transfers of
+// the addresses.
+type PublicTokenAPI struct {
+	e *Ethereum
+}
+
+// NewPublicTokenAPI creates a new token index API.
+func NewPublicTokenAPI(e *Ethereum) *PublicTokenAPI {
+	return &PublicTokenAPI{e: e}
+}
+
+// TokenHolding is the balance of a token held by an address. The token id is
+// omitted for fungible tokens.
+type TokenHolding struct {
+	Token    common.Address `json:"token"`
+	Standard string         `json:"standard"`
+	ID       *hexutil.Big   `json:"tokenId,omitempty"`
+	Balance  *hexutil.Big   `json:"balance"`
+}
+
+// TokenHoldings is the token holdings of an address as of the given block.
+type TokenHoldings struct {
+	BlockNumber hexutil.Uint64  `json:"blockNumber"`
+	Holdings    []*TokenHolding `json:"holdings"`
+}
+
+// TokenStats is the statistics of a token contract as of the given block.
+type TokenStats struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	Standard    string         `json:"standard"`
+	Holders     hexutil.Uint64 `json:"holders"`
+	Transfers   hexutil.Uint64 `json:"transfers"`
+}
+
+// TokenQueryArgs are the range and the page of the transfers to list, and the
+// token to restrict them to if any. The cursor is the one returned with the
+// previous page.
+type TokenQueryArgs struct {
+	Token     *common.Address  `json:"token"`
+	FromBlock *rpc.BlockNumber `json:"fromBlock"`
+	ToBlock   *rpc.BlockNumber `json:"toBlock"`
+	Limit     *hexutil.Uint    `json:"limit"`
+	Cursor    *hexutil.Bytes   `json:"cursor"`
+}
+
+// TokenTransferResult is a token transfer. The transaction hash is null for the
+// blocks beyond the history horizon.
+type TokenTransferResult struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	TxHash      *common.Hash   `json:"transactionHash"`
+	TxIndex     hexutil.Uint   `json:"transactionIndex"`
+	LogIndex    hexutil.Uint   `json:"logIndex"`
+	Token       common.Address `json:"token"`
+	Standard    string         `json:"standard"`
+	From        common.Address `json:"from"`
+	To          common.Address `json:"to"`
+	ID          *hexutil.Big   `json:"tokenId,omitempty"`
+	Value       *hexutil.Big   `json:"value"`
+}
+
+// TokenTransfers is a page of token transfers. The cursor is null on the last
+// page.
+type TokenTransfers struct {
+	Transfers []*TokenTransferResult `json:"transfers"`
+	Cursor    *hexutil.Bytes         `json:"cursor"`
+}
+
+// tokenID returns the token id of a transfer or holding, nil for fungible tokens.
+func tokenID(standard uint16, id common.Hash) *hexutil.Big {
+	if core.TokenStandard(standard) == core.TokenERC20 {
+		return nil
+	}
+	return (*hexutil.Big)(new(big.Int).SetBytes(id[:]))
+}
+
+// indexed returns the number of blocks covered by the token index.
+func (api *PublicTokenAPI) indexed() (uint64, error) {
+	indexer := api.e.tokenIndexer
+	if indexer == nil {
+		return 0, errTokenIndexDisabled
+	}
+	sections, _, _ := indexer.Sections()
+	return sections * params.BloomBitsBlocks, nil
+}
+
+// scan decodes the token transfers of the blocks in the given range that may
+// involve the address, calling the callback until it returns false.
+func (api *PublicTokenAPI) scan(ctx context.Context, addr common.Address, from, to uint64, fn func(transfer *rawdb.TokenTransfer) bool) error {
+	db := api.e.ChainDb()
+	for number := from; number <= to; number++ {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		header := api.e.blockchain.GetHeaderByNumber(number)
+		if header == nil {
+			return nil
+		}
+		if !core.MayContainTokenTransfers(header.Bloom, addr) {
+			continue
+		}
+		for _, transfer := range core.BlockTokenTransfers(header, rawdb.ReadRawReceipts(db, header.Hash(), number)) {
+			if !fn(transfer) {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// GetTokenHoldings returns the ERC-20, ERC-721 and ERC-1155 tokens held by an
+// address. The holdings of the indexed sections are completed with the transfers
+// of the later blocks, unless the index lags too far behind the chain head.
+func (api *PublicTokenAPI) GetTokenHoldings(ctx context.Context, address common.Address) (*TokenHoldings, error) {
+	indexed, err := api.indexed()
+	if err != nil {
+		return nil, err
+	}
+	type holdingKey struct {
+		token common.Address
+		id    common.Hash
+	}
+	var (
+		db        = api.e.ChainDb()
+		head      = api.e.blockchain.CurrentBlock().NumberU64()
+		balances  = make(map[holdingKey]*big.Int)
+		standards = make(map[common.Address]uint16)
+	)
+	rawdb.IterateTokenBalances(db, address, func(token common.Address, id common.Hash, balance *big.Int) bool {
+		balances[holdingKey{token, id}] = balance
+		return true
+	})
+	for key := range balances {
+		if _, ok := standards[key.token]; !ok {
+			if info := rawdb.ReadTokenInfo(db, key.token); info != nil {
+				standards[key.token] = info.Standard
+			}
+		}
+	}
+	number := head
+	if head+1 > indexed+maxTokenScanBlocks {
+		if indexed == 0 {
+			return nil, errTokenIndexNotReady
+		}
+		number = indexed - 1
+	} else if indexed <= head {
+		err := api.scan(ctx, address, indexed, head, func(transfer *rawdb.TokenTransfer) bool {
+			if transfer.From == transfer.To || transfer.Value.Sign() == 0 {
+				return true
+			}
+			key := holdingKey{transfer.Token, transfer.ID}
+			if transfer.From == address {
+				if balance := balances[key]; balance != nil {
+					if balance.Sub(balance, transfer.Value).Sign() <= 0 {
+						delete(balances, key)
+					}
+				}
+			}
+			if transfer.To == address {
+				if balances[key] == nil {
+					balances[key] = new(big.Int)
+				}
+				balances[key].Add(balances[key], transfer.Value)
+				if _, ok := standards[transfer.Token]; !ok {
+					standards[transfer.Token] = transfer.Standard
+				}
+			}
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	keys := make([]holdingKey, 0, len(balances))
+	for key := range balances {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].token != keys[j].token {
+			return bytes.Compare(keys[i].token[:], keys[j].token[:]) < 0
+		}
+		return bytes.Compare(keys[i].id[:], keys[j].id[:]) < 0
+	})
+	holdings := make([]*TokenHolding, len(keys))
+	for i, key := range keys {
+		holdings[i] = &TokenHolding{
+			Token:    key.token,
+			Standard: core.TokenStandard(standards[key.token]).String(),
+			ID:       tokenID(standards[key.token], key.id),
+			Balance:  (*hexutil.Big)(balances[key]),
+		}
+	}
+	return &TokenHoldings{BlockNumber: hexutil.Uint64(number), Holdings: holdings}, nil
+}
+
+// GetTokenHolderCount returns the number of holders and transfers of a token
+// contract as of the last indexed section, or null if none of its transfers were
+// indexed.
+func (api *PublicTokenAPI) GetTokenHolderCount(ctx context.Context, token common.Address) (*TokenStats, error) {
+	indexed, err := api.indexed()
+	if err != nil {
+		return nil, err
+	}
+	if indexed == 0 {
+		return nil, errTokenIndexNotReady
+	}
+	info := rawdb.ReadTokenInfo(api.e.ChainDb(), token)
+	if info == nil {
+		return nil, nil
+	}
+	return &TokenStats{
+		BlockNumber: hexutil.Uint64(indexed - 1),
+		Standard:    core.TokenStandard(info.Standard).String(),
+		Holders:     hexutil.Uint64(info.Holders),
+		Transfers:   hexutil.Uint64(info.Transfers),
+	}, nil
+}
+
+// GetTokenTransfers returns a page of the token transfers sent or received by an
+// address in the given block range, in ascending order, optionally restricted to
+// a single token contract.
+func (api *PublicTokenAPI) GetTokenTransfers(ctx context.Context, address common.Address, args *TokenQueryArgs) (*TokenTransfers, error) {
+	if args == nil {
+		args = new(TokenQueryArgs)
+	}
+	return api.transfers(ctx, address, args, rawdb.IterateHolderTokenTransfers, func(transfer *rawdb.TokenTransfer) bool {
+		if transfer.From != address && transfer.To != address {
+			return false
+		}
+		return args.Token == nil || transfer.Token == *args.Token
+	})
+}
+
+// GetTokenContractTransfers returns a page of the transfers logged by a token
+// contract in the given block range, in ascending order.
+func (api *PublicTokenAPI) GetTokenContractTransfers(ctx context.Context, token common.Address, args *TokenQueryArgs) (*TokenTransfers, error) {
+	if args == nil {
+		args = new(TokenQueryArgs)
+	}
+	return api.transfers(ctx, token, args, rawdb.IterateTokenTransfers, func(transfer *rawdb.TokenTransfer) bool {
+		return transfer.Token == token
+	})
+}
+
+// tokenPage collects token transfers into a page.
+type tokenPage struct {
+	limit     int
+	transfers []*TokenTransferResult
+	body      func(hash common.Hash) *types.Body
+
+	cursor *hexutil.Bytes
+}
+
+// add appends a transfer to the page, returning false if the page is full.
+func (p *tokenPage) add(transfer *rawdb.TokenTransfer) bool {
+	if len(p.transfers) == p.limit {
+		p.cursor = encodeTokenCursor(transfer.Number, transfer.LogIndex, transfer.Item)
+		return false
+	}
+	result := &TokenTransferResult{
+		BlockNumber: hexutil.Uint64(transfer.Number),
+		BlockHash:   transfer.BlockHash,
+		TxIndex:     hexutil.Uint(transfer.TxIndex),
+		LogIndex:    hexutil.Uint(transfer.LogIndex),
+		Token:       transfer.Token,
+		Standard:    core.TokenStandard(transfer.Standard).String(),
+		From:        transfer.From,
+		To:          transfer.To,
+		ID:          tokenID(transfer.Standard, transfer.ID),
+		Value:       (*hexutil.Big)(transfer.Value),
+	}
+	if body := p.body(transfer.BlockHash); body != nil && int(transfer.TxIndex) < len(body.Transactions) {
+		hash := body.Transactions[transfer.TxIndex].Hash()
+		result.TxHash = &hash
+	}
+	p.transfers = append(p.transfers, result)
+	return true
+}
+
+// encodeTokenCursor encodes the position of the next page.
+func encodeTokenCursor(number uint64, logIndex uint32, item uint32) *hexutil.Bytes {
+	cursor := make(hexutil.Bytes, 16)
+	binary.BigEndian.PutUint64(cursor, number)
+	binary.BigEndian.PutUint32(cursor[8:], logIndex)
+	binary.BigEndian.PutUint32(cursor[12:], item)
+	return &cursor
+}
+
+// transfers collects a page of the transfers of an address, reading the indexed
+// sections with the given iterator and scanning the later blocks for transfers
+// accepted by the filter.
+func (api *PublicTokenAPI) transfers(ctx context.Context, addr common.Address, args *TokenQueryArgs,
+	iterate func(db ethdb.Iteratee, addr common.Address, number uint64, logIndex uint32, item uint32, fn func(*rawdb.TokenTransfer) bool),
+	filter func(transfer *rawdb.TokenTransfer) bool) (*TokenTransfers, error) {
+
+	indexed, err := api.indexed()
+	if err != nil {
+		return nil, err
+	}
+	var (
+		db   = api.e.ChainDb()
+		head = api.e.blockchain.CurrentBlock().NumberU64()
+		from uint64
+		to   = head
+	)
+	if args.FromBlock != nil && *args.FromBlock >= 0 {
+		from = uint64(*args.FromBlock)
+	}
+	if args.ToBlock != nil && *args.ToBlock >= 0 && uint64(*args.ToBlock) < to {
+		to = uint64(*args.ToBlock)
+	}
+	limit := defaultTokenPageSize
+	if args.Limit != nil {
+		if *args.Limit == 0 || *args.Limit > maxTokenPageSize {
+			return nil, fmt.Errorf("limit must be between 1 and %d", maxTokenPageSize)
+		}
+		limit = int(*args.Limit)
+	}
+	var logIndex, item uint32
+	if args.Cursor != nil {
+		if len(*args.Cursor) != 16 {
+			return nil, errors.New("invalid cursor")
+		}
+		number := binary.BigEndian.Uint64(*args.Cursor)
+		if number < from {
+			return nil, errors.New("cursor outside of the block range")
+		}
+		from = number
+		logIndex = binary.BigEndian.Uint32((*args.Cursor)[8:])
+		item = binary.BigEndian.Uint32((*args.Cursor)[12:])
+	}
+	page := &tokenPage{
+		limit:     limit,
+		transfers: []*TokenTransferResult{},
+		body:      api.e.blockchain.GetBody,
+	}
+	if from > to {
+		return &TokenTransfers{Transfers: page.transfers}, nil
+	}
+	// Collect the transfers of the indexed sections, skipping the ones of the
+	// blocks reorged since
+	if from < indexed {
+		canonical := make(map[uint64]common.Hash)
+		full := false
+		iterate(db, addr, from, logIndex, item, func(transfer *rawdb.TokenTransfer) bool {
+			if transfer.Number >= indexed || transfer.Number > to {
+				return false
+			}
+			hash, ok := canonical[transfer.Number]
+			if !ok {
+				hash = rawdb.ReadCanonicalHash(db, transfer.Number)
+				canonical[transfer.Number] = hash
+			}
+			if hash != transfer.BlockHash || !filter(transfer) {
+				return true
+			}
+			full = !page.add(transfer)
+			return !full
+		})
+		if full {
+			return &TokenTransfers{Transfers: page.transfers, Cursor: page.cursor}, nil
+		}
+		from, logIndex, item = indexed, 0, 0
+	}
+	// Scan the blocks past the indexed sections
+	if to-from >= maxTokenScanBlocks {
+		to = from + maxTokenScanBlocks - 1
+		page.cursor = encodeTokenCursor(to+1, 0, 0)
+	}
+	next := page.cursor
+	err = api.scan(ctx, addr, from, to, func(transfer *rawdb.TokenTransfer) bool {
+		if transfer.Number == from && (transfer.LogIndex < logIndex || (transfer.LogIndex == logIndex && transfer.Item < item)) {
+			return true
+		}
+		if !filter(transfer) {
+			return true
+		}
+		if !page.add(transfer) {
+			next = page.cursor
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &TokenTransfers{Transfers: page.transfers, Cursor: next}, nil
+}
@@ -33,6 +33,7 @@ import (
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/consensus/clique"
 	"github.com/ethereum/go-ethereum/consensus/congress"
+	"github.com/ethereum/go-ethereum/consensus/congress/systemcontract"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/bloombits"
 	"github.com/ethereum/go-ethereum/core/rawdb"
@@ -88,6 +89,9 @@ type Ethereum struct {
 	bloomIndexer      *core.ChainIndexer             // Bloom indexer operating during block imports
 	closeBloomHandler chan struct{}
 
+	addressIndexer *core.ChainIndexer // Address activity indexer, nil if disabled
+	tokenIndexer   *core.ChainIndexer // Token transfer indexer running as a child of the bloom indexer, nil if disabled
+
 	APIBackend *EthAPIBackend
 
 	miner     *miner.Miner
@@ -115,6 +119,9 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	if !config.SyncMode.IsValid() {
 		return nil, fmt.Errorf("invalid sync mode %d", config.SyncMode)
 	}
+	if _, err := miner.NewBlockBuilder(&config.Miner); err != nil {
+		return nil, err
+	}
 	if config.Miner.GasPrice == nil || config.Miner.GasPrice.Cmp(common.Big0) <= 0 {
 		log.Warn("Sanitizing invalid miner gas price", "provided", config.Miner.GasPrice, "updated", ethconfig.Defaults.Miner.GasPrice)
 		config.Miner.GasPrice = new(big.Int).Set(ethconfig.Defaults.Miner.GasPrice)
@@ -139,6 +146,20 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	if err != nil {
 		return nil, err
 	}
+	// Pick the scheme used to store the state, recording it for fresh databases
+	// before the genesis state gets written.
+	scheme, err := rawdb.ParseStateScheme(config.StateScheme, chainDb)
+	if err != nil {
+		return nil, err
+	}
+	if rawdb.ReadStateScheme(chainDb) == "" {
+		rawdb.WriteStateScheme(chainDb, scheme)
+	}
+	// Nodes of the path-based scheme can't be looked up by hash, which both the
+	// fast and snap sync rely on to heal the state.
+	if scheme == rawdb.PathScheme && config.SyncMode != downloader.FullSync {
+		return nil, fmt.Errorf("%s sync is not supported by the path-based state scheme, full sync is required", config.SyncMode)
+	}
 	chainConfig, genesisHash, genesisErr := core.SetupGenesisBlockWithOverride(chainDb, config.Genesis, config.OverrideArrowGlacier)
 	if _, ok := genesisErr.(*params.ConfigCompatError); genesisErr != nil && !ok {
 		return nil, genesisErr
@@ -184,6 +205,7 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	var (
 		vmConfig = vm.Config{
 			EnablePreimageRecording: config.EnablePreimageRecording,
+			EnableTransferRecording: config.AddressIndex,
 		}
 		cacheConfig = &core.CacheConfig{
 			TrieCleanLimit:      config.TrieCleanCache,
@@ -195,6 +217,9 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 			TrieTimeLimit:       config.TrieTimeout,
 			SnapshotLimit:       config.SnapshotCache,
 			Preimages:           config.Preimages,
+			HistoryLimit:        config.HistoryLimit,
+			StateScheme:         scheme,
+			StateHistory:        config.StateHistory,
 		}
 	)
 	eth.blockchain, err = core.NewBlockChain(chainDb, cacheConfig, chainConfig, eth.engine, vmConfig, eth.shouldPreserve, &config.TxLookupLimit)
@@ -207,11 +232,22 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 		eth.blockchain.SetHead(compat.RewindTo)
 		rawdb.WriteChainConfig(chainDb, genesisHash, chainConfig)
 	}
+	if config.TokenIndex {
+		eth.tokenIndexer = core.NewTokenIndexer(chainDb)
+		eth.bloomIndexer.AddChildIndexer(eth.tokenIndexer)
+	}
 	eth.bloomIndexer.Start(eth.blockchain)
+	if config.AddressIndex {
+		eth.addressIndexer = core.NewAddressIndexer(chainDb, chainConfig)
+		eth.addressIndexer.Start(eth.blockchain)
+	}
 
 	if config.TxPool.Journal != "" {
 		config.TxPool.Journal = stack.ResolvePath(config.TxPool.Journal)
 	}
+	if _, ok := eth.engine.(*congress.Congress); ok && len(config.TxPool.Lanes.SystemContracts) == 0 {
+		config.TxPool.Lanes.SystemContracts = systemcontract.SystemContracts
+	}
 	eth.txPool = core.NewTxPool(config.TxPool, chainConfig, eth.blockchain)
 
 	// do some extra work if consensus engine is congress.
@@ -223,6 +259,10 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 		//
 		congressEngine.SetChain(eth.blockchain)
 	}
+	// Reload the persisted transactions once the validators are in place
+	if config.TxPool.Persist {
+		eth.txPool.EnablePersistence(chainDb)
+	}
 
 	// Permit the downloader to use the trie cache allowance during fast sync
 	cacheLimit := cacheConfig.TrieCleanLimit + cacheConfig.TrieDirtyLimit + cacheConfig.SnapshotLimit
@@ -411,6 +451,16 @@ func (s *Ethereum) APIs() []rpc.API {
 			Version:   "1.0",
 			Service:   s.netRPCService,
 			Public:    true,
+		}, {
+			Namespace: "eth",
+			Version:   "1.0",
+			Service:   NewPublicAddressAPI(s),
+			Public:    true,
+		}, {
+			Namespace: "eth",
+			Version:   "1.0",
+			Service:   NewPublicTokenAPI(s),
+			Public:    true,
 		}, {
 			Namespace: "x402",
 			Version:   "1.0",
@@ -646,8 +696,11 @@ func (s *Ethereum) Stop() error {
 		log.Info("X402: Broadcast manager stopped")
 	}
 
-	// Then stop everything else.
+	// Then stop everything else, the bloom indexer closing its children too.
 	s.bloomIndexer.Close()
+	if s.addressIndexer != nil {
+		s.addressIndexer.Close()
+	}
 	close(s.closeBloomHandler)
 	s.txPool.Stop()
 	s.miner.Close()
@@ -92,6 +92,7 @@ var Defaults = Config{
 	},
 	NetworkId:               128,
 	TxLookupLimit:           0,
+	StateHistory:            90000,
 	LightPeers:              100,
 	UltraLightFraction:      75,
 	DatabaseCache:           512,
@@ -155,6 +156,12 @@ type Config struct {
 	NoPrefetch bool // Whether to disable prefetching and only load state on demand
 
 	TxLookupLimit uint64 `toml:",omitempty"` // The maximum number of blocks from head whose tx indices are reserved.
+	HistoryLimit  uint64 `toml:",omitempty"` // The maximum number of blocks from head whose bodies and receipts are reserved.
+	TraceIndex    bool   `toml:",omitempty"` // Whether to index the accounts in the call traces of new blocks
+	AddressIndex  bool   `toml:",omitempty"` // Whether to index the transactions and internal transfers touching each address
+	TokenIndex    bool   `toml:",omitempty"` // Whether to index the standard token transfers and holdings of each address
+	StateScheme   string `toml:",omitempty"` // Scheme used to store the state trie nodes, "hash" or "path"
+	StateHistory  uint64 `toml:",omitempty"` // Number of recent states that can be rolled back to with the path-based scheme (0 = disabled)
 
 	// Whitelist of required block number -> hash values to accept
 	Whitelist map[uint64]common.Hash `toml:"-"`
@@ -26,6 +26,12 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		NoPruning               bool
 		NoPrefetch              bool
 		TxLookupLimit           uint64                 `toml:",omitempty"`
+		HistoryLimit            uint64                 `toml:",omitempty"`
+		TraceIndex              bool                   `toml:",omitempty"`
+		AddressIndex            bool                   `toml:",omitempty"`
+		TokenIndex              bool                   `toml:",omitempty"`
+		StateScheme             string                 `toml:",omitempty"`
+		StateHistory            uint64                 `toml:",omitempty"`
 		Whitelist               map[uint64]common.Hash `toml:"-"`
 		LightServ               int                    `toml:",omitempty"`
 		LightIngress            int                    `toml:",omitempty"`
@@ -70,6 +76,12 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.NoPruning = c.NoPruning
 	enc.NoPrefetch = c.NoPrefetch
 	enc.TxLookupLimit = c.TxLookupLimit
+	enc.HistoryLimit = c.HistoryLimit
+	enc.TraceIndex = c.TraceIndex
+	enc.AddressIndex = c.AddressIndex
+	enc.TokenIndex = c.TokenIndex
+	enc.StateScheme = c.StateScheme
+	enc.StateHistory = c.StateHistory
 	enc.Whitelist = c.Whitelist
 	enc.LightServ = c.LightServ
 	enc.LightIngress = c.LightIngress
@@ -118,6 +130,12 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		NoPruning               *bool
 		NoPrefetch              *bool
 		TxLookupLimit           *uint64                `toml:",omitempty"`
+		HistoryLimit            *uint64                `toml:",omitempty"`
+		TraceIndex              *bool                  `toml:",omitempty"`
+		AddressIndex            *bool                  `toml:",omitempty"`
+		TokenIndex              *bool                  `toml:",omitempty"`
+		StateScheme             *string                `toml:",omitempty"`
+		StateHistory            *uint64                `toml:",omitempty"`
 		Whitelist               map[uint64]common.Hash `toml:"-"`
 		LightServ               *int                   `toml:",omitempty"`
 		LightIngress            *int                   `toml:",omitempty"`
@@ -181,6 +199,24 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.TxLookupLimit != nil {
 		c.TxLookupLimit = *dec.TxLookupLimit
 	}
+	if dec.HistoryLimit != nil {
+		c.HistoryLimit = *dec.HistoryLimit
+	}
+	if dec.TraceIndex != nil {
+		c.TraceIndex = *dec.TraceIndex
+	}
+	if dec.AddressIndex != nil {
+		c.AddressIndex = *dec.AddressIndex
+	}
+	if dec.TokenIndex != nil {
+		c.TokenIndex = *dec.TokenIndex
+	}
+	if dec.StateScheme != nil {
+		c.StateScheme = *dec.StateScheme
+	}
+	if dec.StateHistory != nil {
+		c.StateHistory = *dec.StateHistory
+	}
 	if dec.Whitelist != nil {
 		c.Whitelist = dec.Whitelist
 	}
@@ -176,6 +176,7 @@ type TxFetcher struct {
 	hasTx    func(common.Hash) bool             // Retrieves a tx from the local txpool
 	addTxs   func([]*types.Transaction) []error // Insert a batch of transactions into local txpool
 	fetchTxs func(string, []common.Hash) error  // Retrieves a set of txs from a remote peer
+	report   func(string, []error)              // Reports the pool errors of a batch relayed by a peer
 
 	step  chan struct{} // Notification channel when the fetcher loop iterates
 	clock mclock.Clock  // Time wrapper to simulate in tests
@@ -218,6 +219,12 @@ func NewTxFetcherForTests(
 	return fetcher
 }
 
+// SetReporter sets a callback to report the pool errors of the transactions each
+// peer relayed, so the pool can rate its peers. It must be set before Start.
+func (f *TxFetcher) SetReporter(report func(peer string, errs []error)) {
+	f.report = report
+}
+
 // Notify announces the fetcher of the potential availability of a new batch of
 // transactions in the network.
 func (f *TxFetcher) Notify(peer string, hashes []common.Hash) error {
@@ -288,6 +295,9 @@ func (f *TxFetcher) Enqueue(peer string, txs []*types.Transaction, direct bool)
 		otherreject int64
 	)
 	errs := f.addTxs(txs)
+	if f.report != nil {
+		f.report(peer, errs)
+	}
 	for i, err := range errs {
 		// Track the transaction hash if the price is too low for us.
 		// Avoid re-request this transaction when we receive another
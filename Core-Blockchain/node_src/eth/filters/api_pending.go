@@ -0,0 +1,343 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package filters
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// pendingQueueSize is the number of notifications buffered per subscriber of the
+// pending transactions before the oldest ones get dropped.
+const pendingQueueSize = 4096
+
+// Transaction kinds the pending transaction subscriptions can filter on.
+const (
+	txKindLegacy     = "legacy"
+	txKindAccessList = "accessList"
+	txKindDynamicFee = "dynamicFee"
+	txKindX402       = "x402"
+	txKindMeta       = "meta"
+	txKindRejected   = "rejected"
+)
+
+var droppedNotificationsMeter = metrics.NewRegisteredMeter("eth/filters/subscriptions/dropped", nil)
+
+// PendingTxCriteria selects the pending transactions streamed to a subscriber,
+// and whether they are streamed as hashes or full objects. Transactions have to
+// match every non-empty criterion.
+type PendingTxCriteria struct {
+	FullTx    bool
+	From      []common.Address
+	To        []common.Address
+	Selectors []hexutil.Bytes // Leading four bytes of the call data
+	Types     []string        // Transaction kinds, "rejected" ones are only streamed if listed
+}
+
+// UnmarshalJSON decodes the criteria from either an object, or a boolean to only
+// request full transactions.
+func (c *PendingTxCriteria) UnmarshalJSON(input []byte) error {
+	var fullTx bool
+	if err := json.Unmarshal(input, &fullTx); err == nil {
+		*c = PendingTxCriteria{FullTx: fullTx}
+		return nil
+	}
+	var raw struct {
+		FullTx    bool             `json:"fullTx"`
+		From      []common.Address `json:"from"`
+		To        []common.Address `json:"to"`
+		Selectors []hexutil.Bytes  `json:"selectors"`
+		Types     []string         `json:"types"`
+	}
+	if err := json.Unmarshal(input, &raw); err != nil {
+		return err
+	}
+	for _, selector := range raw.Selectors {
+		if len(selector) != 4 {
+			return fmt.Errorf("invalid selector length %d, want 4", len(selector))
+		}
+	}
+	for _, kind := range raw.Types {
+		switch kind {
+		case txKindLegacy, txKindAccessList, txKindDynamicFee, txKindX402, txKindMeta, txKindRejected:
+		default:
+			return fmt.Errorf("unknown transaction type %q", kind)
+		}
+	}
+	*c = PendingTxCriteria{
+		FullTx:    raw.FullTx,
+		From:      raw.From,
+		To:        raw.To,
+		Selectors: raw.Selectors,
+		Types:     raw.Types,
+	}
+	return nil
+}
+
+// hasType reports whether the criteria list the given transaction kind.
+func (c *PendingTxCriteria) hasType(kind string) bool {
+	for _, have := range c.Types {
+		if have == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// txKinds returns the kinds of a transaction, its envelope type and whether it's
+// a meta transaction.
+func txKinds(tx *types.Transaction) []string {
+	var kinds []string
+	switch tx.Type() {
+	case types.LegacyTxType:
+		kinds = append(kinds, txKindLegacy)
+	case types.AccessListTxType:
+		kinds = append(kinds, txKindAccessList)
+	case types.DynamicFeeTxType:
+		kinds = append(kinds, txKindDynamicFee)
+	case types.X402TxType:
+		kinds = append(kinds, txKindX402)
+	}
+	if types.IsMetaTransaction(tx.Data()) {
+		kinds = append(kinds, txKindMeta)
+	}
+	return kinds
+}
+
+// match reports whether a transaction from the given sender matches the criteria.
+// Rejected transactions only match if the criteria ask for them, and the other
+// kinds only restrict the pooled ones.
+func (c *PendingTxCriteria) match(from common.Address, tx *types.Transaction, rejected bool) bool {
+	if rejected {
+		if !c.hasType(txKindRejected) {
+			return false
+		}
+	} else if len(c.Types) > 0 {
+		matched := false
+		for _, kind := range txKinds(tx) {
+			if c.hasType(kind) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(c.From) > 0 && !includes(c.From, from) {
+		return false
+	}
+	if len(c.To) > 0 && (tx.To() == nil || !includes(c.To, *tx.To())) {
+		return false
+	}
+	if len(c.Selectors) > 0 {
+		data := tx.Data()
+		if len(data) < 4 {
+			return false
+		}
+		matched := false
+		for _, selector := range c.Selectors {
+			if string(selector) == string(data[:4]) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// rejectedTransaction is the notification of a transaction rejected before it
+// entered the pool.
+type rejectedTransaction struct {
+	*ethapi.RPCTransaction
+	Rejected string `json:"rejected"`
+}
+
+// notifyQueue buffers the notifications of a subscriber, so that a slow one
+// doesn't stall the event system. Once the queue is full, the oldest buffered
+// notifications are dropped in favour of the new ones.
+type notifyQueue struct {
+	items   []interface{}
+	limit   int
+	dropped int
+	wake    chan struct{}
+	lock    sync.Mutex
+}
+
+func newNotifyQueue(limit int) *notifyQueue {
+	return &notifyQueue{limit: limit, wake: make(chan struct{}, 1)}
+}
+
+// push queues a notification, dropping the oldest one if the queue is full.
+func (q *notifyQueue) push(item interface{}) {
+	q.lock.Lock()
+	if len(q.items) >= q.limit {
+		q.items = q.items[1:]
+		q.dropped++
+	}
+	q.items = append(q.items, item)
+	q.lock.Unlock()
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// take returns the queued notifications and the number of dropped ones since
+// the last call.
+func (q *notifyQueue) take() ([]interface{}, int) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	items, dropped := q.items, q.dropped
+	q.items, q.dropped = nil, 0
+	return items, dropped
+}
+
+// run delivers the queued notifications to the subscriber until it goes away.
+func (q *notifyQueue) run(notifier *rpc.Notifier, sub *rpc.Subscription) {
+	for {
+		select {
+		case <-q.wake:
+			items, dropped := q.take()
+			if dropped > 0 {
+				log.Debug("Dropped notifications of slow subscriber", "id", sub.ID, "dropped", dropped)
+				droppedNotificationsMeter.Mark(int64(dropped))
+			}
+			for _, item := range items {
+				if err := notifier.Notify(sub.ID, item); err != nil {
+					return
+				}
+			}
+		case <-sub.Err():
+			return
+		case <-notifier.Closed():
+			return
+		}
+	}
+}
+
+// newFilteredPendingTransactions streams the pending transactions matching the
+// given criteria, along with the rejected ones if asked for.
+func (api *PublicFilterAPI) newFilteredPendingTransactions(notifier *rpc.Notifier, crit *PendingTxCriteria) *rpc.Subscription {
+	rpcSub := notifier.CreateSubscription()
+	queue := newNotifyQueue(pendingQueueSize)
+	go queue.run(notifier, rpcSub)
+
+	go func() {
+		var (
+			config = api.backend.ChainConfig()
+			signer = types.LatestSigner(config)
+
+			txs      = make(chan []*types.Transaction, 128)
+			pendingS = api.events.SubscribePendingTxBodies(txs)
+
+			rejected  chan core.RejectedTxsEvent
+			rejectedS *Subscription
+		)
+		defer pendingS.Unsubscribe()
+		if crit.hasType(txKindRejected) {
+			rejected = make(chan core.RejectedTxsEvent, 128)
+			rejectedS = api.events.SubscribeRejectedTxs(rejected)
+			defer rejectedS.Unsubscribe()
+		}
+		for {
+			select {
+			case batch := <-txs:
+				var header *types.Header
+				for _, tx := range batch {
+					from, _ := types.Sender(signer, tx)
+					if !crit.match(from, tx, false) {
+						continue
+					}
+					if !crit.FullTx {
+						queue.push(tx.Hash())
+						continue
+					}
+					if header == nil {
+						header = api.backend.CurrentHeader()
+					}
+					queue.push(ethapi.NewRPCPendingTransaction(tx, header, config))
+				}
+			case ev := <-rejected:
+				for _, tx := range ev.Txs {
+					from, _ := types.Sender(signer, tx)
+					if !crit.match(from, tx, true) {
+						continue
+					}
+					if !crit.FullTx {
+						queue.push(tx.Hash())
+						continue
+					}
+					queue.push(&rejectedTransaction{
+						RPCTransaction: ethapi.NewRPCPendingTransaction(tx, api.backend.CurrentHeader(), config),
+						Rejected:       ev.Reason.Error(),
+					})
+				}
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub
+}
+
+// PendingBlock sends the pending block each time the miner recommits it. Slow
+// subscribers only get the latest pending block, skipping the stale ones.
+func (api *PublicFilterAPI) PendingBlock(ctx context.Context, fullTx *bool) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	full := fullTx != nil && *fullTx
+
+	rpcSub := notifier.CreateSubscription()
+	queue := newNotifyQueue(1)
+	go queue.run(notifier, rpcSub)
+
+	go func() {
+		blocks := make(chan core.PendingBlockEvent, 4)
+		blocksSub := api.events.SubscribePendingBlock(blocks)
+		defer blocksSub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-blocks:
+				fields, err := ethapi.RPCMarshalBlock(ev.Block, true, full, api.backend.ChainConfig())
+				if err != nil {
+					log.Debug("Failed to marshal pending block", "err", err)
+					continue
+				}
+				queue.push(fields)
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
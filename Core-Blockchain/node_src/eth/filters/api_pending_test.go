@@ -0,0 +1,155 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package filters
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Tests that the pending transaction criteria decode from both booleans and
+// objects, and reject malformed filters.
+func TestPendingTxCriteriaDecoding(t *testing.T) {
+	var crit PendingTxCriteria
+	if err := json.Unmarshal([]byte(`true`), &crit); err != nil || !crit.FullTx {
+		t.Fatalf("failed to decode boolean criteria: %v, %+v", err, crit)
+	}
+	input := `{"fullTx":true,"to":["0x0000000000000000000000000000000000000001"],"selectors":["0xa9059cbb"],"types":["x402","rejected"]}`
+	if err := json.Unmarshal([]byte(input), &crit); err != nil {
+		t.Fatalf("failed to decode criteria: %v", err)
+	}
+	if !crit.FullTx || len(crit.To) != 1 || len(crit.Selectors) != 1 || len(crit.Types) != 2 {
+		t.Fatalf("decoded criteria mismatch: %+v", crit)
+	}
+	for _, input := range []string{`{"selectors":["0xa9059c"]}`, `{"types":["blob"]}`} {
+		if err := json.Unmarshal([]byte(input), &crit); err == nil {
+			t.Errorf("invalid criteria %s accepted", input)
+		}
+	}
+}
+
+// Tests that transactions are matched by sender, recipient, selector and kind,
+// with the rejected ones only matching when asked for.
+func TestPendingTxCriteriaMatching(t *testing.T) {
+	var (
+		from     = common.Address{0x01}
+		to       = common.Address{0x02}
+		selector = []byte{0xa9, 0x05, 0x9c, 0xbb}
+		meta, _  = hex.DecodeString(types.MetaPrefix)
+
+		transfer = types.NewTransaction(0, to, big.NewInt(1), 21000, big.NewInt(1), nil)
+		call     = types.NewTransaction(0, to, big.NewInt(0), 50000, big.NewInt(1), append(selector, make([]byte, 64)...))
+		metaTx   = types.NewTransaction(0, to, big.NewInt(0), 50000, big.NewInt(1), append(meta, make([]byte, 32)...))
+		dynamic  = types.NewTx(&types.DynamicFeeTx{To: &to, Gas: 21000, GasFeeCap: big.NewInt(1), GasTipCap: big.NewInt(1)})
+	)
+	tests := []struct {
+		crit     PendingTxCriteria
+		tx       *types.Transaction
+		rejected bool
+		want     bool
+	}{
+		{PendingTxCriteria{}, transfer, false, true},
+		{PendingTxCriteria{}, transfer, true, false},
+		{PendingTxCriteria{From: []common.Address{from}}, transfer, false, true},
+		{PendingTxCriteria{From: []common.Address{to}}, transfer, false, false},
+		{PendingTxCriteria{To: []common.Address{from}}, transfer, false, false},
+		{PendingTxCriteria{Selectors: []hexutil.Bytes{selector}}, call, false, true},
+		{PendingTxCriteria{Selectors: []hexutil.Bytes{selector}}, transfer, false, false},
+		{PendingTxCriteria{Types: []string{txKindDynamicFee}}, dynamic, false, true},
+		{PendingTxCriteria{Types: []string{txKindDynamicFee}}, transfer, false, false},
+		{PendingTxCriteria{Types: []string{txKindMeta}}, metaTx, false, true},
+		{PendingTxCriteria{Types: []string{txKindMeta}}, transfer, false, false},
+		{PendingTxCriteria{Types: []string{txKindRejected}}, transfer, false, false},
+		{PendingTxCriteria{Types: []string{txKindRejected}}, transfer, true, true},
+		{PendingTxCriteria{Types: []string{txKindRejected}, To: []common.Address{from}}, transfer, true, false},
+	}
+	for i, tt := range tests {
+		if have := tt.crit.match(from, tt.tx, tt.rejected); have != tt.want {
+			t.Errorf("test %d: match mismatch: have %v, want %v", i, have, tt.want)
+		}
+	}
+}
+
+// Tests that the notification queue keeps the newest notifications of a slow
+// subscriber, and counts the dropped ones.
+func TestNotifyQueueBackpressure(t *testing.T) {
+	queue := newNotifyQueue(3)
+	for i := 0; i < 5; i++ {
+		queue.push(i)
+	}
+	items, dropped := queue.take()
+	if dropped != 2 {
+		t.Fatalf("dropped notifications mismatch: have %d, want %d", dropped, 2)
+	}
+	if len(items) != 3 || items[0] != 2 || items[2] != 4 {
+		t.Fatalf("queued notifications mismatch: have %v", items)
+	}
+	if items, dropped := queue.take(); len(items) != 0 || dropped != 0 {
+		t.Fatalf("queue not drained: %v, %d", items, dropped)
+	}
+}
+
+// Tests that the event system delivers transaction bodies, rejected transactions
+// and pending blocks to their subscribers.
+func TestPendingStreamSubscriptions(t *testing.T) {
+	t.Parallel()
+
+	var (
+		backend = &testBackend{db: rawdb.NewMemoryDatabase()}
+		api     = NewPublicFilterAPI(backend, false, deadline)
+
+		txs      = make(chan []*types.Transaction)
+		rejected = make(chan core.RejectedTxsEvent)
+		blocks   = make(chan core.PendingBlockEvent)
+	)
+	txsSub := api.events.SubscribePendingTxBodies(txs)
+	defer txsSub.Unsubscribe()
+	rejectedSub := api.events.SubscribeRejectedTxs(rejected)
+	defer rejectedSub.Unsubscribe()
+	blocksSub := api.events.SubscribePendingBlock(blocks)
+	defer blocksSub.Unsubscribe()
+
+	tx := types.NewTransaction(0, common.Address{0x02}, big.NewInt(1), 21000, big.NewInt(1), nil)
+	block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1)})
+
+	go func() {
+		backend.txFeed.Send(core.NewTxsEvent{Txs: []*types.Transaction{tx}})
+		backend.rejectedFeed.Send(core.RejectedTxsEvent{Txs: []*types.Transaction{tx}, Reason: errors.New("blocklisted")})
+		backend.pendingFeed.Send(core.PendingBlockEvent{Block: block})
+	}()
+	timeout := time.After(5 * time.Second)
+	for i := 0; i < 3; i++ {
+		select {
+		case have := <-txs:
+			if len(have) != 1 || have[0].Hash() != tx.Hash() {
+				t.Errorf("pending transactions mismatch: %v", have)
+			}
+		case ev := <-rejected:
+			if len(ev.Txs) != 1 || ev.Txs[0].Hash() != tx.Hash() || ev.Reason == nil {
+				t.Errorf("rejected transactions mismatch: %+v", ev)
+			}
+		case ev := <-blocks:
+			if ev.Block.Hash() != block.Hash() {
+				t.Errorf("pending block mismatch: have %x, want %x", ev.Block.Hash(), block.Hash())
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for event %d", i)
+		}
+	}
+}
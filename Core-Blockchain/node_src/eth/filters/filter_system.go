@@ -53,6 +53,15 @@ const (
 	PendingTransactionsSubscription
 	// BlocksSubscription queries hashes for blocks that are imported
 	BlocksSubscription
+	// PendingTransactionBodiesSubscription queries full transactions entering
+	// the pending state
+	PendingTransactionBodiesSubscription
+	// RejectedTransactionsSubscription queries transactions rejected before
+	// entering the transaction pool
+	RejectedTransactionsSubscription
+	// PendingBlocksSubscription queries the pending block each time the miner
+	// recommits it
+	PendingBlocksSubscription
 	// LastSubscription keeps track of the last index
 	LastIndexSubscription
 )
@@ -67,6 +76,10 @@ const (
 	logsChanSize = 10
 	// chainEvChanSize is the size of channel listening to ChainEvent.
 	chainEvChanSize = 10
+	// rejectedTxsChanSize is the size of channel listening to RejectedTxsEvent.
+	rejectedTxsChanSize = 256
+	// pendingBlockChanSize is the size of channel listening to PendingBlockEvent.
+	pendingBlockChanSize = 10
 )
 
 type subscription struct {
@@ -77,6 +90,9 @@ type subscription struct {
 	logs      chan []*types.Log
 	hashes    chan []common.Hash
 	headers   chan *types.Header
+	txs       chan []*types.Transaction
+	rejected  chan core.RejectedTxsEvent
+	blocks    chan core.PendingBlockEvent
 	installed chan struct{} // closed when the filter is installed
 	err       chan error    // closed when the filter is uninstalled
 }
@@ -94,15 +110,19 @@ type EventSystem struct {
 	rmLogsSub      event.Subscription // Subscription for removed log event
 	pendingLogsSub event.Subscription // Subscription for pending log event
 	chainSub       event.Subscription // Subscription for new chain event
+	rejectedSub    event.Subscription // Subscription for rejected transactions event
+	pendingSub     event.Subscription // Subscription for pending block event
 
 	// Channels
-	install       chan *subscription         // install filter for event notification
-	uninstall     chan *subscription         // remove filter for event notification
-	txsCh         chan core.NewTxsEvent      // Channel to receive new transactions event
-	logsCh        chan []*types.Log          // Channel to receive new log event
-	pendingLogsCh chan []*types.Log          // Channel to receive new log event
-	rmLogsCh      chan core.RemovedLogsEvent // Channel to receive removed log event
-	chainCh       chan core.ChainEvent       // Channel to receive new chain event
+	install       chan *subscription          // install filter for event notification
+	uninstall     chan *subscription          // remove filter for event notification
+	txsCh         chan core.NewTxsEvent       // Channel to receive new transactions event
+	logsCh        chan []*types.Log           // Channel to receive new log event
+	pendingLogsCh chan []*types.Log           // Channel to receive new log event
+	rmLogsCh      chan core.RemovedLogsEvent  // Channel to receive removed log event
+	chainCh       chan core.ChainEvent        // Channel to receive new chain event
+	rejectedCh    chan core.RejectedTxsEvent  // Channel to receive rejected transactions event
+	pendingCh     chan core.PendingBlockEvent // Channel to receive pending block event
 }
 
 // NewEventSystem creates a new manager that listens for event on the given mux,
@@ -122,6 +142,8 @@ func NewEventSystem(backend Backend, lightMode bool) *EventSystem {
 		rmLogsCh:      make(chan core.RemovedLogsEvent, rmLogsChanSize),
 		pendingLogsCh: make(chan []*types.Log, logsChanSize),
 		chainCh:       make(chan core.ChainEvent, chainEvChanSize),
+		rejectedCh:    make(chan core.RejectedTxsEvent, rejectedTxsChanSize),
+		pendingCh:     make(chan core.PendingBlockEvent, pendingBlockChanSize),
 	}
 
 	// Subscribe events
@@ -130,9 +152,12 @@ func NewEventSystem(backend Backend, lightMode bool) *EventSystem {
 	m.rmLogsSub = m.backend.SubscribeRemovedLogsEvent(m.rmLogsCh)
 	m.chainSub = m.backend.SubscribeChainEvent(m.chainCh)
 	m.pendingLogsSub = m.backend.SubscribePendingLogsEvent(m.pendingLogsCh)
+	m.rejectedSub = m.backend.SubscribeRejectedTxsEvent(m.rejectedCh)
+	m.pendingSub = m.backend.SubscribePendingBlockEvent(m.pendingCh)
 
 	// Make sure none of the subscriptions are empty
-	if m.txsSub == nil || m.logsSub == nil || m.rmLogsSub == nil || m.chainSub == nil || m.pendingLogsSub == nil {
+	if m.txsSub == nil || m.logsSub == nil || m.rmLogsSub == nil || m.chainSub == nil || m.pendingLogsSub == nil ||
+		m.rejectedSub == nil || m.pendingSub == nil {
 		log.Crit("Subscribe for event system failed")
 	}
 
@@ -168,6 +193,9 @@ func (sub *Subscription) Unsubscribe() {
 			case <-sub.f.logs:
 			case <-sub.f.hashes:
 			case <-sub.f.headers:
+			case <-sub.f.txs:
+			case <-sub.f.rejected:
+			case <-sub.f.blocks:
 			}
 		}
 
@@ -307,6 +335,57 @@ func (es *EventSystem) SubscribePendingTxs(hashes chan []common.Hash) *Subscript
 	return es.subscribe(sub)
 }
 
+// SubscribePendingTxBodies creates a subscription that writes the transactions
+// that enter the transaction pool.
+func (es *EventSystem) SubscribePendingTxBodies(txs chan []*types.Transaction) *Subscription {
+	sub := &subscription{
+		id:        rpc.NewID(),
+		typ:       PendingTransactionBodiesSubscription,
+		created:   time.Now(),
+		logs:      make(chan []*types.Log),
+		hashes:    make(chan []common.Hash),
+		headers:   make(chan *types.Header),
+		txs:       txs,
+		installed: make(chan struct{}),
+		err:       make(chan error),
+	}
+	return es.subscribe(sub)
+}
+
+// SubscribeRejectedTxs creates a subscription that writes the transactions that
+// got rejected before entering the transaction pool.
+func (es *EventSystem) SubscribeRejectedTxs(rejected chan core.RejectedTxsEvent) *Subscription {
+	sub := &subscription{
+		id:        rpc.NewID(),
+		typ:       RejectedTransactionsSubscription,
+		created:   time.Now(),
+		logs:      make(chan []*types.Log),
+		hashes:    make(chan []common.Hash),
+		headers:   make(chan *types.Header),
+		rejected:  rejected,
+		installed: make(chan struct{}),
+		err:       make(chan error),
+	}
+	return es.subscribe(sub)
+}
+
+// SubscribePendingBlock creates a subscription that writes the pending block each
+// time the miner recommits it.
+func (es *EventSystem) SubscribePendingBlock(blocks chan core.PendingBlockEvent) *Subscription {
+	sub := &subscription{
+		id:        rpc.NewID(),
+		typ:       PendingBlocksSubscription,
+		created:   time.Now(),
+		logs:      make(chan []*types.Log),
+		hashes:    make(chan []common.Hash),
+		headers:   make(chan *types.Header),
+		blocks:    blocks,
+		installed: make(chan struct{}),
+		err:       make(chan error),
+	}
+	return es.subscribe(sub)
+}
+
 type filterIndex map[Type]map[rpc.ID]*subscription
 
 func (es *EventSystem) handleLogs(filters filterIndex, ev []*types.Log) {
@@ -350,6 +429,21 @@ func (es *EventSystem) handleTxsEvent(filters filterIndex, ev core.NewTxsEvent)
 	for _, f := range filters[PendingTransactionsSubscription] {
 		f.hashes <- hashes
 	}
+	for _, f := range filters[PendingTransactionBodiesSubscription] {
+		f.txs <- ev.Txs
+	}
+}
+
+func (es *EventSystem) handleRejectedTxsEvent(filters filterIndex, ev core.RejectedTxsEvent) {
+	for _, f := range filters[RejectedTransactionsSubscription] {
+		f.rejected <- ev
+	}
+}
+
+func (es *EventSystem) handlePendingBlockEvent(filters filterIndex, ev core.PendingBlockEvent) {
+	for _, f := range filters[PendingBlocksSubscription] {
+		f.blocks <- ev
+	}
 }
 
 func (es *EventSystem) handleChainEvent(filters filterIndex, ev core.ChainEvent) {
@@ -449,6 +543,8 @@ func (es *EventSystem) eventLoop() {
 		es.rmLogsSub.Unsubscribe()
 		es.pendingLogsSub.Unsubscribe()
 		es.chainSub.Unsubscribe()
+		es.rejectedSub.Unsubscribe()
+		es.pendingSub.Unsubscribe()
 	}()
 
 	index := make(filterIndex)
@@ -468,6 +564,10 @@ func (es *EventSystem) eventLoop() {
 			es.handlePendingLogs(index, ev)
 		case ev := <-es.chainCh:
 			es.handleChainEvent(index, ev)
+		case ev := <-es.rejectedCh:
+			es.handleRejectedTxsEvent(index, ev)
+		case ev := <-es.pendingCh:
+			es.handlePendingBlockEvent(index, ev)
 
 		case f := <-es.install:
 			if f.typ == MinedAndPendingLogsSubscription {
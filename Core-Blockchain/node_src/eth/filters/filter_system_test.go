@@ -53,12 +53,23 @@ type testBackend struct {
 	rmLogsFeed      event.Feed
 	pendingLogsFeed event.Feed
 	chainFeed       event.Feed
+	rejectedFeed    event.Feed
+	pendingFeed     event.Feed
 }
 
 func (b *testBackend) ChainDb() ethdb.Database {
 	return b.db
 }
 
+func (b *testBackend) ChainConfig() *params.ChainConfig {
+	return params.TestChainConfig
+}
+
+func (b *testBackend) CurrentHeader() *types.Header {
+	header, _ := b.HeaderByNumber(context.Background(), rpc.LatestBlockNumber)
+	return header
+}
+
 func (b *testBackend) HeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*types.Header, error) {
 	var (
 		hash common.Hash
@@ -127,6 +138,14 @@ func (b *testBackend) SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subsc
 	return b.chainFeed.Subscribe(ch)
 }
 
+func (b *testBackend) SubscribeRejectedTxsEvent(ch chan<- core.RejectedTxsEvent) event.Subscription {
+	return b.rejectedFeed.Subscribe(ch)
+}
+
+func (b *testBackend) SubscribePendingBlockEvent(ch chan<- core.PendingBlockEvent) event.Subscription {
+	return b.pendingFeed.Subscribe(ch)
+}
+
 func (b *testBackend) BloomStatus() (uint64, uint64) {
 	return params.BloomBitsBlocks, b.sections
 }
@@ -18,6 +18,15 @@ var (
 	gwei      = big.NewInt(1e9)
 )
 
+// FeePrediction is the wei precision counterpart of the gas price prediction, the
+// slices hold one entry per speed tier, from fast to low.
+type FeePrediction struct {
+	Tips     []*big.Int // Priority fee per gas of each tier, in wei
+	Blocks   []uint64   // Expected number of blocks until inclusion of each tier
+	Pending  int        // Number of pending transactions competing for inclusion
+	AvgTxCnt int        // Average transaction count of the latest blocks
+}
+
 type Prediction struct {
 	cfg          *Config
 	txCnts       *Stats // tx count statistics of few latest blocks
@@ -26,7 +35,8 @@ type Prediction struct {
 	chainHeadSub event.Subscription
 	pool         *core.TxPool
 
-	predis        []uint // gas price prediction in gwei, currently will be 3 items, from hight(fast) to low(slow)
+	predis        []uint         // gas price prediction in gwei, currently will be 3 items, from hight(fast) to low(slow)
+	fees          *FeePrediction // wei precision prediction, with the same tiers as predis
 	lockPredis    sync.RWMutex
 	wg            sync.WaitGroup
 	blockGasLimit uint64
@@ -37,6 +47,7 @@ func NewPrediction(cfg Config, backend OracleBackend, pool *core.TxPool) *Predic
 		//some test case offers no config
 		return &Prediction{
 			predis: make([]uint, 3),
+			fees:   newFeePrediction(cfg.Default),
 		}
 	}
 	p := &Prediction{
@@ -47,6 +58,7 @@ func NewPrediction(cfg Config, backend OracleBackend, pool *core.TxPool) *Predic
 	}
 	price := wei2GWei(cfg.Default)
 	p.predis = []uint{price * 2, price, price}
+	p.fees = newFeePrediction(cfg.Default)
 
 	// init txCnts
 	p.initTxCnts()
@@ -81,6 +93,24 @@ func (p *Prediction) CurrentPrices() []uint {
 	return prices
 }
 
+// CurrentFees returns the current wei precision prediction; the result is readonly.
+func (p *Prediction) CurrentFees() *FeePrediction {
+	p.lockPredis.RLock()
+	defer p.lockPredis.RUnlock()
+	return p.fees
+}
+
+// newFeePrediction returns the prediction used until the pool has been looked at.
+func newFeePrediction(tip *big.Int) *FeePrediction {
+	if tip == nil {
+		tip = new(big.Int)
+	}
+	return &FeePrediction{
+		Tips:   []*big.Int{new(big.Int).Mul(tip, big.NewInt(2)), tip, tip},
+		Blocks: []uint64{1, 1, 1},
+	}
+}
+
 func (p *Prediction) initTxCnts() {
 	cnts := make([]int, p.cfg.Blocks)
 	ctx := context.Background()
@@ -141,12 +171,25 @@ func (p *Prediction) loop() {
 
 func (p *Prediction) update() {
 	txs := p.pool.Pending(true)
-	byprice := make(TxByPrice, 0, len(txs))
+	all := make(TxByPrice, 0, len(txs))
 	for _, ts := range txs {
-		byprice = append(byprice, ts...)
+		all = append(all, ts...)
+	}
+	// The wei precision prediction considers all the transactions the pool accepts,
+	// the gwei one only those tipping at least a gwei, a prefix of them once sorted.
+	minTip := p.pool.GasPrice()
+	if minTip.Cmp(gwei) > 0 {
+		minTip = gwei
+	}
+	all = p.filteroutInvalid(all, minTip)
+	sort.Sort(all)
+	byprice := all[:sort.Search(len(all), func(i int) bool { return all[i].GasTipCapIntCmp(gwei) < 0 })]
+
+	avgTxCnt := p.txCnts.Avg()
+	p.updateFees(all, avgTxCnt, p.pool.GasPrice())
+	if avgTxCnt < p.cfg.MinTxCntPerBlock {
+		avgTxCnt = p.cfg.MinTxCntPerBlock
 	}
-	byprice = p.filteroutInvalid(byprice)
-	sort.Sort(byprice)
 
 	minPrice := wei2GWei(p.pool.GasPrice())
 	prices := make([]uint, 3)
@@ -159,11 +202,6 @@ func (p *Prediction) update() {
 		return
 	}
 
-	avgTxCnt := p.txCnts.Avg()
-	if avgTxCnt < p.cfg.MinTxCntPerBlock {
-		avgTxCnt = p.cfg.MinTxCntPerBlock
-	}
-
 	// fast price
 	fi := p.cfg.FastFactor * avgTxCnt
 	if pendingCnt <= fi {
@@ -199,7 +237,60 @@ func (p *Prediction) update() {
 	p.updatePredis(prices)
 }
 
-func (p *Prediction) filteroutInvalid(txs TxByPrice) TxByPrice {
+// updateFees computes the wei precision prediction from the pending transactions
+// sorted by tip, with the same tier indexes as the gwei prediction. A tier tipping
+// like the transaction at index i waits for the i transactions ahead to be included.
+func (p *Prediction) updateFees(byprice TxByPrice, recentTxCnt int, minTip *big.Int) {
+	avgTxCnt := recentTxCnt
+	if avgTxCnt < p.cfg.MinTxCntPerBlock {
+		avgTxCnt = p.cfg.MinTxCntPerBlock
+	}
+	if avgTxCnt < 1 {
+		avgTxCnt = 1
+	}
+	var (
+		pendingCnt = len(byprice)
+		fees       = &FeePrediction{
+			Tips:     make([]*big.Int, 3),
+			Blocks:   make([]uint64, 3),
+			Pending:  pendingCnt,
+			AvgTxCnt: recentTxCnt,
+		}
+	)
+	indexes := []int{
+		p.cfg.FastFactor * avgTxCnt,
+		max(p.cfg.MedianFactor*avgTxCnt, p.cfg.MinMedianIndex),
+		max(p.cfg.LowFactor*avgTxCnt, p.cfg.MinLowIndex),
+	}
+	percentiles := []int{p.cfg.FastPercentile, p.cfg.MeidanPercentile}
+	for i, index := range indexes {
+		tip := minTip
+		switch {
+		case pendingCnt > index:
+			tip = byprice[index].GasTipCap()
+		case i < len(percentiles) && pendingCnt > 0:
+			// few pending transactions, the fast and median tiers take a percentile
+			index = pendingCnt * percentiles[i] / 100
+			if index >= pendingCnt {
+				index = pendingCnt - 1
+			}
+			tip = byprice[index].GasTipCap()
+		default:
+			// the low tier queues behind all of them at the minimum price
+			index = pendingCnt
+		}
+		if tip.Cmp(minTip) < 0 {
+			tip = minTip
+		}
+		fees.Tips[i] = tip
+		fees.Blocks[i] = uint64(index/avgTxCnt) + 1
+	}
+	p.lockPredis.Lock()
+	p.fees = fees
+	p.lockPredis.Unlock()
+}
+
+func (p *Prediction) filteroutInvalid(txs TxByPrice, minTip *big.Int) TxByPrice {
 	maxgas := (p.blockGasLimit / 10) * 6
 	maxlive := time.Duration(p.cfg.MaxValidPendingSecs) * time.Second
 	i, j := 0, len(txs)
@@ -207,7 +298,7 @@ func (p *Prediction) filteroutInvalid(txs TxByPrice) TxByPrice {
 		tx := txs[i]
 		if tx.Gas() > maxgas ||
 			time.Since(tx.LocalSeenTime()) > maxlive ||
-			tx.GasTipCapIntCmp(gwei) < 0 {
+			tx.GasTipCapIntCmp(minTip) < 0 {
 			j--
 			txs[i], txs[j] = txs[j], txs[i]
 			continue
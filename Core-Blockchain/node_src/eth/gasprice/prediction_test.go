@@ -0,0 +1,59 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package gasprice
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Tests that the fee prediction keeps wei precision and estimates how many blocks
+// each tier waits for.
+func TestPredictionFees(t *testing.T) {
+	p := &Prediction{cfg: &Config{PredConfig: PredConfig{
+		MinTxCntPerBlock: 10,
+		FastFactor:       2,
+		MedianFactor:     5,
+		LowFactor:        8,
+		MinMedianIndex:   50,
+		MinLowIndex:      100,
+		FastPercentile:   75,
+		MeidanPercentile: 90,
+	}}}
+	pending := func(n int) TxByPrice {
+		txs := make(TxByPrice, n)
+		for i := range txs {
+			tip := big.NewInt(int64(n - i)) // sub-gwei tips, descending
+			txs[i] = types.NewTx(&types.DynamicFeeTx{GasTipCap: tip, GasFeeCap: tip})
+		}
+		return txs
+	}
+	for i, tt := range []struct {
+		pending int
+		tips    []int64
+		blocks  []uint64
+	}{
+		{0, []int64{1, 1, 1}, []uint64{1, 1, 1}},
+		{10, []int64{3, 1, 1}, []uint64{1, 1, 2}},
+		{300, []int64{280, 250, 200}, []uint64{3, 6, 11}},
+	} {
+		p.updateFees(pending(tt.pending), 10, big.NewInt(1))
+		fees := p.CurrentFees()
+		for j := range tt.tips {
+			if fees.Tips[j].Int64() != tt.tips[j] || fees.Blocks[j] != tt.blocks[j] {
+				t.Errorf("test %d, tier %d: have tip %v in %d blocks, want %d in %d", i, j, fees.Tips[j], fees.Blocks[j], tt.tips[j], tt.blocks[j])
+			}
+		}
+		if fees.Pending != tt.pending {
+			t.Errorf("test %d: pending mismatch: have %d, want %d", i, fees.Pending, tt.pending)
+		}
+	}
+}
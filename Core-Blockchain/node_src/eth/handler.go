@@ -74,6 +74,24 @@ type txPool interface {
 	SubscribeNewTxsEvent(chan<- core.NewTxsEvent) event.Subscription
 }
 
+// txAdmitter is implemented by transaction pools rate limiting and rating the
+// peers relaying transactions.
+type txAdmitter interface {
+	// AdmitPeer filters the transactions relayed by a peer, failing if the peer
+	// is to be disconnected.
+	AdmitPeer(peer string, txs []*types.Transaction) ([]*types.Transaction, error)
+
+	// ReportPeer rates a peer by the pool errors of a batch it relayed.
+	ReportPeer(peer string, errs []error)
+}
+
+// conditionalPool is implemented by transaction pools holding conditional
+// transactions, which are kept from the network.
+type conditionalPool interface {
+	// IsConditional reports whether a pooled transaction is a conditional one.
+	IsConditional(hash common.Hash) bool
+}
+
 // handlerConfig is the collection of initialization parameters to create a full
 // node network handler.
 type handlerConfig struct {
@@ -228,6 +246,9 @@ func newHandler(config *handlerConfig) (*handler, error) {
 		return p.RequestTxs(hashes)
 	}
 	h.txFetcher = fetcher.NewTxFetcher(h.txpool.Has, h.txpool.AddRemotes, fetchTx)
+	if admitter, ok := h.txpool.(txAdmitter); ok {
+		h.txFetcher.SetReporter(admitter.ReportPeer)
+	}
 	h.chainSync = newChainSyncer(h)
 	return h, nil
 }
@@ -482,6 +503,9 @@ func (h *handler) BroadcastTransactions(txs types.Transactions) {
 	)
 	// Broadcast transactions to a batch of peers not knowing about it
 	for _, tx := range txs {
+		if !h.relayable(tx.Hash()) {
+			continue
+		}
 		peers := h.peers.peersWithoutTransaction(tx.Hash())
 		// Send the tx unconditionally to a subset of our peers
 		numDirect := int(math.Sqrt(float64(len(peers))))
@@ -508,6 +532,15 @@ func (h *handler) BroadcastTransactions(txs types.Transactions) {
 		"tx packs", directPeers, "broadcast txs", directCount)
 }
 
+// relayable reports whether a pooled transaction may be relayed to the network,
+// which conditional transactions aren't.
+func (h *handler) relayable(hash common.Hash) bool {
+	if pool, ok := h.txpool.(conditionalPool); ok {
+		return !pool.IsConditional(hash)
+	}
+	return true
+}
+
 // minedBroadcastLoop sends mined blocks to connected peers.
 func (h *handler) minedBroadcastLoop() {
 	defer h.wg.Done()
@@ -95,9 +95,14 @@ func (h *ethHandler) Handle(peer *eth.Peer, packet eth.Packet) error {
 		return h.txFetcher.Notify(peer.ID(), *packet)
 
 	case *eth.TransactionsPacket:
-		return h.txFetcher.Enqueue(peer.ID(), *packet, false)
+		txs, err := h.admitTxs(peer, *packet)
+		if err != nil {
+			return err
+		}
+		return h.txFetcher.Enqueue(peer.ID(), txs, false)
 
 	case *eth.PooledTransactionsPacket:
+		// Solicited transactions are bounded by the requests of the fetcher
 		return h.txFetcher.Enqueue(peer.ID(), *packet, true)
 
 	default:
@@ -105,6 +110,15 @@ func (h *ethHandler) Handle(peer *eth.Peer, packet eth.Packet) error {
 	}
 }
 
+// admitTxs filters the transactions broadcast by a peer through the rate limits
+// of the pool, if it has any. An error is returned if the peer is to be dropped.
+func (h *ethHandler) admitTxs(peer *eth.Peer, txs []*types.Transaction) ([]*types.Transaction, error) {
+	if admitter, ok := h.txpool.(txAdmitter); ok {
+		return admitter.AdmitPeer(peer.ID(), txs)
+	}
+	return txs, nil
+}
+
 // handleHeaders is invoked from a peer's message handler when it transmits a batch
 // of headers for the local node to process.
 func (h *ethHandler) handleHeaders(peer *eth.Peer, headers []*types.Header) error {
@@ -21,6 +21,7 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -175,6 +176,12 @@ type TraceConfig struct {
 	Tracer  *string
 	Timeout *string
 	Reexec  *uint64
+	// Config specific to given tracer. Note struct logger
+	// config are historically embedded in main object.
+	TracerConfig json.RawMessage
+	// SysCalls includes the system contract calls the consensus engine makes
+	// before and after the transactions of a block in block traces.
+	SysCalls bool
 }
 
 type BlockOverrides struct {
@@ -195,6 +202,7 @@ type TraceCallConfig struct {
     Tracer         *string
     Timeout        *string
     Reexec         *uint64
+    TracerConfig   json.RawMessage
     StateOverrides *ethapi.StateOverride
     BlockOverrides *BlockOverrides // Add this line
 }
@@ -211,6 +219,10 @@ type StdTraceConfig struct {
 type txTraceResult struct {
 	Result interface{} `json:"result,omitempty"` // Trace results produced by the tracer
 	Error  string      `json:"error,omitempty"`  // Trace failure produced by the tracer
+
+	SysCall *sysCall `json:"sysCall,omitempty"` // System contract call traced instead of a transaction
+
+	sysTx bool // Whether the traced transaction is a PoSA system transaction
 }
 
 // blockTraceTask represents a single block trace task when an entire chain is
@@ -600,12 +612,19 @@ func (api *API) StandardTraceBadBlockToFile(ctx context.Context, hash common.Has
 // executes all the transactions contained within. The return value will be one item
 // per transaction, dependent on the requestd tracer.
 func (api *API) traceBlock(ctx context.Context, block *types.Block, config *TraceConfig) ([]*txTraceResult, error) {
+	results, _, err := api.traceBlockState(ctx, block, config)
+	return results, err
+}
+
+// traceBlockState is traceBlock, additionally returning the state after all the
+// transactions of the block were applied, but before the block was finalized.
+func (api *API) traceBlockState(ctx context.Context, block *types.Block, config *TraceConfig) ([]*txTraceResult, *state.StateDB, error) {
 	if block.NumberU64() == 0 {
-		return nil, errors.New("genesis is not traceable")
+		return nil, nil, errors.New("genesis is not traceable")
 	}
 	parent, err := api.blockByNumberAndHash(ctx, rpc.BlockNumber(block.NumberU64()-1), block.ParentHash())
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	reexec := defaultTraceReexec
 	if config != nil && config.Reexec != nil {
@@ -613,7 +632,7 @@ func (api *API) traceBlock(ctx context.Context, block *types.Block, config *Trac
 	}
 	statedb, err := api.backend.StateAtBlock(ctx, parent, reexec, nil, true, false)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	// Execute all the transaction contained within the block concurrently
 	var (
@@ -633,9 +652,15 @@ func (api *API) traceBlock(ctx context.Context, block *types.Block, config *Trac
 
 
 	
+	var sysCalls *sysCallTracer
 	if api.isPoSA {
 		blockCtx := core.NewEVMBlockContext(block.Header(), api.chainContext(ctx), nil)
-		_ = api.posa.PreHandle(api.backend.ChainHeaderReader(), header, statedb)
+		chain := api.backend.ChainHeaderReader()
+		if config != nil && config.SysCalls {
+			sysCalls = api.newSysCallTracer(ctx, block, config)
+			chain = sysCalls.chain(sysCallPreHandle, statedb)
+		}
+		_ = api.posa.PreHandle(chain, header, statedb)
 		blockCtx.ExtraValidator = api.posa.CreateEvmExtraValidator(header, statedb)
 	}
 	blockHash := block.Hash()
@@ -661,15 +686,21 @@ func (api *API) traceBlock(ctx context.Context, block *types.Block, config *Trac
 					res, err = api.traceTx(ctx, msg, txctx, blockCtx, task.statedb, config)
 				}
 				if err != nil {
-					results[task.index] = &txTraceResult{Error: err.Error()}
+					results[task.index] = &txTraceResult{Error: err.Error(), sysTx: task.isSysTx}
 					continue
 				}
-				results[task.index] = &txTraceResult{Result: res}
+				results[task.index] = &txTraceResult{Result: res, sysTx: task.isSysTx}
 			}
 		}()
 	}
 	// Feed the transactions into the tracers and return
-	var failed error
+	var (
+		failed error
+
+		finalState *state.StateDB       // State the block is finalized on, before the system transactions
+		plainTxs   []*types.Transaction // Transactions the block is finalized with
+		sysTxs     []*types.Transaction // System transactions the block is finalized with
+	)
 	blockCtx := core.NewEVMBlockContext(block.Header(), api.chainContext(ctx), nil)
 	for i, tx := range txs {
 		var isSysTx bool
@@ -677,6 +708,17 @@ func (api *API) traceBlock(ctx context.Context, block *types.Block, config *Trac
 			sender, _ := types.Sender(signer, tx)
 			isSysTx, _ = api.posa.IsSysTransaction(sender, tx, header)
 		}
+		if sysCalls != nil {
+			if !isSysTx {
+				plainTxs = append(plainTxs, tx)
+			} else {
+				// The system transactions are executed by the finalization
+				if finalState == nil {
+					finalState = statedb.Copy()
+				}
+				sysTxs = append(sysTxs, tx)
+			}
+		}
 		// Send the trace task over for execution
 		jobs <- &txTraceTask{statedb: statedb.Copy(), index: i, isSysTx: isSysTx}
 
@@ -705,9 +747,23 @@ func (api *API) traceBlock(ctx context.Context, block *types.Block, config *Trac
 
 	// If execution failed in between, abort
 	if failed != nil {
-		return nil, failed
+		return nil, nil, failed
+	}
+	if sysCalls != nil {
+		preHandled := sysCalls.collect()
+		if finalState == nil {
+			finalState = statedb.Copy()
+		}
+		var (
+			chain    = sysCalls.chain(sysCallFinalize, finalState)
+			receipts = make([]*types.Receipt, 0)
+		)
+		if err := api.backend.Engine().Finalize(chain, types.CopyHeader(header), finalState, &plainTxs, nil, &receipts, sysTxs); err != nil {
+			return nil, nil, fmt.Errorf("failed to trace block finalization: %w", err)
+		}
+		results = append(append(preHandled, results...), sysCalls.collect()...)
 	}
-	return results, nil
+	return results, statedb, nil
 }
 
 // standardTraceBlockToFile configures a new tracer which uses standard JSON output,
@@ -939,10 +995,11 @@ func (api *API) TraceCall(ctx context.Context, args ethapi.TransactionArgs, bloc
 	var traceConfig *TraceConfig
 	if config != nil {
 		traceConfig = &TraceConfig{
-			LogConfig: config.LogConfig,
-			Tracer:    config.Tracer,
-			Timeout:   config.Timeout,
-			Reexec:    config.Reexec,
+			LogConfig:    config.LogConfig,
+			Tracer:       config.Tracer,
+			Timeout:      config.Timeout,
+			Reexec:       config.Reexec,
+			TracerConfig: config.TracerConfig,
 		}
 	}
 	return api.traceTx(ctx, msg, new(Context), vmctx, statedb, traceConfig)
@@ -969,7 +1026,7 @@ func (api *API) traceTx(ctx context.Context, message core.Message, txctx *Contex
 				return nil, err
 			}
 		}
-		if t, err := New(*config.Tracer, txctx); err != nil {
+		if t, err := New(*config.Tracer, txctx, config.TracerConfig); err != nil {
 			return nil, err
 		} else {
 			deadlineCtx, cancel := context.WithTimeout(ctx, timeout)
@@ -1018,7 +1075,7 @@ func (api *API) tracePoSASysTx(ctx context.Context, sender common.Address, tx *t
 			}
 		}
 		// Constuct the JavaScript tracer to execute with
-		if tracer, err = New(*config.Tracer, txctx); err != nil {
+		if tracer, err = New(*config.Tracer, txctx, config.TracerConfig); err != nil {
 			return nil, err
 		}
 		// Handle timeouts and RPC cancellations
@@ -1085,5 +1142,11 @@ func APIs(backend Backend) []rpc.API {
 			Service:   NewAPI(backend),
 			Public:    false,
 		},
+		{
+			Namespace: "trace",
+			Version:   "1.0",
+			Service:   NewTraceAPI(backend),
+			Public:    false,
+		},
 	}
 }
@@ -0,0 +1,73 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package tracers
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// FlatTraceAction is the action of a flat trace. Only the fields relevant to the
+// type of the trace are set: calls, creations, self-destructs or block rewards.
+type FlatTraceAction struct {
+	Author        *common.Address `json:"author,omitempty"`
+	RewardType    string          `json:"rewardType,omitempty"`
+	Address       *common.Address `json:"address,omitempty"`
+	Balance       *hexutil.Big    `json:"balance,omitempty"`
+	CallType      string          `json:"callType,omitempty"`
+	From          *common.Address `json:"from,omitempty"`
+	Gas           *hexutil.Uint64 `json:"gas,omitempty"`
+	Init          *hexutil.Bytes  `json:"init,omitempty"`
+	Input         *hexutil.Bytes  `json:"input,omitempty"`
+	RefundAddress *common.Address `json:"refundAddress,omitempty"`
+	To            *common.Address `json:"to,omitempty"`
+	Value         *hexutil.Big    `json:"value,omitempty"`
+}
+
+// FlatTraceResult is the outcome of a successful call or creation.
+type FlatTraceResult struct {
+	Address *common.Address `json:"address,omitempty"`
+	Code    *hexutil.Bytes  `json:"code,omitempty"`
+	GasUsed hexutil.Uint64  `json:"gasUsed"`
+	Output  *hexutil.Bytes  `json:"output,omitempty"`
+}
+
+// FlatTrace is a single call frame in the flattened, parity-style representation
+// of the call tree of a transaction, or a block reward.
+type FlatTrace struct {
+	Action              FlatTraceAction  `json:"action"`
+	BlockHash           *common.Hash     `json:"blockHash"`
+	BlockNumber         uint64           `json:"blockNumber"`
+	Error               string           `json:"error,omitempty"`
+	Result              *FlatTraceResult `json:"result"`
+	Subtraces           int              `json:"subtraces"`
+	TraceAddress        []int            `json:"traceAddress"`
+	TransactionHash     *common.Hash     `json:"transactionHash"`
+	TransactionPosition *uint64          `json:"transactionPosition"`
+	Type                string           `json:"type"`
+}
+
+// addresses returns the accounts the trace originates from and is directed to.
+func (t *FlatTrace) addresses() (from []common.Address, to []common.Address) {
+	a := t.Action
+	for _, addr := range []*common.Address{a.From, a.Address} {
+		if addr != nil {
+			from = append(from, *addr)
+		}
+	}
+	for _, addr := range []*common.Address{a.To, a.RefundAddress, a.Author} {
+		if addr != nil {
+			to = append(to, *addr)
+		}
+	}
+	if t.Result != nil && t.Result.Address != nil {
+		to = append(to, *t.Result.Address)
+	}
+	return from, to
+}
@@ -0,0 +1,110 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package tracetest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/eth/tracers"
+)
+
+// checkFlatTrace verifies that a flat trace matches the call frame of the call
+// tracer it was flattened from.
+func checkFlatTrace(t *testing.T, have *tracers.FlatTrace, want *callTrace, address []int) {
+	t.Helper()
+
+	if !reflect.DeepEqual(have.TraceAddress, address) {
+		t.Fatalf("trace address mismatch: have %v, want %v", have.TraceAddress, address)
+	}
+	if have.Subtraces != len(want.Calls) {
+		t.Errorf("trace %v: subtraces mismatch: have %d, want %d", address, have.Subtraces, len(want.Calls))
+	}
+	if (have.Error != "") != (want.Error != "") {
+		t.Errorf("trace %v: error mismatch: have %q, want %q", address, have.Error, want.Error)
+	}
+	if have.TransactionHash == nil || have.TransactionPosition == nil {
+		t.Errorf("trace %v: transaction missing", address)
+	}
+	action := have.Action
+	switch want.Type {
+	case "SELFDESTRUCT":
+		if have.Type != "suicide" || *action.Address != want.From || *action.RefundAddress != want.To {
+			t.Errorf("trace %v: self-destruct mismatch: have %+v, want %+v", address, action, want)
+		}
+	case "CREATE", "CREATE2":
+		if have.Type != "create" || *action.From != want.From || uint64(*action.Gas) != uint64(*want.Gas) {
+			t.Errorf("trace %v: create mismatch: have %+v, want %+v", address, action, want)
+		}
+		if want.Error == "" && (have.Result == nil || *have.Result.Address != want.To) {
+			t.Errorf("trace %v: created address mismatch: have %+v, want %x", address, have.Result, want.To)
+		}
+	default:
+		if have.Type != "call" || action.CallType != strings.ToLower(want.Type) || *action.From != want.From || *action.To != want.To {
+			t.Errorf("trace %v: call mismatch: have %+v, want %+v", address, action, want)
+		}
+		if uint64(*action.Gas) != uint64(*want.Gas) {
+			t.Errorf("trace %v: gas mismatch: have %d, want %d", address, *action.Gas, *want.Gas)
+		}
+		if want.Error == "" && (have.Result == nil || uint64(have.Result.GasUsed) != uint64(*want.GasUsed)) {
+			t.Errorf("trace %v: result mismatch: have %+v, want %d", address, have.Result, want.GasUsed)
+		}
+	}
+}
+
+// Iterates over all the input-output datasets of the call tracer and checks the
+// native flat call tracer reports the same call frames, depth first.
+func TestFlatCallTracer(t *testing.T) {
+	files, err := ioutil.ReadDir(filepath.Join("testdata", "call_tracer"))
+	if err != nil {
+		t.Fatalf("failed to retrieve tracer test suite: %v", err)
+	}
+	for _, file := range files {
+		if !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		file := file // capture range variable
+		t.Run(camel(strings.TrimSuffix(file.Name(), ".json")), func(t *testing.T) {
+			t.Parallel()
+
+			test := new(callTracerTest)
+			if blob, err := ioutil.ReadFile(filepath.Join("testdata", "call_tracer", file.Name())); err != nil {
+				t.Fatalf("failed to read testcase: %v", err)
+			} else if err := json.Unmarshal(blob, test); err != nil {
+				t.Fatalf("failed to parse testcase: %v", err)
+			}
+			res, _, _ := runTracer(t, test, "flatCallTracer", json.RawMessage(`{"includePrecompiles": true}`))
+
+			var traces []*tracers.FlatTrace
+			if err := json.Unmarshal(res, &traces); err != nil {
+				t.Fatalf("failed to unmarshal trace result: %v", err)
+			}
+			// Walk the call tree depth first alongside the flat traces
+			var walk func(call *callTrace, address []int)
+			walk = func(call *callTrace, address []int) {
+				if len(traces) == 0 {
+					t.Fatalf("trace %v missing", address)
+				}
+				checkFlatTrace(t, traces[0], call, address)
+				traces = traces[1:]
+				for i := range call.Calls {
+					walk(&call.Calls[i], append(append([]int{}, address...), i))
+				}
+			}
+			walk(test.Result, []int{})
+			if len(traces) != 0 {
+				t.Fatalf("%d unexpected traces", len(traces))
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package tracetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/tests"
+)
+
+// prestateAccount is an account of a prestateTracer result.
+type prestateAccount struct {
+	Balance *hexutil.Big                `json:"balance"`
+	Code    hexutil.Bytes               `json:"code"`
+	Nonce   uint64                      `json:"nonce"`
+	Storage map[common.Hash]common.Hash `json:"storage"`
+}
+
+// prestateDiff is the result of a prestateTracer run in diff mode.
+type prestateDiff struct {
+	Pre  map[common.Address]*prestateAccount `json:"pre"`
+	Post map[common.Address]*prestateAccount `json:"post"`
+}
+
+// runTracer executes the transaction of a call tracer test with the given
+// tracer, returning the raw result and the post-execution state.
+func runTracer(t *testing.T, test *callTracerTest, name string, cfg json.RawMessage) (json.RawMessage, *state.StateDB, common.Address) {
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(common.FromHex(test.Input), tx); err != nil {
+		t.Fatalf("failed to parse testcase input: %v", err)
+	}
+	var (
+		signer    = types.MakeSigner(test.Genesis.Config, new(big.Int).SetUint64(uint64(test.Context.Number)))
+		origin, _ = signer.Sender(tx)
+		txContext = vm.TxContext{
+			Origin:   origin,
+			GasPrice: tx.GasPrice(),
+		}
+		context = vm.BlockContext{
+			CanTransfer: core.CanTransfer,
+			Transfer:    core.Transfer,
+			Coinbase:    test.Context.Miner,
+			BlockNumber: new(big.Int).SetUint64(uint64(test.Context.Number)),
+			Time:        new(big.Int).SetUint64(uint64(test.Context.Time)),
+			Difficulty:  (*big.Int)(test.Context.Difficulty),
+			GasLimit:    uint64(test.Context.GasLimit),
+		}
+		_, statedb = tests.MakePreState(rawdb.NewMemoryDatabase(), test.Genesis.Alloc, false)
+	)
+	tracer, err := tracers.New(name, &tracers.Context{TxHash: tx.Hash()}, cfg)
+	if err != nil {
+		t.Fatalf("failed to create %s: %v", name, err)
+	}
+	evm := vm.NewEVM(context, txContext, statedb, test.Genesis.Config, vm.Config{Debug: true, Tracer: tracer})
+	msg, err := tx.AsMessage(signer, nil)
+	if err != nil {
+		t.Fatalf("failed to prepare transaction for tracing: %v", err)
+	}
+	if _, err = core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(tx.Gas())); err != nil {
+		t.Fatalf("failed to execute transaction: %v", err)
+	}
+	res, err := tracer.GetResult()
+	if err != nil {
+		t.Fatalf("failed to retrieve trace result: %v", err)
+	}
+	return res, statedb, origin
+}
+
+// checkPrestate verifies that the traced accounts match the genesis allocation
+// the transaction was executed on.
+func checkPrestate(t *testing.T, alloc core.GenesisAlloc, accounts map[common.Address]*prestateAccount) {
+	for addr, have := range accounts {
+		want := alloc[addr]
+		balance := want.Balance
+		if balance == nil {
+			balance = new(big.Int)
+		}
+		if have.Balance != nil && have.Balance.ToInt().Cmp(balance) != 0 {
+			t.Errorf("account %x: balance mismatch: have %v, want %v", addr, have.Balance, balance)
+		}
+		if have.Nonce != want.Nonce {
+			t.Errorf("account %x: nonce mismatch: have %d, want %d", addr, have.Nonce, want.Nonce)
+		}
+		if len(have.Code) > 0 && !bytes.Equal(have.Code, want.Code) {
+			t.Errorf("account %x: code mismatch", addr)
+		}
+		for key, val := range have.Storage {
+			if want.Storage[key] != val {
+				t.Errorf("account %x: slot %x mismatch: have %x, want %x", addr, key, val, want.Storage[key])
+			}
+		}
+	}
+}
+
+// Iterates over all the input-output datasets of the call tracer and checks the
+// native prestate tracer against their genesis allocations and execution.
+func TestPrestateTracer(t *testing.T) {
+	files, err := ioutil.ReadDir(filepath.Join("testdata", "call_tracer"))
+	if err != nil {
+		t.Fatalf("failed to retrieve tracer test suite: %v", err)
+	}
+	for _, file := range files {
+		if !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		file := file // capture range variable
+		t.Run(camel(strings.TrimSuffix(file.Name(), ".json")), func(t *testing.T) {
+			t.Parallel()
+
+			test := new(callTracerTest)
+			if blob, err := ioutil.ReadFile(filepath.Join("testdata", "call_tracer", file.Name())); err != nil {
+				t.Fatalf("failed to read testcase: %v", err)
+			} else if err := json.Unmarshal(blob, test); err != nil {
+				t.Fatalf("failed to parse testcase: %v", err)
+			}
+			// The plain prestate has to match the genesis allocation
+			res, _, origin := runTracer(t, test, "prestateTracer", nil)
+			var pre map[common.Address]*prestateAccount
+			if err := json.Unmarshal(res, &pre); err != nil {
+				t.Fatalf("failed to unmarshal trace result: %v", err)
+			}
+			if pre[origin] == nil || pre[origin].Balance == nil {
+				t.Fatalf("sender missing from prestate")
+			}
+			checkPrestate(t, test.Genesis.Alloc, pre)
+
+			// The diff has to match the genesis allocation and the post state
+			res, statedb, origin := runTracer(t, test, "prestateTracer", json.RawMessage(`{"diffMode": true}`))
+			var diff prestateDiff
+			if err := json.Unmarshal(res, &diff); err != nil {
+				t.Fatalf("failed to unmarshal trace result: %v", err)
+			}
+			checkPrestate(t, test.Genesis.Alloc, diff.Pre)
+			if diff.Post[origin] == nil || diff.Post[origin].Nonce != diff.Pre[origin].Nonce+1 {
+				t.Fatalf("sender nonce bump missing from diff")
+			}
+			for addr, post := range diff.Post {
+				if post.Balance != nil && post.Balance.ToInt().Cmp(statedb.GetBalance(addr)) != 0 {
+					t.Errorf("account %x: post balance mismatch: have %v, want %v", addr, post.Balance, statedb.GetBalance(addr))
+				}
+				if post.Nonce != 0 && post.Nonce != statedb.GetNonce(addr) {
+					t.Errorf("account %x: post nonce mismatch: have %d, want %d", addr, post.Nonce, statedb.GetNonce(addr))
+				}
+				if len(post.Code) > 0 && !bytes.Equal(post.Code, statedb.GetCode(addr)) {
+					t.Errorf("account %x: post code mismatch", addr)
+				}
+				for key, val := range post.Storage {
+					if have := statedb.GetState(addr, key); have != val {
+						t.Errorf("account %x: post slot %x mismatch: have %x, want %x", addr, key, val, have)
+					}
+				}
+			}
+		})
+	}
+}
@@ -57,7 +57,13 @@ func init() {
 		name := camel(strings.TrimSuffix(file, ".js"))
 		assetTracers[name] = string(tracers.MustAsset(file))
 	}
-	tracers2.RegisterLookup(true, newJsTracer)
+	tracers2.RegisterLookup(true, lookup)
+}
+
+// lookup constructs a JavaScript tracer. The JavaScript tracers don't take any
+// configuration, so the config of the request is ignored.
+func lookup(code string, ctx *tracers2.Context, _ json.RawMessage) (tracers2.Tracer, error) {
+	return newJsTracer(code, ctx)
 }
 
 // makeSlice convert an unsafe memory pointer with the given type into a Go byte
@@ -826,6 +832,10 @@ func (jst *jsTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
 	}
 }
 
+func (jst *jsTracer) CaptureTxStart(gasLimit uint64) {}
+
+func (jst *jsTracer) CaptureTxEnd(restGas uint64) {}
+
 // GetResult calls the Javascript 'result' function and returns its value, or any accumulated error
 func (jst *jsTracer) GetResult() (json.RawMessage, error) {
 	// Transform the context into a JavaScript object and inject into the state
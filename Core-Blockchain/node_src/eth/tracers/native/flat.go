@@ -0,0 +1,310 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package native
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+)
+
+func init() {
+	register("flatCallTracer", newFlatCallTracer)
+}
+
+// parityErrors maps the evm execution errors to their parity counterparts.
+var parityErrors = map[error]string{
+	vm.ErrExecutionReverted:        "Reverted",
+	vm.ErrOutOfGas:                 "Out of gas",
+	vm.ErrCodeStoreOutOfGas:        "Out of gas",
+	vm.ErrDepth:                    "Out of stack",
+	vm.ErrInsufficientBalance:      "Insufficient balance",
+	vm.ErrContractAddressCollision: "Contract address collision",
+	vm.ErrInvalidJump:              "Bad jump destination",
+	vm.ErrWriteProtection:          "Mutable call in static context",
+	vm.ErrReturnDataOutOfBounds:    "Out of bounds",
+	vm.ErrMaxCodeSizeExceeded:      "Out of gas",
+}
+
+// parityError converts an evm execution error to its parity representation,
+// falling back to the evm message for the unknown ones.
+func parityError(err error) string {
+	if msg, ok := parityErrors[err]; ok {
+		return msg
+	}
+	var (
+		underflow *vm.ErrStackUnderflow
+		overflow  *vm.ErrStackOverflow
+		invalid   *vm.ErrInvalidOpCode
+	)
+	switch {
+	case errors.As(err, &underflow):
+		return "Stack underflow"
+	case errors.As(err, &overflow):
+		return "Out of stack"
+	case errors.As(err, &invalid):
+		return "Bad instruction"
+	}
+	return err.Error()
+}
+
+// flatFrame is a call frame of the tree assembled by the flat call tracer.
+type flatFrame struct {
+	typ     vm.OpCode
+	from    common.Address
+	to      common.Address
+	input   []byte
+	gas     uint64
+	value   *big.Int
+	gasUsed uint64
+	output  []byte
+	err     error
+	skip    bool // Precompile invocations are left out of the traces
+	calls   []*flatFrame
+}
+
+type flatCallTracerConfig struct {
+	IncludePrecompiles bool `json:"includePrecompiles"` // If true, calls to precompiles are traced too
+}
+
+// flatCallTracer collects the call frames of a transaction and reports them as
+// a flat, parity-style list, each frame addressed by its path in the call tree.
+//
+// Example:
+//
+//	> debug.traceTransaction("0x...", {tracer: "flatCallTracer"})
+//	[{
+//	  action: {callType: "call", from: "0x...", gas: "0x...", input: "0x...", to: "0x...", value: "0x0"},
+//	  blockHash: "0x...", blockNumber: 1, result: {gasUsed: "0x...", output: "0x"},
+//	  subtraces: 0, traceAddress: [], transactionHash: "0x...", transactionPosition: 0, type: "call"
+//	}]
+type flatCallTracer struct {
+	env               *vm.EVM
+	ctx               *tracers.Context
+	config            flatCallTracerConfig
+	callstack         []*flatFrame
+	activePrecompiles []common.Address // Updated on CaptureStart based on given rules
+	interrupt         uint32           // Atomic flag to signal execution interruption
+	reason            error            // Textual reason for the interruption
+}
+
+// newFlatCallTracer returns a native go tracer which tracks the call frames of
+// a tx in the parity trace format, and implements vm.EVMLogger.
+func newFlatCallTracer(ctx *tracers.Context, cfg json.RawMessage) (tracers.Tracer, error) {
+	var config flatCallTracerConfig
+	if cfg != nil {
+		if err := json.Unmarshal(cfg, &config); err != nil {
+			return nil, err
+		}
+	}
+	if ctx == nil {
+		ctx = new(tracers.Context)
+	}
+	return &flatCallTracer{ctx: ctx, config: config}, nil
+}
+
+// isPrecompiled returns whether the addr is a precompile.
+func (t *flatCallTracer) isPrecompiled(addr common.Address) bool {
+	for _, p := range t.activePrecompiles {
+		if p == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// CaptureStart implements the EVMLogger interface to initialize the tracing operation.
+func (t *flatCallTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	t.env = env
+
+	rules := env.ChainConfig().Rules(env.Context.BlockNumber)
+	t.activePrecompiles = vm.ActivePrecompiles(rules)
+
+	frame := &flatFrame{
+		typ:   vm.CALL,
+		from:  from,
+		to:    to,
+		input: common.CopyBytes(input),
+		gas:   gas,
+		value: new(big.Int).Set(value),
+	}
+	if create {
+		frame.typ = vm.CREATE
+	}
+	t.callstack = []*flatFrame{frame}
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *flatCallTracer) CaptureEnd(output []byte, gasUsed uint64, _ time.Duration, err error) {
+	if len(t.callstack) == 0 {
+		return
+	}
+	t.callstack[0].gasUsed = gasUsed
+	t.callstack[0].output = common.CopyBytes(output)
+	t.callstack[0].err = err
+}
+
+// CaptureState implements the EVMLogger interface to trace a single step of VM execution.
+func (t *flatCallTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+}
+
+// CaptureFault implements the EVMLogger interface to trace an execution fault.
+func (t *flatCallTracer) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, _ *vm.ScopeContext, depth int, err error) {
+}
+
+// CaptureEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *flatCallTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	// Skip if tracing was interrupted
+	if atomic.LoadUint32(&t.interrupt) > 0 {
+		t.env.Cancel()
+		return
+	}
+	frame := &flatFrame{
+		typ:   typ,
+		from:  from,
+		to:    to,
+		input: common.CopyBytes(input),
+		gas:   gas,
+		skip:  !t.config.IncludePrecompiles && typ != vm.SELFDESTRUCT && t.isPrecompiled(to),
+	}
+	switch {
+	case value != nil:
+		frame.value = new(big.Int).Set(value)
+	case typ == vm.DELEGATECALL && len(t.callstack) > 0:
+		// Delegate calls carry on with the value of the caller
+		frame.value = t.callstack[len(t.callstack)-1].value
+	default:
+		frame.value = new(big.Int)
+	}
+	t.callstack = append(t.callstack, frame)
+}
+
+// CaptureExit is called when EVM exits a scope, even if the scope didn't
+// execute any code.
+func (t *flatCallTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	size := len(t.callstack)
+	if size <= 1 {
+		return
+	}
+	frame := t.callstack[size-1]
+	t.callstack = t.callstack[:size-1]
+
+	frame.gasUsed = gasUsed
+	frame.output = common.CopyBytes(output)
+	frame.err = err
+	if !frame.skip {
+		parent := t.callstack[size-2]
+		parent.calls = append(parent.calls, frame)
+	}
+}
+
+func (*flatCallTracer) CaptureTxStart(gasLimit uint64) {}
+
+func (*flatCallTracer) CaptureTxEnd(restGas uint64) {}
+
+// GetResult returns the json-encoded flat list of call traces, and any error
+// arising from the encoding or forceful termination (via `Stop`).
+func (t *flatCallTracer) GetResult() (json.RawMessage, error) {
+	traces := make([]*tracers.FlatTrace, 0)
+	if len(t.callstack) > 0 {
+		traces = t.flatten(traces, t.callstack[0], []int{})
+	}
+	res, err := json.Marshal(traces)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(res), t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *flatCallTracer) Stop(err error) {
+	t.reason = err
+	atomic.StoreUint32(&t.interrupt, 1)
+}
+
+// flatten appends the trace of the frame and, depth first, the traces of all
+// its sub-calls to the list.
+func (t *flatCallTracer) flatten(traces []*tracers.FlatTrace, frame *flatFrame, address []int) []*tracers.FlatTrace {
+	trace := &tracers.FlatTrace{
+		Subtraces:    len(frame.calls),
+		TraceAddress: address,
+	}
+	if t.env != nil {
+		trace.BlockNumber = t.env.Context.BlockNumber.Uint64()
+	}
+	if t.ctx.BlockHash != (common.Hash{}) {
+		hash := t.ctx.BlockHash
+		trace.BlockHash = &hash
+	}
+	if t.ctx.TxHash != (common.Hash{}) {
+		hash, position := t.ctx.TxHash, uint64(t.ctx.TxIndex)
+		trace.TransactionHash, trace.TransactionPosition = &hash, &position
+	}
+	var (
+		from  = frame.from
+		to    = frame.to
+		gas   = hexutil.Uint64(frame.gas)
+		value = (*hexutil.Big)(frame.value)
+	)
+	switch frame.typ {
+	case vm.CREATE, vm.CREATE2:
+		init := hexutil.Bytes(frame.input)
+		trace.Type = "create"
+		trace.Action = tracers.FlatTraceAction{From: &from, Gas: &gas, Init: &init, Value: value}
+		if frame.err == nil {
+			code := hexutil.Bytes(frame.output)
+			trace.Result = &tracers.FlatTraceResult{Address: &to, Code: &code, GasUsed: hexutil.Uint64(frame.gasUsed)}
+		}
+
+	case vm.SELFDESTRUCT:
+		trace.Type = "suicide"
+		trace.Action = tracers.FlatTraceAction{Address: &from, Balance: value, RefundAddress: &to}
+
+	default:
+		input := hexutil.Bytes(frame.input)
+		trace.Type = "call"
+		trace.Action = tracers.FlatTraceAction{CallType: callType(frame.typ), From: &from, Gas: &gas, Input: &input, To: &to, Value: value}
+		if frame.err == nil {
+			output := hexutil.Bytes(frame.output)
+			trace.Result = &tracers.FlatTraceResult{GasUsed: hexutil.Uint64(frame.gasUsed), Output: &output}
+		}
+	}
+	if frame.err != nil {
+		trace.Error = parityError(frame.err)
+	}
+	traces = append(traces, trace)
+	for i, call := range frame.calls {
+		sub := make([]int, len(address)+1)
+		copy(sub, address)
+		sub[len(address)] = i
+		traces = t.flatten(traces, call, sub)
+	}
+	return traces
+}
+
+// callType returns the parity name of a call opcode.
+func callType(op vm.OpCode) string {
+	switch op {
+	case vm.DELEGATECALL:
+		return "delegatecall"
+	case vm.STATICCALL:
+		return "staticcall"
+	case vm.CALLCODE:
+		return "callcode"
+	default:
+		return "call"
+	}
+}
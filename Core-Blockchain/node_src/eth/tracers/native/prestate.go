@@ -0,0 +1,339 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package native
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+)
+
+func init() {
+	register("prestateTracer", newPrestateTracer)
+}
+
+type state = map[common.Address]*account
+
+// account is the state of an account touched by a transaction.
+type account struct {
+	Balance *big.Int
+	Code    []byte
+	Nonce   uint64
+	Storage map[common.Hash]common.Hash
+}
+
+// exists reports whether the account has any state at all.
+func (a *account) exists() bool {
+	return a.Nonce > 0 || len(a.Code) > 0 || len(a.Storage) > 0 || (a.Balance != nil && a.Balance.Sign() != 0)
+}
+
+// MarshalJSON encodes the account, leaving out the unset fields.
+func (a *account) MarshalJSON() ([]byte, error) {
+	type account struct {
+		Balance *hexutil.Big                `json:"balance,omitempty"`
+		Code    hexutil.Bytes               `json:"code,omitempty"`
+		Nonce   uint64                      `json:"nonce,omitempty"`
+		Storage map[common.Hash]common.Hash `json:"storage,omitempty"`
+	}
+	return json.Marshal(&account{
+		Balance: (*hexutil.Big)(a.Balance),
+		Code:    a.Code,
+		Nonce:   a.Nonce,
+		Storage: a.Storage,
+	})
+}
+
+type prestateTracerConfig struct {
+	DiffMode bool `json:"diffMode"` // If true, this tracer will return state modifications
+}
+
+// prestateTracer collects the state of every account a transaction touches
+// before its execution, or in diff mode, the state before and after execution
+// of the modified accounts only.
+//
+// Example:
+//
+//	> debug.traceTransaction("0x...", {tracer: "prestateTracer", tracerConfig: {diffMode: true}})
+//	{
+//	  pre:  {"0x...": {balance: "0x1", nonce: 1, storage: {...}}},
+//	  post: {"0x...": {balance: "0x2", nonce: 2}}
+//	}
+type prestateTracer struct {
+	env       *vm.EVM
+	pre       state
+	post      state
+	create    bool
+	to        common.Address
+	inTx      bool   // Whether a transaction is traced, rather than a system call
+	gasLimit  uint64 // Gas bought by the transaction, to restore the sender balance
+	config    prestateTracerConfig
+	created   map[common.Address]bool
+	deleted   map[common.Address]bool
+	finalized bool   // Whether the post state was already collected
+	interrupt uint32 // Atomic flag to signal execution interruption
+	reason    error  // Textual reason for the interruption
+}
+
+// newPrestateTracer returns a native go tracer which collects the pre-state of
+// the accounts touched by a tx, and implements vm.EVMLogger.
+func newPrestateTracer(ctx *tracers.Context, cfg json.RawMessage) (tracers.Tracer, error) {
+	var config prestateTracerConfig
+	if cfg != nil {
+		if err := json.Unmarshal(cfg, &config); err != nil {
+			return nil, err
+		}
+	}
+	return &prestateTracer{
+		pre:     state{},
+		post:    state{},
+		config:  config,
+		created: make(map[common.Address]bool),
+		deleted: make(map[common.Address]bool),
+	}, nil
+}
+
+// CaptureStart implements the EVMLogger interface to initialize the tracing operation.
+func (t *prestateTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	t.env = env
+	t.create = create
+	t.to = to
+
+	t.lookupAccount(from)
+	t.lookupAccount(to)
+	t.lookupAccount(env.Context.Coinbase)
+	if env.ChainConfig().Congress != nil {
+		t.lookupAccount(consensus.FeeRecoder)
+	}
+	// The recipient balance already includes the value transferred.
+	t.pre[to].Balance = new(big.Int).Sub(t.pre[to].Balance, value)
+
+	// The sender already paid for the value. Transaction senders also paid for
+	// the gas and bumped their nonce, unlike the callers of system calls. Meta
+	// and gasless x402 transactions bill the gas differently, their payers are
+	// approximated by the regular rules.
+	t.pre[from].Balance = new(big.Int).Add(t.pre[from].Balance, value)
+	if t.inTx {
+		bought := new(big.Int).Mul(env.TxContext.GasPrice, new(big.Int).SetUint64(t.gasLimit))
+		t.pre[from].Balance.Add(t.pre[from].Balance, bought)
+		t.pre[from].Nonce--
+	}
+
+	if create {
+		// The created account was already initialized, its nonce had to be zero
+		t.pre[to].Nonce = 0
+		if t.config.DiffMode {
+			t.created[to] = true
+		}
+	}
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *prestateTracer) CaptureEnd(output []byte, gasUsed uint64, _ time.Duration, err error) {
+	if t.config.DiffMode {
+		return
+	}
+	if t.create {
+		// Keep the existing account prior to contract creation at that address
+		if s := t.pre[t.to]; s != nil && !s.exists() {
+			delete(t.pre, t.to)
+		}
+	}
+}
+
+// CaptureState implements the EVMLogger interface to trace a single step of VM execution.
+func (t *prestateTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	// Skip if tracing was interrupted
+	if atomic.LoadUint32(&t.interrupt) > 0 {
+		t.env.Cancel()
+		return
+	}
+	var (
+		stack    = scope.Stack.Data()
+		stackLen = len(stack)
+		caller   = scope.Contract.Address()
+	)
+	switch {
+	case stackLen >= 1 && (op == vm.SLOAD || op == vm.SSTORE):
+		t.lookupStorage(caller, common.Hash(stack[stackLen-1].Bytes32()))
+
+	case stackLen >= 1 && (op == vm.EXTCODECOPY || op == vm.EXTCODEHASH || op == vm.EXTCODESIZE || op == vm.BALANCE || op == vm.SELFDESTRUCT):
+		t.lookupAccount(common.Address(stack[stackLen-1].Bytes20()))
+		if op == vm.SELFDESTRUCT {
+			t.deleted[caller] = true
+		}
+
+	case stackLen >= 5 && (op == vm.DELEGATECALL || op == vm.CALL || op == vm.STATICCALL || op == vm.CALLCODE):
+		t.lookupAccount(common.Address(stack[stackLen-2].Bytes20()))
+
+	case op == vm.CREATE:
+		addr := crypto.CreateAddress(caller, t.env.StateDB.GetNonce(caller))
+		t.lookupAccount(addr)
+		t.created[addr] = true
+
+	case stackLen >= 4 && op == vm.CREATE2:
+		var (
+			offset = stack[stackLen-2]
+			size   = stack[stackLen-3]
+			salt   = stack[stackLen-4]
+		)
+		init := scope.Memory.GetCopy(int64(offset.Uint64()), int64(size.Uint64()))
+		addr := crypto.CreateAddress2(caller, salt.Bytes32(), crypto.Keccak256(init))
+		t.lookupAccount(addr)
+		t.created[addr] = true
+	}
+}
+
+// CaptureFault implements the EVMLogger interface to trace an execution fault.
+func (t *prestateTracer) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, _ *vm.ScopeContext, depth int, err error) {
+}
+
+// CaptureEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *prestateTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+}
+
+// CaptureExit is called when EVM exits a scope, even if the scope didn't
+// execute any code.
+func (t *prestateTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+}
+
+// CaptureTxStart records the gas bought by the transaction.
+func (t *prestateTracer) CaptureTxStart(gasLimit uint64) {
+	t.inTx = true
+	t.gasLimit = gasLimit
+}
+
+// CaptureTxEnd collects the post state once the fees are settled.
+func (t *prestateTracer) CaptureTxEnd(restGas uint64) {
+	t.finalize()
+}
+
+// finalize collects the post state of the modified accounts in diff mode, and
+// drops the unmodified ones.
+func (t *prestateTracer) finalize() {
+	if !t.config.DiffMode || t.finalized || t.env == nil {
+		return
+	}
+	t.finalized = true
+
+	for addr, pre := range t.pre {
+		// The deleted account's state is pruned from post but kept in pre
+		if t.deleted[addr] {
+			continue
+		}
+		var (
+			modified bool
+			post     = &account{Storage: make(map[common.Hash]common.Hash)}
+			balance  = t.env.StateDB.GetBalance(addr)
+			nonce    = t.env.StateDB.GetNonce(addr)
+			code     = t.env.StateDB.GetCode(addr)
+		)
+		if balance.Cmp(pre.Balance) != 0 {
+			modified = true
+			post.Balance = new(big.Int).Set(balance)
+		}
+		if nonce != pre.Nonce {
+			modified = true
+			post.Nonce = nonce
+		}
+		if !bytes.Equal(code, pre.Code) {
+			modified = true
+			post.Code = code
+		}
+		for key, val := range pre.Storage {
+			// Unmodified slots are left out of both states
+			newVal := t.env.StateDB.GetState(addr, key)
+			if val == newVal {
+				delete(pre.Storage, key)
+				continue
+			}
+			modified = true
+			if val == (common.Hash{}) {
+				delete(pre.Storage, key)
+			}
+			if newVal != (common.Hash{}) {
+				post.Storage[key] = newVal
+			}
+		}
+		if modified {
+			t.post[addr] = post
+		} else {
+			delete(t.pre, addr)
+		}
+	}
+	// The created contracts didn't exist before, unless they were funded
+	for addr := range t.created {
+		if s := t.pre[addr]; s != nil && !s.exists() {
+			delete(t.pre, addr)
+		}
+	}
+}
+
+// GetResult returns the json-encoded pre-state, or the pre and post states in
+// diff mode, and any error arising from the encoding or forceful termination
+// (via `Stop`).
+func (t *prestateTracer) GetResult() (json.RawMessage, error) {
+	var (
+		res []byte
+		err error
+	)
+	if t.config.DiffMode {
+		// System transactions run outside of a state transition
+		t.finalize()
+		res, err = json.Marshal(struct {
+			Post state `json:"post"`
+			Pre  state `json:"pre"`
+		}{t.post, t.pre})
+	} else {
+		res, err = json.Marshal(t.pre)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(res), t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *prestateTracer) Stop(err error) {
+	t.reason = err
+	atomic.StoreUint32(&t.interrupt, 1)
+}
+
+// lookupAccount fetches details of an account and adds it to the prestate
+// if it doesn't exist there yet.
+func (t *prestateTracer) lookupAccount(addr common.Address) {
+	if _, ok := t.pre[addr]; ok {
+		return
+	}
+	t.pre[addr] = &account{
+		Balance: t.env.StateDB.GetBalance(addr),
+		Nonce:   t.env.StateDB.GetNonce(addr),
+		Code:    t.env.StateDB.GetCode(addr),
+		Storage: make(map[common.Hash]common.Hash),
+	}
+}
+
+// lookupStorage fetches the requested storage slot and adds it to the prestate
+// of the given contract. It assumes that the account is already present in
+// the prestate.
+func (t *prestateTracer) lookupStorage(addr common.Address, key common.Hash) {
+	t.lookupAccount(addr)
+	if _, ok := t.pre[addr].Storage[key]; ok {
+		return
+	}
+	t.pre[addr].Storage[key] = t.env.StateDB.GetState(addr, key)
+}
@@ -0,0 +1,177 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package tracers
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+const (
+	// sysCallPreHandle is the phase of the system contract calls made before the
+	// transactions of a block, e.g. system contract upgrades.
+	sysCallPreHandle = "preHandle"
+
+	// sysCallFinalize is the phase of the system contract calls made after the
+	// transactions of a block, e.g. reward distribution and punishments.
+	sysCallFinalize = "finalize"
+)
+
+// sysCall describes a system contract call made by the consensus engine outside
+// of any transaction, along with its outcome.
+type sysCall struct {
+	Phase  string         `json:"phase"`
+	From   common.Address `json:"from"`
+	To     common.Address `json:"to"`
+	Input  hexutil.Bytes  `json:"input"`
+	Value  *hexutil.Big   `json:"value"`
+	Output hexutil.Bytes  `json:"output"`
+	Error  string         `json:"error,omitempty"`
+	Logs   []*types.Log   `json:"logs"`
+}
+
+// sysCallTracer traces the system contract calls of the consensus engine, each
+// with a new instance of the configured tracer.
+type sysCallTracer struct {
+	api     *API
+	ctx     context.Context
+	config  *TraceConfig
+	block   *types.Block
+	statedb *state.StateDB // State the calls of the current phase are executed on
+	phase   string         // Phase of the calls being traced
+	results []*txTraceResult
+
+	call   *sysCall     // System contract call being traced
+	tracer vm.EVMLogger // Tracer of the ongoing call
+	cancel func()       // Releases the timeout of the ongoing call
+	logs   int          // Number of logs recorded for the current transaction hash before the call
+}
+
+// newSysCallTracer creates a tracer of the system contract calls of the block.
+func (api *API) newSysCallTracer(ctx context.Context, block *types.Block, config *TraceConfig) *sysCallTracer {
+	return &sysCallTracer{api: api, ctx: ctx, config: config, block: block}
+}
+
+// chain returns a chain reader handing the system contract calls executed on
+// the given state to the tracer.
+func (t *sysCallTracer) chain(phase string, statedb *state.StateDB) consensus.ChainHeaderReader {
+	t.phase, t.statedb = phase, statedb
+	return &tracingChain{ChainHeaderReader: t.api.backend.ChainHeaderReader(), tracer: t}
+}
+
+// CaptureSysCall implements consensus.SysCallTracer, creating the tracer of the
+// system contract call.
+func (t *sysCallTracer) CaptureSysCall(from common.Address, to common.Address, input []byte, value *big.Int) vm.EVMLogger {
+	t.call = &sysCall{
+		Phase: t.phase,
+		From:  from,
+		To:    to,
+		Input: common.CopyBytes(input),
+		Value: (*hexutil.Big)(new(big.Int).Set(value)),
+		Logs:  []*types.Log{},
+	}
+	t.logs = len(t.statedb.GetLogs(t.statedb.TxHash(), t.block.Hash()))
+
+	txctx := &Context{BlockHash: t.block.Hash(), TxIndex: t.statedb.TxIndex()}
+	tracer, cancel, err := t.api.newTracer(t.ctx, txctx, t.config)
+	if err != nil {
+		t.results = append(t.results, &txTraceResult{Error: err.Error(), SysCall: t.call})
+		t.call = nil
+		return nil
+	}
+	t.tracer, t.cancel = tracer, cancel
+	return tracer
+}
+
+// CaptureSysCallEnd implements consensus.SysCallTracer, collecting the trace of
+// the system contract call.
+func (t *sysCallTracer) CaptureSysCallEnd(output []byte, err error) {
+	if t.call == nil {
+		return
+	}
+	defer t.cancel()
+
+	t.call.Output = common.CopyBytes(output)
+	if err != nil {
+		t.call.Error = err.Error()
+	}
+	if logs := t.statedb.GetLogs(t.statedb.TxHash(), t.block.Hash()); len(logs) > t.logs {
+		t.call.Logs = logs[t.logs:]
+	}
+	res, traceErr := t.api.traceResult(t.tracer, &core.ExecutionResult{Err: err, ReturnData: output})
+	if traceErr != nil {
+		t.results = append(t.results, &txTraceResult{Error: traceErr.Error(), SysCall: t.call})
+	} else {
+		t.results = append(t.results, &txTraceResult{Result: res, SysCall: t.call})
+	}
+	t.call, t.tracer = nil, nil
+}
+
+// collect returns the traces of the system contract calls captured so far.
+func (t *sysCallTracer) collect() []*txTraceResult {
+	results := t.results
+	t.results = nil
+	return results
+}
+
+// tracingChain is a chain reader handing the system contract calls of the
+// consensus engine to a tracer.
+type tracingChain struct {
+	consensus.ChainHeaderReader
+	tracer *sysCallTracer
+}
+
+// SysCallTracer implements consensus.SysCallTracing.
+func (c *tracingChain) SysCallTracer() consensus.SysCallTracer {
+	return c.tracer
+}
+
+// newTracer creates the tracer requested by the configuration, or the struct
+// logger by default. The returned function releases the timeout of the tracer.
+func (api *API) newTracer(ctx context.Context, txctx *Context, config *TraceConfig) (vm.EVMLogger, func(), error) {
+	switch {
+	case config == nil:
+		return vm.NewStructLogger(nil), func() {}, nil
+
+	case config.Tracer != nil:
+		// Define a meaningful timeout of a single call trace
+		timeout := defaultTraceTimeout
+		if config.Timeout != nil {
+			var err error
+			if timeout, err = time.ParseDuration(*config.Timeout); err != nil {
+				return nil, nil, err
+			}
+		}
+		tracer, err := New(*config.Tracer, txctx, config.TracerConfig)
+		if err != nil {
+			return nil, nil, err
+		}
+		deadlineCtx, cancel := context.WithTimeout(ctx, timeout)
+		go func() {
+			<-deadlineCtx.Done()
+			if errors.Is(deadlineCtx.Err(), context.DeadlineExceeded) {
+				tracer.Stop(errors.New("execution timeout"))
+			}
+		}()
+		return tracer, cancel, nil
+
+	default:
+		return vm.NewStructLogger(config.LogConfig), func() {}, nil
+	}
+}
@@ -0,0 +1,541 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package tracers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// flatTracer is the native tracer producing the parity-style call traces.
+	flatTracer = "flatCallTracer"
+
+	// diffTracer is the native tracer producing the state modifications.
+	diffTracer = "prestateTracer"
+
+	// traceFilterRange is the maximum number of blocks trace_filter is willing
+	// to re-execute without the help of the trace index.
+	traceFilterRange = 1000
+)
+
+// TraceAPI is the collection of parity-style tracing APIs, reporting the call
+// frames of transactions as flat lists.
+type TraceAPI struct {
+	api *API
+}
+
+// NewTraceAPI creates a new API definition for the parity-style tracing methods
+// of the Ethereum service.
+func NewTraceAPI(backend Backend) *TraceAPI {
+	return &TraceAPI{api: NewAPI(backend)}
+}
+
+// TraceResults is the outcome of replaying a transaction with the requested
+// trace types.
+type TraceResults struct {
+	Output          hexutil.Bytes                   `json:"output"`
+	StateDiff       map[common.Address]*AccountDiff `json:"stateDiff"`
+	Trace           []*FlatTrace                    `json:"trace"`
+	TransactionHash common.Hash                     `json:"transactionHash"`
+	VmTrace         interface{}                     `json:"vmTrace"`
+}
+
+// AccountDiff is the parity representation of the modifications of an account.
+// Each field is either "=" if unchanged, or an object keyed by "+" for created,
+// "-" for deleted and "*" for modified values.
+type AccountDiff struct {
+	Balance interface{}                 `json:"balance"`
+	Code    interface{}                 `json:"code"`
+	Nonce   interface{}                 `json:"nonce"`
+	Storage map[common.Hash]interface{} `json:"storage"`
+}
+
+// TraceFilterArgs are the criteria of trace_filter. Traces match if they
+// originate from any of the from addresses and are directed to any of the to
+// addresses, an empty list matching any account.
+type TraceFilterArgs struct {
+	FromBlock   *rpc.BlockNumber `json:"fromBlock"`
+	ToBlock     *rpc.BlockNumber `json:"toBlock"`
+	FromAddress []common.Address `json:"fromAddress"`
+	ToAddress   []common.Address `json:"toAddress"`
+	After       *uint64          `json:"after"`
+	Count       *uint64          `json:"count"`
+}
+
+// Block returns the flat call traces of all the transactions in a block,
+// followed by the block reward.
+func (api *TraceAPI) Block(ctx context.Context, number rpc.BlockNumber) ([]*FlatTrace, error) {
+	block, err := api.api.blockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	return api.blockTraces(ctx, block)
+}
+
+// Transaction returns the flat call traces of a transaction.
+func (api *TraceAPI) Transaction(ctx context.Context, hash common.Hash) ([]*FlatTrace, error) {
+	tx, blockHash, blockNumber, index, err := api.api.backend.GetTransaction(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if tx == nil {
+		return nil, fmt.Errorf("transaction %#x not found", hash)
+	}
+	block, err := api.api.blockByNumberAndHash(ctx, rpc.BlockNumber(blockNumber), blockHash)
+	if err != nil {
+		return nil, err
+	}
+	tracer := flatTracer
+	res, err := api.api.TraceTransaction(ctx, hash, &TraceConfig{Tracer: &tracer})
+	if err != nil {
+		return nil, err
+	}
+	var traces []*FlatTrace
+	if err := json.Unmarshal(res.(json.RawMessage), &traces); err != nil {
+		return nil, err
+	}
+	if api.api.isPoSA {
+		sender, err := types.Sender(types.MakeSigner(api.api.backend.ChainConfig(), block.Number()), tx)
+		if err != nil {
+			return nil, err
+		}
+		if sys, _ := api.api.posa.IsSysTransaction(sender, tx, block.Header()); sys {
+			traces = systemTraces(block, int(index), sender, traces)
+		}
+	}
+	return traces, nil
+}
+
+// ReplayBlockTransactions replays all the transactions of a block, returning
+// the requested trace types for each. The supported types are "trace" and
+// "stateDiff".
+func (api *TraceAPI) ReplayBlockTransactions(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash, traceTypes []string) ([]*TraceResults, error) {
+	var wantTrace, wantDiff bool
+	for _, typ := range traceTypes {
+		switch typ {
+		case "trace":
+			wantTrace = true
+		case "stateDiff":
+			wantDiff = true
+		case "vmTrace":
+			return nil, errors.New("vmTrace is not supported")
+		default:
+			return nil, fmt.Errorf("unknown trace type %q", typ)
+		}
+	}
+	var (
+		block *types.Block
+		err   error
+	)
+	if hash, ok := blockNrOrHash.Hash(); ok {
+		block, err = api.api.blockByHash(ctx, hash)
+	} else if number, ok := blockNrOrHash.Number(); ok {
+		block, err = api.api.blockByNumber(ctx, number)
+	} else {
+		return nil, errors.New("invalid arguments; neither block nor hash specified")
+	}
+	if err != nil {
+		return nil, err
+	}
+	// The call traces are needed for the outputs even if not requested
+	tracer := flatTracer
+	results, err := api.api.traceBlock(ctx, block, &TraceConfig{Tracer: &tracer})
+	if err != nil {
+		return nil, err
+	}
+	replays := make([]*TraceResults, len(results))
+	for i, res := range results {
+		traces, err := api.txTraces(block, i, res)
+		if err != nil {
+			return nil, err
+		}
+		replays[i] = &TraceResults{
+			Trace:           []*FlatTrace{},
+			TransactionHash: block.Transactions()[i].Hash(),
+		}
+		if len(traces) > 0 && traces[0].Result != nil {
+			if out := traces[0].Result.Output; out != nil {
+				replays[i].Output = *out
+			} else if code := traces[0].Result.Code; code != nil {
+				replays[i].Output = *code
+			}
+		}
+		if wantTrace {
+			replays[i].Trace = traces
+		}
+	}
+	if wantDiff {
+		tracer, config := diffTracer, json.RawMessage(`{"diffMode": true}`)
+		results, err := api.api.traceBlock(ctx, block, &TraceConfig{Tracer: &tracer, TracerConfig: config})
+		if err != nil {
+			return nil, err
+		}
+		for i, res := range results {
+			if res.Error != "" {
+				return nil, fmt.Errorf("failed to trace transaction %#x: %s", replays[i].TransactionHash, res.Error)
+			}
+			var diff struct {
+				Pre  map[common.Address]*diffAccount `json:"pre"`
+				Post map[common.Address]*diffAccount `json:"post"`
+			}
+			if err := json.Unmarshal(res.Result.(json.RawMessage), &diff); err != nil {
+				return nil, err
+			}
+			replays[i].StateDiff = stateDiff(diff.Pre, diff.Post)
+		}
+	}
+	return replays, nil
+}
+
+// Filter returns the flat call traces matching the given criteria. Without the
+// trace index, or for the blocks it doesn't cover, the number of blocks to
+// re-execute is limited.
+func (api *TraceAPI) Filter(ctx context.Context, args TraceFilterArgs) ([]*FlatTrace, error) {
+	from, err := api.resolveNumber(ctx, args.FromBlock)
+	if err != nil {
+		return nil, err
+	}
+	to, err := api.resolveNumber(ctx, args.ToBlock)
+	if err != nil {
+		return nil, err
+	}
+	if from > to {
+		return nil, fmt.Errorf("invalid block range %d - %d", from, to)
+	}
+	// The genesis block has no transactions to trace
+	if from == 0 {
+		from = 1
+	}
+	numbers, err := api.filterBlocks(from, to, args)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		traces  = []*FlatTrace{}
+		skipped uint64
+	)
+	for _, number := range numbers {
+		block, err := api.api.blockByNumber(ctx, rpc.BlockNumber(number))
+		if err != nil {
+			return nil, err
+		}
+		blockTraces, err := api.blockTraces(ctx, block)
+		if err != nil {
+			return nil, err
+		}
+		for _, trace := range blockTraces {
+			if !args.matches(trace) {
+				continue
+			}
+			if args.After != nil && skipped < *args.After {
+				skipped++
+				continue
+			}
+			traces = append(traces, trace)
+			if args.Count != nil && uint64(len(traces)) >= *args.Count {
+				return traces, nil
+			}
+		}
+	}
+	return traces, nil
+}
+
+// resolveNumber converts a block number argument of trace_filter to the number
+// of a block, defaulting to the latest one.
+func (api *TraceAPI) resolveNumber(ctx context.Context, number *rpc.BlockNumber) (uint64, error) {
+	if number != nil && *number >= 0 {
+		return uint64(*number), nil
+	}
+	if number != nil && *number == rpc.EarliestBlockNumber {
+		return 0, nil
+	}
+	header, err := api.api.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if err != nil {
+		return 0, err
+	}
+	if header == nil {
+		return 0, errors.New("latest header not found")
+	}
+	return header.Number.Uint64(), nil
+}
+
+// filterBlocks returns the numbers of the blocks in the [from, to] range which
+// may contain traces matching the filter, in ascending order. The blocks
+// covered by the trace index are narrowed down to the ones involving the
+// filtered addresses, the others are all returned.
+func (api *TraceAPI) filterBlocks(from uint64, to uint64, args TraceFilterArgs) ([]uint64, error) {
+	var (
+		db         = api.api.backend.ChainDb()
+		tail       = rawdb.ReadTraceIndexTail(db)
+		head, _    = rawdb.ReadTraceIndexHead(db)
+		addrs      = append(append([]common.Address{}, args.FromAddress...), args.ToAddress...)
+		start, end = to + 1, to // The indexed section of the range, empty by default
+	)
+	if tail != nil && head != nil && len(addrs) > 0 && *tail <= to && *head >= from && *tail <= *head {
+		start, end = *tail, *head
+		if start < from {
+			start = from
+		}
+		if end > to {
+			end = to
+		}
+	}
+	var unindexed uint64
+	if start > end {
+		unindexed = to - from + 1
+	} else {
+		unindexed = (start - from) + (to - end)
+	}
+	if unindexed > traceFilterRange {
+		return nil, fmt.Errorf("block range too large for trace filtering: %d blocks, maximum %d", unindexed, traceFilterRange)
+	}
+	var numbers []uint64
+	for n := from; n <= to && (start > end || n < start); n++ {
+		numbers = append(numbers, n)
+	}
+	if start <= end {
+		indexed := make(map[uint64]struct{})
+		for _, addr := range addrs {
+			for _, n := range rawdb.ReadTraceIndexBlocks(db, addr, start, end) {
+				indexed[n] = struct{}{}
+			}
+		}
+		for n := range indexed {
+			numbers = append(numbers, n)
+		}
+		for n := end + 1; n <= to; n++ {
+			numbers = append(numbers, n)
+		}
+	}
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i] < numbers[j] })
+	return numbers, nil
+}
+
+// matches reports whether the trace satisfies the address criteria.
+func (args *TraceFilterArgs) matches(trace *FlatTrace) bool {
+	from, to := trace.addresses()
+	return containsAny(args.FromAddress, from) && containsAny(args.ToAddress, to)
+}
+
+// containsAny reports whether any of the addresses is in the filter, an empty
+// filter containing all of them.
+func containsAny(filter []common.Address, addrs []common.Address) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	for _, want := range filter {
+		for _, addr := range addrs {
+			if addr == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// blockTraces returns the flat call traces of all the transactions in a block,
+// followed by the block reward.
+func (api *TraceAPI) blockTraces(ctx context.Context, block *types.Block) ([]*FlatTrace, error) {
+	tracer := flatTracer
+	results, statedb, err := api.api.traceBlockState(ctx, block, &TraceConfig{Tracer: &tracer})
+	if err != nil {
+		return nil, err
+	}
+	traces := []*FlatTrace{}
+	for i, res := range results {
+		txTraces, err := api.txTraces(block, i, res)
+		if err != nil {
+			return nil, err
+		}
+		traces = append(traces, txTraces...)
+	}
+	if reward := api.blockReward(block, statedb); reward != nil {
+		traces = append(traces, reward)
+	}
+	return traces, nil
+}
+
+// txTraces decodes the flat call traces of the transaction at the given index
+// of a block.
+func (api *TraceAPI) txTraces(block *types.Block, index int, res *txTraceResult) ([]*FlatTrace, error) {
+	tx := block.Transactions()[index]
+	if res.Error != "" {
+		return nil, fmt.Errorf("failed to trace transaction %#x: %s", tx.Hash(), res.Error)
+	}
+	var traces []*FlatTrace
+	if err := json.Unmarshal(res.Result.(json.RawMessage), &traces); err != nil {
+		return nil, err
+	}
+	if res.sysTx {
+		sender, err := types.Sender(types.MakeSigner(api.api.backend.ChainConfig(), block.Number()), tx)
+		if err != nil {
+			return nil, err
+		}
+		traces = systemTraces(block, index, sender, traces)
+	}
+	return traces, nil
+}
+
+// blockReward returns the trace of the fees collected by a Congress block, which
+// the validator deposits through distributeBlockReward when finalizing it. The
+// state is the one after applying all the transactions of the block.
+func (api *TraceAPI) blockReward(block *types.Block, statedb *state.StateDB) *FlatTrace {
+	if !api.api.isPoSA || statedb == nil {
+		return nil
+	}
+	fee := statedb.GetBalance(consensus.FeeRecoder)
+	if fee.Sign() <= 0 {
+		return nil
+	}
+	var (
+		hash     = block.Hash()
+		coinbase = block.Coinbase()
+	)
+	return &FlatTrace{
+		Action: FlatTraceAction{
+			Author:     &coinbase,
+			RewardType: "block",
+			Value:      (*hexutil.Big)(new(big.Int).Set(fee)),
+		},
+		BlockHash:    &hash,
+		BlockNumber:  block.NumberU64(),
+		TraceAddress: []int{},
+		Type:         "reward",
+	}
+}
+
+// systemTraces marks the traces of a governance system transaction with the
+// "system" call type. Proposals that don't execute any code are reported as a
+// bare call of the transaction itself.
+func systemTraces(block *types.Block, index int, sender common.Address, traces []*FlatTrace) []*FlatTrace {
+	if len(traces) == 0 {
+		var (
+			tx       = block.Transactions()[index]
+			hash     = block.Hash()
+			txHash   = tx.Hash()
+			position = uint64(index)
+			gas      = hexutil.Uint64(tx.Gas())
+			input    = hexutil.Bytes(tx.Data())
+			output   = hexutil.Bytes{}
+		)
+		traces = []*FlatTrace{{
+			Action: FlatTraceAction{
+				From:  &sender,
+				Gas:   &gas,
+				Input: &input,
+				To:    tx.To(),
+				Value: (*hexutil.Big)(tx.Value()),
+			},
+			BlockHash:           &hash,
+			BlockNumber:         block.NumberU64(),
+			Result:              &FlatTraceResult{Output: &output},
+			TraceAddress:        []int{},
+			TransactionHash:     &txHash,
+			TransactionPosition: &position,
+			Type:                "call",
+		}}
+	}
+	if traces[0].Type == "call" {
+		traces[0].Action.CallType = "system"
+	}
+	return traces
+}
+
+// diffAccount is an account of a prestateTracer result in diff mode.
+type diffAccount struct {
+	Balance *hexutil.Big                `json:"balance"`
+	Code    hexutil.Bytes               `json:"code"`
+	Nonce   uint64                      `json:"nonce"`
+	Storage map[common.Hash]common.Hash `json:"storage"`
+}
+
+// stateDiff converts the pre and post states of the modified accounts to the
+// parity representation. Accounts only in the pre state were deleted, the ones
+// only in the post state created, and the post state only holds the modified
+// fields of the others.
+func stateDiff(pre, post map[common.Address]*diffAccount) map[common.Address]*AccountDiff {
+	diffs := make(map[common.Address]*AccountDiff)
+	for addr, p := range pre {
+		q, ok := post[addr]
+		if !ok {
+			diffs[addr] = accountDiff("-", p)
+			continue
+		}
+		diff := &AccountDiff{Balance: "=", Code: "=", Nonce: "=", Storage: make(map[common.Hash]interface{})}
+		if q.Balance != nil {
+			diff.Balance = changed(bigOrZero(p.Balance), q.Balance)
+		}
+		if q.Nonce != 0 && q.Nonce != p.Nonce {
+			diff.Nonce = changed(hexutil.Uint64(p.Nonce), hexutil.Uint64(q.Nonce))
+		}
+		if len(q.Code) > 0 {
+			diff.Code = changed(p.Code, q.Code)
+		}
+		// Slots cleared by the transaction are left out of the post state
+		for key, val := range p.Storage {
+			diff.Storage[key] = changed(val, q.Storage[key])
+		}
+		for key, val := range q.Storage {
+			if _, ok := p.Storage[key]; !ok {
+				diff.Storage[key] = changed(common.Hash{}, val)
+			}
+		}
+		diffs[addr] = diff
+	}
+	for addr, q := range post {
+		if _, ok := pre[addr]; !ok {
+			diffs[addr] = accountDiff("+", q)
+		}
+	}
+	return diffs
+}
+
+// accountDiff returns the diff of a created ("+") or deleted ("-") account.
+func accountDiff(op string, a *diffAccount) *AccountDiff {
+	code := a.Code
+	if code == nil {
+		code = hexutil.Bytes{}
+	}
+	diff := &AccountDiff{
+		Balance: map[string]interface{}{op: bigOrZero(a.Balance)},
+		Code:    map[string]interface{}{op: code},
+		Nonce:   map[string]interface{}{op: hexutil.Uint64(a.Nonce)},
+		Storage: make(map[common.Hash]interface{}),
+	}
+	for key, val := range a.Storage {
+		diff.Storage[key] = map[string]interface{}{op: val}
+	}
+	return diff
+}
+
+// changed returns the diff of a modified value.
+func changed(from, to interface{}) interface{} {
+	return map[string]interface{}{"*": map[string]interface{}{"from": from, "to": to}}
+}
+
+// bigOrZero returns the given number, or zero if it's unset.
+func bigOrZero(n *hexutil.Big) *hexutil.Big {
+	if n == nil {
+		return new(hexutil.Big)
+	}
+	return n
+}
@@ -0,0 +1,174 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package tracers
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// IndexBackend is the chain access needed to maintain the trace index.
+type IndexBackend interface {
+	Backend
+	CurrentHeader() *types.Header
+	SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription
+}
+
+// TraceIndexer maintains the index of the accounts appearing in the call traces
+// of every block, narrowing down the blocks trace_filter has to re-execute.
+//
+// The index starts at the head of the chain when first enabled and follows it
+// from there on. Blocks replaced by a reorg are indexed again, their stale
+// entries only costing the filter an extra block to trace.
+type TraceIndexer struct {
+	backend IndexBackend
+	api     *TraceAPI
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewTraceIndexer creates a trace indexer following the chain of the backend.
+func NewTraceIndexer(backend IndexBackend) *TraceIndexer {
+	return &TraceIndexer{
+		backend: backend,
+		api:     NewTraceAPI(backend),
+		quit:    make(chan struct{}),
+	}
+}
+
+// Start implements node.Lifecycle, starting the indexing of the chain.
+func (i *TraceIndexer) Start() error {
+	i.wg.Add(1)
+	go i.loop()
+	return nil
+}
+
+// Stop implements node.Lifecycle, terminating the indexing of the chain.
+func (i *TraceIndexer) Stop() error {
+	close(i.quit)
+	i.wg.Wait()
+	return nil
+}
+
+// loop indexes the chain up to every new head.
+func (i *TraceIndexer) loop() {
+	defer i.wg.Done()
+
+	headCh := make(chan core.ChainHeadEvent, 10)
+	sub := i.backend.SubscribeChainHeadEvent(headCh)
+	defer sub.Unsubscribe()
+
+	i.index(i.backend.CurrentHeader())
+	for {
+		select {
+		case ev := <-headCh:
+			i.index(ev.Block.Header())
+		case <-sub.Err():
+			return
+		case <-i.quit:
+			return
+		}
+	}
+}
+
+// index traces the blocks between the last indexed one and the given head, and
+// stores the accounts appearing in them.
+func (i *TraceIndexer) index(head *types.Header) {
+	var (
+		db     = i.backend.ChainDb()
+		number = head.Number.Uint64()
+		from   uint64
+	)
+	if number == 0 {
+		return
+	}
+	if last, hash := rawdb.ReadTraceIndexHead(db); last == nil {
+		from = number
+		rawdb.WriteTraceIndexTail(db, from)
+		log.Info("Started trace index", "number", from)
+	} else {
+		from = i.ancestor(*last, hash) + 1
+		if tail := rawdb.ReadTraceIndexTail(db); tail == nil || from < *tail {
+			rawdb.WriteTraceIndexTail(db, from)
+		}
+	}
+	for n := from; n <= number; n++ {
+		select {
+		case <-i.quit:
+			return
+		default:
+		}
+		block, err := i.backend.BlockByNumber(context.Background(), rpc.BlockNumber(n))
+		if err != nil || block == nil {
+			log.Warn("Missing block for the trace index", "number", n, "err", err)
+			return
+		}
+		traces, err := i.api.blockTraces(context.Background(), block)
+		if err != nil {
+			// The state of older blocks may not be available anymore, restart
+			// the index from the head
+			log.Warn("Failed to trace block for the trace index, restarting it", "number", n, "err", err)
+			if n == number {
+				rawdb.WriteTraceIndexTail(db, n+1)
+				rawdb.WriteTraceIndexHead(db, n, block.Hash())
+				return
+			}
+			rawdb.WriteTraceIndexTail(db, number)
+			n = number - 1
+			continue
+		}
+		batch := db.NewBatch()
+		rawdb.WriteTraceIndexEntries(batch, n, traceAddresses(traces))
+		rawdb.WriteTraceIndexHead(batch, n, block.Hash())
+		if err := batch.Write(); err != nil {
+			log.Crit("Failed to write the trace index", "err", err)
+		}
+	}
+}
+
+// ancestor walks back the previously indexed chain from its head until it meets
+// the canonical chain, returning the number of the last block still valid.
+func (i *TraceIndexer) ancestor(number uint64, hash common.Hash) uint64 {
+	db := i.backend.ChainDb()
+	for number > 0 && rawdb.ReadCanonicalHash(db, number) != hash {
+		header := rawdb.ReadHeader(db, hash, number)
+		if header == nil {
+			break
+		}
+		number, hash = number-1, header.ParentHash
+	}
+	return number
+}
+
+// traceAddresses returns the distinct accounts appearing in the traces.
+func traceAddresses(traces []*FlatTrace) []common.Address {
+	var (
+		addrs []common.Address
+		seen  = make(map[common.Address]struct{})
+	)
+	for _, trace := range traces {
+		from, to := trace.addresses()
+		for _, addr := range append(from, to...) {
+			if _, ok := seen[addr]; !ok {
+				seen[addr] = struct{}{}
+				addrs = append(addrs, addr)
+			}
+		}
+	}
+	return addrs
+}
@@ -563,6 +563,17 @@ func (ec *Client) SendTransaction(ctx context.Context, tx *types.Transaction) er
 	return ec.c.CallContext(ctx, nil, "eth_sendRawTransaction", hexutil.Encode(data))
 }
 
+// SendTransactionConditional injects a signed transaction into the conditional
+// queue of the pending pool. The transaction only becomes executable while the
+// given conditions hold.
+func (ec *Client) SendTransactionConditional(ctx context.Context, tx *types.Transaction, conditions types.TxConditions) error {
+	data, err := tx.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return ec.c.CallContext(ctx, nil, "eth_sendRawTransactionConditional", hexutil.Encode(data), conditions)
+}
+
 func toBlockNumArg(number *big.Int) string {
 	if number == nil {
 		return "latest"
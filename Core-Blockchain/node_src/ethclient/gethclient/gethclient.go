@@ -81,10 +81,33 @@ type StorageResult struct {
 // GetProof returns the account and storage values of the specified account including the Merkle-proof.
 // The block number can be nil, in which case the value is taken from the latest known block.
 func (ec *Client) GetProof(ctx context.Context, account common.Address, keys []string, blockNumber *big.Int) (*AccountResult, error) {
-
 	var res accountResult
-	err := ec.c.CallContext(ctx, &res, "eth_getProof", account, keys, toBlockNumArg(blockNumber))
-	// Turn hexutils back to normal datatypes
+	if err := ec.c.CallContext(ctx, &res, "eth_getProof", account, keys, toBlockNumArg(blockNumber)); err != nil {
+		return nil, err
+	}
+	return res.toAccountResult(), nil
+}
+
+// storageResult is the JSON representation of a StorageResult.
+type storageResult struct {
+	Key   string       `json:"key"`
+	Value *hexutil.Big `json:"value"`
+	Proof []string     `json:"proof"`
+}
+
+// accountResult is the JSON representation of an AccountResult.
+type accountResult struct {
+	Address      common.Address  `json:"address"`
+	AccountProof []string        `json:"accountProof"`
+	Balance      *hexutil.Big    `json:"balance"`
+	CodeHash     common.Hash     `json:"codeHash"`
+	Nonce        hexutil.Uint64  `json:"nonce"`
+	StorageHash  common.Hash     `json:"storageHash"`
+	StorageProof []storageResult `json:"storageProof"`
+}
+
+// toAccountResult turns the hexutil types of the proof back into normal ones.
+func (res *accountResult) toAccountResult() *AccountResult {
 	storageResults := make([]StorageResult, 0, len(res.StorageProof))
 	for _, st := range res.StorageProof {
 		storageResults = append(storageResults, StorageResult{
@@ -93,16 +116,68 @@ func (ec *Client) GetProof(ctx context.Context, account common.Address, keys []s
 			Proof: st.Proof,
 		})
 	}
-	result := AccountResult{
+	return &AccountResult{
 		Address:      res.Address,
 		AccountProof: res.AccountProof,
 		Balance:      res.Balance.ToInt(),
 		Nonce:        uint64(res.Nonce),
 		CodeHash:     res.CodeHash,
 		StorageHash:  res.StorageHash,
-		StorageProof: storageResults,  // Fix: Populate StorageProof field
+		StorageProof: storageResults,
 	}
-	return &result, err
+}
+
+// ProofRequest is an account, and the storage keys of it, to prove with GetProofs.
+type ProofRequest struct {
+	Address     common.Address `json:"address"`
+	StorageKeys []string       `json:"storageKeys"`
+}
+
+// GetProofs returns the account and storage values of several accounts including
+// the Merkle-proofs, all taken from the state of the same block. The block number
+// can be nil, in which case the values are taken from the latest known block.
+func (ec *Client) GetProofs(ctx context.Context, requests []ProofRequest, blockNumber *big.Int) ([]*AccountResult, error) {
+	var res []*accountResult
+	if err := ec.c.CallContext(ctx, &res, "eth_getProofs", requests, toBlockNumArg(blockNumber)); err != nil {
+		return nil, err
+	}
+	results := make([]*AccountResult, len(res))
+	for i, account := range res {
+		results[i] = account.toAccountResult()
+	}
+	return results, nil
+}
+
+// InclusionProof is a Merkle-proof of the inclusion of a transaction or a receipt
+// in the corresponding trie of a block, along with the RLP encoded block header.
+type InclusionProof struct {
+	BlockHash   common.Hash     `json:"blockHash"`
+	BlockNumber hexutil.Uint64  `json:"blockNumber"`
+	Index       hexutil.Uint    `json:"index"`
+	Header      hexutil.Bytes   `json:"header"`
+	Root        common.Hash     `json:"root"`
+	Value       hexutil.Bytes   `json:"value"`
+	Proof       []hexutil.Bytes `json:"proof"`
+}
+
+// GetTransactionProof returns the proof of the inclusion of a transaction in
+// the transaction trie of its block, nil if the transaction is unknown.
+func (ec *Client) GetTransactionProof(ctx context.Context, hash common.Hash) (*InclusionProof, error) {
+	var proof *InclusionProof
+	if err := ec.c.CallContext(ctx, &proof, "eth_getTransactionProof", hash); err != nil {
+		return nil, err
+	}
+	return proof, nil
+}
+
+// GetReceiptProof returns the proof of the inclusion of the receipt of a
+// transaction in the receipt trie of its block, nil if the transaction is unknown.
+func (ec *Client) GetReceiptProof(ctx context.Context, hash common.Hash) (*InclusionProof, error) {
+	var proof *InclusionProof
+	if err := ec.c.CallContext(ctx, &proof, "eth_getReceiptProof", hash); err != nil {
+		return nil, err
+	}
+	return proof, nil
 }
 
 
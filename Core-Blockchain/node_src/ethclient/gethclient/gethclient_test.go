@@ -43,11 +43,19 @@ var (
 	testKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
 	testAddr    = crypto.PubkeyToAddress(testKey.PublicKey)
 	testBalance = big.NewInt(2e15)
+
+	testContract     = common.HexToAddress("0x1000000000000000000000000000000000000001")
+	testSenderKey, _ = crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+	testSender       = crypto.PubkeyToAddress(testSenderKey.PublicKey)
 )
 
 func newTestBackend(t *testing.T) (*node.Node, []*types.Block) {
 	// Generate test chain.
 	genesis, blocks := generateTestChain()
+	return startTestBackend(t, genesis, blocks)
+}
+
+func startTestBackend(t *testing.T, genesis *core.Genesis, blocks []*types.Block) (*node.Node, []*types.Block) {
 	// Create node
 	n, err := node.New(&node.Config{})
 	if err != nil {
@@ -90,6 +98,52 @@ func generateTestChain() (*core.Genesis, []*types.Block) {
 	return genesis, blocks
 }
 
+// generateProofChain creates a chain with a contract holding storage and a
+// transfer in its single block, to prove accounts, transactions and receipts.
+func generateProofChain() (*core.Genesis, []*types.Block) {
+	db := rawdb.NewMemoryDatabase()
+	config := params.AllEthashProtocolChanges
+	genesis := &core.Genesis{
+		Config: config,
+		Alloc: core.GenesisAlloc{
+			testAddr:   {Balance: testBalance},
+			testSender: {Balance: testBalance},
+			testContract: {
+				Balance: common.Big0,
+				Code:    []byte{0x00},
+				Storage: map[common.Hash]common.Hash{common.HexToHash("0x01"): common.HexToHash("0x02")},
+			},
+		},
+		ExtraData: []byte("test genesis"),
+		Timestamp: 9000,
+	}
+	generate := func(i int, g *core.BlockGen) {
+		g.OffsetTime(5)
+		g.SetExtra([]byte("test"))
+		tx, _ := types.SignTx(types.NewTransaction(g.TxNonce(testSender), common.Address{2}, big.NewInt(1), params.TxGas, g.BaseFee(), nil), types.LatestSigner(config), testSenderKey)
+		g.AddTx(tx)
+	}
+	gblock := genesis.ToBlock(db)
+	engine := ethash.NewFaker()
+	blocks, _ := core.GenerateChain(config, gblock, engine, db, 1, generate)
+	blocks = append([]*types.Block{gblock}, blocks...)
+	return genesis, blocks
+}
+
+func TestGethClientProofs(t *testing.T) {
+	genesis, blocks := generateProofChain()
+	backend, _ := startTestBackend(t, genesis, blocks)
+	client, err := backend.Attach()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer backend.Close()
+	defer client.Close()
+
+	t.Run("TestGetProofs", func(t *testing.T) { testGetProofs(t, client) })
+	t.Run("TestInclusionProofs", func(t *testing.T) { testInclusionProofs(t, client, blocks[1]) })
+}
+
 func TestGethClient(t *testing.T) {
 	backend, _ := newTestBackend(t)
 	client, err := backend.Attach()
@@ -138,12 +192,12 @@ func TestGethClient(t *testing.T) {
 
 func testAccessList(t *testing.T, client *rpc.Client) {
 	ec := New(client)
-	// Test transfer
+	// Test transfer, priced at the base fee floor the chain never goes below
 	msg := ethereum.CallMsg{
 		From:     testAddr,
 		To:       &common.Address{},
 		Gas:      21000,
-		GasPrice: big.NewInt(765625000),
+		GasPrice: big.NewInt(params.MinimumBaseFee),
 		Value:    big.NewInt(1),
 	}
 	al, gas, vmErr, err := ec.CreateAccessList(context.Background(), msg)
@@ -212,6 +266,88 @@ func testGetProof(t *testing.T, client *rpc.Client) {
 	}
 }
 
+func testGetProofs(t *testing.T, client *rpc.Client) {
+	ec := New(client)
+	head, err := ethclient.NewClient(client).HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	requests := []ProofRequest{
+		{Address: testAddr},
+		{Address: testContract, StorageKeys: []string{"0x01", "0x02"}},
+		{Address: common.Address{0xff}, StorageKeys: []string{"0x01"}},
+	}
+	results, err := ec.GetProofs(context.Background(), requests, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != len(requests) {
+		t.Fatalf("result count mismatch: have %d, want %d", len(results), len(requests))
+	}
+	for i, result := range results {
+		if result.Address != requests[i].Address {
+			t.Fatalf("result %d: address mismatch: have %x, want %x", i, result.Address, requests[i].Address)
+		}
+		if err := VerifyAccountProof(head.Root, result); err != nil {
+			t.Fatalf("result %d: proof rejected: %v", i, err)
+		}
+	}
+	if value := results[1].StorageProof[0].Value; value.Cmp(big.NewInt(2)) != 0 {
+		t.Fatalf("storage value mismatch: have %d, want 2", value)
+	}
+	// Forged values must be rejected
+	results[0].Balance.Add(results[0].Balance, big.NewInt(1))
+	if err := VerifyAccountProof(head.Root, results[0]); err == nil {
+		t.Fatal("forged balance accepted")
+	}
+	results[1].StorageProof[1].Value = big.NewInt(1)
+	if err := VerifyAccountProof(head.Root, results[1]); err == nil {
+		t.Fatal("forged storage value accepted")
+	}
+	results[2].Nonce = 1
+	if err := VerifyAccountProof(head.Root, results[2]); err == nil {
+		t.Fatal("forged absent account accepted")
+	}
+}
+
+func testInclusionProofs(t *testing.T, client *rpc.Client, block *types.Block) {
+	ec := New(client)
+	tx := block.Transactions()[0]
+
+	proof, err := ec.GetTransactionProof(context.Background(), tx.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	proven, header, err := VerifyTransactionProof(block.Hash(), proof)
+	if err != nil {
+		t.Fatalf("transaction proof rejected: %v", err)
+	}
+	if proven.Hash() != tx.Hash() || header.Number.Cmp(block.Number()) != 0 {
+		t.Fatalf("proven transaction mismatch: have %x in block %d", proven.Hash(), header.Number)
+	}
+	if _, _, err := VerifyTransactionProof(block.ParentHash(), proof); err == nil {
+		t.Fatal("transaction proof accepted for another block")
+	}
+	proof, err = ec.GetReceiptProof(context.Background(), tx.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	receipt, _, err := VerifyReceiptProof(block.Hash(), proof)
+	if err != nil {
+		t.Fatalf("receipt proof rejected: %v", err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful || receipt.CumulativeGasUsed != params.TxGas {
+		t.Fatalf("proven receipt mismatch: %+v", receipt)
+	}
+	proof.Value[len(proof.Value)-1] ^= 0x01
+	if _, _, err := VerifyReceiptProof(block.Hash(), proof); err == nil {
+		t.Fatal("forged receipt accepted")
+	}
+	if proof, err := ec.GetTransactionProof(context.Background(), common.Hash{1}); err != nil || proof != nil {
+		t.Fatalf("proof of an unknown transaction: %v, %v", proof, err)
+	}
+}
+
 func testGCStats(t *testing.T, client *rpc.Client) {
 	ec := New(client)
 	_, err := ec.GCStats(context.Background())
@@ -357,4 +493,4 @@ func TestOverrideAccountMarshal(t *testing.T) {
 		t.Error("wrong output:", string(marshalled))
 		t.Error("want:", expected)
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,197 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package gethclient
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// The verifiers below check proofs against roots or block hashes the caller
+// already trusts, e.g. the ones of headers finalized by a light client of the
+// chain. A proof passing verification is only as trustworthy as that root.
+
+// proofDB returns a database of the nodes of a Merkle-proof, keyed by their hash.
+func proofDB(nodes [][]byte) ethdb.KeyValueReader {
+	db := memorydb.New()
+	for _, node := range nodes {
+		db.Put(crypto.Keccak256(node), node)
+	}
+	return db
+}
+
+// decodeProof decodes the hex encoded nodes of a Merkle-proof.
+func decodeProof(proof []string) ([][]byte, error) {
+	nodes := make([][]byte, len(proof))
+	for i, node := range proof {
+		blob, err := hexutil.Decode(node)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proof node %d: %v", i, err)
+		}
+		nodes[i] = blob
+	}
+	return nodes, nil
+}
+
+// VerifyAccountProof checks the proof of an account, and the proofs of the
+// storage slots of it, against the state root of a trusted block.
+func VerifyAccountProof(stateRoot common.Hash, result *AccountResult) error {
+	nodes, err := decodeProof(result.AccountProof)
+	if err != nil {
+		return err
+	}
+	value, err := trie.VerifyProof(stateRoot, crypto.Keccak256(result.Address.Bytes()), proofDB(nodes))
+	if err != nil {
+		return fmt.Errorf("invalid account proof: %v", err)
+	}
+	balance := result.Balance
+	if balance == nil {
+		balance = new(big.Int)
+	}
+	if value == nil {
+		// The account doesn't exist, the proof is one of absence
+		if result.Nonce != 0 || balance.Sign() != 0 || result.CodeHash != crypto.Keccak256Hash(nil) || result.StorageHash != types.EmptyRootHash {
+			return errors.New("account absent from the state")
+		}
+	} else {
+		var account types.StateAccount
+		if err := rlp.DecodeBytes(value, &account); err != nil {
+			return fmt.Errorf("invalid account: %v", err)
+		}
+		switch {
+		case account.Nonce != result.Nonce:
+			return fmt.Errorf("nonce mismatch: proven %d, claimed %d", account.Nonce, result.Nonce)
+		case account.Balance.Cmp(balance) != 0:
+			return fmt.Errorf("balance mismatch: proven %d, claimed %d", account.Balance, balance)
+		case !bytes.Equal(account.CodeHash, result.CodeHash[:]):
+			return fmt.Errorf("code hash mismatch: proven %x, claimed %x", account.CodeHash, result.CodeHash)
+		case account.Root != result.StorageHash:
+			return fmt.Errorf("storage hash mismatch: proven %x, claimed %x", account.Root, result.StorageHash)
+		}
+	}
+	for _, slot := range result.StorageProof {
+		if err := verifyStorageProof(result.StorageHash, slot); err != nil {
+			return fmt.Errorf("storage slot %s: %v", slot.Key, err)
+		}
+	}
+	return nil
+}
+
+// verifyStorageProof checks the proof of a storage slot against the storage root
+// of its account.
+func verifyStorageProof(storageRoot common.Hash, slot StorageResult) error {
+	// Keys may be shorter than 32 bytes and lack the leading zero nibble
+	key := common.FromHex(slot.Key)
+	if len(key) > common.HashLength {
+		return errors.New("key too long")
+	}
+	claimed := slot.Value
+	if claimed == nil {
+		claimed = new(big.Int)
+	}
+	if storageRoot == types.EmptyRootHash && len(slot.Proof) == 0 {
+		if claimed.Sign() != 0 {
+			return errors.New("value of an empty storage")
+		}
+		return nil
+	}
+	nodes, err := decodeProof(slot.Proof)
+	if err != nil {
+		return err
+	}
+	value, err := trie.VerifyProof(storageRoot, crypto.Keccak256(common.BytesToHash(key).Bytes()), proofDB(nodes))
+	if err != nil {
+		return fmt.Errorf("invalid storage proof: %v", err)
+	}
+	proven := new(big.Int)
+	if value != nil {
+		_, content, _, err := rlp.Split(value)
+		if err != nil {
+			return fmt.Errorf("invalid storage value: %v", err)
+		}
+		proven.SetBytes(content)
+	}
+	if proven.Cmp(claimed) != 0 {
+		return fmt.Errorf("value mismatch: proven %d, claimed %d", proven, claimed)
+	}
+	return nil
+}
+
+// verifyInclusionProof checks that the RLP encoded header of an inclusion proof
+// hashes to the given block hash, and that the proven item is included in the
+// trie of the block whose root is selected from the header.
+func verifyInclusionProof(blockHash common.Hash, proof *InclusionProof, root func(header *types.Header) common.Hash) (*types.Header, error) {
+	header := new(types.Header)
+	if err := rlp.DecodeBytes(proof.Header, header); err != nil {
+		return nil, fmt.Errorf("invalid header: %v", err)
+	}
+	if hash := header.Hash(); hash != blockHash {
+		return nil, fmt.Errorf("block hash mismatch: have %x, want %x", hash, blockHash)
+	}
+	if root(header) != proof.Root {
+		return nil, fmt.Errorf("root mismatch: proven %x, header %x", proof.Root, root(header))
+	}
+	nodes := make([][]byte, len(proof.Proof))
+	for i, node := range proof.Proof {
+		nodes[i] = node
+	}
+	value, err := trie.VerifyProof(proof.Root, rlp.AppendUint64(nil, uint64(proof.Index)), proofDB(nodes))
+	if err != nil {
+		return nil, fmt.Errorf("invalid inclusion proof: %v", err)
+	}
+	if value == nil {
+		return nil, fmt.Errorf("item %d absent from the block", proof.Index)
+	}
+	if !bytes.Equal(value, proof.Value) {
+		return nil, errors.New("proven value mismatch")
+	}
+	return header, nil
+}
+
+// VerifyTransactionProof checks the proof of the inclusion of a transaction in
+// the block with the given trusted hash, returning the proven transaction along
+// with the header of the block.
+func VerifyTransactionProof(blockHash common.Hash, proof *InclusionProof) (*types.Transaction, *types.Header, error) {
+	header, err := verifyInclusionProof(blockHash, proof, func(header *types.Header) common.Hash { return header.TxHash })
+	if err != nil {
+		return nil, nil, err
+	}
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(proof.Value); err != nil {
+		return nil, nil, fmt.Errorf("invalid transaction: %v", err)
+	}
+	return tx, header, nil
+}
+
+// VerifyReceiptProof checks the proof of the inclusion of a receipt in the block
+// with the given trusted hash, returning the proven receipt along with the header
+// of the block. Only the consensus fields of the receipt, e.g. the status and the
+// logs, are proven and set.
+func VerifyReceiptProof(blockHash common.Hash, proof *InclusionProof) (*types.Receipt, *types.Header, error) {
+	header, err := verifyInclusionProof(blockHash, proof, func(header *types.Header) common.Hash { return header.ReceiptHash })
+	if err != nil {
+		return nil, nil, err
+	}
+	receipt := new(types.Receipt)
+	if err := receipt.UnmarshalBinary(proof.Value); err != nil {
+		return nil, nil, fmt.Errorf("invalid receipt: %v", err)
+	}
+	return receipt, header, nil
+}
@@ -87,8 +87,15 @@ type AncientReader interface {
 	// Ancients returns the ancient item numbers in the ancient store.
 	Ancients() (uint64, error)
 
+	// Tail returns the number of the first block whose prunable ancient data
+	// (bodies and receipts) is still stored in the ancient store.
+	Tail() (uint64, error)
+
 	// AncientSize returns the ancient size of the specified category.
 	AncientSize(kind string) (uint64, error)
+
+	// AncientDatadir returns the path of the root directory of the ancient store.
+	AncientDatadir() (string, error)
 }
 
 // AncientBatchReader is the interface for 'batched' or 'atomic' reading.
@@ -110,6 +117,10 @@ type AncientWriter interface {
 	// TruncateAncients discards all but the first n ancient data from the ancient store.
 	TruncateAncients(n uint64) error
 
+	// TruncateTail discards the prunable ancient data (bodies and receipts) of
+	// the first n blocks from the ancient store.
+	TruncateTail(n uint64) error
+
 	// Sync flushes all in-memory ancient store data to disk.
 	Sync() error
 }
@@ -18,6 +18,7 @@
 package ethapi
 
 import (
+	"bytes"
 	"context"
 	"encoding/hex"
 	"errors"
@@ -42,14 +43,17 @@ import (
 	"github.com/ethereum/go-ethereum/consensus/misc"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
 	"github.com/tyler-smith/go-bip39"
 )
 
@@ -132,6 +136,119 @@ func (s *PublicEthereumAPI) GasPricePrediction(ctx context.Context) (map[string]
 	}, nil
 }
 
+const (
+	feeSuggestionBlocks = 20 // Number of blocks of fee history the fee suggestion looks at
+
+	// Jam indexes above which the network counts as moderately or highly congested
+	mediumCongestionJamIndex = 30
+	highCongestionJamIndex   = 100
+)
+
+// feeSuggestionPercentiles are the reward percentiles of the fee history matching
+// the fast, median and low tiers of the fee prediction.
+var feeSuggestionPercentiles = []float64{90, 50, 10}
+
+// SuggestFees returns EIP-1559 fee suggestions for the fast, median and low tiers,
+// with the expected number of blocks until inclusion of each. The priority fee of
+// a tier is the highest of the pool based prediction and the tips paid in recent
+// blocks, the max fee leaves room for the base fee to double. The congestion level
+// combines the pool backlog, the recent block usage and the jam index, and the
+// confidence drops when recent blocks carry no transactions or the network is
+// congested.
+func (s *PublicEthereumAPI) SuggestFees(ctx context.Context) (map[string]interface{}, error) {
+	prediction, err := s.b.FeePrediction(ctx)
+	if err != nil {
+		return nil, err
+	}
+	_, rewards, baseFees, gasUsedRatios, err := s.b.FeeHistory(ctx, feeSuggestionBlocks, rpc.LatestBlockNumber, feeSuggestionPercentiles)
+	if err != nil {
+		return nil, err
+	}
+	var nextBaseFee *big.Int
+	if len(baseFees) > 0 && baseFees[len(baseFees)-1].Sign() > 0 {
+		nextBaseFee = baseFees[len(baseFees)-1]
+	}
+	// Average the tips paid in recent blocks per tier
+	history := make([]*big.Int, len(feeSuggestionPercentiles))
+	for i := range history {
+		history[i] = new(big.Int)
+		if len(rewards) == 0 {
+			continue
+		}
+		for _, reward := range rewards {
+			history[i].Add(history[i], reward[i])
+		}
+		history[i].Div(history[i], big.NewInt(int64(len(rewards))))
+	}
+	fields := map[string]interface{}{
+		"baseFee": (*hexutil.Big)(nextBaseFee),
+	}
+	for i, tier := range []string{"fast", "median", "low"} {
+		tip := new(big.Int).Set(prediction.Tips[i])
+		if history[i].Cmp(tip) > 0 {
+			tip.Set(history[i])
+		}
+		maxFee := new(big.Int).Set(tip)
+		if nextBaseFee != nil {
+			maxFee.Add(maxFee, new(big.Int).Mul(nextBaseFee, big.NewInt(2)))
+		}
+		fields[tier] = map[string]interface{}{
+			"maxFeePerGas":         (*hexutil.Big)(maxFee),
+			"maxPriorityFeePerGas": (*hexutil.Big)(tip),
+			"expectedBlocks":       hexutil.Uint64(prediction.Blocks[i]),
+		}
+	}
+	// Rate the congestion
+	var gasUsedRatio float64
+	for _, ratio := range gasUsedRatios {
+		gasUsedRatio += ratio
+	}
+	if len(gasUsedRatios) > 0 {
+		gasUsedRatio /= float64(len(gasUsedRatios))
+	}
+	var (
+		jamIndex = s.b.JamIndex()
+		backlog  float64
+	)
+	if prediction.AvgTxCnt > 0 {
+		backlog = float64(prediction.Pending) / float64(prediction.AvgTxCnt)
+	}
+	congestion := "low"
+	switch {
+	case jamIndex >= highCongestionJamIndex || backlog >= 4 || gasUsedRatio >= 0.9:
+		congestion = "high"
+	case jamIndex >= mediumCongestionJamIndex || backlog >= 1 || gasUsedRatio >= 0.5:
+		congestion = "medium"
+	}
+	fields["congestion"] = map[string]interface{}{
+		"level":         congestion,
+		"jamIndex":      jamIndex,
+		"pendingTxs":    prediction.Pending,
+		"backlogBlocks": backlog,
+		"gasUsedRatio":  gasUsedRatio,
+	}
+	// Rate the confidence, missing history or congestion lowers it
+	var doubts int
+	if len(rewards) == 0 || prediction.AvgTxCnt == 0 {
+		doubts++
+	}
+	switch congestion {
+	case "high":
+		doubts += 2
+	case "medium":
+		doubts++
+	}
+	confidence := "high"
+	switch {
+	case doubts >= 2:
+		confidence = "low"
+	case doubts == 1:
+		confidence = "medium"
+	}
+	fields["confidence"] = confidence
+	return fields, nil
+}
+
 // Syncing returns false in case the node is currently not syncing with the network. It can be up to date or has not
 // yet received the latest block headers from its pears. In case it is synchronizing:
 // - startingBlock: block number this node started to synchronise from
@@ -178,7 +295,7 @@ func (s *PublicTxPoolAPI) Content() map[string]map[string]map[string]*RPCTransac
 	for account, txs := range pending {
 		dump := make(map[string]*RPCTransaction)
 		for _, tx := range txs {
-			dump[fmt.Sprintf("%d", tx.Nonce())] = newRPCPendingTransaction(tx, curHeader, s.b.ChainConfig())
+			dump[fmt.Sprintf("%d", tx.Nonce())] = NewRPCPendingTransaction(tx, curHeader, s.b.ChainConfig())
 		}
 		content["pending"][account.Hex()] = dump
 	}
@@ -186,7 +303,7 @@ func (s *PublicTxPoolAPI) Content() map[string]map[string]map[string]*RPCTransac
 	for account, txs := range queue {
 		dump := make(map[string]*RPCTransaction)
 		for _, tx := range txs {
-			dump[fmt.Sprintf("%d", tx.Nonce())] = newRPCPendingTransaction(tx, curHeader, s.b.ChainConfig())
+			dump[fmt.Sprintf("%d", tx.Nonce())] = NewRPCPendingTransaction(tx, curHeader, s.b.ChainConfig())
 		}
 		content["queued"][account.Hex()] = dump
 	}
@@ -202,14 +319,14 @@ func (s *PublicTxPoolAPI) ContentFrom(addr common.Address) map[string]map[string
 	// Build the pending transactions
 	dump := make(map[string]*RPCTransaction, len(pending))
 	for _, tx := range pending {
-		dump[fmt.Sprintf("%d", tx.Nonce())] = newRPCPendingTransaction(tx, curHeader, s.b.ChainConfig())
+		dump[fmt.Sprintf("%d", tx.Nonce())] = NewRPCPendingTransaction(tx, curHeader, s.b.ChainConfig())
 	}
 	content["pending"] = dump
 
 	// Build the queued transactions
 	dump = make(map[string]*RPCTransaction, len(queue))
 	for _, tx := range queue {
-		dump[fmt.Sprintf("%d", tx.Nonce())] = newRPCPendingTransaction(tx, curHeader, s.b.ChainConfig())
+		dump[fmt.Sprintf("%d", tx.Nonce())] = NewRPCPendingTransaction(tx, curHeader, s.b.ChainConfig())
 	}
 	content["queued"] = dump
 
@@ -303,6 +420,20 @@ func (s *PublicTxPoolAPI) JamIndex() int {
 	return s.b.JamIndex()
 }
 
+// Explain reports why a transaction is stuck in the pool or was refused by it,
+// given its hash, or the pool state of an account with its recent rejections,
+// given an address.
+func (s *PublicTxPoolAPI) Explain(target hexutil.Bytes) (interface{}, error) {
+	switch len(target) {
+	case common.HashLength:
+		return s.b.TxPoolExplain(common.BytesToHash(target))
+	case common.AddressLength:
+		return s.b.TxPoolExplainAccount(common.BytesToAddress(target))
+	default:
+		return nil, fmt.Errorf("invalid target length %d, expected a transaction hash or an address", len(target))
+	}
+}
+
 // PublicAccountAPI provides an API to access accounts managed by this node.
 // It offers only methods that can retrieve accounts.
 type PublicAccountAPI struct {
@@ -716,7 +847,56 @@ func (s *PublicBlockChainAPI) GetProof(ctx context.Context, address common.Addre
 	if state == nil || err != nil {
 		return nil, err
 	}
+	return accountProof(state, address, storageKeys)
+}
+
+const (
+	// maxProofAccounts is the maximum number of accounts proven by GetProofs.
+	maxProofAccounts = 256
+
+	// maxProofStorageKeys is the maximum number of storage keys proven by
+	// GetProofs, across all the accounts.
+	maxProofStorageKeys = 1024
+)
+
+// ProofRequest is an account, and the storage keys of it, to prove with GetProofs.
+type ProofRequest struct {
+	Address     common.Address `json:"address"`
+	StorageKeys []string       `json:"storageKeys"`
+}
+
+// GetProofs returns the Merkle-proofs for several accounts and optionally some
+// storage keys of each, all taken from the state of the same block.
+func (s *PublicBlockChainAPI) GetProofs(ctx context.Context, requests []ProofRequest, blockNrOrHash rpc.BlockNumberOrHash) ([]*AccountResult, error) {
+	if len(requests) > maxProofAccounts {
+		return nil, fmt.Errorf("too many accounts, at most %d allowed", maxProofAccounts)
+	}
+	keys := 0
+	for _, request := range requests {
+		keys += len(request.StorageKeys)
+	}
+	if keys > maxProofStorageKeys {
+		return nil, fmt.Errorf("too many storage keys, at most %d allowed", maxProofStorageKeys)
+	}
+	state, _, err := s.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	results := make([]*AccountResult, len(requests))
+	for i, request := range requests {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if results[i], err = accountProof(state, request.Address, request.StorageKeys); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
 
+// accountProof creates the Merkle-proof for an account and optionally some
+// storage keys of it.
+func accountProof(state *state.StateDB, address common.Address, storageKeys []string) (*AccountResult, error) {
 	storageTrie := state.StorageTrie(address)
 	storageHash := types.EmptyRootHash
 	codeHash := state.GetCodeHash(address)
@@ -764,6 +944,116 @@ func (s *PublicBlockChainAPI) GetProof(ctx context.Context, address common.Addre
 	}, state.Error()
 }
 
+// InclusionProof is a Merkle-proof of the inclusion of a transaction or a receipt
+// in the corresponding trie of a block. The RLP encoded header is included so that
+// the root can be checked against the block hash.
+type InclusionProof struct {
+	BlockHash   common.Hash     `json:"blockHash"`
+	BlockNumber hexutil.Uint64  `json:"blockNumber"`
+	Index       hexutil.Uint    `json:"index"`
+	Header      hexutil.Bytes   `json:"header"`
+	Root        common.Hash     `json:"root"`
+	Value       hexutil.Bytes   `json:"value"`
+	Proof       []hexutil.Bytes `json:"proof"`
+}
+
+// nodeList collects the nodes of a Merkle-proof, in order from the root.
+type nodeList []hexutil.Bytes
+
+func (n *nodeList) Put(key []byte, value []byte) error {
+	*n = append(*n, common.CopyBytes(value))
+	return nil
+}
+
+func (n *nodeList) Delete(key []byte) error {
+	panic("not supported")
+}
+
+// GetTransactionProof returns the Merkle-proof of the inclusion of a transaction
+// in the transaction trie of its block.
+func (s *PublicBlockChainAPI) GetTransactionProof(ctx context.Context, hash common.Hash) (*InclusionProof, error) {
+	tx, blockHash, _, index, err := s.b.GetTransaction(ctx, hash)
+	if tx == nil || err != nil {
+		return nil, err
+	}
+	block, err := s.b.BlockByHash(ctx, blockHash)
+	if block == nil || err != nil {
+		return nil, err
+	}
+	return inclusionProof(block.Header(), block.Transactions(), index, block.TxHash())
+}
+
+// GetReceiptProof returns the Merkle-proof of the inclusion of the receipt of a
+// transaction in the receipt trie of its block.
+func (s *PublicBlockChainAPI) GetReceiptProof(ctx context.Context, hash common.Hash) (*InclusionProof, error) {
+	tx, blockHash, _, index, err := s.b.GetTransaction(ctx, hash)
+	if tx == nil || err != nil {
+		return nil, err
+	}
+	header, err := s.b.HeaderByHash(ctx, blockHash)
+	if header == nil || err != nil {
+		return nil, err
+	}
+	receipts, err := s.b.GetReceipts(ctx, blockHash)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(receipts)) <= index {
+		return nil, fmt.Errorf("receipts of block %x unavailable", blockHash)
+	}
+	return inclusionProof(header, receipts, index, header.ReceiptHash)
+}
+
+// inclusionProof rebuilds the trie of a list of block items and creates the
+// Merkle-proof of the item at the given index, checking the root of the trie
+// against the one of the header.
+func inclusionProof(header *types.Header, list types.DerivableList, index uint64, root common.Hash) (*InclusionProof, error) {
+	tr, err := trie.New(common.Hash{}, trie.NewDatabase(memorydb.New()))
+	if err != nil {
+		return nil, err
+	}
+	var (
+		buf bytes.Buffer
+		key []byte
+	)
+	for i := 0; i < list.Len(); i++ {
+		buf.Reset()
+		list.EncodeIndex(i, &buf)
+		key = rlp.AppendUint64(key[:0], uint64(i))
+		if err := tr.TryUpdate(key, common.CopyBytes(buf.Bytes())); err != nil {
+			return nil, err
+		}
+	}
+	if have := tr.Hash(); have != root {
+		return nil, fmt.Errorf("trie root mismatch: have %x, want %x", have, root)
+	}
+	key = rlp.AppendUint64(nil, index)
+	value, err := tr.TryGet(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(value) == 0 {
+		return nil, fmt.Errorf("item %d not committed to the trie of block %x", index, header.Hash())
+	}
+	var proof nodeList
+	if err := tr.Prove(key, 0, &proof); err != nil {
+		return nil, err
+	}
+	enc, err := rlp.EncodeToBytes(header)
+	if err != nil {
+		return nil, err
+	}
+	return &InclusionProof{
+		BlockHash:   header.Hash(),
+		BlockNumber: hexutil.Uint64(header.Number.Uint64()),
+		Index:       hexutil.Uint(index),
+		Header:      enc,
+		Root:        root,
+		Value:       value,
+		Proof:       proof,
+	}, nil
+}
+
 // decodeHash parses a hex-encoded 32-byte hash. The input may optionally
 // be prefixed by 0x and can have an byte length up to 32.
 func decodeHash(s string) (common.Hash, error) {
@@ -1098,6 +1388,46 @@ func (e *revertError) ErrorData() interface{} {
 	return e.reason
 }
 
+// deployDeniedErrorCode is the JSON error code of calls and receipts whose contract
+// creation was denied because the deployer isn't verified by the developer allowlist.
+const deployDeniedErrorCode = -32010
+
+// deployDeniedError is an API error that reports a CREATE/CREATE2 denied by the
+// developer allowlist, with the address of the unverified deployer as data.
+type deployDeniedError struct {
+	deployer common.Address
+}
+
+func (e *deployDeniedError) Error() string {
+	return fmt.Sprintf("deployer not verified: %s", e.deployer.Hex())
+}
+
+// ErrorCode returns the JSON error code for a denied contract creation.
+func (e *deployDeniedError) ErrorCode() int {
+	return deployDeniedErrorCode
+}
+
+// ErrorData returns the address of the unverified deployer.
+func (e *deployDeniedError) ErrorData() interface{} {
+	return e.deployer
+}
+
+// newDeployDeniedError returns a deployDeniedError if the message failed because a
+// contract creation was denied, either of the sender itself or of a nested CREATE.
+func newDeployDeniedError(args TransactionArgs, result *core.ExecutionResult, err error) error {
+	if errors.Is(err, core.ErrUnauthorizedDeveloper) {
+		var deployer common.Address
+		if args.From != nil {
+			deployer = *args.From
+		}
+		return &deployDeniedError{deployer: deployer}
+	}
+	if err == nil && result != nil && result.Failed() && result.DeniedCreator != nil {
+		return &deployDeniedError{deployer: *result.DeniedCreator}
+	}
+	return nil
+}
+
 // Call executes the given transaction on the state for the given block number.
 //
 // Additionally, the caller can specify a batch of contract for fields overriding.
@@ -1110,6 +1440,9 @@ func (s *PublicBlockChainAPI) Call(ctx context.Context, args TransactionArgs, bl
 		blockNrOrHash = &latest
 	}
 	result, err := DoCall(ctx, s.b, args, *blockNrOrHash, overrides, s.b.RPCEVMTimeout(), s.b.RPCGasCap())
+	if denied := newDeployDeniedError(args, result, err); denied != nil {
+		return nil, denied
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -1208,6 +1541,9 @@ func DoEstimateGas(ctx context.Context, b Backend, args TransactionArgs, blockNr
 		args.Gas = (*hexutil.Uint64)(&gas)
 
 		result, err := DoCall(ctx, b, args, blockNrOrHash, nil, 0, gasCap)
+		if denied := newDeployDeniedError(args, result, err); denied != nil {
+			return true, nil, denied // Bail out, the deployer must be verified first
+		}
 		if err != nil {
 			if errors.Is(err, core.ErrIntrinsicGas) {
 				return true, nil, nil // Special case, raise gas limit
@@ -1267,6 +1603,33 @@ func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args TransactionA
 	return DoEstimateGas(ctx, s.b, args, bNrOrHash, s.b.RPCGasCap())
 }
 
+// PreflightResult is the outcome of a transaction preflight. Failures are reported
+// as values, so tools can tell why a transaction would be rejected.
+type PreflightResult struct {
+	Gas       hexutil.Uint64 `json:"gas"`
+	Error     string         `json:"error,omitempty"`
+	ErrorCode int            `json:"errorCode,omitempty"`
+	ErrorData interface{}    `json:"errorData,omitempty"`
+}
+
+// PreflightTransaction estimates the gas of a transaction like EstimateGas, but
+// reports why it would fail instead of returning an error, e.g. that the deployer
+// of a contract creation is not verified by the developer allowlist.
+func (s *PublicBlockChainAPI) PreflightTransaction(ctx context.Context, args TransactionArgs, blockNrOrHash *rpc.BlockNumberOrHash) (*PreflightResult, error) {
+	gas, err := s.EstimateGas(ctx, args, blockNrOrHash)
+	if err == nil {
+		return &PreflightResult{Gas: gas}, nil
+	}
+	result := &PreflightResult{Error: err.Error(), ErrorCode: -32000}
+	if ec, ok := err.(rpc.Error); ok {
+		result.ErrorCode = ec.ErrorCode()
+	}
+	if de, ok := err.(rpc.DataError); ok {
+		result.ErrorData = de.ErrorData()
+	}
+	return result, nil
+}
+
 // ExecutionResult groups all structured logs emitted by the EVM
 // while replaying a transaction in debug mode as well as transaction
 // execution status, the amount of gas used and the return value
@@ -1485,8 +1848,8 @@ func newRPCTransaction(tx *types.Transaction, blockHash common.Hash, blockNumber
 	return result
 }
 
-// newRPCPendingTransaction returns a pending transaction that will serialize to the RPC representation
-func newRPCPendingTransaction(tx *types.Transaction, current *types.Header, config *params.ChainConfig) *RPCTransaction {
+// NewRPCPendingTransaction returns a pending transaction that will serialize to the RPC representation.
+func NewRPCPendingTransaction(tx *types.Transaction, current *types.Header, config *params.ChainConfig) *RPCTransaction {
 	var baseFee *big.Int
 	blockNumber := uint64(0)
 	if current != nil {
@@ -1724,7 +2087,7 @@ func (s *PublicTransactionPoolAPI) GetTransactionByHash(ctx context.Context, has
 	}
 	// No finalized transaction, try to retrieve it from the pool
 	if tx := s.b.GetPoolTransaction(hash); tx != nil {
-		return newRPCPendingTransaction(tx, s.b.CurrentHeader(), s.b.ChainConfig()), nil
+		return NewRPCPendingTransaction(tx, s.b.CurrentHeader(), s.b.ChainConfig()), nil
 	}
 
 	// Transaction unknown, return as such
@@ -1806,6 +2169,14 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(ctx context.Context, ha
 	if receipt.ContractAddress != (common.Address{}) {
 		fields["contractAddress"] = receipt.ContractAddress
 	}
+	// Flag failures caused by a contract creation denied by the developer allowlist
+	if receipt.Status == types.ReceiptStatusFailed {
+		if deployer := rawdb.ReadDeniedCreate(s.b.ChainDb(), hash, blockHash); deployer != nil {
+			denied := &deployDeniedError{deployer: *deployer}
+			fields["errorCode"] = denied.ErrorCode()
+			fields["error"] = denied.Error()
+		}
+	}
 	return fields, nil
 }
 
@@ -1939,7 +2310,11 @@ func (s *PublicTransactionPoolAPI) checkTransactionBlocklistRPC(ctx context.Cont
 		return fmt.Errorf("failed to get transaction sender: %v", err)
 	}
 
-	return s.checkSenderBlocklistRPC(ctx, from)
+	if err := s.checkSenderBlocklistRPC(ctx, from); err != nil {
+		core.GetBlocklistChecker().ReportRejectedTx(tx, err)
+		return err
+	}
+	return nil
 }
 
 // SendRawTransaction will add the signed transaction to the transaction pool.
@@ -2085,7 +2460,7 @@ func (s *PublicTransactionPoolAPI) PendingTransactions() ([]*RPCTransaction, err
 	for _, tx := range pending {
 		from, _ := types.Sender(s.signer, tx)
 		if _, exists := accounts[from]; exists {
-			transactions = append(transactions, newRPCPendingTransaction(tx, curHeader, s.b.ChainConfig()))
+			transactions = append(transactions, NewRPCPendingTransaction(tx, curHeader, s.b.ChainConfig()))
 		}
 	}
 	return transactions, nil
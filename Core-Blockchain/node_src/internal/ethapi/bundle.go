@@ -0,0 +1,226 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package ethapi
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// PublicBundleAPI provides an API to submit and simulate bundles, ordered sets of
+// transactions which are included all-or-nothing by the local miner.
+type PublicBundleAPI struct {
+	b Backend
+}
+
+// NewPublicBundleAPI creates a new bundle API.
+func NewPublicBundleAPI(b Backend) *PublicBundleAPI {
+	return &PublicBundleAPI{b}
+}
+
+// SendBundleArgs represents the arguments of eth_sendBundle.
+type SendBundleArgs struct {
+	Txs               []hexutil.Bytes `json:"txs"`
+	MinBlock          *hexutil.Uint64 `json:"minBlock"` // Defaults to the next block
+	MaxBlock          *hexutil.Uint64 `json:"maxBlock"` // Defaults to the min block
+	RevertingTxHashes []common.Hash   `json:"revertingTxHashes"`
+}
+
+// CallBundleArgs represents the arguments of eth_callBundle.
+type CallBundleArgs struct {
+	Txs               []hexutil.Bytes        `json:"txs"`
+	RevertingTxHashes []common.Hash          `json:"revertingTxHashes"`
+	StateBlockNumber  *rpc.BlockNumberOrHash `json:"stateBlockNumber"` // Defaults to the latest block
+	Timestamp         *hexutil.Uint64        `json:"timestamp"`        // Defaults to the state block's plus one
+	Coinbase          *common.Address        `json:"coinbase"`         // Defaults to the state block's
+}
+
+// decodeBundleTxs decodes the signed transactions of a bundle.
+func decodeBundleTxs(encoded []hexutil.Bytes) (types.Transactions, error) {
+	if len(encoded) == 0 {
+		return nil, core.ErrBundleEmpty
+	}
+	txs := make(types.Transactions, len(encoded))
+	for i, input := range encoded {
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(input); err != nil {
+			return nil, fmt.Errorf("tx %d: %v", i, err)
+		}
+		txs[i] = tx
+	}
+	return txs, nil
+}
+
+// SendBundle queues a bundle for inclusion in its target block range and returns
+// its hash, along with a simulation of the bundle on top of the latest block.
+func (s *PublicBundleAPI) SendBundle(ctx context.Context, args SendBundleArgs) (map[string]interface{}, error) {
+	txs, err := decodeBundleTxs(args.Txs)
+	if err != nil {
+		return nil, err
+	}
+	head := s.b.CurrentHeader().Number.Uint64()
+	bundle := &types.Bundle{
+		Txs:               txs,
+		MinBlock:          head + 1,
+		RevertingTxHashes: args.RevertingTxHashes,
+	}
+	if args.MinBlock != nil {
+		bundle.MinBlock = uint64(*args.MinBlock)
+	}
+	bundle.MaxBlock = bundle.MinBlock
+	if args.MaxBlock != nil {
+		bundle.MaxBlock = uint64(*args.MaxBlock)
+	}
+	if err := s.b.SendBundle(ctx, bundle); err != nil {
+		return nil, err
+	}
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	return s.simulate(ctx, bundle, latest, nil, nil)
+}
+
+// CallBundle simulates a bundle on top of the given block, as if it was included
+// at the start of the next one, without queueing it.
+func (s *PublicBundleAPI) CallBundle(ctx context.Context, args CallBundleArgs) (map[string]interface{}, error) {
+	txs, err := decodeBundleTxs(args.Txs)
+	if err != nil {
+		return nil, err
+	}
+	if len(txs) > core.MaxBundleTxs {
+		return nil, core.ErrBundleTooLarge
+	}
+	stateBlock := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	if args.StateBlockNumber != nil {
+		stateBlock = *args.StateBlockNumber
+	}
+	bundle := &types.Bundle{Txs: txs, RevertingTxHashes: args.RevertingTxHashes}
+	return s.simulate(ctx, bundle, stateBlock, args.Timestamp, args.Coinbase)
+}
+
+// simulate applies a bundle on top of the given block the way the miner would,
+// and reports the outcome of each of its transactions.
+func (s *PublicBundleAPI) simulate(ctx context.Context, bundle *types.Bundle, stateBlock rpc.BlockNumberOrHash, timestamp *hexutil.Uint64, coinbase *common.Address) (map[string]interface{}, error) {
+	statedb, parent, err := s.b.StateAndHeaderByNumberOrHash(ctx, stateBlock)
+	if statedb == nil || err != nil {
+		return nil, err
+	}
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number, common.Big1),
+		GasLimit:   parent.GasLimit,
+		Time:       parent.Time + 1,
+		Difficulty: parent.Difficulty,
+		Coinbase:   parent.Coinbase,
+	}
+	if timestamp != nil {
+		header.Time = uint64(*timestamp)
+	}
+	if coinbase != nil {
+		header.Coinbase = *coinbase
+	}
+	config := s.b.ChainConfig()
+	if config.IsLondon(header.Number) {
+		header.BaseFee = misc.CalcBaseFee(config, parent)
+	}
+	posa, _ := s.b.Engine().(consensus.PoSA)
+	// Setup context so it may be cancelled when the call has completed
+	// or, in case of unmetered gas, setup a context with a timeout.
+	var cancel context.CancelFunc
+	if timeout := s.b.RPCEVMTimeout(); timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	var (
+		gp      = new(core.GasPool).AddGas(header.GasLimit)
+		usedGas uint64
+		before  = statedb.GetBalance(header.Coinbase)
+		chain   = &chainContext{ctx: ctx, b: s.b}
+	)
+	blockContext := core.NewEVMBlockContext(header, chain, &header.Coinbase)
+	evm := vm.NewEVM(blockContext, vm.TxContext{}, statedb, config, vm.Config{})
+
+	// Wait for the context to be done and cancel the evm. Even if the
+	// EVM has finished, cancelling may be done (repeatedly)
+	go func() {
+		<-ctx.Done()
+		evm.Cancel()
+	}()
+	state, results, bundleErr := core.ApplyBundleWithEVM(config, chain, &header.Coinbase, gp, statedb, header, bundle, 0, &usedGas, evm, posa)
+
+	// If the timer caused an abort, return an appropriate error message
+	if evm.Cancelled() {
+		return nil, fmt.Errorf("execution aborted (timeout = %v)", s.b.RPCEVMTimeout())
+	}
+
+	fields := map[string]interface{}{
+		"bundleHash":       bundle.Hash(),
+		"stateBlockNumber": (*hexutil.Big)(parent.Number),
+		"totalGasUsed":     hexutil.Uint64(usedGas),
+	}
+	txResults := make([]map[string]interface{}, len(results))
+	for i, result := range results {
+		txResult := map[string]interface{}{
+			"txHash": result.Tx.Hash(),
+			"from":   result.From,
+		}
+		if receipt := result.Receipt; receipt != nil {
+			txResult["gasUsed"] = hexutil.Uint64(receipt.GasUsed)
+			txResult["status"] = hexutil.Uint(receipt.Status)
+			txResult["logs"] = receipt.Logs
+			if receipt.Logs == nil {
+				txResult["logs"] = []*types.Log{}
+			}
+			if receipt.ContractAddress != (common.Address{}) {
+				txResult["contractAddress"] = receipt.ContractAddress
+			}
+		}
+		if result.Err != nil {
+			txResult["error"] = result.Err.Error()
+		}
+		txResults[i] = txResult
+	}
+	fields["results"] = txResults
+	if bundleErr != nil {
+		fields["error"] = bundleErr.Error()
+		return fields, nil
+	}
+	fields["coinbaseDiff"] = (*hexutil.Big)(new(big.Int).Sub(state.GetBalance(header.Coinbase), before))
+	return fields, nil
+}
+
+// chainContext is an adapter of the API backend to core.ChainContext, used by
+// simulations to resolve the engine and the ancestors of the simulated block.
+type chainContext struct {
+	ctx context.Context
+	b   Backend
+}
+
+func (c *chainContext) Engine() consensus.Engine {
+	return c.b.Engine()
+}
+
+func (c *chainContext) GetHeader(hash common.Hash, number uint64) *types.Header {
+	header, err := c.b.HeaderByHash(c.ctx, hash)
+	if err != nil || header == nil || header.Number.Uint64() != number {
+		return nil
+	}
+	return header
+}
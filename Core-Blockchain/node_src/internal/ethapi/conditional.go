@@ -0,0 +1,66 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package ethapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// SendRawTransactionConditional adds a signed transaction to the conditional queue
+// of the transaction pool. The transaction only becomes executable while the given
+// block, timestamp and state conditions hold, and is dropped once they expire. The
+// conditions need a maximal block number or timestamp, and the transaction is not
+// relayed to the network.
+func (s *PublicTransactionPoolAPI) SendRawTransactionConditional(ctx context.Context, input hexutil.Bytes, conditions types.TxConditions) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(input); err != nil {
+		return common.Hash{}, err
+	}
+	if err := s.checkTransactionBlocklistRPC(ctx, tx); err != nil {
+		return common.Hash{}, err
+	}
+	if err := checkTxFee(tx.GasPrice(), tx.Gas(), s.b.RPCTxFeeCap()); err != nil {
+		return common.Hash{}, err
+	}
+	if !s.b.UnprotectedAllowed() && !tx.Protected() {
+		return common.Hash{}, errors.New("only replay-protected (EIP-155) transactions allowed over RPC")
+	}
+	if err := s.b.SendConditionalTx(ctx, tx, &conditions); err != nil {
+		return common.Hash{}, err
+	}
+	log.Info("Submitted conditional transaction", "hash", tx.Hash().Hex(), "nonce", tx.Nonce(), "recipient", tx.To())
+	return tx.Hash(), nil
+}
+
+// GetConditionalTransaction returns a conditional transaction held by the pool,
+// along with its conditions and whether they currently hold.
+func (s *PublicTransactionPoolAPI) GetConditionalTransaction(ctx context.Context, hash common.Hash) (map[string]interface{}, error) {
+	entry := s.b.GetConditionalTx(hash)
+	if entry == nil {
+		return nil, nil
+	}
+	status := "queued"
+	if entry.Promoted {
+		status = "pending"
+	}
+	return map[string]interface{}{
+		"hash":        hash,
+		"from":        entry.From,
+		"nonce":       hexutil.Uint64(entry.Tx.Nonce()),
+		"conditions":  entry.Conditions,
+		"status":      status,
+		"submittedAt": hexutil.Uint64(entry.Added.Unix()),
+	}, nil
+}
@@ -0,0 +1,155 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package ethapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// ReplaceArgs are the overrides of a replacement transaction. Unset fees default
+// to the pooled ones raised by the minimum replacement bump of the pool.
+type ReplaceArgs struct {
+	GasPrice             *hexutil.Big    `json:"gasPrice"`
+	MaxFeePerGas         *hexutil.Big    `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *hexutil.Big    `json:"maxPriorityFeePerGas"`
+	Gas                  *hexutil.Uint64 `json:"gas"`
+
+	// Meta is the sponsored call data re-signed by the sponsor for the new fees.
+	// It is required to replace a sponsored transaction, as the sponsor signature
+	// of the pooled one covers its fees only.
+	Meta *hexutil.Bytes `json:"meta"`
+}
+
+// bumpPrice raises a price by the given replacement bump percentage, rounding up
+// so that the replacement is always strictly pricier.
+func bumpPrice(price *big.Int, bump uint64) *big.Int {
+	bumped := new(big.Int).Mul(price, new(big.Int).SetUint64(100+bump))
+	bumped.Add(bumped, big.NewInt(99))
+	return bumped.Div(bumped, big.NewInt(100))
+}
+
+// pooledTransaction returns a pending transaction of the pool and its sender.
+func (s *PublicTransactionPoolAPI) pooledTransaction(hash common.Hash) (*types.Transaction, common.Address, error) {
+	tx := s.b.GetPoolTransaction(hash)
+	if tx == nil {
+		return nil, common.Address{}, fmt.Errorf("transaction %#x not found in the pool", hash)
+	}
+	from, err := types.Sender(s.signer, tx)
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+	return tx, from, nil
+}
+
+// replacement assembles a transaction of the same type and nonce as the pooled
+// one, with the given contents and bumped fees.
+func (s *PublicTransactionPoolAPI) replacement(old *types.Transaction, args ReplaceArgs, to *common.Address, value *big.Int, gas uint64, data []byte) (*types.Transaction, error) {
+	bump := s.b.TxPoolPriceBump()
+
+	price := bumpPrice(old.GasPrice(), bump)
+	if args.GasPrice != nil {
+		price = args.GasPrice.ToInt()
+	}
+	chainID := s.b.ChainConfig().ChainID
+
+	switch old.Type() {
+	case types.LegacyTxType:
+		return types.NewTx(&types.LegacyTx{Nonce: old.Nonce(), GasPrice: price, Gas: gas, To: to, Value: value, Data: data}), nil
+
+	case types.AccessListTxType:
+		return types.NewTx(&types.AccessListTx{ChainID: chainID, Nonce: old.Nonce(), GasPrice: price, Gas: gas, To: to, Value: value, Data: data, AccessList: old.AccessList()}), nil
+
+	case types.DynamicFeeTxType:
+		feeCap, tip := bumpPrice(old.GasFeeCap(), bump), bumpPrice(old.GasTipCap(), bump)
+		if args.MaxFeePerGas != nil {
+			feeCap = args.MaxFeePerGas.ToInt()
+		}
+		if args.MaxPriorityFeePerGas != nil {
+			tip = args.MaxPriorityFeePerGas.ToInt()
+		}
+		if tip.Cmp(feeCap) > 0 {
+			return nil, fmt.Errorf("maxFeePerGas (%v) < maxPriorityFeePerGas (%v)", feeCap, tip)
+		}
+		return types.NewTx(&types.DynamicFeeTx{ChainID: chainID, Nonce: old.Nonce(), GasTipCap: tip, GasFeeCap: feeCap, Gas: gas, To: to, Value: value, Data: data, AccessList: old.AccessList()}), nil
+
+	default:
+		return nil, fmt.Errorf("transaction type %d can't be replaced", old.Type())
+	}
+}
+
+// submitReplacement signs a replacement with the account of the pooled
+// transaction and submits it to the pool.
+func (s *PublicTransactionPoolAPI) submitReplacement(ctx context.Context, from common.Address, tx *types.Transaction) (common.Hash, error) {
+	if err := checkTxFee(tx.GasPrice(), tx.Gas(), s.b.RPCTxFeeCap()); err != nil {
+		return common.Hash{}, err
+	}
+	signed, err := s.sign(from, tx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return SubmitTransaction(ctx, s.b, signed)
+}
+
+// ReplaceTransaction speeds up a pending transaction of a local account, sending
+// the same call with higher fees. A sponsored meta transaction needs its meta
+// envelope re-signed by the sponsor for the new fees.
+func (s *PublicTransactionPoolAPI) ReplaceTransaction(ctx context.Context, hash common.Hash, args ReplaceArgs) (common.Hash, error) {
+	old, from, err := s.pooledTransaction(hash)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	gas, data := old.Gas(), old.Data()
+	if args.Gas != nil {
+		gas = uint64(*args.Gas)
+	}
+	if args.Meta == nil && types.IsMetaTransaction(data) {
+		return common.Hash{}, errors.New("sponsored transaction needs its meta re-signed by the sponsor for the new fees")
+	}
+	if args.Meta != nil {
+		if !types.IsMetaTransaction(*args.Meta) {
+			return common.Hash{}, errors.New("meta is not sponsored call data")
+		}
+		data = *args.Meta
+	}
+	tx, err := s.replacement(old, args, old.To(), old.Value(), gas, data)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	log.Debug("Replacing pooled transaction", "hash", hash, "from", from, "nonce", old.Nonce())
+	return s.submitReplacement(ctx, from, tx)
+}
+
+// CancelTransaction cancels a pending transaction of a local account, replacing
+// it with an empty transfer to itself. The gas price defaults to the pooled one
+// raised by the minimum replacement bump.
+func (s *PublicTransactionPoolAPI) CancelTransaction(ctx context.Context, hash common.Hash, gasPrice *hexutil.Big) (common.Hash, error) {
+	old, from, err := s.pooledTransaction(hash)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	args := ReplaceArgs{GasPrice: gasPrice}
+	if old.Type() == types.DynamicFeeTxType && gasPrice != nil {
+		args = ReplaceArgs{MaxFeePerGas: gasPrice, MaxPriorityFeePerGas: gasPrice}
+	}
+	tx, err := s.replacement(old, args, &from, new(big.Int), params.TxGas, nil)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	log.Debug("Cancelling pooled transaction", "hash", hash, "from", from, "nonce", old.Nonce())
+	return s.submitReplacement(ctx, from, tx)
+}
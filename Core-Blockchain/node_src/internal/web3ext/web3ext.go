@@ -29,6 +29,7 @@ var Modules = map[string]string{
 	"net":      NetJs,
 	"personal": PersonalJs,
 	"rpc":      RpcJs,
+	"trace":    TraceJs,
 	"txpool":   TxpoolJs,
 	"les":      LESJs,
 	"vflux":    VfluxJs,
@@ -117,6 +118,29 @@ web3._extend({
 			call: 'congress_getValidatorsAtHash',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'getScheduledUpgrades',
+			call: 'congress_getScheduledUpgrades',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'getDevVerification',
+			call: 'congress_getDevVerification',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'isDeveloperVerified',
+			call: 'congress_isDeveloperVerified',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getVerifiedDevelopers',
+			call: 'congress_getVerifiedDevelopers',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
 	]
 });
 `
@@ -503,6 +527,12 @@ web3._extend({
 			params: 2,
 			inputFormatter:[web3._extend.formatters.inputBlockNumberFormatter, web3._extend.formatters.inputBlockNumberFormatter],
 		}),
+		new web3._extend.Method({
+			name: 'simulateBlockBuilders',
+			call: 'debug_simulateBlockBuilders',
+			params: 2,
+			inputFormatter: [null, null],
+		}),
 	],
 	properties: []
 });
@@ -542,6 +572,22 @@ web3._extend({
 			inputFormatter: [web3._extend.formatters.inputCallFormatter, web3._extend.formatters.inputBlockNumberFormatter],
 			outputFormatter: web3._extend.utils.toDecimal
 		}),
+		new web3._extend.Method({
+			name: 'preflightTransaction',
+			call: 'eth_preflightTransaction',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputCallFormatter, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'sendBundle',
+			call: 'eth_sendBundle',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'callBundle',
+			call: 'eth_callBundle',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'submitTransaction',
 			call: 'eth_submitTransaction',
@@ -596,6 +642,22 @@ web3._extend({
 			params: 3,
 			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null, web3._extend.formatters.inputBlockNumberFormatter]
 		}),
+		new web3._extend.Method({
+			name: 'getProofs',
+			call: 'eth_getProofs',
+			params: 2,
+			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getTransactionProof',
+			call: 'eth_getTransactionProof',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getReceiptProof',
+			call: 'eth_getReceiptProof',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'createAccessList',
 			call: 'eth_createAccessList',
@@ -619,6 +681,26 @@ web3._extend({
 			call: 'eth_getSysTransactionsByBlockHash',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'sendRawTransactionConditional',
+			call: 'eth_sendRawTransactionConditional',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'getConditionalTransaction',
+			call: 'eth_getConditionalTransaction',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'replaceTransaction',
+			call: 'eth_replaceTransaction',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'cancelTransaction',
+			call: 'eth_cancelTransaction',
+			params: 2
+		}),
 	],
 	properties: [
 		new web3._extend.Property({
@@ -642,6 +724,10 @@ web3._extend({
 			name: 'gasPricePrediction',
 			getter: 'eth_gasPricePrediction'
 		}),
+		new web3._extend.Property({
+			name: 'suggestFees',
+			getter: 'eth_suggestFees'
+		}),
 	]
 });
 `
@@ -779,10 +865,46 @@ web3._extend({
 });
 `
 
+const TraceJs = `
+web3._extend({
+	property: 'trace',
+	methods: [
+		new web3._extend.Method({
+			name: 'block',
+			call: 'trace_block',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'transaction',
+			call: 'trace_transaction',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'replayBlockTransactions',
+			call: 'trace_replayBlockTransactions',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter, null]
+		}),
+		new web3._extend.Method({
+			name: 'filter',
+			call: 'trace_filter',
+			params: 1
+		}),
+	]
+});
+`
+
 const TxpoolJs = `
 web3._extend({
 	property: 'txpool',
-	methods: [],
+	methods: [
+		new web3._extend.Method({
+			name: 'explain',
+			call: 'txpool_explain',
+			params: 1
+		}),
+	],
 	properties:
 	[
 		new web3._extend.Property({
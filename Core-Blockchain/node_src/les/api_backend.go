@@ -205,6 +205,18 @@ func (b *LesApiBackend) SendTx(ctx context.Context, signedTx *types.Transaction)
 	return b.eth.txPool.Add(ctx, signedTx)
 }
 
+func (b *LesApiBackend) SendBundle(ctx context.Context, bundle *types.Bundle) error {
+	return errors.New("bundles are not supported by light clients")
+}
+
+func (b *LesApiBackend) SendConditionalTx(ctx context.Context, signedTx *types.Transaction, conditions *types.TxConditions) error {
+	return errors.New("conditional transactions are not supported by light clients")
+}
+
+func (b *LesApiBackend) GetConditionalTx(hash common.Hash) *core.ConditionalTx {
+	return nil
+}
+
 func (b *LesApiBackend) RemoveTx(txHash common.Hash) {
 	b.eth.txPool.RemoveTx(txHash)
 }
@@ -241,6 +253,14 @@ func (b *LesApiBackend) JamIndex() int {
 	return 0 // not implement
 }
 
+func (b *LesApiBackend) TxPoolExplain(hash common.Hash) (*core.TxExplanation, error) {
+	return nil, errors.New("transaction pool diagnostics are not supported by light clients")
+}
+
+func (b *LesApiBackend) TxPoolExplainAccount(addr common.Address) (*core.AccountExplanation, error) {
+	return nil, errors.New("transaction pool diagnostics are not supported by light clients")
+}
+
 func (b *LesApiBackend) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.Subscription {
 	return b.eth.txPool.SubscribeNewTxsEvent(ch)
 }
@@ -268,6 +288,17 @@ func (b *LesApiBackend) SubscribePendingLogsEvent(ch chan<- []*types.Log) event.
 	})
 }
 
+func (b *LesApiBackend) SubscribeRejectedTxsEvent(ch chan<- core.RejectedTxsEvent) event.Subscription {
+	return core.GetBlocklistChecker().SubscribeRejectedTxsEvent(ch)
+}
+
+func (b *LesApiBackend) SubscribePendingBlockEvent(ch chan<- core.PendingBlockEvent) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	})
+}
+
 func (b *LesApiBackend) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.Subscription {
 	return b.eth.blockchain.SubscribeRemovedLogsEvent(ch)
 }
@@ -292,6 +323,10 @@ func (b *LesApiBackend) PricePrediction(ctx context.Context) ([]uint, error) {
 	return nil, errors.New("not implement")
 }
 
+func (b *LesApiBackend) FeePrediction(ctx context.Context) (*gasprice.FeePrediction, error) {
+	return nil, errors.New("not implement")
+}
+
 func (b *LesApiBackend) ChainDb() ethdb.Database {
 	return b.eth.chainDb
 }
@@ -320,6 +355,10 @@ func (b *LesApiBackend) RPCTxFeeCap() float64 {
 	return b.eth.config.RPCTxFeeCap
 }
 
+func (b *LesApiBackend) TxPoolPriceBump() uint64 {
+	return b.eth.config.TxPool.PriceBump
+}
+
 func (b *LesApiBackend) BloomStatus() (uint64, uint64) {
 	if b.eth.bloomIndexer == nil {
 		return 0, 0
@@ -0,0 +1,424 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package les
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/binary"
+	"math/big"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/consensus/congress"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/les/flowcontrol"
+	vfs "github.com/ethereum/go-ethereum/les/vflux/server"
+	"github.com/ethereum/go-ethereum/light"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// congressTestEpoch is the epoch length of the congress test chains.
+const congressTestEpoch = 8
+
+var (
+	congressKeyA, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	congressKeyB, _ = crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+	congressKeyC, _ = crypto.HexToECDSA("49a7b37aa6f6645917e7b807e9d1c00d4fa71f18343b0d4122a4d2df64dd6fee")
+)
+
+// congressTestConfig is the chain config of the congress test chains, headers
+// only are built so the system contracts are never called.
+var congressTestConfig = &params.ChainConfig{
+	ChainID:             big.NewInt(1337),
+	HomesteadBlock:      big.NewInt(0),
+	EIP150Block:         big.NewInt(0),
+	EIP155Block:         big.NewInt(0),
+	EIP158Block:         big.NewInt(0),
+	ByzantiumBlock:      big.NewInt(0),
+	ConstantinopleBlock: big.NewInt(0),
+	PetersburgBlock:     big.NewInt(0),
+	IstanbulBlock:       big.NewInt(0),
+	BerlinBlock:         big.NewInt(0),
+	Congress:            &params.CongressConfig{Period: 1, Epoch: congressTestEpoch},
+}
+
+// congressValidators returns the addresses of the given keys in ascending order,
+// the order the validators are listed in checkpoints and take turns in.
+func congressValidators(keys ...*ecdsa.PrivateKey) ([]common.Address, map[common.Address]*ecdsa.PrivateKey) {
+	var (
+		addrs  = make([]common.Address, len(keys))
+		byAddr = make(map[common.Address]*ecdsa.PrivateKey)
+	)
+	for i, key := range keys {
+		addrs[i] = crypto.PubkeyToAddress(key.PublicKey)
+		byAddr[addrs[i]] = key
+	}
+	sort.Slice(addrs, func(i, j int) bool { return bytes.Compare(addrs[i][:], addrs[j][:]) < 0 })
+	return addrs, byAddr
+}
+
+// congressGovTestConfig is the chain config of the congress test chains whose
+// epoch length is changed by governance.
+var congressGovTestConfig = func() *params.ChainConfig {
+	config := *congressTestConfig
+	config.RedCoastBlock, config.SophonBlock, config.GovParamsBlock = big.NewInt(2), big.NewInt(3), big.NewInt(16)
+	return &config
+}()
+
+// makeCongressHeaders builds a header chain sealed by the in-turn validators,
+// switching from the genesis validator to the given ones at the first epoch.
+// The headers from the bad one on are sealed by the given key instead, if any.
+// Since the GovParams fork, checkpoints carry the epoch lengths voted at them,
+// the current one if none.
+func makeCongressHeaders(config *params.ChainConfig, genesis *types.Header, n int, genesisKey *ecdsa.PrivateKey, next []*ecdsa.PrivateKey, bad int, badKey *ecdsa.PrivateKey, epochs map[uint64]uint64) []*types.Header {
+	var (
+		headers       []*types.Header
+		parent        = genesis
+		epoch         = config.Congress.Epoch
+		validators, _ = congressValidators(genesisKey)
+		keys          = map[common.Address]*ecdsa.PrivateKey{validators[0]: genesisKey}
+	)
+	nextValidators, nextKeys := congressValidators(next...)
+	for i := 1; i <= n; i++ {
+		var (
+			number     = uint64(i)
+			extra      = make([]byte, 32+crypto.SignatureLength)
+			checkpoint = number%epoch == 0
+		)
+		if checkpoint {
+			extra = congress.GenesisExtraData(nextValidators)
+			if config.IsGovParams(new(big.Int).SetUint64(number)) {
+				if voted, ok := epochs[number]; ok {
+					epoch = voted
+				}
+				gp := make([]byte, 16)
+				binary.BigEndian.PutUint64(gp, config.Congress.Period)
+				binary.BigEndian.PutUint64(gp[8:], epoch)
+				extra = append(append(extra[:len(extra)-crypto.SignatureLength], gp...), make([]byte, crypto.SignatureLength)...)
+			}
+		}
+		key := keys[validators[number%uint64(len(validators))]]
+		if bad > 0 && i >= bad {
+			key = badKey
+		}
+		header := &types.Header{
+			ParentHash:  parent.Hash(),
+			UncleHash:   types.EmptyUncleHash,
+			Coinbase:    crypto.PubkeyToAddress(key.PublicKey),
+			Root:        parent.Root,
+			TxHash:      types.EmptyRootHash,
+			ReceiptHash: types.EmptyRootHash,
+			Difficulty:  big.NewInt(2),
+			Number:      new(big.Int).SetUint64(number),
+			GasLimit:    parent.GasLimit,
+			Time:        parent.Time + 1,
+			Extra:       extra,
+		}
+		sig, _ := crypto.Sign(congress.SealHash(header).Bytes(), key)
+		copy(header.Extra[len(header.Extra)-crypto.SignatureLength:], sig)
+
+		headers = append(headers, header)
+		parent = header
+		if checkpoint {
+			validators, keys = nextValidators, nextKeys
+		}
+	}
+	return headers
+}
+
+// writeCongressChain stores the headers as the canonical chain of a full node.
+// They all share the genesis state, which is present.
+func writeCongressChain(db ethdb.Database, genesis *types.Block, headers []*types.Header) {
+	td := new(big.Int).Set(genesis.Difficulty())
+	for _, header := range headers {
+		td.Add(td, header.Difficulty)
+		block := types.NewBlockWithHeader(header)
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteTd(db, block.Hash(), block.NumberU64(), td)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), nil)
+	}
+	head := headers[len(headers)-1].Hash()
+	rawdb.WriteHeadBlockHash(db, head)
+	rawdb.WriteHeadFastBlockHash(db, head)
+	rawdb.WriteHeadHeaderHash(db, head)
+}
+
+// congressEnv is a light client connected to a server of a congress chain.
+type congressEnv struct {
+	server    *serverHandler
+	client    *clientHandler
+	sdb       ethdb.Database
+	odr       *LesOdr
+	sIndexers []*core.ChainIndexer
+	cIndexers []*core.ChainIndexer
+	closers   []func()
+}
+
+func (env *congressEnv) close() {
+	for i := len(env.closers) - 1; i >= 0; i-- {
+		env.closers[i]()
+	}
+}
+
+// newCongressEnv creates a server serving the given headers of a congress chain,
+// and a light client verifying them with the congress engine, not connected yet.
+func newCongressEnv(t *testing.T, config *params.ChainConfig, genesisKey *ecdsa.PrivateKey, headers func(genesis *types.Header) []*types.Header) *congressEnv {
+	var (
+		sdb       = rawdb.NewMemoryDatabase()
+		cdb       = rawdb.NewMemoryDatabase()
+		clock     = &mclock.System{}
+		speers    = newServerPeerSet()
+		dist      = newRequestDistributor(speers, clock)
+		rm        = newRetrieveManager(speers, dist, func() time.Duration { return time.Millisecond * 500 })
+		odr       = NewLesOdr(cdb, light.TestClientIndexerConfig, speers, rm)
+		addrs, _  = congressValidators(genesisKey)
+		bankFunds = big.NewInt(1_000_000_000_000_000_000)
+		gspec     = core.Genesis{
+			Config:     config,
+			ExtraData:  congress.GenesisExtraData(addrs),
+			GasLimit:   8_000_000,
+			Difficulty: big.NewInt(1),
+			Alloc:      core.GenesisAlloc{bankAddr: {Balance: bankFunds}},
+		}
+	)
+	env := &congressEnv{sdb: sdb, odr: odr}
+	env.closers = append(env.closers, dist.close)
+
+	genesis := gspec.MustCommit(sdb)
+	gspec.MustCommit(cdb)
+	writeCongressChain(sdb, genesis, headers(genesis.Header()))
+
+	// Create the server on top of the stored chain
+	sengine := congress.New(config, sdb)
+	schain, err := core.NewBlockChain(sdb, nil, config, sengine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create server chain: %v", err)
+	}
+	sengine.SetChain(schain)
+	env.closers = append(env.closers, schain.Stop)
+
+	txpoolConfig := core.DefaultTxPoolConfig
+	txpoolConfig.Journal = ""
+	txpool := core.NewTxPool(txpoolConfig, config, schain)
+	env.closers = append(env.closers, txpool.Stop)
+
+	server := &LesServer{
+		lesCommons: lesCommons{
+			genesis:     genesis.Hash(),
+			config:      &ethconfig.Config{LightPeers: 100, NetworkId: NetworkId},
+			chainConfig: config,
+			iConfig:     light.TestServerIndexerConfig,
+			chainDb:     sdb,
+			chainReader: schain,
+			closeCh:     make(chan struct{}),
+		},
+		peers:        newClientPeerSet(),
+		servingQueue: newServingQueue(int64(time.Millisecond*10), 1),
+		defParams: flowcontrol.ServerParams{
+			BufLimit:    testBufLimit,
+			MinRecharge: testBufRecharge,
+		},
+		fcManager: flowcontrol.NewClientManager(nil, clock),
+	}
+	server.costTracker, server.minCapacity = newCostTracker(sdb, server.config)
+	server.costTracker.testCostList = testCostList(0) // Disable flow control mechanism.
+	server.clientPool = vfs.NewClientPool(sdb, testBufRecharge, defaultConnectedBias, clock, alwaysTrueFn)
+	server.clientPool.Start()
+	server.clientPool.SetLimits(10000, 10000) // Assign enough capacity for clientpool
+	server.handler = newServerHandler(server, schain, sdb, txpool, func() bool { return true })
+	server.servingQueue.setThreads(4)
+	server.handler.start()
+	env.server = server.handler
+	env.closers = append(env.closers, func() { server.Stop() })
+
+	// Create the light client verifying the headers with the congress engine
+	env.sIndexers = testIndexers(sdb, nil, light.TestServerIndexerConfig, true)
+	env.cIndexers = testIndexers(cdb, odr, light.TestClientIndexerConfig, true)
+	odr.SetIndexers(env.cIndexers[0], env.cIndexers[1], env.cIndexers[2])
+
+	cengine := congress.New(config, cdb)
+	cchain, err := light.NewLightChain(odr, config, cengine, nil)
+	if err != nil {
+		t.Fatalf("failed to create light chain: %v", err)
+	}
+	cengine.SetChain(cchain)
+
+	client := &LightEthereum{
+		lesCommons: lesCommons{
+			genesis:     genesis.Hash(),
+			config:      &ethconfig.Config{LightPeers: 100, NetworkId: NetworkId},
+			chainConfig: config,
+			iConfig:     light.TestClientIndexerConfig,
+			chainDb:     cdb,
+			chainReader: cchain,
+			closeCh:     make(chan struct{}),
+		},
+		peers:      speers,
+		reqDist:    dist,
+		retriever:  rm,
+		odr:        odr,
+		engine:     cengine,
+		blockchain: cchain,
+		eventMux:   new(event.TypeMux),
+	}
+	client.handler = newClientHandler(nil, 0, nil, client)
+	client.handler.start()
+	env.client = client.handler
+	env.closers = append(env.closers, client.handler.stop)
+
+	env.sIndexers[0].Start(schain)
+	env.sIndexers[1].Start(schain)
+	env.cIndexers[0].Start(cchain)
+	env.cIndexers[1].Start(cchain)
+	env.closers = append(env.closers, func() {
+		env.cIndexers[0].Close()
+		env.cIndexers[1].Close()
+		env.sIndexers[0].Close()
+		env.sIndexers[1].Close()
+	})
+	return env
+}
+
+// sync connects the client to the server and waits for it to finish syncing,
+// returning the head header of the client.
+func (env *congressEnv) sync(t *testing.T) *types.Header {
+	done := make(chan *types.Header, 1)
+	env.client.syncEnd = func(header *types.Header) { done <- header }
+
+	cpeer, speer, err := newTestPeerPair("peer", lpv3, env.server, env.client, false)
+	if err != nil {
+		t.Fatalf("failed to connect testing peers: %v", err)
+	}
+	env.closers = append(env.closers, func() {
+		speer.close()
+		cpeer.close()
+		cpeer.cpeer.close()
+		speer.speer.close()
+	})
+	select {
+	case head := <-done:
+		return head
+	case <-time.After(10 * time.Second):
+		t.Fatal("congress header syncing timeout")
+	}
+	return nil
+}
+
+// Tests that a light client verifies the signers of a congress header chain it
+// syncs over LES against the validator sets of the checkpoints, switching to a
+// new set at an epoch, and that it refuses the headers of unauthorized signers.
+func TestCongressHeaderSync(t *testing.T) {
+	tests := []struct {
+		bad  int
+		head uint64
+	}{
+		{0, 20}, // every header authorized, the validators change at block 8
+		{5, 0},  // sealed by an outsider before the first epoch
+		{13, 0}, // sealed by the genesis validator, removed at block 8
+	}
+	// The headers are delivered in a single batch, which is refused as a whole
+	for i, tt := range tests {
+		badKey := congressKeyC
+		if tt.bad > congressTestEpoch {
+			badKey = congressKeyA
+		}
+		env := newCongressEnv(t, congressTestConfig, congressKeyA, func(genesis *types.Header) []*types.Header {
+			return makeCongressHeaders(congressTestConfig, genesis, 20, congressKeyA, []*ecdsa.PrivateKey{congressKeyB, congressKeyC}, tt.bad, badKey, nil)
+		})
+		if head := env.sync(t); head.Number.Uint64() != tt.head {
+			t.Errorf("test %d: synced head mismatch: have %d, want %d", i, head.Number, tt.head)
+		}
+		env.close()
+	}
+}
+
+// Tests that a light client starts verifying a congress header chain from the
+// latest epoch covered by a trusted checkpoint, and proves the state of the
+// synced head over LES.
+func TestCongressCheckpointSync(t *testing.T) {
+	start := (light.TestServerIndexerConfig.ChtSize - 1) / congressTestEpoch * congressTestEpoch
+	testCongressCheckpointSync(t, congressTestConfig, nil, start)
+}
+
+// Tests that a light client finds the latest epoch covered by a trusted checkpoint
+// after governance changed the epoch length, following the voted ones.
+func TestCongressGovernedCheckpointSync(t *testing.T) {
+	// The epoch length is 8 until the checkpoint at 16, 11 until the one at 33 and
+	// 13 afterwards, the last checkpoint of the section being 117.
+	testCongressCheckpointSync(t, congressGovTestConfig, map[uint64]uint64{16: 11, 33: 13}, 117)
+}
+
+func testCongressCheckpointSync(t *testing.T, chainConfig *params.ChainConfig, epochs map[uint64]uint64, start uint64) {
+	var (
+		config = light.TestServerIndexerConfig
+		blocks = int(config.ChtSize + config.ChtConfirms)
+	)
+	env := newCongressEnv(t, chainConfig, congressKeyA, func(genesis *types.Header) []*types.Header {
+		return makeCongressHeaders(chainConfig, genesis, blocks, congressKeyA, []*ecdsa.PrivateKey{congressKeyB, congressKeyC}, 0, nil, epochs)
+	})
+	defer env.close()
+
+	// Wait for the server to index the CHT section of the checkpoint
+	for {
+		cs, _, _ := env.sIndexers[0].Sections()
+		bts, _, _ := env.sIndexers[2].Sections()
+		if cs >= 1 && bts >= 1 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	s, _, head := env.sIndexers[0].Sections()
+	cp := &params.TrustedCheckpoint{
+		SectionIndex: 0,
+		SectionHead:  head,
+		CHTRoot:      light.GetChtRoot(env.sdb, s-1, head),
+		BloomRoot:    light.GetBloomTrieRoot(env.sdb, s-1, head),
+	}
+	env.client.checkpoint = cp
+	env.client.backend.blockchain.AddTrustedCheckpoint(cp)
+
+	synced := env.sync(t)
+	if synced.Number.Uint64() != uint64(blocks) {
+		t.Fatalf("synced head mismatch: have %d, want %d", synced.Number, blocks)
+	}
+	// Only the headers from the checkpoint epoch on should have been downloaded
+	if header := env.client.backend.blockchain.GetHeaderByNumber(start - 1); header != nil {
+		t.Errorf("header %d before the checkpoint epoch downloaded", start-1)
+	}
+	if header := env.client.backend.blockchain.GetHeaderByNumber(start); header == nil {
+		t.Errorf("checkpoint epoch header %d missing", start)
+	}
+	// Prove an account against the synced head, retrieving the nodes over LES
+	statedb := light.NewState(context.Background(), synced, env.odr)
+	proof, err := statedb.GetProof(bankAddr)
+	if err != nil {
+		t.Fatalf("failed to prove account: %v", err)
+	}
+	proofDb := rawdb.NewMemoryDatabase()
+	for _, node := range proof {
+		proofDb.Put(crypto.Keccak256(node), node)
+	}
+	if val, err := trie.VerifyProof(synced.Root, crypto.Keccak256(bankAddr.Bytes()), proofDb); err != nil || len(val) == 0 {
+		t.Fatalf("failed to verify account proof: value %x, err %v", val, err)
+	}
+}
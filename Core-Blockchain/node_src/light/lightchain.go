@@ -22,6 +22,7 @@ package light
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math/big"
 	"sync"
 	"sync/atomic"
@@ -29,6 +30,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/congress"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
@@ -495,8 +497,8 @@ func (lc *LightChain) Config() *params.ChainConfig { return lc.hc.Config() }
 // SyncCheckpoint fetches the checkpoint point block header according to
 // the checkpoint provided by the remote peer.
 //
-// Note if we are running the clique, fetches the last epoch snapshot header
-// which covered by checkpoint.
+// Note if we are running the clique or congress, fetches the last epoch snapshot
+// header which covered by checkpoint.
 func (lc *LightChain) SyncCheckpoint(ctx context.Context, checkpoint *params.TrustedCheckpoint) bool {
 	// Ensure the remote checkpoint head is ahead of us
 	head := lc.CurrentHeader().Number.Uint64()
@@ -505,23 +507,104 @@ func (lc *LightChain) SyncCheckpoint(ctx context.Context, checkpoint *params.Tru
 	if clique := lc.hc.Config().Clique; clique != nil {
 		latest -= latest % clique.Epoch // epoch snapshot for clique
 	}
-	if head >= latest {
-		return true
+	var (
+		header *types.Header
+		err    error
+	)
+	if lc.hc.Config().Congress != nil {
+		// The congress epoch length may have been changed by governance, so the
+		// epoch snapshot is looked up checkpoint by checkpoint.
+		if header, err = lc.congressCheckpoint(ctx, latest); err != nil {
+			log.Error("Failed to retrieve congress checkpoint", "section", checkpoint.SectionIndex, "err", err)
+			return false
+		}
+		if header == nil {
+			return true
+		}
+	} else {
+		if head >= latest {
+			return true
+		}
+		// Retrieve the latest useful header and update to it
+		if header, err = GetHeaderByNumber(ctx, lc.odr, latest); header == nil || err != nil {
+			return false
+		}
 	}
-	// Retrieve the latest useful header and update to it
-	if header, err := GetHeaderByNumber(ctx, lc.odr, latest); header != nil && err == nil {
-		lc.chainmu.Lock()
-		defer lc.chainmu.Unlock()
+	lc.chainmu.Lock()
+	defer lc.chainmu.Unlock()
 
-		// Ensure the chain didn't move past the latest block while retrieving it
-		if lc.hc.CurrentHeader().Number.Uint64() < header.Number.Uint64() {
-			log.Info("Updated latest header based on CHT", "number", header.Number, "hash", header.Hash(), "age", common.PrettyAge(time.Unix(int64(header.Time), 0)))
-			rawdb.WriteHeadHeaderHash(lc.chainDb, header.Hash())
-			lc.hc.SetCurrentHeader(header)
+	// Ensure the chain didn't move past the latest block while retrieving it
+	if lc.hc.CurrentHeader().Number.Uint64() < header.Number.Uint64() {
+		log.Info("Updated latest header based on CHT", "number", header.Number, "hash", header.Hash(), "age", common.PrettyAge(time.Unix(int64(header.Time), 0)))
+		rawdb.WriteHeadHeaderHash(lc.chainDb, header.Hash())
+		lc.hc.SetCurrentHeader(header)
+	}
+	return true
+}
+
+// congressCheckpoint retrieves the last congress checkpoint header at or before
+// the given block, nil if it isn't ahead of the local head. The epoch length in
+// force is taken from the local head and followed along the checkpoints, whose
+// positions are checked against it.
+func (lc *LightChain) congressCheckpoint(ctx context.Context, latest uint64) (checkpoint *types.Header, err error) {
+	var (
+		config  = lc.hc.Config()
+		head    = lc.CurrentHeader()
+		number  = head.Number.Uint64()
+		epoch   = config.Congress.Epoch
+		fetched []*types.Header
+	)
+	if engine, ok := lc.engine.(*congress.Congress); ok {
+		if epoch, err = engine.EpochLength(lc, head); err != nil {
+			return nil, err
+		}
+	}
+	// Retrieved headers are stored, drop the ones probed past the checkpoint not
+	// to be taken for synced ones.
+	defer func() {
+		keep := head.Number.Uint64()
+		if err == nil && checkpoint != nil {
+			keep = checkpoint.Number.Uint64()
+		}
+		for _, header := range fetched {
+			if n := header.Number.Uint64(); n > keep {
+				rawdb.DeleteCanonicalHash(lc.chainDb, n)
+				rawdb.DeleteTd(lc.chainDb, header.Hash(), n)
+				rawdb.DeleteHeader(lc.chainDb, header.Hash(), n)
+			}
+		}
+	}()
+	for {
+		// Assuming the epoch length didn't change, jump to the last checkpoint
+		next := latest - latest%epoch
+		if next <= number {
+			return checkpoint, nil
+		}
+		header, err := GetHeaderByNumber(ctx, lc.odr, next)
+		if err != nil {
+			return nil, err
+		}
+		fetched = append(fetched, header)
+
+		if !congress.IsCheckpoint(header) {
+			// The epoch length was changed on the way, step to the next checkpoint
+			// instead. The configured epoch length holds until the GovParams fork.
+			next = number - number%epoch + epoch
+			if fork := config.GovParamsBlock; fork != nil && next < fork.Uint64() {
+				if first := (fork.Uint64() + epoch - 1) / epoch * epoch; first <= latest {
+					next = first
+				}
+			}
+			if header, err = GetHeaderByNumber(ctx, lc.odr, next); err != nil {
+				return nil, err
+			}
+			fetched = append(fetched, header)
+		}
+		if epoch, err = congress.CheckpointEpoch(config.Congress, header); err != nil {
+			return nil, fmt.Errorf("invalid congress checkpoint %d: %v", next, err)
 		}
-		return true
+		checkpoint, number = header, next
 	}
-	return false
 }
 
 // LockChain locks the chain mutex for reading so that multiple canonical hashes can be
@@ -19,7 +19,6 @@ package light
 
 import (
 	"context"
-	"errors"
 	"fmt"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -145,6 +144,13 @@ func (t *odrTrie) Commit(onleaf trie.LeafCallback) (common.Hash, int, error) {
 	return t.trie.Commit(onleaf)
 }
 
+func (t *odrTrie) CommittedNodes() *trie.NodeSet {
+	if t.trie == nil {
+		return nil
+	}
+	return t.trie.CommittedNodes()
+}
+
 func (t *odrTrie) Hash() common.Hash {
 	if t.trie == nil {
 		return t.id.Root
@@ -160,8 +166,18 @@ func (t *odrTrie) GetKey(sha []byte) []byte {
 	return nil
 }
 
+// Prove constructs a merkle proof for the already hashed key. The nodes on the
+// path are retrieved on demand and verified against the trie root before the
+// proof is assembled.
 func (t *odrTrie) Prove(key []byte, fromLevel uint, proofDb ethdb.KeyValueWriter) error {
-	return errors.New("not implemented, needs client/server interface split")
+	err := t.do(key, func() (err error) {
+		_, err = t.trie.TryGet(key)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return t.trie.Prove(key, fromLevel, proofDb)
 }
 
 // do tries and retries to execute a function until it returns with no error or
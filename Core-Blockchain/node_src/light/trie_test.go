@@ -30,6 +30,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/trie"
 )
@@ -61,6 +62,41 @@ func TestNodeIterator(t *testing.T) {
 	}
 }
 
+func TestOdrTrieProve(t *testing.T) {
+	var (
+		fulldb  = rawdb.NewMemoryDatabase()
+		lightdb = rawdb.NewMemoryDatabase()
+		gspec   = core.Genesis{
+			Alloc:   core.GenesisAlloc{testBankAddress: {Balance: testBankFunds}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		genesis = gspec.MustCommit(fulldb)
+	)
+	gspec.MustCommit(lightdb)
+	blockchain, _ := core.NewBlockChain(fulldb, nil, params.TestChainConfig, ethash.NewFullFaker(), vm.Config{}, nil, nil)
+	gchain, _ := core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), fulldb, 4, testChainGen)
+	if _, err := blockchain.InsertChain(gchain); err != nil {
+		panic(err)
+	}
+
+	odr := &testOdr{sdb: fulldb, ldb: lightdb, indexerConfig: TestClientIndexerConfig}
+	head := blockchain.CurrentHeader()
+	statedb := NewState(context.Background(), head, odr)
+
+	proof, err := statedb.GetProof(testBankAddress)
+	if err != nil {
+		t.Fatalf("failed to prove account: %v", err)
+	}
+	proofDb := rawdb.NewMemoryDatabase()
+	for _, node := range proof {
+		proofDb.Put(crypto.Keccak256(node), node)
+	}
+	val, err := trie.VerifyProof(head.Root, crypto.Keccak256(testBankAddress.Bytes()), proofDb)
+	if err != nil || len(val) == 0 {
+		t.Fatalf("failed to verify account proof: value %x, err %v", val, err)
+	}
+}
+
 func diffTries(t1, t2 state.Trie) error {
 	i1 := trie.NewIterator(t1.NodeIterator(nil))
 	i2 := trie.NewIterator(t2.NodeIterator(nil))
@@ -0,0 +1,194 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package miner
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// DefaultBlockBuilder is the name of the block-building policy used if none is
+// configured, ordering the transactions by price and nonce.
+const DefaultBlockBuilder = "price"
+
+// TxIterator walks pending transactions in the order a policy includes them in,
+// honouring the nonces of every sender.
+type TxIterator interface {
+	// Peek returns the next transaction to try, or nil if there are none left.
+	Peek() *types.Transaction
+
+	// Shift replaces the next transaction with the following one of its sender.
+	Shift()
+
+	// Pop drops the next transaction along with all the following ones of its
+	// sender.
+	Pop()
+}
+
+// BuildState is the progress of the block being built, as seen by a policy.
+type BuildState struct {
+	Header   *types.Header          // Header of the block, with the gas used so far
+	Included map[common.Address]int // Number of transactions included per sender
+}
+
+// newBuildState creates the build state of an empty block.
+func newBuildState(header *types.Header) *BuildState {
+	return &BuildState{Header: header, Included: make(map[common.Address]int)}
+}
+
+// BlockBuilder is a block-building policy, deciding the order the pending
+// transactions are tried in and which of them a block admits. Policies must be
+// deterministic, building the same block out of the same pending set and state.
+type BlockBuilder interface {
+	// Name returns the name of the policy.
+	Name() string
+
+	// Order returns an iterator over the given pending transactions, grouped
+	// by sender and nonce sorted. The map is reowned by the iterator.
+	Order(signer types.Signer, pending map[common.Address]types.Transactions, baseFee *big.Int) TxIterator
+
+	// Admit reports whether the next transaction of the iterator may enter the
+	// block. Rejected transactions are dropped along with the following ones of
+	// their sender.
+	Admit(state *BuildState, from common.Address, tx *types.Transaction) bool
+}
+
+var (
+	blockBuildersLock sync.RWMutex
+	blockBuilders     = map[string]func(config *Config) BlockBuilder{
+		"price": newPriceBuilder,
+		"fifo":  newFifoBuilder,
+	}
+)
+
+// RegisterBlockBuilder makes a block-building policy selectable by name. It is
+// meant to be called from the init function of the package defining the policy.
+func RegisterBlockBuilder(name string, constructor func(config *Config) BlockBuilder) {
+	blockBuildersLock.Lock()
+	defer blockBuildersLock.Unlock()
+
+	blockBuilders[name] = constructor
+}
+
+// BlockBuilders returns the names of the selectable block-building policies.
+func BlockBuilders() []string {
+	blockBuildersLock.RLock()
+	defer blockBuildersLock.RUnlock()
+
+	names := make([]string, 0, len(blockBuilders))
+	for name := range blockBuilders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewBlockBuilder creates the block-building policy selected by the config, the
+// default one if none is.
+func NewBlockBuilder(config *Config) (BlockBuilder, error) {
+	name := config.Policy
+	if name == "" {
+		name = DefaultBlockBuilder
+	}
+	blockBuildersLock.RLock()
+	constructor, ok := blockBuilders[name]
+	blockBuildersLock.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown block-building policy %q", name)
+	}
+	return constructor(config), nil
+}
+
+// builderLimits are the admission rules shared by the built-in policies: a cap on
+// the transactions of a sender per block, and gas kept for x402 transactions.
+type builderLimits struct {
+	senderCap   int    // Maximum number of transactions per sender in a block, 0 for no cap
+	x402Reserve uint64 // Percentage of the block gas only x402 transactions can use
+}
+
+func newBuilderLimits(config *Config) builderLimits {
+	reserve := config.X402Reserve
+	if reserve > 100 {
+		log.Warn("Sanitizing invalid miner x402 gas reserve", "provided", reserve, "updated", 100)
+		reserve = 100
+	}
+	return builderLimits{senderCap: config.SenderCap, x402Reserve: reserve}
+}
+
+// Admit implements BlockBuilder, applying the sender cap and the x402 reserve.
+func (l builderLimits) Admit(state *BuildState, from common.Address, tx *types.Transaction) bool {
+	if l.senderCap > 0 && state.Included[from] >= l.senderCap {
+		return false
+	}
+	if l.x402Reserve > 0 && !isX402(tx, state.Header) {
+		header := state.Header
+		reserved := header.GasLimit / 100 * l.x402Reserve
+		if header.GasUsed+tx.Gas() > header.GasLimit-reserved {
+			return false
+		}
+	}
+	return true
+}
+
+// isX402 reports whether a transaction is an x402 payment, be it an envelope or a
+// transaction the state transition treats as one.
+func isX402(tx *types.Transaction, header *types.Header) bool {
+	return tx.Type() == types.X402TxType || core.IsX402Transaction(tx.To(), tx.Data(), header.Number)
+}
+
+// priceBuilder is the default policy, including the best paying transactions
+// first and the earliest seen ones first among equal payers.
+type priceBuilder struct {
+	builderLimits
+}
+
+func newPriceBuilder(config *Config) BlockBuilder {
+	return &priceBuilder{builderLimits: newBuilderLimits(config)}
+}
+
+// Name implements BlockBuilder.
+func (b *priceBuilder) Name() string { return "price" }
+
+// Order implements BlockBuilder, sorting the transactions by price and nonce.
+func (b *priceBuilder) Order(signer types.Signer, pending map[common.Address]types.Transactions, baseFee *big.Int) TxIterator {
+	return types.NewTransactionsByPriceAndNonce(signer, pending, baseFee)
+}
+
+// fifoBuilder is the fair-ordering policy, grouping the transactions into bands
+// of miner fees and including the earliest seen ones first within a band. Paying
+// slightly more than a competitor thus doesn't jump the queue.
+type fifoBuilder struct {
+	builderLimits
+	band *big.Int // Width of the miner fee bands, in wei
+}
+
+func newFifoBuilder(config *Config) BlockBuilder {
+	band := config.PriceBand
+	if band == nil || band.Sign() <= 0 {
+		band = big.NewInt(1)
+	}
+	return &fifoBuilder{builderLimits: newBuilderLimits(config), band: new(big.Int).Set(band)}
+}
+
+// Name implements BlockBuilder.
+func (b *fifoBuilder) Name() string { return "fifo" }
+
+// Order implements BlockBuilder, sorting the transactions by price band, arrival
+// and nonce.
+func (b *fifoBuilder) Order(signer types.Signer, pending map[common.Address]types.Transactions, baseFee *big.Int) TxIterator {
+	return types.NewTransactionsByPriceBandAndNonce(signer, pending, baseFee, b.band)
+}
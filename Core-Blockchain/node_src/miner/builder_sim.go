@@ -0,0 +1,192 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package miner
+
+import (
+	"errors"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// SimulatedBlock is the block a policy built out of a pool snapshot.
+type SimulatedBlock struct {
+	Policy  string        // Name of the building policy
+	Txs     []common.Hash // Hashes of the included transactions, in block order
+	GasUsed uint64        // Gas used by the included transactions
+	Fees    *big.Int      // Miner tips earned with the block
+}
+
+// BlockDiff is the difference between the blocks two policies built.
+type BlockDiff struct {
+	Missing   []common.Hash // Transactions of the first block missing from the second
+	Extra     []common.Hash // Transactions of the second block missing from the first
+	Reordered bool          // Whether the shared transactions are in a different order
+}
+
+// Equal reports whether the two blocks are identical.
+func (d *BlockDiff) Equal() bool {
+	return len(d.Missing) == 0 && len(d.Extra) == 0 && !d.Reordered
+}
+
+// Diff compares the block with another one built out of the same snapshot.
+func (b *SimulatedBlock) Diff(other *SimulatedBlock) *BlockDiff {
+	var (
+		diff   = new(BlockDiff)
+		ours   = make(map[common.Hash]bool, len(b.Txs))
+		theirs = make(map[common.Hash]bool, len(other.Txs))
+	)
+	for _, hash := range b.Txs {
+		ours[hash] = true
+	}
+	for _, hash := range other.Txs {
+		theirs[hash] = true
+		if !ours[hash] {
+			diff.Extra = append(diff.Extra, hash)
+		}
+	}
+	var shared []common.Hash
+	for _, hash := range b.Txs {
+		if theirs[hash] {
+			shared = append(shared, hash)
+		} else {
+			diff.Missing = append(diff.Missing, hash)
+		}
+	}
+	i := 0
+	for _, hash := range other.Txs {
+		if ours[hash] {
+			if shared[i] != hash {
+				diff.Reordered = true
+				break
+			}
+			i++
+		}
+	}
+	return diff
+}
+
+// simChain is the chain context of the simulations, which don't have access to
+// the historical headers.
+type simChain struct{}
+
+func (simChain) Engine() consensus.Engine                    { return nil }
+func (simChain) GetHeader(common.Hash, uint64) *types.Header { return nil }
+
+// simulationPending groups the transactions of a snapshot into the nonce sorted
+// executable sequences of every sender, as the pool would return them.
+func simulationPending(signer types.Signer, statedb *state.StateDB, txs []*types.Transaction) map[common.Address]types.Transactions {
+	bySender := make(map[common.Address]types.Transactions)
+	for _, tx := range txs {
+		from, err := types.Sender(signer, tx)
+		if err != nil {
+			continue
+		}
+		bySender[from] = append(bySender[from], tx)
+	}
+	pending := make(map[common.Address]types.Transactions)
+	for from, list := range bySender {
+		sort.Sort(types.TxByNonce(list))
+
+		nonce := statedb.GetNonce(from)
+		var executable types.Transactions
+		for _, tx := range list {
+			if tx.Nonce() < nonce {
+				continue
+			}
+			if tx.Nonce() > nonce {
+				break
+			}
+			executable = append(executable, tx)
+			nonce++
+		}
+		if len(executable) > 0 {
+			pending[from] = executable
+		}
+	}
+	return pending
+}
+
+// SimulateBlock builds a block on top of the given state out of a recorded pool
+// snapshot with the given policy, following the ordering and admission rules of
+// the worker. The header sets the block context, its gas used is ignored. The
+// state isn't modified.
+//
+// The simulation only compares the policies. Unlike the worker, it doesn't fill
+// the gas shares of the priority lanes first, doesn't include bundles, and skips
+// the transaction checks of a PoSA engine and the blocklist of the EVM, so it may
+// include transactions the worker would leave out.
+func SimulateBlock(config *params.ChainConfig, statedb *state.StateDB, header *types.Header, snapshot []*types.Transaction, builder BlockBuilder) *SimulatedBlock {
+	var (
+		signer = types.MakeSigner(config, header.Number)
+		env    = types.CopyHeader(header)
+		build  = newBuildState(env)
+		gp     = new(core.GasPool).AddGas(env.GasLimit)
+		block  = &SimulatedBlock{Policy: builder.Name(), Fees: new(big.Int)}
+	)
+	env.GasUsed = 0
+	statedb = statedb.Copy()
+
+	txs := builder.Order(signer, simulationPending(signer, statedb, snapshot), env.BaseFee)
+	for gp.Gas() >= params.TxGas {
+		tx := txs.Peek()
+		if tx == nil {
+			break
+		}
+		from, _ := types.Sender(signer, tx)
+		if !builder.Admit(build, from, tx) {
+			txs.Pop()
+			continue
+		}
+		snap := statedb.Snapshot()
+		statedb.Prepare(tx.Hash(), len(block.Txs))
+
+		receipt, err := core.ApplyTransaction(config, simChain{}, &env.Coinbase, gp, statedb, env, tx, &env.GasUsed, vm.Config{}, nil)
+		switch {
+		case err == nil:
+			block.Txs = append(block.Txs, tx.Hash())
+			build.Included[from]++
+			if tip, err := tx.EffectiveGasTip(env.BaseFee); err == nil {
+				block.Fees.Add(block.Fees, new(big.Int).Mul(tip, new(big.Int).SetUint64(receipt.GasUsed)))
+			}
+			txs.Shift()
+
+		case errors.Is(err, core.ErrNonceTooLow):
+			statedb.RevertToSnapshot(snap)
+			txs.Shift()
+
+		case errors.Is(err, core.ErrGasLimitReached), errors.Is(err, core.ErrNonceTooHigh), errors.Is(err, core.ErrTxTypeNotSupported):
+			statedb.RevertToSnapshot(snap)
+			txs.Pop()
+
+		default:
+			statedb.RevertToSnapshot(snap)
+			txs.Shift()
+		}
+	}
+	block.GasUsed = env.GasUsed
+	return block
+}
+
+// SimulateBuilders builds a block out of the same recorded pool snapshot with
+// each of the given policies, so their blocks can be compared.
+func SimulateBuilders(config *params.ChainConfig, statedb *state.StateDB, header *types.Header, snapshot []*types.Transaction, builders []BlockBuilder) []*SimulatedBlock {
+	blocks := make([]*SimulatedBlock, len(builders))
+	for i, builder := range builders {
+		blocks[i] = SimulateBlock(config, statedb, header, snapshot, builder)
+	}
+	return blocks
+}
@@ -0,0 +1,157 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package miner
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// builderTester is a recorded pool snapshot along with the state to replay it on.
+type builderTester struct {
+	keys    []*ecdsa.PrivateKey
+	statedb *state.StateDB
+	header  *types.Header
+	signer  types.Signer
+	txs     []*types.Transaction
+}
+
+func newBuilderTester(t *testing.T, accounts int, gasLimit uint64) *builderTester {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	tester := &builderTester{
+		statedb: statedb,
+		header: &types.Header{
+			Number:   big.NewInt(1),
+			GasLimit: gasLimit,
+			BaseFee:  big.NewInt(1),
+			Time:     uint64(time.Now().Unix()),
+		},
+		signer: types.LatestSigner(params.TestChainConfig),
+	}
+	for i := 0; i < accounts; i++ {
+		key, _ := crypto.GenerateKey()
+		tester.keys = append(tester.keys, key)
+		statedb.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(params.Ether))
+	}
+	return tester
+}
+
+// add records a transfer in the snapshot, first seen at the given second.
+func (b *builderTester) add(account int, nonce uint64, price int64, seen int64) *types.Transaction {
+	tx, _ := types.SignTx(types.NewTransaction(nonce, common.Address{0xff}, big.NewInt(1), params.TxGas, big.NewInt(price), nil), b.signer, b.keys[account])
+	tx.SetTime(time.Unix(seen, 0))
+	b.txs = append(b.txs, tx)
+	return tx
+}
+
+// replay round trips the recorded snapshot through its encoding, and builds a
+// block out of it with every given policy.
+func (b *builderTester) replay(t *testing.T, builders ...BlockBuilder) []*SimulatedBlock {
+	blob, err := core.EncodeTxPoolSnapshot(b.txs)
+	if err != nil {
+		t.Fatalf("failed to encode snapshot: %v", err)
+	}
+	snapshot, err := core.DecodeTxPoolSnapshot(blob)
+	if err != nil {
+		t.Fatalf("failed to decode snapshot: %v", err)
+	}
+	return SimulateBuilders(params.TestChainConfig, b.statedb, b.header, snapshot, builders)
+}
+
+func checkBlockTxs(t *testing.T, block *SimulatedBlock, want ...*types.Transaction) {
+	t.Helper()
+
+	if len(block.Txs) != len(want) {
+		t.Fatalf("%s: included transactions mismatch: have %d, want %d", block.Policy, len(block.Txs), len(want))
+	}
+	for i, tx := range want {
+		if block.Txs[i] != tx.Hash() {
+			t.Errorf("%s: transaction %d mismatch: have %x, want %x", block.Policy, i, block.Txs[i], tx.Hash())
+		}
+	}
+}
+
+// Tests that the price policy includes the best payers first, while the fifo
+// policy includes the earliest seen first within a price band.
+func TestBlockBuilderOrdering(t *testing.T) {
+	tester := newBuilderTester(t, 3, 1000000)
+
+	early := tester.add(0, 0, 1500000000, 1)
+	later := tester.add(1, 0, 1900000000, 2)
+	best := tester.add(2, 0, 3000000000, 3)
+
+	blocks := tester.replay(t,
+		newPriceBuilder(&Config{}),
+		newFifoBuilder(&Config{PriceBand: big.NewInt(params.GWei)}),
+	)
+	checkBlockTxs(t, blocks[0], best, later, early)
+	checkBlockTxs(t, blocks[1], best, early, later)
+
+	if diff := blocks[0].Diff(blocks[1]); len(diff.Missing) != 0 || len(diff.Extra) != 0 || !diff.Reordered {
+		t.Errorf("block diff mismatch: %+v", diff)
+	}
+	if blocks[0].GasUsed != 3*params.TxGas {
+		t.Errorf("gas used mismatch: have %d, want %d", blocks[0].GasUsed, 3*params.TxGas)
+	}
+	// Replays of the same snapshot are deterministic
+	again := tester.replay(t, newPriceBuilder(&Config{}))
+	if diff := blocks[0].Diff(again[0]); !diff.Equal() {
+		t.Errorf("replayed block differs: %+v", diff)
+	}
+}
+
+// Tests that the sender cap and the x402 reserve limit the admitted transactions.
+func TestBlockBuilderLimits(t *testing.T) {
+	tester := newBuilderTester(t, 3, 100000)
+
+	first := tester.add(0, 0, 3000000000, 1)
+	second := tester.add(0, 1, 3000000000, 1)
+	tester.add(0, 2, 3000000000, 1)
+	other := tester.add(1, 0, 2000000000, 2)
+
+	// Payments the state transition treats as x402 use the reserve too
+	token := common.HexToAddress("0x8e519737d890df040b027b292C9aD2c321bC64dD")
+	payment, _ := types.SignTx(types.NewTransaction(0, token, big.NewInt(0), 25000, big.NewInt(1000000000), append([]byte("x402"), make([]byte, 8)...)), tester.signer, tester.keys[2])
+	payment.SetTime(time.Unix(3, 0))
+	tester.txs = append(tester.txs, payment)
+
+	blocks := tester.replay(t,
+		newPriceBuilder(&Config{SenderCap: 2}),
+		newPriceBuilder(&Config{X402Reserve: 50}),
+	)
+	checkBlockTxs(t, blocks[0], first, second, other, payment)
+	checkBlockTxs(t, blocks[1], first, second, payment)
+
+	if diff := blocks[0].Diff(blocks[1]); len(diff.Missing) != 1 || diff.Missing[0] != other.Hash() || diff.Reordered {
+		t.Errorf("block diff mismatch: %+v", diff)
+	}
+}
+
+// Tests that policies are selected by name, and that unknown ones are rejected.
+func TestBlockBuilderSelection(t *testing.T) {
+	if _, err := NewBlockBuilder(&Config{Policy: "unknown"}); err == nil {
+		t.Errorf("unknown policy accepted")
+	}
+	if builder, err := NewBlockBuilder(&Config{}); err != nil || builder.Name() != DefaultBlockBuilder {
+		t.Errorf("default policy mismatch: have %v (%v), want %s", builder, err, DefaultBlockBuilder)
+	}
+	if builder, err := NewBlockBuilder(&Config{Policy: "fifo"}); err != nil || builder.Name() != "fifo" {
+		t.Errorf("selected policy mismatch: have %v (%v), want fifo", builder, err)
+	}
+}
@@ -53,6 +53,11 @@ type Config struct {
 	GasPrice   *big.Int       // Minimum gas price for mining a transaction
 	Recommit   time.Duration  // The time interval for miner to re-create mining work.
 	Noverify   bool           // Disable remote mining solution verification(only useful in ethash).
+
+	Policy      string   `toml:",omitempty"` // Block-building policy ordering the transactions (default = price)
+	PriceBand   *big.Int `toml:",omitempty"` // Width of the miner fee bands of the fifo policy
+	SenderCap   int      `toml:",omitempty"` // Maximum number of transactions per sender in a block (0 = no cap)
+	X402Reserve uint64   `toml:",omitempty"` // Percentage of the block gas only x402 transactions can use
 }
 
 // Miner creates blocks and searches for proof-of-work values.
@@ -239,3 +244,9 @@ func (miner *Miner) DisablePreseal() {
 func (miner *Miner) SubscribePendingLogs(ch chan<- []*types.Log) event.Subscription {
 	return miner.worker.pendingLogsFeed.Subscribe(ch)
 }
+
+// SubscribePendingBlock starts delivering the pending block to the given channel
+// each time the worker recommits it.
+func (miner *Miner) SubscribePendingBlock(ch chan<- core.PendingBlockEvent) event.Subscription {
+	return miner.worker.pendingBlockFeed.Subscribe(ch)
+}
@@ -89,6 +89,7 @@ type environment struct {
 	uncles    mapset.Set     // uncle set
 	tcount    int            // tx count in cycle
 	gasPool   *core.GasPool  // available gas used to pack transactions
+	build     *BuildState    // progress of the block as seen by the building policy
 
 	header   *types.Header
 	txs      []*types.Transaction
@@ -137,8 +138,12 @@ type worker struct {
 	posa   consensus.PoSA
 	isPoSA bool
 
+	// Block-building policy ordering and admitting the transactions
+	builder BlockBuilder
+
 	// Feeds
-	pendingLogsFeed event.Feed
+	pendingLogsFeed  event.Feed
+	pendingBlockFeed event.Feed
 
 	// Subscriptions
 	mux          *event.TypeMux
@@ -157,6 +162,7 @@ type worker struct {
 	exitCh             chan struct{}
 	resubmitIntervalCh chan time.Duration
 	resubmitAdjustCh   chan *intervalAdjust
+	pendingBlockCh     chan core.PendingBlockEvent // Latest pending block not yet sent on the feed
 
 	wg sync.WaitGroup
 
@@ -200,12 +206,17 @@ type worker struct {
 
 func newWorker(config *Config, chainConfig *params.ChainConfig, engine consensus.Engine, eth Backend, mux *event.TypeMux, isLocalBlock func(*types.Block) bool, init bool) *worker {
 	posa, isPoSA := engine.(consensus.PoSA)
+	builder, err := NewBlockBuilder(config)
+	if err != nil {
+		log.Crit("Invalid miner config", "err", err) // Rejected by the config validation
+	}
 	worker := &worker{
 		config:             config,
 		chainConfig:        chainConfig,
 		engine:             engine,
 		isPoSA:             isPoSA,
 		posa:               posa,
+		builder:            builder,
 		eth:                eth,
 		mux:                mux,
 		chain:              eth.BlockChain(),
@@ -224,6 +235,7 @@ func newWorker(config *Config, chainConfig *params.ChainConfig, engine consensus
 		startCh:            make(chan struct{}, 1),
 		resubmitIntervalCh: make(chan time.Duration),
 		resubmitAdjustCh:   make(chan *intervalAdjust, resubmitAdjustChanSize),
+		pendingBlockCh:     make(chan core.PendingBlockEvent, 1),
 	}
 	// Subscribe NewTxsEvent for tx pool
 	worker.txsSub = eth.TxPool().SubscribeNewTxsEvent(worker.txsCh)
@@ -238,11 +250,12 @@ func newWorker(config *Config, chainConfig *params.ChainConfig, engine consensus
 		recommit = minRecommitInterval
 	}
 
-	worker.wg.Add(4)
+	worker.wg.Add(5)
 	go worker.mainLoop()
 	go worker.newWorkLoop(recommit)
 	go worker.resultLoop()
 	go worker.taskLoop()
+	go worker.pendingBlockLoop()
 
 	// Submit first work to initialize pending state.
 	if init {
@@ -533,7 +546,7 @@ func (w *worker) mainLoop() {
 					acc, _ := types.Sender(w.current.signer, tx)
 					txs[acc] = append(txs[acc], tx)
 				}
-				txset := types.NewTransactionsByPriceAndNonce(w.current.signer, txs, w.current.header.BaseFee)
+				txset := w.builder.Order(w.current.signer, txs, w.current.header.BaseFee)
 				tcount := w.current.tcount
 				w.commitTransactions(txset, coinbase, nil)
 				// Only update the snapshot if any new transactons were added
@@ -705,6 +718,7 @@ func (w *worker) makeCurrent(parent *types.Block, header *types.Header) error {
 		family:    mapset.NewSet(),
 		uncles:    mapset.NewSet(),
 		header:    header,
+		build:     newBuildState(header),
 	}
 	// when 08 is processed ancestors contain 07 (quick block)
 	for _, ancestor := range w.chain.GetBlocksFromHash(parent.Hash(), 7) {
@@ -749,7 +763,6 @@ func (w *worker) commitUncle(env *environment, uncle *types.Header) error {
 // Note this function assumes the current variable is thread safe.
 func (w *worker) updateSnapshot() {
 	w.snapshotMu.Lock()
-	defer w.snapshotMu.Unlock()
 
 	var uncles []*types.Header
 	w.current.uncles.Each(func(item interface{}) bool {
@@ -777,6 +790,37 @@ func (w *worker) updateSnapshot() {
 	)
 	w.snapshotReceipts = copyReceipts(w.current.receipts)
 	w.snapshotState = w.current.state.Copy()
+	event := core.PendingBlockEvent{Block: w.snapshotBlock, Receipts: copyReceipts(w.snapshotReceipts)}
+	w.snapshotMu.Unlock()
+
+	// Hand the block over to the feed without waiting for the subscribers, the
+	// newer block replaces one still waiting to be sent
+	for {
+		select {
+		case w.pendingBlockCh <- event:
+			return
+		default:
+		}
+		select {
+		case <-w.pendingBlockCh:
+		default:
+		}
+	}
+}
+
+// pendingBlockLoop sends the pending blocks on the feed, so slow subscribers
+// don't hold up the worker.
+func (w *worker) pendingBlockLoop() {
+	defer w.wg.Done()
+
+	for {
+		select {
+		case event := <-w.pendingBlockCh:
+			w.pendingBlockFeed.Send(event)
+		case <-w.exitCh:
+			return
+		}
+	}
 }
 
 func (w *worker) commitTransaction(tx *types.Transaction, coinbase common.Address) ([]*types.Log, error) {
@@ -793,7 +837,141 @@ func (w *worker) commitTransaction(tx *types.Transaction, coinbase common.Addres
 	return receipt.Logs, nil
 }
 
-func (w *worker) commitTransactions(txs *types.TransactionsByPriceAndNonce, coinbase common.Address, interrupt *int32) bool {
+// commitBundles applies the given bundles in order ahead of the pool transactions,
+// each one entirely or not at all. It returns whether the work was interrupted by
+// a new head.
+func (w *worker) commitBundles(bundles []*types.Bundle, coinbase common.Address, interrupt *int32) bool {
+	if w.current.gasPool == nil {
+		w.current.gasPool = new(core.GasPool).AddGas(w.current.header.GasLimit)
+	}
+	for _, bundle := range bundles {
+		// Leave the resubmit interval adjustment to the transactions following
+		if interrupt != nil && atomic.LoadInt32(interrupt) != commitInterruptNone {
+			return atomic.LoadInt32(interrupt) == commitInterruptNewHead
+		}
+		if w.current.gasPool.Gas() < params.TxGas {
+			log.Trace("Not enough gas for further bundles", "have", w.current.gasPool, "want", params.TxGas)
+			return false
+		}
+		if !w.admitBundle(bundle) {
+			log.Trace("Ignoring bundle rejected by the building policy", "hash", bundle.Hash(), "policy", w.builder.Name())
+			continue
+		}
+		state, results, err := core.ApplyBundle(w.chainConfig, w.chain, &coinbase, w.current.gasPool, w.current.state, w.current.header, bundle, w.current.tcount, &w.current.header.GasUsed, *w.chain.GetVMConfig(), w.posa)
+		if err != nil {
+			log.Trace("Skipping bundle", "hash", bundle.Hash(), "err", err)
+			continue
+		}
+		// The bundle ran on a copy of the state, continue with its result
+		w.current.state.StopPrefetcher()
+		w.current.state = state
+		for _, result := range results {
+			w.current.txs = append(w.current.txs, result.Tx)
+			w.current.receipts = append(w.current.receipts, result.Receipt)
+			w.current.tcount++
+			w.current.build.Included[result.From]++
+		}
+	}
+	return false
+}
+
+// admitBundle reports whether the block-building policy admits all transactions
+// of a bundle, counting each one as included with its whole gas limit before
+// admitting the next.
+func (w *worker) admitBundle(bundle *types.Bundle) bool {
+	build := &BuildState{
+		Header:   types.CopyHeader(w.current.header),
+		Included: make(map[common.Address]int, len(w.current.build.Included)),
+	}
+	for from, n := range w.current.build.Included {
+		build.Included[from] = n
+	}
+	for _, tx := range bundle.Txs {
+		from, _ := types.Sender(w.current.signer, tx) // already validated by the pool
+		if !w.builder.Admit(build, from, tx) {
+			return false
+		}
+		build.Included[from]++
+		build.Header.GasUsed += tx.Gas()
+	}
+	return true
+}
+
+// conditionsHold reports whether the conditions of a transaction, if the pool
+// holds it as a conditional one, hold at its position in the current block.
+func (w *worker) conditionsHold(tx *types.Transaction) bool {
+	entry := w.eth.TxPool().Conditional(tx.Hash())
+	if entry == nil {
+		return true
+	}
+	parent := w.chain.GetHeaderByHash(w.current.header.ParentHash)
+	if parent == nil {
+		return false
+	}
+	return core.ConditionsHold(entry.Conditions, w.current.header.Number.Uint64(), parent.Time, w.current.state)
+}
+
+// commitLanes fills the reserved gas share of each priority lane with its pending
+// transactions, in lane order, and drops the included ones from pending. It
+// returns whether the work was interrupted by a new head.
+func (w *worker) commitLanes(pending map[common.Address]types.Transactions, interrupt *int32) bool {
+	pool := w.eth.TxPool()
+	if w.current.gasPool == nil {
+		w.current.gasPool = new(core.GasPool).AddGas(w.current.header.GasLimit)
+	}
+	for _, lane := range core.ReservedTxLanes {
+		config := pool.LaneConfig(lane)
+		if config.GasShare == 0 {
+			continue
+		}
+		laneTxs := pool.LanePending(pending, lane)
+		if len(laneTxs) == 0 {
+			continue
+		}
+		accounts := make([]common.Address, 0, len(laneTxs))
+		for addr := range laneTxs {
+			accounts = append(accounts, addr)
+		}
+		var txs TxIterator
+		if config.ByArrival {
+			txs = types.NewTransactionsByArrivalAndNonce(w.current.signer, laneTxs)
+		} else {
+			txs = types.NewTransactionsByPriceAndNonce(w.current.signer, laneTxs, w.current.header.BaseFee)
+		}
+		// Run the lane against its share of the block gas only
+		share := w.current.header.GasLimit * config.GasShare / 100
+		if left := w.current.gasPool.Gas(); share > left {
+			share = left
+		}
+		blockPool, tcount := w.current.gasPool, w.current.tcount
+		w.current.gasPool = new(core.GasPool).AddGas(share)
+		interrupted := w.commitTransactions(txs, w.coinbase, interrupt)
+		used := share - w.current.gasPool.Gas()
+		w.current.gasPool = blockPool
+		w.current.gasPool.SubGas(used)
+
+		core.MarkLaneInclusions(lane, w.current.tcount-tcount)
+		if interrupted {
+			return true
+		}
+		// Drop the included transactions from the pending set
+		for _, addr := range accounts {
+			nonce := w.current.state.GetNonce(addr)
+			list := pending[addr]
+			for len(list) > 0 && list[0].Nonce() < nonce {
+				list = list[1:]
+			}
+			if len(list) == 0 {
+				delete(pending, addr)
+			} else {
+				pending[addr] = list
+			}
+		}
+	}
+	return false
+}
+
+func (w *worker) commitTransactions(txs TxIterator, coinbase common.Address, interrupt *int32) bool {
 	// Short circuit if current is nil
 	if w.current == nil {
 		return true
@@ -859,6 +1037,18 @@ func (w *worker) commitTransactions(txs *types.TransactionsByPriceAndNonce, coin
 				continue
 			}
 		}
+		// Block-building policy admission
+		if !w.builder.Admit(w.current.build, from, tx) {
+			log.Trace("Ignoring transaction rejected by the building policy", "hash", tx.Hash(), "from", from, "policy", w.builder.Name())
+			txs.Pop()
+			continue
+		}
+		// Conditional transactions only go in while their conditions hold
+		if !w.conditionsHold(tx) {
+			log.Trace("Ignoring conditional transaction not meeting its conditions", "hash", tx.Hash(), "from", from)
+			txs.Pop()
+			continue
+		}
 		// Start executing the transaction
 		w.current.state.Prepare(tx.Hash(), w.current.tcount)
 
@@ -883,6 +1073,7 @@ func (w *worker) commitTransactions(txs *types.TransactionsByPriceAndNonce, coin
 			// Everything ok, collect the logs and shift in the next transaction from the same account
 			coalescedLogs = append(coalescedLogs, logs...)
 			w.current.tcount++
+			w.current.build.Included[from]++
 			txs.Shift()
 
 		case errors.Is(err, core.ErrTxTypeNotSupported):
@@ -1017,15 +1208,24 @@ func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64)
 		w.commit(uncles, nil, false, tstart)
 	}
 
-	// Fill the block with all available pending transactions.
+	// Fill the block with the bundles first, then with all available pending transactions.
+	if w.commitBundles(w.eth.TxPool().Bundles(header.Number.Uint64()), w.coinbase, interrupt) {
+		w.current.state.StopPrefetcher()
+		return
+	}
 	pending := w.eth.TxPool().Pending(true)
 	// Short circuit if there is no available pending transactions.
 	// But if we disable empty precommit already, ignore it. Since
 	// empty block is necessary to keep the liveness of the network.
-	if len(pending) == 0 && atomic.LoadUint32(&w.noempty) == 0 {
+	if len(pending) == 0 && w.current.tcount == 0 && atomic.LoadUint32(&w.noempty) == 0 {
 		w.updateSnapshot()
 		return
 	}
+	// Fill the reserved gas share of the priority lanes first
+	if w.commitLanes(pending, interrupt) {
+		w.current.state.StopPrefetcher()
+		return
+	}
 	// Split the pending transactions into locals and remotes
 	localTxs, remoteTxs := make(map[common.Address]types.Transactions), pending
 	for _, account := range w.eth.TxPool().Locals() {
@@ -1035,14 +1235,14 @@ func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64)
 		}
 	}
  	if len(localTxs) > 0 {
-		txs := types.NewTransactionsByPriceAndNonce(w.current.signer, localTxs, header.BaseFee)
+		txs := w.builder.Order(w.current.signer, localTxs, header.BaseFee)
 		if w.commitTransactions(txs, w.coinbase, interrupt) {
 			w.current.state.StopPrefetcher()
 			return
 		}
 	}
 	if len(remoteTxs) > 0 {
-		txs := types.NewTransactionsByPriceAndNonce(w.current.signer, remoteTxs, header.BaseFee)
+		txs := w.builder.Order(w.current.signer, remoteTxs, header.BaseFee)
 		if w.commitTransactions(txs, w.coinbase, interrupt) {
 			w.current.state.StopPrefetcher()
 			return
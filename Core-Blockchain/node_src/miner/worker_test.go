@@ -26,6 +26,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/consensus/clique"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
@@ -522,3 +523,106 @@ func testAdjustInterval(t *testing.T, chainConfig *params.ChainConfig, engine co
 		t.Error("interval reset timeout")
 	}
 }
+
+func TestAdmitBundle(t *testing.T) {
+	engine := ethash.NewFaker()
+	defer engine.Close()
+
+	w, _ := newTestWorker(t, ethashChainConfig, engine, rawdb.NewMemoryDatabase(), 0)
+	defer w.close()
+
+	header := &types.Header{Number: big.NewInt(1), GasLimit: params.GenesisGasLimit}
+	w.builder = newPriceBuilder(&Config{SenderCap: 1})
+	w.current = &environment{header: header, build: newBuildState(header), signer: types.LatestSigner(ethashChainConfig)}
+
+	if !w.admitBundle(&types.Bundle{Txs: types.Transactions{pendingTxs[0]}}) {
+		t.Fatal("single transaction bundle rejected")
+	}
+	if w.admitBundle(&types.Bundle{Txs: types.Transactions{pendingTxs[0], newTxs[0]}}) {
+		t.Fatal("bundle over the sender cap admitted")
+	}
+	if len(w.current.build.Included) != 0 || header.GasUsed != 0 {
+		t.Fatal("admission changed the build state")
+	}
+	w.current.build.Included[testBankAddress] = 1
+	if w.admitBundle(&types.Bundle{Txs: types.Transactions{newTxs[0]}}) {
+		t.Fatal("bundle of an included sender admitted over the cap")
+	}
+}
+
+func TestConditionsHold(t *testing.T) {
+	engine := ethash.NewFaker()
+	defer engine.Close()
+
+	w, b := newTestWorker(t, ethashChainConfig, engine, rawdb.NewMemoryDatabase(), 0)
+	defer w.close()
+
+	max := hexutil.Uint64(10)
+	conditions := &types.TxConditions{
+		BlockNumberMax: &max,
+		MinBalances:    map[common.Address]*hexutil.Big{testUserAddress: (*hexutil.Big)(big.NewInt(1))},
+	}
+	if err := b.txPool.AddConditional(newTxs[0], conditions, false); err != nil {
+		t.Fatalf("failed to add conditional transaction: %v", err)
+	}
+	parent := w.chain.CurrentBlock()
+	header := &types.Header{ParentHash: parent.Hash(), Number: big.NewInt(1), GasLimit: parent.GasLimit(), Time: parent.Time() + 1}
+	if err := w.makeCurrent(parent, header); err != nil {
+		t.Fatalf("failed to prepare work: %v", err)
+	}
+	if !w.conditionsHold(pendingTxs[0]) {
+		t.Fatal("unconditional transaction held back")
+	}
+	if w.conditionsHold(newTxs[0]) {
+		t.Fatal("conditional transaction admitted before its conditions hold")
+	}
+	// The conditions are checked against the state of the block being built
+	w.current.state.AddBalance(testUserAddress, big.NewInt(1))
+	if !w.conditionsHold(newTxs[0]) {
+		t.Fatal("conditional transaction held back although its conditions hold")
+	}
+}
+
+func TestPendingBlockFeedAsync(t *testing.T) {
+	engine := ethash.NewFaker()
+	defer engine.Close()
+
+	w, _ := newTestWorker(t, ethashChainConfig, engine, rawdb.NewMemoryDatabase(), 0)
+	defer w.close()
+
+	parent := w.chain.CurrentBlock()
+	header := &types.Header{ParentHash: parent.Hash(), Number: big.NewInt(1), GasLimit: parent.GasLimit(), Time: parent.Time() + 1}
+	if err := w.makeCurrent(parent, header); err != nil {
+		t.Fatalf("failed to prepare work: %v", err)
+	}
+	// Nobody reads the subscription while the worker updates its snapshot
+	events := make(chan core.PendingBlockEvent)
+	sub := w.pendingBlockFeed.Subscribe(events)
+	defer sub.Unsubscribe()
+
+	done := make(chan struct{})
+	go func() {
+		for i := byte(1); i <= 3; i++ {
+			w.current.header.Extra = []byte{i}
+			w.updateSnapshot()
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("snapshot update blocked on the pending block subscriber")
+	}
+	// The latest pending block is delivered eventually
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case event := <-events:
+			if extra := event.Block.Extra(); len(extra) == 1 && extra[0] == 3 {
+				return
+			}
+		case <-timeout:
+			t.Fatal("latest pending block not delivered")
+		}
+	}
+}
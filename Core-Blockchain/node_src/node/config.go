@@ -66,6 +66,11 @@ type Config struct {
 	// in memory.
 	DataDir string
 
+	// DBEngine is the key-value store implementation backing the databases of
+	// the node (leveldb or pebble). Existing databases keep their implementation
+	// if empty.
+	DBEngine string `toml:",omitempty"`
+
 	// Configuration of peer-to-peer networking.
 	P2P p2p.Config
 
@@ -18,11 +18,13 @@
 package params
 
 import (
+	"bytes"
 	"encoding/binary"
 	"fmt"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"golang.org/x/crypto/sha3"
 )
 
@@ -139,18 +141,18 @@ var (
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, new(EthashConfig), nil, nil}
+	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, nil, new(EthashConfig), nil, nil}
 
 	// AllCliqueProtocolChanges contains every protocol change (EIPs) introduced
 	// and accepted by the Ethereum core developers into the Clique consensus.
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, nil, &CliqueConfig{Period: 0, Epoch: 30000}, nil}
+	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, nil, nil, nil, &CliqueConfig{Period: 0, Epoch: 30000}, nil}
 
-	AllCongressProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, big.NewInt(2), big.NewInt(3), nil, nil, &CongressConfig{Period: 0, Epoch: 30000}}
+	AllCongressProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, big.NewInt(2), big.NewInt(3), nil, nil, nil, nil, &CongressConfig{Period: 0, Epoch: 30000}}
 
-	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, new(EthashConfig), nil, nil}
+	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, nil, new(EthashConfig), nil, nil}
 	TestRules       = TestChainConfig.Rules(new(big.Int))
 )
 // REMOVED: DevAdmin addresses - these were only for development testing and have been removed
@@ -240,8 +242,13 @@ type ChainConfig struct {
 	// the network that triggers the consensus upgrade.
 	TerminalTotalDifficulty *big.Int `json:"terminalTotalDifficulty,omitempty"`
 
-	RedCoastBlock *big.Int `json:"redCoastBlock,omitempty"` // RedCoast switch block (nil = no fork, set value ≥ 2 to activate it)
-	SophonBlock   *big.Int `json:"sophonBlock,omitempty"`   // Sophon switch block (nil = no fork, set > RedCoastBlock to activate it)
+	RedCoastBlock  *big.Int `json:"redCoastBlock,omitempty"`  // RedCoast switch block (nil = no fork, set value ≥ 2 to activate it)
+	SophonBlock    *big.Int `json:"sophonBlock,omitempty"`    // Sophon switch block (nil = no fork, set > RedCoastBlock to activate it)
+	GovParamsBlock *big.Int `json:"govParamsBlock,omitempty"` // GovParams switch block (nil = no fork, set > SophonBlock to activate it)
+
+	// SystemContractUpgrades lists the declarative system contract upgrades the
+	// congress engine applies before executing the transactions of a block.
+	SystemContractUpgrades []*SystemContractUpgrade `json:"systemContractUpgrades,omitempty"`
 
 	// Various consensus engines
 	Ethash   *EthashConfig   `json:"ethash,omitempty"`
@@ -281,6 +288,63 @@ func (c *CongressConfig) String() string {
 	return "congress"
 }
 
+// SystemContractUpgrade is a manifest describing a system contract code replacement.
+// The runtime bytecode is either given inline (Code) or refers to a bytecode bundled
+// with the congress engine by name (Source), in both cases it must hash to CodeHash.
+type SystemContractUpgrade struct {
+	Name     string         `json:"name"`               // Human readable name of the upgrade
+	Block    *big.Int       `json:"block"`              // Block at which the upgrade is applied (must be ≥ 2)
+	Address  common.Address `json:"address"`            // Address of the system contract to upgrade
+	CodeHash common.Hash    `json:"codeHash"`           // Keccak256 hash of the new runtime bytecode
+	Source   string         `json:"source,omitempty"`   // Name of a bundled bytecode (exclusive with Code)
+	Code     hexutil.Bytes  `json:"code,omitempty"`     // Inline runtime bytecode (exclusive with Source)
+	InitData hexutil.Bytes  `json:"initData,omitempty"` // Calldata executed against the contract after the code replacement
+}
+
+// check verifies the manifest is well formed.
+func (u *SystemContractUpgrade) check() error {
+	if u.Block == nil || u.Block.Cmp(big.NewInt(2)) < 0 {
+		return fmt.Errorf("system contract upgrade %q: block must be set and greater than 1", u.Name)
+	}
+	if u.Address == (common.Address{}) {
+		return fmt.Errorf("system contract upgrade %q: missing contract address", u.Name)
+	}
+	if u.CodeHash == (common.Hash{}) {
+		return fmt.Errorf("system contract upgrade %q: missing code hash", u.Name)
+	}
+	if (len(u.Code) == 0) == (u.Source == "") {
+		return fmt.Errorf("system contract upgrade %q: exactly one of code and source must be set", u.Name)
+	}
+	if len(u.Code) > 0 {
+		var hash common.Hash
+		hasher := sha3.NewLegacyKeccak256()
+		hasher.Write(u.Code)
+		hasher.Sum(hash[:0])
+		if hash != u.CodeHash {
+			return fmt.Errorf("system contract upgrade %q: code hash mismatch (have %x, want %x)", u.Name, hash, u.CodeHash)
+		}
+	}
+	return nil
+}
+
+// equal reports whether two manifests describe the same upgrade.
+func (u *SystemContractUpgrade) equal(o *SystemContractUpgrade) bool {
+	return u.Name == o.Name && configNumEqual(u.Block, o.Block) && u.Address == o.Address &&
+		u.CodeHash == o.CodeHash && bytes.Equal(u.InitData, o.InitData)
+}
+
+// SystemContractUpgradesAt returns the system contract upgrades scheduled at the
+// given block, in the order they are declared.
+func (c *ChainConfig) SystemContractUpgradesAt(num *big.Int) []*SystemContractUpgrade {
+	var upgrades []*SystemContractUpgrade
+	for _, u := range c.SystemContractUpgrades {
+		if configNumEqual(u.Block, num) {
+			upgrades = append(upgrades, u)
+		}
+	}
+	return upgrades
+}
+
 // String implements the fmt.Stringer interface.
 func (c *ChainConfig) String() string {
 	var engine interface{}
@@ -294,7 +358,7 @@ func (c *ChainConfig) String() string {
 	default:
 		engine = "unknown"
 	}
-	return fmt.Sprintf("{ChainID: %v Homestead: %v DAO: %v DAOSupport: %v EIP150: %v EIP155: %v EIP158: %v Byzantium: %v Constantinople: %v Petersburg: %v Istanbul: %v, Muir Glacier: %v, RedCoastBlock: %v, Berlin: %v, London: %v, Sophon: %v, Engine: %v}",
+	return fmt.Sprintf("{ChainID: %v Homestead: %v DAO: %v DAOSupport: %v EIP150: %v EIP155: %v EIP158: %v Byzantium: %v Constantinople: %v Petersburg: %v Istanbul: %v, Muir Glacier: %v, RedCoastBlock: %v, Berlin: %v, London: %v, Sophon: %v, GovParams: %v, Engine: %v}",
 		c.ChainID,
 		c.HomesteadBlock,
 		c.DAOForkBlock,
@@ -311,6 +375,7 @@ func (c *ChainConfig) String() string {
 		c.BerlinBlock,
 		c.LondonBlock,
 		c.SophonBlock,
+		c.GovParamsBlock,
 		engine,
 	)
 }
@@ -400,6 +465,12 @@ func (c *ChainConfig) IsSophon(num *big.Int) bool {
 	return isForked(c.SophonBlock, num)
 }
 
+// IsGovParams returns whether num represents a block number after the GovParamsBlock fork,
+// from which on the congress block period and epoch length can be changed by governance.
+func (c *ChainConfig) IsGovParams(num *big.Int) bool {
+	return isForked(c.GovParamsBlock, num)
+}
+
 // CheckCompatible checks whether scheduled fork transitions have been imported
 // with a mismatching chain configuration.
 func (c *ChainConfig) CheckCompatible(newcfg *ChainConfig, height uint64) *ConfigCompatError {
@@ -465,6 +536,7 @@ func (c *ChainConfig) CheckConfigForkOrder() error {
 	for _, cur := range []fork{
 		{name: "redCoastBlock", block: c.RedCoastBlock, minValue: big.NewInt(2)},
 		{name: "sophonBlock", block: c.SophonBlock},
+		{name: "govParamsBlock", block: c.GovParamsBlock, optional: true},
 	} {
 		// check minimal fork block
 		if cur.block != nil && cur.minValue != nil {
@@ -490,6 +562,18 @@ func (c *ChainConfig) CheckConfigForkOrder() error {
 			lastFork = cur
 		}
 	}
+	// system contract upgrades
+	var lastUpgrade *SystemContractUpgrade
+	for _, cur := range c.SystemContractUpgrades {
+		if err := cur.check(); err != nil {
+			return err
+		}
+		if lastUpgrade != nil && lastUpgrade.Block.Cmp(cur.Block) > 0 {
+			return fmt.Errorf("unsupported upgrade ordering: %q scheduled at %v, but %q scheduled at %v",
+				lastUpgrade.Name, lastUpgrade.Block, cur.Name, cur.Block)
+		}
+		lastUpgrade = cur
+	}
 	return nil
 }
 
@@ -543,9 +627,41 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int) *Confi
 	if isForkIncompatible(c.RedCoastBlock, newcfg.RedCoastBlock, head) {
 		return newCompatError("RedCoast fork block", c.RedCoastBlock, newcfg.RedCoastBlock)
 	}
+	if isForkIncompatible(c.GovParamsBlock, newcfg.GovParamsBlock, head) {
+		return newCompatError("GovParams fork block", c.GovParamsBlock, newcfg.GovParamsBlock)
+	}
 	if isForkIncompatible(c.ArrowGlacierBlock, newcfg.ArrowGlacierBlock, head) {
 		return newCompatError("Arrow Glacier fork block", c.ArrowGlacierBlock, newcfg.ArrowGlacierBlock)
 	}
+	if err := checkUpgradesCompatible(c.SystemContractUpgrades, newcfg.SystemContractUpgrades, head); err != nil {
+		return err
+	}
+	return nil
+}
+
+// checkUpgradesCompatible returns an error if a system contract upgrade scheduled
+// at or before head was added, removed or altered between the two configs.
+func checkUpgradesCompatible(stored, newcfg []*SystemContractUpgrade, head *big.Int) *ConfigCompatError {
+	applied := func(upgrades []*SystemContractUpgrade) []*SystemContractUpgrade {
+		var list []*SystemContractUpgrade
+		for _, u := range upgrades {
+			if isForked(u.Block, head) {
+				list = append(list, u)
+			}
+		}
+		return list
+	}
+	s, n := applied(stored), applied(newcfg)
+	for i := 0; i < len(s) || i < len(n); i++ {
+		switch {
+		case i >= len(s):
+			return newCompatError("system contract upgrade "+n[i].Name, nil, n[i].Block)
+		case i >= len(n):
+			return newCompatError("system contract upgrade "+s[i].Name, s[i].Block, nil)
+		case !s[i].equal(n[i]):
+			return newCompatError("system contract upgrade "+s[i].Name, s[i].Block, n[i].Block)
+		}
+	}
 	return nil
 }
 
@@ -21,6 +21,9 @@ import (
 	"math/big"
 	"reflect"
 	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 )
 
 func TestCheckCompatible(t *testing.T) {
@@ -126,6 +129,12 @@ func TestCheckConfigForkOrder(t *testing.T) {
 		{new: &ChainConfig{RedCoastBlock: big.NewInt(1)}, isErr: true},
 		{new: &ChainConfig{SophonBlock: big.NewInt(3)}, isErr: true},
 		{new: &ChainConfig{RedCoastBlock: big.NewInt(2), SophonBlock: big.NewInt(2)}, isErr: true},
+		{new: &ChainConfig{SystemContractUpgrades: []*SystemContractUpgrade{testUpgrade(10)}}},
+		{new: &ChainConfig{SystemContractUpgrades: []*SystemContractUpgrade{testUpgrade(10), testUpgrade(10)}}},
+		{new: &ChainConfig{SystemContractUpgrades: []*SystemContractUpgrade{testUpgrade(1)}}, isErr: true},
+		{new: &ChainConfig{SystemContractUpgrades: []*SystemContractUpgrade{testUpgrade(11), testUpgrade(10)}}, isErr: true},
+		{new: &ChainConfig{SystemContractUpgrades: []*SystemContractUpgrade{{Name: "bad hash", Block: big.NewInt(10), Address: common.HexToAddress("0xf004"), CodeHash: common.HexToHash("0x01"), Code: []byte{0x60}}}}, isErr: true},
+		{new: &ChainConfig{SystemContractUpgrades: []*SystemContractUpgrade{{Name: "no code", Block: big.NewInt(10), Address: common.HexToAddress("0xf004"), CodeHash: common.HexToHash("0x01")}}}, isErr: true},
 	}
 	for _, tc := range tests {
 		err := tc.new.CheckConfigForkOrder()
@@ -137,3 +146,35 @@ func TestCheckConfigForkOrder(t *testing.T) {
 		}
 	}
 }
+
+func testUpgrade(block int64) *SystemContractUpgrade {
+	code := []byte{0x60, 0x00, 0x60, 0x00, 0xf3}
+	return &SystemContractUpgrade{
+		Name:     "test",
+		Block:    big.NewInt(block),
+		Address:  common.HexToAddress("0xf004"),
+		CodeHash: crypto.Keccak256Hash(code),
+		Code:     code,
+	}
+}
+
+func TestCheckUpgradesCompatible(t *testing.T) {
+	stored := &ChainConfig{SystemContractUpgrades: []*SystemContractUpgrade{testUpgrade(10)}}
+
+	// Upgrades in the future may be added, moved or removed freely
+	if err := stored.CheckCompatible(&ChainConfig{}, 9); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := stored.CheckCompatible(&ChainConfig{SystemContractUpgrades: []*SystemContractUpgrade{testUpgrade(10), testUpgrade(20)}}, 10); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	// Applied upgrades must not change
+	want := &ConfigCompatError{What: "system contract upgrade test", StoredConfig: big.NewInt(10), NewConfig: nil, RewindTo: 9}
+	if err := stored.CheckCompatible(&ChainConfig{}, 10); !reflect.DeepEqual(err, want) {
+		t.Errorf("error mismatch: have %v, want %v", err, want)
+	}
+	want = &ConfigCompatError{What: "system contract upgrade test", StoredConfig: big.NewInt(10), NewConfig: big.NewInt(12), RewindTo: 9}
+	if err := stored.CheckCompatible(&ChainConfig{SystemContractUpgrades: []*SystemContractUpgrade{testUpgrade(12)}}, 15); !reflect.DeepEqual(err, want) {
+		t.Errorf("error mismatch: have %v, want %v", err, want)
+	}
+}
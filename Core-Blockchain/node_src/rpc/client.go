@@ -122,6 +122,10 @@ func (c *Client) newClientConn(conn ServerCodec) *clientConn {
 	if !c.isHTTP() && c.scheme != "" {
 		ctx = context.WithValue(ctx, "scheme", c.scheme)
 	}
+	// Websocket connections don't pass the HTTP handler setting the remote address
+	if wc, ok := conn.(*websocketCodec); ok {
+		ctx = context.WithValue(ctx, "remote", wc.conn.RemoteAddr().String())
+	}
 	handler := newHandler(ctx, conn, c.idgen, c.services)
 	return &clientConn{conn, handler}
 }
@@ -85,6 +85,11 @@ func (s *testService) EchoWithCtx(ctx context.Context, str string, i int, args *
 	return echoResult{str, i, args}
 }
 
+func (s *testService) Remote(ctx context.Context) string {
+	remote, _ := ctx.Value("remote").(string)
+	return remote
+}
+
 func (s *testService) Sleep(ctx context.Context, duration time.Duration) {
 	time.Sleep(duration)
 }
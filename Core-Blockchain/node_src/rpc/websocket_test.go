@@ -83,6 +83,34 @@ func TestWebsocketOriginCheck(t *testing.T) {
 }
 
 // This test checks whether calls exceeding the request size limit are rejected.
+// This test checks that the remote address of websocket clients is available to
+// the handlers.
+func TestWebsocketRemoteAddr(t *testing.T) {
+	t.Parallel()
+
+	var (
+		srv     = newTestServer()
+		httpsrv = httptest.NewServer(srv.WebsocketHandler([]string{"*"}))
+		wsURL   = "ws:" + strings.TrimPrefix(httpsrv.URL, "http:")
+	)
+	defer srv.Stop()
+	defer httpsrv.Close()
+
+	client, err := DialWebsocket(context.Background(), wsURL, "")
+	if err != nil {
+		t.Fatalf("can't dial: %v", err)
+	}
+	defer client.Close()
+
+	var remote string
+	if err := client.Call(&remote, "test_remote"); err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if host, _, err := net.SplitHostPort(remote); err != nil || host != "127.0.0.1" {
+		t.Fatalf("remote address mismatch: have %q", remote)
+	}
+}
+
 func TestWebsocketLargeCall(t *testing.T) {
 	t.Parallel()
 
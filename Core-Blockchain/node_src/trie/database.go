@@ -95,6 +95,9 @@ type Database struct {
 	// used as database when processing block while its parent block's state is still commtting
 	flushedHashCache *HashCache
 
+	scheme string        // Scheme used to store the trie nodes on disk
+	path   *pathDatabase // Layered node storage of the path-based scheme, nil for the hash-based one
+
 	FlushLatch sync.WaitGroup
 	lock       sync.RWMutex
 }
@@ -359,6 +362,9 @@ type Config struct {
 	Cache     int    // Memory allowance (MB) to use for caching trie nodes in memory
 	Journal   string // Journal of clean cache to survive node restarts
 	Preimages bool   // Flag whether the preimage of trie key is recorded
+
+	Scheme       string // Scheme used to store the trie nodes on disk, hash-based if empty
+	StateHistory uint64 // Number of recent states that can be rolled back to with the path-based scheme (0 = disabled)
 }
 
 // NewDatabase creates a new trie database to store ephemeral trie content before
@@ -387,13 +393,45 @@ func NewDatabaseWithConfig(diskdb ethdb.KeyValueStore, config *Config) *Database
 			children: make(map[common.Hash]uint16),
 		}},
 		dirtyHashCache: &HashCache{inner: make(map[common.Hash]node)},
+		scheme:         rawdb.HashScheme,
 	}
 	if config == nil || config.Preimages { // TODO(karalabe): Flip to default off in the future
 		db.preimages = make(map[common.Hash][]byte)
 	}
+	if config != nil && config.Scheme != "" {
+		db.scheme = config.Scheme
+	}
+	if db.scheme == rawdb.PathScheme {
+		db.path = newPathDatabase(diskdb, config.StateHistory)
+	}
 	return db
 }
 
+// openStateFreezer opens the freezer storing the state histories next to the
+// chain freezer of the given database, returning nil if there is none.
+func openStateFreezer(diskdb ethdb.KeyValueStore) ethdb.AncientStore {
+	ancients, ok := diskdb.(ethdb.AncientReader)
+	if !ok {
+		return nil
+	}
+	datadir, err := ancients.AncientDatadir()
+	if err != nil {
+		log.Warn("State history unavailable, rollbacks disabled", "err", err)
+		return nil
+	}
+	freezer, err := rawdb.NewStateFreezer(datadir, false)
+	if err != nil {
+		log.Error("Failed to open state history freezer, rollbacks disabled", "err", err)
+		return nil
+	}
+	return freezer
+}
+
+// Scheme returns the scheme used to store the trie nodes on disk.
+func (db *Database) Scheme() string {
+	return db.scheme
+}
+
 // DiskDB retrieves the persistent storage backing the trie database.
 func (db *Database) DiskDB() ethdb.KeyValueStore {
 	return db.diskdb
@@ -451,8 +489,9 @@ func (db *Database) insertPreimage(hash common.Hash, preimage []byte) {
 }
 
 // node retrieves a cached trie node from memory, or returns nil if none can be
-// found in the memory cache.
-func (db *Database) node(hash common.Hash) node {
+// found in the memory cache. The owner and path of the node are only needed by
+// the path-based scheme.
+func (db *Database) node(owner common.Hash, path []byte, hash common.Hash) node {
 	// Retrieve the node from the clean cache if available
 	if db.cleans != nil {
 		if enc := db.cleans.Get(nil, hash[:]); enc != nil {
@@ -467,6 +506,20 @@ func (db *Database) node(hash common.Hash) node {
 			return createNode(n)
 		}
 	}
+	if db.path != nil {
+		db.lock.RLock()
+		enc := db.path.node(owner, path, hash)
+		db.lock.RUnlock()
+		if enc == nil {
+			return nil
+		}
+		if db.cleans != nil {
+			db.cleans.Set(hash[:], enc)
+			memcacheCleanMissMeter.Mark(1)
+			memcacheCleanWriteMeter.Mark(int64(len(enc)))
+		}
+		return mustDecodeNode(hash[:], enc)
+	}
 	db.lock.RLock()
 	dirty := db.dirties[hash]
 	db.lock.RUnlock()
@@ -515,6 +568,11 @@ func (db *Database) Node(hash common.Hash) ([]byte, error) {
 			return entry.rlp(), nil
 		}
 	}
+	// Nodes are stored by path with the path-based scheme, a hash alone is not
+	// enough to locate them outside of the caches.
+	if db.path != nil {
+		return nil, errors.New("not found")
+	}
 	db.lock.RLock()
 	dirty := db.dirties[hash]
 	db.lock.RUnlock()
@@ -539,6 +597,39 @@ func (db *Database) Node(hash common.Hash) ([]byte, error) {
 	return nil, errors.New("not found")
 }
 
+// nodeBlob retrieves the encoded trie node with the given hash, stored at the
+// given path of the trie of the given owner, or nil if it can't be found.
+func (db *Database) nodeBlob(owner common.Hash, path []byte, hash common.Hash) []byte {
+	if db.path == nil {
+		enc, _ := db.Node(hash)
+		return enc
+	}
+	if db.cleans != nil {
+		if enc := db.cleans.Get(nil, hash[:]); enc != nil {
+			memcacheCleanHitMeter.Mark(1)
+			memcacheCleanReadMeter.Mark(int64(len(enc)))
+			return enc
+		}
+	}
+	if flushed := db.GetFlushedHashCache(); flushed != nil {
+		if n, e := flushed.Get(hash); e {
+			entry := &cachedNode{
+				node: simplifyNode(n),
+			}
+			return entry.rlp()
+		}
+	}
+	db.lock.RLock()
+	enc := db.path.node(owner, path, hash)
+	db.lock.RUnlock()
+	if enc != nil && db.cleans != nil {
+		db.cleans.Set(hash[:], enc)
+		memcacheCleanMissMeter.Mark(1)
+		memcacheCleanWriteMeter.Mark(int64(len(enc)))
+	}
+	return enc
+}
+
 // preimage retrieves a cached trie node pre-image from memory. If it cannot be
 // found cached, the method queries the persistent database for the content.
 func (db *Database) preimage(hash common.Hash) []byte {
@@ -578,6 +669,10 @@ func (db *Database) Nodes() []common.Hash {
 // and external node(e.g. storage trie root), all internal trie nodes
 // are referenced together by database itself.
 func (db *Database) Reference(child common.Hash, parent common.Hash) {
+	// Nodes are not reference counted with the path-based scheme
+	if db.path != nil {
+		return
+	}
 	db.lock.Lock()
 	defer db.lock.Unlock()
 
@@ -612,6 +707,9 @@ func (db *Database) Dereference(root common.Hash) {
 		log.Error("Attempted to dereference the trie cache meta root")
 		return
 	}
+	if db.path != nil {
+		return
+	}
 	db.lock.Lock()
 	defer db.lock.Unlock()
 
@@ -686,6 +784,10 @@ func (db *Database) dereference(child common.Hash, parent common.Hash) {
 // Note, this method is a non-synchronized mutator. It is unsafe to call this
 // concurrently with other mutators.
 func (db *Database) Cap(limit common.StorageSize) error {
+	// The memory used by the path-based scheme is bounded by CapLayers
+	if db.path != nil {
+		return nil
+	}
 	// Create a database batch to flush persistent data out. It is important that
 	// outside code doesn't see an inconsistent state (referenced data removed from
 	// memory cache during commit but not yet in persistent storage). This is ensured
@@ -806,6 +908,17 @@ func (db *Database) Commit(node common.Hash, report bool, callback func(common.H
 		}
 		batch.Reset()
 	}
+	// With the path-based scheme, flatten the in-memory states up to the given
+	// one into the persisted state
+	if db.path != nil {
+		db.lock.Lock()
+		defer db.lock.Unlock()
+
+		if db.preimages != nil {
+			db.preimages, db.preimagesSize = make(map[common.Hash][]byte), 0
+		}
+		return db.path.commit(node, report)
+	}
 	// Move the trie itself into the batch, flushing if enough data is accumulated
 	nodes, storage := len(db.dirties), db.dirtiesSize
 
@@ -933,6 +1046,11 @@ func (c *cleaner) Delete(key []byte) error {
 // Size returns the current storage size of the memory cache in front of the
 // persistent database layer.
 func (db *Database) Size() (common.StorageSize, common.StorageSize) {
+	if db.path != nil {
+		db.lock.RLock()
+		defer db.lock.RUnlock()
+		return db.path.memory(), db.preimagesSize
+	}
 	db.lock.RLock()
 	defer db.lock.RUnlock()
 
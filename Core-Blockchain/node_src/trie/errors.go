@@ -27,10 +27,14 @@ import (
 // in the case where a trie node is not present in the local database. It contains
 // information necessary for retrieving the missing node.
 type MissingNodeError struct {
+	Owner    common.Hash // owner of the trie if it's a storage trie, empty otherwise
 	NodeHash common.Hash // hash of the missing node
 	Path     []byte      // hex-encoded path to the missing node
 }
 
 func (err *MissingNodeError) Error() string {
-	return fmt.Sprintf("missing trie node %x (path %x)", err.NodeHash, err.Path)
+	if err.Owner == (common.Hash{}) {
+		return fmt.Sprintf("missing trie node %x (path %x)", err.NodeHash, err.Path)
+	}
+	return fmt.Sprintf("missing trie node %x (owner %x) (path %x)", err.NodeHash, err.Owner, err.Path)
 }
@@ -0,0 +1,98 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package trie
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// memoryNode is a trie node collected by a commit, keyed by its path in the
+// owning node set. Deleted nodes have neither hash nor blob.
+type memoryNode struct {
+	hash common.Hash // Hash of the node, empty for deleted nodes
+	blob []byte      // Encoded node, nil for deleted nodes
+}
+
+// size returns the memory used by the node, excluding its path.
+func (n *memoryNode) size() int {
+	return common.HashLength + len(n.blob)
+}
+
+// isDeleted reports whether the node was deleted.
+func (n *memoryNode) isDeleted() bool {
+	return n.hash == (common.Hash{})
+}
+
+// NodeSet contains the trie nodes updated or deleted by the commit of a single
+// trie, keyed by their path within the trie. It's only collected when the trie
+// database uses the path-based scheme.
+type NodeSet struct {
+	owner common.Hash // Hash of the account owning the storage trie, empty for the account trie
+	nodes map[string]*memoryNode
+}
+
+// NewNodeSet creates an empty node set for the trie of the given owner.
+func NewNodeSet(owner common.Hash) *NodeSet {
+	return &NodeSet{
+		owner: owner,
+		nodes: make(map[string]*memoryNode),
+	}
+}
+
+// Owner returns the hash of the account owning the trie, empty for the account
+// trie.
+func (set *NodeSet) Owner() common.Hash {
+	return set.owner
+}
+
+// Len returns the number of nodes in the set, deleted ones included.
+func (set *NodeSet) Len() int {
+	return len(set.nodes)
+}
+
+// add tracks the node updated at the given path.
+func (set *NodeSet) add(path []byte, hash common.Hash, blob []byte) {
+	set.nodes[string(path)] = &memoryNode{hash: hash, blob: blob}
+}
+
+// MarkDeleted tracks the node deleted at the given path, unless the path was
+// reused by an updated node.
+func (set *NodeSet) MarkDeleted(path []byte) {
+	if _, ok := set.nodes[string(path)]; ok {
+		return
+	}
+	set.nodes[string(path)] = &memoryNode{}
+}
+
+// MergedNodeSet aggregates the node sets of all the tries committed along with
+// a state transition.
+type MergedNodeSet struct {
+	sets map[common.Hash]*NodeSet
+}
+
+// NewMergedNodeSet creates an empty merged node set.
+func NewMergedNodeSet() *MergedNodeSet {
+	return &MergedNodeSet{sets: make(map[common.Hash]*NodeSet)}
+}
+
+// Merge adds the given node set. The nodes of a trie committed twice, e.g. a
+// storage trie wiped and then recreated, override the previous ones.
+func (set *MergedNodeSet) Merge(other *NodeSet) {
+	if other == nil {
+		return
+	}
+	prev, ok := set.sets[other.owner]
+	if !ok {
+		set.sets[other.owner] = other
+		return
+	}
+	for path, n := range other.nodes {
+		prev.nodes[path] = n
+	}
+}
@@ -0,0 +1,567 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package trie
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// stateHistoryVersion is the version of the encoding of the state histories.
+const stateHistoryVersion = 1
+
+var (
+	// errMissingLayer is returned if the state to update or flatten is unknown.
+	errMissingLayer = errors.New("triedb layer missing")
+
+	// errStateUnrecoverable is returned if the persisted state can't be rolled
+	// back to the requested one.
+	errStateUnrecoverable = errors.New("state is unrecoverable")
+)
+
+// historyMeta is the metadata of a state history, the reverse diff recorded when
+// the state with the given root is persisted on top of its parent.
+type historyMeta struct {
+	Version uint8
+	Parent  common.Hash
+	Root    common.Hash
+}
+
+// historyNode is a trie node overwritten when persisting a state, along with
+// its original value, empty if it didn't exist.
+type historyNode struct {
+	Owner common.Hash
+	Path  []byte
+	Blob  []byte
+}
+
+// diffLayer is a state kept in memory on top of its parent, holding the trie
+// nodes updated by the state transition.
+type diffLayer struct {
+	root   common.Hash
+	parent common.Hash
+	id     uint64 // Id of the state, one more than its parent's
+	nodes  map[common.Hash]map[string]*memoryNode
+	size   common.StorageSize
+}
+
+// pathDatabase is the backend of the trie database with the path-based scheme.
+// A single state, the disk layer, is persisted with its nodes stored by path.
+// The most recent states are kept in memory as diff layers forming a tree on
+// top of it. Flattening a diff layer into the disk overwrites the nodes in
+// place, with the original ones recorded as a state history in the freezer so
+// that the persisted state can be rolled back within a bounded window.
+type pathDatabase struct {
+	diskdb  ethdb.KeyValueStore
+	freezer ethdb.AncientStore // Freezer of the state histories, nil if not recorded
+	limit   uint64             // Number of state histories to retain (0 = disabled)
+
+	diskRoot common.Hash // Root of the persisted state
+	diskID   uint64      // Id of the persisted state
+
+	layers map[common.Hash]*diffLayer // In-memory states keyed by root
+	lookup map[string][]*diffLayer    // Diff layers holding a node, keyed by owner and path, oldest first
+	size   common.StorageSize         // Memory used by the diff layers
+}
+
+// newPathDatabase creates the path-based backend on top of the persisted state
+// of the given database. State histories are only recorded with a non-zero
+// limit, i.e. by the database backing the chain.
+func newPathDatabase(diskdb ethdb.KeyValueStore, limit uint64) *pathDatabase {
+	db := &pathDatabase{
+		diskdb:   diskdb,
+		limit:    limit,
+		diskRoot: emptyRoot,
+		diskID:   rawdb.ReadPersistentStateID(diskdb),
+		layers:   make(map[common.Hash]*diffLayer),
+		lookup:   make(map[string][]*diffLayer),
+	}
+	if blob := rawdb.ReadAccountTrieNode(diskdb, nil); len(blob) > 0 {
+		db.diskRoot = crypto.Keccak256Hash(blob)
+	}
+	if limit > 0 {
+		db.freezer = openStateFreezer(diskdb)
+	}
+	if db.freezer != nil {
+		if err := db.repairHistory(); err != nil {
+			log.Error("Failed to repair state histories, rollbacks disabled", "err", err)
+			db.freezer.Close()
+			db.freezer = nil
+		}
+	}
+	return db
+}
+
+// repairHistory aligns the state histories with the persisted state. Histories
+// written before a crash prevented the state itself to be persisted are removed,
+// and placeholders are recorded for the states persisted without a freezer, e.g.
+// the genesis one.
+func (db *pathDatabase) repairHistory() error {
+	items, err := db.freezer.Ancients()
+	if err != nil {
+		return err
+	}
+	if items > db.diskID {
+		log.Warn("Truncating dangling state histories", "persisted", db.diskID, "histories", items)
+		return db.freezer.TruncateAncients(db.diskID)
+	}
+	for id := items + 1; id <= db.diskID; id++ {
+		meta, _ := rlp.EncodeToBytes(&historyMeta{Version: stateHistoryVersion})
+		if err := rawdb.WriteStateHistory(db.freezer, id, meta, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nodeKey returns the key of a node in the lookup index.
+func nodeKey(owner common.Hash, path []byte) string {
+	return string(owner[:]) + string(path)
+}
+
+// readDiskNode retrieves the persisted node of the given owner at the given path.
+func readDiskNode(db ethdb.KeyValueReader, owner common.Hash, path []byte) []byte {
+	if owner == (common.Hash{}) {
+		return rawdb.ReadAccountTrieNode(db, path)
+	}
+	return rawdb.ReadStorageTrieNode(db, owner, path)
+}
+
+// writeDiskNode persists the node of the given owner at the given path, or
+// deletes it if the blob is empty.
+func writeDiskNode(db ethdb.KeyValueWriter, owner common.Hash, path []byte, blob []byte) {
+	switch {
+	case owner == (common.Hash{}) && len(blob) == 0:
+		rawdb.DeleteAccountTrieNode(db, path)
+	case owner == (common.Hash{}):
+		rawdb.WriteAccountTrieNode(db, path, blob)
+	case len(blob) == 0:
+		rawdb.DeleteStorageTrieNode(db, owner, path)
+	default:
+		rawdb.WriteStorageTrieNode(db, owner, path, blob)
+	}
+}
+
+// node retrieves the encoded node with the given hash, stored at the given path
+// of the trie of the given owner, from the newest layer holding it. As nodes are
+// addressed by their hash, the state being read doesn't matter.
+//
+// Note, this method assumes that the database's lock is held!
+func (db *pathDatabase) node(owner common.Hash, path []byte, hash common.Hash) []byte {
+	layers := db.lookup[nodeKey(owner, path)]
+	for i := len(layers) - 1; i >= 0; i-- {
+		if n := layers[i].nodes[owner][string(path)]; n != nil && n.hash == hash {
+			return n.blob
+		}
+	}
+	blob := readDiskNode(db.diskdb, owner, path)
+	if len(blob) == 0 || crypto.Keccak256Hash(blob) != hash {
+		return nil
+	}
+	return blob
+}
+
+// memory returns the memory used by the diff layers.
+func (db *pathDatabase) memory() common.StorageSize {
+	return db.size
+}
+
+// update adds the state with the given root, made of the given nodes on top of
+// its parent, as a new diff layer.
+//
+// Note, this method assumes that the database's lock is held!
+func (db *pathDatabase) update(root common.Hash, parent common.Hash, nodes *MergedNodeSet) error {
+	if root == parent || root == db.diskRoot {
+		return nil
+	}
+	if _, ok := db.layers[root]; ok {
+		return nil
+	}
+	var id uint64
+	if parent == db.diskRoot {
+		id = db.diskID + 1
+	} else if p := db.layers[parent]; p != nil {
+		id = p.id + 1
+	} else {
+		return fmt.Errorf("%w: parent %x", errMissingLayer, parent)
+	}
+	layer := &diffLayer{
+		root:   root,
+		parent: parent,
+		id:     id,
+		nodes:  make(map[common.Hash]map[string]*memoryNode),
+	}
+	if nodes != nil {
+		for owner, set := range nodes.sets {
+			layer.nodes[owner] = set.nodes
+			for path, n := range set.nodes {
+				layer.size += common.StorageSize(len(path) + n.size())
+
+				key := nodeKey(owner, []byte(path))
+				db.lookup[key] = append(db.lookup[key], layer)
+			}
+		}
+	}
+	db.layers[root] = layer
+	db.size += layer.size
+	return nil
+}
+
+// removeLayer drops the given diff layer from memory.
+//
+// Note, this method assumes that the database's lock is held!
+func (db *pathDatabase) removeLayer(layer *diffLayer) {
+	delete(db.layers, layer.root)
+	for owner, subset := range layer.nodes {
+		for path := range subset {
+			key := nodeKey(owner, []byte(path))
+			layers := db.lookup[key]
+			for i, l := range layers {
+				if l == layer {
+					layers = append(layers[:i], layers[i+1:]...)
+					break
+				}
+			}
+			if len(layers) == 0 {
+				delete(db.lookup, key)
+			} else {
+				db.lookup[key] = layers
+			}
+		}
+	}
+	db.size -= layer.size
+}
+
+// capLayers flattens the diff layers below the given state into the disk, so
+// that at most the given number of layers are kept in memory for it. Layers no
+// longer descending from the persisted state are dropped.
+//
+// Note, this method assumes that the database's lock is held!
+func (db *pathDatabase) capLayers(root common.Hash, layers int) error {
+	var chain []*diffLayer
+	for r := root; r != db.diskRoot; {
+		layer := db.layers[r]
+		if layer == nil {
+			return fmt.Errorf("%w: %x", errMissingLayer, r)
+		}
+		chain = append(chain, layer)
+		r = layer.parent
+	}
+	if len(chain) <= layers {
+		return nil
+	}
+	for i := len(chain) - 1; i >= layers; i-- {
+		if err := db.flatten(chain[i]); err != nil {
+			return err
+		}
+	}
+	// Drop the siblings of the flattened layers along with their descendants
+	keep := map[common.Hash]bool{db.diskRoot: true}
+	var descends func(root common.Hash) bool
+	descends = func(root common.Hash) bool {
+		if ok, known := keep[root]; known {
+			return ok
+		}
+		layer := db.layers[root]
+		ok := layer != nil && descends(layer.parent)
+		keep[root] = ok
+		return ok
+	}
+	for root, layer := range db.layers {
+		if !descends(root) {
+			db.removeLayer(layer)
+		}
+	}
+	return nil
+}
+
+// commit flattens all the diff layers up to the given state into the disk.
+//
+// Note, this method assumes that the database's lock is held!
+func (db *pathDatabase) commit(root common.Hash, report bool) error {
+	start, layers := time.Now(), len(db.layers)
+	if err := db.capLayers(root, 0); err != nil {
+		return err
+	}
+	logger := log.Info
+	if !report {
+		logger = log.Debug
+	}
+	logger("Persisted trie from memory database", "root", root, "id", db.diskID, "layers", layers-len(db.layers), "livelayers", len(db.layers), "livesize", db.size, "time", time.Since(start))
+	return nil
+}
+
+// flatten persists the given diff layer, which must be a direct child of the
+// persisted state, recording the overwritten nodes as a state history.
+//
+// Note, this method assumes that the database's lock is held!
+func (db *pathDatabase) flatten(layer *diffLayer) error {
+	var (
+		batch = db.diskdb.NewBatch()
+		prevs []historyNode
+	)
+	for owner, subset := range layer.nodes {
+		for path, n := range subset {
+			prevs = append(prevs, historyNode{
+				Owner: owner,
+				Path:  []byte(path),
+				Blob:  readDiskNode(db.diskdb, owner, []byte(path)),
+			})
+			writeDiskNode(batch, owner, []byte(path), n.blob)
+		}
+	}
+	// The history must be recorded before the state is persisted, a dangling
+	// one being truncated at startup.
+	if db.freezer != nil {
+		sort.Slice(prevs, func(i, j int) bool {
+			if prevs[i].Owner != prevs[j].Owner {
+				return bytes.Compare(prevs[i].Owner[:], prevs[j].Owner[:]) < 0
+			}
+			return bytes.Compare(prevs[i].Path, prevs[j].Path) < 0
+		})
+		meta, err := rlp.EncodeToBytes(&historyMeta{Version: stateHistoryVersion, Parent: db.diskRoot, Root: layer.root})
+		if err != nil {
+			return err
+		}
+		nodes, err := rlp.EncodeToBytes(prevs)
+		if err != nil {
+			return err
+		}
+		if err := rawdb.WriteStateHistory(db.freezer, layer.id, meta, nodes); err != nil {
+			return err
+		}
+	}
+	rawdb.WriteStateID(batch, layer.root, layer.id)
+	rawdb.WritePersistentStateID(batch, layer.id)
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	db.diskRoot, db.diskID = layer.root, layer.id
+	db.removeLayer(layer)
+
+	if db.freezer != nil && db.limit > 0 && db.diskID > db.limit {
+		return db.pruneHistory(db.diskID - db.limit)
+	}
+	return nil
+}
+
+// pruneHistory removes the state histories with an id up to the given one,
+// along with the ids of the states that are no longer recoverable.
+//
+// Note, this method assumes that the database's lock is held!
+func (db *pathDatabase) pruneHistory(tail uint64) error {
+	pruned, err := db.freezer.Tail()
+	if err != nil {
+		return err
+	}
+	if pruned >= tail {
+		return nil
+	}
+	batch := db.diskdb.NewBatch()
+	for id := pruned + 1; id <= tail; id++ {
+		blob, _, err := rawdb.ReadStateHistory(db.freezer, id)
+		if err != nil {
+			return err
+		}
+		var meta historyMeta
+		if err := rlp.DecodeBytes(blob, &meta); err != nil {
+			return err
+		}
+		if stored := rawdb.ReadStateID(db.diskdb, meta.Parent); stored != nil && *stored == id-1 {
+			rawdb.DeleteStateID(batch, meta.Parent)
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	return db.freezer.TruncateTail(tail)
+}
+
+// recoverable reports whether the persisted state can be rolled back to the
+// one with the given root.
+//
+// Note, this method assumes that the database's lock is held!
+func (db *pathDatabase) recoverable(root common.Hash) bool {
+	if db.freezer == nil {
+		return false
+	}
+	id := rawdb.ReadStateID(db.diskdb, root)
+	if id == nil || *id >= db.diskID {
+		return false
+	}
+	tail, err := db.freezer.Tail()
+	if err != nil {
+		return false
+	}
+	return *id >= tail
+}
+
+// recover rolls the persisted state back to the one with the given root by
+// applying the state histories in reverse, dropping all the diff layers.
+//
+// Note, this method assumes that the database's lock is held!
+func (db *pathDatabase) recover(root common.Hash) error {
+	if !db.recoverable(root) {
+		return fmt.Errorf("%w: %x", errStateUnrecoverable, root)
+	}
+	target := *rawdb.ReadStateID(db.diskdb, root)
+
+	// Ensure the histories chain up to the requested state before touching the
+	// persisted one.
+	expect := db.diskRoot
+	for id := db.diskID; id > target; id-- {
+		meta, err := db.readHistoryMeta(id)
+		if err != nil {
+			return err
+		}
+		if meta.Root != expect {
+			return fmt.Errorf("%w: history %d root mismatch, have %x, want %x", errStateUnrecoverable, id, meta.Root, expect)
+		}
+		expect = meta.Parent
+	}
+	if expect != root {
+		return fmt.Errorf("%w: history chain ends at %x", errStateUnrecoverable, expect)
+	}
+	start := time.Now()
+	for id := db.diskID; id > target; id-- {
+		meta, err := db.readHistoryMeta(id)
+		if err != nil {
+			return err
+		}
+		_, blob, err := rawdb.ReadStateHistory(db.freezer, id)
+		if err != nil {
+			return err
+		}
+		var nodes []historyNode
+		if err := rlp.DecodeBytes(blob, &nodes); err != nil {
+			return err
+		}
+		batch := db.diskdb.NewBatch()
+		for _, n := range nodes {
+			writeDiskNode(batch, n.Owner, n.Path, n.Blob)
+		}
+		rawdb.DeleteStateID(batch, meta.Root)
+		rawdb.WritePersistentStateID(batch, id-1)
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		if err := db.freezer.TruncateAncients(id - 1); err != nil {
+			return err
+		}
+		db.diskRoot, db.diskID = meta.Parent, id-1
+	}
+	for _, layer := range db.layers {
+		db.removeLayer(layer)
+	}
+	log.Info("Rolled back persisted state", "root", root, "id", db.diskID, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// readHistoryMeta retrieves and decodes the metadata of the state history with
+// the given id.
+func (db *pathDatabase) readHistoryMeta(id uint64) (*historyMeta, error) {
+	blob, _, err := rawdb.ReadStateHistory(db.freezer, id)
+	if err != nil {
+		return nil, err
+	}
+	var meta historyMeta
+	if err := rlp.DecodeBytes(blob, &meta); err != nil {
+		return nil, err
+	}
+	if meta.Version != stateHistoryVersion {
+		return nil, fmt.Errorf("unsupported state history version %d", meta.Version)
+	}
+	return &meta, nil
+}
+
+// Update adds the state with the given root, made of the given trie nodes on
+// top of its parent state, to the database. It's a noop with the hash-based
+// scheme, where nodes are inserted by the tries themselves.
+func (db *Database) Update(root common.Hash, parent common.Hash, nodes *MergedNodeSet) error {
+	if db.path == nil {
+		return nil
+	}
+	if parent == (common.Hash{}) {
+		parent = emptyRoot
+	}
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	return db.path.update(root, parent, nodes)
+}
+
+// CapLayers persists the states below the one with the given root, so that at
+// most the given number of its ancestors are kept in memory, and drops all the
+// states no longer building on the persisted one. It's a noop with the
+// hash-based scheme, which is bounded by Cap instead.
+func (db *Database) CapLayers(root common.Hash, layers int) error {
+	if db.path == nil {
+		return nil
+	}
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if len(db.preimages) > 0 {
+		batch := db.diskdb.NewBatch()
+		rawdb.WritePreimages(batch, db.preimages)
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		db.preimages, db.preimagesSize = make(map[common.Hash][]byte), 0
+	}
+	return db.path.capLayers(root, layers)
+}
+
+// Recoverable reports whether the persisted state can be rolled back to the one
+// with the given root, which is only possible with the path-based scheme.
+func (db *Database) Recoverable(root common.Hash) bool {
+	if db.path == nil {
+		return false
+	}
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	return db.path.recoverable(root)
+}
+
+// Recover rolls the persisted state back to the one with the given root, using
+// the recorded state histories. All the states kept in memory are dropped.
+func (db *Database) Recover(root common.Hash) error {
+	if db.path == nil {
+		return errors.New("state rollback requires the path-based scheme")
+	}
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	return db.path.recover(root)
+}
+
+// Close releases the resources held by the database, the state history freezer
+// with the path-based scheme.
+func (db *Database) Close() error {
+	if db.path == nil || db.path.freezer == nil {
+		return nil
+	}
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	err := db.path.freezer.Close()
+	db.path.freezer = nil
+	return err
+}
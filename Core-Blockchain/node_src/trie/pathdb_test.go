@@ -0,0 +1,181 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package trie
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+)
+
+// commitPathState applies the given updates on top of the given state and adds
+// the resulting state to the path-based database.
+func commitPathState(t *testing.T, db *Database, parent common.Hash, updates map[string]string) common.Hash {
+	t.Helper()
+
+	tr, err := New(parent, db)
+	if err != nil {
+		t.Fatalf("failed to open trie %x: %v", parent, err)
+	}
+	for k, v := range updates {
+		if v == "" {
+			tr.Delete([]byte(k))
+		} else {
+			tr.Update([]byte(k), []byte(v))
+		}
+	}
+	root, _, err := tr.Commit(nil)
+	if err != nil {
+		t.Fatalf("failed to commit trie: %v", err)
+	}
+	nodes := NewMergedNodeSet()
+	nodes.Merge(tr.CommittedNodes())
+	if err := db.Update(root, parent, nodes); err != nil {
+		t.Fatalf("failed to update database: %v", err)
+	}
+	return root
+}
+
+// checkPathState ensures the state with the given root holds exactly the given
+// values.
+func checkPathState(t *testing.T, db *Database, root common.Hash, want map[string]string) {
+	t.Helper()
+
+	tr, err := New(root, db)
+	if err != nil {
+		t.Fatalf("failed to open trie %x: %v", root, err)
+	}
+	have := make(map[string]string)
+	it := NewIterator(tr.NodeIterator(nil))
+	for it.Next() {
+		have[string(it.Key)] = string(it.Value)
+	}
+	if it.Err != nil {
+		t.Fatalf("failed to iterate trie %x: %v", root, it.Err)
+	}
+	if len(have) != len(want) {
+		t.Fatalf("state %x: item count mismatch: have %d, want %d", root, len(have), len(want))
+	}
+	for k, v := range want {
+		if have[k] != v {
+			t.Fatalf("state %x: value mismatch for %q: have %q, want %q", root, k, have[k], v)
+		}
+	}
+}
+
+func newPathTestDatabase(t *testing.T, diskdb ethdb.Database, limit uint64) *Database {
+	t.Helper()
+
+	db := NewDatabaseWithConfig(diskdb, &Config{Scheme: rawdb.PathScheme, StateHistory: limit})
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// Tests that states kept in memory, flattened to disk and rolled back through
+// the state histories all remain consistent.
+func TestPathDatabaseRecover(t *testing.T) {
+	diskdb, err := rawdb.NewDatabaseWithFreezer(memorydb.New(), t.TempDir(), "", false)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	defer diskdb.Close()
+
+	db := newPathTestDatabase(t, diskdb, 128)
+
+	var (
+		roots  []common.Hash
+		states []map[string]string
+		parent = emptyRoot
+		state  = make(map[string]string)
+	)
+	for i := 0; i < 10; i++ {
+		updates := make(map[string]string)
+		for j := 0; j < 20; j++ {
+			key := fmt.Sprintf("key-%d", (i*7+j)%50)
+			if j%5 == 4 {
+				updates[key] = ""
+				delete(state, key)
+			} else {
+				updates[key] = fmt.Sprintf("val-%d-%d", i, j)
+				state[key] = updates[key]
+			}
+		}
+		parent = commitPathState(t, db, parent, updates)
+
+		snapshot := make(map[string]string)
+		for k, v := range state {
+			snapshot[k] = v
+		}
+		roots, states = append(roots, parent), append(states, snapshot)
+	}
+	// Keep the three most recent states in memory, persisting the others
+	if err := db.CapLayers(roots[9], 3); err != nil {
+		t.Fatalf("failed to cap layers: %v", err)
+	}
+	if id := rawdb.ReadPersistentStateID(diskdb); id != 7 {
+		t.Fatalf("persistent state id mismatch: have %d, want 7", id)
+	}
+	for i := 6; i < 10; i++ {
+		checkPathState(t, db, roots[i], states[i])
+	}
+	// Ensure the persisted state is served after a restart, without the diff layers
+	db.Close()
+	db = newPathTestDatabase(t, diskdb, 128)
+	checkPathState(t, db, roots[6], states[6])
+	if _, err := New(roots[9], db); err == nil {
+		t.Fatalf("unpersisted state available after restart")
+	}
+	// Roll back a few states and check they are fully restored
+	for _, i := range []int{4, 1, 0} {
+		if !db.Recoverable(roots[i]) {
+			t.Fatalf("state %d not recoverable", i)
+		}
+		if err := db.Recover(roots[i]); err != nil {
+			t.Fatalf("failed to recover state %d: %v", i, err)
+		}
+		checkPathState(t, db, roots[i], states[i])
+		if db.Recoverable(roots[i+1]) {
+			t.Fatalf("reverted state %d still recoverable", i+1)
+		}
+	}
+}
+
+// Tests that the state histories are pruned beyond the configured limit.
+func TestPathDatabaseHistoryLimit(t *testing.T) {
+	diskdb, err := rawdb.NewDatabaseWithFreezer(memorydb.New(), t.TempDir(), "", false)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	defer diskdb.Close()
+
+	db := newPathTestDatabase(t, diskdb, 3)
+
+	var roots []common.Hash
+	parent := emptyRoot
+	for i := 0; i < 8; i++ {
+		parent = commitPathState(t, db, parent, map[string]string{fmt.Sprintf("key-%d", i): "value"})
+		roots = append(roots, parent)
+	}
+	if err := db.Commit(roots[7], false, nil); err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	for i, root := range roots {
+		want := i >= 4 && i < 7
+		if have := db.Recoverable(root); have != want {
+			t.Errorf("state %d: recoverable mismatch: have %v, want %v", i, have, want)
+		}
+	}
+	if err := db.Recover(roots[3]); err == nil {
+		t.Fatalf("pruned state recovered")
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2024 The Splendor Blockchain Authors
+// This file is part of the Splendor Blockchain library.
+//
+// The Splendor Blockchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package trie
+
+// tracer tracks the paths of the trie nodes loaded from the database, inserted
+// or deleted since the last commit. It's needed by the path-based scheme, where
+// nodes are stored by path and nodes removed from the trie must be explicitly
+// deleted from the database.
+//
+// All methods are safe to call on a nil tracer, which is used by tries backed
+// by the hash-based scheme.
+type tracer struct {
+	loaded  map[string]struct{}
+	inserts map[string]struct{}
+	deletes map[string]struct{}
+}
+
+// newTracer creates an empty tracer.
+func newTracer() *tracer {
+	return &tracer{
+		loaded:  make(map[string]struct{}),
+		inserts: make(map[string]struct{}),
+		deletes: make(map[string]struct{}),
+	}
+}
+
+// onRead tracks a node loaded from the database at the given path.
+func (t *tracer) onRead(path []byte) {
+	if t == nil {
+		return
+	}
+	t.loaded[string(path)] = struct{}{}
+}
+
+// onInsert tracks a node created at the given path. A node deleted earlier at
+// the same path is resurrected.
+func (t *tracer) onInsert(path []byte) {
+	if t == nil {
+		return
+	}
+	if _, ok := t.deletes[string(path)]; ok {
+		delete(t.deletes, string(path))
+		return
+	}
+	t.inserts[string(path)] = struct{}{}
+}
+
+// onDelete tracks a node removed at the given path. A node inserted earlier at
+// the same path is simply forgotten.
+func (t *tracer) onDelete(path []byte) {
+	if t == nil {
+		return
+	}
+	if _, ok := t.inserts[string(path)]; ok {
+		delete(t.inserts, string(path))
+		return
+	}
+	t.deletes[string(path)] = struct{}{}
+}
+
+// isLoaded reports whether the node at the given path was loaded from the
+// database, meaning it's persisted there.
+func (t *tracer) isLoaded(path []byte) bool {
+	if t == nil {
+		return false
+	}
+	_, ok := t.loaded[string(path)]
+	return ok
+}
+
+// deletedNodes returns the paths of the persisted nodes removed from the trie.
+func (t *tracer) deletedNodes() []string {
+	if t == nil {
+		return nil
+	}
+	var paths []string
+	for path := range t.deletes {
+		if _, ok := t.loaded[path]; ok {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// reset clears the tracked paths, once the trie is committed.
+func (t *tracer) reset() {
+	if t == nil {
+		return
+	}
+	t.loaded = make(map[string]struct{})
+	t.inserts = make(map[string]struct{})
+	t.deletes = make(map[string]struct{})
+}
+
+// copy returns a deep copy of the tracer.
+func (t *tracer) copy() *tracer {
+	if t == nil {
+		return nil
+	}
+	cpy := newTracer()
+	for path := range t.loaded {
+		cpy.loaded[path] = struct{}{}
+	}
+	for path := range t.inserts {
+		cpy.inserts[path] = struct{}{}
+	}
+	for path := range t.deletes {
+		cpy.deletes[path] = struct{}{}
+	}
+	return cpy
+}
@@ -25,6 +25,7 @@ import (
 	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/log"
@@ -61,12 +62,19 @@ type LeafCallback func(paths [][]byte, hexpath []byte, leaf []byte, parent commo
 //
 // Trie is not safe for concurrent use.
 type Trie struct {
-	db   *Database
-	root node
+	db    *Database
+	root  node
+	owner common.Hash // Hash of the account owning the storage trie, empty for the account trie
+
 	// Keep track of the number leafs which have been inserted since the last
 	// hashing operation. This number will not directly map to the number of
 	// actually unhashed nodes
 	unhashed int
+
+	// Path-based scheme only: paths of the nodes loaded, inserted and deleted
+	// since the last commit, and the nodes collected by the last commit.
+	tracer *tracer
+	nodes  *NodeSet
 }
 
 // newFlag returns the cache flag value for a newly created node.
@@ -81,11 +89,23 @@ func (t *Trie) newFlag() nodeFlag {
 // New will panic if db is nil and returns a MissingNodeError if root does
 // not exist in the database. Accessing the trie loads nodes from db on demand.
 func New(root common.Hash, db *Database) (*Trie, error) {
+	return NewWithOwner(common.Hash{}, root, db)
+}
+
+// NewWithOwner creates a trie with an existing root node from db, owned by the
+// account with the given hash. Storage tries must be opened with their owner
+// when the database uses the path-based scheme, as their nodes are stored by
+// owner and path.
+func NewWithOwner(owner common.Hash, root common.Hash, db *Database) (*Trie, error) {
 	if db == nil {
 		panic("trie.New called without a database")
 	}
 	trie := &Trie{
-		db: db,
+		db:    db,
+		owner: owner,
+	}
+	if db.Scheme() == rawdb.PathScheme {
+		trie.tracer = newTracer()
 	}
 	if root != (common.Hash{}) && root != emptyRoot {
 		rootnode, err := trie.resolveHash(root[:], nil)
@@ -195,7 +215,7 @@ func (t *Trie) tryGetNode(origNode node, path []byte, pos int) (item []byte, new
 		if hash == nil {
 			return nil, origNode, 0, errors.New("non-consensus node")
 		}
-		blob, err := t.db.Node(common.BytesToHash(hash))
+		blob, err := t.resolveBlob(hash, path[:pos])
 		return blob, origNode, 1, err
 	}
 	// Path still needs to be traversed, descend into children
@@ -319,6 +339,7 @@ func (t *Trie) insert(n node, prefix, key []byte, value node) (bool, node, error
 			return true, branch, nil
 		}
 		// Otherwise, replace it with a short node leading up to the branch.
+		t.tracer.onInsert(append(prefix, key[:matchlen]...))
 		return true, &shortNode{key[:matchlen], branch, t.newFlag()}, nil
 
 	case *fullNode:
@@ -332,6 +353,7 @@ func (t *Trie) insert(n node, prefix, key []byte, value node) (bool, node, error
 		return true, n, nil
 
 	case nil:
+		t.tracer.onInsert(prefix)
 		return true, &shortNode{key, value, t.newFlag()}, nil
 
 	case hashNode:
@@ -384,6 +406,7 @@ func (t *Trie) delete(n node, prefix, key []byte) (bool, node, error) {
 			return false, n, nil // don't replace n on mismatch
 		}
 		if matchlen == len(key) {
+			t.tracer.onDelete(prefix)
 			return true, nil, nil // remove n entirely for whole matches
 		}
 		// The key is longer than n.Key. Remove the remaining suffix
@@ -402,6 +425,7 @@ func (t *Trie) delete(n node, prefix, key []byte) (bool, node, error) {
 			// always creates a new slice) instead of append to
 			// avoid modifying n.Key since it might be shared with
 			// other nodes.
+			t.tracer.onDelete(append(prefix, n.Key...))
 			return true, &shortNode{concat(n.Key, child.Key...), child.Val, t.newFlag()}, nil
 		default:
 			return true, &shortNode{n.Key, child, t.newFlag()}, nil
@@ -452,11 +476,16 @@ func (t *Trie) delete(n node, prefix, key []byte) (bool, node, error) {
 				// shortNode{..., shortNode{...}}.  Since the entry
 				// might not be loaded yet, resolve it just for this
 				// check.
-				cnode, err := t.resolve(n.Children[pos], prefix)
+				cnode, err := t.resolve(n.Children[pos], append(prefix, byte(pos)))
 				if err != nil {
 					return false, nil, err
 				}
 				if cnode, ok := cnode.(*shortNode); ok {
+					// Replace the entire full node with the short node.
+					// Mark the original short node as deleted since the
+					// value is embedded into the parent now.
+					t.tracer.onDelete(append(prefix, byte(pos)))
+
 					k := append([]byte{byte(pos)}, cnode.Key...)
 					return true, &shortNode{k, cnode.Val, t.newFlag()}, nil
 				}
@@ -509,10 +538,20 @@ func (t *Trie) resolve(n node, prefix []byte) (node, error) {
 
 func (t *Trie) resolveHash(n hashNode, prefix []byte) (node, error) {
 	hash := common.BytesToHash(n)
-	if node := t.db.node(hash); node != nil {
+	if node := t.db.node(t.owner, prefix, hash); node != nil {
+		t.tracer.onRead(prefix)
 		return node, nil
 	}
-	return nil, &MissingNodeError{NodeHash: hash, Path: prefix}
+	return nil, &MissingNodeError{Owner: t.owner, NodeHash: hash, Path: prefix}
+}
+
+// resolveBlob retrieves the encoded trie node with the given hash and path.
+func (t *Trie) resolveBlob(n hashNode, prefix []byte) ([]byte, error) {
+	hash := common.BytesToHash(n)
+	if blob := t.db.nodeBlob(t.owner, prefix, hash); len(blob) > 0 {
+		return blob, nil
+	}
+	return nil, &MissingNodeError{Owner: t.owner, NodeHash: hash, Path: prefix}
 }
 
 // Hash returns the root hash of the trie. It does not write to the
@@ -529,6 +568,18 @@ func (t *Trie) Commit(onleaf LeafCallback) (common.Hash, int, error) {
 	if t.db == nil {
 		panic("commit called on trie with nil database")
 	}
+	// With the path-based scheme, collect the committed nodes along with the
+	// persisted ones removed from the trie.
+	t.nodes = nil
+	if t.tracer != nil {
+		t.nodes = NewNodeSet(t.owner)
+		defer func() {
+			for _, path := range t.tracer.deletedNodes() {
+				t.nodes.MarkDeleted([]byte(path))
+			}
+			t.tracer.reset()
+		}()
+	}
 	if t.root == nil {
 		return emptyRoot, 0, nil
 	}
@@ -544,6 +595,7 @@ func (t *Trie) Commit(onleaf LeafCallback) (common.Hash, int, error) {
 	if _, dirty := t.root.cache(); !dirty {
 		return rootHash, 0, nil
 	}
+	h.nodes, h.tracer = t.nodes, t.tracer
 	var wg sync.WaitGroup
 	if onleaf != nil {
 		h.onleaf = onleaf
@@ -570,6 +622,12 @@ func (t *Trie) Commit(onleaf LeafCallback) (common.Hash, int, error) {
 	return rootHash, committed, nil
 }
 
+// CommittedNodes returns the nodes collected by the last commit when the trie
+// database uses the path-based scheme, nil otherwise.
+func (t *Trie) CommittedNodes() *NodeSet {
+	return t.nodes
+}
+
 // hashRoot calculates the root hash of the given trie
 func (t *Trie) hashRoot() (node, node, error) {
 	if t.root == nil {
@@ -592,4 +650,14 @@ func (t *Trie) hashRoot() (node, node, error) {
 func (t *Trie) Reset() {
 	t.root = nil
 	t.unhashed = 0
+	t.tracer.reset()
+	t.nodes = nil
+}
+
+// Copy returns a copy of the trie, sharing the immutable nodes but tracking
+// the changes separately.
+func (t *Trie) Copy() *Trie {
+	cpy := *t
+	cpy.tracer = t.tracer.copy()
+	return &cpy
 }
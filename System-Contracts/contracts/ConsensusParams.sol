@@ -0,0 +1,11 @@
+// SPDX-License-Identifier: MIT
+pragma solidity 0.8.17;
+
+// ConsensusParams exposes the block period and epoch length voted by governance.
+// The state variables are written by the consensus engine when a consensus params
+// proposal passes, and take effect from the next checkpoint on. Their layout must
+// match ConsensusPeriodPosition and ConsensusEpochPosition of the node.
+contract ConsensusParams {
+    uint256 public period;
+    uint256 public epoch;
+}